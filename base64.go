@@ -0,0 +1,92 @@
+package jsonapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// byteEncoding is the base64 encoding of a byte slice or array attribute, as given by an option of
+// its attribute directive, e.g. `jsonapi:"attribute,base64url"`.
+type byteEncoding string
+
+const (
+	// base64Std is the standard base64 encoding defined by RFC 4648, as used by encoding/json for
+	// byte slices.
+	base64Std byteEncoding = "base64"
+
+	// base64URL is the URL and filename safe base64 encoding defined by RFC 4648.
+	base64URL byteEncoding = "base64url"
+)
+
+// parseByteEncoding returns the byteEncoding named by the option of an attribute directive.
+func parseByteEncoding(option string) (byteEncoding, bool) {
+	switch e := byteEncoding(option); e {
+	case base64Std, base64URL:
+		return e, true
+	}
+	return "", false
+}
+
+// isByteType returns true if t is a byte slice or array, or a pointer to one.
+func isByteType(t reflect.Type) bool {
+	t = derefType(t)
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
+// encoding returns the base64 encoding of e, without padding.
+func (e byteEncoding) encoding() *base64.Encoding {
+	if e == base64URL {
+		return base64.RawURLEncoding
+	}
+	return base64.RawStdEncoding
+}
+
+// formatBytes returns the value of the byte slice or array attribute fv encoded with e, with
+// padding.
+func formatBytes(fv reflect.Value, e byteEncoding) any {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	if fv.Kind() == reflect.Slice && fv.IsNil() {
+		return nil
+	}
+
+	b := make([]byte, fv.Len())
+	for i := range b {
+		b[i] = byte(fv.Index(i).Uint())
+	}
+	return e.encoding().WithPadding(base64.StdPadding).EncodeToString(b)
+}
+
+// parseBytes converts the json encoded string data holding bytes encoded with e, with or without
+// padding, to the encoding of the byte slice or array type t used by encoding/json.
+func parseBytes(data []byte, e byteEncoding, t reflect.Type) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	b, err := e.encoding().DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+
+	t = derefType(t)
+	if t.Kind() == reflect.Slice {
+		return json.Marshal(b)
+	}
+	// encoding/json decodes byte arrays from arrays of numbers
+	if len(b) != t.Len() {
+		return nil, fmt.Errorf("expected %d bytes, got %d", t.Len(), len(b))
+	}
+	values := make([]int, len(b))
+	for i, c := range b {
+		values[i] = int(c)
+	}
+	return json.Marshal(values)
+}
@@ -0,0 +1,106 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// ArticleBytes has byte attributes in various base64 encodings.
+type ArticleBytes struct {
+	ID        string   `jsonapi:"primary,articles"`
+	Body      []byte   `jsonapi:"attribute" json:"body"`
+	Signature []byte   `jsonapi:"attribute,base64url" json:"signature"`
+	Digest    [4]byte  `jsonapi:"attribute,base64" json:"digest"`
+	Thumbnail *[]byte  `jsonapi:"attribute,readonly,base64url" json:"thumbnail,omitempty"`
+	Checksum  [2]uint8 `jsonapi:"attribute" json:"checksum"`
+}
+
+func TestMarshalBase64(t *testing.T) {
+	t.Parallel()
+
+	thumbnail := []byte{0xfb, 0xff}
+
+	tests := []struct {
+		description string
+		given       *ArticleBytes
+		expect      string
+	}{
+		{
+			description: "encodings",
+			given:       &ArticleBytes{ID: "1", Body: []byte{0xfb, 0xff}, Signature: []byte{0xfb, 0xff}, Digest: [4]byte{1, 2, 3, 4}, Thumbnail: &thumbnail, Checksum: [2]uint8{1, 2}},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"body":"+/8=","signature":"-_8=","digest":"AQIDBA==","thumbnail":"-_8=","checksum":[1,2]}}}`,
+		}, {
+			description: "nil slices",
+			given:       &ArticleBytes{ID: "1"},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"body":null,"signature":null,"digest":"AAAAAA==","checksum":[0,0]}}}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestUnmarshalBase64(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expect      *ArticleBytes
+		expectError error
+	}{
+		{
+			description: "encodings",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"body":"+/8=","signature":"-_8=","digest":"AQIDBA==","checksum":[1,2]}}}`,
+			expect:      &ArticleBytes{ID: "1", Body: []byte{0xfb, 0xff}, Signature: []byte{0xfb, 0xff}, Digest: [4]byte{1, 2, 3, 4}, Checksum: [2]uint8{1, 2}},
+		}, {
+			description: "without padding",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"signature":"-_8","digest":"AQIDBA"}}}`,
+			expect:      &ArticleBytes{ID: "1", Signature: []byte{0xfb, 0xff}, Digest: [4]byte{1, 2, 3, 4}},
+		}, {
+			description: "null",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"signature":null}}}`,
+			expect:      &ArticleBytes{ID: "1"},
+		}, {
+			description: "wrong encoding",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"signature":"+/8="}}}`,
+			expectError: &FieldError{Code: CodeInvalidAttribute, Member: "signature", Pointer: "/data/attributes/signature"},
+		}, {
+			description: "wrong length",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"digest":"AQID"}}}`,
+			expectError: &FieldError{Code: CodeInvalidAttribute, Member: "digest", Pointer: "/data/attributes/digest"},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var actual ArticleBytes
+			err := Unmarshal([]byte(tc.given), &actual)
+			if tc.expectError != nil {
+				var fe *FieldError
+				is.MustEqual(t, true, errors.As(err, &fe))
+				is.Equal(t, tc.expectError.(*FieldError).Member, fe.Member)
+				is.Equal(t, tc.expectError.(*FieldError).Pointer, fe.Pointer)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &actual)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// UnmarshalMany parses the json:api encoded data, which must have an array of resource objects as
+// primary data, into the slice pointed to by v, e.g. to handle the body of a bulk POST or PATCH
+// request creating or updating several resources at once.
+//
+// Unlike Unmarshal, which stops at the first invalid resource object, UnmarshalMany unmarshals all
+// of them and returns a BulkError holding an error for each invalid one, whose JSON pointers
+// include its index within the array (e.g. "/data/1/attributes/title"). The elements of v at the
+// indices of invalid resource objects are left zero, so that v is aligned with the array.
+//
+// A document whose primary data is a single resource object or null is rejected with a
+// DocumentError wrapping ErrInvalidBulkData. UnmarshalMany accepts the same options as Unmarshal.
+func UnmarshalMany(data []byte, v any, opts ...UnmarshalOption) error {
+	m := makeUnmarshaler(opts...)
+	m.bulk = true
+
+	return m.observe(func(op *Operation) error {
+		op.Size = len(data)
+		d, err := m.unmarshal(data, v)
+		op.add(d)
+		return err
+	})
+}
+
+// BulkError holds the errors of the invalid resource objects of a document given to UnmarshalMany,
+// one per resource object. ErrorObjects converts it to one error object per resource object.
+type BulkError []error
+
+// Error implements the error interface.
+func (e BulkError) Error() string {
+	if len(e) == 0 {
+		return "jsonapi: empty bulk error"
+	}
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors of the invalid resource objects.
+func (e BulkError) Unwrap() []error {
+	return e
+}
+
+// Is reports whether the error of any invalid resource object matches target, so that errors.Is
+// inspects them before Go 1.20, as done by ErrorList.Is.
+func (e BulkError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error of an invalid resource object matching target, so that errors.As
+// inspects them before Go 1.20, as done by ErrorList.As.
+func (e BulkError) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e BulkError) mapPointer(f func(pointer string) string) {
+	for _, err := range e {
+		mapPointers(err, f)
+	}
+}
+
+func (e BulkError) locate(f func(pointer string) int64) {
+	for _, err := range e {
+		for c := err; c != nil; c = errors.Unwrap(c) {
+			if le, ok := c.(locatable); ok {
+				le.locate(f)
+			}
+		}
+	}
+}
+
+// bulkElementError returns the error err of the resource object ro at index i of the primary data
+// of a document given to UnmarshalMany, wrapped in a ResourceError if it has no JSON pointer, with
+// its JSON pointers prefixed by the index.
+func bulkElementError(err error, ro *resourceObject, i int) error {
+	var pe pointerError
+	if !errors.As(err, &pe) {
+		err = &ResourceError{Code: CodeInvalidResource, Type: ro.Type, ID: ro.ID, Err: err}
+	}
+	return prefixPointer(err, fmt.Sprintf("/%d", i))
+}
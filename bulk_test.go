@@ -0,0 +1,98 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestUnmarshalMany(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expect      []*Article
+		expectError error
+	}{
+		{
+			description: "array",
+			given:       `{"data":[{"type":"articles","attributes":{"title":"A"}},{"type":"articles","attributes":{"title":"B"}}]}`,
+			expect:      []*Article{{Title: "A"}, {Title: "B"}},
+		}, {
+			description: "empty array",
+			given:       `{"data":[]}`,
+			expect:      []*Article{},
+		}, {
+			description: "single resource object",
+			given:       `{"data":{"type":"articles","attributes":{"title":"A"}}}`,
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrInvalidBulkData},
+		}, {
+			description: "null",
+			given:       `{"data":null}`,
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrInvalidBulkData},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var articles []*Article
+			err := UnmarshalMany([]byte(tc.given), &articles)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, articles)
+		})
+	}
+}
+
+func TestUnmarshalManyErrors(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":[` +
+		`{"type":"articles","attributes":{"title":"A"}},` +
+		`{"type":"articles","attributes":{"title":1}},` +
+		`{"type":"articles","attributes":{"title":"C"}},` +
+		`{"type":"comments","attributes":{"body":"D"}}` +
+		`]}`
+
+	var articles []*Article
+	err := UnmarshalMany([]byte(body), &articles)
+	is.MustError(t, err)
+
+	errs, ok := err.(BulkError)
+	is.MustEqual(t, true, ok)
+	is.Equal(t, 2, len(errs))
+
+	// invalid resource objects leave zero elements, keeping the slice aligned with the array
+	is.Equal(t, []*Article{{Title: "A"}, nil, {Title: "C"}, nil}, articles)
+
+	objects := ErrorObjects(err)
+	is.MustEqual(t, 2, len(objects))
+	is.Equal(t, "/data/1/attributes/title", objects[0].Source.Pointer)
+	is.Equal(t, "/data/3/type", objects[1].Source.Pointer)
+}
+
+func TestBulkErrorOffsets(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":[{"type":"articles","attributes":{"title":"A"}},{"type":"articles","attributes":{"title":1}}]}`
+
+	var articles []*Article
+	err := UnmarshalMany([]byte(body), &articles)
+	is.MustError(t, err)
+
+	var fe *FieldError
+	is.MustEqual(t, true, errors.As(err, &fe))
+	is.Equal(t, "/data/1/attributes/title", fe.Pointer)
+	is.Equal(t, int64(strings.Index(body, `1}}]`)), fe.Offset)
+}
@@ -0,0 +1,98 @@
+package jsonapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// Canonical returns the canonical form of the json:api document data: object members are sorted by
+// name, included resources are sorted by type and id, and insignificant whitespace is removed.
+// Numbers and strings are kept as they are, except for the escaping of strings. Documents which
+// are equal as compared by Equal, except for the order of arrays other than included, have the same
+// canonical form.
+func Canonical(data []byte) ([]byte, error) {
+	doc, err := decodeNumbers(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if top, ok := doc.(map[string]any); ok {
+		if included, ok := top["included"].([]any); ok {
+			sort.SliceStable(included, func(i, j int) bool {
+				return includedKey(included[i]) < includedKey(included[j])
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	// unlike json.Marshal, json.Encoder terminates every value with a newline
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// decodeNumbers decodes the json value data into a generic Go value, keeping numbers as json.Number
+// so that they are neither rounded nor reformatted.
+func decodeNumbers(data []byte) (any, error) {
+	var v any
+	if !json.Valid(data) {
+		// let encoding/json report the syntax error, e.g. data following the value
+		return nil, json.Unmarshal(data, &v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// includedKey returns the key by which the decoded resource object ro is sorted among the included
+// resources.
+func includedKey(ro any) string {
+	m, _ := ro.(map[string]any)
+	typ, _ := m["type"].(string)
+	id, _ := m["id"].(string)
+	lid, _ := m["lid"].(string)
+	return typ + "\x00" + id + "\x00" + lid
+}
+
+// ETag returns a weak entity tag, as defined by https://www.rfc-editor.org/rfc/rfc9110#name-etag, of
+// the json:api encoding of v marshaled with the given options. The entity tag is computed from the
+// canonical form of the document, so it only changes when the document does. It is weak as
+// encodings which differ in the order of their members or included resources, or in their
+// formatting, have the same entity tag. It can be matched with the If-None-Match header of
+// conditional GET requests with NotModified:
+//
+//	etag, err := jsonapi.ETag(&article)
+//	if err != nil {
+//		// ...
+//	}
+//	w.Header().Set("ETag", etag)
+//	if jsonapi.NotModified(r, etag) {
+//		w.WriteHeader(http.StatusNotModified)
+//		return
+//	}
+//
+// Weak entity tags never satisfy If-Match preconditions, which use the strong comparison function,
+// so CheckPreconditions only accepts "*" for them.
+func ETag(v any, opts ...MarshalOption) (string, error) {
+	b, err := Marshal(v, opts...)
+	if err != nil {
+		return "", err
+	}
+	canonical, err := Canonical(b)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+	return `W/"` + hex.EncodeToString(sum[:]) + `"`, nil
+}
@@ -0,0 +1,81 @@
+package jsonapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestCanonical(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expect      string
+		expectError bool
+	}{
+		{
+			description: "member order and whitespace",
+			given:       "{\n  \"data\": {\"type\": \"articles\", \"id\": \"1\", \"attributes\": {\"title\": \"A\", \"body\": \"B\"}}\n}\n",
+			expect:      `{"data":{"attributes":{"body":"B","title":"A"},"id":"1","type":"articles"}}`,
+		}, {
+			description: "included order",
+			given:       `{"data":null,"included":[{"type":"comments","id":"2"},{"type":"authors","id":"1"},{"type":"comments","id":"1"}]}`,
+			expect:      `{"data":null,"included":[{"id":"1","type":"authors"},{"id":"1","type":"comments"},{"id":"2","type":"comments"}]}`,
+		}, {
+			description: "primary data order is kept",
+			given:       `{"data":[{"type":"articles","id":"2"},{"type":"articles","id":"1"}]}`,
+			expect:      `{"data":[{"id":"2","type":"articles"},{"id":"1","type":"articles"}]}`,
+		}, {
+			description: "numbers and html are kept",
+			given:       `{"meta":{"big":12345678901234567890,"float":1.50,"html":"<a>&"}}`,
+			expect:      `{"meta":{"big":12345678901234567890,"float":1.50,"html":"<a>&"}}`,
+		}, {
+			description: "invalid json",
+			given:       `{"data":`,
+			expectError: true,
+		}, {
+			description: "trailing data",
+			given:       `{"data":null}]`,
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Canonical([]byte(tc.given))
+			is.Equal(t, tc.expectError, err != nil)
+			is.Equal(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	t.Parallel()
+
+	etag, err := ETag(&articleA)
+	is.MustNoError(t, err)
+	is.Equal(t, 68, len(etag))
+	is.Equal(t, `W/"`, etag[:3])
+	is.Equal(t, byte('"'), etag[len(etag)-1])
+
+	// the same document with its included resources in another order has the same entity tag
+	etagAB, err := ETag(&articleRelatedComplete, MarshalInclude(&authorA, &commentA, &commentB))
+	is.MustNoError(t, err)
+	etagBA, err := ETag(&articleRelatedComplete, MarshalInclude(&commentB, &commentA, &authorA))
+	is.MustNoError(t, err)
+	is.Equal(t, etagAB, etagBA)
+
+	etagB, err := ETag(&articleB)
+	is.MustNoError(t, err)
+	is.Equal(t, false, etag == etagB)
+
+	_, err = ETag(&Article{Title: "A"})
+	is.EqualError(t, ErrEmptyPrimaryField, err)
+}
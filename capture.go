@@ -0,0 +1,251 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Exchange is a request and response pair recorded by Capture, which can be replayed in contract
+// tests, e.g. with jsonapitest.Replay.
+type Exchange struct {
+	// Method is the request method.
+	Method string `json:"method"`
+
+	// URL is the request URL, without scheme and host.
+	URL string `json:"url"`
+
+	// Request is the request document, if any.
+	Request json.RawMessage `json:"request,omitempty"`
+
+	// Status is the response status code.
+	Status int `json:"status"`
+
+	// Response is the response document, if any.
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// capturer holds the configuration of Capture.
+type capturer struct {
+	dir     string
+	redact  AttributeRedactor
+	onError func(error)
+	seq     uint64
+
+	// run is the directory of the exchanges recorded by this run, created along with the first one
+	run     string
+	runOnce sync.Once
+	runErr  error
+}
+
+// CaptureOption allows for configuration of Capture.
+type CaptureOption func(c *capturer)
+
+// CaptureRedactor consults r for every attribute of the resource objects of recorded documents, as
+// done by MarshalAttributeRedactor, e.g. to redact personal data before it is written to disk. r is
+// called with the context of the recorded request.
+func CaptureRedactor(r AttributeRedactor) CaptureOption {
+	return func(c *capturer) {
+		c.redact = r
+	}
+}
+
+// CaptureErrorHandler calls f with every error encountered while recording an exchange. By default,
+// such errors are ignored so that recording never fails requests.
+func CaptureErrorHandler(f func(error)) CaptureOption {
+	return func(c *capturer) {
+		c.onError = f
+	}
+}
+
+// Capture is a middleware recording every request and response of the next handler as an Exchange
+// in a json file. Every run, i.e. every call to Capture, records its exchanges in a new directory
+// within the directory dir, which must exist. Run directories are named by the time they are
+// created, and the files within them by the order of the requests, so that LoadExchanges returns
+// the exchanges of the latest run in the order they were recorded, and never stale exchanges of
+// earlier runs.
+//
+// Request and response bodies which are not json are not recorded.
+func Capture(dir string, opts ...CaptureOption) func(http.Handler) http.Handler {
+	c := &capturer{dir: dir, onError: func(error) {}}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var reqBody []byte
+			if r.Body != nil {
+				var err error
+				if reqBody, err = io.ReadAll(r.Body); err != nil {
+					c.onError(err)
+				}
+				r.Body = io.NopCloser(bytes.NewReader(reqBody))
+			}
+
+			cw := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(cw, r)
+
+			if err := c.record(r, reqBody, cw); err != nil {
+				c.onError(err)
+			}
+		})
+	}
+}
+
+// record writes the exchange of the given request and response to a new file.
+func (c *capturer) record(r *http.Request, reqBody []byte, cw *captureWriter) error {
+	e := &Exchange{Method: r.Method, URL: r.URL.RequestURI(), Status: cw.status}
+
+	var err error
+	if e.Request, err = c.document(r.Context(), reqBody); err != nil {
+		return err
+	}
+	if e.Response, err = c.document(r.Context(), cw.body.Bytes()); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	c.runOnce.Do(func() {
+		c.run, c.runErr = os.MkdirTemp(c.dir, time.Now().UTC().Format("20060102T150405.000000000Z")+"-")
+	})
+	if c.runErr != nil {
+		return c.runErr
+	}
+
+	seq := atomic.AddUint64(&c.seq, 1)
+	name := fmt.Sprintf("%06d-%s.json", seq, strings.ToLower(r.Method))
+	return os.WriteFile(filepath.Join(c.run, name), b, 0o600)
+}
+
+// document returns the redacted document in data, or nil if data is not json.
+func (c *capturer) document(ctx context.Context, data []byte) (json.RawMessage, error) {
+	if len(data) == 0 || !json.Valid(data) {
+		return nil, nil
+	}
+	if c.redact == nil {
+		return data, nil
+	}
+
+	var doc map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		// json values other than objects aren't documents, so have no attributes
+		return data, nil
+	}
+	for _, member := range []string{"data", "included"} {
+		switch ros := doc[member].(type) {
+		case map[string]any:
+			c.redactAttributes(ctx, ros)
+		case []any:
+			for _, ro := range ros {
+				if ro, ok := ro.(map[string]any); ok {
+					c.redactAttributes(ctx, ro)
+				}
+			}
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// redactAttributes applies the capturer's AttributeRedactor to the attributes of the decoded
+// resource object ro.
+func (c *capturer) redactAttributes(ctx context.Context, ro map[string]any) {
+	typ, _ := ro["type"].(string)
+	attributes, _ := ro["attributes"].(map[string]any)
+	for name, value := range attributes {
+		if value, ok := c.redact(ctx, typ, name, value); ok {
+			attributes[name] = value
+		} else {
+			delete(attributes, name)
+		}
+	}
+}
+
+// captureWriter is an http.ResponseWriter keeping a copy of the response.
+type captureWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (cw *captureWriter) WriteHeader(status int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		cw.status = status
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *captureWriter) Write(b []byte) (int, error) {
+	cw.wroteHeader = true
+	cw.body.Write(b)
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush implements the http.Flusher interface if the underlying http.ResponseWriter does.
+func (cw *captureWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		cw.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+func (cw *captureWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// LoadExchanges loads the exchanges recorded by the latest run of Capture in the directory dir, in
+// the order they were recorded.
+func LoadExchanges(dir string) ([]*Exchange, error) {
+	runs, err := filepath.Glob(filepath.Join(dir, "*-*"))
+	if err != nil {
+		return nil, err
+	}
+	latest := ""
+	for _, run := range runs {
+		if fi, err := os.Stat(run); err == nil && fi.IsDir() && run > latest {
+			latest = run
+		}
+	}
+	if latest == "" {
+		return []*Exchange{}, nil
+	}
+
+	names, err := filepath.Glob(filepath.Join(latest, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	exchanges := make([]*Exchange, 0, len(names))
+	for _, name := range names {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		e := new(Exchange)
+		if err := json.Unmarshal(b, e); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		exchanges = append(exchanges, e)
+	}
+	return exchanges, nil
+}
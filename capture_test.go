@@ -0,0 +1,87 @@
+package jsonapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// articleHandler serves the article with id 1 and the given title, and creates articles with id 2.
+func articleHandler(title string) http.Handler {
+	return HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.Method == http.MethodPost {
+			var a Article
+			if err := Read(r, &a); err != nil {
+				return err
+			}
+			a.ID = "2"
+			return Write(w, http.StatusCreated, &a)
+		}
+		return Write(w, http.StatusOK, &Article{ID: "1", Title: title})
+	})
+}
+
+func TestCaptureAndReplay(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var captureErr error
+	redact := func(ctx context.Context, resourceType, attribute string, value any) (any, bool) {
+		if resourceType == "articles" && attribute == "title" && value == "secret" {
+			return "[redacted]", true
+		}
+		return value, true
+	}
+	h := Capture(dir, CaptureRedactor(redact), CaptureErrorHandler(func(err error) { captureErr = err }))(articleHandler("A"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/articles/1?include=author", nil))
+	is.Equal(t, http.StatusOK, rec.Code)
+	is.EqualJSON(t, articleABody, rec.Body.String())
+
+	r := httptest.NewRequest(http.MethodPost, "/articles", strings.NewReader(`{"data":{"type":"articles","attributes":{"title":"secret"}}}`))
+	r.Header.Set("Content-Type", MediaType)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	is.Equal(t, http.StatusCreated, rec.Code)
+	is.EqualJSON(t, `{"data":{"type":"articles","id":"2","attributes":{"title":"secret"}}}`, rec.Body.String())
+	is.MustNoError(t, captureErr)
+
+	exchanges, err := LoadExchanges(dir)
+	is.MustNoError(t, err)
+	is.MustEqual(t, 2, len(exchanges))
+
+	is.Equal(t, http.MethodGet, exchanges[0].Method)
+	is.Equal(t, "/articles/1?include=author", exchanges[0].URL)
+	is.Equal(t, http.StatusOK, exchanges[0].Status)
+	is.Equal(t, 0, len(exchanges[0].Request))
+	is.EqualJSON(t, articleABody, string(exchanges[0].Response))
+
+	is.Equal(t, http.MethodPost, exchanges[1].Method)
+	is.Equal(t, http.StatusCreated, exchanges[1].Status)
+	is.EqualJSON(t, `{"data":{"type":"articles","attributes":{"title":"[redacted]"}}}`, string(exchanges[1].Request))
+	is.EqualJSON(t, `{"data":{"type":"articles","id":"2","attributes":{"title":"[redacted]"}}}`, string(exchanges[1].Response))
+
+}
+
+func TestCaptureRuns(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, title := range []string{"A", "B"} {
+		var captureErr error
+		h := Capture(dir, CaptureErrorHandler(func(err error) { captureErr = err }))(articleHandler(title))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/articles/1", nil))
+		is.MustNoError(t, captureErr)
+	}
+
+	// only the exchanges of the latest run are loaded
+	exchanges, err := LoadExchanges(dir)
+	is.MustNoError(t, err)
+	is.MustEqual(t, 1, len(exchanges))
+	is.EqualJSON(t, `{"data":{"type":"articles","id":"1","attributes":{"title":"B"}}}`, string(exchanges[0].Response))
+}
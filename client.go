@@ -0,0 +1,330 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// Client is a client of JSON:API services, wrapping an *http.Client. It marshals request bodies,
+// sets the JSON:API headers, unmarshals response documents and converts error documents to
+// *ResponseError's.
+//
+// Requests are made with Client.Do and Client.Delete, or the generic functions Get, List, Create
+// and Update.
+type Client struct {
+	httpClient       *http.Client
+	header           http.Header
+	marshalOptions   []MarshalOption
+	unmarshalOptions []UnmarshalOption
+}
+
+// ClientOption allows for configuration of a Client.
+type ClientOption func(c *Client)
+
+// ClientHeader sets a header sent with every request, e.g. Authorization.
+func ClientHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// ClientMarshalOptions sets the options used to marshal request bodies, in addition to
+// MarshalClientMode.
+func ClientMarshalOptions(opts ...MarshalOption) ClientOption {
+	return func(c *Client) {
+		c.marshalOptions = append(c.marshalOptions, opts...)
+	}
+}
+
+// ClientUnmarshalOptions sets the options used to unmarshal response documents. The size of response
+// bodies read, including error responses, is limited to DefaultMaxBodySize unless configured
+// otherwise with UnmarshalMaxBodySize, and to the MaxBytes limit given by UnmarshalLimits.
+func ClientUnmarshalOptions(opts ...UnmarshalOption) ClientOption {
+	return func(c *Client) {
+		c.unmarshalOptions = append(c.unmarshalOptions, opts...)
+	}
+}
+
+// NewClient creates a new Client making requests with the given *http.Client, or
+// http.DefaultClient if it is nil.
+func NewClient(httpClient *http.Client, opts ...ClientOption) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	c := &Client{httpClient: httpClient, header: make(http.Header)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ResponseError is returned by a Client for responses with a 4xx or 5xx status code.
+type ResponseError struct {
+	// StatusCode is the status code of the response.
+	StatusCode int
+
+	// Errors are the error objects of the response's error document, if any. The problem details
+	// document of application/problem+json responses is converted to a single error object.
+	Errors []*Error
+}
+
+// Error implements the error interface.
+func (e *ResponseError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("jsonapi: unexpected response status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("jsonapi: response status %d: %s", e.StatusCode, strings.Join(messages, "; "))
+}
+
+// Unwrap returns the error objects of the response's error document.
+func (e *ResponseError) Unwrap() []error {
+	return ErrorList(e.Errors).Unwrap()
+}
+
+// Is reports whether any error object of the response's error document matches target, as done by
+// ErrorList.Is.
+func (e *ResponseError) Is(target error) bool {
+	return ErrorList(e.Errors).Is(target)
+}
+
+// As finds the first error object of the response's error document matching target, as done by
+// ErrorList.As.
+func (e *ResponseError) As(target any) bool {
+	return ErrorList(e.Errors).As(target)
+}
+
+// Status returns the status code of the response, implementing StatusError.
+func (e *ResponseError) Status() int {
+	return e.StatusCode
+}
+
+// newResponseError creates a ResponseError from the given response, reading its error document if
+// it has the JSON:API media type, or its problem details document converted to an error object.
+func newResponseError(resp *http.Response, body []byte) *ResponseError {
+	re := &ResponseError{StatusCode: resp.StatusCode}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err == nil && mediaType == ProblemMediaType {
+		var p Problem
+		if err := json.Unmarshal(body, &p); err == nil {
+			re.Errors = []*Error{p.ErrorObject()}
+		}
+		return re
+	}
+	if err != nil || mediaType != MediaType {
+		return re
+	}
+
+	var d struct {
+		Errors []*Error `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &d); err == nil {
+		re.Errors = d.Errors
+	}
+	return re
+}
+
+// do makes a request with the given method and url, marshaling body as request body unless it is
+// nil. The response document is unmarshaled into v and returned, unless v is nil or the response
+// has no content, in which case the returned document is nil.
+func (c *Client) do(ctx context.Context, method, url string, body, v any) (*document, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := Marshal(body, append([]MarshalOption{MarshalClientMode()}, c.marshalOptions...)...)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", MediaType)
+	if body != nil {
+		req.Header.Set("Content-Type", MediaType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var rb io.Reader = resp.Body
+	if max := makeUnmarshaler(c.unmarshalOptions...).maxResponseSize(); max >= 0 {
+		rb = &limitedReader{r: resp.Body, max: max}
+	}
+	data, err := io.ReadAll(rb)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, newResponseError(resp, data)
+	}
+	if v == nil || resp.StatusCode == http.StatusNoContent || len(data) == 0 {
+		return nil, nil
+	}
+
+	return c.unmarshal(data, v)
+}
+
+// maxResponseSize returns the maximum size in bytes of the response bodies read by a Client with the
+// options of m, the smaller of the body size given by UnmarshalMaxBodySize and the MaxBytes limit, or
+// -1 if they are unlimited.
+func (m *Unmarshaler) maxResponseSize() int64 {
+	max := m.maxBodySize
+	if l := m.limits.MaxBytes; l > 0 && (max < 0 || l < max) {
+		max = l
+	}
+	return max
+}
+
+// unmarshal behaves like Unmarshal using the client's options, but returns the parsed document.
+func (c *Client) unmarshal(data []byte, v any) (d *document, err error) {
+	m := makeUnmarshaler(append([]UnmarshalOption{UnmarshalClientMode()}, c.unmarshalOptions...)...)
+
+	err = m.observe(func(op *Operation) (err error) {
+		op.Size = len(data)
+		d, err = m.unmarshal(data, v)
+		op.add(d)
+		return err
+	})
+
+	return
+}
+
+// Do makes a request with the given method and url. Unless they are nil, body is marshaled as the
+// request body, and the response document is unmarshaled into v.
+func (c *Client) Do(ctx context.Context, method, url string, body, v any) error {
+	_, err := c.do(ctx, method, url, body, v)
+	return err
+}
+
+// Delete deletes the resource at the given url.
+func (c *Client) Delete(ctx context.Context, url string) error {
+	_, err := c.do(ctx, http.MethodDelete, url, nil, nil)
+	return err
+}
+
+// Get fetches the single resource of type T at the given url.
+func Get[T any](ctx context.Context, c *Client, url string) (*T, error) {
+	v := new(T)
+	if err := c.Do(ctx, http.MethodGet, url, nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// List fetches the collection of resources of type T at the given url.
+func List[T any](ctx context.Context, c *Client, url string) ([]*T, error) {
+	vs := make([]*T, 0)
+	if err := c.Do(ctx, http.MethodGet, url, nil, &vs); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// Create creates the given resource by posting it to the given url, returning the created
+// resource. If the server responds without content, v is returned as is.
+func Create[T any](ctx context.Context, c *Client, url string, v *T) (*T, error) {
+	return send(ctx, c, http.MethodPost, url, v)
+}
+
+// Update updates the resource at the given url, returning the updated resource. If the server
+// responds without content, v is returned as is.
+func Update[T any](ctx context.Context, c *Client, url string, v *T) (*T, error) {
+	return send(ctx, c, http.MethodPatch, url, v)
+}
+
+func send[T any](ctx context.Context, c *Client, method, url string, v *T) (*T, error) {
+	out := new(T)
+	d, err := c.do(ctx, method, url, v, out)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return v, nil
+	}
+	return out, nil
+}
+
+// Pages returns an iterator over the pages of the collection of resources of type T at the given
+// url, as defined by https://jsonapi.org/format/#fetching-pagination. The next page is fetched by
+// following the next link of the current page, so any pagination strategy (e.g. page numbers or
+// cursors) is supported. Iteration stops after the page without a next link, or the first error,
+// such as ErrPaginationCycle if a next link points to a page which has already been fetched.
+//
+// With Go 1.23 or later, the iterator can be used in range loops:
+//
+//	for page, err := range jsonapi.Pages[Article](ctx, client, "https://example.com/articles") {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Pages[T any](ctx context.Context, c *Client, url string) func(yield func([]*T, error) bool) {
+	return func(yield func([]*T, error) bool) {
+		// visited holds the urls of the pages fetched so far, so that misbehaving servers can't
+		// make iteration loop forever
+		visited := make(map[string]bool)
+		for u := url; u != ""; {
+			visited[u] = true
+			page := make([]*T, 0)
+			d, err := c.do(ctx, http.MethodGet, u, nil, &page)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+
+			next := ""
+			if d != nil && d.Links != nil {
+				next = LinkHref(d.Links.Next)
+			}
+			if next == "" {
+				return
+			}
+			if u, err = resolveURL(u, next); err != nil {
+				yield(nil, err)
+				return
+			}
+			if visited[u] {
+				yield(nil, fmt.Errorf("%w: %s", ErrPaginationCycle, u))
+				return
+			}
+		}
+	}
+}
+
+// resolveURL resolves the given link relative to the url of the document it is part of.
+func resolveURL(base, link string) (string, error) {
+	b, err := neturl.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	l, err := neturl.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(l).String(), nil
+}
@@ -0,0 +1,384 @@
+package jsonapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// newArticleServer creates a test server serving articles at /articles.
+func newArticleServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.Handle("/articles", HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		switch r.Method {
+		case http.MethodGet:
+			return Write(w, http.StatusOK, articlesABPtr)
+		case http.MethodPost:
+			var a Article
+			if err := Read(r, &a); err != nil {
+				return err
+			}
+			a.ID = "3"
+			return Write(w, http.StatusCreated, &a)
+		}
+		return &Error{Status: Status(http.StatusMethodNotAllowed)}
+	}))
+	mux.Handle("/articles/1", HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			return &Error{Status: Status(http.StatusUnauthorized), Title: "Unauthorized", Detail: "missing token"}
+		}
+		switch r.Method {
+		case http.MethodGet:
+			return Write(w, http.StatusOK, &articleA)
+		case http.MethodPatch:
+			var a Article
+			if err := Read(r, &a); err != nil {
+				return err
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+		return &Error{Status: Status(http.StatusMethodNotAllowed)}
+	}))
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	})
+	mux.HandleFunc("/problem", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ProblemMediaType)
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"type":"https://example.com/probs/out-of-credit","title":"Forbidden","status":403,"detail":"out of credit"}`))
+	})
+
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	s := newArticleServer(t)
+	ctx := context.Background()
+	c := NewClient(s.Client(), ClientHeader("Authorization", "Bearer token"))
+
+	a, err := Get[Article](ctx, c, s.URL+"/articles/1")
+	is.MustNoError(t, err)
+	is.Equal(t, &articleA, a)
+
+	as, err := List[Article](ctx, c, s.URL+"/articles")
+	is.MustNoError(t, err)
+	is.Equal(t, articlesABPtr, as)
+
+	created, err := Create(ctx, c, s.URL+"/articles", &Article{Title: "C"})
+	is.MustNoError(t, err)
+	is.Equal(t, &Article{ID: "3", Title: "C"}, created)
+
+	updated, err := Update(ctx, c, s.URL+"/articles/1", &Article{ID: "1", Title: "D"})
+	is.MustNoError(t, err)
+	is.Equal(t, &Article{ID: "1", Title: "D"}, updated)
+
+	err = c.Delete(ctx, s.URL+"/articles/1")
+	is.MustNoError(t, err)
+}
+
+func TestClientLimits(t *testing.T) {
+	t.Parallel()
+
+	s := newArticleServer(t)
+	c := NewClient(s.Client(), ClientUnmarshalOptions(UnmarshalLimits(DecodeLimits{MaxBytes: 10})))
+
+	_, err := List[Article](context.Background(), c, s.URL+"/articles")
+	var le *LimitError
+	is.Equal(t, true, errors.As(err, &le))
+}
+
+func TestClientMaxBodySize(t *testing.T) {
+	t.Parallel()
+
+	large := &Article{ID: "1", Title: strings.Repeat("A", int(DefaultMaxBodySize))}
+	s := httptest.NewServer(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return Write(w, http.StatusOK, large)
+	}))
+	t.Cleanup(s.Close)
+
+	tests := []struct {
+		description string
+		opts        []UnmarshalOption
+		expectError bool
+	}{
+		{
+			description: "default",
+			expectError: true,
+		}, {
+			description: "larger body size",
+			opts:        []UnmarshalOption{UnmarshalMaxBodySize(2 * DefaultMaxBodySize)},
+		}, {
+			description: "unlimited body size",
+			opts:        []UnmarshalOption{UnmarshalMaxBodySize(-1)},
+		}, {
+			description: "smaller MaxBytes",
+			opts:        []UnmarshalOption{UnmarshalMaxBodySize(-1), UnmarshalLimits(DecodeLimits{MaxBytes: 10})},
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			c := NewClient(s.Client(), ClientUnmarshalOptions(tc.opts...))
+			a, err := Get[Article](context.Background(), c, s.URL)
+			if tc.expectError {
+				var le *LimitError
+				is.Equal(t, true, errors.As(err, &le))
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, large, a)
+		})
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Parallel()
+
+	s := newArticleServer(t)
+
+	tests := []struct {
+		description  string
+		url          string
+		expectStatus int
+		expectErrors []*Error
+	}{
+		{
+			description:  "error document",
+			url:          "/articles/1",
+			expectStatus: http.StatusUnauthorized,
+			expectErrors: []*Error{{Status: Status(http.StatusUnauthorized), Title: "Unauthorized", Detail: "missing token"}},
+		}, {
+			description:  "not found",
+			url:          "/comments",
+			expectStatus: http.StatusNotFound,
+		}, {
+			description:  "plain text error",
+			url:          "/plain",
+			expectStatus: http.StatusBadGateway,
+		}, {
+			description:  "problem details",
+			url:          "/problem",
+			expectStatus: http.StatusForbidden,
+			expectErrors: []*Error{{
+				Status: Status(http.StatusForbidden),
+				Title:  "Forbidden",
+				Detail: "out of credit",
+				Links:  &ErrorLink{Type: "https://example.com/probs/out-of-credit"},
+			}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			_, err := Get[Article](context.Background(), NewClient(s.Client()), s.URL+tc.url)
+
+			var re *ResponseError
+			is.MustEqual(t, true, errors.As(err, &re))
+			is.Equal(t, tc.expectStatus, re.StatusCode)
+			is.Equal(t, tc.expectErrors, re.Errors)
+		})
+	}
+}
+
+func TestErrorUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expect      *Error
+		expectError bool
+	}{
+		{
+			description: "string status",
+			given:       `{"status":"404","title":"T"}`,
+			expect:      &Error{Status: Status(http.StatusNotFound), Title: "T"},
+		}, {
+			description: "number status",
+			given:       `{"status":404}`,
+			expect:      &Error{Status: Status(http.StatusNotFound)},
+		}, {
+			description: "no status",
+			given:       `{"title":"T"}`,
+			expect:      &Error{Title: "T"},
+		}, {
+			description: "invalid status",
+			given:       `{"status":"not found"}`,
+			expectError: true,
+		}, {
+			description: "about and type links",
+			given:       `{"code":"C","links":{"about":"https://example.com/errors/C","type":{"href":"https://example.com/errors/types/validation"}}}`,
+			expect:      &errorsWithTypeLink,
+		}, {
+			description: "invalid about link",
+			given:       `{"links":{"about":1}}`,
+			expectError: true,
+		}, {
+			description: "invalid type link",
+			given:       `{"links":{"type":["A"]}}`,
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var actual Error
+			err := actual.UnmarshalJSON([]byte(tc.given))
+			if tc.expectError {
+				is.Equal(t, true, err != nil)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &actual)
+		})
+	}
+}
+
+func TestPages(t *testing.T) {
+	t.Parallel()
+
+	articles := []*Article{{ID: "1", Title: "A"}, {ID: "2", Title: "B"}, {ID: "3", Title: "C"}}
+
+	mux := http.NewServeMux()
+	// page number pagination with absolute links
+	mux.Handle("/numbered", HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var page int
+		if _, err := fmt.Sscan(r.URL.Query().Get("page[number]"), &page); err != nil {
+			page = 1
+		}
+		link := &Link{}
+		if page < len(articles) {
+			link.Next = fmt.Sprintf("http://%s/numbered?page[number]=%d", r.Host, page+1)
+		}
+		return Write(w, http.StatusOK, articles[page-1:page], MarshalLinks(link))
+	}))
+	// cursor pagination with relative links
+	mux.Handle("/cursor", HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		start := 0
+		for i, a := range articles {
+			if a.ID == r.URL.Query().Get("page[after]") {
+				start = i + 1
+			}
+		}
+		link := &Link{}
+		if start < len(articles)-1 {
+			link.Next = "/cursor?page[after]=" + articles[start].ID
+		}
+		return Write(w, http.StatusOK, articles[start:start+1], MarshalLinks(link))
+	}))
+	mux.Handle("/failing", HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Query().Get("page[number]") == "2" {
+			return &Error{Status: Status(http.StatusServiceUnavailable)}
+		}
+		return Write(w, http.StatusOK, articles[:1], MarshalLinks(&Link{Next: "/failing?page[number]=2"}))
+	}))
+	// next links pointing back to the same page, or to an earlier one
+	mux.Handle("/looping", HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return Write(w, http.StatusOK, articles[:1], MarshalLinks(&Link{Next: r.URL.String()}))
+	}))
+	mux.Handle("/cycling", HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		next := "/cycling?page[number]=2"
+		if r.URL.Query().Get("page[number]") == "2" {
+			next = "/cycling"
+		}
+		return Write(w, http.StatusOK, articles[:1], MarshalLinks(&Link{Next: next}))
+	}))
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	tests := []struct {
+		description string
+		url         string
+		limit       int
+		expect      [][]*Article
+		expectError error
+	}{
+		{
+			description: "page numbers",
+			url:         "/numbered",
+			expect:      [][]*Article{articles[0:1], articles[1:2], articles[2:3]},
+		}, {
+			description: "cursors",
+			url:         "/cursor",
+			expect:      [][]*Article{articles[0:1], articles[1:2], articles[2:3]},
+		}, {
+			description: "stop early",
+			url:         "/numbered",
+			limit:       2,
+			expect:      [][]*Article{articles[0:1], articles[1:2]},
+		}, {
+			description: "error",
+			url:         "/failing",
+			expect:      [][]*Article{articles[0:1]},
+			expectError: &Error{Status: Status(http.StatusServiceUnavailable)},
+		}, {
+			description: "next link to the same page",
+			url:         "/looping",
+			expect:      [][]*Article{articles[0:1]},
+			expectError: ErrPaginationCycle,
+		}, {
+			description: "next link to an earlier page",
+			url:         "/cycling",
+			expect:      [][]*Article{articles[0:1], articles[0:1]},
+			expectError: ErrPaginationCycle,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var (
+				actual [][]*Article
+				errs   []error
+			)
+			Pages[Article](context.Background(), NewClient(s.Client()), s.URL+tc.url)(func(page []*Article, err error) bool {
+				if err != nil {
+					errs = append(errs, err)
+					return false
+				}
+				actual = append(actual, page)
+				return tc.limit == 0 || len(actual) < tc.limit
+			})
+
+			is.Equal(t, tc.expect, actual)
+			if tc.expectError == nil {
+				is.Equal(t, 0, len(errs))
+				return
+			}
+			is.MustEqual(t, 1, len(errs))
+			is.Equal(t, true, errors.Is(errs[0], tc.expectError))
+		})
+	}
+}
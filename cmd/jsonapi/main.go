@@ -0,0 +1,423 @@
+// Command jsonapi is a tool for debugging JSON:API documents, e.g. payloads captured from
+// production.
+//
+// Usage:
+//
+//	jsonapi <command> [arguments]
+//
+// The commands are:
+//
+//	validate [file]      validate a document against the specification
+//	linkage [file]       check that a compound document is fully linked
+//	pretty [file]        pretty-print a document
+//	ndjson [-included] [file]
+//	                     print the resource objects of a document as newline delimited json
+//	csv [-included] [file]
+//	                     print the resource objects of a document as csv
+//	diff <file> <file>   print the differences between two documents
+//
+// Documents are read from standard input if no file (or "-") is given.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/jsonapi"
+)
+
+const usage = `usage: jsonapi <command> [arguments]
+
+commands:
+  validate [file]             validate a document against the specification
+  linkage [file]              check that a compound document is fully linked
+  pretty [file]               pretty-print a document
+  ndjson [-included] [file]   print the resource objects of a document as newline delimited json
+  csv [-included] [file]      print the resource objects of a document as csv
+  diff <file> <file>          print the differences between two documents
+
+Documents are read from standard input if no file (or "-") is given.
+`
+
+// errDifferent is returned by the diff command if the given documents are different.
+var errDifferent = errors.New("documents are different")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command given by args, returning the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	cmd := &command{stdin: stdin, stdout: stdout}
+
+	var run func(args []string) error
+	switch args[0] {
+	case "validate":
+		run = cmd.validate
+	case "linkage":
+		run = cmd.linkage
+	case "pretty":
+		run = cmd.pretty
+	case "ndjson":
+		run = cmd.ndjson
+	case "csv":
+		run = cmd.csv
+	case "diff":
+		run = cmd.diff
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "jsonapi: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	if err := run(args[1:]); err != nil {
+		if !errors.Is(err, errDifferent) {
+			fmt.Fprintf(stderr, "jsonapi %s: %s\n", args[0], err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// command holds the standard streams of a command.
+type command struct {
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+// read reads the document in the given file, or standard input if it is empty or "-".
+func (c *command) read(file string) ([]byte, error) {
+	if file == "" || file == "-" {
+		return io.ReadAll(c.stdin)
+	}
+	return os.ReadFile(file)
+}
+
+// readArg reads the document in the file given by args, which must not have more than one
+// element.
+func (c *command) readArg(args []string) ([]byte, error) {
+	switch len(args) {
+	case 0:
+		return c.read("")
+	case 1:
+		return c.read(args[0])
+	}
+	return nil, fmt.Errorf("too many arguments")
+}
+
+func (c *command) validate(args []string) error {
+	data, err := c.readArg(args)
+	if err != nil {
+		return err
+	}
+	if err := jsonapi.Verify(data); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, "ok")
+	return nil
+}
+
+func (c *command) linkage(args []string) error {
+	data, err := c.readArg(args)
+	if err != nil {
+		return err
+	}
+	if err := jsonapi.Verify(data, jsonapi.UnmarshalDisableNameValidation()); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, "ok")
+	return nil
+}
+
+func (c *command) pretty(args []string) error {
+	data, err := c.readArg(args)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, "", "  "); err != nil {
+		return err
+	}
+	b.WriteByte('\n')
+
+	_, err = b.WriteTo(c.stdout)
+	return err
+}
+
+// resourceObjects returns the primary data of the given document, followed by its included
+// resources if included is true.
+func resourceObjects(data []byte, included bool) ([]json.RawMessage, error) {
+	var d struct {
+		Data     json.RawMessage   `json:"data"`
+		Included []json.RawMessage `json:"included"`
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+
+	ros := make([]json.RawMessage, 0)
+	switch trimmed := bytes.TrimSpace(d.Data); {
+	case len(trimmed) == 0, bytes.Equal(trimmed, []byte("null")):
+	case trimmed[0] == '[':
+		if err := json.Unmarshal(trimmed, &ros); err != nil {
+			return nil, err
+		}
+	default:
+		ros = append(ros, trimmed)
+	}
+
+	if included {
+		ros = append(ros, d.Included...)
+	}
+	return ros, nil
+}
+
+// parseResourceArgs parses the flags of commands converting resource objects.
+func parseResourceArgs(name string, args []string) (file string, included bool, err error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&included, "included", false, "include the included resources")
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		file = fs.Arg(0)
+	default:
+		err = fmt.Errorf("too many arguments")
+	}
+	return
+}
+
+func (c *command) ndjson(args []string) error {
+	file, included, err := parseResourceArgs("ndjson", args)
+	if err != nil {
+		return err
+	}
+	data, err := c.read(file)
+	if err != nil {
+		return err
+	}
+	ros, err := resourceObjects(data, included)
+	if err != nil {
+		return err
+	}
+
+	for _, ro := range ros {
+		var b bytes.Buffer
+		if err := json.Compact(&b, ro); err != nil {
+			return err
+		}
+		b.WriteByte('\n')
+		if _, err := b.WriteTo(c.stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *command) csv(args []string) error {
+	file, included, err := parseResourceArgs("csv", args)
+	if err != nil {
+		return err
+	}
+	data, err := c.read(file)
+	if err != nil {
+		return err
+	}
+	raw, err := resourceObjects(data, included)
+	if err != nil {
+		return err
+	}
+
+	type resourceObject struct {
+		ID         string         `json:"id"`
+		Type       string         `json:"type"`
+		Attributes map[string]any `json:"attributes"`
+	}
+
+	ros := make([]resourceObject, len(raw))
+	names := make(map[string]bool)
+	for i, r := range raw {
+		if err := json.Unmarshal(r, &ros[i]); err != nil {
+			return err
+		}
+		for name := range ros[i].Attributes {
+			names[name] = true
+		}
+	}
+
+	header := []string{"type", "id"}
+	attributes := make([]string, 0, len(names))
+	for name := range names {
+		attributes = append(attributes, name)
+	}
+	sort.Strings(attributes)
+	header = append(header, attributes...)
+
+	w := csv.NewWriter(c.stdout)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, ro := range ros {
+		record := []string{ro.Type, ro.ID}
+		for _, name := range attributes {
+			value, err := csvValue(ro.Attributes[name])
+			if err != nil {
+				return err
+			}
+			record = append(record, value)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+
+	return w.Error()
+}
+
+// csvValue formats an attribute value as csv field. Strings are formatted as is, and all other
+// values as json.
+func csvValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func (c *command) diff(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected two files")
+	}
+
+	docs := make([]any, 2)
+	for i, file := range args {
+		data, err := c.read(file)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &docs[i]); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		docs[i] = normalize(docs[i])
+	}
+
+	differences := make([]string, 0)
+	diff("", docs[0], docs[1], &differences)
+	for _, d := range differences {
+		fmt.Fprintln(c.stdout, d)
+	}
+	if len(differences) > 0 {
+		return errDifferent
+	}
+	return nil
+}
+
+// normalize sorts the included resources of the given decoded document by type and id, as their
+// order is not significant.
+func normalize(doc any) any {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return doc
+	}
+	included, ok := m["included"].([]any)
+	if !ok {
+		return doc
+	}
+
+	key := func(v any) string {
+		ro, _ := v.(map[string]any)
+		return fmt.Sprintf("%v/%v", ro["type"], ro["id"])
+	}
+	sort.SliceStable(included, func(i, j int) bool {
+		return key(included[i]) < key(included[j])
+	})
+	return doc
+}
+
+// diff appends the differences between the decoded json values a and b at the given JSON
+// pointer to differences.
+func diff(pointer string, a, b any, differences *[]string) {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		names := make([]string, 0, len(a)+len(b))
+		for name := range a {
+			names = append(names, name)
+		}
+		for name := range b {
+			if _, ok := a[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			p := pointer + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+			av, aok := a[name]
+			bv, bok := b[name]
+			switch {
+			case !bok:
+				*differences = append(*differences, fmt.Sprintf("- %s: %s", p, encode(av)))
+			case !aok:
+				*differences = append(*differences, fmt.Sprintf("+ %s: %s", p, encode(bv)))
+			default:
+				diff(p, av, bv, differences)
+			}
+		}
+		return
+	case []any:
+		b, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(a) || i < len(b); i++ {
+			p := fmt.Sprintf("%s/%d", pointer, i)
+			switch {
+			case i >= len(b):
+				*differences = append(*differences, fmt.Sprintf("- %s: %s", p, encode(a[i])))
+			case i >= len(a):
+				*differences = append(*differences, fmt.Sprintf("+ %s: %s", p, encode(b[i])))
+			default:
+				diff(p, a[i], b[i], differences)
+			}
+		}
+		return
+	}
+
+	if encode(a) != encode(b) {
+		*differences = append(*differences, fmt.Sprintf("~ %s: %s -> %s", pointer, encode(a), encode(b)))
+	}
+}
+
+// encode returns the json encoding of the decoded json value v.
+func encode(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	articleBody  = `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`
+	articlesBody = `{"data":[{"type":"articles","id":"1","attributes":{"title":"A","tags":["a"]}},{"type":"articles","id":"2","attributes":{"title":"B","views":2}}]}`
+	compoundBody = `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":{"type":"author","id":"1"}},"comments":{"data":[{"type":"comments","id":"1"}]}}},"included":[{"type":"comments","id":"1","attributes":{"body":"A"}},{"type":"author","id":"1","attributes":{"name":"A"}}]}`
+	partialBody  = `{"data":{"type":"articles","id":"1"},"included":[{"type":"author","id":"1"}]}`
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	compound := write("compound.json", compoundBody)
+	reordered := write("reordered.json", `{"included":[{"id":"1","type":"author","attributes":{"name":"A"}},{"type":"comments","id":"1","attributes":{"body":"A"}}],"data":{"type":"articles","id":"1","relationships":{"comments":{"data":[{"type":"comments","id":"1"}]},"author":{"data":{"type":"author","id":"1"}}}}}`)
+	changed := write("changed.json", `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":{"type":"author","id":"1"}}}},"included":[{"type":"author","id":"1","attributes":{"name":"B"}}]}`)
+
+	tests := []struct {
+		description  string
+		args         []string
+		stdin        string
+		expectCode   int
+		expectStdout string
+		expectStderr string
+	}{
+		{
+			description:  "no command",
+			expectCode:   2,
+			expectStderr: "usage: jsonapi",
+		}, {
+			description:  "unknown command",
+			args:         []string{"foo"},
+			expectCode:   2,
+			expectStderr: `unknown command "foo"`,
+		}, {
+			description:  "validate valid document",
+			args:         []string{"validate"},
+			stdin:        compoundBody,
+			expectStdout: "ok\n",
+		}, {
+			description:  "validate invalid document",
+			args:         []string{"validate", "-"},
+			stdin:        `{"data":{"type":"articles","id":"1","attributes":{"ti%tle":"A"}}}`,
+			expectCode:   1,
+			expectStderr: "jsonapi validate: invalid member name: ti%tle\n",
+		}, {
+			description:  "validate file",
+			args:         []string{"validate", compound},
+			expectStdout: "ok\n",
+		}, {
+			description:  "linkage",
+			args:         []string{"linkage"},
+			stdin:        partialBody,
+			expectCode:   1,
+			expectStderr: "no chain of relationships from primary data",
+		}, {
+			description:  "pretty",
+			args:         []string{"pretty"},
+			stdin:        articleBody,
+			expectStdout: "{\n  \"data\": {\n    \"type\": \"articles\",\n    \"id\": \"1\",\n    \"attributes\": {\n      \"title\": \"A\"\n    }\n  }\n}\n",
+		}, {
+			description:  "ndjson",
+			args:         []string{"ndjson"},
+			stdin:        articlesBody,
+			expectStdout: `{"type":"articles","id":"1","attributes":{"title":"A","tags":["a"]}}` + "\n" + `{"type":"articles","id":"2","attributes":{"title":"B","views":2}}` + "\n",
+		}, {
+			description:  "ndjson with included",
+			args:         []string{"ndjson", "-included"},
+			stdin:        compoundBody,
+			expectStdout: `{"type":"articles","id":"1","relationships":{"author":{"data":{"type":"author","id":"1"}},"comments":{"data":[{"type":"comments","id":"1"}]}}}` + "\n" + `{"type":"comments","id":"1","attributes":{"body":"A"}}` + "\n" + `{"type":"author","id":"1","attributes":{"name":"A"}}` + "\n",
+		}, {
+			description:  "csv",
+			args:         []string{"csv"},
+			stdin:        articlesBody,
+			expectStdout: "type,id,tags,title,views\narticles,1,\"[\"\"a\"\"]\",A,\narticles,2,,B,2\n",
+		}, {
+			description: "diff equal documents",
+			args:        []string{"diff", compound, reordered},
+		}, {
+			description:  "diff different documents",
+			args:         []string{"diff", compound, changed},
+			expectCode:   1,
+			expectStdout: "- /data/relationships/comments: {\"data\":[{\"id\":\"1\",\"type\":\"comments\"}]}\n~ /included/0/attributes/name: \"A\" -> \"B\"\n- /included/1: {\"attributes\":{\"body\":\"A\"},\"id\":\"1\",\"type\":\"comments\"}\n",
+		}, {
+			description:  "diff missing file",
+			args:         []string{"diff", compound},
+			expectCode:   1,
+			expectStderr: "expected two files",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var stdout, stderr bytes.Buffer
+			code := run(tc.args, strings.NewReader(tc.stdin), &stdout, &stderr)
+			if code != tc.expectCode {
+				t.Errorf("expected exit code %d, got %d (stderr: %s)", tc.expectCode, code, stderr.String())
+			}
+			if stdout.String() != tc.expectStdout {
+				t.Errorf("expected stdout:\n%s\ngot:\n%s", tc.expectStdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tc.expectStderr) {
+				t.Errorf("expected stderr to contain %q, got %q", tc.expectStderr, stderr.String())
+			}
+		})
+	}
+}
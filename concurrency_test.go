@@ -0,0 +1,90 @@
+package jsonapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// concurrency is the number of goroutines used by the concurrency tests, which are meant to be run
+// with the race detector, and iterations the number of calls made by each goroutine.
+const (
+	concurrency = 16
+	iterations  = 20
+)
+
+// runConcurrently calls f from concurrency goroutines at once and waits for them to return.
+func runConcurrently(f func()) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for j := 0; j < iterations; j++ {
+				f()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+}
+
+func TestConcurrentMarshal(t *testing.T) {
+	t.Parallel()
+
+	// options are shared, with spare capacity to detect appends to the given slice
+	opts := make([]MarshalOption, 0, 8)
+	opts = append(opts, MarshalInclude(&authorAWithMeta, &commentA, &commentB), MarshalMeta(map[string]any{"count": 1}))
+
+	expect, err := Marshal(&articleRelatedComplete, opts...)
+	is.MustNoError(t, err)
+
+	runConcurrently(func() {
+		actual, err := Marshal(&articleRelatedComplete, opts...)
+		is.MustNoError(t, err)
+		is.EqualJSON(t, string(expect), string(actual))
+	})
+}
+
+func TestConcurrentUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	opts := make([]UnmarshalOption, 0, 8)
+	opts = append(opts, UnmarshalMaxBodySize(1<<20))
+
+	runConcurrently(func() {
+		var a ArticleRelated
+		is.MustNoError(t, Unmarshal([]byte(articleRelatedCompleteWithIncludeBody), &a, opts...))
+		is.Equal(t, "A", a.Title)
+
+		var b ArticleRelated
+		idx, err := UnmarshalWithIncluded([]byte(articleRelatedCompleteWithIncludeBody), &b, opts...)
+		is.MustNoError(t, err)
+		is.Equal(t, 3, idx.Len())
+	})
+}
+
+func TestConcurrentServer(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer[ArticleRelated](newArticleStore(), ServerMarshalOptions(MarshalJSONAPI(nil)))
+
+	runConcurrently(func() {
+		r := httptest.NewRequest(http.MethodGet, "/1", nil)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		is.Equal(t, http.StatusOK, rec.Code)
+
+		r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"data":{"type":"articles","attributes":{"title":"B"}}}`))
+		r.Header.Set("Content-Type", MediaType)
+		rec = httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		is.Equal(t, http.StatusCreated, rec.Code)
+	})
+}
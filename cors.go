@@ -0,0 +1,201 @@
+package jsonapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// corsOptions holds the configuration of the CORS middleware.
+type corsOptions struct {
+	origins        []string
+	credentials    bool
+	methods        []string
+	headers        []string
+	exposedHeaders []string
+	maxAge         time.Duration
+	extensions     []string
+}
+
+// CORSOption allows for configuration of the CORS middleware.
+type CORSOption func(o *corsOptions)
+
+// CORSOrigins sets the origins allowed to make cross-origin requests, or "*" to allow any origin,
+// which is the default. "*" is ignored with CORSCredentials.
+func CORSOrigins(origins ...string) CORSOption {
+	return func(o *corsOptions) {
+		o.origins = origins
+	}
+}
+
+// CORSCredentials allows cross-origin requests with credentials (cookies and HTTP authentication).
+// The request's origin is then returned instead of "*" in the Access-Control-Allow-Origin header,
+// as required by https://fetch.spec.whatwg.org/#cors-protocol-and-credentials.
+//
+// Credentialed requests are only allowed from the origins given explicitly by CORSOrigins, as
+// allowing them from any origin would let any site act on behalf of users, so no cross-origin
+// request is allowed if CORSOrigins isn't given or only allows "*".
+func CORSCredentials() CORSOption {
+	return func(o *corsOptions) {
+		o.credentials = true
+	}
+}
+
+// CORSMethods sets the methods allowed in cross-origin requests. By default, the methods of the
+// JSON:API specification are allowed: GET, HEAD, POST, PATCH and DELETE.
+func CORSMethods(methods ...string) CORSOption {
+	return func(o *corsOptions) {
+		o.methods = methods
+	}
+}
+
+// CORSHeaders sets additional request headers allowed in cross-origin requests, besides the Accept
+// and Content-Type headers used by content negotiation, e.g. Authorization.
+func CORSHeaders(headers ...string) CORSOption {
+	return func(o *corsOptions) {
+		o.headers = append(o.headers, headers...)
+	}
+}
+
+// CORSExposedHeaders sets additional response headers exposed to cross-origin requests, besides the
+// Location, ETag and Vary headers.
+func CORSExposedHeaders(headers ...string) CORSOption {
+	return func(o *corsOptions) {
+		o.exposedHeaders = append(o.exposedHeaders, headers...)
+	}
+}
+
+// CORSMaxAge sets how long the results of preflight requests can be cached by clients. By default,
+// the Access-Control-Max-Age header isn't set.
+func CORSMaxAge(d time.Duration) CORSOption {
+	return func(o *corsOptions) {
+		o.maxAge = d
+	}
+}
+
+// CORSExtensions sets the URIs of the extensions supported by the next handler, which are allowed
+// by content negotiation as described by NegotiateRequest.
+func CORSExtensions(extensions ...string) CORSOption {
+	return func(o *corsOptions) {
+		o.extensions = append(o.extensions, extensions...)
+	}
+}
+
+// CORS is a middleware preset for JSON:API endpoints handling cross-origin resource sharing as
+// defined by https://fetch.spec.whatwg.org/#http-cors-protocol, OPTIONS requests and content
+// negotiation, so that the next handler only serves requests it can respond to.
+//
+// Preflight requests (OPTIONS requests with the Origin and Access-Control-Request-Method headers)
+// are answered with 204 (No Content) and the CORS headers allowing the request, if any. Other OPTIONS
+// requests are answered with 204 (No Content) and the Allow header. Every other request is
+// negotiated as done by Negotiate, and if it is acceptable, served by next with the CORS headers set
+// if its origin is allowed.
+func CORS(next http.Handler, opts ...CORSOption) http.Handler {
+	o := &corsOptions{
+		origins: []string{"*"},
+		methods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodDelete},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	allowedHeaders := append([]string{"Accept", "Content-Type"}, o.headers...)
+	exposedHeaders := strings.Join(append([]string{"Location", "ETag", "Vary"}, o.exposedHeaders...), ", ")
+	allow := strings.Join(append(append([]string{}, o.methods...), http.MethodOptions), ", ")
+	anyOrigin := len(o.origins) == 1 && o.origins[0] == "*" && !o.credentials
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := origin != "" && o.allowsOrigin(origin)
+		switch {
+		case anyOrigin && allowed:
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		case !anyOrigin:
+			// the response depends on the origin, so caches mustn't reuse it for other origins
+			w.Header().Add("Vary", "Origin")
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				if o.credentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+		}
+
+		if r.Method == http.MethodOptions {
+			requestMethod := r.Header.Get("Access-Control-Request-Method")
+			if origin != "" && requestMethod != "" {
+				// preflight request
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				if allowed && o.allowsMethod(requestMethod) && allowsHeaders(allowedHeaders, r.Header.Values("Access-Control-Request-Headers")) {
+					w.Header().Set("Access-Control-Allow-Methods", strings.Join(o.methods, ", "))
+					w.Header().Set("Access-Control-Allow-Headers", strings.Join(allowedHeaders, ", "))
+					if o.maxAge > 0 {
+						w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(o.maxAge.Seconds())))
+					}
+				}
+			} else {
+				w.Header().Set("Allow", allow)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed {
+			w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+		}
+
+		if !Negotiate(w, r, o.extensions...) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowsOrigin returns true if cross-origin requests from the given origin are allowed. Any origin
+// is only allowed by "*" without credentials.
+func (o *corsOptions) allowsOrigin(origin string) bool {
+	for _, allowed := range o.origins {
+		if allowed == "*" && !o.credentials || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsMethod returns true if cross-origin requests with the given method are allowed. Methods are
+// case-sensitive.
+func (o *corsOptions) allowsMethod(method string) bool {
+	for _, allowed := range o.methods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders returns true if every header listed in the given Access-Control-Request-Headers
+// values is allowed.
+func allowsHeaders(allowed, requested []string) bool {
+	for _, value := range requested {
+		for _, header := range strings.Split(value, ",") {
+			header = strings.TrimSpace(header)
+			if header != "" && !containsFold(allowed, header) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// containsFold returns true if values contains s, ignoring case.
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+
+	next := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return Write(w, http.StatusOK, &articleA)
+	})
+
+	tests := []struct {
+		description   string
+		opts          []CORSOption
+		method        string
+		header        http.Header
+		expectStatus  int
+		expectHeader  http.Header
+		expectMissing []string
+	}{
+		{
+			description:   "same-origin request",
+			method:        http.MethodGet,
+			header:        http.Header{"Accept": {MediaType}},
+			expectStatus:  http.StatusOK,
+			expectHeader:  http.Header{"Content-Type": {MediaType}, "Vary": {"Accept"}},
+			expectMissing: []string{"Access-Control-Allow-Origin"},
+		}, {
+			description:  "cross-origin request from any origin",
+			method:       http.MethodGet,
+			header:       http.Header{"Origin": {"https://example.com"}},
+			expectStatus: http.StatusOK,
+			expectHeader: http.Header{
+				"Access-Control-Allow-Origin":   {"*"},
+				"Access-Control-Expose-Headers": {"Location, ETag, Vary"},
+			},
+		}, {
+			description:  "cross-origin request from allowed origin with credentials",
+			opts:         []CORSOption{CORSOrigins("https://example.com"), CORSCredentials(), CORSExposedHeaders("X-Request-Id")},
+			method:       http.MethodGet,
+			header:       http.Header{"Origin": {"https://example.com"}},
+			expectStatus: http.StatusOK,
+			expectHeader: http.Header{
+				"Access-Control-Allow-Origin":      {"https://example.com"},
+				"Access-Control-Allow-Credentials": {"true"},
+				"Access-Control-Expose-Headers":    {"Location, ETag, Vary, X-Request-Id"},
+				"Vary":                             {"Origin", "Accept"},
+			},
+		}, {
+			description:   "cross-origin request with credentials from any origin",
+			opts:          []CORSOption{CORSCredentials()},
+			method:        http.MethodGet,
+			header:        http.Header{"Origin": {"https://example.com"}},
+			expectStatus:  http.StatusOK,
+			expectHeader:  http.Header{"Vary": {"Origin", "Accept"}},
+			expectMissing: []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials", "Access-Control-Expose-Headers"},
+		}, {
+			description:   "cross-origin request from other origin",
+			opts:          []CORSOption{CORSOrigins("https://example.com")},
+			method:        http.MethodGet,
+			header:        http.Header{"Origin": {"https://example.org"}},
+			expectStatus:  http.StatusOK,
+			expectHeader:  http.Header{"Vary": {"Origin", "Accept"}},
+			expectMissing: []string{"Access-Control-Allow-Origin", "Access-Control-Expose-Headers"},
+		}, {
+			description: "preflight request",
+			opts:        []CORSOption{CORSHeaders("Authorization"), CORSMaxAge(time.Hour)},
+			method:      http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"https://example.com"},
+				"Access-Control-Request-Method":  {http.MethodPatch},
+				"Access-Control-Request-Headers": {"content-type, authorization"},
+			},
+			expectStatus: http.StatusNoContent,
+			expectHeader: http.Header{
+				"Access-Control-Allow-Origin":  {"*"},
+				"Access-Control-Allow-Methods": {"GET, HEAD, POST, PATCH, DELETE"},
+				"Access-Control-Allow-Headers": {"Accept, Content-Type, Authorization"},
+				"Access-Control-Max-Age":       {"3600"},
+			},
+		}, {
+			description: "preflight request with disallowed method",
+			opts:        []CORSOption{CORSMethods(http.MethodGet)},
+			method:      http.MethodOptions,
+			header: http.Header{
+				"Origin":                        {"https://example.com"},
+				"Access-Control-Request-Method": {http.MethodDelete},
+			},
+			expectStatus:  http.StatusNoContent,
+			expectMissing: []string{"Access-Control-Allow-Methods", "Access-Control-Allow-Headers"},
+		}, {
+			description: "preflight request with disallowed header",
+			method:      http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"https://example.com"},
+				"Access-Control-Request-Method":  {http.MethodGet},
+				"Access-Control-Request-Headers": {"X-Custom"},
+			},
+			expectStatus:  http.StatusNoContent,
+			expectMissing: []string{"Access-Control-Allow-Methods", "Access-Control-Allow-Headers"},
+		}, {
+			description:  "options request",
+			method:       http.MethodOptions,
+			expectStatus: http.StatusNoContent,
+			expectHeader: http.Header{"Allow": {"GET, HEAD, POST, PATCH, DELETE, OPTIONS"}},
+		}, {
+			description:  "unsupported media type",
+			method:       http.MethodPost,
+			header:       http.Header{"Origin": {"https://example.com"}, "Content-Type": {"application/json"}},
+			expectStatus: http.StatusUnsupportedMediaType,
+			expectHeader: http.Header{"Access-Control-Allow-Origin": {"*"}},
+		}, {
+			description:  "supported extension",
+			opts:         []CORSOption{CORSExtensions("https://jsonapi.org/ext/atomic")},
+			method:       http.MethodPost,
+			header:       http.Header{"Content-Type": {MediaType + `; ext="https://jsonapi.org/ext/atomic"`}},
+			expectStatus: http.StatusOK,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			r := httptest.NewRequest(tc.method, "/articles", nil)
+			for name, values := range tc.header {
+				r.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			CORS(next, tc.opts...).ServeHTTP(rec, r)
+
+			is.Equal(t, tc.expectStatus, rec.Code)
+			for name, values := range tc.expectHeader {
+				is.Equal(t, values, rec.Header().Values(name))
+			}
+			for _, name := range tc.expectMissing {
+				is.Equal(t, "", rec.Header().Get(name))
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AtomicResultsMember is the top-level member holding the results of the operations of an Atomic
+// Operations extension request, as defined by https://jsonapi.org/ext/atomic/#auto-id-responses.
+const AtomicResultsMember = "atomic:results"
+
+// MarshalDataMember writes the primary data of documents under the given extension member (e.g.
+// AtomicResultsMember) instead of data, as allowed by https://jsonapi.org/format/1.1/#extensions.
+// Primary data is otherwise marshaled as usual, e.g. included resources must be linked to it.
+//
+// The results of AtomicResultsMember are result objects, each holding one primary resource object in
+// its data member. Other members hold the primary data directly.
+func MarshalDataMember(name string) MarshalOption {
+	return func(m *Marshaler) {
+		m.dataMember = name
+	}
+}
+
+// UnmarshalDataMember reads the primary data of documents from the given extension member (e.g.
+// AtomicResultsMember) instead of data, as written with MarshalDataMember. Result objects of
+// AtomicResultsMember without data (e.g. the results of remove operations) are skipped. Error
+// pointers refer to the given member.
+func UnmarshalDataMember(name string) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.dataMember = name
+	}
+}
+
+// dataMemberKey returns the json encoding of the name of the member holding primary data followed
+// by a colon, e.g. "data":.
+func dataMemberKey(name string) ([]byte, error) {
+	if name == "" {
+		name = "data"
+	}
+	b, err := json.Marshal(name)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, ':'), nil
+}
+
+// moveDataMember returns the given document with the primary data held by the member of the given
+// name moved to the data member, along with a function mapping JSON pointers into the returned
+// document to pointers into the given one.
+func moveDataMember(data []byte, name string) ([]byte, func(string) string, error) {
+	var members map[string]rawValue
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, nil, err
+	}
+	pointer, err := moveMember(members, name, func(primary rawValue) (rawValue, []int, error) {
+		var results []map[string]rawValue
+		if err := json.Unmarshal(primary, &results); err != nil {
+			return nil, nil, &TypeError{Actual: jsonKindOf(primary), Expected: []string{"array of result objects"}}
+		}
+
+		ros := make([]rawValue, 0, len(results))
+		indexes := make([]int, 0, len(results))
+		for i, result := range results {
+			if ro, ok := result["data"]; ok && string(ro) != "null" {
+				ros = append(ros, ro)
+				indexes = append(indexes, i)
+			}
+		}
+		b, err := json.Marshal(ros)
+		return b, indexes, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := json.Marshal(members)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, pointer, nil
+}
+
+// moveMapDataMember is moveDataMember for documents given as generic Go values, which are left
+// untouched.
+func moveMapDataMember(doc map[string]any, name string) (map[string]any, func(string) string, error) {
+	members := make(map[string]any, len(doc))
+	for member, value := range doc {
+		members[member] = value
+	}
+	pointer, err := moveMember(members, name, func(primary any) (any, []int, error) {
+		results, ok := primary.([]any)
+		if !ok {
+			return nil, nil, &TypeError{Actual: kindOfValue(primary), Expected: []string{"array of result objects"}}
+		}
+
+		ros := make([]any, 0, len(results))
+		indexes := make([]int, 0, len(results))
+		for i, result := range results {
+			switch result := result.(type) {
+			case nil:
+			case map[string]any:
+				if ro, ok := result["data"]; ok && ro != nil {
+					ros = append(ros, ro)
+					indexes = append(indexes, i)
+				}
+			default:
+				return nil, nil, &TypeError{Actual: kindOfValue(primary), Expected: []string{"array of result objects"}}
+			}
+		}
+		return ros, indexes, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return members, pointer, nil
+}
+
+// moveMember moves the primary data held by the member of the given name of the document members to
+// its data member, unwrapping the result objects of AtomicResultsMember with results, which also
+// returns the indexes of the result objects holding primary data. It returns a function mapping
+// JSON pointers into the moved document to pointers into the given one.
+func moveMember[V any](members map[string]V, name string, results func(primary V) (V, []int, error)) (func(string) string, error) {
+	if _, ok := members["data"]; ok {
+		return nil, &DocumentError{
+			Code:    CodeInvalidData,
+			Pointer: "/data",
+			Err:     fmt.Errorf("%w: primary data must be held by the %s member", ErrInvalidDataField, name),
+		}
+	}
+	primary, ok := members[name]
+	if !ok {
+		return nil, &DocumentError{Code: CodeMissingData, Err: ErrMissingDataField}
+	}
+	delete(members, name)
+
+	namePointer := "/" + escapePointerToken(name)
+	pointer := func(p string) string {
+		return namePointer + p
+	}
+
+	if name == AtomicResultsMember {
+		ros, indexes, err := results(primary)
+		if err != nil {
+			return nil, &DocumentError{Code: CodeInvalidData, Pointer: namePointer, Err: err}
+		}
+		primary = ros
+
+		pointer = func(p string) string {
+			var i int
+			rest := ""
+			if _, err := fmt.Sscanf(p, "/%d", &i); err != nil || i < 0 || i >= len(indexes) {
+				return namePointer + p
+			}
+			if j := strings.IndexByte(p[1:], '/'); j >= 0 {
+				rest = p[j+1:]
+			}
+			return fmt.Sprintf("%s/%d/data%s", namePointer, indexes[i], rest)
+		}
+	}
+
+	members["data"] = primary
+
+	return func(p string) string {
+		if p != "/data" && !strings.HasPrefix(p, "/data/") {
+			return p
+		}
+		return pointer(strings.TrimPrefix(p, "/data"))
+	}, nil
+}
@@ -0,0 +1,154 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalDataMember(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+	}{
+		{
+			description: "atomic results",
+			given:       articlesABPtr,
+			opts:        []MarshalOption{MarshalDataMember(AtomicResultsMember)},
+			expect:      `{"atomic:results":[{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}},{"data":{"type":"articles","id":"2","attributes":{"title":"B"}}}]}`,
+		}, {
+			description: "atomic results of a single resource",
+			given:       &articleA,
+			opts:        []MarshalOption{MarshalDataMember(AtomicResultsMember)},
+			expect:      `{"atomic:results":[{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}]}`,
+		}, {
+			description: "atomic results of a nil resource",
+			given:       (*Article)(nil),
+			opts:        []MarshalOption{MarshalDataMember(AtomicResultsMember)},
+			expect:      `{"atomic:results":[]}`,
+		}, {
+			description: "atomic results with included and meta",
+			given:       &articleRelatedAuthor,
+			opts: []MarshalOption{
+				MarshalDataMember(AtomicResultsMember),
+				MarshalInclude(&authorA),
+				MarshalMeta(map[string]any{"count": 2}),
+			},
+			expect: `{"atomic:results":[{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}}}}}],"included":[{"id":"1","type":"author","attributes":{"name":"A"}}],"meta":{"count":2}}`,
+		}, {
+			description: "other member",
+			given:       &articleA,
+			opts:        []MarshalOption{MarshalDataMember("version:resource")},
+			expect:      `{"version:resource":{"type":"articles","id":"1","attributes":{"title":"A"}}}`,
+		}, {
+			description: "other member with many resources",
+			given:       articlesABPtr,
+			opts:        []MarshalOption{MarshalDataMember("version:resources")},
+			expect:      `{"version:resources":[{"type":"articles","id":"1","attributes":{"title":"A"}},{"type":"articles","id":"2","attributes":{"title":"B"}}]}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+
+			rec := httptest.NewRecorder()
+			is.MustNoError(t, Write(rec, 200, tc.given, tc.opts...))
+			is.EqualJSON(t, tc.expect, rec.Body.String())
+		})
+	}
+}
+
+func TestUnmarshalDataMember(t *testing.T) {
+	t.Parallel()
+
+	t.Run("atomic results", func(t *testing.T) {
+		t.Parallel()
+
+		var articles []*Article
+		body := `{"atomic:results":[{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}},{},{"data":null},{"data":{"type":"articles","id":"2","attributes":{"title":"B"}}}]}`
+		is.MustNoError(t, Unmarshal([]byte(body), &articles, UnmarshalDataMember(AtomicResultsMember)))
+		is.Equal(t, articlesABPtr, articles)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := Marshal(&articleRelatedAuthor, MarshalDataMember(AtomicResultsMember), MarshalInclude(&authorA))
+		is.MustNoError(t, err)
+
+		var articles []*ArticleRelated
+		is.MustNoError(t, Unmarshal(b, &articles, UnmarshalDataMember(AtomicResultsMember)))
+		is.Equal(t, []*ArticleRelated{&articleRelatedAuthor}, articles)
+	})
+
+	t.Run("other member", func(t *testing.T) {
+		t.Parallel()
+
+		var article Article
+		body := `{"version:resource":{"type":"articles","id":"1","attributes":{"title":"A"}}}`
+		is.MustNoError(t, Unmarshal([]byte(body), &article, UnmarshalDataMember("version:resource")))
+		is.Equal(t, articleA, article)
+	})
+
+	t.Run("error pointers", func(t *testing.T) {
+		t.Parallel()
+
+		var articles []*Article
+		body := `{"atomic:results":[{},{"data":{"type":"articles","id":"1"}},{"data":{"type":"articles","id":"2","attributes":{"title":2}}}]}`
+		err := Unmarshal([]byte(body), &articles, UnmarshalDataMember(AtomicResultsMember))
+
+		var re *ResourceError
+		is.MustEqual(t, true, errors.As(err, &re))
+		is.Equal(t, "/atomic:results/2/data", re.Pointer)
+
+		var fe *FieldError
+		is.MustEqual(t, true, errors.As(err, &fe))
+		is.Equal(t, "/atomic:results/2/data/attributes/title", fe.Pointer)
+	})
+
+	tests := []struct {
+		description string
+		body        string
+		expectError error
+	}{
+		{
+			description: "missing member",
+			body:        `{"meta":{"count":0}}`,
+			expectError: &DocumentError{Code: CodeMissingData, Err: ErrMissingDataField},
+		}, {
+			description: "data and member",
+			body:        `{"data":[],"atomic:results":[]}`,
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: fmt.Errorf("%w: primary data must be held by the atomic:results member", ErrInvalidDataField)},
+		}, {
+			description: "results are not an array",
+			body:        `{"atomic:results":{}}`,
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/atomic:results", Err: &TypeError{Actual: "object", Expected: []string{"array of result objects"}}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var articles []*Article
+			err := Unmarshal([]byte(tc.body), &articles, UnmarshalDataMember(AtomicResultsMember))
+			is.EqualError(t, tc.expectError, err)
+		})
+	}
+}
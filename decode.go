@@ -0,0 +1,218 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DecodeEach reads a json:api document from r and unmarshals its primary data into values of type
+// T one resource object at a time, calling f with each as soon as it has been read. This keeps the
+// memory used to decode huge collections independent of their size, unlike Unmarshal.
+//
+// Resource objects are unmarshaled as by Unmarshal with the given options, except that included
+// resources aren't used to populate relationships, which hold resource linkage only, as included
+// resources may only be read after all primary data. Members other than data and included (e.g.
+// meta) are unmarshaled once the whole document has been read.
+//
+// Documents with both data and errors are rejected as soon as the second of these members is read.
+// If errors follows data, f has already been called for every primary resource by then.
+//
+// If f returns an error, decoding stops and the error is returned as is.
+func DecodeEach[T any](r io.Reader, f func(v *T) error, opts ...UnmarshalOption) error {
+	m := makeUnmarshaler(opts...)
+
+	return m.observe(func(op *Operation) (err error) {
+		cr := &countingReader{r: r}
+		defer func() { op.Size = cr.n }()
+
+		// the document is checked while being read, one member or resource object at a time, rather
+		// than as a whole
+		s := m.newDocumentScanner(false)
+		m.checked = true
+		var lr io.Reader = cr
+		if m.limits.MaxBytes > 0 {
+			lr = &limitedReader{r: cr, max: m.limits.MaxBytes}
+		}
+		dec := json.NewDecoder(lr)
+
+		if err = expectDelim(dec, '{'); err != nil {
+			return
+		}
+
+		rest := make(map[string]json.RawMessage)
+		names := newMemberNames(true)
+		hasData := false
+		for dec.More() {
+			var tok json.Token
+			if tok, err = dec.Token(); err != nil {
+				return
+			}
+			member, _ := tok.(string)
+			if m.rejectDuplicateMembers {
+				if err = names.add(member, "/"+escapePointerToken(member)); err != nil {
+					return
+				}
+			}
+			if member == "errors" && hasData {
+				return &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrDataAndErrorsFields}
+			}
+			if member != "data" {
+				var raw json.RawMessage
+				if err = dec.Decode(&raw); err != nil {
+					return
+				}
+				if err = s.scan(raw, []pathSegment{{name: member, index: -1}}, 1); err != nil {
+					return
+				}
+				rest[member] = raw
+				continue
+			}
+
+			// f must not be called for resources of a document which also has errors
+			if _, ok := rest["errors"]; ok {
+				return &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrDataAndErrorsFields}
+			}
+			hasData = true
+			if err = decodeEachData(dec, m, s, op, f); err != nil {
+				return
+			}
+		}
+		if err = expectDelim(dec, '}'); err != nil {
+			return
+		}
+
+		if hasData {
+			rest["data"] = json.RawMessage("null")
+		}
+		delete(rest, "included")
+
+		b, err := json.Marshal(rest)
+		if err != nil {
+			return
+		}
+		d, err := m.unmarshal(b, new(T))
+		op.add(d)
+
+		return
+	})
+}
+
+// expectDelim reads the next token of dec, which must be the given delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("invalid document: expected %q, got %v", delim, tok)
+	}
+	return nil
+}
+
+// decodeEachData reads the primary data of a document from dec, calling f with each resource object
+// unmarshaled into a new T. Resource objects are checked by s before they are unmarshaled, and added
+// to op once they are.
+func decodeEachData[T any](dec *json.Decoder, m *Unmarshaler, s *documentScanner, op *Operation, f func(v *T) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case nil:
+		// {"data":null}
+		return nil
+	case json.Delim('{'):
+		// a single resource object is small, so collect its members to unmarshal it as a whole
+		members := make(map[string]json.RawMessage)
+		names := newMemberNames(true)
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if m.rejectDuplicateMembers {
+				name, _ := key.(string)
+				if err := names.add(name, "/data/"+escapePointerToken(name)); err != nil {
+					return err
+				}
+			}
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			members[fmt.Sprint(key)] = raw
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return err
+		}
+		ro, err := json.Marshal(members)
+		if err != nil {
+			return err
+		}
+		if err := s.scan(ro, []pathSegment{{name: "data", index: -1}}, 1); err != nil {
+			return err
+		}
+		return decodeEachResourceObject(ro, "/data", m, op, f)
+	case json.Delim('['):
+		if err := s.checkDepth(2); err != nil {
+			return err
+		}
+		for i := 0; dec.More(); i++ {
+			var ro json.RawMessage
+			if err := dec.Decode(&ro); err != nil {
+				return err
+			}
+			if err := s.scan(ro, []pathSegment{{name: "data", index: -1}, {index: i}}, 2); err != nil {
+				return err
+			}
+			if err := decodeEachResourceObject(ro, fmt.Sprintf("/data/%d", i), m, op, f); err != nil {
+				return err
+			}
+		}
+		return expectDelim(dec, ']')
+	}
+
+	return &DocumentError{
+		Code:    CodeInvalidData,
+		Pointer: "/data",
+		Err:     &TypeError{Actual: jsonTokenKind(tok), Expected: []string{"object", "array", "null"}},
+	}
+}
+
+// jsonTokenKind returns the kind of the json value starting with the given token, as used by
+// json.UnmarshalTypeError.
+func jsonTokenKind(tok json.Token) string {
+	switch tok.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64, json.Number:
+		return "number"
+	}
+	return fmt.Sprint(tok)
+}
+
+// decodeEachResourceObject unmarshals the given resource object into a new T, adds it to op and calls
+// f with it. Error pointers refer to the given pointer to the resource object.
+func decodeEachResourceObject[T any](ro []byte, pointer string, m *Unmarshaler, op *Operation, f func(v *T) error) error {
+	data := make([]byte, 0, len(ro)+9)
+	data = append(append(append(data, `{"data":`...), ro...), '}')
+
+	v := new(T)
+	d, err := m.unmarshal(data, v)
+	if err != nil {
+		return mapPointers(err, func(p string) string {
+			if p != "/data" && !strings.HasPrefix(p, "/data/") {
+				return p
+			}
+			return pointer + strings.TrimPrefix(p, "/data")
+		})
+	}
+	op.add(d)
+
+	return f(v)
+}
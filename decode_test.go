@@ -0,0 +1,140 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestDecodeEach(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		opts        []UnmarshalOption
+		expect      []*Article
+		expectError error
+	}{
+		{
+			description: "many",
+			given:       articlesABBody,
+			expect:      articlesABPtr,
+		}, {
+			description: "one",
+			given:       articleABody,
+			expect:      []*Article{&articleA},
+		}, {
+			description: "null",
+			given:       nullDataBody,
+			expect:      []*Article{},
+		}, {
+			description: "empty",
+			given:       emptyManyBody,
+			expect:      []*Article{},
+		}, {
+			description: "meta only",
+			given:       `{"meta":{"count":0}}`,
+			expect:      []*Article{},
+		}, {
+			description: "missing data",
+			given:       `{}`,
+			expect:      []*Article{},
+			expectError: &DocumentError{Code: CodeMissingData, Err: ErrMissingDataField},
+		}, {
+			description: "data and errors",
+			given:       `{"data":[],"errors":[{"title":"T"}]}`,
+			expect:      []*Article{},
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrDataAndErrorsFields},
+		}, {
+			description: "errors before data",
+			given:       `{"errors":[{"title":"T"}],` + strings.TrimPrefix(articlesABBody, "{"),
+			expect:      []*Article{},
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrDataAndErrorsFields},
+		}, {
+			description: "errors after data in a truncated document",
+			given:       `{"data":[{"type":"articles","id":"1","attributes":{"title":"A"}}],"errors":[{"title":"T"}],"meta":`,
+			expect:      []*Article{&articleA},
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrDataAndErrorsFields},
+		}, {
+			description: "invalid data",
+			given:       `{"data":"1"}`,
+			expect:      []*Article{},
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: &TypeError{Actual: "string", Expected: []string{"object", "array", "null"}}},
+		}, {
+			description: "invalid resource object",
+			given:       `{"data":[{"type":"articles","id":"1","attributes":{"title":"A"}},{"type":"not-articles","id":"2"}]}`,
+			expect:      []*Article{&articleA},
+			expectError: &TypeError{Actual: "not-articles", Expected: []string{"articles"}},
+		}, {
+			description: "invalid json",
+			given:       `{"data":[{"type":"articles","id":"1","attributes":{"title":"A"}}`,
+			expect:      []*Article{&articleA},
+			expectError: errors.New("unexpected end of JSON input"),
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual := make([]*Article, 0)
+			err := DecodeEach(strings.NewReader(tc.given), func(a *Article) error {
+				actual = append(actual, a)
+				return nil
+			}, tc.opts...)
+			is.EqualError(t, tc.expectError, err)
+			is.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestDecodeEachCompound(t *testing.T) {
+	t.Parallel()
+
+	var meta map[string]any
+	actual := make([]*ArticleRelated, 0)
+	err := DecodeEach(strings.NewReader(articleRelatedAuthorTwiceWithIncludeBody), func(a *ArticleRelated) error {
+		actual = append(actual, a)
+		return nil
+	}, UnmarshalMeta(&meta))
+	is.MustNoError(t, err)
+	is.Equal(t, []*ArticleRelated{
+		{ID: "1", Title: "A", Author: &Author{ID: "1"}},
+		{ID: "2", Title: "B", Author: &Author{ID: "1"}},
+	}, actual)
+	is.Equal(t, map[string]any(nil), meta)
+
+	err = DecodeEach(strings.NewReader(`{"meta":{"count":2},"data":[]}`), func(a *ArticleRelated) error { return nil }, UnmarshalMeta(&meta))
+	is.MustNoError(t, err)
+	is.Equal(t, map[string]any{"count": float64(2)}, meta)
+}
+
+func TestDecodeEachStop(t *testing.T) {
+	t.Parallel()
+
+	errStop := errors.New("stop")
+	calls := 0
+	err := DecodeEach(strings.NewReader(articlesABBody), func(a *Article) error {
+		calls++
+		return errStop
+	})
+	is.Equal(t, true, errors.Is(err, errStop))
+	is.Equal(t, 1, calls)
+}
+
+func TestDecodeEachErrorPointer(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":[{"type":"articles","id":"1","attributes":{"title":"A"}},{"type":"articles","id":"2","attributes":{"title":2}}]}`
+	err := DecodeEach(strings.NewReader(body), func(a *Article) error { return nil })
+
+	var fe *FieldError
+	is.MustEqual(t, true, errors.As(err, &fe))
+	is.Equal(t, "/data/1/attributes/title", fe.Pointer)
+}
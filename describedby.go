@@ -0,0 +1,70 @@
+package jsonapi
+
+import "encoding/json"
+
+// DescribedByLink is the name of the top-level link to a description of the document, such as an
+// OpenAPI or JSON Schema document, as defined by https://jsonapi.org/format/1.1/#document-top-level.
+const DescribedByLink = "describedby"
+
+// MarshalDescribedBy sets the describedby top-level link, which must be a string or *LinkObject,
+// pointing to a description of the document such as an OpenAPI or JSON Schema document. It is added
+// to the links given by MarshalLinks, if any, without modifying them.
+func MarshalDescribedBy(link any) MarshalOption {
+	return func(m *Marshaler) {
+		m.describedBy = link
+	}
+}
+
+// documentLinks returns the top-level links of documents, which are the links given by
+// MarshalLinks along with the link given by MarshalDescribedBy.
+func (m *Marshaler) documentLinks() (*Link, error) {
+	if m.describedBy == nil {
+		return m.link, nil
+	}
+	isEmpty, err := checkLinkValue(m.describedBy)
+	if err != nil {
+		return nil, err
+	}
+	if isEmpty {
+		return m.link, nil
+	}
+
+	var l Link
+	if m.link != nil {
+		l = *m.link
+		l.Extra = make(map[string]any, len(m.link.Extra)+1)
+		for name, link := range m.link.Extra {
+			l.Extra[name] = link
+		}
+	}
+	return l.Set(DescribedByLink, m.describedBy), nil
+}
+
+// DocumentInfo holds the top-level members of an unmarshaled document besides its primary data and
+// included resources, as given by UnmarshalDocumentInfo.
+type DocumentInfo struct {
+	// Links are the top-level links, if any.
+	Links *Link
+
+	// JSONAPI is the top-level jsonapi object, if any.
+	JSONAPI *JSONAPIObject
+
+	// Meta is the json encoded top-level meta, if any, which can be decoded with DecodeMeta.
+	Meta json.RawMessage
+}
+
+// DescribedBy returns the describedby top-level link, or nil if there is none.
+func (i *DocumentInfo) DescribedBy() any {
+	if i.Links == nil {
+		return nil
+	}
+	return i.Links.Get(DescribedByLink)
+}
+
+// UnmarshalDocumentInfo stores the top-level links, jsonapi object and meta of the document in info
+// when unmarshaling, e.g. to follow its describedby link or decode its meta with DecodeMeta.
+func UnmarshalDocumentInfo(info *DocumentInfo) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.documentInfo = info
+	}
+}
@@ -0,0 +1,83 @@
+package jsonapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalDescribedBy(t *testing.T) {
+	t.Parallel()
+
+	links := &Link{Self: "https://example.com/articles/1"}
+
+	tests := []struct {
+		description string
+		opts        []MarshalOption
+		expect      string
+		expectError bool
+	}{
+		{
+			description: "string",
+			opts:        []MarshalOption{MarshalDescribedBy("https://example.com/schemas/articles.json")},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"links":{"describedby":"https://example.com/schemas/articles.json"}}`,
+		}, {
+			description: "link object with links",
+			opts: []MarshalOption{
+				MarshalDescribedBy(&LinkObject{Href: "https://example.com/openapi.json", Type: "application/openapi+json"}),
+				MarshalLinks(links),
+			},
+			expect: `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"links":{"self":"https://example.com/articles/1","describedby":{"href":"https://example.com/openapi.json","type":"application/openapi+json"}}}`,
+		}, {
+			description: "empty",
+			opts:        []MarshalOption{MarshalLinks(links), MarshalDescribedBy("")},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"links":{"self":"https://example.com/articles/1"}}`,
+		}, {
+			description: "invalid",
+			opts:        []MarshalOption{MarshalDescribedBy(1)},
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(&articleA, tc.opts...)
+			if tc.expectError {
+				is.MustError(t, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+
+	// the links given by MarshalLinks are left untouched
+	is.Equal(t, &Link{Self: "https://example.com/articles/1"}, links)
+}
+
+func TestUnmarshalDocumentInfo(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"links":{"self":"https://example.com/articles/1","describedby":{"href":"https://example.com/openapi.json"}},"jsonapi":{"version":"1.1"}}`
+
+	var (
+		a    Article
+		info DocumentInfo
+	)
+	err := Unmarshal([]byte(body), &a, UnmarshalDocumentInfo(&info))
+	is.MustNoError(t, err)
+	is.Equal(t, articleA, a)
+	is.Equal(t, "https://example.com/articles/1", LinkHref(info.Links.Self))
+	is.Equal(t, "https://example.com/openapi.json", LinkHref(info.DescribedBy()))
+	is.Equal(t, "1.1", info.JSONAPI.Version)
+
+	info = DocumentInfo{}
+	err = Unmarshal([]byte(articleABody), &a, UnmarshalDocumentInfo(&info))
+	is.MustNoError(t, err)
+	is.Equal(t, nil, info.DescribedBy())
+}
@@ -0,0 +1,211 @@
+package jsonapi
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+)
+
+// ChangeSet is the difference between two versions of a resource, as computed by Diff. Its json
+// encoding is a minimal json:api document updating the old version to the new one, as defined by
+// https://jsonapi.org/format/#crud-updating, i.e. a resource object with the changed attributes and
+// relationships only.
+type ChangeSet struct {
+	// Type and ID identify the resource.
+	Type string
+	ID   string
+
+	// Attributes holds the changed attributes by member name.
+	Attributes map[string]AttributeChange
+
+	// Relationships holds the relationships with changed resource linkage by member name.
+	Relationships map[string]RelationshipChange
+}
+
+// AttributeChange is a changed attribute of a ChangeSet. Old or New are nil if the attribute was
+// omitted from the old or new version of the resource, e.g. by sparse fieldsets.
+type AttributeChange struct {
+	Old any
+	New any
+}
+
+// RelationshipChange is a relationship of a ChangeSet whose resource linkage changed.
+type RelationshipChange struct {
+	// ToMany is true for to-many relationships.
+	ToMany bool
+
+	// Added and Removed are the related resources added to and removed from the relationship, in
+	// the order of the resource linkage they were added to or removed from.
+	Added   []ResourceIdentifier
+	Removed []ResourceIdentifier
+
+	// Linkage is the new resource linkage of the relationship, which is empty if the relationship
+	// was cleared.
+	Linkage []ResourceIdentifier
+}
+
+// IsEmpty returns true if the ChangeSet has no changes.
+func (c *ChangeSet) IsEmpty() bool {
+	return len(c.Attributes) == 0 && len(c.Relationships) == 0
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (c *ChangeSet) MarshalJSON() ([]byte, error) {
+	ro := &resourceObject{
+		Type:          c.Type,
+		ID:            c.ID,
+		Attributes:    make(map[string]any, len(c.Attributes)),
+		Relationships: make(map[string]*document, len(c.Relationships)),
+	}
+	for name, change := range c.Attributes {
+		ro.Attributes[name] = change.New
+	}
+	for name, change := range c.Relationships {
+		rd := newDocument()
+		rd.hasMany = change.ToMany
+		for _, ri := range change.Linkage {
+			rd.DataMany = append(rd.DataMany, &resourceObject{Type: ri.Type, ID: ri.ID})
+		}
+		if !rd.hasMany && len(rd.DataMany) > 0 {
+			rd.DataOne, rd.DataMany = rd.DataMany[0], rd.DataMany[:0]
+		}
+		ro.Relationships[name] = rd
+	}
+
+	d := newDocument()
+	d.DataOne = ro
+	return marshalJSON(d)
+}
+
+// Diff returns the changes between the old and new versions of a resource (structs or pointers to
+// structs), which must have the same type and id. Attributes are compared by their json encoding,
+// and to-many relationships regardless of the order of their resource linkage.
+//
+// Both versions are marshaled as by Marshal with the given options, e.g. MarshalClientMode to diff
+// resources without an id. Relationships marshaled without resource linkage, e.g. due to
+// MarshalLinksOnly, are left out. Zero attributes are marshaled regardless of omitempty, omitzero
+// and MarshalZeroAttributes, so that attributes cleared to their zero value are updated to it
+// rather than to null.
+func Diff(oldVersion, newVersion any, opts ...MarshalOption) (c *ChangeSet, err error) {
+	defer func() {
+		// because we make use of reflect we must recover any panics
+		if rvr := recover(); rvr != nil {
+			err = recoverError(rvr)
+			return
+		}
+	}()
+
+	if isNilInput(oldVersion) || isNilInput(newVersion) {
+		err = ErrNilResource
+		return
+	}
+
+	m := makeMarshaler(opts...)
+	m.keepZero = true
+	oro, err := makeResourceObject(oldVersion, reflect.TypeOf(oldVersion), m, false)
+	if err != nil {
+		return
+	}
+	nro, err := makeResourceObject(newVersion, reflect.TypeOf(newVersion), m, false)
+	if err != nil {
+		return
+	}
+	if oro.Type != nro.Type || oro.ID != nro.ID {
+		err = ErrDiffResourceMismatch
+		return
+	}
+
+	c = &ChangeSet{
+		Type:          nro.Type,
+		ID:            nro.ID,
+		Attributes:    make(map[string]AttributeChange),
+		Relationships: make(map[string]RelationshipChange),
+	}
+	if err = c.diffAttributes(oro.Attributes, nro.Attributes); err != nil {
+		c = nil
+		return
+	}
+	c.diffRelationships(oro.Relationships, nro.Relationships)
+
+	return
+}
+
+// diffAttributes adds the attributes which differ between the old and new attributes to c.
+func (c *ChangeSet) diffAttributes(oldAttributes, newAttributes map[string]any) error {
+	for _, name := range unionKeys(oldAttributes, newAttributes) {
+		ov, inOld := oldAttributes[name]
+		nv, inNew := newAttributes[name]
+		if inOld && inNew {
+			ob, err := marshalJSON(ov)
+			if err != nil {
+				return err
+			}
+			nb, err := marshalJSON(nv)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(ob, nb) {
+				continue
+			}
+		}
+		c.Attributes[name] = AttributeChange{Old: ov, New: nv}
+	}
+	return nil
+}
+
+// diffRelationships adds the relationships whose resource linkage differs between the old and new
+// relationships to c.
+func (c *ChangeSet) diffRelationships(oldRelationships, newRelationships map[string]*document) {
+	for _, name := range unionKeys(oldRelationships, newRelationships) {
+		od, nd := oldRelationships[name], newRelationships[name]
+		if od != nil && od.noData || nd != nil && nd.noData {
+			continue
+		}
+		var oldLinkage, newLinkage resourceLinkage
+		if od != nil {
+			oldLinkage = *linkageOf(od)
+		}
+		if nd != nil {
+			newLinkage = *linkageOf(nd)
+		}
+		change := RelationshipChange{
+			ToMany:  oldLinkage.toMany || newLinkage.toMany,
+			Added:   linkageDifference(newLinkage.identifiers, oldLinkage.identifiers),
+			Removed: linkageDifference(oldLinkage.identifiers, newLinkage.identifiers),
+			Linkage: newLinkage.identifiers,
+		}
+		if len(change.Added) > 0 || len(change.Removed) > 0 {
+			c.Relationships[name] = change
+		}
+	}
+}
+
+// linkageDifference returns the resource identifiers of a which aren't in b.
+func linkageDifference(a, b []ResourceIdentifier) []ResourceIdentifier {
+	in := make(map[ResourceIdentifier]bool, len(b))
+	for _, ri := range b {
+		in[ri] = true
+	}
+	var diff []ResourceIdentifier
+	for _, ri := range a {
+		if !in[ri] {
+			diff = append(diff, ri)
+		}
+	}
+	return diff
+}
+
+// unionKeys returns the keys of a and b in sorted order.
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
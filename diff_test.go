@@ -0,0 +1,151 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	authorB := Author{ID: "2", Name: "B"}
+	commentC := Comment{ID: "3", Body: "C"}
+
+	tests := []struct {
+		description string
+		oldVersion  any
+		newVersion  any
+		opts        []MarshalOption
+		expect      *ChangeSet
+		expectPatch string
+		expectError error
+	}{
+		{
+			description: "unchanged",
+			oldVersion:  &ArticleRelated{ID: "1", Title: "A", Author: &authorA, Comments: commentsAB},
+			newVersion:  ArticleRelated{ID: "1", Title: "A", Author: &authorA, Comments: []*Comment{&commentB, &commentA}},
+			expect:      &ChangeSet{Type: "articles", ID: "1", Attributes: map[string]AttributeChange{}, Relationships: map[string]RelationshipChange{}},
+			expectPatch: `{"data":{"type":"articles","id":"1"}}`,
+		}, {
+			description: "changed",
+			oldVersion:  &ArticleRelated{ID: "1", Title: "A", Author: &authorA, Comments: commentsAB},
+			newVersion:  &ArticleRelated{ID: "1", Title: "B", Author: &authorB, Comments: []*Comment{&commentB, &commentC}},
+			expect: &ChangeSet{
+				Type:       "articles",
+				ID:         "1",
+				Attributes: map[string]AttributeChange{"title": {Old: "A", New: "B"}},
+				Relationships: map[string]RelationshipChange{
+					"author": {
+						Added:   []ResourceIdentifier{{Type: "author", ID: "2"}},
+						Removed: []ResourceIdentifier{{Type: "author", ID: "1"}},
+						Linkage: []ResourceIdentifier{{Type: "author", ID: "2"}},
+					},
+					"comments": {
+						ToMany:  true,
+						Added:   []ResourceIdentifier{{Type: "comments", ID: "3"}},
+						Removed: []ResourceIdentifier{{Type: "comments", ID: "1"}},
+						Linkage: []ResourceIdentifier{{Type: "comments", ID: "2"}, {Type: "comments", ID: "3"}},
+					},
+				},
+			},
+			expectPatch: `{"data":{"type":"articles","id":"1","attributes":{"title":"B"},"relationships":{"author":{"data":{"type":"author","id":"2"}},"comments":{"data":[{"type":"comments","id":"2"},{"type":"comments","id":"3"}]}}}}`,
+		}, {
+			description: "cleared relationships",
+			oldVersion:  &ArticleRelatedNoOmitEmpty{ID: "1", Title: "A", Author: &authorA, Comments: commentsAB},
+			newVersion:  &ArticleRelatedNoOmitEmpty{ID: "1", Title: "A"},
+			expect: &ChangeSet{
+				Type:       "articles",
+				ID:         "1",
+				Attributes: map[string]AttributeChange{},
+				Relationships: map[string]RelationshipChange{
+					"author": {
+						Removed: []ResourceIdentifier{{Type: "author", ID: "1"}},
+					},
+					"comments": {
+						ToMany:  true,
+						Removed: []ResourceIdentifier{{Type: "comments", ID: "1"}, {Type: "comments", ID: "2"}},
+					},
+				},
+			},
+			expectPatch: `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":null},"comments":{"data":[]}}}}`,
+		}, {
+			description: "omitted relationships",
+			oldVersion:  &ArticleRelated{ID: "1", Title: "A", Author: &authorA},
+			newVersion:  &ArticleRelated{ID: "1", Title: "A"},
+			expect: &ChangeSet{
+				Type:       "articles",
+				ID:         "1",
+				Attributes: map[string]AttributeChange{},
+				Relationships: map[string]RelationshipChange{
+					"author": {Removed: []ResourceIdentifier{{Type: "author", ID: "1"}}},
+				},
+			},
+			expectPatch: `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":null}}}}`,
+		}, {
+			description: "attributes cleared to zero",
+			oldVersion:  &Comment{ID: "1", Body: "A", Archived: true},
+			newVersion:  &Comment{ID: "1", Archived: false},
+			opts:        []MarshalOption{MarshalZeroAttributes(ZeroAttributesOmit)},
+			expect: &ChangeSet{
+				Type:          "comments",
+				ID:            "1",
+				Attributes:    map[string]AttributeChange{"body": {Old: "A", New: ""}, "archived": {Old: true, New: false}},
+				Relationships: map[string]RelationshipChange{},
+			},
+			expectPatch: `{"data":{"type":"comments","id":"1","attributes":{"body":"","archived":false}}}`,
+		}, {
+			description: "links-only relationships",
+			oldVersion:  &ArticleRelated{ID: "1", Title: "A", Author: &authorA},
+			newVersion:  &ArticleRelated{ID: "1", Title: "A"},
+			opts:        []MarshalOption{MarshalLinksOnly("articles")},
+			expect:      &ChangeSet{Type: "articles", ID: "1", Attributes: map[string]AttributeChange{}, Relationships: map[string]RelationshipChange{}},
+			expectPatch: `{"data":{"type":"articles","id":"1"}}`,
+		}, {
+			description: "client mode",
+			oldVersion:  &Article{Title: "A"},
+			newVersion:  &Article{Title: "B"},
+			opts:        []MarshalOption{MarshalClientMode()},
+			expect:      &ChangeSet{Type: "articles", Attributes: map[string]AttributeChange{"title": {Old: "A", New: "B"}}, Relationships: map[string]RelationshipChange{}},
+			expectPatch: `{"data":{"type":"articles","attributes":{"title":"B"}}}`,
+		}, {
+			description: "different ids",
+			oldVersion:  &Article{ID: "1"},
+			newVersion:  &Article{ID: "2"},
+			expectError: ErrDiffResourceMismatch,
+		}, {
+			description: "different types",
+			oldVersion:  &Article{ID: "1"},
+			newVersion:  &Comment{ID: "1"},
+			expectError: ErrDiffResourceMismatch,
+		}, {
+			description: "nil",
+			oldVersion:  (*Article)(nil),
+			newVersion:  &Article{ID: "1"},
+			expectError: ErrNilResource,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Diff(tc.oldVersion, tc.newVersion, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, actual)
+			is.Equal(t, len(tc.expect.Attributes)+len(tc.expect.Relationships) == 0, actual.IsEmpty())
+
+			b, err := json.Marshal(actual)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expectPatch, string(b))
+		})
+	}
+}
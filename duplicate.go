@@ -0,0 +1,72 @@
+package jsonapi
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// UnmarshalRejectDuplicateMembers rejects documents containing objects with duplicate member names,
+// e.g. two data members or an attribute given twice, with an error wrapping ErrDuplicateMember.
+// encoding/json accepts them, keeping the last value of each member, while other parsers may keep
+// the first one, which is a known source of request smuggling bugs. As encoding/json also matches
+// member names to struct fields case-insensitively, member names differing in case only (e.g. name
+// and NAME) are duplicates as well in objects whose members are matched to struct fields, i.e. the
+// top-level object, resource objects, resource identifier objects, relationship objects, error
+// objects, and attributes and relationships objects. Member names of other objects, e.g. meta,
+// links or map attributes, are compared exactly.
+//
+// Documents with duplicate member names are always rejected when built with the jsonv2 build tag.
+func UnmarshalRejectDuplicateMembers() UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.rejectDuplicateMembers = true
+	}
+}
+
+// memberNames holds the member names of an object, to detect duplicate ones.
+type memberNames struct {
+	names map[string]bool
+
+	// fold is true if member names differing in case only are duplicates, as for objects whose
+	// members are matched to struct fields by encoding/json
+	fold bool
+}
+
+// newMemberNames creates the memberNames of an object, whose member names are compared
+// case-insensitively if fold is true.
+func newMemberNames(fold bool) *memberNames {
+	return &memberNames{names: make(map[string]bool), fold: fold}
+}
+
+// add adds the name of the member at the given JSON pointer, returning a DocumentError if the
+// object already has a member of the same name.
+func (n *memberNames) add(name, pointer string) error {
+	key := name
+	if n.fold {
+		key = foldName(name)
+	}
+	if n.names[key] {
+		return &DocumentError{Code: CodeInvalidData, Pointer: pointer, Err: fmt.Errorf("%w: %q", ErrDuplicateMember, name)}
+	}
+	n.names[key] = true
+	return nil
+}
+
+// foldName returns the canonical form of name under Unicode simple case folding, as used by
+// encoding/json to match member names to struct fields, such that foldName(a) == foldName(b) if
+// and only if strings.EqualFold(a, b).
+func foldName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	for _, r := range name {
+		// use the smallest rune of those equivalent under case folding
+		folded := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < folded {
+				folded = f
+			}
+		}
+		b.WriteRune(folded)
+	}
+	return b.String()
+}
@@ -0,0 +1,174 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestUnmarshalRejectDuplicateMembers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description   string
+		given         string
+		expectPointer string
+	}{
+		{
+			description: "no duplicates",
+			given:       articleRelatedCompleteWithIncludeBody,
+		}, {
+			description: "same names in different objects",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"title":"A","id":"1"},"meta":{"title":"A"}}}`,
+		}, {
+			description: "meta and links members differing in case",
+			given:       `{"data":{"type":"articles","id":"1","meta":{"a":1,"A":2}},"meta":{"ETag":1,"etag":2},"links":{"self":"http://example.com/a","Self":"http://example.com/b"}}`,
+		}, {
+			description:   "duplicate meta member",
+			given:         `{"data":{"type":"articles","id":"1"},"meta":{"etag":1,"etag":2}}`,
+			expectPointer: "/meta/etag",
+		}, {
+			description:   "relationship object members differing in case",
+			given:         `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":null,"DATA":{"type":"author","id":"1"}}}}}`,
+			expectPointer: "/data/relationships/author/DATA",
+		}, {
+			description:   "resource identifier object members differing in case",
+			given:         `{"data":{"type":"articles","id":"1","relationships":{"comments":{"data":[{"type":"comments","id":"1","ID":"2"}]}}}}`,
+			expectPointer: "/data/relationships/comments/data/0/ID",
+		}, {
+			description:   "duplicate data",
+			given:         `{"data":{"type":"articles","id":"1"},"data":{"type":"articles","id":"2"}}`,
+			expectPointer: "/data",
+		}, {
+			description:   "duplicate attribute",
+			given:         `{"data":{"type":"articles","id":"1","attributes":{"title":"A","title":"B"}}}`,
+			expectPointer: "/data/attributes/title",
+		}, {
+			description:   "duplicate escaped member",
+			given:         `{"data":{"type":"articles","id":"1","attributes":{"title":"A","\u0074itle":"B"}}}`,
+			expectPointer: "/data/attributes/title",
+		}, {
+			description:   "attribute differing in case",
+			given:         `{"data":{"type":"articles","id":"1","attributes":{"title":"A","TITLE":"B"}}}`,
+			expectPointer: "/data/attributes/TITLE",
+		}, {
+			description:   "data differing in case",
+			given:         `{"data":{"type":"articles","id":"1"},"Data":{"type":"articles","id":"2"}}`,
+			expectPointer: "/Data",
+		}, {
+			description:   "duplicate in array",
+			given:         `{"data":{"type":"articles","id":"1","relationships":{"comments":{"data":[{"type":"comments","id":"1","id":"2"}]}}}}`,
+			expectPointer: "/data/relationships/comments/data/0/id",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var a ArticleRelated
+			err := Unmarshal([]byte(tc.given), &a, UnmarshalRejectDuplicateMembers())
+			if tc.expectPointer == "" {
+				is.MustNoError(t, err)
+				return
+			}
+			is.Equal(t, true, errors.Is(err, ErrDuplicateMember))
+			objects := ErrorObjects(err)
+			is.MustEqual(t, 1, len(objects))
+			is.Equal(t, CodeInvalidData, objects[0].Code)
+			is.Equal(t, tc.expectPointer, objects[0].Source.Pointer)
+		})
+	}
+}
+
+func TestRejectDuplicateMembersMapAttributes(t *testing.T) {
+	t.Parallel()
+
+	// map attributes aren't matched to struct fields, so their members are compared exactly
+	var o Order
+	err := Unmarshal([]byte(`{"data":{"type":"orders","id":"1","attributes":{"details":{"color":"red","Color":"blue"}}}}`), &o, UnmarshalRejectDuplicateMembers())
+	is.MustNoError(t, err)
+	is.Equal(t, map[string]any{"color": "red", "Color": "blue"}, o.Details)
+
+	err = Unmarshal([]byte(`{"data":{"type":"orders","id":"1","attributes":{"details":{"color":"red","color":"blue"}}}}`), &o, UnmarshalRejectDuplicateMembers())
+	is.Equal(t, true, errors.Is(err, ErrDuplicateMember))
+}
+
+func TestRejectDuplicateMembersEntryPoints(t *testing.T) {
+	t.Parallel()
+
+	opt := UnmarshalRejectDuplicateMembers()
+
+	tests := []struct {
+		description   string
+		do            func() error
+		expectPointer string
+	}{
+		{
+			description: "UnmarshalRelationshipUpdate",
+			do: func() error {
+				_, err := UnmarshalRelationshipUpdate([]byte(`{"data":[{"type":"comments","type":"articles","id":"1"}]}`), http.MethodPost, opt)
+				return err
+			},
+			expectPointer: "/data/0/type",
+		}, {
+			description: "UnmarshalRef",
+			do: func() error {
+				var a ArticleRelated
+				return UnmarshalRef([]byte(`{"data":{"type":"author","id":"1","ID":"2"}}`), &a, "author", opt)
+			},
+			expectPointer: "/data/ID",
+		}, {
+			description: "Verify",
+			do: func() error {
+				return Verify([]byte(`{"data":{"type":"articles","id":"1","attributes":{"title":"A","Title":"B"}}}`), opt)
+			},
+			expectPointer: "/data/attributes/Title",
+		}, {
+			description: "UnmarshalErrors",
+			do: func() error {
+				_, err := UnmarshalErrors([]byte(`{"errors":[{"status":"404","Status":"500"}]}`), opt)
+				return err
+			},
+			expectPointer: "/errors/0/Status",
+		}, {
+			description: "DecodeEach top-level member",
+			do: func() error {
+				return DecodeEach(strings.NewReader(`{"data":[],"DATA":[]}`), func(*Article) error { return nil }, opt)
+			},
+			expectPointer: "/DATA",
+		}, {
+			description: "DecodeEach single resource object",
+			do: func() error {
+				return DecodeEach(strings.NewReader(`{"data":{"type":"articles","id":"1","Type":"comments"}}`), func(*Article) error { return nil }, opt)
+			},
+			expectPointer: "/data/Type",
+		}, {
+			description: "DecodeEach resource object in array",
+			do: func() error {
+				return DecodeEach(strings.NewReader(`{"data":[{"type":"articles","id":"1","attributes":{"title":"A","tItle":"B"}}]}`), func(*Article) error { return nil }, opt)
+			},
+			expectPointer: "/data/0/attributes/tItle",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			err := tc.do()
+			is.Equal(t, true, errors.Is(err, ErrDuplicateMember))
+			objects := ErrorObjects(err)
+			is.MustEqual(t, 1, len(objects))
+			is.Equal(t, tc.expectPointer, objects[0].Source.Pointer)
+		})
+	}
+}
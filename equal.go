@@ -0,0 +1,179 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+// Difference is a difference between two json:api documents found by Equal.
+type Difference struct {
+	// Pointer is the JSON pointer to the differing value, in a unless the value is only in b.
+	// Included resources are matched by type and id, so the pointers to them may differ between a
+	// and b.
+	Pointer string
+
+	// A and B are the differing values, or nil if the value is missing. Values are decoded as by
+	// encoding/json, except for numbers, which are json.Number values.
+	A, B any
+
+	// Reason describes the difference, e.g. "member only in a".
+	Reason string
+}
+
+// String returns the pointer and reason of the Difference.
+func (d Difference) String() string {
+	if d.Pointer == "" {
+		return d.Reason
+	}
+	return d.Pointer + ": " + d.Reason
+}
+
+// Equal compares the json:api documents a and b semantically, i.e. ignoring the order of object
+// members and of included resources, which are matched by type and id, and returns the differences
+// between them. Numbers are compared exactly by value, so 1, 1.0 and 1e0 are equal while integers
+// too large for a float64 aren't rounded. It is meant to be used in tests:
+//
+//	if ok, diffs := jsonapi.Equal(expected, actual); !ok {
+//		t.Errorf("documents differ: %v", diffs)
+//	}
+func Equal(a, b []byte) (bool, []Difference) {
+	av, err := decodeNumbers(a)
+	if err != nil {
+		return false, []Difference{{Reason: fmt.Sprintf("a is not valid json: %v", err)}}
+	}
+	bv, err := decodeNumbers(b)
+	if err != nil {
+		return false, []Difference{{Reason: fmt.Sprintf("b is not valid json: %v", err)}}
+	}
+
+	var c comparison
+	c.compare("", av, bv)
+	return len(c.diffs) == 0, c.diffs
+}
+
+// comparison accumulates the differences between two documents.
+type comparison struct {
+	diffs []Difference
+}
+
+func (c *comparison) add(pointer string, a, b any, reason string) {
+	c.diffs = append(c.diffs, Difference{Pointer: pointer, A: a, B: b, Reason: reason})
+}
+
+// compare compares the decoded json values a and b found at the given pointer.
+func (c *comparison) compare(pointer string, a, b any) {
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			c.compareObjects(pointer, av, bv)
+			return
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			c.compareArrays(pointer, av, bv)
+			return
+		}
+	case json.Number:
+		if bv, ok := b.(json.Number); ok && numbersEqual(av, bv) {
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		c.add(pointer, a, b, "values differ")
+	}
+}
+
+// numbersEqual returns true if the json numbers a and b have the same value, e.g. 1, 1.0 and 1e0.
+// They are compared exactly, so that large integers such as ids differing in their last digits are
+// told apart.
+func numbersEqual(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	ar, aok := new(big.Rat).SetString(string(a))
+	br, bok := new(big.Rat).SetString(string(b))
+	return aok && bok && ar.Cmp(br) == 0
+}
+
+func (c *comparison) compareObjects(pointer string, a, b map[string]any) {
+	for _, name := range unionKeys(a, b) {
+		p := pointer + "/" + escapePointerToken(name)
+		av, inA := a[name]
+		bv, inB := b[name]
+		switch {
+		case !inB:
+			c.add(p, av, nil, "member only in a")
+		case !inA:
+			c.add(p, nil, bv, "member only in b")
+		case pointer == "" && name == "included":
+			ai, aok := av.([]any)
+			bi, bok := bv.([]any)
+			if aok && bok {
+				c.compareIncluded(p, ai, bi)
+			} else {
+				c.compare(p, av, bv)
+			}
+		default:
+			c.compare(p, av, bv)
+		}
+	}
+}
+
+func (c *comparison) compareArrays(pointer string, a, b []any) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		p := pointer + "/" + strconv.Itoa(i)
+		switch {
+		case i >= len(b):
+			c.add(p, a[i], nil, "element only in a")
+		case i >= len(a):
+			c.add(p, nil, b[i], "element only in b")
+		default:
+			c.compare(p, a[i], b[i])
+		}
+	}
+}
+
+// compareIncluded compares the included resources a and b regardless of their order, matching
+// them by type and id.
+func (c *comparison) compareIncluded(pointer string, a, b []any) {
+	byIdentifier := make(map[string]int, len(b))
+	for j, ro := range b {
+		byIdentifier[includedIdentifier(ro)] = j
+	}
+
+	matched := make(map[int]bool, len(b))
+	for i, ro := range a {
+		p := pointer + "/" + strconv.Itoa(i)
+		j, ok := byIdentifier[includedIdentifier(ro)]
+		if !ok || matched[j] {
+			c.add(p, ro, nil, "resource only in a")
+			continue
+		}
+		matched[j] = true
+		c.compare(p, ro, b[j])
+	}
+
+	for j, ro := range b {
+		if !matched[j] {
+			c.add(pointer+"/"+strconv.Itoa(j), nil, ro, "resource only in b")
+		}
+	}
+}
+
+// includedIdentifier returns a string identifying the decoded resource object ro by its type and
+// id, or the json encoding of ro if it has neither.
+func includedIdentifier(ro any) string {
+	if m, ok := ro.(map[string]any); ok {
+		typ, _ := m["type"].(string)
+		id, _ := m["id"].(string)
+		lid, _ := m["lid"].(string)
+		if typ != "" && (id != "" || lid != "") {
+			return resourceIdentifier(typ, id) + lid
+		}
+	}
+	b, _ := json.Marshal(ro)
+	return string(b)
+}
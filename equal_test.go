@@ -0,0 +1,130 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		a, b        string
+		expect      []Difference
+	}{
+		{
+			description: "identical",
+			a:           `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`,
+			b:           `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`,
+		}, {
+			description: "member order",
+			a:           `{"data":{"type":"articles","id":"1","attributes":{"title":"A","body":"B"}}}`,
+			b:           `{"data":{"attributes":{"body":"B","title":"A"},"id":"1","type":"articles"}}`,
+		}, {
+			description: "equal numbers",
+			a:           `{"meta":{"count":1}}`,
+			b:           `{"meta":{"count":1.0}}`,
+		}, {
+			description: "equal numbers with exponent",
+			a:           `{"meta":{"count":1200}}`,
+			b:           `{"meta":{"count":1.2e3}}`,
+		}, {
+			description: "large numbers",
+			a:           `{"meta":{"total":9007199254740993}}`,
+			b:           `{"meta":{"total":9007199254740992}}`,
+			expect:      []Difference{{Pointer: "/meta/total", A: json.Number("9007199254740993"), B: json.Number("9007199254740992"), Reason: "values differ"}},
+		}, {
+			description: "included order",
+			a:           `{"data":null,"included":[{"type":"authors","id":"1"},{"type":"comments","id":"1"}]}`,
+			b:           `{"data":null,"included":[{"type":"comments","id":"1"},{"type":"authors","id":"1"}]}`,
+		}, {
+			description: "changed value",
+			a:           `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`,
+			b:           `{"data":{"type":"articles","id":"1","attributes":{"title":"B"}}}`,
+			expect:      []Difference{{Pointer: "/data/attributes/title", A: "A", B: "B", Reason: "values differ"}},
+		}, {
+			description: "missing members",
+			a:           `{"data":{"type":"articles","id":"1","attributes":{"a/b":1}}}`,
+			b:           `{"data":{"type":"articles","id":"1","attributes":{"c~d":2}}}`,
+			expect: []Difference{
+				{Pointer: "/data/attributes/a~1b", A: json.Number("1"), Reason: "member only in a"},
+				{Pointer: "/data/attributes/c~0d", B: json.Number("2"), Reason: "member only in b"},
+			},
+		}, {
+			description: "data order",
+			a:           `{"data":[{"type":"articles","id":"1"},{"type":"articles","id":"2"}]}`,
+			b:           `{"data":[{"type":"articles","id":"2"},{"type":"articles","id":"1"}]}`,
+			expect: []Difference{
+				{Pointer: "/data/0/id", A: "1", B: "2", Reason: "values differ"},
+				{Pointer: "/data/1/id", A: "2", B: "1", Reason: "values differ"},
+			},
+		}, {
+			description: "extra element",
+			a:           `{"data":[{"type":"articles","id":"1"}]}`,
+			b:           `{"data":[{"type":"articles","id":"1"},{"type":"articles","id":"2"}]}`,
+			expect: []Difference{
+				{Pointer: "/data/1", B: map[string]any{"type": "articles", "id": "2"}, Reason: "element only in b"},
+			},
+		}, {
+			description: "changed included resource",
+			a:           `{"data":null,"included":[{"type":"authors","id":"1","attributes":{"name":"A"}},{"type":"comments","id":"1"}]}`,
+			b:           `{"data":null,"included":[{"type":"comments","id":"1"},{"type":"authors","id":"1","attributes":{"name":"B"}}]}`,
+			expect:      []Difference{{Pointer: "/included/0/attributes/name", A: "A", B: "B", Reason: "values differ"}},
+		}, {
+			description: "different included resources",
+			a:           `{"data":null,"included":[{"type":"authors","id":"1"},{"type":"comments","id":"1"}]}`,
+			b:           `{"data":null,"included":[{"type":"comments","id":"1"},{"type":"authors","id":"2"}]}`,
+			expect: []Difference{
+				{Pointer: "/included/0", A: map[string]any{"type": "authors", "id": "1"}, Reason: "resource only in a"},
+				{Pointer: "/included/1", B: map[string]any{"type": "authors", "id": "2"}, Reason: "resource only in b"},
+			},
+		}, {
+			description: "changed type",
+			a:           `{"meta":{"tags":["a"]}}`,
+			b:           `{"meta":{"tags":{"0":"a"}}}`,
+			expect: []Difference{
+				{Pointer: "/meta/tags", A: []any{"a"}, B: map[string]any{"0": "a"}, Reason: "values differ"},
+			},
+		}, {
+			description: "invalid json",
+			a:           `{"data":null}`,
+			b:           `{"data":`,
+			expect:      []Difference{{Reason: "b is not valid json: unexpected end of JSON input"}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			ok, diffs := Equal([]byte(tc.a), []byte(tc.b))
+			is.Equal(t, len(tc.expect) == 0, ok)
+			is.Equal(t, tc.expect, diffs)
+		})
+	}
+}
+
+func TestEqualMarshaled(t *testing.T) {
+	t.Parallel()
+
+	// the included resources are in a different order than in the expected body
+	b, err := Marshal(&articleRelatedComments, MarshalInclude(&authorA, &commentAWithAuthor))
+	is.MustNoError(t, err)
+
+	ok, diffs := Equal([]byte(articleRelatedCommentsNestedWithIncludeBody), b)
+	is.Equal(t, true, ok)
+	is.Equal(t, 0, len(diffs))
+}
+
+func TestDifferenceString(t *testing.T) {
+	t.Parallel()
+
+	is.Equal(t, "/data/id: values differ", Difference{Pointer: "/data/id", Reason: "values differ"}.String())
+	is.Equal(t, "a is not valid json", Difference{Reason: "a is not valid json"}.String())
+}
@@ -0,0 +1,116 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Codes of the error objects created by NewRateLimitError, NewNotFound, NewConflict and
+// NewValidationError, so that clients can tell them apart from other errors of the same status.
+const (
+	// CodeRateLimited indicates that too many requests have been sent.
+	CodeRateLimited = "rate_limited"
+
+	// CodeNotFound indicates that a resource doesn't exist.
+	CodeNotFound = "not_found"
+
+	// CodeConflict indicates that a request conflicts with the current state of a resource.
+	CodeConflict = "conflict"
+
+	// CodeValidationFailed indicates that a resource object is well-formed, but invalid.
+	CodeValidationFailed = "validation_failed"
+)
+
+// NewRateLimitError creates a 429 (Too Many Requests) error object. If retryAfter is positive, it is
+// given in seconds, rounded up, by the retryAfter member of its meta, and WriteError sets the
+// Retry-After header accordingly.
+func NewRateLimitError(retryAfter time.Duration) *Error {
+	e := &Error{
+		Status: Status(http.StatusTooManyRequests),
+		Code:   CodeRateLimited,
+		Title:  http.StatusText(http.StatusTooManyRequests),
+		Detail: "Too many requests have been sent.",
+	}
+	if seconds := int64((retryAfter + time.Second - 1) / time.Second); seconds > 0 {
+		e.Detail = fmt.Sprintf("Too many requests have been sent. Retry after %d seconds.", seconds)
+		e.Meta = map[string]any{"retryAfter": seconds}
+	}
+	return e
+}
+
+// NewNotFound creates a 404 (Not Found) error object for the resource of the given type and id,
+// which are given by the type and id members of its meta.
+func NewNotFound(resourceType, id string) *Error {
+	return &Error{
+		Status: Status(http.StatusNotFound),
+		Code:   CodeNotFound,
+		Title:  http.StatusText(http.StatusNotFound),
+		Detail: fmt.Sprintf("The %q resource with id %q does not exist.", resourceType, id),
+		Meta:   map[string]any{"type": resourceType, "id": id},
+	}
+}
+
+// NewConflict creates a 409 (Conflict) error object with the given detail, pointing to the
+// conflicting member of the request document, e.g. "/data/attributes/slug", unless pointer is empty.
+func NewConflict(pointer, detail string) *Error {
+	return newErrorObject(http.StatusConflict, CodeConflict, pointer, errors.New(detail))
+}
+
+// NewValidationError creates a 422 (Unprocessable Entity) error object with the given detail,
+// pointing to the invalid member of the request document, e.g. "/data/attributes/title", unless
+// pointer is empty.
+func NewValidationError(pointer, detail string) *Error {
+	return newErrorObject(http.StatusUnprocessableEntity, CodeValidationFailed, pointer, errors.New(detail))
+}
+
+// retryAfter returns the Retry-After header value for the error objects created by
+// NewRateLimitError, or an empty string if there are none. The longest delay wins.
+func retryAfter(objects []*Error) string {
+	var longest int64
+	for _, e := range objects {
+		if e.Status == nil || *e.Status != http.StatusTooManyRequests || e.Code != CodeRateLimited {
+			continue
+		}
+		meta, ok := e.Meta.(map[string]any)
+		if !ok {
+			continue
+		}
+		if seconds, ok := retryAfterSeconds(meta["retryAfter"]); ok && seconds > longest {
+			longest = seconds
+		}
+	}
+	if longest == 0 {
+		return ""
+	}
+	return strconv.FormatInt(longest, 10)
+}
+
+// retryAfterSeconds returns the delay in seconds, rounded up, given by the retryAfter meta member v,
+// which is an int64 if set by NewRateLimitError, but may be any integer set by applications, or a
+// float64 or json.Number if unmarshaled.
+func retryAfterSeconds(v any) (int64, bool) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		if math.IsNaN(v) || v > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(math.Ceil(v)), true
+	case json.Number:
+		if seconds, err := v.Int64(); err == nil {
+			return seconds, true
+		}
+		if f, err := v.Float64(); err == nil {
+			return retryAfterSeconds(f)
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,60 @@
+package jsonapi
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestErrorHelpers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       *Error
+		expect      string
+	}{
+		{
+			description: "rate limit",
+			given:       NewRateLimitError(1500 * time.Millisecond),
+			expect:      `{"errors":[{"status":"429","code":"rate_limited","title":"Too Many Requests","detail":"Too many requests have been sent. Retry after 2 seconds.","meta":{"retryAfter":2}}]}`,
+		}, {
+			description: "rate limit without delay",
+			given:       NewRateLimitError(0),
+			expect:      `{"errors":[{"status":"429","code":"rate_limited","title":"Too Many Requests","detail":"Too many requests have been sent."}]}`,
+		}, {
+			description: "not found",
+			given:       NewNotFound("articles", "1"),
+			expect:      `{"errors":[{"status":"404","code":"not_found","title":"Not Found","detail":"The \"articles\" resource with id \"1\" does not exist.","meta":{"type":"articles","id":"1"}}]}`,
+		}, {
+			description: "conflict",
+			given:       NewConflict("/data/attributes/slug", "The slug is taken."),
+			expect:      `{"errors":[{"status":"409","code":"conflict","title":"Conflict","detail":"The slug is taken.","source":{"pointer":"/data/attributes/slug"}}]}`,
+		}, {
+			description: "conflict without pointer",
+			given:       NewConflict("", "The article is locked."),
+			expect:      `{"errors":[{"status":"409","code":"conflict","title":"Conflict","detail":"The article is locked."}]}`,
+		}, {
+			description: "validation",
+			given:       NewValidationError("/data/attributes/title", "The title must not be empty."),
+			expect:      `{"errors":[{"status":"422","code":"validation_failed","title":"Unprocessable Entity","detail":"The title must not be empty.","source":{"pointer":"/data/attributes/title"}}]}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+
+			is.Equal(t, true, HasStatus(tc.given, *tc.given.Status))
+			is.Equal(t, true, HasCode(tc.given, tc.given.Code))
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package jsonapi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// CodeTimeout indicates that a request couldn't be completed in time.
+const CodeTimeout = "timeout"
+
+// ErrorMapper translates Go errors, such as sentinel errors of the standard library or of an
+// application, into error objects. Errors without a mapping are converted by ErrorObjects.
+//
+// An ErrorMapper is used by WriteError when writing to a response given by the MapErrors
+// middleware, and thereby by HandlerFunc, Recover and Server. It must not be modified once in use.
+type ErrorMapper struct {
+	mappings []errorMapping
+}
+
+// errorMapping maps errors matching target to the error object returned by f.
+type errorMapping struct {
+	target error
+	f      func(err error) *Error
+}
+
+// NewErrorMapper creates an ErrorMapper mapping context.DeadlineExceeded to a 504 (Gateway Timeout)
+// error with code CodeTimeout. Errors of other packages are left to be mapped by the application,
+// e.g. sql.ErrNoRows to a 404 (Not Found) error with
+//
+//	m.MapStatus(sql.ErrNoRows, http.StatusNotFound, jsonapi.CodeNotFound)
+func NewErrorMapper() *ErrorMapper {
+	m := new(ErrorMapper)
+	m.MapStatus(context.DeadlineExceeded, http.StatusGatewayTimeout, CodeTimeout)
+	return m
+}
+
+// Map maps errors matching target, as reported by errors.Is, to the error object returned by f.
+// Mappings added later take precedence, which allows overriding those of NewErrorMapper. If f
+// returns nil, the error is mapped by the next matching mapping, if any.
+func (m *ErrorMapper) Map(target error, f func(err error) *Error) {
+	m.mappings = append(m.mappings, errorMapping{target: target, f: f})
+}
+
+// MapStatus maps errors matching target, as reported by errors.Is, to error objects with the given
+// status and code. As done by ErrorObjects for a StatusError, the error message is exposed as
+// detail only if the status is not a 5xx server error.
+func (m *ErrorMapper) MapStatus(target error, status int, code string) {
+	m.Map(target, func(err error) *Error {
+		e := &Error{Status: Status(status), Code: code, Title: http.StatusText(status)}
+		if status < http.StatusInternalServerError {
+			e.Detail = err.Error()
+		}
+		return e
+	})
+}
+
+// ErrorObjects converts err to error objects as done by ErrorObjects, using the mappings of m
+// first. Errors implementing `Unwrap() []error` are converted to one error object per wrapped
+// error.
+func (m *ErrorMapper) ErrorObjects(err error) []*Error {
+	if err == nil {
+		return nil
+	}
+
+	if u, ok := err.(interface{ Unwrap() []error }); ok {
+		objects := make([]*Error, 0)
+		for _, e := range u.Unwrap() {
+			objects = append(objects, m.ErrorObjects(e)...)
+		}
+		return objects
+	}
+
+	for i := len(m.mappings) - 1; i >= 0; i-- {
+		mapping := m.mappings[i]
+		if !errors.Is(err, mapping.target) {
+			continue
+		}
+		if e := mapping.f(err); e != nil {
+			return []*Error{e}
+		}
+	}
+
+	return ErrorObjects(err)
+}
+
+// MapErrors is a middleware having WriteError convert errors with m when writing to the responses
+// of next.
+func MapErrors(next http.Handler, m *ErrorMapper) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&errorMappingWriter{ResponseWriter: w, mapper: m}, r)
+	})
+}
+
+// errorMappingWriter is an http.ResponseWriter carrying the ErrorMapper given by MapErrors.
+type errorMappingWriter struct {
+	http.ResponseWriter
+	mapper *ErrorMapper
+}
+
+// Flush implements the http.Flusher interface if the underlying http.ResponseWriter does.
+func (w *errorMappingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements the http.Hijacker interface, failing if the underlying http.ResponseWriter
+// doesn't.
+func (w *errorMappingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("jsonapi: response writer doesn't support hijacking")
+}
+
+// Unwrap returns the underlying http.ResponseWriter, as used by http.ResponseController.
+func (w *errorMappingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// errorObjectsFor converts err to error objects with the ErrorMapper carried by w or by any
+// http.ResponseWriter it wraps, or with ErrorObjects if there is none.
+func errorObjectsFor(w http.ResponseWriter, err error) []*Error {
+	for w != nil {
+		if mw, ok := w.(*errorMappingWriter); ok {
+			return mw.mapper.ErrorObjects(err)
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	return ErrorObjects(err)
+}
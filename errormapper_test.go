@@ -0,0 +1,116 @@
+package jsonapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+var errArchived = errors.New("article is archived")
+
+func TestErrorMapper(t *testing.T) {
+	t.Parallel()
+
+	m := NewErrorMapper()
+	m.MapStatus(errArchived, http.StatusGone, "archived")
+	m.MapStatus(sql.ErrNoRows, http.StatusNotFound, CodeNotFound)
+	m.Map(sql.ErrNoRows, func(err error) *Error {
+		if err != sql.ErrNoRows {
+			return nil
+		}
+		return NewNotFound("articles", "1")
+	})
+
+	tests := []struct {
+		description string
+		given       error
+		expect      []*Error
+	}{
+		{
+			description: "nil",
+			given:       nil,
+			expect:      nil,
+		}, {
+			description: "deadline exceeded",
+			given:       fmt.Errorf("loading article: %w", context.DeadlineExceeded),
+			expect:      []*Error{{Status: Status(http.StatusGatewayTimeout), Code: CodeTimeout, Title: "Gateway Timeout"}},
+		}, {
+			description: "overridden mapping",
+			given:       sql.ErrNoRows,
+			expect:      []*Error{NewNotFound("articles", "1")},
+		}, {
+			description: "overridden mapping declined",
+			given:       fmt.Errorf("loading article: %w", sql.ErrNoRows),
+			expect:      []*Error{{Status: Status(http.StatusNotFound), Code: CodeNotFound, Title: "Not Found", Detail: "loading article: sql: no rows in result set"}},
+		}, {
+			description: "application error",
+			given:       errorList{errArchived, errors.New("A")},
+			expect: []*Error{
+				{Status: Status(http.StatusGone), Code: "archived", Title: "Gone", Detail: "article is archived"},
+				{Status: Status(http.StatusInternalServerError), Title: "Internal Server Error"},
+			},
+		}, {
+			description: "unmapped error",
+			given:       &Error{Status: Status(http.StatusConflict)},
+			expect:      []*Error{{Status: Status(http.StatusConflict)}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			is.Equal(t, tc.expect, m.ErrorObjects(tc.given))
+		})
+	}
+}
+
+func TestMapErrors(t *testing.T) {
+	t.Parallel()
+
+	m := NewErrorMapper()
+	m.MapStatus(errArchived, http.StatusGone, "archived")
+
+	handler := MapErrors(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("getting article: %w", errArchived)
+	}), m)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/articles/1", nil))
+	is.Equal(t, http.StatusGone, rec.Code)
+	is.EqualJSON(t, `{"errors":[{"status":"410","code":"archived","title":"Gone","detail":"getting article: article is archived"}]}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	is.MustNoError(t, WriteError(rec, errArchived))
+	is.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestMapErrorsResponseWriter(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	handler := MapErrors(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := w.(http.Flusher)
+		is.Equal(t, true, ok)
+		w.(http.Flusher).Flush()
+
+		// httptest.ResponseRecorder can't be hijacked
+		_, _, err := w.(http.Hijacker).Hijack()
+		is.Equal(t, true, err != nil)
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		is.MustEqual(t, true, ok)
+		is.Equal(t, http.ResponseWriter(rec), u.Unwrap())
+	}), NewErrorMapper())
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/articles/1", nil))
+	is.Equal(t, true, rec.Flushed)
+}
@@ -1,18 +1,35 @@
 package jsonapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"reflect"
 	"sort"
 	"strings"
 )
 
+// The following errors may be wrapped in a DocumentError, ResourceError or FieldError by Unmarshal,
+// so comparing errors to them with == is deprecated in favor of errors.Is: code such as
+//
+//	if err == jsonapi.ErrMissingDataField {
+//
+// must become
+//
+//	if errors.Is(err, jsonapi.ErrMissingDataField) {
+//
+// or check the code of the wrapping error with HasCode, e.g. HasCode(err, CodeMissingData). Errors
+// returned as is by earlier versions which are now always wrapped are marked as deprecated.
 var (
 	// ErrMarshalInvalidPrimaryField indicates that the id (primary) fields was invalid.
 	ErrMarshalInvalidPrimaryField = errors.New("primary/id field must be a string or implement fmt.Stringer or in a struct which implements MarshalIdentifier")
 
 	// ErrUnmarshalInvalidPrimaryField indicates that the id (primary) fields was invalid.
+	//
+	// Deprecated: Unmarshal wraps it in a FieldError with code CodeInvalidID, so it can't be
+	// compared with ==. Use errors.Is or HasCode(err, CodeInvalidID) instead.
 	ErrUnmarshalInvalidPrimaryField = errors.New("primary/id field must be a string or in a struct which implements UnmarshalIdentifer")
 
 	// ErrUnmarshalDuplicatePrimaryField indicates that the id (primary) field is duplicated in a struct.
@@ -28,16 +45,109 @@ var (
 	ErrMissingLinkFields = errors.New("at least one of Links.Self or Links.Related must be set to a nonempty string or *LinkObject")
 
 	// ErrMissingDataField indicates that a *jsonapi.document is missing data in an invalid way
+	//
+	// Deprecated: Unmarshal wraps it in a DocumentError with code CodeMissingData, so it can't be
+	// compared with ==. Use errors.Is or HasCode(err, CodeMissingData) instead.
 	ErrMissingDataField = errors.New("document is missing a required top-level or relationship-level data member")
 
 	// ErrInvalidDataField indicates that a data field for primary data or relationship resource linkage is an empty object {}
+	//
+	// Deprecated: Unmarshal wraps it in a DocumentError with code CodeInvalidData, so it can't be
+	// compared with ==. Use errors.Is or HasCode(err, CodeInvalidData) instead.
 	ErrInvalidDataField = errors.New("data fields cannot be represented as an empty object")
+
+	// ErrDataAndErrorsFields indicates that a document contains both the data and errors top-level members.
+	//
+	// Deprecated: Unmarshal wraps it in a DocumentError with code CodeInvalidData, so it can't be
+	// compared with ==. Use errors.Is or HasCode(err, CodeInvalidData) instead.
+	ErrDataAndErrorsFields = errors.New("the members data and errors must not coexist in the same document")
+
+	// ErrDuplicateMember indicates that an object of a document has several members of the same
+	// name, as rejected by UnmarshalRejectDuplicateMembers.
+	ErrDuplicateMember = errors.New("duplicate member name")
+
+	// ErrMissingErrorsField indicates that a document unmarshaled by UnmarshalErrors is not an error
+	// document, i.e. it has no errors member.
+	ErrMissingErrorsField = errors.New("document is missing the top-level errors member")
+
+	// ErrMissingTypeField indicates that a resource object or resource identifier has no type.
+	ErrMissingTypeField = errors.New("resource objects must have a non-empty type member")
+
+	// ErrUnknownRelationship indicates that a resource has no relationship of the given name.
+	ErrUnknownRelationship = errors.New("resource has no relationship of the given name")
+
+	// ErrResourceIdentifierOnly indicates that resource linkage contains a resource object with
+	// members other than type, id and meta.
+	ErrResourceIdentifierOnly = errors.New("resource linkage must only contain resource identifier objects")
+
+	// ErrRelationshipUpdateMethod indicates that a relationship update uses a method other than
+	// PATCH, POST or DELETE.
+	ErrRelationshipUpdateMethod = errors.New("relationship updates must use the PATCH, POST or DELETE method")
+
+	// ErrReadOnlyAttribute indicates that a document sets an attribute which is read-only, as given
+	// by the readonly option of its jsonapi struct tag.
+	ErrReadOnlyAttribute = errors.New("attribute is read-only")
+
+	// ErrIncludedResourceNotFound indicates that a resource is not included in a compound document.
+	ErrIncludedResourceNotFound = errors.New("resource is not included in the document")
+
+	// ErrNilInput indicates that the value given to Marshal is nil, a nil pointer or a nil slice, and
+	// MarshalRejectNil is used.
+	ErrNilInput = errors.New("marshaled value must not be nil")
+
+	// ErrNilResource indicates that a collection, relationship or list of included resources contains
+	// a nil resource, or that an error object is nil.
+	ErrNilResource = errors.New("resource objects and error objects must not be nil")
+
+	// ErrUnmarshalInvalidTarget indicates that the value given to Unmarshal is not a non-nil pointer.
+	// It is wrapped in a TypeError.
+	ErrUnmarshalInvalidTarget = errors.New("unmarshal target must be a non-nil pointer")
+
+	// ErrDiffResourceMismatch indicates that the versions given to Diff aren't versions of the same
+	// resource, i.e. that their types or ids differ.
+	ErrDiffResourceMismatch = errors.New("only versions of the same resource can be diffed")
+
+	// ErrIdentifierConflict indicates that RewriteIdentifiers rewrote the identifiers of distinct
+	// resources to the same type and id.
+	ErrIdentifierConflict = errors.New("distinct resources must not be rewritten to the same type and id")
+
+	// ErrDuplicateResourceType indicates that a TypeRegistry was given several Go types for the same
+	// resource type.
+	ErrDuplicateResourceType = errors.New("resource type is registered more than once")
+
+	// ErrTypeConflict indicates that the type of a resource object doesn't match the resource type
+	// it is unmarshaled into. It is wrapped in a TypeError, and converted to an error object with
+	// status 409 (Conflict) as required by https://jsonapi.org/format/#crud-creating-responses-409.
+	ErrTypeConflict = errors.New("resource object type conflicts with the expected resource type")
+
+	// ErrInvalidPatchData indicates that the primary data of a document given to UnmarshalPatch is not
+	// a single resource object.
+	ErrInvalidPatchData = errors.New("patch documents must have a single resource object as primary data")
+
+	// ErrInvalidBulkData indicates that the primary data of a document given to UnmarshalMany is not
+	// an array of resource objects.
+	ErrInvalidBulkData = errors.New("bulk documents must have an array of resource objects as primary data")
+
+	// ErrInvalidTimeFormat indicates that a TimeFormat given via MarshalTimeFormat or
+	// UnmarshalTimeFormat is unknown.
+	ErrInvalidTimeFormat = errors.New("invalid time format")
+
+	// ErrInvalidJSONAPIObject indicates that the jsonapi object given via MarshalJSONAPIObject is
+	// invalid.
+	ErrInvalidJSONAPIObject = errors.New("invalid jsonapi object")
+
+	// ErrPaginationCycle indicates that the next link of a page iterated by Pages points to a page
+	// which has already been fetched.
+	ErrPaginationCycle = errors.New("next link points to a page which has already been fetched")
 )
 
 // TypeError indicates that an unexpected type was encountered.
 type TypeError struct {
 	Actual   string
 	Expected []string
+
+	// err is the sentinel error identifying the TypeError, if any (e.g. ErrUnmarshalInvalidTarget)
+	err error
 }
 
 // Error implements the error interface.
@@ -48,6 +158,11 @@ func (e *TypeError) Error() string {
 	return fmt.Sprintf("got type %q expected %q", e.Actual, e.Expected[0])
 }
 
+// Unwrap returns the sentinel error identifying e, if any.
+func (e *TypeError) Unwrap() error {
+	return e.err
+}
+
 // TagError indicates that an invalid struct tag was encountered.
 type TagError struct {
 	TagName string
@@ -62,37 +177,434 @@ func (e *TagError) Error() string {
 
 // PartialLinkageError indicates that an incomplete relationship chain was encountered.
 type PartialLinkageError struct {
-	invalidResources []string
+	// Resources identifies the included resources which have no chain of relationships from
+	// primary data, sorted by type and id.
+	Resources []ResourceIdentifier
 }
 
 // Error implements the error interface.
 func (e *PartialLinkageError) Error() string {
-	sort.Strings(e.invalidResources)
+	invalidResources := make([]string, len(e.Resources))
+	for i, r := range e.Resources {
+		invalidResources[i] = resourceIdentifier(r.Type, r.ID)
+	}
+	sort.Strings(invalidResources)
 	return fmt.Sprintf(
 		"the following resources have no chain of relationships from primary data: %q",
-		strings.Join(e.invalidResources, ","),
+		strings.Join(invalidResources, ","),
 	)
 }
 
+// allowPartialLinkage returns err unless it is a *PartialLinkageError and partial linkage is
+// allowed, in which case it is passed to the given handler, if any.
+func allowPartialLinkage(err error, allowed bool, handler func(err *PartialLinkageError)) error {
+	var ple *PartialLinkageError
+	if !allowed || !errors.As(err, &ple) {
+		return err
+	}
+	if handler != nil {
+		handler(ple)
+	}
+	return nil
+}
+
+// IncludePathError indicates that an include path could not be followed.
+type IncludePathError struct {
+	Path   string
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *IncludePathError) Error() string {
+	return fmt.Sprintf("invalid include path %q: %s", e.Path, e.Reason)
+}
+
 // MemberNameValidationError indicates that a document member name failed a validation step.
 type MemberNameValidationError struct {
 	MemberName string
+
+	// Field identifies the struct field the member was marshaled from (e.g. Article.Title), if
+	// known, so that invalid struct tags can be found.
+	Field string
+
+	// Pointer is a JSON pointer (RFC 6901) to the offending member, if known.
+	Pointer string
 }
 
 // Error implements the error interface.
 func (e *MemberNameValidationError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("invalid member name: %s (field %s)", e.MemberName, e.Field)
+	}
 	return fmt.Sprintf("invalid member name: %s", e.MemberName)
 }
 
+func (e *MemberNameValidationError) mapPointer(f func(pointer string) string) {
+	e.Pointer = f(e.Pointer)
+}
+
+// Codes identifying the DocumentError, ResourceError and FieldError values returned by this package,
+// which are used as Error.Code when converting them to error objects.
+const (
+	// CodeMissingData indicates that a document is missing its data member.
+	CodeMissingData = "missing_data"
+
+	// CodeInvalidData indicates that a document's data member is invalid.
+	CodeInvalidData = "invalid_data"
+
+	// CodeInvalidResource indicates that a resource object is invalid.
+	CodeInvalidResource = "invalid_resource"
+
+	// CodeInvalidType indicates that a resource object's type is invalid.
+	CodeInvalidType = "invalid_type"
+
+	// CodeInvalidID indicates that a resource object's id is invalid.
+	CodeInvalidID = "invalid_id"
+
+	// CodeInvalidAttribute indicates that a resource object's attribute is invalid.
+	CodeInvalidAttribute = "invalid_attribute"
+
+	// CodeInvalidRelationship indicates that a resource object's relationship is invalid.
+	CodeInvalidRelationship = "invalid_relationship"
+
+	// CodeInvalidMeta indicates that a meta object is invalid.
+	CodeInvalidMeta = "invalid_meta"
+
+	// CodeInvalidExtension indicates that an extension member is invalid.
+	CodeInvalidExtension = "invalid_extension"
+)
+
+// pointerError is implemented by errors carrying a JSON pointer to the offending document member.
+type pointerError interface {
+	error
+	mapPointer(f func(pointer string) string)
+}
+
+// mapPointers replaces the JSON pointers of all errors in err's chain with the result of f.
+func mapPointers(err error, f func(pointer string) string) error {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if pe, ok := e.(pointerError); ok {
+			pe.mapPointer(f)
+		}
+	}
+	return err
+}
+
+// prefixPointer prepends prefix to the JSON pointers of all errors in err's chain.
+func prefixPointer(err error, prefix string) error {
+	return mapPointers(err, func(pointer string) string {
+		return prefix + pointer
+	})
+}
+
+// DocumentError indicates that a document is invalid as a whole, e.g. it has no primary data.
+//
+// For compatibility with errors returned by previous versions of this package, its message is the
+// message of the wrapped error.
+type DocumentError struct {
+	// Code identifies the error, e.g. CodeMissingData.
+	Code string
+
+	// Pointer is a JSON pointer (RFC 6901) to the offending member of the document, if any.
+	Pointer string
+
+	// Offset is the byte offset of the offending member in the unmarshaled document, or 0 if
+	// unknown.
+	Offset int64
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *DocumentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DocumentError) mapPointer(f func(pointer string) string) {
+	e.Pointer = f(e.Pointer)
+}
+
+func (e *DocumentError) locate(f func(pointer string) int64) {
+	e.Offset = f(e.Pointer)
+}
+
+// ErrorObject converts e to an error object with status 400 (Bad Request).
+func (e *DocumentError) ErrorObject() *Error {
+	return newBadRequestError(e.Code, e.Pointer, e.Err)
+}
+
+// ResourceError indicates that a resource object within a document is invalid.
+//
+// For compatibility with errors returned by previous versions of this package, its message is the
+// message of the wrapped error.
+type ResourceError struct {
+	// Code identifies the error, e.g. CodeInvalidResource.
+	Code string
+
+	// Type and ID identify the offending resource object.
+	Type string
+	ID   string
+
+	// Pointer is a JSON pointer (RFC 6901) to the offending resource object.
+	Pointer string
+
+	// Offset is the byte offset of the offending member in the unmarshaled document, or 0 if
+	// unknown.
+	Offset int64
+
+	// Err is the underlying error, which may be a FieldError.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ResourceError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ResourceError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ResourceError) mapPointer(f func(pointer string) string) {
+	e.Pointer = f(e.Pointer)
+}
+
+func (e *ResourceError) locate(f func(pointer string) int64) {
+	e.Offset = f(e.Pointer)
+}
+
+// ErrorObject converts e to an error object with status 400 (Bad Request).
+func (e *ResourceError) ErrorObject() *Error {
+	return newBadRequestError(e.Code, e.Pointer, e.Err)
+}
+
+// FieldError indicates that a member of a resource object (e.g. an attribute) is invalid.
+//
+// For compatibility with errors returned by previous versions of this package, its message is the
+// message of the wrapped error.
+type FieldError struct {
+	// Code identifies the error, e.g. CodeInvalidAttribute.
+	Code string
+
+	// Member is the name of the offending member, e.g. the attribute name.
+	Member string
+
+	// Pointer is a JSON pointer (RFC 6901) to the offending member.
+	Pointer string
+
+	// Offset is the byte offset of the offending member in the unmarshaled document, or 0 if
+	// unknown.
+	Offset int64
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FieldError) mapPointer(f func(pointer string) string) {
+	e.Pointer = f(e.Pointer)
+}
+
+func (e *FieldError) locate(f func(pointer string) int64) {
+	e.Offset = f(e.Pointer)
+}
+
+// ErrorObject converts e to an error object with status 400 (Bad Request), or 409 (Conflict) if it
+// wraps ErrTypeConflict.
+func (e *FieldError) ErrorObject() *Error {
+	if errors.Is(e.Err, ErrTypeConflict) {
+		return newErrorObject(http.StatusConflict, e.Code, e.Pointer, e.Err)
+	}
+	return newBadRequestError(e.Code, e.Pointer, e.Err)
+}
+
+func newBadRequestError(code, pointer string, err error) *Error {
+	return newErrorObject(http.StatusBadRequest, code, pointer, err)
+}
+
+func newErrorObject(status int, code, pointer string, err error) *Error {
+	e := &Error{
+		Status: Status(status),
+		Code:   code,
+		Title:  http.StatusText(status),
+		Detail: err.Error(),
+	}
+	if pointer != "" {
+		e.Source = &ErrorSource{Pointer: pointer}
+	}
+	return e
+}
+
+// StatusError can be implemented by errors to be converted to error objects with a specific HTTP
+// status code by ErrorObjects.
+type StatusError interface {
+	error
+	Status() int
+}
+
+// ErrorObjects converts err to error objects which can be marshaled as an error document.
+//
+// If err is or wraps an ErrorList or *Error, its error objects are returned as is, and a *Problem or
+// *LimitError is converted with its ErrorObject method. If err is or wraps a StatusError, it is converted to an
+// error object with its status code, exposing its message as detail only if the status is not a 5xx
+// server error. If err is or wraps a FieldError, ResourceError or DocumentError (in that order of
+// precedence) it is converted with its ErrorObject method. Errors from decoding invalid json are
+// converted to 400 (Bad Request) errors. Any other error is converted to a 500 (Internal Server
+// Error) error which doesn't expose its message. Errors implementing `Unwrap() []error` are
+// converted to one error object per wrapped error.
+func ErrorObjects(err error) []*Error {
+	if err == nil {
+		return nil
+	}
+
+	if u, ok := err.(interface{ Unwrap() []error }); ok {
+		objects := make([]*Error, 0)
+		for _, e := range u.Unwrap() {
+			objects = append(objects, ErrorObjects(e)...)
+		}
+		return objects
+	}
+
+	var (
+		el ErrorList
+		e  *Error
+		p  *Problem
+		se StatusError
+		fe *FieldError
+		re *ResourceError
+		de *DocumentError
+		le *LimitError
+	)
+	switch {
+	case errors.As(err, &el):
+		return el
+	case errors.As(err, &e):
+		return []*Error{e}
+	case errors.As(err, &p):
+		return []*Error{p.ErrorObject()}
+	case errors.As(err, &le):
+		return []*Error{le.ErrorObject()}
+	case errors.As(err, &se):
+		status := se.Status()
+		obj := &Error{Status: Status(status), Title: http.StatusText(status)}
+		if status < http.StatusInternalServerError {
+			obj.Detail = err.Error()
+		}
+		return []*Error{obj}
+	case errors.As(err, &fe):
+		return []*Error{fe.ErrorObject()}
+	case errors.As(err, &re):
+		return []*Error{re.ErrorObject()}
+	case errors.As(err, &de):
+		return []*Error{de.ErrorObject()}
+	case isJSONDecodeError(err):
+		return []*Error{newBadRequestError("", "", err)}
+	}
+
+	return []*Error{{
+		Status: Status(http.StatusInternalServerError),
+		Title:  http.StatusText(http.StatusInternalServerError),
+	}}
+}
+
 // ErrorLink represents a JSON:API error links object as defined by https://jsonapi.org/format/1.0/#error-objects.
+// Type is defined by https://jsonapi.org/format/1.1/#error-objects.
+//
+// About and Type must be a string or *LinkObject. About typically links to a human-readable page
+// documenting the error's code, and Type to a page documenting the class of errors it belongs to.
 type ErrorLink struct {
 	About any `json:"about,omitempty"`
+	Type  any `json:"type,omitempty"`
+}
+
+// check returns an error if a link of l is neither a string nor a *LinkObject, and clears empty
+// links to satisfy omitempty.
+func (l *ErrorLink) check() error {
+	aboutIsEmpty, err := checkLinkValue(l.About)
+	if err != nil {
+		return err
+	}
+	if aboutIsEmpty {
+		l.About = nil
+	}
+
+	typeIsEmpty, err := checkLinkValue(l.Type)
+	if err != nil {
+		return err
+	}
+	if typeIsEmpty {
+		l.Type = nil
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Links given as link objects are
+// unmarshaled as *LinkObject.
+func (l *ErrorLink) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		About json.RawMessage `json:"about"`
+		Type  json.RawMessage `json:"type"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if l.About, err = unmarshalLinkValue(aux.About, "links.about"); err != nil {
+		return err
+	}
+	l.Type, err = unmarshalLinkValue(aux.Type, "links.type")
+	return err
+}
+
+// unmarshalLinkValue unmarshals the given link, which must be null, a string or a link object,
+// returning nil, a string or a *LinkObject respectively.
+func unmarshalLinkValue(data json.RawMessage, field string) (any, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	switch jsonKindOf(data) {
+	case "null":
+		return nil, nil
+	case "string":
+		var s string
+		err := json.Unmarshal(data, &s)
+		return s, err
+	case "object":
+		var lo LinkObject
+		if err := json.Unmarshal(data, &lo); err != nil {
+			return nil, err
+		}
+		return &lo, nil
+	default:
+		return nil, &json.UnmarshalTypeError{Value: jsonKindOf(data), Type: reflect.TypeOf(""), Field: field}
+	}
 }
 
 // ErrorSource represents a JSON:API Error.Source as defined by https://jsonapi.org/format/1.0/#error-objects.
+// Header is defined by https://jsonapi.org/format/1.1/#error-objects.
 type ErrorSource struct {
 	Pointer   string `json:"pointer,omitempty"`
 	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
 }
 
 // Status provides a helper for setting an Error.Status value.
@@ -129,7 +641,128 @@ func (e *Error) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// UnmarshalJSON implements the json.Unmarshaler interface. The status member may be given as a
+// string, as defined by the specification, or as a number.
+func (e *Error) UnmarshalJSON(data []byte) error {
+	type alias Error
+	aux := &struct {
+		Status json.RawMessage `json:"status,omitempty"`
+		*alias
+	}{
+		alias: (*alias)(e),
+	}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	if len(aux.Status) == 0 || string(aux.Status) == "null" {
+		return nil
+	}
+	var status json.Number
+	if err := json.Unmarshal(bytes.Trim(aux.Status, `"`), &status); err != nil {
+		return &json.UnmarshalTypeError{Value: string(aux.Status), Type: reflect.TypeOf(0), Field: "status"}
+	}
+	s, err := status.Int64()
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: string(aux.Status), Type: reflect.TypeOf(0), Field: "status"}
+	}
+	e.Status = Status(int(s))
+
+	return nil
+}
+
 // Error implements the error interface.
 func (e *Error) Error() string {
 	return fmt.Sprintf("%s: %s", e.Title, e.Detail)
 }
+
+// Is reports whether e matches target, which must be an *Error whose status and code are either
+// unset or equal to those of e. This allows matching error objects with errors.Is, e.g.
+// errors.Is(err, &jsonapi.Error{Status: jsonapi.Status(http.StatusNotFound)}).
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t.Status == nil && t.Code == "" {
+		return false
+	}
+	if t.Status != nil && (e.Status == nil || *e.Status != *t.Status) {
+		return false
+	}
+	return t.Code == "" || e.Code == t.Code
+}
+
+// ErrorList is a list of error objects, e.g. the errors of an error document, which can be returned
+// as a single error. Unmarshal returns the error objects of error documents as an ErrorList, unless
+// they are unmarshaled into an ErrorList or []*Error.
+//
+// ErrorList unwraps to its error objects, so they can be inspected with errors.Is, errors.As,
+// HasStatus and HasCode.
+type ErrorList []*Error
+
+// Error implements the error interface.
+func (l ErrorList) Error() string {
+	if len(l) == 0 {
+		return "jsonapi: empty error list"
+	}
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the error objects of the list.
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, err := range l {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Is reports whether any error object of the list matches target, so that errors.Is inspects them
+// with versions of Go which don't unwrap errors implementing `Unwrap() []error` (before Go 1.20).
+func (l ErrorList) Is(target error) bool {
+	for _, err := range l {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error object of the list matching target, so that errors.As inspects them
+// with versions of Go which don't unwrap errors implementing `Unwrap() []error` (before Go 1.20).
+func (l ErrorList) As(target any) bool {
+	for _, err := range l {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns the HTTP status code for the error objects of the list, implementing
+// StatusError. It is their common status code if they have one, otherwise 400 (Bad Request) if
+// they are all 4xx client errors, or 500 (Internal Server Error).
+func (l ErrorList) Status() int {
+	if len(l) == 0 {
+		return http.StatusInternalServerError
+	}
+	return errorsStatus(l)
+}
+
+// HasStatus reports whether err is or wraps an error object (e.g. within an ErrorList or
+// ResponseError) or StatusError with the given HTTP status code.
+func HasStatus(err error, status int) bool {
+	if errors.Is(err, &Error{Status: Status(status)}) {
+		return true
+	}
+	var se StatusError
+	return errors.As(err, &se) && se.Status() == status
+}
+
+// HasCode reports whether err is or wraps an error object (e.g. within an ErrorList or
+// ResponseError) with the given application-specific error code.
+func HasCode(err error, code string) bool {
+	return errors.Is(err, &Error{Code: code})
+}
@@ -0,0 +1,226 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestUnmarshalStructuredErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description   string
+		given         string
+		many          bool
+		expectCode    string
+		expectStatus  int
+		expectPointer string
+		expectIs      error
+	}{
+		{
+			description:   "missing data",
+			given:         `{}`,
+			expectCode:    CodeMissingData,
+			expectPointer: "",
+			expectIs:      ErrMissingDataField,
+		}, {
+			description:   "invalid data",
+			given:         `{"data":{}}`,
+			expectCode:    CodeInvalidData,
+			expectPointer: "/data",
+			expectIs:      ErrInvalidDataField,
+		}, {
+			description:   "wrong type",
+			given:         `{"data":{"id":"1","type":"comments","attributes":{"title":"A"}}}`,
+			expectCode:    CodeInvalidType,
+			expectStatus:  http.StatusConflict,
+			expectPointer: "/data/type",
+			expectIs:      ErrTypeConflict,
+		}, {
+			description:   "invalid attribute",
+			given:         `{"data":{"id":"1","type":"articles","attributes":{"title":1}}}`,
+			expectCode:    CodeInvalidAttribute,
+			expectPointer: "/data/attributes/title",
+		}, {
+			description:   "invalid attribute in collection",
+			given:         `{"data":[{"id":"1","type":"articles","attributes":{"title":"A"}},{"id":"2","type":"articles","attributes":{"title":1}}]}`,
+			many:          true,
+			expectCode:    CodeInvalidAttribute,
+			expectPointer: "/data/1/attributes/title",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var err error
+			if tc.many {
+				var articles []Article
+				err = Unmarshal([]byte(tc.given), &articles)
+			} else {
+				var article Article
+				err = Unmarshal([]byte(tc.given), &article)
+			}
+
+			if tc.expectIs != nil {
+				is.Equal(t, true, errors.Is(err, tc.expectIs))
+			}
+
+			objects := ErrorObjects(err)
+			is.MustEqual(t, 1, len(objects))
+			is.Equal(t, tc.expectCode, objects[0].Code)
+			expectStatus := tc.expectStatus
+			if expectStatus == 0 {
+				expectStatus = http.StatusBadRequest
+			}
+			is.Equal(t, expectStatus, int(*objects[0].Status))
+			if tc.expectPointer == "" {
+				is.Nil(t, objects[0].Source)
+				return
+			}
+			is.MustEqual(t, false, objects[0].Source == nil)
+			is.Equal(t, tc.expectPointer, objects[0].Source.Pointer)
+		})
+	}
+}
+
+func TestUnmarshalResourceError(t *testing.T) {
+	t.Parallel()
+
+	var article Article
+	err := Unmarshal([]byte(`{"data":{"id":"1","type":"articles","attributes":{"title":1}}}`), &article)
+
+	var re *ResourceError
+	is.MustEqual(t, true, errors.As(err, &re))
+	is.Equal(t, "articles", re.Type)
+	is.Equal(t, "1", re.ID)
+	is.Equal(t, "/data", re.Pointer)
+
+	var fe *FieldError
+	is.MustEqual(t, true, errors.As(err, &fe))
+	is.Equal(t, "title", fe.Member)
+}
+
+func TestErrorObjects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       error
+		expect      []*Error
+	}{
+		{
+			description: "nil",
+			given:       nil,
+			expect:      nil,
+		}, {
+			description: "*Error",
+			given:       fmt.Errorf("wrapped: %w", &errorsSimpleStruct),
+			expect:      []*Error{&errorsSimpleStruct},
+		}, {
+			description: "FieldError",
+			given:       &FieldError{Code: CodeInvalidID, Member: "id", Pointer: "/data/id", Err: ErrUnmarshalInvalidPrimaryField},
+			expect: []*Error{{
+				Status: Status(http.StatusBadRequest),
+				Code:   CodeInvalidID,
+				Title:  http.StatusText(http.StatusBadRequest),
+				Detail: ErrUnmarshalInvalidPrimaryField.Error(),
+				Source: &ErrorSource{Pointer: "/data/id"},
+			}},
+		}, {
+			description: "unknown error",
+			given:       errors.New("secret"),
+			expect: []*Error{{
+				Status: Status(http.StatusInternalServerError),
+				Title:  http.StatusText(http.StatusInternalServerError),
+			}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			is.Equal(t, tc.expect, ErrorObjects(tc.given))
+		})
+	}
+}
+
+func TestErrorList(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"errors":[{"status":"404","code":"not_found","title":"Not Found","detail":"article 1"},{"status":"422","title":"Unprocessable Entity"}]}`)
+
+	t.Run("unmarshal into error list", func(t *testing.T) {
+		t.Parallel()
+
+		var list ErrorList
+		is.MustNoError(t, Unmarshal(body, &list))
+		is.MustEqual(t, 2, len(list))
+		is.Equal(t, "not_found", list[0].Code)
+
+		var objects []*Error
+		is.MustNoError(t, Unmarshal(body, &objects))
+		is.Equal(t, []*Error(list), objects)
+	})
+
+	t.Run("unmarshal into resource", func(t *testing.T) {
+		t.Parallel()
+
+		var a Article
+		err := Unmarshal(body, &a)
+		is.MustError(t, err)
+		is.Equal(t, "Not Found: article 1; Unprocessable Entity: ", err.Error())
+
+		var list ErrorList
+		is.MustEqual(t, true, errors.As(err, &list))
+		is.Equal(t, http.StatusBadRequest, list.Status())
+
+		var e *Error
+		is.MustEqual(t, true, errors.As(err, &e))
+		is.Equal(t, "not_found", e.Code)
+	})
+
+	t.Run("matching", func(t *testing.T) {
+		t.Parallel()
+
+		var a Article
+		err := fmt.Errorf("get article: %w", Unmarshal(body, &a))
+
+		tests := []struct {
+			description string
+			match       bool
+			expect      bool
+		}{
+			{description: "status", match: HasStatus(err, http.StatusNotFound), expect: true},
+			{description: "other status", match: HasStatus(err, http.StatusUnprocessableEntity), expect: true},
+			{description: "list status", match: HasStatus(err, http.StatusBadRequest), expect: true},
+			{description: "missing status", match: HasStatus(err, http.StatusConflict), expect: false},
+			{description: "code", match: HasCode(err, "not_found"), expect: true},
+			{description: "missing code", match: HasCode(err, "conflict"), expect: false},
+			{description: "status and code", match: errors.Is(err, &Error{Status: Status(http.StatusNotFound), Code: "not_found"}), expect: true},
+			{description: "mismatched status and code", match: errors.Is(err, &Error{Status: Status(http.StatusUnprocessableEntity), Code: "not_found"}), expect: false},
+			{description: "empty target", match: errors.Is(err, &Error{}), expect: false},
+			{description: "nil error", match: HasStatus(nil, http.StatusNotFound), expect: false},
+		}
+		for _, tc := range tests {
+			is.Equal(t, tc.expect, tc.match)
+		}
+	})
+
+	t.Run("error objects", func(t *testing.T) {
+		t.Parallel()
+
+		list := ErrorList{&errorsSimpleStruct, &errorsComplexStruct}
+		is.Equal(t, []*Error(list), ErrorObjects(fmt.Errorf("wrapped: %w", list)))
+	})
+}
@@ -0,0 +1,202 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// MarshalExtensions registers the namespaces of the extensions applied to documents, e.g. atomic or
+// version, as defined by https://jsonapi.org/format/1.1/#extensions. Member names prefixed with a
+// registered namespace (e.g. version:id) then pass member name validation anywhere in the
+// document, so they can be marshaled from extension fields, MarshalExtensionMembers and
+// RelationshipExtensionsMarshaler. Namespaces must only contain ASCII letters and digits.
+func MarshalExtensions(namespaces ...string) MarshalOption {
+	return func(m *Marshaler) {
+		m.extensions = newExtensionNamespaces(namespaces)
+	}
+}
+
+// MarshalExtensionMembers includes the members of the given map or struct as top-level members of
+// the document when marshaling, e.g. a struct with a field tagged `json:"version:id"`. Every member
+// must be named namespace:member with a namespace registered via MarshalExtensions.
+func MarshalExtensionMembers(v any) MarshalOption {
+	return func(m *Marshaler) {
+		m.extensionMembers = v
+	}
+}
+
+// UnmarshalExtensions registers the namespaces of the extensions accepted in documents, e.g. atomic
+// or version, as defined by https://jsonapi.org/format/1.1/#extensions. Member names prefixed with
+// a registered namespace then pass member name validation anywhere in the document, so they can be
+// unmarshaled into extension fields, UnmarshalExtensionMembers and
+// RelationshipExtensionsUnmarshaler. Members of other namespaces are rejected, unless member name
+// validation is disabled.
+func UnmarshalExtensions(namespaces ...string) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.extensions = newExtensionNamespaces(namespaces)
+	}
+}
+
+// UnmarshalExtensionMembers decodes the top-level members of the document named with a namespace
+// registered via UnmarshalExtensions into the given map or struct when unmarshaling.
+func UnmarshalExtensionMembers(v any) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.extensionMembers = v
+	}
+}
+
+// RelationshipExtensionsMarshaler can be implemented by resources to add extension members to the
+// relationship objects of their relationships.
+type RelationshipExtensionsMarshaler interface {
+	// MarshalRelationshipExtensions returns the extension members (a map or struct, or nil) of the
+	// relationship object of the relationship named relation. Every member must be named
+	// namespace:member with a namespace registered via MarshalExtensions.
+	MarshalRelationshipExtensions(relation string) any
+}
+
+// RelationshipExtensionsUnmarshaler can be implemented by resources to unmarshal the extension
+// members of the relationship objects of their relationships.
+type RelationshipExtensionsUnmarshaler interface {
+	// UnmarshalRelationshipExtensions is called with the json encoding of an object holding the
+	// members of the relationship object of the relationship named relation whose namespace is
+	// registered via UnmarshalExtensions, for each relationship object having such members.
+	UnmarshalRelationshipExtensions(relation string, members []byte) error
+}
+
+// makeExtensionMembers returns the members of the json encoding of the map or struct v, which must
+// all be extension members of a registered namespace.
+func (m *Marshaler) makeExtensionMembers(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if err := checkMeta(v); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]rawValue
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	members := make(map[string]any, len(raw))
+	for name, value := range raw {
+		if !m.extensions.isExtensionMemberName(name, m.memberNameValidationMode) {
+			return nil, &MemberNameValidationError{MemberName: name, Pointer: "/" + escapePointerToken(name)}
+		}
+		members[name] = value
+	}
+	return members, nil
+}
+
+// addExtensions sets the extension members of the relationship document of v named relation, if v
+// implements RelationshipExtensionsMarshaler.
+func (d *document) addExtensions(v any, relation string, m *Marshaler) error {
+	vm, ok := v.(RelationshipExtensionsMarshaler)
+	if !ok {
+		return nil
+	}
+	extensions, err := m.makeExtensionMembers(vm.MarshalRelationshipExtensions(relation))
+	if err != nil {
+		return err
+	}
+	if d.extensions == nil {
+		d.extensions = extensions
+		return nil
+	}
+	// keep the extension members of top-level relationship documents given via MarshalExtensionMembers
+	for name, value := range extensions {
+		d.extensions[name] = value
+	}
+	return nil
+}
+
+// unmarshalExtensions passes the extension members of the relationship document of v named
+// relation to v, if it implements RelationshipExtensionsUnmarshaler.
+func (d *document) unmarshalExtensions(v any, relation string, m *Unmarshaler) error {
+	vu, ok := v.(RelationshipExtensionsUnmarshaler)
+	if !ok {
+		return nil
+	}
+	b, ok, err := m.extensionMembersJSON(d.raw)
+	if err != nil || !ok {
+		return err
+	}
+	if err := vu.UnmarshalRelationshipExtensions(relation, b); err != nil {
+		return &DocumentError{Code: CodeInvalidExtension, Err: err}
+	}
+	return nil
+}
+
+// members returns the members of the json object data named with a namespace registered in ns.
+func (ns extensionNamespaces) members(data []byte) (map[string]rawValue, error) {
+	var raw map[string]rawValue
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	members := make(map[string]rawValue)
+	for name, value := range raw {
+		if ns.isExtensionMemberName(name, disableValidation) {
+			members[name] = value
+		}
+	}
+	return members, nil
+}
+
+// unmarshalExtensionMembers decodes the members of the json object data named with a registered
+// namespace into v, which is left untouched if there are none.
+func (m *Unmarshaler) unmarshalExtensionMembers(data []byte, v any) error {
+	b, ok, err := m.extensionMembersJSON(data)
+	if err != nil || !ok {
+		return err
+	}
+	return m.decodeJSON(b, v)
+}
+
+// extensionMembersJSON returns the json encoding of an object holding the members of the json
+// object data named with a registered namespace. It returns false if there are no such members.
+func (m *Unmarshaler) extensionMembersJSON(data []byte) ([]byte, bool, error) {
+	if len(m.extensions) == 0 || data == nil {
+		return nil, false, nil
+	}
+	members, err := m.extensions.members(data)
+	if err != nil || len(members) == 0 {
+		return nil, false, err
+	}
+	b, err := json.Marshal(members)
+	return b, err == nil, err
+}
+
+// appendMembers returns the json object obj with the given members added after its own, in sorted
+// order. obj is returned as is if there are no members.
+func appendMembers(obj []byte, members map[string]any) ([]byte, error) {
+	if len(members) == 0 {
+		return obj, nil
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// obj is a json object, so replace its closing brace to append the members
+	b := append(make([]byte, 0, len(obj)+64), obj[:len(obj)-1]...)
+	for i, name := range names {
+		if i > 0 || len(obj) > len("{}") {
+			b = append(b, ',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(members[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		b = append(append(append(b, key...), ':'), value...)
+	}
+	return append(b, '}'), nil
+}
@@ -0,0 +1,139 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalExtensions(t *testing.T) {
+	t.Parallel()
+
+	type atomicMembers struct {
+		Count int `json:"atomic:count"`
+	}
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+		expectError error
+	}{
+		{
+			description: "resource extension member",
+			given:       &ArticleVersioned{ID: "1", Title: "A", VersionID: "v1"},
+			opts:        []MarshalOption{MarshalExtensions("version")},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"A"},"version:id":"v1"}}`,
+		}, {
+			description: "empty resource extension member",
+			given:       &ArticleVersioned{ID: "1", Title: "A"},
+			opts:        []MarshalOption{MarshalExtensions("version")},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`,
+		}, {
+			description: "relationship extension members",
+			given:       &ArticleVersioned{ID: "1", Title: "A", Author: &authorA, AuthorVersion: "v2"},
+			opts:        []MarshalOption{MarshalExtensions("version")},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"A"},"relationships":{"author":{"data":{"type":"author","id":"1"},"version:id":"v2"}}}}`,
+		}, {
+			description: "document extension members",
+			given:       &articleA,
+			opts:        []MarshalOption{MarshalExtensions("atomic"), MarshalExtensionMembers(atomicMembers{Count: 1})},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}},"atomic:count":1}`,
+		}, {
+			description: "document extension members written incrementally",
+			given:       []*Article{&articleA},
+			opts:        []MarshalOption{MarshalExtensions("atomic"), MarshalExtensionMembers(map[string]any{"atomic:count": 1}), MarshalDataMember(AtomicResultsMember)},
+			expect:      `{"atomic:results":[{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}],"atomic:count":1}`,
+		}, {
+			description: "unregistered namespace",
+			given:       &ArticleVersioned{ID: "1", Title: "A", VersionID: "v1"},
+			opts:        []MarshalOption{MarshalExtensions("atomic")},
+			expectError: &MemberNameValidationError{MemberName: "version:id", Field: "ArticleVersioned.VersionID", Pointer: "/data/version:id"},
+		}, {
+			description: "document member without namespace",
+			given:       &articleA,
+			opts:        []MarshalOption{MarshalExtensions("atomic"), MarshalExtensionMembers(map[string]any{"count": 1})},
+			expectError: &MemberNameValidationError{MemberName: "count", Pointer: "/count"},
+		}, {
+			description: "document members not an object",
+			given:       &articleA,
+			opts:        []MarshalOption{MarshalExtensions("atomic"), MarshalExtensionMembers("count")},
+			expectError: &TypeError{Actual: "string", Expected: []string{"struct", "map"}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestUnmarshalExtensions(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":{"type":"articles","id":"1","attributes":{"title":"A"},"relationships":{"author":{"data":{"type":"author","id":"1"},"version:id":"v2"}},"version:id":"v1"},"atomic:count":1}`
+
+	t.Run("resource and relationship members", func(t *testing.T) {
+		t.Parallel()
+
+		var a ArticleVersioned
+		is.MustNoError(t, Unmarshal([]byte(body), &a, UnmarshalExtensions("version", "atomic")))
+		is.Equal(t, ArticleVersioned{ID: "1", Title: "A", VersionID: "v1", Author: &Author{ID: "1"}, AuthorVersion: "v2"}, a)
+	})
+
+	t.Run("document members", func(t *testing.T) {
+		t.Parallel()
+
+		var members struct {
+			Count int `json:"atomic:count"`
+		}
+		var a ArticleVersioned
+		is.MustNoError(t, Unmarshal([]byte(body), &a, UnmarshalExtensions("version", "atomic"), UnmarshalExtensionMembers(&members)))
+		is.Equal(t, 1, members.Count)
+	})
+
+	t.Run("unregistered namespace", func(t *testing.T) {
+		t.Parallel()
+
+		var a ArticleVersioned
+		err := Unmarshal([]byte(body), &a, UnmarshalExtensions("version"))
+		is.EqualError(t, &MemberNameValidationError{MemberName: "atomic:count", Pointer: "/atomic:count"}, err)
+	})
+
+	t.Run("invalid resource member", func(t *testing.T) {
+		t.Parallel()
+
+		var a ArticleVersioned
+		err := Unmarshal([]byte(`{"data":{"type":"articles","id":"1","version:id":1}}`), &a, UnmarshalExtensions("version"))
+		var fe *FieldError
+		is.MustEqual(t, true, errors.As(err, &fe))
+		is.Equal(t, CodeInvalidExtension, fe.Code)
+		is.Equal(t, "/data/version:id", fe.Pointer)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+
+		given := &ArticleVersioned{ID: "1", Title: "A", VersionID: "v1", Author: &Author{ID: "1"}, AuthorVersion: "v2"}
+		b, err := Marshal(given, MarshalExtensions("version"))
+		is.MustNoError(t, err)
+
+		var got ArticleVersioned
+		is.MustNoError(t, Unmarshal(b, &got, UnmarshalExtensions("version")))
+		is.Equal(t, given, &got)
+	})
+}
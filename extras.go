@@ -0,0 +1,122 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// attributeNames returns the member names of the attributes of the struct type t, including those
+// of embedded structs, as derived with the given NamingConvention.
+func attributeNames(t reflect.Type, naming NamingConvention) map[string]bool {
+	names := make(map[string]bool)
+	addAttributeNames(derefType(t), naming, names)
+	return names
+}
+
+func addAttributeNames(t reflect.Type, naming NamingConvention, names map[string]bool) {
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isFlattened(f) {
+			addAttributeNames(derefType(f.Type), naming, names)
+			continue
+		}
+		tag, err := parseJSONAPITag(f)
+		if err != nil || tag == nil || tag.directive != attribute {
+			continue
+		}
+		if name, ok, _ := memberName(f, naming); ok {
+			names[name] = true
+		}
+	}
+}
+
+// extrasField returns the field of the struct pointed to by rv tagged with the extras directive,
+// if any. Nil embedded struct pointers are skipped.
+func extrasField(rv reflect.Value) (reflect.Value, bool) {
+	rv = derefValue(rv)
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		fv := rv.Field(i)
+		ft := rt.Field(i)
+		if isFlattened(ft) {
+			if fv.Kind() == reflect.Pointer && fv.IsNil() {
+				continue
+			}
+			if ev, ok := extrasField(fv); ok {
+				return ev, true
+			}
+			continue
+		}
+		tag, err := parseJSONAPITag(ft)
+		if err == nil && tag != nil && tag.directive == extras && fv.CanSet() {
+			return fv, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// addExtras adds the entries of the extras map ev to the attributes of ro, except for those named
+// like an attribute field given in names, which take precedence even if omitted.
+func addExtras(ro *resourceObject, ev reflect.Value, names map[string]bool) {
+	iter := ev.MapRange()
+	for iter.Next() {
+		name := iter.Key().String()
+		if names[name] {
+			continue
+		}
+		ro.Attributes[name] = iter.Value().Interface()
+	}
+}
+
+// unmarshalExtras sets the extras map ev to the attributes of the given attributes object not named
+// in names. It returns the remaining attributes, or nil if there are none.
+func (m *Unmarshaler) unmarshalExtras(data []byte, ev reflect.Value, names map[string]bool) ([]byte, error) {
+	var attributes map[string]rawValue
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, &FieldError{Code: CodeInvalidAttribute, Member: "attributes", Pointer: "/attributes", Err: err}
+	}
+
+	unknown := make([]string, 0)
+	for name := range attributes {
+		if !names[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return data, nil
+	}
+	// decode in a deterministic order so the same error is reported for the same document
+	sort.Strings(unknown)
+
+	// patches add to the extras of the existing value rather than replacing them
+	values := ev
+	if !m.patch || ev.IsNil() {
+		values = reflect.MakeMapWithSize(ev.Type(), len(unknown))
+	}
+	for _, name := range unknown {
+		value := reflect.New(ev.Type().Elem())
+		if err := m.decodeJSON(attributes[name], value.Interface()); err != nil {
+			return nil, &FieldError{
+				Code:    CodeInvalidAttribute,
+				Member:  name,
+				Pointer: "/attributes/" + escapePointerToken(name),
+				Err:     err,
+			}
+		}
+		values.SetMapIndex(reflect.ValueOf(name).Convert(ev.Type().Key()), value.Elem())
+		delete(attributes, name)
+	}
+	ev.Set(values)
+
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(attributes)
+}
@@ -0,0 +1,86 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestExtras(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expect      *ArticleExtras
+		expectJSON  string
+		expectError error
+	}{
+		{
+			description: "unknown attributes",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"title":"A","body":"B","tags":["c"]}}}`,
+			expect: &ArticleExtras{ID: "1", Title: "A", Extra: map[string]json.RawMessage{
+				"body": json.RawMessage(`"B"`),
+				"tags": json.RawMessage(`["c"]`),
+			}},
+			expectJSON: `{"data":{"type":"articles","id":"1","attributes":{"title":"A","body":"B","tags":["c"]}}}`,
+		}, {
+			description: "only unknown attributes",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"body":"B"}}}`,
+			expect:      &ArticleExtras{ID: "1", Extra: map[string]json.RawMessage{"body": json.RawMessage(`"B"`)}},
+			expectJSON:  `{"data":{"type":"articles","id":"1","attributes":{"body":"B"}}}`,
+		}, {
+			description: "no unknown attributes",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`,
+			expect:      &ArticleExtras{ID: "1", Title: "A"},
+			expectJSON:  `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`,
+		}, {
+			description: "attribute named like the extras field",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"extra":1}}}`,
+			expect:      &ArticleExtras{ID: "1", Extra: map[string]json.RawMessage{"extra": json.RawMessage(`1`)}},
+			expectJSON:  `{"data":{"type":"articles","id":"1","attributes":{"extra":1}}}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var a ArticleExtras
+			err := Unmarshal([]byte(tc.given), &a)
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &a)
+
+			b, err := Marshal(&a)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expectJSON, string(b))
+		})
+	}
+}
+
+func TestExtrasDeclaredAttributesTakePrecedence(t *testing.T) {
+	t.Parallel()
+
+	a := &ArticleExtras{ID: "1", Extra: map[string]json.RawMessage{"title": json.RawMessage(`"B"`), "body": json.RawMessage(`"C"`)}}
+	b, err := Marshal(a)
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":{"type":"articles","id":"1","attributes":{"body":"C"}}}`, string(b))
+}
+
+func TestExtrasInvalidValue(t *testing.T) {
+	t.Parallel()
+
+	var a struct {
+		ID    string         `jsonapi:"primary,articles"`
+		Extra map[string]int `jsonapi:"extras"`
+	}
+	err := Unmarshal([]byte(`{"data":{"type":"articles","id":"1","attributes":{"body":"B"}}}`), &a)
+	var fe *FieldError
+	is.MustEqual(t, true, errors.As(err, &fe))
+	is.Equal(t, "/data/attributes/body", fe.Pointer)
+}
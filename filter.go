@@ -0,0 +1,209 @@
+package jsonapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FilterOperator is the operator of a FilterCondition.
+type FilterOperator string
+
+// The filter operators understood by ParseFilter, given as filter[field][operator]. A filter
+// parameter without an operator, such as filter[field], uses FilterEqual.
+const (
+	FilterEqual          FilterOperator = "eq"
+	FilterNotEqual       FilterOperator = "ne"
+	FilterLess           FilterOperator = "lt"
+	FilterLessOrEqual    FilterOperator = "le"
+	FilterGreater        FilterOperator = "gt"
+	FilterGreaterOrEqual FilterOperator = "ge"
+	FilterContains       FilterOperator = "contains"
+
+	// FilterIn matches any of a comma separated list of values, e.g. filter[status][in]=a,b. It is
+	// parsed into a FilterOr of FilterEqual conditions.
+	FilterIn FilterOperator = "in"
+)
+
+// isValid returns true if op is one of the filter operators understood by ParseFilter.
+func (op FilterOperator) isValid() bool {
+	switch op {
+	case FilterEqual, FilterNotEqual, FilterLess, FilterLessOrEqual, FilterGreater, FilterGreaterOrEqual, FilterContains, FilterIn:
+		return true
+	}
+	return false
+}
+
+// FilterExpr is a node of a filter expression as returned by ParseFilter: a *FilterCondition,
+// FilterAnd or FilterOr.
+type FilterExpr interface {
+	filterExpr()
+}
+
+// FilterCondition is a filter expression comparing a field with a value, e.g. filter[views][gt]=10.
+type FilterCondition struct {
+	// Field is the name of the filtered field, e.g. "title" or "author.name".
+	Field string
+
+	// Operator is the comparison operator.
+	Operator FilterOperator
+
+	// Value is the value compared with, as given in the query.
+	Value string
+}
+
+// FilterAnd is a filter expression matching if all of its expressions match.
+type FilterAnd []FilterExpr
+
+// FilterOr is a filter expression matching if any of its expressions matches.
+type FilterOr []FilterExpr
+
+func (*FilterCondition) filterExpr() {}
+func (FilterAnd) filterExpr()        {}
+func (FilterOr) filterExpr()         {}
+
+// FilterCompiler translates filter expressions into a backend specific representation T, such as a
+// SQL where clause, a query builder scope or an in-memory predicate. It is used by CompileFilter,
+// which walks the filter expression and calls And and Or with the already compiled expressions of
+// a group.
+type FilterCompiler[T any] interface {
+	Condition(c *FilterCondition) (T, error)
+	And(exprs []T) (T, error)
+	Or(exprs []T) (T, error)
+}
+
+// CompileFilter compiles the filter expression expr with c. The zero value of T is returned if expr
+// is nil.
+func CompileFilter[T any](expr FilterExpr, c FilterCompiler[T]) (T, error) {
+	var zero T
+
+	var group []FilterExpr
+	switch e := expr.(type) {
+	case nil:
+		return zero, nil
+	case *FilterCondition:
+		return c.Condition(e)
+	case FilterAnd:
+		group = e
+	case FilterOr:
+		group = e
+	default:
+		return zero, fmt.Errorf("jsonapi: unknown filter expression %T", expr)
+	}
+
+	compiled := make([]T, len(group))
+	for i, e := range group {
+		v, err := CompileFilter(e, c)
+		if err != nil {
+			return zero, err
+		}
+		compiled[i] = v
+	}
+
+	if _, ok := expr.(FilterOr); ok {
+		return c.Or(compiled)
+	}
+	return c.And(compiled)
+}
+
+// ParseFilter parses the filter parameters of a query as returned by ParseQuery into a filter
+// expression, or returns nil if there are none.
+//
+// Each filter[field] or filter[field][operator] parameter is a FilterCondition, and the filter
+// parameters are combined with FilterAnd, ordered by parameter name. Comma separated values are
+// lists for the equality operators only: a list given to FilterEqual or FilterIn, as in
+// filter[status]=draft,published, is a FilterOr of a FilterEqual condition per value, and a list
+// given to FilterNotEqual is a FilterAnd of a condition per value. Within lists, a comma preceded
+// by a backslash is part of the value, as is a backslash preceded by one. Values of all other
+// operators are taken as is, commas and backslashes included. Groups of a single expression are
+// replaced by the expression itself.
+//
+// A 400 (Bad Request) *Error pointing to the filter parameter is returned for unknown operators.
+func ParseFilter(filter map[string]string) (FilterExpr, error) {
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	and := make(FilterAnd, 0, len(keys))
+	for _, key := range keys {
+		field, op := key, FilterEqual
+		if i := strings.IndexByte(key, '['); i >= 0 && strings.HasSuffix(key, "]") {
+			field, op = key[:i], FilterOperator(key[i+1:len(key)-1])
+		}
+		parameter := "filter[" + field + "]"
+		if field != key {
+			parameter += "[" + string(op) + "]"
+		}
+		if !op.isValid() {
+			return nil, newQueryParameterError(parameter, fmt.Sprintf("The filter operator %q is not supported.", op))
+		}
+
+		value := filter[key]
+		switch op {
+		case FilterEqual, FilterIn:
+			values := splitFilterValues(value)
+			or := make(FilterOr, len(values))
+			for i, v := range values {
+				or[i] = &FilterCondition{Field: field, Operator: FilterEqual, Value: v}
+			}
+			and = append(and, simplifyFilter(or))
+		case FilterNotEqual:
+			values := splitFilterValues(value)
+			ne := make(FilterAnd, len(values))
+			for i, v := range values {
+				ne[i] = &FilterCondition{Field: field, Operator: op, Value: v}
+			}
+			and = append(and, simplifyFilter(ne))
+		default:
+			and = append(and, &FilterCondition{Field: field, Operator: op, Value: value})
+		}
+	}
+
+	if len(and) == 0 {
+		return nil, nil
+	}
+	return simplifyFilter(and), nil
+}
+
+// splitFilterValues splits the comma separated list of filter values s, unescaping commas and
+// backslashes preceded by a backslash.
+func splitFilterValues(s string) []string {
+	if !strings.ContainsRune(s, '\\') {
+		return strings.Split(s, ",")
+	}
+
+	var (
+		values []string
+		value  strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == ',' || s[i+1] == '\\'):
+			i++
+			value.WriteByte(s[i])
+		case c == ',':
+			values = append(values, value.String())
+			value.Reset()
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return append(values, value.String())
+}
+
+// simplifyFilter returns the only expression of a group of one, or the group otherwise.
+func simplifyFilter(group FilterExpr) FilterExpr {
+	switch g := group.(type) {
+	case FilterAnd:
+		if len(g) == 1 {
+			return g[0]
+		}
+	case FilterOr:
+		if len(g) == 1 {
+			return g[0]
+		}
+	}
+	return group
+}
@@ -0,0 +1,140 @@
+package jsonapi
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// sqlFilterCompiler compiles filter expressions into SQL where clauses.
+type sqlFilterCompiler struct{}
+
+func (sqlFilterCompiler) Condition(c *FilterCondition) (string, error) {
+	ops := map[FilterOperator]string{
+		FilterEqual:          "=",
+		FilterNotEqual:       "<>",
+		FilterLess:           "<",
+		FilterLessOrEqual:    "<=",
+		FilterGreater:        ">",
+		FilterGreaterOrEqual: ">=",
+	}
+	if c.Operator == FilterContains {
+		return fmt.Sprintf("%s LIKE '%%%s%%'", c.Field, c.Value), nil
+	}
+	return fmt.Sprintf("%s %s '%s'", c.Field, ops[c.Operator], c.Value), nil
+}
+
+func (sqlFilterCompiler) And(exprs []string) (string, error) {
+	return "(" + strings.Join(exprs, " AND ") + ")", nil
+}
+
+func (sqlFilterCompiler) Or(exprs []string) (string, error) {
+	return "(" + strings.Join(exprs, " OR ") + ")", nil
+}
+
+func TestParseFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description     string
+		given           map[string]string
+		expect          FilterExpr
+		expectSQL       string
+		expectParameter string
+	}{
+		{
+			description: "no filter",
+			given:       map[string]string{},
+			expect:      nil,
+		}, {
+			description: "single condition",
+			given:       map[string]string{"title": "A"},
+			expect:      &FilterCondition{Field: "title", Operator: FilterEqual, Value: "A"},
+			expectSQL:   "title = 'A'",
+		}, {
+			description: "operator",
+			given:       map[string]string{"views[ge]": "10"},
+			expect:      &FilterCondition{Field: "views", Operator: FilterGreaterOrEqual, Value: "10"},
+			expectSQL:   "views >= '10'",
+		}, {
+			description: "several parameters",
+			given:       map[string]string{"views[lt]": "10", "author.name[contains]": "Jo"},
+			expect: FilterAnd{
+				&FilterCondition{Field: "author.name", Operator: FilterContains, Value: "Jo"},
+				&FilterCondition{Field: "views", Operator: FilterLess, Value: "10"},
+			},
+			expectSQL: "(author.name LIKE '%Jo%' AND views < '10')",
+		}, {
+			description: "comma separated values",
+			given:       map[string]string{"status": "draft,published", "views[gt]": "1"},
+			expect: FilterAnd{
+				FilterOr{
+					&FilterCondition{Field: "status", Operator: FilterEqual, Value: "draft"},
+					&FilterCondition{Field: "status", Operator: FilterEqual, Value: "published"},
+				},
+				&FilterCondition{Field: "views", Operator: FilterGreater, Value: "1"},
+			},
+			expectSQL: "((status = 'draft' OR status = 'published') AND views > '1')",
+		}, {
+			description: "list of values",
+			given:       map[string]string{"status[in]": "draft,published"},
+			expect: FilterOr{
+				&FilterCondition{Field: "status", Operator: FilterEqual, Value: "draft"},
+				&FilterCondition{Field: "status", Operator: FilterEqual, Value: "published"},
+			},
+			expectSQL: "(status = 'draft' OR status = 'published')",
+		}, {
+			description: "comma separated values not equal",
+			given:       map[string]string{"status[ne]": "draft,published"},
+			expect: FilterAnd{
+				&FilterCondition{Field: "status", Operator: FilterNotEqual, Value: "draft"},
+				&FilterCondition{Field: "status", Operator: FilterNotEqual, Value: "published"},
+			},
+			expectSQL: "(status <> 'draft' AND status <> 'published')",
+		}, {
+			description: "commas in values of other operators",
+			given:       map[string]string{"title[contains]": "A,B", "views[gt]": "1,5"},
+			expect: FilterAnd{
+				&FilterCondition{Field: "title", Operator: FilterContains, Value: "A,B"},
+				&FilterCondition{Field: "views", Operator: FilterGreater, Value: "1,5"},
+			},
+			expectSQL: "(title LIKE '%A,B%' AND views > '1,5')",
+		}, {
+			description: "escaped commas",
+			given:       map[string]string{"title": `A\,B,C\\`},
+			expect: FilterOr{
+				&FilterCondition{Field: "title", Operator: FilterEqual, Value: "A,B"},
+				&FilterCondition{Field: "title", Operator: FilterEqual, Value: `C\`},
+			},
+			expectSQL: `(title = 'A,B' OR title = 'C\')`,
+		}, {
+			description:     "unknown operator",
+			given:           map[string]string{"views[between]": "1"},
+			expectParameter: "filter[views][between]",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			expr, err := ParseFilter(tc.given)
+			if tc.expectParameter != "" {
+				e, ok := err.(*Error)
+				is.MustEqual(t, true, ok)
+				is.Equal(t, tc.expectParameter, e.Source.Parameter)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, expr)
+
+			sql, err := CompileFilter[string](expr, sqlFilterCompiler{})
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expectSQL, sql)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package jsonapi
+
+import "reflect"
+
+// flattenOption is the option of the attribute directive promoting the fields of a named struct
+// field to the attributes of its parent, as done for embedded structs.
+const flattenOption = "flatten"
+
+// isFlattened returns true if the fields of the struct field f are promoted to its parent, i.e. if
+// it is embedded or has the flatten option.
+func isFlattened(f reflect.StructField) bool {
+	if f.Anonymous {
+		return true
+	}
+	tag, err := parseJSONAPITag(f)
+	return err == nil && tag != nil && tag.flatten
+}
+
+// isStructType returns true if t is a struct or a pointer to one.
+func isStructType(t reflect.Type) bool {
+	return derefType(t).Kind() == reflect.Struct
+}
+
+// decodeFlattened decodes the given attributes object into the fields of the struct pointed to by
+// rv with the flatten option, including those of embedded structs. encoding/json only promotes the
+// fields of embedded structs, so each of them is decoded from the whole attributes object.
+func (m *Unmarshaler) decodeFlattened(b []byte, rv reflect.Value) error {
+	rv = derefValue(rv)
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		fv := rv.Field(i)
+		ft := rt.Field(i)
+		if !isFlattened(ft) || fv.Kind() == reflect.Pointer && fv.IsNil() && ft.Anonymous {
+			// nil embedded struct pointers are allocated when decoding if any of their fields is set
+			continue
+		}
+		if !ft.Anonymous {
+			if !fv.CanSet() {
+				continue
+			}
+			if err := m.decodeJSON(b, fv.Addr().Interface()); err != nil {
+				return err
+			}
+		}
+		if err := m.decodeFlattened(b, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// formatAttribute returns the value of the attribute field fv, described by f and its parsed tag, in
+// the format given by the options of its attribute directive or the Marshaler.
+func (m *Marshaler) formatAttribute(fv reflect.Value, f reflect.StructField, tag *tag) (any, error) {
+	switch {
+	case isTimeType(f.Type):
+		format, utc := attributeTimeFormat(tag, m.timeFormat, m.timeUTC)
+		return formatTime(fv, format, utc)
+	case tag.byteEncoding != "":
+		return formatBytes(fv, tag.byteEncoding), nil
+	case isInt64Type(f.Type) && (tag.int64String || m.int64Strings):
+		return formatInt64(fv), nil
+	}
+	return fv.Interface(), nil
+}
+
+// attributeParser converts the json encoding of an attribute in the format given by the options of
+// its attribute directive to the json encoding decoded by encoding/json.
+type attributeParser func(data []byte) ([]byte, error)
+
+// attributeParser returns the parser of the attribute field f with the given parsed tag, or nil if
+// it is decoded as usual.
+func (m *Unmarshaler) attributeParser(f reflect.StructField, tag *tag) attributeParser {
+	switch {
+	case isTimeType(f.Type):
+		format, utc := attributeTimeFormat(tag, m.timeFormat, m.timeUTC)
+		if format == "" && !utc {
+			return nil
+		}
+		return func(data []byte) ([]byte, error) {
+			t, err := parseTime(data, format, utc)
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(t)
+		}
+	case tag.byteEncoding != "":
+		return func(data []byte) ([]byte, error) {
+			return parseBytes(data, tag.byteEncoding, f.Type)
+		}
+	case isInt64Type(f.Type) && (tag.int64String || m.int64Strings):
+		return func(data []byte) ([]byte, error) {
+			return parseInt64(data, f.Type)
+		}
+	}
+	return nil
+}
+
+// attributeParsers adds the parsers of the attributes of the struct type t, including those of
+// embedded structs, to parsers by member name, and returns it.
+func (m *Unmarshaler) attributeParsers(t reflect.Type, parsers map[string]attributeParser) map[string]attributeParser {
+	t = derefType(t)
+	if t.Kind() != reflect.Struct {
+		return parsers
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isFlattened(f) {
+			parsers = m.attributeParsers(f.Type, parsers)
+			continue
+		}
+		tag, err := parseJSONAPITag(f)
+		if err != nil || tag == nil || tag.directive != attribute {
+			continue
+		}
+		parse := m.attributeParser(f, tag)
+		if parse == nil {
+			continue
+		}
+		if name, ok, _ := parseJSONTag(f); ok {
+			if parsers == nil {
+				parsers = make(map[string]attributeParser)
+			}
+			parsers[name] = parse
+		}
+	}
+	return parsers
+}
+
+// parseAttributes converts the attributes of the attributes object data which have a parser, except
+// null ones, to the json encoding decoded by encoding/json.
+func parseAttributes(data []byte, parsers map[string]attributeParser) ([]byte, error) {
+	var attributes map[string]rawValue
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, &FieldError{Code: CodeInvalidAttribute, Member: "attributes", Pointer: "/attributes", Err: err}
+	}
+
+	// parse in a deterministic order so the same error is reported for the same document
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, ok := attributes[name]
+		if !ok || string(value) == "null" {
+			continue
+		}
+		parsed, err := parsers[name](value)
+		if err != nil {
+			return nil, &FieldError{
+				Code:    CodeInvalidAttribute,
+				Member:  name,
+				Pointer: "/attributes/" + escapePointerToken(name),
+				Err:     err,
+			}
+		}
+		attributes[name] = parsed
+	}
+	return json.Marshal(attributes)
+}
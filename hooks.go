@@ -0,0 +1,37 @@
+package jsonapi
+
+import "context"
+
+// BeforeMarshaler can be implemented by resources to run code before they are marshaled, e.g. to
+// compute derived attributes or to check invariants, without every handler having to call it.
+// BeforeMarshalJSONAPI is called with the context given by MarshalContext for primary data and
+// included resources, but not for resources which are only referred to by resource linkage. An
+// error aborts marshaling.
+type BeforeMarshaler interface {
+	BeforeMarshalJSONAPI(ctx context.Context) error
+}
+
+// AfterUnmarshaler can be implemented by resources to run code after they are unmarshaled, e.g. to
+// normalize data or to validate invariants, without every handler having to call it.
+// AfterUnmarshalJSONAPI is called with the context given by UnmarshalContext once all fields of the
+// resource are set, including its relationships. An error aborts unmarshaling, and is wrapped in a
+// ResourceError.
+type AfterUnmarshaler interface {
+	AfterUnmarshalJSONAPI(ctx context.Context) error
+}
+
+// beforeMarshal calls the BeforeMarshalJSONAPI hook of v, if any.
+func (m *Marshaler) beforeMarshal(v any) error {
+	if h, ok := v.(BeforeMarshaler); ok {
+		return h.BeforeMarshalJSONAPI(m.context())
+	}
+	return nil
+}
+
+// afterUnmarshal calls the AfterUnmarshalJSONAPI hook of v, if any.
+func (m *Unmarshaler) afterUnmarshal(v any) error {
+	if h, ok := v.(AfterUnmarshaler); ok {
+		return h.AfterUnmarshalJSONAPI(m.context())
+	}
+	return nil
+}
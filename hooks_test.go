@@ -0,0 +1,99 @@
+package jsonapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestBeforeMarshaler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		expect      string
+		expectError error
+	}{
+		{
+			description: "resource",
+			given:       &ArticleHooked{ID: "1", Title: "Hello World"},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"Hello World","slug":"hello-world"}}}`,
+		}, {
+			description: "collection",
+			given:       []*ArticleHooked{{ID: "1", Title: "A"}, {ID: "2", Title: "B"}},
+			expect:      `{"data":[{"type":"articles","id":"1","attributes":{"title":"A","slug":"a"}},{"type":"articles","id":"2","attributes":{"title":"B","slug":"b"}}]}`,
+		}, {
+			description: "error",
+			given:       []*ArticleHooked{{ID: "1", Title: "A"}, {ID: "2"}},
+			expectError: errArticleHookedTitle,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given)
+			if tc.expectError != nil {
+				is.Equal(t, true, errors.Is(err, tc.expectError))
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestAfterUnmarshaler(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":{"type":"articles","id":"1","attributes":{"title":" A "}}}`
+
+	t.Run("resource", func(t *testing.T) {
+		t.Parallel()
+
+		var a ArticleHooked
+		is.MustNoError(t, Unmarshal([]byte(body), &a))
+		is.Equal(t, ArticleHooked{ID: "1", Title: "A"}, a)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.WithValue(context.Background(), editorKey{}, "jane")
+		var as []*ArticleHooked
+		is.MustNoError(t, Unmarshal([]byte(`{"data":[{"type":"articles","id":"1","attributes":{"title":"A"}}]}`), &as, UnmarshalContext(ctx)))
+		is.Equal(t, []*ArticleHooked{{ID: "1", Title: "A", Editor: "jane"}}, as)
+	})
+
+	t.Run("request context", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest("POST", "/articles", strings.NewReader(body))
+		r.Header.Set("Content-Type", MediaType)
+		r = r.WithContext(context.WithValue(r.Context(), editorKey{}, "jane"))
+
+		var a ArticleHooked
+		is.MustNoError(t, Read(r, &a))
+		is.Equal(t, "jane", a.Editor)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		var a ArticleHooked
+		err := Unmarshal([]byte(`{"data":{"type":"articles","id":"1","attributes":{"title":" "}}}`), &a)
+		is.Equal(t, true, errors.Is(err, errArticleHookedTitle))
+		var re *ResourceError
+		is.MustEqual(t, true, errors.As(err, &re))
+		is.Equal(t, "/data", re.Pointer)
+	})
+}
@@ -0,0 +1,285 @@
+package jsonapi
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MediaType is the JSON:API media type as defined by https://jsonapi.org/format/#content-negotiation.
+const MediaType = "application/vnd.api+json"
+
+// DefaultMaxBodySize is the maximum size in bytes of request bodies read by Read and of response
+// bodies read by a Client, unless configured otherwise with UnmarshalMaxBodySize.
+const DefaultMaxBodySize int64 = 1 << 20
+
+// Write writes the json:api encoding of v to w with the given status code and the JSON:API media
+// type as Content-Type.
+//
+// Primary data containing many resource objects is made and written incrementally, as done by
+// MarshalTo, and w is flushed as configured by MarshalFlushThreshold if it implements http.Flusher.
+// The status code is written along with the first bytes of the document, so that if marshaling
+// fails before, nothing is written to w and the error is returned, e.g. to be written by WriteError
+// instead. Failures midway, such as an IncludeResolver failing for a later chunk of primary data,
+// are handled as configured by MarshalStreamFailure.
+func Write(w http.ResponseWriter, status int, v any, opts ...MarshalOption) error {
+	m := makeMarshaler(opts...)
+
+	return m.observe(func(op *Operation) error {
+		return writeDocument(w, status, m, func(dw *documentWriter) error {
+			defer func() { op.Size = dw.n }()
+			d, err := dw.stream(v)
+			op.add(d)
+			return err
+		})
+	})
+}
+
+// writeDocument writes a document to w with the given status code by calling write. The status code
+// is written along with the first bytes of the document, so that nothing is written if marshaling
+// fails before.
+func writeDocument(w http.ResponseWriter, status int, m *Marshaler, write func(dw *documentWriter) error) error {
+	w.Header().Set("Content-Type", m.contentType())
+
+	sw := &statusWriter{ResponseWriter: w, status: status}
+	dw := &documentWriter{w: sw, m: m}
+	err := write(dw)
+	if err != nil {
+		if dw.started && m.streamFailure == StreamFailureAbort {
+			panic(http.ErrAbortHandler)
+		}
+		return err
+	}
+	sw.writeHeader()
+	return nil
+}
+
+// statusWriter is an http.ResponseWriter deferring writing the status code given to Write until
+// the body is written or flushed.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// writeHeader writes the status code unless already written.
+func (w *statusWriter) writeHeader() {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// Write writes the status code before the first bytes of the body.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.writeHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements the http.Flusher interface if the underlying http.ResponseWriter does.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.writeHeader()
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteError writes err to w as an error document, as converted by ErrorObjects, or by the
+// ErrorMapper given by MapErrors if w is a response of the middleware. The Retry-After header is set
+// if there are error objects created by NewRateLimitError.
+//
+// The response status code is the status of the error objects if they all share the same one.
+// Otherwise, it is the most generally applicable one as recommended by
+// https://jsonapi.org/format/#errors-processing: 400 (Bad Request) if all error objects have a 4xx
+// status, or 500 (Internal Server Error) if not.
+func WriteError(w http.ResponseWriter, err error) error {
+	objects := errorObjectsFor(w, err)
+	if len(objects) == 0 {
+		objects = ErrorObjects(fmt.Errorf("unknown error"))
+	}
+	if seconds := retryAfter(objects); seconds != "" {
+		w.Header().Set("Retry-After", seconds)
+	}
+	return Write(w, errorsStatus(objects), objects)
+}
+
+// errorsStatus returns the response status code for the given error objects.
+func errorsStatus(objects []*Error) int {
+	status := 0
+	for _, e := range objects {
+		s := http.StatusInternalServerError
+		if e.Status != nil {
+			s = *e.Status
+		}
+		switch {
+		case status == 0 || status == s:
+			status = s
+		case s >= 400 && s < 500 && status >= 400 && status < 500:
+			status = http.StatusBadRequest
+		default:
+			status = http.StatusInternalServerError
+		}
+	}
+	return status
+}
+
+// Read reads the json:api encoded body of r and stores the result in the value pointed to by v, as
+// done by Unmarshal. The context of r is passed to callbacks such as an AfterUnmarshaler, unless
+// another one is given via UnmarshalContext.
+//
+// A 415 (Unsupported Media Type) error is returned if the Content-Type header of r is not the
+// JSON:API media type, or has parameters other than ext and profile. The ext parameter is not
+// checked, use NegotiateRequest to check for supported extensions. A 413 (Payload Too Large) error
+// is returned if the body is larger than configured by UnmarshalMaxBodySize, and a 400 (Bad
+// Request) error if it is empty. Any error returned by Read can be written as error document with
+// WriteError.
+func Read(r *http.Request, v any, opts ...UnmarshalOption) error {
+	data, err := readBody(r, makeUnmarshaler(opts...))
+	if err != nil {
+		return err
+	}
+	return Unmarshal(data, v, append([]UnmarshalOption{UnmarshalContext(r.Context())}, opts...)...)
+}
+
+// readBody reads the json:api encoded body of r, returning the errors documented by Read.
+func readBody(r *http.Request, m *Unmarshaler) ([]byte, error) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	reason := "the media type must be " + MediaType
+	if err == nil && mediaType == MediaType {
+		reason = checkMediaTypeParams(params, nil, "ext")
+	}
+	if reason != "" {
+		return nil, &Error{
+			Status: Status(http.StatusUnsupportedMediaType),
+			Title:  http.StatusText(http.StatusUnsupportedMediaType),
+			Detail: fmt.Sprintf("Invalid Content-Type header: %s.", reason),
+			Source: &ErrorSource{Header: "Content-Type"},
+		}
+	}
+
+	var body io.Reader = http.NoBody
+	if r.Body != nil {
+		body = r.Body
+	}
+	if m.maxBodySize >= 0 {
+		// read one more byte than allowed to detect bodies that are too large
+		body = io.LimitReader(body, m.maxBodySize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if m.maxBodySize >= 0 && int64(len(data)) > m.maxBodySize {
+		return nil, &Error{
+			Status: Status(http.StatusRequestEntityTooLarge),
+			Title:  http.StatusText(http.StatusRequestEntityTooLarge),
+			Detail: fmt.Sprintf("The request body must not be larger than %d bytes.", m.maxBodySize),
+		}
+	}
+	if len(data) == 0 {
+		return nil, &Error{
+			Status: Status(http.StatusBadRequest),
+			Title:  http.StatusText(http.StatusBadRequest),
+			Detail: "The request body must not be empty.",
+		}
+	}
+	return data, nil
+}
+
+// CheckPreconditions evaluates the If-Match precondition of r against the current entity tag of the
+// target resource, as defined by https://www.rfc-editor.org/rfc/rfc9110#name-if-match, to protect
+// against lost updates. An empty currentETag means the target resource doesn't exist.
+//
+// Requests with methods PUT, PATCH, or DELETE must be conditional, so if they lack an If-Match
+// header a 428 (Precondition Required) error is returned. If the precondition is not satisfied a
+// 412 (Precondition Failed) error is returned. Otherwise, CheckPreconditions returns nil.
+//
+// The returned errors are ready to be written as error documents.
+func CheckPreconditions(r *http.Request, currentETag string) *Error {
+	ifMatch := r.Header.Values("If-Match")
+
+	if len(ifMatch) == 0 {
+		switch r.Method {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			return &Error{
+				Status: Status(http.StatusPreconditionRequired),
+				Title:  http.StatusText(http.StatusPreconditionRequired),
+				Detail: "This request is required to be conditional, try using If-Match.",
+				Source: &ErrorSource{Header: "If-Match"},
+			}
+		}
+		return nil
+	}
+
+	if !etagMatches(ifMatch, currentETag) {
+		return &Error{
+			Status: Status(http.StatusPreconditionFailed),
+			Title:  http.StatusText(http.StatusPreconditionFailed),
+			Detail: "The resource has been modified since it was last retrieved.",
+			Source: &ErrorSource{Header: "If-Match"},
+		}
+	}
+
+	return nil
+}
+
+// quoteETag returns the given entity tag as a quoted string, unless it is already quoted.
+func quoteETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// etagMatches returns true if one of the given If-Match header values matches the current entity
+// tag, using the strong comparison function. "*" matches any current entity tag, even a weak one.
+func etagMatches(ifMatch []string, currentETag string) bool {
+	if currentETag == "" {
+		return false
+	}
+	current := quoteETag(currentETag)
+	// weak entity tags never match using the strong comparison function
+	weak := strings.HasPrefix(current, "W/")
+
+	for _, value := range ifMatch {
+		for _, etag := range strings.Split(value, ",") {
+			etag = strings.TrimSpace(etag)
+			if etag == "*" || !weak && etag == current {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// NotModified returns true if the If-None-Match header of r matches the given entity tag of the
+// target resource, e.g. as returned by ETag, using the weak comparison function as defined by
+// https://www.rfc-editor.org/rfc/rfc9110#name-if-none-match. "*" matches any entity tag, and an
+// empty etag means the target resource doesn't exist. GET and HEAD requests for which NotModified
+// returns true should be responded to with 304 (Not Modified).
+func NotModified(r *http.Request, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	// the weak comparison function ignores whether entity tags are weak
+	current := strings.TrimPrefix(quoteETag(etag), "W/")
+
+	for _, value := range r.Header.Values("If-None-Match") {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == current {
+				return true
+			}
+		}
+	}
+
+	return false
+}
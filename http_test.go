@@ -0,0 +1,521 @@
+package jsonapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// flushCounter counts the calls to Flush.
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (fc *flushCounter) Flush() {
+	fc.flushes++
+	fc.ResponseRecorder.Flush()
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+		expectError error
+	}{
+		{
+			description: "nil",
+			given:       nil,
+			expect:      nullDataBody,
+		}, {
+			description: "*Article",
+			given:       &articleA,
+			expect:      articleABody,
+		}, {
+			description: "[]*Article (empty)",
+			given:       []*Article{},
+			expect:      emptyManyBody,
+		}, {
+			description: "[]*Article",
+			given:       articlesABPtr,
+			expect:      articlesABBody,
+		}, {
+			description: "[]*Article with meta",
+			given:       articlesABPtr,
+			opts:        []MarshalOption{MarshalMeta(map[string]any{"foo": "bar"})},
+			expect:      `{"data":[{"type":"articles","id":"1","attributes":{"title":"A"}},{"type":"articles","id":"2","attributes":{"title":"B"}}],"meta":{"foo":"bar"}}`,
+		}, {
+			description: "[]*ArticleRelated with include",
+			given:       []*ArticleRelated{&articleRelatedComplete},
+			opts:        []MarshalOption{MarshalInclude(&authorAWithMeta, &commentA, &commentB)},
+			expect:      `{"data":[{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"meta":{"count":10},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}},"comments":{"data":[{"id":"1","type":"comments"},{"id":"2","type":"comments"}],"links":{"self":"http://example.com/articles/1/relationships/comments","related":"http://example.com/articles/1/comments"}}}}],"included":[{"id":"1","type":"author","attributes":{"name":"A"},"meta":{"count":10}},{"id":"1","type":"comments","attributes":{"body":"A"}},{"id":"2","type":"comments","attributes":{"body":"B"}}]}`,
+		}, {
+			description: "[]*Error",
+			given:       errorsComplexSliceManyPtr,
+			expect:      errorsComplexSliceManyBody,
+		}, {
+			description: "[]*Article with missing ID",
+			given:       []*Article{&articleA, &articleANoID},
+			expectError: ErrEmptyPrimaryField,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			rec := httptest.NewRecorder()
+			err := Write(rec, http.StatusOK, tc.given, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				is.Equal(t, 0, rec.Body.Len())
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, http.StatusOK, rec.Code)
+			is.Equal(t, MediaType, rec.Header().Get("Content-Type"))
+			is.EqualJSON(t, tc.expect, rec.Body.String())
+		})
+	}
+}
+
+func TestWriteFlushThreshold(t *testing.T) {
+	t.Parallel()
+
+	articles := make([]*Article, 10)
+	for i := range articles {
+		articles[i] = &Article{ID: fmt.Sprintf("%d", i), Title: "A"}
+	}
+
+	tests := []struct {
+		description string
+		threshold   int
+		expect      int
+	}{
+		{description: "disabled", threshold: 0, expect: 0},
+		{description: "every resource", threshold: 1, expect: 10},
+		{description: "every 3 resources", threshold: 3, expect: 3},
+		{description: "more than the number of resources", threshold: 20, expect: 0},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			w := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+			err := Write(w, http.StatusOK, articles, MarshalFlushThreshold(tc.threshold))
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, w.flushes)
+
+			expect, err := Marshal(articles)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, string(expect), w.Body.String())
+		})
+	}
+}
+
+// ArticleStreamed records the length of the body written so far when it is marshaled.
+type ArticleStreamed struct {
+	ID string `jsonapi:"primary,articles"`
+
+	body *httptest.ResponseRecorder
+	seen *[]int
+}
+
+func (a *ArticleStreamed) BeforeMarshalJSONAPI(context.Context) error {
+	*a.seen = append(*a.seen, a.body.Body.Len())
+	return nil
+}
+
+func TestWriteStreamsPrimaryData(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	var seen []int
+	articles := make([]*ArticleStreamed, 4)
+	for i := range articles {
+		articles[i] = &ArticleStreamed{ID: fmt.Sprintf("%d", i), body: rec, seen: &seen}
+	}
+
+	w := &flushCounter{ResponseRecorder: rec}
+	err := Write(w, http.StatusOK, articles, MarshalFlushThreshold(2))
+	is.MustNoError(t, err)
+	is.Equal(t, 2, w.flushes)
+
+	// the second chunk of resources is made once the first one is written
+	is.Equal(t, 4, len(seen))
+	is.Equal(t, []int{0, 0}, seen[:2])
+	is.Equal(t, true, seen[2] > 0 && seen[2] == seen[3])
+}
+
+func TestWriteFailureMidway(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	err := Write(rec, http.StatusCreated, []*ArticleFailing{{ID: "1"}, {ID: "2", Fail: true}})
+	is.MustError(t, err)
+	is.Equal(t, http.StatusCreated, rec.Code)
+	is.Equal(t, `{"data":[{"id":"1","type":"articles","attributes":{"fail":false}}`, rec.Body.String())
+}
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description  string
+		contentType  string
+		body         string
+		opts         []UnmarshalOption
+		expect       *Article
+		expectStatus int
+	}{
+		{
+			description: "*Article",
+			contentType: MediaType,
+			body:        articleABody,
+			expect:      &articleA,
+		}, {
+			description: "*Article with ext and profile",
+			contentType: MediaType + `; ext="https://example.com/ext"; profile="https://example.com/profile"`,
+			body:        articleABody,
+			expect:      &articleA,
+		}, {
+			description:  "missing Content-Type",
+			body:         articleABody,
+			expectStatus: http.StatusUnsupportedMediaType,
+		}, {
+			description:  "application/json",
+			contentType:  "application/json",
+			body:         articleABody,
+			expectStatus: http.StatusUnsupportedMediaType,
+		}, {
+			description:  "unsupported media type parameter",
+			contentType:  MediaType + "; charset=utf-8",
+			body:         articleABody,
+			expectStatus: http.StatusUnsupportedMediaType,
+		}, {
+			description:  "empty body",
+			contentType:  MediaType,
+			expectStatus: http.StatusBadRequest,
+		}, {
+			description:  "body too large",
+			contentType:  MediaType,
+			body:         articleABody,
+			opts:         []UnmarshalOption{UnmarshalMaxBodySize(int64(len(articleABody) - 1))},
+			expectStatus: http.StatusRequestEntityTooLarge,
+		}, {
+			description: "body at max size",
+			contentType: MediaType,
+			body:        articleABody,
+			opts:        []UnmarshalOption{UnmarshalMaxBodySize(int64(len(articleABody)))},
+			expect:      &articleA,
+		}, {
+			description: "unlimited body size",
+			contentType: MediaType,
+			body:        articleABody,
+			opts:        []UnmarshalOption{UnmarshalMaxBodySize(-1)},
+			expect:      &articleA,
+		}, {
+			description:  "invalid json",
+			contentType:  MediaType,
+			body:         `{"data":`,
+			expectStatus: http.StatusBadRequest,
+		}, {
+			description:  "invalid document",
+			contentType:  MediaType,
+			body:         `{}`,
+			expectStatus: http.StatusBadRequest,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			r := httptest.NewRequest(http.MethodPost, "/articles", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+
+			var actual Article
+			err := Read(r, &actual, tc.opts...)
+			if tc.expectStatus != 0 {
+				is.MustEqual(t, true, err != nil)
+
+				rec := httptest.NewRecorder()
+				is.MustNoError(t, WriteError(rec, err))
+				is.Equal(t, tc.expectStatus, rec.Code)
+				is.Equal(t, MediaType, rec.Header().Get("Content-Type"))
+				is.Equal(t, true, strings.Contains(rec.Body.String(), fmt.Sprintf(`"status":"%d"`, tc.expectStatus)))
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &actual)
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	t.Parallel()
+
+	// rate limit errors received from another server, whose meta holds float64 or json.Number
+	rateLimitBody := []byte(`{"errors":[{"status":"429","code":"rate_limited","meta":{"retryAfter":3}}]}`)
+	decoded, err := UnmarshalErrors(rateLimitBody)
+	is.MustNoError(t, err)
+	decodedNumber, err := UnmarshalErrors(rateLimitBody, UnmarshalUseNumber())
+	is.MustNoError(t, err)
+
+	tests := []struct {
+		description      string
+		given            error
+		expectStatus     int
+		expectRetryAfter string
+	}{
+		{
+			description:  "single error object",
+			given:        &Error{Status: Status(http.StatusNotFound)},
+			expectStatus: http.StatusNotFound,
+		}, {
+			description:  "4xx error objects",
+			given:        errorList{&Error{Status: Status(http.StatusNotFound)}, &Error{Status: Status(http.StatusConflict)}},
+			expectStatus: http.StatusBadRequest,
+		}, {
+			description:  "4xx and 5xx error objects",
+			given:        errorList{&Error{Status: Status(http.StatusNotFound)}, errors.New("A")},
+			expectStatus: http.StatusInternalServerError,
+		}, {
+			description:  "unknown error",
+			given:        errors.New("A"),
+			expectStatus: http.StatusInternalServerError,
+		}, {
+			description:      "rate limit errors",
+			given:            errorList{NewRateLimitError(time.Second), NewRateLimitError(1500 * time.Millisecond)},
+			expectStatus:     http.StatusTooManyRequests,
+			expectRetryAfter: "2",
+		}, {
+			description:  "rate limit error without delay",
+			given:        NewRateLimitError(0),
+			expectStatus: http.StatusTooManyRequests,
+		}, {
+			description:      "rate limit error with int delay",
+			given:            &Error{Status: Status(http.StatusTooManyRequests), Code: CodeRateLimited, Meta: map[string]any{"retryAfter": 4}},
+			expectStatus:     http.StatusTooManyRequests,
+			expectRetryAfter: "4",
+		}, {
+			description:      "rate limit error with fractional delay",
+			given:            &Error{Status: Status(http.StatusTooManyRequests), Code: CodeRateLimited, Meta: map[string]any{"retryAfter": 1.5}},
+			expectStatus:     http.StatusTooManyRequests,
+			expectRetryAfter: "2",
+		}, {
+			description:      "unmarshaled rate limit error",
+			given:            ErrorList(decoded),
+			expectStatus:     http.StatusTooManyRequests,
+			expectRetryAfter: "3",
+		}, {
+			description:      "unmarshaled rate limit error with json.Number",
+			given:            ErrorList(decodedNumber),
+			expectStatus:     http.StatusTooManyRequests,
+			expectRetryAfter: "3",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			rec := httptest.NewRecorder()
+			err := WriteError(rec, tc.given)
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expectStatus, rec.Code)
+			is.Equal(t, MediaType, rec.Header().Get("Content-Type"))
+			is.Equal(t, tc.expectRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
+
+// errorList wraps many errors.
+type errorList []error
+
+func (el errorList) Error() string {
+	return fmt.Sprintf("%d errors", len(el))
+}
+
+func (el errorList) Unwrap() []error {
+	return el
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		method      string
+		ifMatch     []string
+		currentETag string
+		expect      *int
+	}{
+		{description: "GET unconditional", method: http.MethodGet, currentETag: "a", expect: nil},
+		{description: "POST unconditional", method: http.MethodPost, currentETag: "", expect: nil},
+		{description: "PATCH unconditional", method: http.MethodPatch, currentETag: "a", expect: Status(http.StatusPreconditionRequired)},
+		{description: "DELETE unconditional", method: http.MethodDelete, currentETag: "a", expect: Status(http.StatusPreconditionRequired)},
+		{description: "PATCH match", method: http.MethodPatch, ifMatch: []string{`"a"`}, currentETag: "a", expect: nil},
+		{description: "PATCH match quoted", method: http.MethodPatch, ifMatch: []string{`"a"`}, currentETag: `"a"`, expect: nil},
+		{description: "PATCH match list", method: http.MethodPatch, ifMatch: []string{`"b", "a"`}, currentETag: "a", expect: nil},
+		{description: "PATCH match multiple headers", method: http.MethodPatch, ifMatch: []string{`"b"`, `"a"`}, currentETag: "a", expect: nil},
+		{description: "PATCH match any", method: http.MethodPatch, ifMatch: []string{"*"}, currentETag: "a", expect: nil},
+		{description: "PATCH mismatch", method: http.MethodPatch, ifMatch: []string{`"b"`}, currentETag: "a", expect: Status(http.StatusPreconditionFailed)},
+		{description: "PATCH weak", method: http.MethodPatch, ifMatch: []string{`W/"a"`}, currentETag: `W/"a"`, expect: Status(http.StatusPreconditionFailed)},
+		{description: "PATCH any weak", method: http.MethodPatch, ifMatch: []string{"*"}, currentETag: `W/"a"`, expect: nil},
+		{description: "PATCH any missing resource", method: http.MethodPatch, ifMatch: []string{"*"}, currentETag: "", expect: Status(http.StatusPreconditionFailed)},
+		{description: "GET mismatch", method: http.MethodGet, ifMatch: []string{`"b"`}, currentETag: "a", expect: Status(http.StatusPreconditionFailed)},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			r := httptest.NewRequest(tc.method, "/articles/1", nil)
+			for _, v := range tc.ifMatch {
+				r.Header.Add("If-Match", v)
+			}
+
+			err := CheckPreconditions(r, tc.currentETag)
+			if tc.expect == nil {
+				is.Nil(t, err)
+				return
+			}
+			is.MustEqual(t, true, err != nil)
+			is.Equal(t, tc.expect, err.Status)
+			is.Equal(t, "If-Match", err.Source.Header)
+
+			_, merr := Marshal(err)
+			is.MustNoError(t, merr)
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		ifNoneMatch []string
+		etag        string
+		expect      bool
+	}{
+		{description: "unconditional", etag: `W/"a"`, expect: false},
+		{description: "weak match", ifNoneMatch: []string{`W/"a"`}, etag: `W/"a"`, expect: true},
+		{description: "weak and strong match", ifNoneMatch: []string{`"a"`}, etag: `W/"a"`, expect: true},
+		{description: "unquoted", ifNoneMatch: []string{`"a"`}, etag: "a", expect: true},
+		{description: "match list", ifNoneMatch: []string{`"b", W/"a"`}, etag: `W/"a"`, expect: true},
+		{description: "match multiple headers", ifNoneMatch: []string{`"b"`, `W/"a"`}, etag: `W/"a"`, expect: true},
+		{description: "match any", ifNoneMatch: []string{"*"}, etag: `W/"a"`, expect: true},
+		{description: "mismatch", ifNoneMatch: []string{`W/"b"`}, etag: `W/"a"`, expect: false},
+		{description: "missing resource", ifNoneMatch: []string{"*"}, etag: "", expect: false},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			r := httptest.NewRequest(http.MethodGet, "/articles/1", nil)
+			for _, v := range tc.ifNoneMatch {
+				r.Header.Add("If-None-Match", v)
+			}
+			is.Equal(t, tc.expect, NotModified(r, tc.etag))
+		})
+	}
+}
+
+// failingResponseWriter is an http.ResponseWriter whose writes fail after the first n bytes.
+type failingResponseWriter struct {
+	*httptest.ResponseRecorder
+	fw failingWriter
+}
+
+func (w *failingResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.fw.Write(p)
+	_, _ = w.ResponseRecorder.Write(p[:n])
+	return n, err
+}
+
+func TestWriteFailureLeavesStatusUnwritten(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+	}{
+		{
+			description: "*ArticleWithGenericMeta with invalid meta member name",
+			given:       &articleWithInvalidResourceMetaMemberName,
+		}, {
+			description: "[]*ArticleWithGenericMeta with invalid meta member name",
+			given:       []*ArticleWithGenericMeta{&articleWithInvalidResourceMetaMemberName, {ID: "1"}},
+		}, {
+			description: "[]*ArticleFailing failing first",
+			given:       []*ArticleFailing{{ID: "1", Fail: true}, {ID: "2"}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			rec := httptest.NewRecorder()
+			err := Write(rec, http.StatusCreated, tc.given)
+			is.MustError(t, err)
+			is.Equal(t, false, rec.Flushed)
+			is.Equal(t, 0, rec.Body.Len())
+
+			// the error can be written instead, with its own status code, which depends on the json
+			// backend
+			is.MustNoError(t, WriteError(rec, err))
+			is.Equal(t, errorsStatus(ErrorObjects(err)), rec.Code)
+			is.Equal(t, true, strings.HasPrefix(rec.Body.String(), `{"errors":`))
+		})
+	}
+}
+
+func TestWriteStreamFailureAbort(t *testing.T) {
+	t.Parallel()
+
+	articles := []*Article{{ID: "1", Title: "A"}, {ID: "2", Title: "B"}}
+
+	rvr := func() (rvr any) {
+		defer func() { rvr = recover() }()
+		w := &failingResponseWriter{ResponseRecorder: httptest.NewRecorder(), fw: failingWriter{n: 20, err: errors.New("write failed")}}
+		_ = Write(w, http.StatusOK, articles, MarshalStreamFailure(StreamFailureAbort))
+		return nil
+	}()
+	is.Equal(t, http.ErrAbortHandler, rvr)
+
+	// failures before anything is written are returned as usual
+	err := Write(httptest.NewRecorder(), http.StatusOK, &ArticleFailing{ID: "1", Fail: true}, MarshalStreamFailure(StreamFailureAbort))
+	is.MustError(t, err)
+}
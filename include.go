@@ -0,0 +1,295 @@
+package jsonapi
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// IncludeResolver can be implemented to load the related resources of a relationship on demand
+// when marshaling compound documents as defined by https://jsonapi.org/format/#document-compound-documents.
+//
+// Resolve is only called for relationships requested via the include paths given to
+// MarshalIncludeResolver, so related resources don't need to be preloaded into the primary data.
+// The parent is the resource object (a struct or a pointer to one) the relation belongs to, and the
+// returned values must be resource objects as well.
+type IncludeResolver interface {
+	Resolve(ctx context.Context, parent any, relation string) ([]any, error)
+}
+
+// BatchIncludeResolver can optionally be implemented by an IncludeResolver to resolve a relationship
+// for many parents at once. The returned slice must contain the related resources of parents[i] at
+// index i.
+type BatchIncludeResolver interface {
+	IncludeResolver
+	ResolveBatch(ctx context.Context, parents []any, relation string) ([][]any, error)
+}
+
+// IncludeResolverFunc is an adapter to allow the use of an ordinary function as an IncludeResolver.
+type IncludeResolverFunc func(ctx context.Context, parent any, relation string) ([]any, error)
+
+// Resolve implements the IncludeResolver interface.
+func (f IncludeResolverFunc) Resolve(ctx context.Context, parent any, relation string) ([]any, error) {
+	return f(ctx, parent, relation)
+}
+
+// IncludeAuthorizer decides whether the related resources of the relationship named relation of
+// resources of the given type may be included in a compound document.
+type IncludeAuthorizer func(ctx context.Context, resourceType, relation string) bool
+
+// authorized returns true if the related resources of the given relationship may be included.
+func (m *Marshaler) authorized(resourceType, relation string) bool {
+	return m.includeAuthorizer == nil || m.includeAuthorizer(m.context(), resourceType, relation)
+}
+
+// parseIncludePaths splits comma separated include paths (e.g. "author,comments.author") into their
+// dot separated relationship names.
+func parseIncludePaths(paths []string) [][]string {
+	parsed := make([][]string, 0, len(paths))
+	for _, path := range paths {
+		for _, p := range strings.Split(path, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				parsed = append(parsed, strings.Split(p, "."))
+			}
+		}
+	}
+	return parsed
+}
+
+// findRelationshipField returns the relationship field of the given resource object value whose
+// member name is relation, as derived with the given NamingConvention, along with its value.
+func findRelationshipField(v any, relation string, naming NamingConvention) (reflect.Value, reflect.StructField, bool) {
+	if v == nil || derefType(reflect.TypeOf(v)).Kind() != reflect.Struct {
+		return reflect.Value{}, reflect.StructField{}, false
+	}
+	for _, field := range getFlattenedFields(v) {
+		tag, err := parseJSONAPITag(field.f)
+		if err != nil || tag == nil || tag.directive != relationship {
+			continue
+		}
+		if name, ok, _ := memberName(field.f, naming); ok && name == relation {
+			return field.v, field.f, true
+		}
+	}
+	return reflect.Value{}, reflect.StructField{}, false
+}
+
+// resolvedNode is a resource object created while resolving include paths, along with the value it
+// was created from.
+type resolvedNode struct {
+	v  any
+	ro *resourceObject
+}
+
+// includeResolution tracks the state of resolving the include paths of a single document.
+type includeResolution struct {
+	m        *Marshaler
+	d        *document
+	resolved map[string]*resolvedNode
+}
+
+func (ir *includeResolution) resolve(ctx context.Context, parents []*resolvedNode, relation string) ([][]any, error) {
+	values := make([]any, len(parents))
+	for i, parent := range parents {
+		values[i] = parent.v
+	}
+
+	if br, ok := ir.m.includeResolver.(BatchIncludeResolver); ok {
+		return br.ResolveBatch(ctx, values, relation)
+	}
+
+	related := make([][]any, len(parents))
+	for i, v := range values {
+		rvs, err := ir.m.includeResolver.Resolve(ctx, v, relation)
+		if err != nil {
+			return nil, err
+		}
+		related[i] = rvs
+	}
+	return related, nil
+}
+
+// link adds resource linkage from the parent resource object to the given related resource objects.
+func (ir *includeResolution) link(parent *resolvedNode, relation string, field reflect.StructField, related []*resourceObject) error {
+	rd, ok := parent.ro.Relationships[relation]
+	if !ok {
+		link, err := ir.m.relationLink(parent.v, relation)
+		if err != nil {
+			return err
+		}
+		rd = newDocument()
+		rd.hasMany = derefType(field.Type).Kind() == reflect.Slice
+		rd.Links = link
+		parent.ro.Relationships[relation] = rd
+	}
+	// relationships on include paths have resource linkage even if they are links-only
+	rd.noData = false
+
+	if !rd.hasMany {
+		if len(related) > 0 {
+			rd.DataOne = &resourceObject{Type: related[0].Type, ID: related[0].ID}
+		}
+		return nil
+	}
+
+	linked := make(map[string]bool, len(rd.DataMany))
+	for _, ro := range rd.DataMany {
+		linked[ro.identifier()] = true
+	}
+	for _, ro := range related {
+		if !linked[ro.identifier()] {
+			linked[ro.identifier()] = true
+			rd.DataMany = append(rd.DataMany, &resourceObject{Type: ro.Type, ID: ro.ID})
+		}
+	}
+	return nil
+}
+
+// include follows the given include path from its i'th relationship starting from the given
+// parents, adding every resolved resource object to the document's included resources.
+func (ir *includeResolution) include(ctx context.Context, parents []*resolvedNode, path []string, i int) error {
+	if i >= len(path) || len(parents) == 0 {
+		return nil
+	}
+	relation := path[i]
+
+	authorized := make([]*resolvedNode, 0, len(parents))
+	for _, parent := range parents {
+		if _, _, ok := findRelationshipField(parent.v, relation, ir.m.naming); !ok {
+			return &IncludePathError{Path: strings.Join(path, "."), Reason: "unknown relationship " + relation}
+		}
+		if ir.m.authorized(parent.ro.Type, relation) {
+			authorized = append(authorized, parent)
+		}
+	}
+	if parents = authorized; len(parents) == 0 {
+		return nil
+	}
+
+	related, err := ir.resolve(ctx, parents, relation)
+	if err != nil {
+		return err
+	}
+	if len(related) != len(parents) {
+		return &IncludePathError{Path: strings.Join(path, "."), Reason: "resolver returned an unexpected number of results"}
+	}
+
+	next := make([]*resolvedNode, 0)
+	seen := make(map[*resolvedNode]bool)
+	for j, parent := range parents {
+		_, field, _ := findRelationshipField(parent.v, relation, ir.m.naming)
+
+		ros := make([]*resourceObject, 0, len(related[j]))
+		for _, rv := range related[j] {
+			ro, err := makeResourceObject(rv, reflect.TypeOf(rv), ir.m, false)
+			if err != nil {
+				return err
+			}
+
+			node, ok := ir.resolved[ro.identifier()]
+			if !ok {
+				node = &resolvedNode{v: rv, ro: ro}
+				ir.resolved[ro.identifier()] = node
+				ir.d.Included = append(ir.d.Included, ro)
+			}
+			ros = append(ros, node.ro)
+			if !seen[node] {
+				seen[node] = true
+				next = append(next, node)
+			}
+		}
+
+		if err := ir.link(parent, relation, field, ros); err != nil {
+			return err
+		}
+	}
+
+	return ir.include(ctx, next, path, i+1)
+}
+
+// newIncludeResolution creates the state of resolving the include paths of the given document with
+// the Marshaler's IncludeResolver, or returns nil if it has none.
+func newIncludeResolution(d *document, m *Marshaler) *includeResolution {
+	if m.includeResolver == nil {
+		return nil
+	}
+	return &includeResolution{m: m, d: d, resolved: make(map[string]*resolvedNode)}
+}
+
+// add adds the given nodes of primary data or included resources of the document, so that they
+// aren't included again.
+func (ir *includeResolution) add(nodes []*resolvedNode) {
+	for _, node := range nodes {
+		ir.resolved[node.ro.identifier()] = node
+	}
+}
+
+// resolveIncludes uses the Marshaler's IncludeResolver to build the included resources of the
+// given nodes of primary data, which must have been added to ir.
+func (ir *includeResolution) resolveIncludes(primary []*resolvedNode) error {
+	for _, path := range parseIncludePaths(ir.m.includePaths) {
+		if err := ir.include(ir.m.context(), primary, path, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorizeIncludes removes the included resources of the given document which are not reachable
+// from primary data via relationships authorized by the Marshaler's IncludeAuthorizer.
+func authorizeIncludes(d *document, m *Marshaler) {
+	if m.includeAuthorizer == nil {
+		return
+	}
+	pruneIncludes(d, m.authorized)
+}
+
+// pruneIncludes removes the included resources of the given document which are not reachable from
+// primary data via the relationships for which follow returns true.
+func pruneIncludes(d *document, follow func(resourceType, relation string) bool) {
+	if len(d.Included) == 0 {
+		return
+	}
+
+	included := make(map[string]*resourceObject, len(d.Included))
+	for _, ro := range d.Included {
+		included[ro.identifier()] = ro
+	}
+
+	queue := make([]*resourceObject, 0, len(d.DataMany)+1)
+	queue = append(queue, d.DataMany...)
+	if d.DataOne != nil {
+		queue = append(queue, d.DataOne)
+	}
+
+	reachable := make(map[string]bool)
+	for len(queue) > 0 {
+		ro := queue[0]
+		queue = queue[1:]
+
+		for name, rel := range ro.Relationships {
+			if !follow(ro.Type, name) {
+				continue
+			}
+			linkage := rel.DataMany
+			if rel.DataOne != nil {
+				linkage = append(linkage, rel.DataOne)
+			}
+			for _, ri := range linkage {
+				id := ri.identifier()
+				if iro, ok := included[id]; ok && !reachable[id] {
+					reachable[id] = true
+					queue = append(queue, iro)
+				}
+			}
+		}
+	}
+
+	authorized := make([]*resourceObject, 0, len(reachable))
+	for _, ro := range d.Included {
+		if reachable[ro.identifier()] {
+			authorized = append(authorized, ro)
+		}
+	}
+	d.Included = authorized
+}
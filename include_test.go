@@ -0,0 +1,262 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// articleResolver resolves the relationships of ArticleRelated and Comment values.
+func articleResolver(ctx context.Context, parent any, relation string) ([]any, error) {
+	switch p := parent.(type) {
+	case *ArticleRelated:
+		switch relation {
+		case "author":
+			return []any{&authorA}, nil
+		case "comments":
+			return []any{&commentA, &commentB}, nil
+		}
+	case *Comment:
+		if relation == "author" {
+			return []any{&authorA}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unexpected parent %T", p)
+	}
+	return nil, nil
+}
+
+type batchArticleResolver struct {
+	calls int
+}
+
+func (r *batchArticleResolver) Resolve(ctx context.Context, parent any, relation string) ([]any, error) {
+	return nil, fmt.Errorf("unexpected call to Resolve")
+}
+
+func (r *batchArticleResolver) ResolveBatch(ctx context.Context, parents []any, relation string) ([][]any, error) {
+	r.calls++
+	related := make([][]any, len(parents))
+	for i, parent := range parents {
+		rvs, err := articleResolver(ctx, parent, relation)
+		if err != nil {
+			return nil, err
+		}
+		related[i] = rvs
+	}
+	return related, nil
+}
+
+func TestMarshalIncludeResolver(t *testing.T) {
+	t.Parallel()
+
+	articleAuthorBody := `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}}}},"included":[{"id":"1","type":"author","attributes":{"name":"A"}}]}`
+	articleCommentsAuthorBody := `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"comments":{"data":[{"id":"1","type":"comments"},{"id":"2","type":"comments"}],"links":{"self":"http://example.com/articles/1/relationships/comments","related":"http://example.com/articles/1/comments"}}}},"included":[{"id":"1","type":"comments","attributes":{"body":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/comments/1/relationships/author","related":"http://example.com/comments/1/author"}}}},{"id":"2","type":"comments","attributes":{"body":"B"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/comments/2/relationships/author","related":"http://example.com/comments/2/author"}}}},{"id":"1","type":"author","attributes":{"name":"A"}}]}`
+	articlesAuthorBody := `{"data":[{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}}}},{"id":"2","type":"articles","attributes":{"title":"B"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/articles/2/relationships/author","related":"http://example.com/articles/2/author"}}}}],"included":[{"id":"1","type":"author","attributes":{"name":"A"}}]}`
+
+	tests := []struct {
+		description string
+		given       any
+		paths       []string
+		expect      string
+		expectError error
+	}{
+		{
+			description: "no include paths",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			paths:       nil,
+			expect:      articleABody,
+		}, {
+			description: "to-one",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			paths:       []string{"author"},
+			expect:      articleAuthorBody,
+		}, {
+			description: "nested to-many, deduplicated",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			paths:       []string{"comments.author"},
+			expect:      articleCommentsAuthorBody,
+		}, {
+			description: "many primary resources, deduplicated",
+			given:       []*ArticleRelated{{ID: "1", Title: "A"}, {ID: "2", Title: "B"}},
+			paths:       []string{"author"},
+			expect:      articlesAuthorBody,
+		}, {
+			description: "unknown relationship",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			paths:       []string{"author.comments"},
+			expectError: &IncludePathError{Path: "author.comments", Reason: "unknown relationship comments"},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given, MarshalIncludeResolver(IncludeResolverFunc(articleResolver), tc.paths...))
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+
+			r := new(batchArticleResolver)
+			actual, err = Marshal(tc.given, MarshalIncludeResolver(r, tc.paths...))
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestMarshalIncludeResolverBatch(t *testing.T) {
+	t.Parallel()
+
+	r := new(batchArticleResolver)
+	articles := []*ArticleRelated{{ID: "1", Title: "A"}, {ID: "2", Title: "B"}}
+
+	_, err := Marshal(articles, MarshalIncludeResolver(r, "author,comments.author"))
+	is.MustNoError(t, err)
+
+	// one call for each relationship in each path, regardless of the number of parents
+	is.Equal(t, 3, r.calls)
+}
+
+func TestMarshalIncludeAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	// comment authors are not visible
+	authorizer := func(ctx context.Context, resourceType, relation string) bool {
+		return !(resourceType == "comments" && relation == "author")
+	}
+
+	articleAuthorBody := `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}}}},"included":[{"id":"1","type":"author","attributes":{"name":"A"}}]}`
+	articleCommentsBody := `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"comments":{"data":[{"id":"1","type":"comments"},{"id":"2","type":"comments"}],"links":{"self":"http://example.com/articles/1/relationships/comments","related":"http://example.com/articles/1/comments"}}}},"included":[{"id":"1","type":"comments","attributes":{"body":"A"}},{"id":"2","type":"comments","attributes":{"body":"B"}}]}`
+	articleAuthorLinkageBody := `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}}}}}`
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+	}{
+		{
+			description: "resolver, authorized",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			opts:        []MarshalOption{MarshalIncludeResolver(IncludeResolverFunc(articleResolver), "author")},
+			expect:      articleAuthorBody,
+		}, {
+			description: "resolver, nested relationship not authorized",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			opts:        []MarshalOption{MarshalIncludeResolver(IncludeResolverFunc(articleResolver), "comments.author")},
+			expect:      articleCommentsBody,
+		}, {
+			description: "explicit include, authorized",
+			given:       &ArticleRelated{ID: "1", Title: "A", Author: &authorA},
+			opts:        []MarshalOption{MarshalInclude(&authorA)},
+			expect:      articleAuthorBody,
+		}, {
+			description: "explicit include, not authorized",
+			given:       &ArticleRelated{ID: "1", Title: "A", Author: &authorA},
+			opts: []MarshalOption{MarshalInclude(&authorA), MarshalIncludeAuthorizer(func(ctx context.Context, resourceType, relation string) bool {
+				return false
+			})},
+			expect: articleAuthorLinkageBody,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given, append([]MarshalOption{MarshalIncludeAuthorizer(authorizer)}, tc.opts...)...)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestMarshalIncludeAuthorizerSkipsResolver(t *testing.T) {
+	t.Parallel()
+
+	r := new(batchArticleResolver)
+	authorizer := func(ctx context.Context, resourceType, relation string) bool {
+		return relation != "comments"
+	}
+
+	_, err := Marshal(&ArticleRelated{ID: "1", Title: "A"}, MarshalIncludeResolver(r, "author,comments.author"), MarshalIncludeAuthorizer(authorizer))
+	is.MustNoError(t, err)
+
+	// the comments relationship and everything below it is never resolved
+	is.Equal(t, 1, r.calls)
+}
+
+func TestMarshalCyclicGraph(t *testing.T) {
+	t.Parallel()
+
+	writer, articles := newWriterGraph()
+	resolver := IncludeResolverFunc(func(ctx context.Context, parent any, relation string) ([]any, error) {
+		switch p := parent.(type) {
+		case *Writer:
+			related := make([]any, len(p.Articles))
+			for i, a := range p.Articles {
+				related[i] = a
+			}
+			return related, nil
+		case *WrittenArticle:
+			return []any{p.Writer}, nil
+		}
+		return nil, fmt.Errorf("unexpected parent %T", parent)
+	})
+
+	writerBody := `{"id":"1","type":"writers","relationships":{"articles":{"data":[{"id":"1","type":"articles"},{"id":"2","type":"articles"}]}}}`
+	article1Body := `{"id":"1","type":"articles","relationships":{"writer":{"data":{"id":"1","type":"writers"}}}}`
+	article2Body := `{"id":"2","type":"articles","relationships":{"writer":{"data":{"id":"1","type":"writers"}}}}`
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+	}{
+		{
+			description: "included resources are deduplicated",
+			given:       articles[0],
+			opts:        []MarshalOption{MarshalInclude(writer, writer, articles[1])},
+			expect:      `{"data":` + article1Body + `,"included":[` + writerBody + `,` + article2Body + `]}`,
+		}, {
+			description: "primary data isn't included",
+			given:       articles,
+			opts:        []MarshalOption{MarshalInclude(writer, articles[0], articles[1])},
+			expect:      `{"data":[` + article1Body + `,` + article2Body + `],"included":[` + writerBody + `]}`,
+		}, {
+			description: "include paths back to primary data",
+			given:       articles[0],
+			opts:        []MarshalOption{MarshalIncludeResolver(resolver, "writer.articles.writer.articles")},
+			expect:      `{"data":` + article1Body + `,"included":[` + writerBody + `,` + article2Body + `]}`,
+		}, {
+			description: "include paths back to included resources",
+			given:       writer,
+			opts:        []MarshalOption{MarshalInclude(articles[1]), MarshalIncludeResolver(resolver, "articles.writer")},
+			expect:      `{"data":` + writerBody + `,"included":[` + article2Body + `,` + article1Body + `]}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// IncludedIndex provides access to the included resources of a compound document as defined by
+// https://jsonapi.org/format/#document-compound-documents, by their type and id.
+type IncludedIndex struct {
+	m         *Unmarshaler
+	included  []*resourceObject
+	resources map[string]*resourceObject
+}
+
+func newIncludedIndex(d *document, m *Unmarshaler) IncludedIndex {
+	idx := IncludedIndex{
+		m:         m,
+		included:  d.Included,
+		resources: make(map[string]*resourceObject, len(d.Included)),
+	}
+	for _, ro := range d.Included {
+		idx.resources[ro.identifier()] = ro
+	}
+	return idx
+}
+
+// Len returns the number of included resources.
+func (idx IncludedIndex) Len() int {
+	return len(idx.resources)
+}
+
+// Has returns true if the resource with the given type and id is included.
+func (idx IncludedIndex) Has(resourceType, id string) bool {
+	_, ok := idx.resources[resourceIdentifier(resourceType, id)]
+	return ok
+}
+
+// Unmarshal stores the included resource with the given type and id in the value pointed to by v.
+// If the resource is not included, Unmarshal returns ErrIncludedResourceNotFound.
+func (idx IncludedIndex) Unmarshal(resourceType, id string, v any) (err error) {
+	defer func() {
+		// because we make use of reflect we must recover any panics
+		if rvr := recover(); rvr != nil {
+			err = recoverError(rvr)
+			return
+		}
+	}()
+
+	ro, ok := idx.resources[resourceIdentifier(resourceType, id)]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrIncludedResourceNotFound, resourceIdentifier(resourceType, id))
+	}
+
+	return ro.unmarshal(v, idx.m.relationshipUnmarshaler())
+}
+
+// LookupIncluded returns the included resource with the given id, and the resource type given by the
+// primary field of T. If the resource is not included, LookupIncluded returns
+// ErrIncludedResourceNotFound.
+func LookupIncluded[T any](idx IncludedIndex, id string) (*T, error) {
+	resourceType, err := resourceTypeOf(reflect.TypeOf((*T)(nil)))
+	if err != nil {
+		return nil, err
+	}
+
+	v := new(T)
+	if err := idx.Unmarshal(resourceType, id, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// IncludedOfType returns all included resources of the resource type given by the primary field of
+// T, in the order they appear in the document.
+func IncludedOfType[T any](idx IncludedIndex) ([]*T, error) {
+	resourceType, err := resourceTypeOf(reflect.TypeOf((*T)(nil)))
+	if err != nil {
+		return nil, err
+	}
+
+	vs := make([]*T, 0)
+	for _, ro := range idx.included {
+		if ro.Type != resourceType {
+			continue
+		}
+		v := new(T)
+		if err := idx.Unmarshal(ro.Type, ro.ID, v); err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	return vs, nil
+}
+
+// UnmarshalWithIncluded behaves like Unmarshal, but instead of populating relationship fields with
+// the included resources of a compound document, it populates them with resource linkage only and
+// returns an IncludedIndex to look up the included resources.
+func UnmarshalWithIncluded(data []byte, v any, opts ...UnmarshalOption) (idx IncludedIndex, err error) {
+	// copy the options, as appending to opts could write to a slice shared with other goroutines
+	m := makeUnmarshaler(append(append([]UnmarshalOption{}, opts...), UnmarshalLinkageOnly())...)
+
+	err = m.observe(func(op *Operation) error {
+		op.Size = len(data)
+		d, err := m.unmarshal(data, v)
+		op.add(d)
+		if err != nil {
+			return err
+		}
+
+		idx = newIncludedIndex(d, m)
+		return nil
+	})
+
+	return
+}
@@ -0,0 +1,61 @@
+package jsonapi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestUnmarshalWithIncluded(t *testing.T) {
+	t.Parallel()
+
+	var a ArticleRelated
+	idx, err := UnmarshalWithIncluded([]byte(articleRelatedCompleteWithIncludeBody), &a)
+	is.MustNoError(t, err)
+
+	// relationships are populated with resource linkage only
+	is.Equal(t, &ArticleRelated{
+		ID:       "1",
+		Title:    "A",
+		Author:   &Author{ID: "1"},
+		Comments: []*Comment{{ID: "1"}, {ID: "2"}},
+	}, &a)
+
+	is.Equal(t, 3, idx.Len())
+	is.Equal(t, true, idx.Has("author", "1"))
+	is.Equal(t, true, idx.Has("comments", "2"))
+	is.Equal(t, false, idx.Has("comments", "3"))
+
+	var author Author
+	is.MustNoError(t, idx.Unmarshal("author", "1", &author))
+	is.Equal(t, authorA, author)
+
+	comment, err := LookupIncluded[Comment](idx, "2")
+	is.MustNoError(t, err)
+	is.Equal(t, &commentB, comment)
+
+	_, err = LookupIncluded[Comment](idx, "3")
+	is.Equal(t, true, errors.Is(err, ErrIncludedResourceNotFound))
+
+	comments, err := IncludedOfType[Comment](idx)
+	is.MustNoError(t, err)
+	is.Equal(t, commentsAB, comments)
+
+	_, err = IncludedOfType[string](idx)
+	is.EqualError(t, &TypeError{Actual: "string", Expected: []string{"struct"}}, err)
+}
+
+func TestUnmarshalWithIncludedNoIncluded(t *testing.T) {
+	t.Parallel()
+
+	var a Article
+	idx, err := UnmarshalWithIncluded([]byte(articleABody), &a)
+	is.MustNoError(t, err)
+	is.Equal(t, articleA, a)
+	is.Equal(t, 0, idx.Len())
+
+	authors, err := IncludedOfType[Author](idx)
+	is.MustNoError(t, err)
+	is.Equal(t, []*Author{}, authors)
+}
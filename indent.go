@@ -0,0 +1,80 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MarshalEscapeHTML sets whether the characters <, > and & are escaped in strings as \u003c,
+// \u003e and \u0026, as done by default like json.Encoder.SetEscapeHTML. Disabling it keeps URLs
+// in links readable, e.g. "/articles?page[number]=2&page[size]=10".
+func MarshalEscapeHTML(escape bool) MarshalOption {
+	return func(m *Marshaler) {
+		m.noEscapeHTML = !escape
+	}
+}
+
+// MarshalIndent indents documents like json.Encoder.SetIndent, e.g. for human-facing or debug
+// endpoints: each json element begins on a new line starting with prefix followed by one or more
+// copies of indent according to its nesting. Documents marshaled with MarshalIndent are written as
+// a whole rather than incrementally by MarshalTo and Write.
+func MarshalIndent(prefix, indent string) MarshalOption {
+	return func(m *Marshaler) {
+		m.indentPrefix = prefix
+		m.indent = indent
+	}
+}
+
+// formatted returns true if documents are reformatted by format once encoded.
+func (m *Marshaler) formatted() bool {
+	return m.noEscapeHTML || m.indentPrefix != "" || m.indent != ""
+}
+
+// format returns the encoded document b formatted as given by MarshalEscapeHTML and MarshalIndent.
+func (m *Marshaler) format(b []byte) ([]byte, error) {
+	if m.noEscapeHTML {
+		b = unescapeHTML(b)
+	}
+	if m.indentPrefix != "" || m.indent != "" {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, b, m.indentPrefix, m.indent); err != nil {
+			return nil, err
+		}
+		b = buf.Bytes()
+	}
+	return b, nil
+}
+
+// htmlEscapes are the escape sequences of the characters escaped for HTML by encoding/json.
+var htmlEscapes = map[string]byte{
+	"003c": '<',
+	"003e": '>',
+	"0026": '&',
+}
+
+// unescapeHTML returns the json encoded data with the characters <, > and & unescaped in strings.
+// Values encoded by json.Marshaler implementations are escaped regardless of the encoder settings,
+// so the whole document is unescaped once encoded.
+func unescapeHTML(data []byte) []byte {
+	if !bytes.Contains(data, []byte(`\u00`)) {
+		return data
+	}
+	b := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\\' || i+1 == len(data) {
+			b = append(b, data[i])
+			continue
+		}
+		if data[i+1] == 'u' && i+6 <= len(data) {
+			if c, ok := htmlEscapes[string(bytes.ToLower(data[i+2:i+6]))]; ok {
+				b = append(b, c)
+				i += 5
+				continue
+			}
+		}
+		// copy escape sequences as is, so that escaped backslashes aren't taken for escapes
+		b = append(b, data[i], data[i+1])
+		i++
+	}
+	return b
+}
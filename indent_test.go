@@ -0,0 +1,126 @@
+package jsonapi
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalFormatting(t *testing.T) {
+	t.Parallel()
+
+	article := &Article{ID: "1", Title: "<b>A & B</b>"}
+	articles := []*Article{{ID: "1", Title: "A"}, {ID: "2", Title: "B"}}
+	link := MarshalLinks(&Link{Self: "http://example.com/articles?page[number]=2&page[size]=10"})
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+	}{
+		{
+			description: "escaped by default",
+			given:       article,
+			opts:        []MarshalOption{link},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"\u003cb\u003eA \u0026 B\u003c/b\u003e"}},"links":{"self":"http://example.com/articles?page[number]=2\u0026page[size]=10"}}`,
+		}, {
+			description: "not escaped",
+			given:       article,
+			opts:        []MarshalOption{link, MarshalEscapeHTML(false)},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"<b>A & B</b>"}},"links":{"self":"http://example.com/articles?page[number]=2&page[size]=10"}}`,
+		}, {
+			description: "escaped backslashes",
+			given:       &Article{ID: "1", Title: `\u0026`},
+			opts:        []MarshalOption{MarshalEscapeHTML(false)},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"\\u0026"}}}`,
+		}, {
+			description: "indented",
+			given:       articles,
+			opts:        []MarshalOption{MarshalIndent("", "  ")},
+			expect: `{
+  "data": [
+    {
+      "id": "1",
+      "type": "articles",
+      "attributes": {
+        "title": "A"
+      }
+    },
+    {
+      "id": "2",
+      "type": "articles",
+      "attributes": {
+        "title": "B"
+      }
+    }
+  ]
+}`,
+		}, {
+			description: "indented with prefix",
+			given:       &Article{ID: "1", Title: "A&B"},
+			opts:        []MarshalOption{MarshalIndent("> ", "\t"), MarshalEscapeHTML(false)},
+			expect:      "{\n> \t\"data\": {\n> \t\t\"id\": \"1\",\n> \t\t\"type\": \"articles\",\n> \t\t\"attributes\": {\n> \t\t\t\"title\": \"A&B\"\n> \t\t}\n> \t}\n> }",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, string(b))
+
+			// documents written incrementally are formatted the same
+			var buf bytes.Buffer
+			is.MustNoError(t, MarshalTo(&buf, tc.given, tc.opts...))
+			is.Equal(t, tc.expect, buf.String())
+		})
+	}
+}
+
+func TestMarshalFormattingEntryPoints(t *testing.T) {
+	t.Parallel()
+
+	article := &ArticleRelated{ID: "1", Title: "A", Author: &Author{ID: "1", Name: "A&B"}}
+	opts := []MarshalOption{MarshalIndent("", " "), MarshalEscapeHTML(false), MarshalLinks(&Link{Self: "http://example.com/?a=1&b=2"})}
+
+	tests := []struct {
+		description string
+		marshal     func() ([]byte, error)
+		expect      string
+	}{
+		{
+			description: "MarshalRef",
+			marshal:     func() ([]byte, error) { return MarshalRef(article, "author", opts...) },
+			expect:      "{\n \"data\": {\n  \"id\": \"1\",\n  \"type\": \"author\"\n },\n \"links\": {\n  \"self\": \"http://example.com/?a=1&b=2\"\n }\n}",
+		}, {
+			description: "MarshalRelated",
+			marshal:     func() ([]byte, error) { return MarshalRelated(article, "author", opts...) },
+			expect:      "{\n \"data\": {\n  \"id\": \"1\",\n  \"type\": \"author\",\n  \"attributes\": {\n   \"name\": \"A&B\"\n  }\n },\n \"links\": {\n  \"self\": \"http://example.com/?a=1&b=2\"\n }\n}",
+		}, {
+			description: "MarshalInfo",
+			marshal: func() ([]byte, error) {
+				return MarshalInfo(map[string]any{"status": "A&B"}, &Link{Self: "http://example.com/?a=1&b=2"}, opts...)
+			},
+			expect: "{\n \"meta\": {\n  \"status\": \"A&B\"\n },\n \"links\": {\n  \"self\": \"http://example.com/?a=1&b=2\"\n }\n}",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := tc.marshal()
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, string(b))
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// MetaSchema validates the structure of the top-level meta object of documents.
+type MetaSchema interface {
+	// ValidateMeta returns an error if the given json encoded meta object is invalid.
+	ValidateMeta(meta []byte) error
+}
+
+// MetaSchemaFunc is an adapter to allow the use of an ordinary function as a MetaSchema.
+type MetaSchemaFunc func(meta []byte) error
+
+// ValidateMeta implements the MetaSchema interface.
+func (f MetaSchemaFunc) ValidateMeta(meta []byte) error {
+	return f(meta)
+}
+
+// TypedMeta returns a MetaSchema requiring meta objects to decode into a T without unknown members.
+// If *T has a Validate() error method, decoded meta objects must be valid as well.
+func TypedMeta[T any]() MetaSchema {
+	return MetaSchemaFunc(func(meta []byte) error {
+		var v T
+		dec := json.NewDecoder(bytes.NewReader(meta))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		if validator, ok := any(&v).(interface{ Validate() error }); ok {
+			return validator.Validate()
+		}
+		return nil
+	})
+}
+
+// MarshalMetaSchema registers the schema the top-level meta of marshaled documents must conform to,
+// if present.
+func MarshalMetaSchema(s MetaSchema) MarshalOption {
+	return func(m *Marshaler) {
+		m.metaSchema = s
+	}
+}
+
+// UnmarshalMetaSchema registers the schema the top-level meta of unmarshaled documents must conform
+// to, if present.
+func UnmarshalMetaSchema(s MetaSchema) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.metaSchema = s
+	}
+}
+
+// validateMeta returns an error if the given json encoded top-level meta doesn't conform to the given
+// schema.
+func validateMeta(s MetaSchema, meta []byte) error {
+	if s == nil || len(meta) == 0 || string(meta) == "null" {
+		return nil
+	}
+	if err := s.ValidateMeta(meta); err != nil {
+		return &DocumentError{Code: CodeInvalidMeta, Pointer: "/meta", Err: err}
+	}
+	return nil
+}
+
+// MarshalInfo returns the json:api encoding of a document without primary data, consisting of the
+// given top-level meta and links (which may be nil), as served by e.g. health or capability
+// endpoints. The meta must be a non-nil map or struct, as documents must contain at least one of the
+// data, errors and meta members per https://jsonapi.org/format/#document-top-level.
+//
+// Options setting the meta or links of the document are overridden by the given meta and links.
+func MarshalInfo(meta any, links *Link, opts ...MarshalOption) (b []byte, err error) {
+	m := makeMarshaler(append(append([]MarshalOption{}, opts...), MarshalMeta(meta), MarshalLinks(links))...)
+
+	err = m.observe(func(op *Operation) error {
+		// a nil meta, including a nil map or pointer, would be encoded as null
+		if isNilMeta(meta) {
+			return &TypeError{Actual: "nil", Expected: []string{"struct", "map"}}
+		}
+
+		d := newDocument()
+		d.noData = true
+		if err := addOptionalDocumentFields(d, m); err != nil {
+			return err
+		}
+
+		var err error
+		b, err = m.appendDocument(nil, d)
+		op.Size = len(b)
+		return err
+	})
+
+	return
+}
+
+// isNilMeta returns true if meta is nil, a nil map or a nil pointer.
+func isNilMeta(meta any) bool {
+	rv := reflect.ValueOf(meta)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Map, reflect.Pointer:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// DecodeMeta decodes the top-level meta of a document stored in info by UnmarshalDocumentInfo into a
+// T, e.g. to read the pagination totals of a collection document. It returns the zero value of T if
+// the document has no meta.
+//
+//	type page struct {
+//		Total int `json:"total"`
+//	}
+//	var info jsonapi.DocumentInfo
+//	err := jsonapi.Unmarshal(body, &articles, jsonapi.UnmarshalDocumentInfo(&info))
+//	...
+//	p, err := jsonapi.DecodeMeta[page](&info)
+func DecodeMeta[T any](info *DocumentInfo) (T, error) {
+	var meta T
+	if len(info.Meta) == 0 || string(info.Meta) == "null" {
+		return meta, nil
+	}
+	if err := json.Unmarshal(info.Meta, &meta); err != nil {
+		return meta, &DocumentError{Code: CodeInvalidMeta, Pointer: "/meta", Err: err}
+	}
+	return meta, nil
+}
+
+// topLevelMeta returns the json encoded top-level meta of the json:api encoded document data, or nil
+// if it has none.
+func topLevelMeta(data []byte) (json.RawMessage, error) {
+	var d struct {
+		Meta json.RawMessage `json:"meta"`
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	return d.Meta, nil
+}
+
+// DecodeResourceMeta decodes the meta of the resource object r into a T. It returns the zero value
+// of T if r has no meta.
+func DecodeResourceMeta[T any](r *Resource) (T, error) {
+	var meta T
+	if r.meta == nil {
+		return meta, nil
+	}
+	b, err := json.Marshal(r.meta)
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return meta, &FieldError{Code: CodeInvalidMeta, Member: "meta", Pointer: "/meta", Err: err}
+	}
+	return meta, nil
+}
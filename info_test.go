@@ -0,0 +1,202 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// healthMeta is the meta of a health check document.
+type healthMeta struct {
+	Status  string `json:"status"`
+	Version string `json:"version,omitempty"`
+}
+
+func (m *healthMeta) Validate() error {
+	if m.Status != "ok" && m.Status != "degraded" {
+		return fmt.Errorf("invalid status %q", m.Status)
+	}
+	return nil
+}
+
+func TestMarshalInfo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		meta        any
+		links       *Link
+		opts        []MarshalOption
+		expect      string
+		expectError error
+	}{
+		{
+			description: "meta",
+			meta:        map[string]any{"status": "ok"},
+			expect:      `{"meta":{"status":"ok"}}`,
+		}, {
+			description: "meta and links",
+			meta:        &healthMeta{Status: "ok", Version: "1.2.3"},
+			links:       &Link{Self: "https://example.com/health"},
+			opts:        []MarshalOption{MarshalJSONAPI(nil)},
+			expect:      `{"meta":{"status":"ok","version":"1.2.3"},"links":{"self":"https://example.com/health"},"jsonapi":{"version":"1.0"}}`,
+		}, {
+			description: "valid typed meta",
+			meta:        &healthMeta{Status: "degraded"},
+			opts:        []MarshalOption{MarshalMetaSchema(TypedMeta[healthMeta]())},
+			expect:      `{"meta":{"status":"degraded"}}`,
+		}, {
+			description: "invalid typed meta",
+			meta:        &healthMeta{Status: "down"},
+			opts:        []MarshalOption{MarshalMetaSchema(TypedMeta[healthMeta]())},
+			expectError: &DocumentError{Code: CodeInvalidMeta, Pointer: "/meta", Err: errors.New(`invalid status "down"`)},
+		}, {
+			description: "unknown typed meta member",
+			meta:        map[string]any{"status": "ok", "uptime": 1},
+			opts:        []MarshalOption{MarshalMetaSchema(TypedMeta[healthMeta]())},
+			expectError: &DocumentError{Code: CodeInvalidMeta, Pointer: "/meta", Err: errors.New(`json: unknown field "uptime"`)},
+		}, {
+			description: "nil meta",
+			meta:        nil,
+			expectError: &TypeError{Actual: "nil", Expected: []string{"struct", "map"}},
+		}, {
+			description: "nil map meta",
+			meta:        map[string]any(nil),
+			expectError: &TypeError{Actual: "nil", Expected: []string{"struct", "map"}},
+		}, {
+			description: "nil pointer meta",
+			meta:        (*healthMeta)(nil),
+			expectError: &TypeError{Actual: "nil", Expected: []string{"struct", "map"}},
+		}, {
+			description: "invalid meta",
+			meta:        "ok",
+			expectError: &TypeError{Actual: "string", Expected: []string{"struct", "map"}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := MarshalInfo(tc.meta, tc.links, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestUnmarshalMetaSchema(t *testing.T) {
+	t.Parallel()
+
+	var meta healthMeta
+	err := Unmarshal([]byte(`{"meta":{"status":"ok"}}`), &Article{}, UnmarshalMeta(&meta), UnmarshalMetaSchema(TypedMeta[healthMeta]()))
+	is.MustNoError(t, err)
+	is.Equal(t, healthMeta{Status: "ok"}, meta)
+
+	err = Unmarshal([]byte(`{"meta":{"status":"down"}}`), &Article{}, UnmarshalMetaSchema(TypedMeta[healthMeta]()))
+	is.EqualError(t, &DocumentError{Code: CodeInvalidMeta, Pointer: "/meta", Err: errors.New(`invalid status "down"`)}, err)
+
+	// the meta is validated as received, without losing the precision of its numbers
+	var validated string
+	schema := MetaSchemaFunc(func(meta []byte) error {
+		validated = string(meta)
+		return nil
+	})
+	err = Unmarshal([]byte(`{"meta":{"total":12345678901234567890}}`), &Article{}, UnmarshalMetaSchema(schema))
+	is.MustNoError(t, err)
+	is.Equal(t, `{"total":12345678901234567890}`, validated)
+}
+
+func TestMarshalNilMeta(t *testing.T) {
+	t.Parallel()
+
+	b, err := Marshal(&articleA, MarshalMeta(map[string]any(nil)))
+	is.MustNoError(t, err)
+	is.EqualJSON(t, articleABody, string(b))
+}
+
+func TestDecodeMeta(t *testing.T) {
+	t.Parallel()
+
+	type page struct {
+		Total int `json:"total"`
+	}
+
+	tests := []struct {
+		description string
+		given       string
+		expect      page
+		expectError bool
+	}{
+		{
+			description: "collection document",
+			given:       `{"data":[{"type":"articles","id":"1"}],"meta":{"total":10}}`,
+			expect:      page{Total: 10},
+		}, {
+			description: "meta with other members",
+			given:       `{"data":[],"meta":{"total":10,"other":true}}`,
+			expect:      page{Total: 10},
+		}, {
+			description: "no meta",
+			given:       `{"data":[]}`,
+		}, {
+			description: "null meta",
+			given:       `{"data":[],"meta":null}`,
+		}, {
+			description: "invalid meta",
+			given:       `{"data":[],"meta":{"total":"10"}}`,
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var info DocumentInfo
+			err := Unmarshal([]byte(tc.given), &[]*Article{}, UnmarshalDocumentInfo(&info))
+			is.MustNoError(t, err)
+
+			actual, err := DecodeMeta[page](&info)
+			is.Equal(t, tc.expectError, err != nil)
+			is.Equal(t, tc.expect, actual)
+		})
+	}
+
+	_, err := DecodeMeta[page](&DocumentInfo{Meta: json.RawMessage(`{"total":"10"}`)})
+	var docErr *DocumentError
+	is.MustEqual(t, true, errors.As(err, &docErr))
+	is.Equal(t, CodeInvalidMeta, docErr.Code)
+}
+
+func TestDecodeResourceMeta(t *testing.T) {
+	t.Parallel()
+
+	type counts struct {
+		Views int `json:"views"`
+	}
+
+	var r Resource
+	is.MustNoError(t, Unmarshal([]byte(`{"data":{"type":"articles","id":"1","meta":{"views":3}}}`), &r))
+	meta, err := DecodeResourceMeta[counts](&r)
+	is.MustNoError(t, err)
+	is.Equal(t, counts{Views: 3}, meta)
+
+	meta, err = DecodeResourceMeta[counts](NewResource("articles", "1"))
+	is.MustNoError(t, err)
+	is.Equal(t, counts{}, meta)
+
+	_, err = DecodeResourceMeta[counts](NewResource("articles", "1").SetMeta(map[string]any{"views": "3"}))
+	is.Equal(t, true, err != nil)
+}
@@ -0,0 +1,70 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+// stringOption is the option of the attribute directive encoding 64-bit integers as strings.
+const stringOption = "string"
+
+// MarshalInt64Strings encodes all int64 and uint64 attributes as strings, as done for attributes
+// with the string option, e.g. `jsonapi:"attribute,string"`. JavaScript numbers can't represent
+// integers above 2^53 exactly, so large ids and counters should be sent as strings.
+func MarshalInt64Strings() MarshalOption {
+	return func(m *Marshaler) {
+		m.int64Strings = true
+	}
+}
+
+// UnmarshalInt64Strings accepts strings for all int64 and uint64 attributes, as done for attributes
+// with the string option. Numbers are accepted as well.
+func UnmarshalInt64Strings() UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.int64Strings = true
+	}
+}
+
+// isInt64Type returns true if t is an int64 or uint64 kind, or a pointer to one.
+func isInt64Type(t reflect.Type) bool {
+	k := derefType(t).Kind()
+	return k == reflect.Int64 || k == reflect.Uint64
+}
+
+// formatInt64 returns the value of the int64 or uint64 attribute fv as a string.
+func formatInt64(fv reflect.Value) any {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	if fv.Kind() == reflect.Uint64 {
+		return strconv.FormatUint(fv.Uint(), 10)
+	}
+	return strconv.FormatInt(fv.Int(), 10)
+}
+
+// parseInt64 converts the json encoded int64 or uint64 value data of type t, given as a string or
+// a number, to a number.
+func parseInt64(data []byte, t reflect.Type) ([]byte, error) {
+	if len(data) == 0 || data[0] != '"' {
+		return data, nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if derefType(t).Kind() == reflect.Uint64 {
+		_, err = strconv.ParseUint(s, 10, 64)
+	} else {
+		_, err = strconv.ParseInt(s, 10, 64)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
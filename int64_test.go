@@ -0,0 +1,120 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// ArticleCounters has 64-bit integer attributes, some of which are encoded as strings.
+type ArticleCounters struct {
+	ID        string  `jsonapi:"primary,articles"`
+	Snowflake int64   `jsonapi:"attribute,string" json:"snowflake"`
+	Views     uint64  `jsonapi:"attribute,string" json:"views"`
+	Likes     *int64  `jsonapi:"attribute,string" json:"likes"`
+	Shares    int64   `jsonapi:"attribute" json:"shares"`
+	Rating    float64 `jsonapi:"attribute" json:"rating"`
+}
+
+func TestMarshalInt64Strings(t *testing.T) {
+	t.Parallel()
+
+	likes := int64(-3)
+
+	tests := []struct {
+		description string
+		given       *ArticleCounters
+		opts        []MarshalOption
+		expect      string
+	}{
+		{
+			description: "string option",
+			given:       &ArticleCounters{ID: "1", Snowflake: math.MaxInt64, Views: math.MaxUint64, Likes: &likes, Shares: 2},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"snowflake":"9223372036854775807","views":"18446744073709551615","likes":"-3","shares":2,"rating":0}}}`,
+		}, {
+			description: "nil pointer",
+			given:       &ArticleCounters{ID: "1"},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"snowflake":"0","views":"0","likes":null,"shares":0,"rating":0}}}`,
+		}, {
+			description: "MarshalInt64Strings",
+			given:       &ArticleCounters{ID: "1", Shares: 2, Rating: 1.5},
+			opts:        []MarshalOption{MarshalInt64Strings()},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"snowflake":"0","views":"0","likes":null,"shares":"2","rating":1.5}}}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestUnmarshalInt64Strings(t *testing.T) {
+	t.Parallel()
+
+	likes := int64(-3)
+
+	tests := []struct {
+		description   string
+		given         string
+		opts          []UnmarshalOption
+		expect        *ArticleCounters
+		expectPointer string
+	}{
+		{
+			description: "strings",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"snowflake":"9223372036854775807","views":"18446744073709551615","likes":"-3"}}}`,
+			expect:      &ArticleCounters{ID: "1", Snowflake: math.MaxInt64, Views: math.MaxUint64, Likes: &likes},
+		}, {
+			description: "numbers",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"snowflake":9223372036854775807,"views":1,"likes":null}}}`,
+			expect:      &ArticleCounters{ID: "1", Snowflake: math.MaxInt64, Views: 1},
+		}, {
+			description: "UnmarshalInt64Strings",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"shares":"2"}}}`,
+			opts:        []UnmarshalOption{UnmarshalInt64Strings()},
+			expect:      &ArticleCounters{ID: "1", Shares: 2},
+		}, {
+			description:   "string without option",
+			given:         `{"data":{"type":"articles","id":"1","attributes":{"shares":"2"}}}`,
+			expectPointer: "/data/attributes/shares",
+		}, {
+			description:   "out of range",
+			given:         `{"data":{"type":"articles","id":"1","attributes":{"views":"-1"}}}`,
+			expectPointer: "/data/attributes/views",
+		}, {
+			description:   "not a number",
+			given:         `{"data":{"type":"articles","id":"1","attributes":{"snowflake":"1e3"}}}`,
+			expectPointer: "/data/attributes/snowflake",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var actual ArticleCounters
+			err := Unmarshal([]byte(tc.given), &actual, tc.opts...)
+			if tc.expectPointer != "" {
+				var fe *FieldError
+				is.MustEqual(t, true, errors.As(err, &fe))
+				is.Equal(t, tc.expectPointer, fe.Pointer)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &actual)
+		})
+	}
+}
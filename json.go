@@ -0,0 +1,52 @@
+//go:build !jsonv2
+
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// marshalJSON returns the json encoding of v, using encoding/json unless built with the jsonv2
+// build tag.
+func marshalJSON(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// encodeJSON appends the json encoding of v to buf, using encoding/json unless built with the
+// jsonv2 build tag.
+func encodeJSON(buf *bytes.Buffer, v any) error {
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	// unlike json.Marshal, json.Encoder terminates every value with a newline
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// unmarshalJSON parses the json encoded data into the value pointed to by v, using encoding/json
+// unless built with the jsonv2 build tag.
+func unmarshalJSON(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// isJSONDecodeError returns true if err is caused by decoding invalid json, or json not matching
+// the Go value it is decoded into.
+func isJSONDecodeError(err error) bool {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// syntaxErrorPosition returns the byte offset of the syntax error err, and the JSON pointer to the
+// value containing it if known, or false if err is not a syntax error.
+func syntaxErrorPosition(err error) (int64, string, bool) {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Offset, "", true
+	}
+	return 0, "", false
+}
@@ -0,0 +1,83 @@
+//go:build jsonv2
+
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"errors"
+)
+
+// The jsonv2 build tag enables the experimental encoding/json/v2 backend, which requires a Go
+// version providing encoding/json/v2 (e.g. Go 1.25 with GOEXPERIMENT=jsonv2).
+//
+// Documents are encoded and decoded with encoding/json/v2, which is faster and rejects documents
+// with duplicate member names as required by RFC 8259. The encoded output is identical to the
+// default encoding/json backend.
+
+// jsonv2Options are the options used to make encoding/json/v2 behave like encoding/json, except
+// for rejecting duplicate member names.
+var jsonv2Options = jsonv2.JoinOptions(
+	jsonv2.Deterministic(false),
+	jsonv2.FormatNilSliceAsNull(true),
+	jsonv2.FormatNilMapAsNull(true),
+	jsonv2.MatchCaseInsensitiveNames(true),
+	jsontext.EscapeForHTML(true),
+	jsontext.EscapeForJS(true),
+	jsontext.AllowDuplicateNames(false),
+)
+
+// marshalJSON returns the json encoding of v, using encoding/json/v2.
+func marshalJSON(v any) ([]byte, error) {
+	return jsonv2.Marshal(v, jsonv2Options)
+}
+
+// encodeJSON appends the json encoding of v to buf, using encoding/json/v2.
+func encodeJSON(buf *bytes.Buffer, v any) error {
+	return jsonv2.MarshalWrite(buf, v, jsonv2Options)
+}
+
+// unmarshalJSON parses the json encoded data into the value pointed to by v, using
+// encoding/json/v2.
+func unmarshalJSON(data []byte, v any) error {
+	err := jsonv2.Unmarshal(data, v, jsonv2Options)
+
+	// errors returned by json.Unmarshaler implementations (e.g. DocumentError) are wrapped, but
+	// must be returned as is for parity with encoding/json
+	var se *jsonv2.SemanticError
+	if errors.As(err, &se) && se.Err != nil && !isJSONDecodeError(se.Err) {
+		return se.Err
+	}
+	return err
+}
+
+// isJSONDecodeError returns true if err is caused by decoding invalid json, or json not matching
+// the Go value it is decoded into.
+func isJSONDecodeError(err error) bool {
+	var (
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+		syntacticV2 *jsontext.SyntacticError
+		semanticV2  *jsonv2.SemanticError
+	)
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr) ||
+		errors.As(err, &syntacticV2) || errors.As(err, &semanticV2)
+}
+
+// syntaxErrorPosition returns the byte offset of the syntax error err, and the JSON pointer to the
+// value containing it if known, or false if err is not a syntax error.
+func syntaxErrorPosition(err error) (int64, string, bool) {
+	var (
+		syntaxErr   *json.SyntaxError
+		syntacticV2 *jsontext.SyntacticError
+	)
+	switch {
+	case errors.As(err, &syntacticV2):
+		return syntacticV2.ByteOffset, string(syntacticV2.JSONPointer), true
+	case errors.As(err, &syntaxErr):
+		return syntaxErr.Offset, "", true
+	}
+	return 0, "", false
+}
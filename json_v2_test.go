@@ -0,0 +1,22 @@
+//go:build jsonv2
+
+package jsonapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestUnmarshalDuplicateMemberNames(t *testing.T) {
+	t.Parallel()
+
+	var a Article
+	err := Unmarshal([]byte(`{"data":{"id":"1","type":"articles","id":"2"}}`), &a)
+	is.MustEqual(t, true, err != nil)
+
+	objects := ErrorObjects(err)
+	is.MustEqual(t, 1, len(objects))
+	is.Equal(t, http.StatusBadRequest, *objects[0].Status)
+}
@@ -1,10 +1,32 @@
-// Package jsonapi implements encoding and decoding of JSON:API as defined in https://jsonapi.org/format/.
+// Package jsonapi implements encoding and decoding of JSON:API as defined in
+// https://jsonapi.org/format/.
+//
+// # Concurrency
+//
+// The package's only global state are caches synchronized internally, so its functions are safe to
+// call from multiple goroutines at once. Options are applied to a new Marshaler or Unmarshaler on
+// each call, so the same MarshalOption and UnmarshalOption values (and slices of them) can be
+// shared between concurrent calls, as long as the values they were created with aren't modified
+// meanwhile. The exceptions are UnmarshalMeta, UnmarshalJSONAPIObject and
+// UnmarshalExtensionMembers, which decode into the value they were given and so must not be shared.
+//
+// Client, Server, and the handlers returned by CORS and Capture are safe for concurrent use once
+// created. The values being marshaled must not be modified while being marshaled, and the values
+// being unmarshaled into must not be accessed until unmarshaling returns, like with encoding/json.
+//
+// # Startup
+//
+// Struct tags are parsed once per type and cached. Precompile parses the tags of resource types
+// up front, e.g. at startup, so that misconfigured types fail fast.
 package jsonapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"reflect"
+	"sort"
 )
 
 // ResourceObject is a JSON:API resource object as defined by https://jsonapi.org/format/1.0/#document-resource-objects
@@ -15,12 +37,151 @@ type resourceObject struct {
 	Relationships map[string]*document `json:"relationships,omitempty"`
 	Meta          any                  `json:"meta,omitempty"`
 	Links         *Link                `json:"links,omitempty"`
+
+	// rawAttributes holds the attributes of unmarshaled resource objects, which are decoded
+	// directly into the destination value instead of Attributes
+	rawAttributes rawValue
+
+	// identifierMeta holds the meta of unmarshaled resource identifier objects, which is kept when
+	// they are filled with included data
+	identifierMeta any
+
+	// extensions holds the extension members of the resource object, which
+	// resourceObject.MarshalJSON adds after the other members
+	extensions map[string]any
+
+	// raw holds the json encoding of unmarshaled resource objects, from which extension members are
+	// decoded
+	raw rawValue
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (ro *resourceObject) MarshalJSON() ([]byte, error) {
+	type alias resourceObject
+	b, err := json.Marshal((*alias)(ro))
+	if err != nil {
+		return nil, err
+	}
+	return appendMembers(b, ro.extensions)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (ro *resourceObject) UnmarshalJSON(data []byte) error {
+	type alias resourceObject
+	aux := &struct {
+		*alias
+		Attributes rawValue `json:"attributes"`
+	}{
+		alias: (*alias)(ro),
+	}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	if len(aux.Attributes) > 0 && aux.Attributes[0] != '{' && string(aux.Attributes) != "null" {
+		return &json.UnmarshalTypeError{
+			Value: jsonKindOf(aux.Attributes),
+			Type:  reflect.TypeOf(ro.Attributes),
+			Field: "attributes",
+		}
+	}
+	ro.rawAttributes = aux.Attributes
+	ro.identifierMeta = ro.Meta
+	ro.raw = append(rawValue(nil), data...)
+	return nil
+}
+
+// hasAttributes returns true if the resource object has at least one attribute.
+func (ro *resourceObject) hasAttributes() bool {
+	if ro.rawAttributes != nil {
+		return !bytes.Equal(ro.rawAttributes, []byte("null")) && len(bytes.TrimSpace(ro.rawAttributes[1:len(ro.rawAttributes)-1])) > 0
+	}
+	return len(ro.Attributes) > 0
+}
+
+// rawValue is a raw encoded json value, like json.RawMessage. Like json.RawMessage, it copies the
+// data it is unmarshaled from, as json.Unmarshaler implementations must not retain it.
+type rawValue []byte
+
+// MarshalJSON implements the json.Marshaler interface.
+func (r rawValue) MarshalJSON() ([]byte, error) {
+	if r == nil {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (r *rawValue) UnmarshalJSON(data []byte) error {
+	*r = append((*r)[:0], data...)
+	return nil
+}
+
+// jsonKindOf returns the kind of the given json value, as used by json.UnmarshalTypeError.
+func jsonKindOf(data []byte) string {
+	switch data[0] {
+	case '"':
+		return "string"
+	case '[':
+		return "array"
+	case '{':
+		return "object"
+	case 't', 'f':
+		return "bool"
+	case 'n':
+		return "null"
+	}
+	return "number"
+}
+
+// ResourceIdentifier identifies a resource by its type and id, like a resource identifier object as
+// defined by https://jsonapi.org/format/#document-resource-identifier-objects.
+type ResourceIdentifier struct {
+	Type string
+	ID   string
+}
+
+// identifier returns a string uniquely identifying the resource object by its type and id.
+func (ro *resourceObject) identifier() string {
+	return resourceIdentifier(ro.Type, ro.ID)
+}
+
+// resourceIdentifier returns a string uniquely identifying a resource by its type and id.
+func resourceIdentifier(resourceType, id string) string {
+	return fmt.Sprintf("{Type: %v, ID: %v}", resourceType, id)
+}
+
+// JSONAPIObject is the top-level jsonapi object of a document, describing the server's
+// implementation as defined by https://jsonapi.org/format/1.1/#document-jsonapi-object.
+//
+// Version is "1.0", "1.1", or empty to omit it. Ext and Profile are the URIs of the extensions and
+// profiles applied to the document, which require JSON:API 1.1. Meta must be a map or struct.
+type JSONAPIObject struct {
+	Version string   `json:"version,omitempty"`
+	Ext     []string `json:"ext,omitempty"`
+	Profile []string `json:"profile,omitempty"`
+	Meta    any      `json:"meta,omitempty"`
 }
 
-// JSONAPI is a JSON:API object as defined by https://jsonapi.org/format/1.0/#document-jsonapi-object.
-type jsonAPI struct {
-	Version string `json:"version"`
-	Meta    any    `json:"meta,omitempty"`
+// check returns an error if o has an unsupported version, extensions or profiles along with
+// version 1.0, or URIs which aren't absolute.
+func (o *JSONAPIObject) check() error {
+	switch o.Version {
+	case "", "1.0", "1.1":
+	default:
+		return fmt.Errorf("%w: unsupported version %q", ErrInvalidJSONAPIObject, o.Version)
+	}
+	if o.Version == "1.0" && (len(o.Ext) > 0 || len(o.Profile) > 0) {
+		return fmt.Errorf("%w: ext and profile require version 1.1", ErrInvalidJSONAPIObject)
+	}
+	for _, uri := range append(append([]string{}, o.Ext...), o.Profile...) {
+		if u, err := url.Parse(uri); err != nil || !u.IsAbs() {
+			return fmt.Errorf("%w: %q is not an absolute URI", ErrInvalidJSONAPIObject, uri)
+		}
+	}
+	if err := checkMeta(o.Meta); err != nil {
+		return err
+	}
+	return nil
 }
 
 // checkMeta returns a type error if the given meta value is not map-like
@@ -37,22 +198,232 @@ func checkMeta(m any) *TypeError {
 	return &TypeError{Actual: mt.String(), Expected: []string{"struct", "map"}}
 }
 
-// LinkObject is a links object as defined by https://jsonapi.org/format/1.0/#document-links
+// LinkObject is a link object as defined by https://jsonapi.org/format/1.1/#document-links-link-object.
+// Rel, DescribedBy, Title, Type and HrefLang are defined by JSON:API 1.1.
+//
+// DescribedBy must be a string or *LinkObject, linking to a description document (e.g. a JSON
+// Schema) of the link's target.
 type LinkObject struct {
-	Href string `json:"href,omitempty"`
-	Meta any    `json:"meta,omitempty"`
+	Href        string   `json:"href,omitempty"`
+	Rel         string   `json:"rel,omitempty"`
+	DescribedBy any      `json:"describedby,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	HrefLang    HrefLang `json:"hreflang,omitempty"`
+	Meta        any      `json:"meta,omitempty"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. A describedby link given as a link
+// object is unmarshaled as *LinkObject.
+func (lo *LinkObject) UnmarshalJSON(data []byte) error {
+	type alias LinkObject
+	aux := &struct {
+		*alias
+		DescribedBy json.RawMessage `json:"describedby"`
+	}{
+		alias: (*alias)(lo),
+	}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	var err error
+	lo.DescribedBy, err = unmarshalLinkValue(aux.DescribedBy, "describedby")
+	return err
+}
+
+// HrefLang holds the language tags (as defined by RFC 5646) of the target of a link object. It is
+// encoded as a string if it holds a single language tag, and as an array of strings otherwise.
+type HrefLang []string
+
+// MarshalJSON implements the json.Marshaler interface.
+func (h HrefLang) MarshalJSON() ([]byte, error) {
+	if len(h) == 1 {
+		return json.Marshal(h[0])
+	}
+	return json.Marshal([]string(h))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (h *HrefLang) UnmarshalJSON(data []byte) error {
+	switch jsonKindOf(data) {
+	case "null":
+		return nil
+	case "string":
+		var tag string
+		if err := json.Unmarshal(data, &tag); err != nil {
+			return err
+		}
+		*h = HrefLang{tag}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(h))
 }
 
 // Link is the top-level links object as defined by https://jsonapi.org/format/1.0/#document-top-level.
 // First|Last|Next|Previous are provided to support pagination as defined by https://jsonapi.org/format/1.0/#fetching-pagination.
+//
+// Each link must be a string or *LinkObject, as returned by LinkHref.
 type Link struct {
 	Self    any `json:"self,omitempty"`
 	Related any `json:"related,omitempty"`
 
-	First    string `json:"first,omitempty"`
-	Last     string `json:"last,omitempty"`
-	Next     string `json:"next,omitempty"`
-	Previous string `json:"previous,omitempty"`
+	First    any `json:"first,omitempty"`
+	Last     any `json:"last,omitempty"`
+	Next     any `json:"next,omitempty"`
+	Previous any `json:"previous,omitempty"`
+
+	// Extra holds links with other names (e.g. describedby, canonical or vendor specific links) by
+	// name. They are marshaled after the links above, sorted by name. Names of the links above are
+	// ignored, use Set to set links by name instead.
+	Extra map[string]any `json:"-"`
+}
+
+// namedLink is a link of a Link held by a field, along with its name.
+type namedLink struct {
+	name string
+	link *any
+}
+
+// namedLinks returns the links of l held by fields, in the order they are marshaled.
+func (l *Link) namedLinks() []namedLink {
+	return []namedLink{
+		{"self", &l.Self},
+		{"related", &l.Related},
+		{"first", &l.First},
+		{"last", &l.Last},
+		{"next", &l.Next},
+		{"previous", &l.Previous},
+	}
+}
+
+// field returns the field of l holding the link with the given name, or nil if it's an extra link.
+func (l *Link) field(name string) *any {
+	for _, nl := range l.namedLinks() {
+		if nl.name == name {
+			return nl.link
+		}
+	}
+	return nil
+}
+
+// Get returns the link with the given name, or nil if l has no such link.
+func (l *Link) Get(name string) any {
+	if link := l.field(name); link != nil {
+		return *link
+	}
+	return l.Extra[name]
+}
+
+// Set sets the link with the given name, which must be a string or *LinkObject, and returns l. A
+// nil link removes it.
+func (l *Link) Set(name string, link any) *Link {
+	if f := l.field(name); f != nil {
+		*f = link
+		return l
+	}
+	if link == nil {
+		delete(l.Extra, name)
+		return l
+	}
+	if l.Extra == nil {
+		l.Extra = make(map[string]any)
+	}
+	l.Extra[name] = link
+	return l
+}
+
+// MarshalJSON implements the json.Marshaler interface. Empty string links are omitted.
+func (l *Link) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	write := func(name string, link any) error {
+		if isEmptyLink(link) {
+			return nil
+		}
+		b, err := json.Marshal(link)
+		if err != nil {
+			return err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(b)
+		return nil
+	}
+
+	for _, nl := range l.namedLinks() {
+		if err := write(nl.name, *nl.link); err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(l.Extra))
+	for name := range l.Extra {
+		if l.field(name) == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := write(name, l.Extra[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// isEmptyLink returns true if link is nil, an empty string or a nil *LinkObject.
+func isEmptyLink(link any) bool {
+	switch l := link.(type) {
+	case nil:
+		return true
+	case string:
+		return l == ""
+	case *LinkObject:
+		return l == nil
+	}
+	return false
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Links given as link objects are
+// unmarshaled as *LinkObject, and links with other names than those of the fields of Link are
+// unmarshaled into Extra.
+func (l *Link) UnmarshalJSON(data []byte) error {
+	var links map[string]json.RawMessage
+	if err := json.Unmarshal(data, &links); err != nil {
+		return err
+	}
+
+	for name, raw := range links {
+		link, err := unmarshalLinkValue(raw, "links."+name)
+		if err != nil {
+			return err
+		}
+		if link != nil {
+			l.Set(name, link)
+		}
+	}
+	return nil
+}
+
+// LinkHref returns the URL of the given link, which is the link itself if it is a string, or its
+// href if it is a *LinkObject. It returns an empty string for any other value.
+func LinkHref(link any) string {
+	switch l := link.(type) {
+	case string:
+		return l
+	case *LinkObject:
+		if l != nil {
+			return l.Href
+		}
+	}
+	return ""
 }
 
 func checkLinkValue(linkValue any) (bool, *TypeError) {
@@ -63,6 +434,13 @@ func checkLinkValue(linkValue any) (bool, *TypeError) {
 		if err := checkMeta(lv.Meta); err != nil {
 			return false, err
 		}
+		describedByIsEmpty, err := checkLinkValue(lv.DescribedBy)
+		if err != nil {
+			return false, err
+		}
+		if describedByIsEmpty {
+			lv.DescribedBy = nil
+		}
 		isEmpty = (lv.Href == "")
 	case string:
 		isEmpty = (lv == "")
@@ -86,6 +464,22 @@ func (l *Link) check() error {
 		return err
 	}
 
+	// pagination and extra links are optional, but must be set to nil if empty to satisfy omitempty
+	for _, link := range []*any{&l.First, &l.Last, &l.Next, &l.Previous} {
+		isEmpty, err := checkLinkValue(*link)
+		if err != nil {
+			return err
+		}
+		if isEmpty {
+			*link = nil
+		}
+	}
+	for _, link := range l.Extra {
+		if _, err := checkLinkValue(link); err != nil {
+			return err
+		}
+	}
+
 	// if both are empty then fail, and if one is empty, it must be set to nil to satisfy omitempty
 	switch {
 	case selfIsEmpty && relatedIsEmpty:
@@ -104,6 +498,7 @@ type document struct {
 	// Data is a ResourceObject as defined by https://jsonapi.org/format/1.0/#document-resource-objects.
 	// DataOne/DataMany are translated to Data in document.MarshalJSON
 	hasMany  bool
+	noData   bool              // omits data for documents with only meta, e.g. as created by MarshalInfo, or those unmarshaled without it
 	DataOne  *resourceObject   `json:"-"`
 	DataMany []*resourceObject `json:"-"`
 
@@ -111,7 +506,7 @@ type document struct {
 	Meta any `json:"meta,omitempty"`
 
 	// JSONAPI is a JSON:API object as defined by https://jsonapi.org/format/1.0/#document-jsonapi-object.
-	JSONAPI *jsonAPI `json:"jsonapi,omitempty"`
+	JSONAPI *JSONAPIObject `json:"jsonapi,omitempty"`
 
 	// Errors is a list of JSON:API error objects as defined by https://jsonapi.org/format/1.0/#error-objects.
 	Errors []*Error `json:"errors,omitempty"`
@@ -121,6 +516,13 @@ type document struct {
 
 	// Includes contains ResourceObjects creating a compound document as defined by https://jsonapi.org/format/#document-compound-documents.
 	Included []*resourceObject `json:"included,omitempty"`
+
+	// extensions holds the extension members of the document as defined by https://jsonapi.org/format/1.1/#extensions,
+	// which document.MarshalJSON adds after the other members
+	extensions map[string]any
+
+	// raw holds the json encoding of unmarshaled documents, from which extension members are decoded
+	raw rawValue
 }
 
 func newDocument() *document {
@@ -132,8 +534,17 @@ func newDocument() *document {
 
 // MarshalJSON implements the json.Marshaler interface.
 func (d *document) MarshalJSON() ([]byte, error) {
+	b, err := d.marshalMembers()
+	if err != nil {
+		return nil, err
+	}
+	return appendMembers(b, d.extensions)
+}
+
+// marshalMembers returns the json encoding of the document without its extension members.
+func (d *document) marshalMembers() ([]byte, error) {
 	// if we get errors, force exclusion of the Data field
-	if len(d.Errors) > 0 {
+	if len(d.Errors) > 0 || d.noData {
 		type alias document
 		return json.Marshal(&struct{ *alias }{alias: (*alias)(d)})
 	}
@@ -168,13 +579,14 @@ func (d *document) tryUnmarshalEmpty(data []byte) (err error) {
 	}
 	if len(m) == 0 {
 		// {} - NOT OK
-		err = ErrMissingDataField
+		err = &DocumentError{Code: CodeMissingData, Err: ErrMissingDataField}
 		return
 	}
 
 	ros, ok := m["data"]
 	if !ok {
 		// e.g. {"meta":{...}} - OK
+		d.noData = true
 		return
 	}
 
@@ -185,7 +597,7 @@ func (d *document) tryUnmarshalEmpty(data []byte) (err error) {
 		// {"data":{...}} - OK
 		if len(ros) == 0 {
 			// {"data":{}} - NOT OK
-			err = ErrInvalidDataField
+			err = &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrInvalidDataField}
 		}
 	case []any:
 		// {"data":[...]} - OK
@@ -203,6 +615,7 @@ func (d *document) UnmarshalJSON(data []byte) (err error) {
 	if err != nil {
 		return
 	}
+	d.raw = append(rawValue(nil), data...)
 
 	if d.hasMany {
 		auxMany := &struct {
@@ -253,10 +666,6 @@ func (d *document) verifyFullLinkage(aliasRelationships bool) error {
 		return []*resourceObject{d.DataOne}
 	}
 
-	resourceIdentifier := func(ro *resourceObject) string {
-		return fmt.Sprintf("{Type: %v, ID: %v}", ro.Type, ro.ID)
-	}
-
 	// a list of related resource identifiers, and a flag to mark nodes as visited
 	type includeNode struct {
 		included  *resourceObject
@@ -273,19 +682,22 @@ func (d *document) verifyFullLinkage(aliasRelationships bool) error {
 			relatedTo = append(relatedTo, getResourceObjectSlice(relationship)...)
 		}
 
-		includeGraph[resourceIdentifier(included)] = &includeNode{included, relatedTo, false}
+		includeGraph[included.identifier()] = &includeNode{included, relatedTo, false}
 	}
 
 	// helper to traverse the graph from a given key and mark nodes as visited
 	var visit func(ro *resourceObject)
 	visit = func(ro *resourceObject) {
-		node, ok := includeGraph[resourceIdentifier(ro)]
+		node, ok := includeGraph[ro.identifier()]
 		if !ok {
 			return
 		}
 		if aliasRelationships {
-			// fill the relationship document itself with included data
+			// fill the relationship document itself with included data, keeping the meta of the
+			// resource identifier object
+			identifierMeta := ro.identifierMeta
 			*ro = *node.included
+			ro.identifierMeta = identifierMeta
 		}
 		if node.visited {
 			// cycle detected, don't visit adjacent nodes
@@ -308,15 +720,21 @@ func (d *document) verifyFullLinkage(aliasRelationships bool) error {
 		}
 	}
 
-	invalidResources := make([]string, 0)
-	for identifier, node := range includeGraph {
+	invalidResources := make([]ResourceIdentifier, 0)
+	for _, node := range includeGraph {
 		if !node.visited {
-			invalidResources = append(invalidResources, identifier)
+			invalidResources = append(invalidResources, ResourceIdentifier{Type: node.included.Type, ID: node.included.ID})
 		}
 	}
 
 	if len(invalidResources) > 0 {
-		return &PartialLinkageError{invalidResources}
+		sort.Slice(invalidResources, func(i, j int) bool {
+			if invalidResources[i].Type != invalidResources[j].Type {
+				return invalidResources[i].Type < invalidResources[j].Type
+			}
+			return invalidResources[i].ID < invalidResources[j].ID
+		})
+		return &PartialLinkageError{Resources: invalidResources}
 	}
 
 	return nil
@@ -332,6 +750,95 @@ type LinkableRelation interface {
 	LinkRelation(relation string) *Link
 }
 
+// LinkUnmarshaler can be implemented by resources to unmarshal the links of their resource object,
+// e.g. so clients can follow the self link of a resource instead of constructing its URL. It is the
+// counterpart of Linkable.
+type LinkUnmarshaler interface {
+	// UnmarshalLink is called with the links of the resource object, if it has any.
+	UnmarshalLink(link *Link) error
+}
+
+// RelationLinkUnmarshaler can be implemented by resources to unmarshal the links of the
+// relationships of their resource object, e.g. so clients can follow the related link of a
+// relationship to fetch the related resources. It is the counterpart of LinkableRelation.
+type RelationLinkUnmarshaler interface {
+	// UnmarshalLinkRelation is called with the links of the relationship named relation, for each
+	// relationship having links, in order of their names.
+	UnmarshalLinkRelation(relation string, link *Link) error
+}
+
+// IdentifierMetaMarshaler can be implemented by resources to marshal the meta of the resource
+// identifier objects in the resource linkage of their relationships, e.g. data of a join table such
+// as when a tag was assigned to an article.
+type IdentifierMetaMarshaler interface {
+	// MarshalIdentifierMeta returns the meta (a map or struct, or nil) of the resource identifier
+	// object of the related resource identified by id in the relationship named relation.
+	MarshalIdentifierMeta(relation string, id ResourceIdentifier) any
+}
+
+// IdentifierMetaUnmarshaler can be implemented by resources to unmarshal the meta of the resource
+// identifier objects in the resource linkage of their relationships.
+type IdentifierMetaUnmarshaler interface {
+	// UnmarshalIdentifierMeta is called with the json encoding of the meta of the resource
+	// identifier object of the related resource identified by id in the relationship named
+	// relation, for each resource identifier object having meta.
+	UnmarshalIdentifierMeta(relation string, id ResourceIdentifier, meta []byte) error
+}
+
+// linkage returns the resource linkage of the given relationship document.
+func (d *document) linkage() []*resourceObject {
+	if d.hasMany {
+		return d.DataMany
+	}
+	if d.DataOne == nil {
+		return nil
+	}
+	return []*resourceObject{d.DataOne}
+}
+
+// addIdentifierMeta sets the meta of the resource identifier objects of the relationship document
+// of v named relation, if v implements IdentifierMetaMarshaler.
+func (d *document) addIdentifierMeta(v any, relation string) error {
+	vm, ok := v.(IdentifierMetaMarshaler)
+	if !ok {
+		return nil
+	}
+	for _, ri := range d.linkage() {
+		meta := vm.MarshalIdentifierMeta(relation, ResourceIdentifier{Type: ri.Type, ID: ri.ID})
+		if err := checkMeta(meta); err != nil {
+			return err
+		}
+		ri.Meta = meta
+	}
+	return nil
+}
+
+// unmarshalIdentifierMeta passes the meta of the resource identifier objects of the relationship
+// document of v named relation to v, if it implements IdentifierMetaUnmarshaler.
+func (d *document) unmarshalIdentifierMeta(v any, relation string) error {
+	vu, ok := v.(IdentifierMetaUnmarshaler)
+	if !ok {
+		return nil
+	}
+	for i, ri := range d.linkage() {
+		if ri.identifierMeta == nil {
+			continue
+		}
+		b, err := json.Marshal(ri.identifierMeta)
+		if err != nil {
+			return err
+		}
+		if err := vu.UnmarshalIdentifierMeta(relation, ResourceIdentifier{Type: ri.Type, ID: ri.ID}, b); err != nil {
+			pointer := "/data"
+			if d.hasMany {
+				pointer = fmt.Sprintf("/data/%d", i)
+			}
+			return &FieldError{Code: CodeInvalidMeta, Member: "meta", Pointer: pointer + "/meta", Err: err}
+		}
+	}
+	return nil
+}
+
 // MarshalIdentifier can be optionally implemented to control marshaling of the primary field to a string.
 //
 // The order of operations for marshaling the primary field is:
@@ -344,6 +851,22 @@ type MarshalIdentifier interface {
 	MarshalID() string
 }
 
+// MarshalType can be optionally implemented by resources to decide their resource type at runtime,
+// e.g. for multi-tenant or versioned type names, so that a single struct can represent several
+// resource types. The type returned by MarshalResourceType takes the place of the one given by the
+// primary field's struct tag, which remains the type described by SchemaOf.
+type MarshalType interface {
+	MarshalResourceType() string
+}
+
+// UnmarshalType can be optionally implemented by resources accepting resource objects of types
+// other than the one given by the primary field's struct tag. UnmarshalResourceType is called with
+// the type of the resource object in place of checking it against the struct tag, and returns an
+// error to reject it.
+type UnmarshalType interface {
+	UnmarshalResourceType(resourceType string) error
+}
+
 // UnmarshalIdentifier can be optionally implemented to control unmarshaling of the primary field from a string.
 //
 // The order of operations for unmarshaling the primary field is:
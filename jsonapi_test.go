@@ -1,10 +1,14 @@
 package jsonapi
 
 import (
+	"context"
 	"encoding"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -60,6 +64,8 @@ var (
 	articlesEncodingIntIDABPtr                   = []*ArticleEncodingIntID{&articleAEncodingIntID, &articleBEncodingIntID}
 	articleEmbedded                              = ArticleEmbedded{ID: "1", Title: "A", Metadata: Metadata{LastModified: time.Date(1989, 06, 15, 0, 0, 0, 0, time.UTC)}}
 	articleEmbeddedPointer                       = ArticleEmbeddedPointer{ID: "1", Title: "A", Metadata: &Metadata{LastModified: time.Date(1989, 06, 15, 0, 0, 0, 0, time.UTC)}}
+	articleFlattened                             = ArticleFlattened{ID: "1", Title: "A", Metadata: Metadata{LastModified: time.Date(1989, 06, 15, 0, 0, 0, 0, time.UTC)}}
+	articleFlattenedPointer                      = ArticleFlattenedPointer{ID: "1", Title: "A", Metadata: &Metadata{LastModified: time.Date(1989, 06, 15, 0, 0, 0, 0, time.UTC)}}
 
 	// articles with optional meta
 	articleAWithMeta              = ArticleWithMeta{ID: "1", Title: "A", Meta: &ArticleMetrics{Views: 10, Reads: 4}}
@@ -97,6 +103,7 @@ var (
 	nullDataBody                      = `{"data":null}`
 	emptySingleBody                   = `{"data":{}}`
 	emptyManyBody                     = `{"data":[]}`
+	emptyBody                         = `{"data":[]}`
 	articleABody                      = `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`
 	articleANoIDBody                  = `{"data":{"type":"articles","attributes":{"title":"A"}}}`
 	articleAInvalidTypeBody           = `{"data":{"type":"not-articles","id":"1","attributes":{"title":"A"}}}`
@@ -109,6 +116,7 @@ var (
 	articleWithResourceObjectMetaBody = `{"data":{"type":"articles","id":"1","attributes":{"title":"A"},"meta":{"count":10}}}`
 	articleAWithMetaBody              = `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"meta":{"views":10,"reads":4}}}`
 	articleEmbeddedBody               = `{"data":{"type":"articles","id":"1","attributes":{"title":"A","lastModified":"1989-06-15T00:00:00Z"}}}`
+	articleEmbeddedNilBody            = `{"data":{"type":"articles","id":"1","attributes":{"title":"A","lastModified":"0001-01-01T00:00:00Z"}}}`
 
 	// articles with relationships bodies
 	articleRelatedInvalidEmptyRelationshipBody  = `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{}}}}`
@@ -162,13 +170,22 @@ var (
 			},
 		},
 	}
+	errorsWithTypeLink = Error{ //nolint: errname
+		Code: "C",
+		Links: &ErrorLink{
+			About: "https://example.com/errors/C",
+			Type:  &LinkObject{Href: "https://example.com/errors/types/validation"},
+		},
+	}
 	errorsWithInvalidLink     = Error{Links: &ErrorLink{About: 1}}                                   //nolint: errname
+	errorsWithInvalidTypeLink = Error{Links: &ErrorLink{About: "A", Type: 1}}                        //nolint: errname
 	errorsWithInvalidLinkMeta = Error{Links: &ErrorLink{About: &LinkObject{Href: "A", Meta: "foo"}}} //nolint: errname
 
 	// error bodies
 	errorsSimpleStructBody     = `{"errors":[{"title":"T"}]}`
 	errorsComplexStructBody    = `{"errors":[{"id":"1","links":{"about":"A"},"status":"500","code":"C","title":"T","detail":"D","source":{"pointer":"PO","parameter":"PA"},"meta":{"K":"V"}}]}`
 	errorsComplexSliceManyBody = `{"errors":[{"title":"T"},{"id":"1","links":{"about":"A"},"status":"500","code":"C","title":"T","detail":"D","source":{"pointer":"PO","parameter":"PA"},"meta":{"K":"V"}}]}`
+	errorsWithTypeLinkBody     = `{"errors":[{"links":{"about":"https://example.com/errors/C","type":{"href":"https://example.com/errors/types/validation"}},"code":"C"}]}`
 	errorsWithLinkObjectBody   = `{"errors":[{"links":{"about":{"href":"A","meta":{"key_i":420,"key_s":"B"}}}}]}`
 )
 
@@ -226,6 +243,34 @@ func (a *ArticleLinked) Link() *Link {
 	}
 }
 
+// ArticleLinksUnmarshaled keeps the links of its resource object and relationships, which must have
+// a self and related link respectively.
+type ArticleLinksUnmarshaled struct {
+	ID           string            `jsonapi:"primary,articles"`
+	Comments     []*Comment        `jsonapi:"relationship" json:"comments,omitempty"`
+	Self         string            `json:"-"`
+	RelatedLinks map[string]string `json:"-"`
+}
+
+func (a *ArticleLinksUnmarshaled) UnmarshalLink(link *Link) error {
+	if a.Self = LinkHref(link.Self); a.Self == "" {
+		return errors.New("missing self link")
+	}
+	return nil
+}
+
+func (a *ArticleLinksUnmarshaled) UnmarshalLinkRelation(relation string, link *Link) error {
+	related := LinkHref(link.Related)
+	if related == "" {
+		return errors.New("missing related link")
+	}
+	if a.RelatedLinks == nil {
+		a.RelatedLinks = make(map[string]string)
+	}
+	a.RelatedLinks[relation] = related
+	return nil
+}
+
 type ArticleLinkedOnlySelf struct {
 	ID string `jsonapi:"primary,articles"`
 }
@@ -234,6 +279,16 @@ func (a *ArticleLinkedOnlySelf) Link() *Link {
 	return &Link{Self: fmt.Sprintf("https://example.com/articles/%s", a.ID)}
 }
 
+// ArticleLinkedWithComments has a self link, but no relationship links.
+type ArticleLinkedWithComments struct {
+	ID       string     `jsonapi:"primary,articles"`
+	Comments []*Comment `jsonapi:"relationship" json:"comments,omitempty"`
+}
+
+func (a *ArticleLinkedWithComments) Link() *Link {
+	return &Link{Self: fmt.Sprintf("https://example.com/articles/%s", a.ID)}
+}
+
 type ArticleLinkedInvalidSelf struct {
 	ID string `jsonapi:"primary,articles"`
 }
@@ -365,12 +420,154 @@ func (c *Comment) LinkRelation(relation string) *Link {
 	}
 }
 
+// ArticleSortable has attributes of every kind SortResources sorts by.
+type ArticleSortable struct {
+	ID        string     `jsonapi:"primary,articles"`
+	Title     string     `jsonapi:"attribute" json:"title"`
+	Views     int        `jsonapi:"attribute" json:"views"`
+	Rating    *float64   `jsonapi:"attribute" json:"rating"`
+	Draft     bool       `jsonapi:"attribute" json:"draft"`
+	Published time.Time  `jsonapi:"attribute" json:"published"`
+	Tags      []string   `jsonapi:"attribute" json:"tags"`
+	Author    *Author    `jsonapi:"relationship" json:"author"`
+	Comments  []*Comment `jsonapi:"relationship" json:"comments"`
+}
+
 type Author struct {
 	ID   string         `jsonapi:"primary,author"`
 	Name string         `jsonapi:"attribute" json:"name"`
 	Meta map[string]any `jsonapi:"meta"`
 }
 
+// TenantArticle is an article whose resource type is prefixed with the name of its tenant, if any.
+type TenantArticle struct {
+	ID     string `jsonapi:"primary,articles"`
+	Tenant string `json:"-"`
+	Title  string `jsonapi:"attribute" json:"title"`
+}
+
+func (a *TenantArticle) MarshalResourceType() string {
+	if a.Tenant == "" {
+		return "articles"
+	}
+	return a.Tenant + "-articles"
+}
+
+func (a *TenantArticle) UnmarshalResourceType(resourceType string) error {
+	if resourceType == "articles" {
+		return nil
+	}
+	if !strings.HasSuffix(resourceType, "-articles") {
+		return &TypeError{Actual: resourceType, Expected: []string{"articles", "<tenant>-articles"}}
+	}
+	a.Tenant = strings.TrimSuffix(resourceType, "-articles")
+	return nil
+}
+
+type ArticleWithResourceAuthor struct {
+	ID     string    `jsonapi:"primary,articles"`
+	Author *Resource `jsonapi:"relationship" json:"author,omitempty"`
+}
+
+// ArticleCustom controls its resource object, storing its title in words and its author by id.
+type ArticleCustom struct {
+	ID       string
+	Words    []string
+	AuthorID string
+}
+
+func (a *ArticleCustom) MarshalResource() (*Resource, error) {
+	if len(a.Words) == 0 {
+		return nil, errArticleCustomTitle
+	}
+	r := NewResource("articles", a.ID).SetAttr("title", strings.Join(a.Words, " "))
+	if a.AuthorID != "" {
+		r.SetToOne("author", &ResourceIdentifier{Type: "author", ID: a.AuthorID})
+	}
+	return r, nil
+}
+
+func (a *ArticleCustom) UnmarshalResource(r *Resource) error {
+	title, _ := r.Attr("title")
+	s, ok := title.(string)
+	if !ok || s == "" {
+		return errArticleCustomTitle
+	}
+	*a = ArticleCustom{ID: r.ID(), Words: strings.Fields(s)}
+	if author, _ := r.ToOne("author"); author != nil {
+		a.AuthorID = author.ID
+	}
+	return nil
+}
+
+var errArticleCustomTitle = fmt.Errorf("title is required")
+
+type ArticleWithCustomAuthor struct {
+	ID     string         `jsonapi:"primary,articles"`
+	Author *ArticleCustom `jsonapi:"relationship" json:"author,omitempty"`
+}
+
+// ArticleHooked derives its slug from its title before marshaling, and trims its title after
+// unmarshaling, recording the editor given by the context.
+type ArticleHooked struct {
+	ID     string `jsonapi:"primary,articles"`
+	Title  string `jsonapi:"attribute" json:"title"`
+	Slug   string `jsonapi:"attribute" json:"slug,omitempty"`
+	Editor string `json:"-"`
+}
+
+type editorKey struct{}
+
+var errArticleHookedTitle = fmt.Errorf("title is required")
+
+func (a *ArticleHooked) BeforeMarshalJSONAPI(ctx context.Context) error {
+	if a.Title == "" {
+		return errArticleHookedTitle
+	}
+	a.Slug = strings.ToLower(strings.ReplaceAll(a.Title, " ", "-"))
+	return nil
+}
+
+func (a *ArticleHooked) AfterUnmarshalJSONAPI(ctx context.Context) error {
+	a.Title = strings.TrimSpace(a.Title)
+	if a.Title == "" {
+		return errArticleHookedTitle
+	}
+	a.Editor, _ = ctx.Value(editorKey{}).(string)
+	return nil
+}
+
+type ArticleVersioned struct {
+	ID            string  `jsonapi:"primary,articles"`
+	Title         string  `jsonapi:"attribute" json:"title"`
+	VersionID     string  `jsonapi:"extension" json:"version:id,omitempty"`
+	Author        *Author `jsonapi:"relationship" json:"author,omitempty"`
+	AuthorVersion string  `json:"-"`
+}
+
+func (a *ArticleVersioned) MarshalRelationshipExtensions(relation string) any {
+	if relation != "author" || a.AuthorVersion == "" {
+		return nil
+	}
+	return map[string]string{"version:id": a.AuthorVersion}
+}
+
+func (a *ArticleVersioned) UnmarshalRelationshipExtensions(relation string, members []byte) error {
+	var v struct {
+		ID string `json:"version:id"`
+	}
+	if err := json.Unmarshal(members, &v); err != nil {
+		return err
+	}
+	a.AuthorVersion = v.ID
+	return nil
+}
+
+type ArticleWithInvalidExtensionName struct {
+	ID        string `jsonapi:"primary,articles"`
+	VersionID string `jsonapi:"extension" json:"ver-sion:id"`
+}
+
 type ArticleRelated struct {
 	ID       string     `jsonapi:"primary,articles"`
 	Title    string     `jsonapi:"attribute" json:"title"`
@@ -392,6 +589,130 @@ type ArticleRelatedNoOmitEmpty struct {
 	Comments []*Comment `jsonapi:"relationship" json:"comments"`
 }
 
+type ArticleRelatedIDs struct {
+	ID         string   `jsonapi:"primary,articles"`
+	Title      string   `jsonapi:"attribute" json:"title"`
+	AuthorID   string   `jsonapi:"relationship" json:"author,omitempty" reltype:"author"`
+	CommentIDs []string `jsonapi:"relationship" json:"comments,omitempty" reltype:"comments"`
+}
+
+type ArticleRelatedIDsOption struct {
+	ID         string   `jsonapi:"primary,articles"`
+	Title      string   `jsonapi:"attribute" json:"title"`
+	AuthorID   string   `jsonapi:"rel,type=author" json:"author,omitempty"`
+	CommentIDs []string `jsonapi:"relationship,type=comments" json:"comments,omitempty"`
+}
+
+// ArticleNullable has to-one relationships which may be omitted or null.
+type ArticleNullable struct {
+	ID       string                        `jsonapi:"primary,articles"`
+	Title    string                        `jsonapi:"attribute" json:"title"`
+	Author   NullableRelationship[*Author] `jsonapi:"relationship" json:"author,omitempty"`
+	EditorID NullableRelationship[string]  `jsonapi:"relationship,type=author" json:"editor"`
+}
+
+// Writer and WrittenArticle reference each other, forming cyclic object graphs.
+type Writer struct {
+	ID       string            `jsonapi:"primary,writers"`
+	Articles []*WrittenArticle `jsonapi:"relationship" json:"articles,omitempty"`
+}
+
+type WrittenArticle struct {
+	ID     string  `jsonapi:"primary,articles"`
+	Writer *Writer `jsonapi:"relationship" json:"writer,omitempty"`
+}
+
+// newWriterGraph returns a writer of two articles which reference the writer back.
+func newWriterGraph() (*Writer, []*WrittenArticle) {
+	w := &Writer{ID: "1"}
+	articles := []*WrittenArticle{{ID: "1", Writer: w}, {ID: "2", Writer: w}}
+	w.Articles = articles
+	return w, articles
+}
+
+type Tag struct {
+	ID   string `jsonapi:"primary,tags"`
+	Name string `jsonapi:"attribute" json:"name"`
+}
+
+// ArticleTagged holds when its tags were assigned, as meta of its resource identifier objects.
+type ArticleTagged struct {
+	ID         string            `jsonapi:"primary,articles"`
+	Tags       []*Tag            `jsonapi:"relationship" json:"tags,omitempty"`
+	TagIDs     []string          `jsonapi:"relationship" json:"featuredTags,omitempty" reltype:"tags"`
+	AssignedAt map[string]string `json:"-"`
+}
+
+func (a *ArticleTagged) MarshalIdentifierMeta(relation string, id ResourceIdentifier) any {
+	assignedAt, ok := a.AssignedAt[id.ID]
+	if !ok {
+		return nil
+	}
+	return map[string]any{"assignedAt": assignedAt}
+}
+
+func (a *ArticleTagged) UnmarshalIdentifierMeta(relation string, id ResourceIdentifier, meta []byte) error {
+	var m struct {
+		AssignedAt string `json:"assignedAt"`
+	}
+	if err := json.Unmarshal(meta, &m); err != nil {
+		return err
+	}
+	if a.AssignedAt == nil {
+		a.AssignedAt = make(map[string]string)
+	}
+	a.AssignedAt[id.ID] = m.AssignedAt
+	return nil
+}
+
+// Account has a server-computed read-only attribute and a write-only secret.
+type Account struct {
+	ID        string `jsonapi:"primary,accounts"`
+	Name      string `jsonapi:"attribute" json:"name"`
+	CreatedAt string `jsonapi:"attribute,readonly" json:"createdAt,omitempty"`
+	Password  string `jsonapi:"attribute,writeonly" json:"password,omitempty"`
+}
+
+// ArticleExtras captures the attributes it doesn't declare.
+type ArticleExtras struct {
+	ID    string                     `jsonapi:"primary,articles"`
+	Title string                     `jsonapi:"attribute" json:"title,omitempty"`
+	Extra map[string]json.RawMessage `jsonapi:"extras"`
+}
+
+// Order has attributes of dynamic types.
+type Order struct {
+	ID      string         `jsonapi:"primary,orders"`
+	Total   any            `jsonapi:"attribute" json:"total"`
+	Details map[string]any `jsonapi:"attribute" json:"details"`
+}
+
+// Profile has attributes and relationships without json tags.
+type Profile struct {
+	ID         string  `jsonapi:"primary,profiles"`
+	FirstName  string  `jsonapi:"attribute"`
+	LastName   string  `jsonapi:"attribute"`
+	Nickname   string  `jsonapi:"attribute" json:"nick"`
+	BestFriend *Author `jsonapi:"relationship"`
+}
+
+type ArticleInvalidRelType struct {
+	ID       string `jsonapi:"primary,articles"`
+	AuthorID int    `jsonapi:"relationship" json:"author" reltype:"author"`
+}
+
+// ArticleWithInvalidRelated has a relationship to a resource type with invalid tags.
+type ArticleWithInvalidRelated struct {
+	ID    string               `jsonapi:"primary,articles"`
+	Posts []*ArticleInvalidTag `jsonapi:"relationship" json:"posts"`
+}
+
+// ArticleInvalidTag has an invalid jsonapi tag.
+type ArticleInvalidTag struct {
+	ID    string `jsonapi:"primary,posts"`
+	Title string `jsonapi:"attr,readonly,writeonly" json:"title"`
+}
+
 type ArticleDoubleID struct {
 	ID      string `jsonapi:"primary,articles"`
 	Title   string `jsonapi:"attribute" json:"title"`
@@ -416,6 +737,18 @@ type ArticleEmbeddedPointer struct {
 	Title string `jsonapi:"attribute" json:"title"`
 }
 
+type ArticleFlattened struct {
+	ID       string   `jsonapi:"primary,articles"`
+	Title    string   `jsonapi:"attribute" json:"title"`
+	Metadata Metadata `jsonapi:"attribute,,flatten" json:"-"`
+}
+
+type ArticleFlattenedPointer struct {
+	ID       string    `jsonapi:"primary,articles"`
+	Title    string    `jsonapi:"attribute" json:"title"`
+	Metadata *Metadata `jsonapi:"attr,,flatten" json:"-"`
+}
+
 type ArticleWithGenericMeta struct {
 	ID   string         `jsonapi:"primary,articles"`
 	Meta map[string]any `jsonapi:"meta"`
@@ -458,6 +791,17 @@ type ArticleWithInvalidRelationshipAttributeName struct {
 	Author *AuthorWithInvalidAttributeName `jsonapi:"relationship" json:"author"`
 }
 
+type Person struct {
+	ID      string    `jsonapi:"primary,people"`
+	Name    string    `jsonapi:"attribute" json:"name"`
+	Friends []*Person `jsonapi:"relationship" json:"friends,omitempty"`
+}
+
+type LegacyWorkspace struct {
+	ID   string `jsonapi:"primary,WorkspaceResource"`
+	Name string `jsonapi:"attribute" json:"DisplayName"`
+}
+
 type WebsiteWithInvalidNestedRelationshipTypeName struct {
 	ID       string                                    `jsonapi:"primary,website"`
 	Articles []*ArticleWithInvalidRelationshipTypeName `jsonapi:"relationship" json:"articles"`
@@ -0,0 +1,146 @@
+// Package jsonapitest provides helpers for testing the JSON:API documents produced by handlers and
+// resource structs against golden files:
+//
+//	func TestArticle(t *testing.T) {
+//		jsonapitest.AssertMarshals(t, &article, "testdata/article.json")
+//	}
+//
+// Documents are normalized before being compared or written, so golden files do not depend on the
+// order of object members or included resources. Run the tests with the -jsonapi.update flag to
+// write the golden files instead of comparing against them:
+//
+//	go test ./... -jsonapi.update
+//
+// The flag is namespaced so that it doesn't conflict with an -update flag of the test packages
+// importing jsonapitest.
+package jsonapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/DataDog/jsonapi"
+)
+
+var update = flag.Bool("jsonapi.update", false, "update the golden files of jsonapitest")
+
+// T is the subset of testing.TB used by the helpers.
+type T interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+}
+
+// AssertMarshals asserts that v marshaled with the given options is the document in the golden
+// file, or writes it to the golden file if the tests are run with -jsonapi.update.
+func AssertMarshals(t T, v any, golden string, opts ...jsonapi.MarshalOption) {
+	t.Helper()
+
+	b, err := jsonapi.Marshal(v, opts...)
+	if err != nil {
+		t.Fatalf("marshal %T: %v", v, err)
+		return
+	}
+	AssertGolden(t, b, golden)
+}
+
+// AssertGolden asserts that the json:api document body is the document in the golden file, or
+// writes it to the golden file if the tests are run with -jsonapi.update.
+func AssertGolden(t T, body []byte, golden string) {
+	t.Helper()
+
+	normalized, err := Normalize(body)
+	if err != nil {
+		t.Fatalf("normalize document: %v", err)
+		return
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatalf("update golden file: %v", err)
+			return
+		}
+		if err := os.WriteFile(golden, normalized, 0o644); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden file (run the tests with -jsonapi.update to create it): %v", err)
+		return
+	}
+	if ok, diffs := jsonapi.Equal(expected, normalized); !ok {
+		t.Errorf("document differs from %s:%s", golden, formatDifferences(diffs))
+	}
+}
+
+// AssertConforms asserts that resp has the JSON:API media type and a body conforming to the
+// JSON:API specification, as checked by jsonapi.Validate with the given options. The body of resp
+// is replaced, so it can still be read after the assertion.
+func AssertConforms(t T, resp *http.Response, opts ...jsonapi.ValidateOption) {
+	t.Helper()
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != jsonapi.MediaType {
+		t.Errorf("expected Content-Type %s, got %q", jsonapi.MediaType, resp.Header.Get("Content-Type"))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read response body: %v", err)
+		return
+	}
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if violations := jsonapi.Validate(body, opts...); len(violations) > 0 {
+		var buf bytes.Buffer
+		for _, v := range violations {
+			buf.WriteString("\n\t")
+			buf.WriteString(v.String())
+		}
+		t.Errorf("response body does not conform to JSON:API:%s", buf.String())
+	}
+}
+
+// Normalize returns the json document data in the canonical form returned by jsonapi.Canonical,
+// indented and followed by a newline.
+func Normalize(data []byte) ([]byte, error) {
+	canonical, err := jsonapi.Canonical(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, canonical, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// formatDifferences formats diffs with one difference per line.
+func formatDifferences(diffs []jsonapi.Difference) string {
+	var buf bytes.Buffer
+	for _, d := range diffs {
+		buf.WriteString("\n\t")
+		buf.WriteString(d.String())
+		if d.A != nil || d.B != nil {
+			a, _ := json.Marshal(d.A)
+			b, _ := json.Marshal(d.B)
+			buf.WriteString("\n\t\t- ")
+			buf.Write(a)
+			buf.WriteString("\n\t\t+ ")
+			buf.Write(b)
+		}
+	}
+	return buf.String()
+}
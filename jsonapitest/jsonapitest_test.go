@@ -0,0 +1,226 @@
+package jsonapitest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi"
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+type author struct {
+	ID   string `jsonapi:"primary,authors"`
+	Name string `jsonapi:"attribute" json:"name"`
+}
+
+type comment struct {
+	ID   string `jsonapi:"primary,comments"`
+	Body string `jsonapi:"attribute" json:"body"`
+}
+
+type article struct {
+	ID       string     `jsonapi:"primary,articles"`
+	Title    string     `jsonapi:"attribute" json:"title"`
+	Author   *author    `jsonapi:"relationship" json:"author,omitempty"`
+	Comments []*comment `jsonapi:"relationship" json:"comments,omitempty"`
+}
+
+var (
+	authorA  = author{ID: "1", Name: "A"}
+	commentA = comment{ID: "1", Body: "A"}
+	commentB = comment{ID: "2", Body: "B"}
+	articleA = article{ID: "1", Title: "A", Author: &authorA, Comments: []*comment{&commentA, &commentB}}
+)
+
+// recorder records the failures reported by the helpers.
+type recorder struct {
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func TestAssertMarshals(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []jsonapi.MarshalOption
+		golden      string
+		expectError bool
+		expectFatal bool
+	}{
+		{
+			description: "equal",
+			given:       &articleA,
+			opts:        []jsonapi.MarshalOption{jsonapi.MarshalInclude(&commentB, &authorA, &commentA)},
+			golden:      "testdata/article.json",
+		}, {
+			description: "equal with included resources in another order",
+			given:       &articleA,
+			opts:        []jsonapi.MarshalOption{jsonapi.MarshalInclude(&commentA, &commentB, &authorA)},
+			golden:      "testdata/article.json",
+		}, {
+			description: "different",
+			given:       &article{ID: "1", Title: "B", Author: &authorA, Comments: []*comment{&commentA, &commentB}},
+			opts:        []jsonapi.MarshalOption{jsonapi.MarshalInclude(&authorA, &commentA, &commentB)},
+			golden:      "testdata/article.json",
+			expectError: true,
+		}, {
+			description: "missing golden file",
+			given:       &articleA,
+			golden:      "testdata/missing.json",
+			expectError: true,
+			expectFatal: true,
+		}, {
+			description: "marshal error",
+			given:       &article{Title: "A"},
+			golden:      "testdata/article.json",
+			expectError: true,
+			expectFatal: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var r recorder
+			AssertMarshals(&r, tc.given, tc.golden, tc.opts...)
+			is.Equal(t, tc.expectError, len(r.errors) > 0)
+			is.Equal(t, tc.expectFatal, r.fatal)
+		})
+	}
+}
+
+func TestAssertGoldenDiff(t *testing.T) {
+	t.Parallel()
+
+	var r recorder
+	AssertGolden(&r, []byte(`{"data":null}`), "testdata/article.json")
+	is.Equal(t, 1, len(r.errors))
+	is.Equal(t, true, strings.Contains(r.errors[0], "/data: values differ"))
+	is.Equal(t, true, strings.Contains(r.errors[0], "/included: member only in a"))
+}
+
+// TestAssertGoldenUpdate can't run in parallel as it sets the -jsonapi.update flag.
+func TestAssertGoldenUpdate(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "testdata", "article.json")
+
+	*update = true
+	defer func() { *update = false }()
+
+	var r recorder
+	AssertMarshals(&r, &articleA, golden, jsonapi.MarshalInclude(&authorA, &commentA, &commentB))
+	is.Equal(t, 0, len(r.errors))
+
+	expected, err := os.ReadFile("testdata/article.json")
+	is.MustNoError(t, err)
+	actual, err := os.ReadFile(golden)
+	is.MustNoError(t, err)
+	is.Equal(t, string(expected), string(actual))
+}
+
+func TestAssertConforms(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		contentType string
+		body        string
+		expectError bool
+	}{
+		{
+			description: "conforming",
+			contentType: jsonapi.MediaType,
+			body:        `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`,
+		}, {
+			description: "content type with parameters",
+			contentType: jsonapi.MediaType + `; ext="https://jsonapi.org/ext/atomic"`,
+			body:        `{"data":null}`,
+		}, {
+			description: "wrong content type",
+			contentType: "application/json",
+			body:        `{"data":null}`,
+			expectError: true,
+		}, {
+			description: "nonconforming body",
+			contentType: jsonapi.MediaType,
+			body:        `{"data":{"type":"articles","id":"1","attributes":{"id":"1"}}}`,
+			expectError: true,
+		}, {
+			description: "invalid json",
+			contentType: jsonapi.MediaType,
+			body:        `{"data":`,
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {tc.contentType}},
+				Body:       io.NopCloser(strings.NewReader(tc.body)),
+			}
+
+			var r recorder
+			AssertConforms(&r, resp)
+			is.Equal(t, tc.expectError, len(r.errors) > 0)
+
+			// the body can still be read
+			body, err := io.ReadAll(resp.Body)
+			is.MustNoError(t, err)
+			is.Equal(t, tc.body, string(body))
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	b, err := Normalize([]byte(`{"included":[{"type":"comments","id":"2"},{"id":"1","type":"authors"},{"type":"comments","id":"1"}],"data":null}`))
+	is.MustNoError(t, err)
+	is.Equal(t, `{
+  "data": null,
+  "included": [
+    {
+      "id": "1",
+      "type": "authors"
+    },
+    {
+      "id": "1",
+      "type": "comments"
+    },
+    {
+      "id": "2",
+      "type": "comments"
+    }
+  ]
+}
+`, string(b))
+
+	_, err = Normalize([]byte(`{"data":`))
+	is.Equal(t, true, err != nil)
+}
@@ -0,0 +1,61 @@
+package jsonapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DataDog/jsonapi"
+)
+
+// ReplayError indicates that the response to a replayed jsonapi.Exchange differs from the recorded
+// one.
+type ReplayError struct {
+	Exchange *jsonapi.Exchange
+	Status   int
+	Response json.RawMessage
+}
+
+// Error implements the error interface.
+func (e *ReplayError) Error() string {
+	if e.Status != e.Exchange.Status {
+		return fmt.Sprintf("%s %s: expected status %d, got %d", e.Exchange.Method, e.Exchange.URL, e.Exchange.Status, e.Status)
+	}
+	return fmt.Sprintf("%s %s: expected response %s, got %s", e.Exchange.Method, e.Exchange.URL, e.Exchange.Response, e.Response)
+}
+
+// Replay sends the request of the exchange e, as recorded by jsonapi.Capture, to the given handler,
+// and returns a *ReplayError if its response status or document differ from the recorded ones.
+// Documents are compared with jsonapi.Equal, so the order of members and included resources
+// doesn't matter.
+func Replay(e *jsonapi.Exchange, h http.Handler) error {
+	var body io.Reader
+	if len(e.Request) > 0 {
+		body = bytes.NewReader(e.Request)
+	}
+	r := httptest.NewRequest(e.Method, e.URL, body)
+	r.Header.Set("Accept", jsonapi.MediaType)
+	if body != nil {
+		r.Header.Set("Content-Type", jsonapi.MediaType)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	var response json.RawMessage
+	if b := rec.Body.Bytes(); len(b) > 0 && json.Valid(b) {
+		response = b
+	}
+
+	equal := len(e.Response) == len(response)
+	if len(e.Response) > 0 && len(response) > 0 {
+		equal, _ = jsonapi.Equal(e.Response, response)
+	}
+	if rec.Code != e.Status || !equal {
+		return &ReplayError{Exchange: e, Status: rec.Code, Response: response}
+	}
+	return nil
+}
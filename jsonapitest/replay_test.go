@@ -0,0 +1,40 @@
+package jsonapitest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/jsonapi"
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// articleHandler serves the article with id 1 and the given title.
+func articleHandler(title string) http.Handler {
+	return jsonapi.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return jsonapi.Write(w, http.StatusOK, &article{ID: "1", Title: title})
+	})
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	h := jsonapi.Capture(dir)(articleHandler("A"))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/articles/1", nil))
+
+	exchanges, err := jsonapi.LoadExchanges(dir)
+	is.MustNoError(t, err)
+	is.MustEqual(t, 1, len(exchanges))
+
+	// replaying against an unchanged handler
+	is.MustNoError(t, Replay(exchanges[0], articleHandler("A")))
+
+	// replaying against a changed handler
+	err = Replay(exchanges[0], articleHandler("B"))
+	var re *ReplayError
+	is.MustEqual(t, true, errors.As(err, &re))
+	is.Equal(t, http.StatusOK, re.Status)
+	is.EqualJSON(t, `{"data":{"type":"articles","id":"1","attributes":{"title":"B"}}}`, string(re.Response))
+}
@@ -0,0 +1,80 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DecodeLimits limits the size and complexity of unmarshaled documents, protecting servers from
+// hostile input such as compound documents with millions of included resources. Zero values are
+// unlimited.
+type DecodeLimits struct {
+	// MaxBytes is the maximum size of documents in bytes.
+	MaxBytes int64
+
+	// MaxIncluded is the maximum number of included resources of compound documents.
+	MaxIncluded int
+
+	// MaxRelationships is the maximum number of resource identifier objects in the resource
+	// linkage of a relationship.
+	MaxRelationships int
+
+	// MaxDepth is the maximum nesting depth of the objects and arrays of documents, the top-level
+	// object having a depth of 1.
+	MaxDepth int
+}
+
+// UnmarshalLimits makes Unmarshal reject documents exceeding the given limits with a LimitError.
+// Documents are tokenized before they are decoded, and rejected as soon as a limit is exceeded, so
+// that hostile documents aren't decoded into memory. The limits apply to every function unmarshaling
+// documents, e.g. UnmarshalRelationshipUpdate, UnmarshalRef, Verify, UnmarshalErrors and
+// DecodeEach, whose primary data counts as resource linkage for relationship documents.
+func UnmarshalLimits(l DecodeLimits) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.limits = l
+	}
+}
+
+// LimitError indicates that an unmarshaled document exceeds a limit given by UnmarshalLimits.
+type LimitError struct {
+	// Limit is the name of the exceeded limit, e.g. "MaxIncluded".
+	Limit string
+
+	// Max is the value of the exceeded limit.
+	Max int64
+
+	// Pointer is a JSON pointer (RFC 6901) to the member of the document exceeding the limit, if
+	// any.
+	Pointer string
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case "MaxBytes":
+		return fmt.Sprintf("document is larger than %d bytes", e.Max)
+	case "MaxIncluded":
+		return fmt.Sprintf("document has more than %d included resources", e.Max)
+	case "MaxRelationships":
+		return fmt.Sprintf("relationship has more than %d resource identifier objects", e.Max)
+	case "MaxDepth":
+		return fmt.Sprintf("document is nested deeper than %d levels", e.Max)
+	}
+	return fmt.Sprintf("document exceeds %s of %d", e.Limit, e.Max)
+}
+
+// Status returns 413 (Payload Too Large), implementing StatusError.
+func (e *LimitError) Status() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+func (e *LimitError) mapPointer(f func(pointer string) string) {
+	if e.Pointer != "" {
+		e.Pointer = f(e.Pointer)
+	}
+}
+
+// ErrorObject converts e to an error object with status 413 (Payload Too Large).
+func (e *LimitError) ErrorObject() *Error {
+	return newErrorObject(e.Status(), "", e.Pointer, e)
+}
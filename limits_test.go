@@ -0,0 +1,159 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestUnmarshalLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		limits      DecodeLimits
+		expectError error
+	}{
+		{
+			description: "no limits",
+			given:       articleRelatedCompleteWithIncludeBody,
+		}, {
+			description: "within limits",
+			given:       articleRelatedCompleteWithIncludeBody,
+			limits:      DecodeLimits{MaxBytes: int64(len(articleRelatedCompleteWithIncludeBody)), MaxIncluded: 3, MaxRelationships: 2, MaxDepth: 6},
+		}, {
+			description: "too many bytes",
+			given:       articleRelatedCompleteWithIncludeBody,
+			limits:      DecodeLimits{MaxBytes: 10},
+			expectError: &LimitError{Limit: "MaxBytes", Max: 10},
+		}, {
+			description: "too many included resources",
+			given:       articleRelatedCompleteWithIncludeBody,
+			limits:      DecodeLimits{MaxIncluded: 2},
+			expectError: &LimitError{Limit: "MaxIncluded", Max: 2, Pointer: "/included"},
+		}, {
+			description: "too many resource identifier objects",
+			given:       articleRelatedCompleteWithIncludeBody,
+			limits:      DecodeLimits{MaxRelationships: 1},
+			expectError: &LimitError{Limit: "MaxRelationships", Max: 1, Pointer: "/data/relationships/comments/data"},
+		}, {
+			description: "too many resource identifier objects in included resource",
+			given:       `{"data":{"type":"articles","id":"1"},"included":[{"type":"articles","id":"2","relationships":{"comments":{"data":[{"type":"comments","id":"1"},{"type":"comments","id":"2"}]}}}]}`,
+			limits:      DecodeLimits{MaxRelationships: 1},
+			expectError: &LimitError{Limit: "MaxRelationships", Max: 1, Pointer: "/included/0/relationships/comments/data"},
+		}, {
+			description: "too deep",
+			given:       articleRelatedCompleteWithIncludeBody,
+			limits:      DecodeLimits{MaxDepth: 5},
+			expectError: &LimitError{Limit: "MaxDepth", Max: 5},
+		}, {
+			description: "brackets in strings",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"title":"[[{{\"}}"}}}`,
+			limits:      DecodeLimits{MaxDepth: 3},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var article ArticleRelated
+			err := Unmarshal([]byte(tc.given), &article, UnmarshalLimits(tc.limits))
+			if tc.expectError == nil {
+				is.MustNoError(t, err)
+				return
+			}
+			is.Equal(t, tc.expectError, err)
+		})
+	}
+}
+
+func TestLimitErrorObjects(t *testing.T) {
+	t.Parallel()
+
+	err := Unmarshal([]byte(articleRelatedCompleteWithIncludeBody), &ArticleRelated{}, UnmarshalLimits(DecodeLimits{MaxIncluded: 1}))
+	is.Equal(t, []*Error{{
+		Status: Status(http.StatusRequestEntityTooLarge),
+		Title:  http.StatusText(http.StatusRequestEntityTooLarge),
+		Detail: "document has more than 1 included resources",
+		Source: &ErrorSource{Pointer: "/included"},
+	}}, ErrorObjects(err))
+}
+
+func TestUnmarshalLimitsEntryPoints(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		do          func(opt UnmarshalOption) error
+		limits      DecodeLimits
+		expectError error
+	}{
+		{
+			description: "UnmarshalRelationshipUpdate",
+			do: func(opt UnmarshalOption) error {
+				_, err := UnmarshalRelationshipUpdate([]byte(`{"data":[{"type":"comments","id":"1"},{"type":"comments","id":"2"}]}`), http.MethodPost, opt)
+				return err
+			},
+			limits:      DecodeLimits{MaxRelationships: 1},
+			expectError: &LimitError{Limit: "MaxRelationships", Max: 1, Pointer: "/data"},
+		}, {
+			description: "UnmarshalRef",
+			do: func(opt UnmarshalOption) error {
+				var a ArticleRelated
+				return UnmarshalRef([]byte(`{"data":{"type":"author","id":"1","meta":{"a":{"b":{}}}}}`), &a, "author", opt)
+			},
+			limits:      DecodeLimits{MaxDepth: 3},
+			expectError: &LimitError{Limit: "MaxDepth", Max: 3},
+		}, {
+			description: "Verify",
+			do: func(opt UnmarshalOption) error {
+				return Verify([]byte(articleRelatedCompleteWithIncludeBody), opt)
+			},
+			limits:      DecodeLimits{MaxIncluded: 1},
+			expectError: &LimitError{Limit: "MaxIncluded", Max: 1, Pointer: "/included"},
+		}, {
+			description: "UnmarshalErrors",
+			do: func(opt UnmarshalOption) error {
+				_, err := UnmarshalErrors([]byte(`{"errors":[{"status":"404"}]}`), opt)
+				return err
+			},
+			limits:      DecodeLimits{MaxBytes: 10},
+			expectError: &LimitError{Limit: "MaxBytes", Max: 10},
+		}, {
+			description: "DecodeEach resource object in array",
+			do: func(opt UnmarshalOption) error {
+				return DecodeEach(strings.NewReader(articleRelatedCompleteWithIncludeBody), func(*ArticleRelated) error { return nil }, opt)
+			},
+			limits:      DecodeLimits{MaxIncluded: 1},
+			expectError: &LimitError{Limit: "MaxIncluded", Max: 1, Pointer: "/included"},
+		}, {
+			description: "DecodeEach too many bytes",
+			do: func(opt UnmarshalOption) error {
+				return DecodeEach(strings.NewReader(articleRelatedCompleteWithIncludeBody), func(*ArticleRelated) error { return nil }, opt)
+			},
+			limits:      DecodeLimits{MaxBytes: 10},
+			expectError: &LimitError{Limit: "MaxBytes", Max: 10},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			err := tc.do(UnmarshalLimits(tc.limits))
+			var le *LimitError
+			is.Equal(t, true, errors.As(err, &le))
+			is.Equal(t, tc.expectError, le)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package jsonapi
+
+// MarshalLinksOnly marshals the relationships of resources of the given type with the given names,
+// or all of them if none are given, with their links and meta only, omitting their resource
+// linkage. This allows list endpoints to link large to-many relationships without loading the
+// identifiers of all related resources.
+//
+// Relationships without links or meta keep their resource linkage, as relationship objects must
+// have at least one of them. So do relationships linking included resources, as compound documents
+// require full linkage: relationships on the include paths given to MarshalIncludeResolver always
+// have resource linkage, as do those linking resources given via MarshalInclude.
+func MarshalLinksOnly(resourceType string, relationships ...string) MarshalOption {
+	return func(m *Marshaler) {
+		if m.linksOnly == nil {
+			m.linksOnly = make(map[string][]string)
+		}
+		names, ok := m.linksOnly[resourceType]
+		switch {
+		case ok && names == nil:
+			// all relationships are links-only already
+		case len(relationships) == 0:
+			m.linksOnly[resourceType] = nil
+		default:
+			m.linksOnly[resourceType] = append(names, relationships...)
+		}
+	}
+}
+
+// isLinksOnly returns true if the relationship named relation of resources of the given type is
+// marshaled without resource linkage.
+func (m *Marshaler) isLinksOnly(resourceType, relation string) bool {
+	names, ok := m.linksOnly[resourceType]
+	if !ok {
+		return false
+	}
+	if names == nil {
+		return true
+	}
+	for _, name := range names {
+		if name == relation {
+			return true
+		}
+	}
+	return false
+}
+
+// omitLinkage omits the resource linkage of the links-only relationships of ro which have links or
+// meta, and removes the other relationships named in omitted.
+func (m *Marshaler) omitLinkage(ro *resourceObject, omitted map[string]bool) {
+	if len(m.linksOnly) == 0 {
+		return
+	}
+	for name, rd := range ro.Relationships {
+		if (rd.Links != nil || rd.Meta != nil) && m.isLinksOnly(ro.Type, name) {
+			rd.noData = true
+		} else if omitted[name] {
+			delete(ro.Relationships, name)
+		}
+	}
+}
+
+// linkIncluded restores the resource linkage of the links-only relationships of the resource
+// objects of d which link included resources.
+func linkIncluded(d *document) {
+	ros := make([]*resourceObject, 0, len(d.DataMany)+len(d.Included)+1)
+	ros = append(ros, d.DataMany...)
+	if d.DataOne != nil {
+		ros = append(ros, d.DataOne)
+	}
+	ros = append(ros, d.Included...)
+	linkResourceObjects(ros, d.Included)
+}
+
+// linkResourceObjects restores the resource linkage of the links-only relationships of the given
+// resource objects which link one of the given included resources.
+func linkResourceObjects(ros, included []*resourceObject) {
+	if len(included) == 0 {
+		return
+	}
+
+	ids := make(map[string]bool, len(included))
+	for _, ro := range included {
+		ids[ro.identifier()] = true
+	}
+
+	for _, ro := range ros {
+		for _, rd := range ro.Relationships {
+			if !rd.noData {
+				continue
+			}
+			linkage := rd.DataMany
+			if rd.DataOne != nil {
+				linkage = append(linkage, rd.DataOne)
+			}
+			for _, ri := range linkage {
+				if ids[ri.identifier()] {
+					rd.noData = false
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package jsonapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalLinksOnly(t *testing.T) {
+	t.Parallel()
+
+	authorLinks := `"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}`
+	commentsLinks := `"links":{"self":"http://example.com/articles/1/relationships/comments","related":"http://example.com/articles/1/comments"}`
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+	}{
+		{
+			description: "all relationships",
+			given:       &ArticleRelated{ID: "1", Title: "A", Author: &authorA, Comments: commentsAB},
+			opts:        []MarshalOption{MarshalLinksOnly("articles")},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{` + authorLinks + `},"comments":{` + commentsLinks + `}}}}`,
+		}, {
+			description: "empty relationships aren't omitted",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			opts:        []MarshalOption{MarshalLinksOnly("articles", "comments")},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"comments":{` + commentsLinks + `}}}}`,
+		}, {
+			description: "named relationships",
+			given:       &ArticleRelated{ID: "1", Title: "A", Author: &authorA, Comments: commentsAB},
+			opts:        []MarshalOption{MarshalLinksOnly("articles", "comments")},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},` + authorLinks + `},"comments":{` + commentsLinks + `}}}}`,
+		}, {
+			description: "other resource types",
+			given:       &ArticleRelated{ID: "1", Title: "A", Author: &authorA},
+			opts:        []MarshalOption{MarshalLinksOnly("comments")},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},` + authorLinks + `}}}}`,
+		}, {
+			description: "relationships without links keep linkage",
+			given:       &ArticleRelatedNoOmitEmpty{ID: "1", Title: "A", Author: &authorA},
+			opts:        []MarshalOption{MarshalLinksOnly("articles")},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"}},"comments":{"data":[]}}}}`,
+		}, {
+			description: "relationships linking included resources keep linkage",
+			given:       &ArticleRelated{ID: "1", Title: "A", Author: &authorA, Comments: commentsAB},
+			opts:        []MarshalOption{MarshalLinksOnly("articles"), MarshalInclude(&authorA)},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},` + authorLinks + `},"comments":{` + commentsLinks + `}}},"included":[{"id":"1","type":"author","attributes":{"name":"A"}}]}`,
+		}, {
+			description: "relationships on include paths keep linkage",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			opts:        []MarshalOption{MarshalLinksOnly("articles"), MarshalIncludeResolver(IncludeResolverFunc(articleResolver), "author")},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},` + authorLinks + `},"comments":{` + commentsLinks + `}}},"included":[{"id":"1","type":"author","attributes":{"name":"A"}}]}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
@@ -0,0 +1,515 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MarshalToMap returns the json:api encoding of v, as encoded by Marshal with the given options, as
+// a generic Go value: json objects are map[string]any, arrays []any, strings string, booleans bool
+// and numbers json.Number, so that large integers and decimal values keep their precision. It lets
+// documents be manipulated, e.g. by middleware, before being encoded with json.Marshal or
+// unmarshaled with UnmarshalFromMap.
+//
+// The map is made from the document directly rather than by decoding its encoding. Only values
+// which aren't generic Go values already, e.g. structs or values implementing json.Marshaler, are
+// encoded to be decoded as such. Formatting options such as MarshalIndent have no effect.
+func MarshalToMap(v any, opts ...MarshalOption) (doc map[string]any, err error) {
+	m := makeMarshaler(opts...)
+
+	err = m.observe(func(op *Operation) error {
+		d, err := makeDocument(v, m, false)
+		if err != nil {
+			return err
+		}
+		op.add(d)
+
+		if doc, err = d.toMap(); err != nil {
+			return err
+		}
+		if err := validateMemberNames(doc, m.memberNameValidationMode, m.relaxedMemberClasses, m.extensions); err != nil {
+			return err
+		}
+		if m.dataMember != "" {
+			moveToDataMember(doc, m.dataMember)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// UnmarshalFromMap stores the document doc, given as a generic Go value such as returned by
+// MarshalToMap, in the value pointed to by v, as done by Unmarshal with the given options.
+//
+// The document is made from doc directly rather than from its encoding. Only the values decoded by
+// encoding/json into values of their own types, i.e. attributes, links, error objects and the
+// jsonapi object, are encoded to be decoded, so they must be encodable by encoding/json. DecodeLimits
+// apply, but for MaxBytes.
+func UnmarshalFromMap(doc map[string]any, v any, opts ...UnmarshalOption) error {
+	m := makeUnmarshaler(opts...)
+
+	return m.observe(func(op *Operation) error {
+		d, err := m.unmarshalMap(doc, v)
+		op.add(d)
+		return err
+	})
+}
+
+// unmarshalMap stores the document doc, given as a generic Go value, in v, returning the document
+// made from it, as done by unmarshalDocument.
+func (m *Unmarshaler) unmarshalMap(doc map[string]any, v any) (*document, error) {
+	if doc == nil {
+		return nil, &TypeError{Actual: "nil", Expected: []string{"map[string]any"}}
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return nil, &TypeError{Actual: rv.Kind().String(), Expected: []string{"non-nil pointer"}, err: ErrUnmarshalInvalidTarget}
+	}
+
+	if err := m.newDocumentScanner(false).scanValue(doc, nil, 0); err != nil {
+		return nil, err
+	}
+
+	pointer := func(p string) string { return p }
+	if m.dataMember != "" {
+		var err error
+		if doc, pointer, err = moveMapDataMember(doc, m.dataMember); err != nil {
+			return nil, err
+		}
+	}
+
+	d, err := documentFromMap(doc)
+	if err != nil {
+		return nil, mapPointers(err, pointer)
+	}
+
+	if err := validateMemberNames(doc, m.memberNameValidationMode, m.relaxedMemberClasses, m.extensions); err != nil {
+		return nil, mapPointers(err, pointer)
+	}
+
+	if len(d.Errors) > 0 {
+		return d, mapPointers(d.unmarshalErrors(v, m), pointer)
+	}
+
+	return d, mapPointers(d.unmarshal(v, m), pointer)
+}
+
+// toMap returns the document as a generic Go value, as encoded by document.MarshalJSON.
+func (d *document) toMap() (map[string]any, error) {
+	obj := make(map[string]any)
+
+	if len(d.Errors) == 0 && !d.noData {
+		if d.hasMany {
+			var data []any
+			if d.DataMany != nil {
+				data = make([]any, len(d.DataMany))
+			}
+			for i, ro := range d.DataMany {
+				var err error
+				if data[i], err = ro.toMap(); err != nil {
+					return nil, err
+				}
+			}
+			obj["data"] = data
+		} else {
+			data, err := d.DataOne.toMap()
+			if err != nil {
+				return nil, err
+			}
+			obj["data"] = data
+		}
+	}
+
+	members := make(map[string]any)
+	if d.Meta != nil {
+		members["meta"] = d.Meta
+	}
+	if d.JSONAPI != nil {
+		members["jsonapi"] = d.JSONAPI
+	}
+	if len(d.Errors) > 0 {
+		members["errors"] = d.Errors
+	}
+	if d.Links != nil {
+		members["links"] = d.Links
+	}
+	for name, value := range d.extensions {
+		members[name] = value
+	}
+	if err := addGenericMembers(obj, members); err != nil {
+		return nil, err
+	}
+
+	if len(d.Included) > 0 {
+		included := make([]any, len(d.Included))
+		for i, ro := range d.Included {
+			var err error
+			if included[i], err = ro.toMap(); err != nil {
+				return nil, err
+			}
+		}
+		obj["included"] = included
+	}
+
+	return obj, nil
+}
+
+// toMap returns the resource object as a generic Go value, as encoded by resourceObject.MarshalJSON,
+// or nil if ro is nil.
+func (ro *resourceObject) toMap() (any, error) {
+	if ro == nil {
+		return nil, nil
+	}
+
+	obj := map[string]any{"type": ro.Type}
+	if ro.ID != "" {
+		obj["id"] = ro.ID
+	}
+	if len(ro.Attributes) > 0 {
+		attributes := make(map[string]any, len(ro.Attributes))
+		if err := addGenericMembers(attributes, ro.Attributes); err != nil {
+			return nil, err
+		}
+		obj["attributes"] = attributes
+	}
+	if len(ro.Relationships) > 0 {
+		relationships := make(map[string]any, len(ro.Relationships))
+		for name, rd := range ro.Relationships {
+			if rd == nil {
+				relationships[name] = nil
+				continue
+			}
+			rel, err := rd.toMap()
+			if err != nil {
+				return nil, err
+			}
+			relationships[name] = rel
+		}
+		obj["relationships"] = relationships
+	}
+
+	members := make(map[string]any)
+	if ro.Meta != nil {
+		members["meta"] = ro.Meta
+	}
+	if ro.Links != nil {
+		members["links"] = ro.Links
+	}
+	for name, value := range ro.extensions {
+		members[name] = value
+	}
+	if err := addGenericMembers(obj, members); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// addGenericMembers adds the given members to obj as generic Go values.
+func addGenericMembers(obj map[string]any, members map[string]any) error {
+	for name, value := range members {
+		v, err := genericValue(value)
+		if err != nil {
+			return err
+		}
+		obj[name] = v
+	}
+	return nil
+}
+
+// genericValue returns v as a generic Go value, as encoded by encoding/json: maps and slices of
+// generic Go values are copied, integers are converted to json.Number and all other values are
+// encoded to be decoded as such.
+func genericValue(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, string, bool, json.Number:
+		return v, nil
+	case map[string]any:
+		if v == nil {
+			return nil, nil
+		}
+		obj := make(map[string]any, len(v))
+		if err := addGenericMembers(obj, v); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case []any:
+		if v == nil {
+			return nil, nil
+		}
+		arr := make([]any, len(v))
+		for i, value := range v {
+			var err error
+			if arr[i], err = genericValue(value); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case int:
+		return json.Number(strconv.FormatInt(int64(v), 10)), nil
+	case int8:
+		return json.Number(strconv.FormatInt(int64(v), 10)), nil
+	case int16:
+		return json.Number(strconv.FormatInt(int64(v), 10)), nil
+	case int32:
+		return json.Number(strconv.FormatInt(int64(v), 10)), nil
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case uint:
+		return json.Number(strconv.FormatUint(uint64(v), 10)), nil
+	case uint8:
+		return json.Number(strconv.FormatUint(uint64(v), 10)), nil
+	case uint16:
+		return json.Number(strconv.FormatUint(uint64(v), 10)), nil
+	case uint32:
+		return json.Number(strconv.FormatUint(uint64(v), 10)), nil
+	case uint64:
+		return json.Number(strconv.FormatUint(v, 10)), nil
+	}
+
+	b, err := marshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// moveToDataMember moves the primary data of the document doc, if any, to the member of the given
+// name, wrapping primary resource objects held by AtomicResultsMember in result objects, as written
+// by documentWriter.
+func moveToDataMember(doc map[string]any, name string) {
+	data, ok := doc["data"]
+	if !ok {
+		return
+	}
+	delete(doc, "data")
+
+	if name == AtomicResultsMember {
+		ros, ok := data.([]any)
+		if !ok {
+			ros = nil
+			if data != nil {
+				ros = []any{data}
+			}
+		}
+		results := make([]any, len(ros))
+		for i, ro := range ros {
+			results[i] = map[string]any{"data": ro}
+		}
+		data = results
+	}
+	doc[name] = data
+}
+
+// documentFromMap makes the document held by the generic Go value obj, as document.UnmarshalJSON
+// makes it from its json encoding.
+func documentFromMap(obj map[string]any) (*document, error) {
+	if len(obj) == 0 {
+		// {} - NOT OK
+		return nil, &DocumentError{Code: CodeMissingData, Err: ErrMissingDataField}
+	}
+
+	d := &document{}
+	switch data := obj["data"].(type) {
+	case nil:
+		// e.g. {"meta":{...}} - OK, or {"data":null} - OK
+		_, ok := obj["data"]
+		d.noData = !ok
+	case map[string]any:
+		if len(data) == 0 {
+			// {"data":{}} - NOT OK
+			return nil, &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrInvalidDataField}
+		}
+		ro, err := resourceObjectFromMap(data)
+		if err != nil {
+			return nil, prefixPointer(err, "/data")
+		}
+		d.DataOne = ro
+	case []any:
+		d.hasMany = true
+		ros, err := resourceObjectsFromMap(data)
+		if err != nil {
+			return nil, prefixPointer(err, "/data")
+		}
+		d.DataMany = ros
+	default:
+		return nil, &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: &TypeError{Actual: kindOfValue(data), Expected: []string{"object", "array", "null"}}}
+	}
+
+	d.Meta = obj["meta"]
+	if err := decodeMember(obj, "jsonapi", &d.JSONAPI); err != nil {
+		return nil, err
+	}
+	if err := decodeMember(obj, "errors", &d.Errors); err != nil {
+		return nil, err
+	}
+	if err := decodeMember(obj, "links", &d.Links); err != nil {
+		return nil, err
+	}
+
+	switch included := obj["included"].(type) {
+	case nil:
+	case []any:
+		ros, err := resourceObjectsFromMap(included)
+		if err != nil {
+			return nil, prefixPointer(err, "/included")
+		}
+		d.Included = ros
+	default:
+		return nil, &DocumentError{Pointer: "/included", Err: &TypeError{Actual: kindOfValue(included), Expected: []string{"array"}}}
+	}
+
+	var err error
+	d.raw, err = rawMembers(obj)
+	return d, err
+}
+
+// resourceObjectsFromMap makes the resource objects held by the generic Go value arr, keeping null
+// entries as nil.
+func resourceObjectsFromMap(arr []any) ([]*resourceObject, error) {
+	ros := make([]*resourceObject, len(arr))
+	for i, value := range arr {
+		pointer := "/" + strconv.Itoa(i)
+		switch value := value.(type) {
+		case nil:
+		case map[string]any:
+			ro, err := resourceObjectFromMap(value)
+			if err != nil {
+				return nil, prefixPointer(err, pointer)
+			}
+			ros[i] = ro
+		default:
+			return nil, &DocumentError{Code: CodeInvalidData, Pointer: pointer, Err: &TypeError{Actual: kindOfValue(value), Expected: []string{"object"}}}
+		}
+	}
+	return ros, nil
+}
+
+// resourceObjectFromMap makes the resource object held by the generic Go value obj, as
+// resourceObject.UnmarshalJSON makes it from its json encoding.
+func resourceObjectFromMap(obj map[string]any) (*resourceObject, error) {
+	ro := &resourceObject{}
+	for name, s := range map[string]*string{"id": &ro.ID, "type": &ro.Type} {
+		switch value := obj[name].(type) {
+		case nil:
+		case string:
+			*s = value
+		default:
+			return nil, &DocumentError{Pointer: "/" + name, Err: &TypeError{Actual: kindOfValue(value), Expected: []string{"string"}}}
+		}
+	}
+
+	if attributes, ok := obj["attributes"]; ok {
+		if _, isObject := attributes.(map[string]any); !isObject && attributes != nil {
+			return nil, &DocumentError{Pointer: "/attributes", Err: &json.UnmarshalTypeError{
+				Value: kindOfValue(attributes),
+				Type:  reflect.TypeOf(ro.Attributes),
+				Field: "attributes",
+			}}
+		}
+		// attributes are decoded into fields by encoding/json, so are held by their json encoding
+		b, err := marshalJSON(attributes)
+		if err != nil {
+			return nil, &DocumentError{Pointer: "/attributes", Err: err}
+		}
+		ro.rawAttributes = b
+	}
+
+	switch relationships := obj["relationships"].(type) {
+	case nil:
+	case map[string]any:
+		ro.Relationships = make(map[string]*document, len(relationships))
+		for name, rel := range relationships {
+			pointer := "/relationships/" + escapePointerToken(name)
+			switch rel := rel.(type) {
+			case nil:
+				ro.Relationships[name] = nil
+			case map[string]any:
+				rd, err := documentFromMap(rel)
+				if err != nil {
+					return nil, prefixPointer(err, pointer)
+				}
+				ro.Relationships[name] = rd
+			default:
+				return nil, &DocumentError{Pointer: pointer, Err: &TypeError{Actual: kindOfValue(rel), Expected: []string{"object"}}}
+			}
+		}
+	default:
+		return nil, &DocumentError{Pointer: "/relationships", Err: &TypeError{Actual: kindOfValue(relationships), Expected: []string{"object"}}}
+	}
+
+	ro.Meta = obj["meta"]
+	ro.identifierMeta = ro.Meta
+	if err := decodeMember(obj, "links", &ro.Links); err != nil {
+		return nil, err
+	}
+
+	var err error
+	ro.raw, err = rawMembers(obj)
+	return ro, err
+}
+
+// decodeMember decodes the member of the given name of the generic Go value obj, if any, into v.
+// The member is held by values of their own types, so it is decoded from its json encoding.
+func decodeMember(obj map[string]any, name string, v any) error {
+	value, ok := obj[name]
+	if !ok {
+		return nil
+	}
+	b, err := marshalJSON(value)
+	if err == nil {
+		err = unmarshalJSON(b, v)
+	}
+	if err != nil {
+		return &DocumentError{Pointer: "/" + escapePointerToken(name), Err: err}
+	}
+	return nil
+}
+
+// rawMembers returns the json encoding of an object holding the meta and extension members of the
+// generic Go value obj, from which they are decoded as from the raw encoding of unmarshaled
+// documents and resource objects, or nil if there are none.
+func rawMembers(obj map[string]any) (rawValue, error) {
+	members := make(map[string]any)
+	for name, value := range obj {
+		if name == "meta" || strings.Contains(name, ":") {
+			members[name] = value
+		}
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	return marshalJSON(members)
+}
+
+// kindOfValue returns the kind of json value the generic Go value v is encoded as, as used by
+// json.UnmarshalTypeError, or its type if it isn't a generic Go value.
+func kindOfValue(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case json.Number, float64:
+		return "number"
+	}
+	return reflect.TypeOf(v).String()
+}
@@ -0,0 +1,142 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalToMap(t *testing.T) {
+	t.Parallel()
+
+	doc, err := MarshalToMap(&ArticleSortable{ID: "1", Title: "A", Views: 9007199254740993}, MarshalMeta(map[string]any{"count": 1}))
+	is.MustNoError(t, err)
+
+	data := doc["data"].(map[string]any)
+	is.Equal(t, "articles", data["type"])
+	attributes := data["attributes"].(map[string]any)
+	is.Equal(t, "A", attributes["title"])
+	is.Equal(t, json.Number("9007199254740993"), attributes["views"])
+	is.Equal(t, map[string]any{"count": json.Number("1")}, doc["meta"])
+
+	// documents are manipulated between marshaling and unmarshaling
+	attributes["title"] = "B"
+
+	var a ArticleSortable
+	is.MustNoError(t, UnmarshalFromMap(doc, &a))
+	is.Equal(t, "1", a.ID)
+	is.Equal(t, "B", a.Title)
+	is.Equal(t, 9007199254740993, a.Views)
+}
+
+func TestUnmarshalFromMap(t *testing.T) {
+	t.Parallel()
+
+	var a Article
+	err := UnmarshalFromMap(map[string]any{"data": map[string]any{"id": "1", "attributes": map[string]any{"title": "A"}}}, &a)
+	is.MustError(t, err)
+
+	err = UnmarshalFromMap(nil, &a)
+	is.MustError(t, err)
+
+	_, err = MarshalToMap(&Article{Title: "A"})
+	is.EqualError(t, ErrEmptyPrimaryField, err)
+}
+
+func TestMarshalToMapMatchesMarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+	}{
+		{description: "one", given: &articleA},
+		{description: "many", given: articlesABPtr},
+		{description: "empty", given: []*Article{}},
+		{description: "null", given: (*Article)(nil)},
+		{description: "nested attributes", given: &articleComplete},
+		{description: "links", given: &articleALinked},
+		{description: "meta", given: &articleWithResourceObjectMeta},
+		{description: "relationships", given: &articleRelatedComplete},
+		{description: "compound", given: &articleRelatedComplete, opts: []MarshalOption{MarshalInclude(&authorAWithMeta, &commentA, &commentB)}},
+		{description: "errors", given: errorsComplexSliceManyPtr},
+		{description: "document meta", given: nil, opts: []MarshalOption{MarshalMeta(map[string]any{"count": uint64(1 << 60)})}},
+		{description: "atomic results", given: articlesABPtr, opts: []MarshalOption{MarshalDataMember(AtomicResultsMember), MarshalExtensions("atomic")}},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			var expect map[string]any
+			dec := json.NewDecoder(bytes.NewReader(b))
+			dec.UseNumber()
+			is.MustNoError(t, dec.Decode(&expect))
+
+			doc, err := MarshalToMap(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.Equal(t, expect, doc)
+		})
+	}
+}
+
+func TestUnmarshalFromMapMatchesUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		do          func(doc map[string]any, data []byte, opts ...UnmarshalOption) (any, any, error, error)
+		opts        []UnmarshalOption
+	}{
+		{description: "one", given: articleABody, do: unmarshalBoth[Article]},
+		{description: "many", given: articlesABBody, do: unmarshalBoth[[]*Article]},
+		{description: "null", given: nullDataBody, do: unmarshalBoth[*Article]},
+		{description: "nested attributes", given: articleCompleteBody, do: unmarshalBoth[ArticleComplete]},
+		{description: "links", given: articleALinkedBody, do: unmarshalBoth[ArticleLinked]},
+		{description: "meta", given: articleWithResourceObjectMetaBody, do: unmarshalBoth[ArticleWithResourceObjectMeta]},
+		{description: "compound", given: articleRelatedCompleteWithIncludeBody, do: unmarshalBoth[ArticleRelated]},
+		{description: "errors", given: errorsComplexSliceManyBody, do: unmarshalBoth[ErrorList]},
+		{description: "atomic results", given: `{"atomic:results":[{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}},{}]}`, do: unmarshalBoth[[]*Article], opts: []UnmarshalOption{UnmarshalDataMember(AtomicResultsMember), UnmarshalExtensions("atomic")}},
+		{description: "empty document", given: `{}`, do: unmarshalBoth[Article]},
+		{description: "empty primary data", given: emptySingleBody, do: unmarshalBoth[Article]},
+		{description: "invalid member name", given: authorWithInvalidAttributeNameBody, do: unmarshalBoth[Author]},
+		{description: "invalid attribute", given: `{"data":{"type":"articles","id":"1","attributes":{"title":1}}}`, do: unmarshalBoth[Article]},
+		{description: "too many included", given: articleRelatedCompleteWithIncludeBody, do: unmarshalBoth[ArticleRelated], opts: []UnmarshalOption{UnmarshalLimits(DecodeLimits{MaxIncluded: 2})}},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var doc map[string]any
+			dec := json.NewDecoder(strings.NewReader(tc.given))
+			dec.UseNumber()
+			is.MustNoError(t, dec.Decode(&doc))
+
+			expect, actual, expectErr, err := tc.do(doc, []byte(tc.given), tc.opts...)
+			is.EqualError(t, expectErr, err)
+			is.Equal(t, expect, actual)
+		})
+	}
+}
+
+// unmarshalBoth unmarshals the document doc with UnmarshalFromMap, and its encoding data with
+// Unmarshal, into values of type T.
+func unmarshalBoth[T any](doc map[string]any, data []byte, opts ...UnmarshalOption) (any, any, error, error) {
+	var expect, actual T
+	expectErr := Unmarshal(data, &expect, opts...)
+	err := UnmarshalFromMap(doc, &actual, opts...)
+	return expect, actual, expectErr, err
+}
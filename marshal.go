@@ -1,8 +1,9 @@
 package jsonapi
 
 import (
+	"context"
 	"encoding"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"reflect"
@@ -20,12 +21,46 @@ func init() {
 // It's used to configure the Marshaling by including optional fields like Meta or JSONAPI.
 type Marshaler struct {
 	meta                     any
-	includeJSONAPI           bool
-	jsonAPImeta              any
+	jsonAPI                  *JSONAPIObject
 	included                 []any
+	includeResolver          IncludeResolver
+	includePaths             []string
+	includeAuthorizer        IncludeAuthorizer
+	attributeRedactor        AttributeRedactor
+	timeFormat               TimeFormat
+	timeUTC                  bool
+	int64Strings             bool
+	linksOnly                map[string][]string
+	relationshipLinker       RelationshipLinker
+	ctx                      context.Context
+	flushThreshold           int
 	link                     *Link
+	describedBy              any
 	clientMode               bool
 	memberNameValidationMode memberNameValidationMode
+	relaxedMemberClasses     memberClasses
+	stringTableMinCount      int
+	includeLimit             int
+	metaSchema               MetaSchema
+	dataMember               string
+	rejectNil                bool
+	naming                   NamingConvention
+	partialLinkage           bool
+	partialLinkageHandler    func(err *PartialLinkageError)
+	extensions               extensionNamespaces
+	extensionMembers         any
+	zeroAttributes           ZeroAttributes
+	noEscapeHTML             bool
+	indentPrefix             string
+	indent                   string
+	streamFailure            StreamFailure
+	metaFuncs                []func(ctx context.Context) map[string]any
+	onMarshal                func(ctx context.Context, op Operation)
+	profiles                 []*ProfileHandler
+
+	// keepZero is true if zero attributes are marshaled regardless of options, as done by Diff to
+	// tell cleared attributes from omitted ones
+	keepZero bool
 
 	// fields support sparse fieldsets https://jsonapi.org/format/#fetching-sparse-fieldsets
 	fields map[string][]string
@@ -45,18 +80,74 @@ func MarshalMeta(meta any) MarshalOption {
 // This also enables writing Document.JSONAPI.Version.
 func MarshalJSONAPI(meta any) MarshalOption {
 	return func(m *Marshaler) {
-		m.includeJSONAPI = true
-		m.jsonAPImeta = meta
+		m.jsonAPI = &JSONAPIObject{Version: "1.0", Meta: meta}
+	}
+}
+
+// MarshalJSONAPIObject includes the given jsonapi object as Document.JSONAPI when marshaling, to
+// choose its version and declare the extensions and profiles applied to the document. A nil o
+// omits the jsonapi object, e.g. to override a MarshalJSONAPI option given before.
+func MarshalJSONAPIObject(o *JSONAPIObject) MarshalOption {
+	return func(m *Marshaler) {
+		m.jsonAPI = o
 	}
 }
 
 // MarshalInclude includes the json:api encoding of v within Document.Included creating a compound document as defined by https://jsonapi.org/format/#document-compound-documents.
+//
+// Each resource is included once per type and id pair, and not at all if it is primary data, so
+// the resources of cyclic object graphs (e.g. an article, its author and the author's articles) may
+// be given without deduplicating them first.
 func MarshalInclude(v ...any) MarshalOption {
 	return func(m *Marshaler) {
 		m.included = v
 	}
 }
 
+// MarshalIncludeResolver creates a compound document by calling r for each relationship requested
+// via the given include paths, as defined by https://jsonapi.org/format/#fetching-includes. Paths
+// are dot separated relationship names and may be comma separated (e.g. "author,comments.author"),
+// so the value of the `include` query parameter can be passed directly.
+//
+// Resources resolved this way are deduplicated, included, and linked from the parent's relationship
+// even if the parent's relationship field was left empty.
+func MarshalIncludeResolver(r IncludeResolver, paths ...string) MarshalOption {
+	return func(m *Marshaler) {
+		m.includeResolver = r
+		m.includePaths = paths
+	}
+}
+
+// MarshalIncludeAuthorizer consults a for every relationship before hoisting its related resources
+// into the included resources of a compound document, given via MarshalInclude or resolved via
+// MarshalIncludeResolver. This allows servers to honor per-relationship visibility rules without
+// building separate documents per role.
+//
+// Resources which are only reachable from primary data via relationships the authorizer denies are
+// left out of the included resources, and an IncludeResolver is not called for such relationships.
+// Resource linkage of the relationships is unaffected.
+func MarshalIncludeAuthorizer(a IncludeAuthorizer) MarshalOption {
+	return func(m *Marshaler) {
+		m.includeAuthorizer = a
+	}
+}
+
+// MarshalContext sets the context passed to callbacks invoked while marshaling, such as an
+// IncludeResolver.
+func MarshalContext(ctx context.Context) MarshalOption {
+	return func(m *Marshaler) {
+		m.ctx = ctx
+	}
+}
+
+// context returns the context given by MarshalContext, or context.Background.
+func (m *Marshaler) context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
 // MarshalFields supports sparse fieldsets as defined by https://jsonapi.org/format/1.0/#fetching-sparse-fieldsets.
 // The input is a url.Values and if given only the fields included in `fields[type]=a,b` are included in the response.
 func MarshalFields(query url.Values) MarshalOption {
@@ -80,12 +171,24 @@ func MarshalLinks(l *Link) MarshalOption {
 }
 
 // MarshalClientMode enables client mode which skips validation only relevant for servers writing JSON:API responses.
+// In client mode, read-only attributes are omitted and write-only attributes are included.
 func MarshalClientMode() MarshalOption {
 	return func(m *Marshaler) {
 		m.clientMode = true
 	}
 }
 
+// MarshalAllowPartialLinkage disables the verification of full linkage of compound documents, as
+// defined by https://jsonapi.org/format/#document-compound-documents, so included resources without
+// a chain of relationships from primary data are marshaled as is. If handler is not nil, it is
+// called with the error describing the unlinked resources, e.g. to log a warning.
+func MarshalAllowPartialLinkage(handler func(err *PartialLinkageError)) MarshalOption {
+	return func(m *Marshaler) {
+		m.partialLinkage = true
+		m.partialLinkageHandler = handler
+	}
+}
+
 // MarshalStrictNameValidation enables member name validation that is more strict than default.
 //
 // In addition to the basic naming rules from https://jsonapi.org/format/#document-member-names,
@@ -107,51 +210,137 @@ func MarshalDisableNameValidation() MarshalOption {
 	}
 }
 
+// MarshalRelaxNameValidation exempts the given classes of member names from strict member name
+// validation, while keeping it for every other member of the document. This is useful for
+// compatibility with APIs using e.g. PascalCase type names.
+//
+// Relaxed member names must still conform to https://jsonapi.org/format/#document-member-names.
+func MarshalRelaxNameValidation(classes ...MemberClass) MarshalOption {
+	return func(m *Marshaler) {
+		m.relaxedMemberClasses = newMemberClasses(classes)
+	}
+}
+
+// MarshalFlushThreshold flushes the writer after every n primary resource objects when writing a
+// document incrementally (e.g. with Write), if the writer implements http.Flusher. Primary resource
+// objects are made and their includes resolved n at a time as well. This lets slow clients start
+// receiving very large collections early.
+func MarshalFlushThreshold(n int) MarshalOption {
+	return func(m *Marshaler) {
+		m.flushThreshold = n
+	}
+}
+
+// MarshalRejectNil makes Marshal return ErrNilInput for nil values, nil pointers and nil slices,
+// which are otherwise marshaled as documents with null or empty primary data. Empty non-nil slices
+// are still marshaled as documents with empty primary data.
+func MarshalRejectNil() MarshalOption {
+	return func(m *Marshaler) {
+		m.rejectNil = true
+	}
+}
+
+// makeMarshaler creates a new Marshaler configured with the given options.
+func makeMarshaler(opts ...MarshalOption) *Marshaler {
+	m := new(Marshaler)
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.stringTableMinCount > 0 {
+		// the members of the string table extension must pass member name validation
+		m.extensions = m.extensions.with(StringTableNamespace)
+	}
+	return m
+}
+
 // relationshipMarshaler creates a new marshaler from a parent one for the sake of marshaling
 // relationship documents, by copying over relevant fields.
 func (m *Marshaler) relationshipMarshaler(link *Link) *Marshaler {
 	rm := new(Marshaler)
 
 	rm.memberNameValidationMode = m.memberNameValidationMode
+	rm.relaxedMemberClasses = m.relaxedMemberClasses
+	rm.extensions = m.extensions
 	rm.link = link
 	return rm
 }
 
 // Marshal returns the json:api encoding of v. If v is type *Error or []*Error only the errors will be marshaled.
-func Marshal(v any, opts ...MarshalOption) (b []byte, err error) {
-	defer func() {
-		// because we make use of reflect we must recover any panics
-		if rvr := recover(); rvr != nil {
-			err = recoverError(rvr)
-			return
-		}
-	}()
+//
+// A nil v or nil pointer is marshaled as a document with null primary data, and a nil slice as a
+// document with empty primary data, unless MarshalRejectNil is used. Nil resources within slices,
+// relationships or included resources, and nil error objects, are rejected with ErrNilResource.
+func Marshal(v any, opts ...MarshalOption) ([]byte, error) {
+	return MarshalAppend(nil, v, opts...)
+}
 
-	m := new(Marshaler)
-	for _, opt := range opts {
-		opt(m)
-	}
+// MarshalAppend appends the json:api encoding of v to dst and returns the extended buffer, as
+// encoded by Marshal. On failure, dst is returned unchanged along with the error.
+//
+// Documents are encoded into buffers reused across calls, so appending to a buffer reused by the
+// caller as well avoids most allocations besides those of the document itself.
+func MarshalAppend(dst []byte, v any, opts ...MarshalOption) (b []byte, err error) {
+	m := makeMarshaler(opts...)
+
+	err = m.observe(func(op *Operation) error {
+		// marshal first constructs a jsonapi.Document
+		// the given "v" is the resource document (either one or many) of any type
+		d, err := makeDocument(v, m, false)
+		if err != nil {
+			return err
+		}
+		op.add(d)
 
-	// marshal first constructs a jsonapi.Document
-	// the given "v" is the resource document (either one or many) of any type
-	var d *document
-	d, err = makeDocument(v, m, false)
+		if b, err = m.appendDocument(dst, d); err != nil {
+			return err
+		}
+		op.Size = len(b) - len(dst)
+		return nil
+	})
 	if err != nil {
-		return
+		return dst, err
 	}
+	return b, nil
+}
 
-	// now that we have a document, just marshal it as normal json
-	b, err = json.Marshal(d)
+// appendDocument appends the json encoding of the document d to dst, validating its member names and
+// formatting it as given by MarshalEscapeHTML and MarshalIndent.
+func (m *Marshaler) appendDocument(dst []byte, d *document) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	var err error
+	if m.dataMember != "" {
+		err = (&documentWriter{w: buf, m: m}).write(d)
+	} else if err = encodeJSON(buf, d); err == nil {
+		// now that we have a document, just marshal it as normal json
+		err = validateJSONMemberNames(buf.Bytes(), m.memberNameValidationMode, m.relaxedMemberClasses, m.extensions)
+	}
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	err = validateJSONMemberNames(b, m.memberNameValidationMode)
+	out := buf.Bytes()
+	if m.dataMember == "" && m.formatted() {
+		// documents with a data member are formatted by documentWriter
+		if out, err = m.format(out); err != nil {
+			return nil, err
+		}
+	}
 
-	return
+	return append(dst, out...), nil
 }
 
 func makeDocument(v any, m *Marshaler, isRelationship bool) (*document, error) {
+	return buildDocument(v, m, isRelationship, nil)
+}
+
+// buildDocument makes the document of v. If emit is non-nil and the document can be streamed (see
+// streamable), many primary resource objects are made in chunks of MarshalFlushThreshold resource
+// objects, and emit is called with each chunk as soon as its resource objects are final, i.e. once
+// the includes of the chunk have been resolved, so that they can be written before the rest of the
+// document is made.
+func buildDocument(v any, m *Marshaler, isRelationship bool, emit func(ros []*resourceObject) error) (*document, error) {
 	// first attempt to make errors
 	// if we got errors the document will be non-nil and since data+errors cannot
 	// both exist in the same document, just return before any other work
@@ -166,6 +355,16 @@ func makeDocument(v any, m *Marshaler, isRelationship bool) (*document, error) {
 	// at this point we have no errors, so lets make the document
 	d = newDocument()
 
+	if m.rejectNil && !isRelationship && isNilInput(v) {
+		return nil, ErrNilInput
+	}
+
+	// the values primary data is created from, used to resolve includes
+	var primary []*resolvedNode
+
+	// the values many primary data is created from, if any
+	var values reflect.Value
+
 	// the given "v" is the resource object (or a slice of them)
 	//
 	// besides nil, only a struct or slice of struct are valid here because
@@ -183,17 +382,7 @@ func makeDocument(v any, m *Marshaler, isRelationship bool) (*document, error) {
 		if reflect.ValueOf(v).IsZero() {
 			break
 		}
-		rv := derefValue(reflect.ValueOf(v))
-		for i := 0; i < rv.Len(); i++ {
-			iv := rv.Index(i).Interface()
-			ro, err := makeResourceObject(iv, reflect.TypeOf(iv), m, isRelationship)
-			if err != nil {
-				return nil, err
-			}
-			if ro != nil {
-				d.DataMany = append(d.DataMany, ro)
-			}
-		}
+		values = derefValue(reflect.ValueOf(v))
 	case derefType(vt).Kind() == reflect.Struct:
 		if reflect.ValueOf(v).IsZero() {
 			break
@@ -201,36 +390,195 @@ func makeDocument(v any, m *Marshaler, isRelationship bool) (*document, error) {
 		// if we get a struct we just make a single resource object
 		ro, err := makeResourceObject(v, vt, m, isRelationship)
 		if err != nil {
-			return nil, err
+			return nil, prefixPointer(err, "/data")
 		}
 		d.DataOne = ro
+		primary = append(primary, &resolvedNode{v: v, ro: ro})
 	default:
 		return nil, &TypeError{Actual: fmt.Sprintf("%T", v), Expected: []string{"struct", "slice"}}
 	}
 
-	// if we got any included data, build the resource object/s and include them
-	for _, v := range m.included {
-		ro, err := makeResourceObject(v, reflect.TypeOf(v), m, isRelationship)
-		if err != nil {
+	n := 0
+	if values.IsValid() {
+		n = values.Len()
+	}
+	chunk := n
+	if n == 0 || isRelationship || !m.streamable() {
+		// only many primary data is streamed
+		emit = nil
+	}
+	if emit != nil {
+		if m.flushThreshold > 0 {
+			chunk = m.flushThreshold
+		}
+		// the members following primary data are made up front, so that their failures happen
+		// before anything is written
+		if err := addOptionalDocumentFields(d, m); err != nil {
 			return nil, err
 		}
-		d.Included = append(d.Included, ro)
+	}
+
+	var ir *includeResolution
+	if !isRelationship {
+		ir = newIncludeResolution(d, m)
+	}
+
+	nodes := primary
+	for i, first := 0, true; ; nodes, first = nil, false {
+		end := i + chunk
+		if end > n {
+			end = n
+		}
+		// we make a resource object for each item of the chunk
+		for ; i < end; i++ {
+			iv := values.Index(i).Interface()
+			ro, err := makeResourceObject(iv, reflect.TypeOf(iv), m, isRelationship)
+			if err != nil {
+				return nil, prefixPointer(err, fmt.Sprintf("/data/%d", i))
+			}
+			if ro != nil {
+				d.DataMany = append(d.DataMany, ro)
+				node := &resolvedNode{v: iv, ro: ro}
+				primary = append(primary, node)
+				nodes = append(nodes, node)
+			}
+		}
+
+		if first {
+			// if we got any included data, build the resource object/s and include them, once
+			// per type and id pair and only if they aren't primary data, so cyclic object graphs
+			// are included only once
+			included, err := makeIncluded(d, primary, m, isRelationship)
+			if err != nil {
+				return nil, err
+			}
+			if ir != nil {
+				ir.add(included)
+			}
+		}
+
+		// resolve included data on demand for the requested include paths
+		if ir != nil {
+			ir.add(nodes)
+			if err := ir.resolveIncludes(nodes); err != nil {
+				return nil, err
+			}
+		}
+
+		if emit != nil && len(nodes) > 0 {
+			ros := make([]*resourceObject, len(nodes))
+			for j, node := range nodes {
+				ros[j] = node.ro
+			}
+			// links-only relationships only link the resources included so far
+			linkResourceObjects(ros, d.Included)
+			for _, ro := range ros {
+				filterFieldset(ro, m)
+				redactAttributes(m.context(), ro, m)
+			}
+			if err := emit(ros); err != nil {
+				return nil, err
+			}
+		}
+
+		if i >= n {
+			break
+		}
+	}
+
+	var truncation *IncludeTruncation
+	if !isRelationship {
+		if emit != nil {
+			// resources included before being made primary data are primary data nonetheless
+			d.Included = withoutPrimaryData(d.Included, primary)
+		}
+		authorizeIncludes(d, m)
+		truncation = truncateIncluded(d, m.includeLimit)
+		linkIncluded(d)
 	}
 
 	// if we got any included data, verify full-linkage of this compound document.
-	if err := d.verifyFullLinkage(false); err != nil {
+	if err := allowPartialLinkage(d.verifyFullLinkage(false), m.partialLinkage, m.partialLinkageHandler); err != nil {
 		return nil, err
 	}
 
+	if emit != nil {
+		// primary data was filtered and redacted as it was emitted
+		for _, ro := range d.Included {
+			filterFieldset(ro, m)
+			redactAttributes(m.context(), ro, m)
+		}
+		return d, nil
+	}
+
 	filterDocumentFieldsets(d, m)
+	redactDocumentAttributes(d, m)
 
 	if err := addOptionalDocumentFields(d, m); err != nil {
 		return nil, err
 	}
 
+	if truncation != nil {
+		meta, err := mergeMeta(d.Meta, map[string]any{IncludeTruncationMeta: truncation})
+		if err != nil {
+			return nil, err
+		}
+		d.Meta = meta
+	}
+
+	if m.stringTableMinCount > 0 && !isRelationship {
+		applyStringTable(d, m.stringTableMinCount)
+	}
+
 	return d, nil
 }
 
+// streamable returns true if documents can be made one chunk of primary data at a time, i.e. if no
+// option changing primary resource objects after the whole document is made is used.
+func (m *Marshaler) streamable() bool {
+	return m.stringTableMinCount == 0 && m.includeLimit <= 0
+}
+
+// makeIncluded makes the resource objects of the included values given by MarshalInclude and adds
+// them to the included resources of d, unless they are given primary data, returning their nodes.
+func makeIncluded(d *document, primary []*resolvedNode, m *Marshaler, isRelationship bool) ([]*resolvedNode, error) {
+	visited := make(map[string]bool, len(primary)+len(m.included))
+	for _, node := range primary {
+		visited[node.ro.identifier()] = true
+	}
+	included := make([]*resolvedNode, 0, len(m.included))
+	for i, v := range m.included {
+		ro, err := makeResourceObject(v, reflect.TypeOf(v), m, isRelationship)
+		if err != nil {
+			return nil, prefixPointer(err, fmt.Sprintf("/included/%d", i))
+		}
+		if ro != nil && ro.ID != "" {
+			if visited[ro.identifier()] {
+				continue
+			}
+			visited[ro.identifier()] = true
+		}
+		d.Included = append(d.Included, ro)
+		included = append(included, &resolvedNode{v: v, ro: ro})
+	}
+	return included, nil
+}
+
+// withoutPrimaryData returns the given included resource objects but those of primary data.
+func withoutPrimaryData(included []*resourceObject, primary []*resolvedNode) []*resourceObject {
+	ids := make(map[string]bool, len(primary))
+	for _, node := range primary {
+		ids[node.ro.identifier()] = true
+	}
+	kept := included[:0]
+	for _, ro := range included {
+		if ro == nil || ro.ID == "" || !ids[ro.identifier()] {
+			kept = append(kept, ro)
+		}
+	}
+	return kept
+}
+
 // filterDocumentFieldsets supports Sparse Fieldsets by filtering out any of the attributes or
 // relationships in the document's resource objects that were not chosen in MarshalFields.
 func filterDocumentFieldsets(d *document, m *Marshaler) {
@@ -238,42 +586,46 @@ func filterDocumentFieldsets(d *document, m *Marshaler) {
 		return
 	}
 
-	// retain only the attributes or relationships specified in MarshalFields for some type
-	filterResourceObject := func(ro *resourceObject) {
-		fields, ok := m.fields[ro.Type]
-		if !ok {
-			// this type has no fieldset filters
-			return
-		}
-
-		filteredAttributes := make(map[string]any)
-		filteredRelationships := make(map[string]*document)
-
-		for _, field := range fields {
-			if v, ok := ro.Attributes[field]; ok {
-				filteredAttributes[field] = v
-			} else if v, ok := ro.Relationships[field]; ok {
-				filteredRelationships[field] = v
-			}
-		}
-		ro.Attributes = filteredAttributes
-		ro.Relationships = filteredRelationships
-	}
-
 	// filter fields in primary data and then included data
 	if d.hasMany {
 		for _, ro := range d.DataMany {
-			filterResourceObject(ro)
+			filterFieldset(ro, m)
 		}
 	} else {
-		filterResourceObject(d.DataOne)
+		filterFieldset(d.DataOne, m)
 	}
 
 	for _, ro := range d.Included {
-		filterResourceObject(ro)
+		filterFieldset(ro, m)
 	}
 }
 
+// filterFieldset retains only the attributes or relationships of ro specified in MarshalFields for
+// its type, if any.
+func filterFieldset(ro *resourceObject, m *Marshaler) {
+	if ro == nil {
+		return
+	}
+	fields, ok := m.fields[ro.Type]
+	if !ok {
+		// this type has no fieldset filters
+		return
+	}
+
+	filteredAttributes := make(map[string]any)
+	filteredRelationships := make(map[string]*document)
+
+	for _, field := range fields {
+		if v, ok := ro.Attributes[field]; ok {
+			filteredAttributes[field] = v
+		} else if v, ok := ro.Relationships[field]; ok {
+			filteredRelationships[field] = v
+		}
+	}
+	ro.Attributes = filteredAttributes
+	ro.Relationships = filteredRelationships
+}
+
 func makeDocumentErrors(v any, m *Marshaler) (*document, error) {
 	var errorObjects []*Error
 
@@ -299,8 +651,11 @@ func makeDocumentErrors(v any, m *Marshaler) (*document, error) {
 
 	// check for valid error links and meta fields if present
 	for _, eo := range errorObjects {
+		if eo == nil {
+			return nil, ErrNilResource
+		}
 		if eo.Links != nil {
-			if _, err := checkLinkValue(eo.Links.About); err != nil {
+			if err := eo.Links.check(); err != nil {
 				return nil, err
 			}
 		}
@@ -319,14 +674,74 @@ func makeDocumentErrors(v any, m *Marshaler) (*document, error) {
 	return d, nil
 }
 
+// structFieldName returns the qualified name of the field f of the struct type t, e.g. Article.Title.
+func structFieldName(t reflect.Type, f reflect.StructField) string {
+	return derefType(t).Name() + "." + f.Name
+}
+
+// isNilInput returns true if v is nil, a nil pointer or a nil slice, or a pointer to one of them.
+func isNilInput(v any) bool {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Pointer, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
+
 func makeResourceObject(v any, vt reflect.Type, m *Marshaler, isRelationship bool) (*resourceObject, error) {
+	ro, err := makeBareResourceObject(v, vt, m, isRelationship)
+	if err != nil || ro == nil || isRelationship || len(m.profiles) == 0 {
+		return ro, err
+	}
+	return ro, m.marshalProfiles(v, ro)
+}
+
+// makeBareResourceObject makes the resource object of v, without the members added by profiles.
+func makeBareResourceObject(v any, vt reflect.Type, m *Marshaler, isRelationship bool) (*resourceObject, error) {
 	// the given "v" here is a single resource object
 
+	// nil resources can't be told apart from one another, e.g. in a collection
+	if rv := reflect.ValueOf(v); !rv.IsValid() || rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, ErrNilResource
+	}
+
+	// resources only known at runtime aren't described by struct tags
+	switch r := v.(type) {
+	case *Resource:
+		return r.resourceObject(m, isRelationship)
+	case Resource:
+		return r.resourceObject(m, isRelationship)
+	}
+
 	// first, it must be a struct since we'll be parsing the jsonapi struct tags
 	if derefType(vt).Kind() != reflect.Struct {
 		return nil, &TypeError{Actual: vt.String(), Expected: []string{"struct"}}
 	}
 
+	if !isRelationship {
+		if err := m.beforeMarshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	// resources may take full control of their resource object instead
+	if rm, ok := v.(ResourceMarshaler); ok {
+		r, err := rm.MarshalResource()
+		if err != nil {
+			return nil, err
+		}
+		if r == nil {
+			return nil, ErrNilResource
+		}
+		return r.resourceObject(m, isRelationship)
+	}
+
 	ro := &resourceObject{
 		Attributes:    make(map[string]any, 0),
 		Relationships: make(map[string]*document, 0),
@@ -336,6 +751,9 @@ func makeResourceObject(v any, vt reflect.Type, m *Marshaler, isRelationship boo
 	fields := getFlattenedFields(v)
 
 	var foundPrimary bool
+	var extrasValue reflect.Value
+	// empty relationships which are omitted unless they are links-only
+	var omitted map[string]bool
 	for _, field := range fields {
 		// for each field in the struct we'll parse the jsonapi struct tag
 		// this will determine where it goes in the resource object (e.g. id,type,attributes,...)
@@ -355,9 +773,17 @@ func makeResourceObject(v any, vt reflect.Type, m *Marshaler, isRelationship boo
 		switch tag.directive {
 		case primary:
 			ro.Type = tag.resourceType
-			if !isValidMemberName(ro.Type, m.memberNameValidationMode) {
+			if vo, ok := v.(resourceTypeOverrider); ok && vo.resourceTypeOverride() != "" {
+				ro.Type = vo.resourceTypeOverride()
+			}
+			if vm, ok := v.(MarshalType); ok {
+				if ro.Type = vm.MarshalResourceType(); ro.Type == "" {
+					return nil, ErrMissingTypeField
+				}
+			}
+			if !isValidMemberName(ro.Type, m.relaxedMemberClasses.modeFor(TypeMembers, m.memberNameValidationMode)) {
 				// type names count as member names
-				return nil, &MemberNameValidationError{ro.Type}
+				return nil, &MemberNameValidationError{MemberName: ro.Type, Field: structFieldName(vt, ft), Pointer: "/type"}
 			}
 
 			// to marshal the id we follow these rules
@@ -403,14 +829,25 @@ func makeResourceObject(v any, vt reflect.Type, m *Marshaler, isRelationship boo
 				// relationships must only be resource identifier objects so skip attributes
 				continue
 			}
-			fieldName, ok, omit := parseJSONTag(ft)
+			if tag.writeOnly && !m.clientMode || tag.readOnly && m.clientMode {
+				// write-only attributes are only sent by clients, and read-only ones by servers
+				continue
+			}
+			fieldName, ok, omit := memberName(ft, m.naming)
 			if !ok {
 				continue
 			}
-			if f.IsZero() && omit {
+			if !isValidMemberName(fieldName, m.relaxedMemberClasses.modeFor(AttributeMembers, m.memberNameValidationMode)) {
+				return nil, &MemberNameValidationError{MemberName: fieldName, Field: structFieldName(vt, ft), Pointer: "/attributes/" + escapePointerToken(fieldName)}
+			}
+			if m.omitAttribute(f, tag, omit) {
 				continue
 			}
-			ro.Attributes[fieldName] = f.Interface()
+			value, err := m.formatAttribute(f, ft, tag)
+			if err != nil {
+				return nil, &FieldError{Code: CodeInvalidAttribute, Member: fieldName, Pointer: "/attributes/" + escapePointerToken(fieldName), Err: err}
+			}
+			ro.Attributes[fieldName] = value
 		case meta:
 			metaObject := f.Interface()
 			if err := checkMeta(metaObject); err != nil {
@@ -423,8 +860,11 @@ func makeResourceObject(v any, vt reflect.Type, m *Marshaler, isRelationship boo
 			}
 
 			if isRelationship {
-				// let meta become document-level for relationships (treated as nested documents)
-				m.meta = metaObject
+				// let meta become document-level for relationships (treated as nested documents),
+				// without discarding meta given via MarshalMeta
+				if metaObject != nil {
+					m.meta = metaObject
+				}
 			} else {
 				ro.Meta = metaObject
 			}
@@ -433,33 +873,102 @@ func makeResourceObject(v any, vt reflect.Type, m *Marshaler, isRelationship boo
 				// relationship nesting must occur in include data, not the relationship fields
 				continue
 			}
-			fieldName, ok, omit := parseJSONTag(ft)
+			fieldName, ok, omit := memberName(ft, m.naming)
 			if !ok {
 				continue
 			}
-			if f.IsZero() && omit {
+			if !isValidMemberName(fieldName, m.memberNameValidationMode) {
+				return nil, &MemberNameValidationError{MemberName: fieldName, Field: structFieldName(vt, ft), Pointer: "/relationships/" + escapePointerToken(fieldName)}
+			}
+			related, present := relatedField(f)
+			if !present {
 				continue
 			}
+			if f.IsZero() && omit {
+				if len(m.linksOnly) == 0 {
+					continue
+				}
+				// the resource type deciding whether the relationship is links-only may be unknown yet
+				if omitted == nil {
+					omitted = make(map[string]bool)
+				}
+				omitted[fieldName] = true
+			}
 
-			// if LinkableRelation is implemented include Document.Links for the related resource
-			var link *Link
-			if lv, ok := v.(LinkableRelation); ok {
-				link = lv.LinkRelation(fieldName)
-				if err := link.check(); err != nil {
+			// include Document.Links for the related resource if LinkableRelation is implemented or
+			// a RelationshipLinker is given
+			link, err := m.relationLink(v, fieldName)
+			if err != nil {
+				return nil, err
+			}
+
+			relatedType, idsOnly, err := parseRelTypeTag(ft)
+			if err != nil {
+				return nil, err
+			}
+			if idsOnly {
+				d, err := makeLinkageDocument(related, relatedType, m)
+				if err != nil {
+					var ne *MemberNameValidationError
+					if errors.As(err, &ne) {
+						ne.Field = structFieldName(vt, ft)
+					}
+					return nil, prefixPointer(err, "/relationships/"+escapePointerToken(fieldName))
+				}
+				d.Links = link
+				if err := d.addIdentifierMeta(v, fieldName); err != nil {
 					return nil, err
 				}
+				if err := d.addExtensions(v, fieldName, m); err != nil {
+					return nil, prefixPointer(err, "/relationships/"+escapePointerToken(fieldName))
+				}
+				ro.Relationships[fieldName] = d
+				continue
 			}
 
 			rm := m.relationshipMarshaler(link)
-			d, err := makeDocument(f.Interface(), rm, true)
+			d, err := makeDocument(related.Interface(), rm, true)
 			if err != nil {
+				return nil, prefixPointer(err, "/relationships/"+escapePointerToken(fieldName))
+			}
+			if err := d.addIdentifierMeta(v, fieldName); err != nil {
 				return nil, err
 			}
+			if err := d.addExtensions(v, fieldName, m); err != nil {
+				return nil, prefixPointer(err, "/relationships/"+escapePointerToken(fieldName))
+			}
 
 			ro.Relationships[fieldName] = d
+		case extras:
+			if !isRelationship {
+				extrasValue = f
+			}
+		case extension:
+			if isRelationship {
+				// resource identifier objects are made of their type and id only
+				continue
+			}
+			name, ok, omit := parseJSONTag(ft)
+			if !ok {
+				continue
+			}
+			if !m.extensions.isExtensionMemberName(name, m.memberNameValidationMode) {
+				return nil, &MemberNameValidationError{MemberName: name, Field: structFieldName(vt, ft), Pointer: "/" + escapePointerToken(name)}
+			}
+			if f.IsZero() && omit {
+				continue
+			}
+			if ro.extensions == nil {
+				ro.extensions = make(map[string]any)
+			}
+			ro.extensions[name] = f.Interface()
 		}
 	}
 
+	if extrasValue.IsValid() {
+		addExtras(ro, extrasValue, attributeNames(vt, m.naming))
+	}
+
 	// primary is the only required jsonapi struct tag as it defines the id/type
 	if !foundPrimary {
 		return nil, ErrMissingPrimaryField
@@ -470,24 +979,65 @@ func makeResourceObject(v any, vt reflect.Type, m *Marshaler, isRelationship boo
 		return nil, ErrEmptyPrimaryField
 	}
 
+	m.omitLinkage(ro, omitted)
+
 	// if Linkable is implemented include ResourceObject.Links
 	if lv, ok := v.(Linkable); ok {
-		link := lv.Link()
-		if err := link.check(); err != nil {
-			return nil, err
+		// resources without links, such as those embedding ResourceBase, return nil
+		if link := lv.Link(); link != nil {
+			if err := link.check(); err != nil {
+				return nil, err
+			}
+			ro.Links = link
 		}
-		ro.Links = link
 	}
 
 	return ro, nil
 }
 
+// makeLinkageDocument makes the relationship document of a relationship field holding the ids of
+// related resources of the given type only, i.e. a string for to-one relationships and a []string
+// for to-many relationships.
+func makeLinkageDocument(fv reflect.Value, relatedType string, m *Marshaler) (*document, error) {
+	if !isValidMemberName(relatedType, m.relaxedMemberClasses.modeFor(TypeMembers, m.memberNameValidationMode)) {
+		// type names count as member names
+		return nil, &MemberNameValidationError{MemberName: relatedType}
+	}
+
+	d := newDocument()
+	if fv.Kind() != reflect.Slice {
+		if id := fv.String(); id != "" {
+			d.DataOne = &resourceObject{Type: relatedType, ID: id}
+		}
+		return d, nil
+	}
+
+	d.hasMany = true
+	for i := 0; i < fv.Len(); i++ {
+		id := fv.Index(i).String()
+		if id == "" && !m.clientMode {
+			return nil, ErrEmptyPrimaryField
+		}
+		d.DataMany = append(d.DataMany, &resourceObject{Type: relatedType, ID: id})
+	}
+	return d, nil
+}
+
 func getFlattenedFields(iface interface{}) []struct {
 	v reflect.Value
 	f reflect.StructField
 } {
-	rv := derefValue(reflect.ValueOf(iface))
-	rt := reflect.TypeOf(rv.Interface())
+	return flattenFields(derefValue(reflect.ValueOf(iface)))
+}
+
+// flattenFields returns the fields of the struct value rv, with the fields of embedded structs and
+// of struct fields with the flatten option in their place. Nil struct pointers are flattened as
+// zero values.
+func flattenFields(rv reflect.Value) []struct {
+	v reflect.Value
+	f reflect.StructField
+} {
+	rt := rv.Type()
 
 	fields := make([]struct {
 		v reflect.Value
@@ -498,8 +1048,24 @@ func getFlattenedFields(iface interface{}) []struct {
 		v := rv.Field(i)
 		f := rt.Field(i)
 
-		if f.Anonymous && (v.Kind() == reflect.Struct || v.Kind() == reflect.Pointer) {
-			fields = append(fields, getFlattenedFields(v.Interface())...)
+		if isFlattened(f) && isStructType(f.Type) {
+			for v.Kind() == reflect.Pointer {
+				if v.IsNil() {
+					v = reflect.Zero(v.Type().Elem())
+				} else {
+					v = v.Elem()
+				}
+			}
+			flattened := flattenFields(v)
+			if f.Anonymous && f.Type == resourceBaseType {
+				// the primary field of ResourceBase is tagged by the embedded field
+				for i := range flattened {
+					if flattened[i].f.Name == "ID" {
+						flattened[i].f.Tag = resourceBasePrimaryTag(f)
+					}
+				}
+			}
+			fields = append(fields, flattened...)
 		} else {
 			fields = append(fields, struct {
 				v reflect.Value
@@ -516,19 +1082,46 @@ func addOptionalDocumentFields(d *document, m *Marshaler) error {
 	if err := checkMeta(m.meta); err != nil {
 		return err
 	}
-	d.Meta = m.meta
+	meta, err := m.documentMeta()
+	if err != nil {
+		return err
+	}
+	if isNilMeta(meta) {
+		// omit nil maps and pointers rather than encoding them as null
+		meta = nil
+	}
+	if m.metaSchema != nil && meta != nil {
+		b, err := marshalJSON(meta)
+		if err != nil {
+			return err
+		}
+		if err := validateMeta(m.metaSchema, b); err != nil {
+			return err
+		}
+	}
+	d.Meta = meta
 
 	// optionally include the Document.jsonapi (may be nil, which will be omitted)
-	if m.includeJSONAPI {
-		d.JSONAPI = &jsonAPI{Version: "1.0"}
-		if err := checkMeta(m.jsonAPImeta); err != nil {
+	if o := m.documentJSONAPIObject(); o != nil {
+		if err := o.check(); err != nil {
 			return err
 		}
-		d.JSONAPI.Meta = m.jsonAPImeta
+		d.JSONAPI = o
 	}
 
 	// optionally include Document.links (may be nil, which will be omitted)
-	d.Links = m.link
+	links, err := m.documentLinks()
+	if err != nil {
+		return err
+	}
+	d.Links = links
+
+	// optionally include extension members (may be nil, which will be omitted)
+	extensions, err := m.makeExtensionMembers(m.extensionMembers)
+	if err != nil {
+		return err
+	}
+	d.extensions = extensions
 
 	return nil
 }
@@ -1,6 +1,7 @@
 package jsonapi
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"testing"
@@ -215,6 +216,26 @@ func TestMarshal(t *testing.T) {
 			given:       &articleEmbeddedPointer,
 			expect:      articleEmbeddedBody,
 			expectError: nil,
+		}, {
+			description: "ArticleEmbeddedPointer nil",
+			given:       &ArticleEmbeddedPointer{ID: "1", Title: "A"},
+			expect:      articleEmbeddedNilBody,
+			expectError: nil,
+		}, {
+			description: "ArticleFlattened",
+			given:       &articleFlattened,
+			expect:      articleEmbeddedBody,
+			expectError: nil,
+		}, {
+			description: "ArticleFlattenedPointer",
+			given:       &articleFlattenedPointer,
+			expect:      articleEmbeddedBody,
+			expectError: nil,
+		}, {
+			description: "ArticleFlattenedPointer nil",
+			given:       &ArticleFlattenedPointer{ID: "1", Title: "A"},
+			expect:      articleEmbeddedNilBody,
+			expectError: nil,
 		}, {
 			description: "Error simple",
 			given:       errorsSimpleStruct,
@@ -265,6 +286,16 @@ func TestMarshal(t *testing.T) {
 			given:       errorsWithInvalidLink,
 			expect:      "",
 			expectError: &TypeError{Actual: "int", Expected: []string{"*LinkObject", "string"}},
+		}, {
+			description: "Error with about and type links",
+			given:       errorsWithTypeLink,
+			expect:      errorsWithTypeLinkBody,
+			expectError: nil,
+		}, {
+			description: "Error with invalid type link",
+			given:       errorsWithInvalidTypeLink,
+			expect:      "",
+			expectError: &TypeError{Actual: "int", Expected: []string{"*LinkObject", "string"}},
 		}, {
 			description: "Error with invalid Links.About.Meta",
 			given:       errorsWithInvalidLinkMeta,
@@ -443,6 +474,68 @@ func TestMarshalJSONAPI(t *testing.T) {
 	}
 }
 
+func TestMarshalJSONAPIObject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		opts        []MarshalOption
+		expect      string
+		expectError error
+	}{
+		{
+			description: "version 1.1 with ext and profile",
+			opts: []MarshalOption{MarshalJSONAPIObject(&JSONAPIObject{
+				Version: "1.1",
+				Ext:     []string{"https://jsonapi.org/ext/atomic"},
+				Profile: []string{"https://example.com/profiles/timestamps"},
+				Meta:    map[string]any{"foo": "bar"},
+			})},
+			expect: `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"jsonapi":{"version":"1.1","ext":["https://jsonapi.org/ext/atomic"],"profile":["https://example.com/profiles/timestamps"],"meta":{"foo":"bar"}}}`,
+		}, {
+			description: "omitted version",
+			opts:        []MarshalOption{MarshalJSONAPIObject(&JSONAPIObject{Meta: map[string]any{"foo": "bar"}})},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"jsonapi":{"meta":{"foo":"bar"}}}`,
+		}, {
+			description: "nil overrides MarshalJSONAPI",
+			opts:        []MarshalOption{MarshalJSONAPI(nil), MarshalJSONAPIObject(nil)},
+			expect:      articleABody,
+		}, {
+			description: "unsupported version",
+			opts:        []MarshalOption{MarshalJSONAPIObject(&JSONAPIObject{Version: "2.0"})},
+			expectError: fmt.Errorf("%w: unsupported version %q", ErrInvalidJSONAPIObject, "2.0"),
+		}, {
+			description: "ext with version 1.0",
+			opts:        []MarshalOption{MarshalJSONAPIObject(&JSONAPIObject{Version: "1.0", Ext: []string{"https://jsonapi.org/ext/atomic"}})},
+			expectError: fmt.Errorf("%w: ext and profile require version 1.1", ErrInvalidJSONAPIObject),
+		}, {
+			description: "relative profile URI",
+			opts:        []MarshalOption{MarshalJSONAPIObject(&JSONAPIObject{Version: "1.1", Profile: []string{"timestamps"}})},
+			expectError: fmt.Errorf("%w: %q is not an absolute URI", ErrInvalidJSONAPIObject, "timestamps"),
+		}, {
+			description: "invalid meta",
+			opts:        []MarshalOption{MarshalJSONAPIObject(&JSONAPIObject{Meta: "foo"})},
+			expectError: &TypeError{Actual: "string", Expected: []string{"struct", "map"}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(&articleA, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
 func TestMarshalLinks(t *testing.T) {
 	t.Parallel()
 
@@ -464,6 +557,35 @@ func TestMarshalLinks(t *testing.T) {
 			given:       &articleA,
 			givenLink:   nil,
 			expect:      articleABody,
+		}, {
+			description: "with 1.1 link object",
+			given:       &articleA,
+			givenLink: &Link{Self: &LinkObject{
+				Href:        "https://example.com/articles/1",
+				Rel:         "self",
+				DescribedBy: "https://example.com/schemas/articles",
+				Title:       "Article",
+				Type:        "application/vnd.api+json",
+				HrefLang:    HrefLang{"en"},
+			}},
+			expect: `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"links":{"self":{"href":"https://example.com/articles/1","rel":"self","describedby":"https://example.com/schemas/articles","title":"Article","type":"application/vnd.api+json","hreflang":"en"}}}`,
+		}, {
+			description: "with pagination link objects",
+			given:       []*Article{&articleA},
+			givenLink: &Link{
+				Self:  "https://example.com/articles?page=2",
+				First: "",
+				Next:  &LinkObject{Href: "https://example.com/articles?page=3", HrefLang: HrefLang{"en", "fr"}, DescribedBy: &LinkObject{Href: "https://example.com/schemas/articles"}},
+			},
+			expect: `{"data":[{"id":"1","type":"articles","attributes":{"title":"A"}}],"links":{"self":"https://example.com/articles?page=2","next":{"href":"https://example.com/articles?page=3","describedby":{"href":"https://example.com/schemas/articles"},"hreflang":["en","fr"]}}}`,
+		}, {
+			description: "with extra links",
+			given:       &articleA,
+			givenLink: (&Link{Self: "https://example.com/articles/1"}).
+				Set("describedby", "https://example.com/schemas/articles").
+				Set("canonical", &LinkObject{Href: "https://example.com/a/1"}).
+				Set("empty", ""),
+			expect: `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"links":{"self":"https://example.com/articles/1","canonical":{"href":"https://example.com/a/1"},"describedby":"https://example.com/schemas/articles"}}`,
 		},
 	}
 
@@ -621,7 +743,7 @@ func TestMarshalRelationships(t *testing.T) {
 			given:          &articleA,
 			marshalOptions: []MarshalOption{MarshalInclude(&commentAWithAuthor, &authorA)},
 			expect:         "",
-			expectError:    &PartialLinkageError{[]string{"{Type: comments, ID: 1}", "{Type: author, ID: 1}"}},
+			expectError:    &PartialLinkageError{Resources: []ResourceIdentifier{{Type: "author", ID: "1"}, {Type: "comments", ID: "1"}}},
 		},
 	}
 
@@ -672,6 +794,21 @@ func TestMarshalClientMode(t *testing.T) {
 	}
 }
 
+func TestMarshalAllowPartialLinkage(t *testing.T) {
+	t.Parallel()
+
+	var unlinked *PartialLinkageError
+	actual, err := Marshal(&articleA, MarshalInclude(&commentAWithAuthor, &authorA), MarshalAllowPartialLinkage(func(err *PartialLinkageError) {
+		unlinked = err
+	}))
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}},"included":[{"type":"comments","id":"1","attributes":{"body":"A"},"relationships":{"author":{"data":{"type":"author","id":"1"},"links":{"self":"http://example.com/comments/1/relationships/author","related":"http://example.com/comments/1/author"}}}},{"type":"author","id":"1","attributes":{"name":"A"}}]}`, string(actual))
+	is.Equal(t, &PartialLinkageError{Resources: []ResourceIdentifier{{Type: "author", ID: "1"}, {Type: "comments", ID: "1"}}}, unlinked)
+
+	_, err = Marshal(&articleA, MarshalInclude(&authorA), MarshalAllowPartialLinkage(nil))
+	is.MustNoError(t, err)
+}
+
 // TestMarshalMemberNameValidation collects tests which verify that invalid member names are caught
 // during marshaling, no matter where they're placed. This test does not exhaustively test every
 // possible invalid name.
@@ -691,37 +828,37 @@ func TestMarshalMemberNameValidation(t *testing.T) {
 		}, {
 			description: "Author with invalid type name",
 			given:       &authorWithInvalidTypeName,
-			expectError: &MemberNameValidationError{"aut%hor"},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor", Field: "AuthorWithInvalidTypeName.ID"},
 		}, {
 			description: "Author with invalid attribute name",
 			given:       &authorWithInvalidAttributeName,
-			expectError: &MemberNameValidationError{"na%me"},
+			expectError: &MemberNameValidationError{MemberName: "na%me", Field: "AuthorWithInvalidAttributeName.Name"},
 		}, {
 			description: "Article with invalid resource meta member name",
 			given:       &articleWithInvalidResourceMetaMemberName,
-			expectError: &MemberNameValidationError{"foo%"},
+			expectError: &MemberNameValidationError{MemberName: "foo%"},
 		}, {
 			description:       "Article with invalid top-level meta member name",
 			given:             &articleA,
-			expectError:       &MemberNameValidationError{"foo%"},
+			expectError:       &MemberNameValidationError{MemberName: "foo%"},
 			additionalOptions: []MarshalOption{MarshalMeta(map[string]any{"foo%": 2})},
 		}, {
 			description:       "Article with invalid jsonapi meta member name",
 			given:             &articleA,
-			expectError:       &MemberNameValidationError{"foo%"},
+			expectError:       &MemberNameValidationError{MemberName: "foo%"},
 			additionalOptions: []MarshalOption{MarshalJSONAPI(map[string]any{"foo%": 1})},
 		}, {
 			description: "Article with invalid link meta member name",
 			given:       &articleWithInvalidLinkMetaMemberName,
-			expectError: &MemberNameValidationError{"foo%"},
+			expectError: &MemberNameValidationError{MemberName: "foo%"},
 		}, {
 			description: "Article with invalid relationship name",
 			given:       &articleWithInvalidRelationshipName,
-			expectError: &MemberNameValidationError{"aut%hor"},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor", Field: "ArticleWithInvalidRelationshipName.Author"},
 		}, {
 			description: "Article with invalid relationship type name",
 			given:       &articleWithInvalidRelationshipTypeName,
-			expectError: &MemberNameValidationError{"aut%hor"},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor", Field: "AuthorWithInvalidTypeName.ID"},
 		}, {
 			description: "Article with invalid relationship attribute name not included",
 			given:       &articleWithInvalidRelationshipAttributeName,
@@ -729,18 +866,18 @@ func TestMarshalMemberNameValidation(t *testing.T) {
 		}, {
 			description:       "Article with invalid relationship attribute name included",
 			given:             &articleWithInvalidRelationshipAttributeName,
-			expectError:       &MemberNameValidationError{"na%me"},
+			expectError:       &MemberNameValidationError{MemberName: "na%me", Field: "AuthorWithInvalidAttributeName.Name"},
 			additionalOptions: []MarshalOption{MarshalInclude(&authorWithInvalidAttributeName)},
 		}, {
 			description: "Articles with one invalid resource meta member name",
 			given: []*ArticleWithGenericMeta{
 				{ID: "1"}, {ID: "1", Meta: map[string]any{"foo%": 1}},
 			},
-			expectError: &MemberNameValidationError{"foo%"},
+			expectError: &MemberNameValidationError{MemberName: "foo%"},
 		}, {
 			description: "Website with invalid nested relationship type name",
 			given:       &websiteWithInvalidNestedRelationshipTypeName,
-			expectError: &MemberNameValidationError{"aut%hor"},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor", Field: "AuthorWithInvalidTypeName.ID"},
 			additionalOptions: []MarshalOption{
 				MarshalInclude(
 					websiteWithInvalidNestedRelationshipTypeName.Articles[0],
@@ -767,3 +904,391 @@ func TestMarshalMemberNameValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalRelaxNameValidation(t *testing.T) {
+	t.Parallel()
+
+	legacyWorkspaceBody := `{"data":{"id":"1","type":"WorkspaceResource","attributes":{"DisplayName":"A"}}}`
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+		expectError error
+	}{
+		{
+			description: "strict",
+			given:       &LegacyWorkspace{ID: "1", Name: "A"},
+			opts:        []MarshalOption{MarshalStrictNameValidation()},
+			expectError: &MemberNameValidationError{MemberName: "WorkspaceResource", Field: "LegacyWorkspace.ID"},
+		}, {
+			description: "strict, relaxed types",
+			given:       &LegacyWorkspace{ID: "1", Name: "A"},
+			opts:        []MarshalOption{MarshalStrictNameValidation(), MarshalRelaxNameValidation(TypeMembers)},
+			expectError: &MemberNameValidationError{MemberName: "DisplayName", Field: "LegacyWorkspace.Name"},
+		}, {
+			description: "strict, relaxed types and attributes",
+			given:       &LegacyWorkspace{ID: "1", Name: "A"},
+			opts:        []MarshalOption{MarshalStrictNameValidation(), MarshalRelaxNameValidation(TypeMembers, AttributeMembers)},
+			expect:      legacyWorkspaceBody,
+		}, {
+			description: "strict, relaxed types and attributes, strict meta",
+			given:       &LegacyWorkspace{ID: "1", Name: "A"},
+			opts: []MarshalOption{
+				MarshalStrictNameValidation(),
+				MarshalRelaxNameValidation(TypeMembers, AttributeMembers),
+				MarshalMeta(map[string]any{"RequestID": "1"}),
+			},
+			expectError: &MemberNameValidationError{MemberName: "RequestID"},
+		}, {
+			description: "default, relaxed types doesn't allow invalid names",
+			given:       &authorWithInvalidTypeName,
+			opts:        []MarshalOption{MarshalRelaxNameValidation(TypeMembers)},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor", Field: "AuthorWithInvalidTypeName.ID"},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestMarshalRelationshipIDs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		expect      string
+		expectError error
+	}{
+		{
+			description: "to-one and to-many",
+			given:       &ArticleRelatedIDs{ID: "1", Title: "A", AuthorID: "1", CommentIDs: []string{"1", "2"}},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"}},"comments":{"data":[{"id":"1","type":"comments"},{"id":"2","type":"comments"}]}}}}`,
+		}, {
+			description: "type option",
+			given:       &ArticleRelatedIDsOption{ID: "1", Title: "A", AuthorID: "1", CommentIDs: []string{"1", "2"}},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"}},"comments":{"data":[{"id":"1","type":"comments"},{"id":"2","type":"comments"}]}}}}`,
+		}, {
+			description: "empty relationships are omitted",
+			given:       &ArticleRelatedIDs{ID: "1", Title: "A"},
+			expect:      articleABody,
+		}, {
+			description: "empty to-many",
+			given:       &ArticleRelatedIDs{ID: "1", Title: "A", CommentIDs: []string{}},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"comments":{"data":[]}}}}`,
+		}, {
+			description: "empty id",
+			given:       &ArticleRelatedIDs{ID: "1", Title: "A", CommentIDs: []string{""}},
+			expectError: ErrEmptyPrimaryField,
+		}, {
+			description: "invalid reltype field",
+			given:       &ArticleInvalidRelType{ID: "1", AuthorID: 1},
+			expectError: &TagError{TagName: "reltype", Field: "AuthorID", Reason: "only valid on relationship fields of type string or []string"},
+		}, {
+			description: "invalid type option field",
+			given: &struct {
+				ID       string `jsonapi:"primary,articles"`
+				AuthorID int    `jsonapi:"relationship,type=author" json:"author"`
+			}{ID: "1", AuthorID: 1},
+			expectError: &TagError{TagName: "jsonapi", Field: "AuthorID", Reason: "only valid on relationship fields of type string or []string"},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestMarshalIdentifierMeta(t *testing.T) {
+	t.Parallel()
+
+	tagA := &Tag{ID: "1", Name: "A"}
+	tagB := &Tag{ID: "2", Name: "B"}
+	article := &ArticleTagged{
+		ID:         "1",
+		Tags:       []*Tag{tagA, tagB},
+		TagIDs:     []string{"2"},
+		AssignedAt: map[string]string{"1": "1989-06-15"},
+	}
+
+	actual, err := Marshal(article, MarshalInclude(tagA))
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":{"type":"articles","id":"1","relationships":{"tags":{"data":[{"type":"tags","id":"1","meta":{"assignedAt":"1989-06-15"}},{"type":"tags","id":"2"}]},"featuredTags":{"data":[{"type":"tags","id":"2"}]}}},"included":[{"type":"tags","id":"1","attributes":{"name":"A"}}]}`, string(actual))
+
+	actual, err = MarshalRef(article, "tags")
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":[{"type":"tags","id":"1","meta":{"assignedAt":"1989-06-15"}},{"type":"tags","id":"2"}]}`, string(actual))
+}
+
+func TestMarshalReadOnlyWriteOnly(t *testing.T) {
+	t.Parallel()
+
+	account := &Account{ID: "1", Name: "A", CreatedAt: "1989-06-15", Password: "secret"}
+
+	actual, err := Marshal(account)
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":{"type":"accounts","id":"1","attributes":{"name":"A","createdAt":"1989-06-15"}}}`, string(actual))
+
+	actual, err = Marshal(account, MarshalClientMode())
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":{"type":"accounts","id":"1","attributes":{"name":"A","password":"secret"}}}`, string(actual))
+}
+
+func TestMarshalAppend(t *testing.T) {
+	t.Parallel()
+
+	dst := []byte("prefix:")
+
+	actual, err := MarshalAppend(dst, &articleA)
+	is.MustNoError(t, err)
+	is.Equal(t, "prefix:", string(actual[:len(dst)]))
+	is.EqualJSON(t, articleABody, string(actual[len(dst):]))
+
+	actual, err = MarshalAppend(actual[:len(dst)], articlesABPtr)
+	is.MustNoError(t, err)
+	is.Equal(t, "prefix:", string(actual[:len(dst)]))
+	is.EqualJSON(t, articlesABBody, string(actual[len(dst):]))
+
+	actual, err = MarshalAppend(dst, &authorWithInvalidAttributeName)
+	is.MustError(t, err)
+	is.Equal(t, "prefix:", string(actual))
+}
+
+func TestMarshalNil(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nilArticle  *Article
+		nilArticles []*Article
+	)
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+		expectError error
+	}{
+		{
+			description: "nil",
+			given:       nil,
+			expect:      nullDataBody,
+		}, {
+			description: "nil pointer",
+			given:       nilArticle,
+			expect:      nullDataBody,
+		}, {
+			description: "nil slice",
+			given:       nilArticles,
+			expect:      emptyManyBody,
+		}, {
+			description: "pointer to nil slice",
+			given:       &nilArticles,
+			expect:      emptyManyBody,
+		}, {
+			description: "nil rejected",
+			given:       nil,
+			opts:        []MarshalOption{MarshalRejectNil()},
+			expectError: ErrNilInput,
+		}, {
+			description: "nil pointer rejected",
+			given:       nilArticle,
+			opts:        []MarshalOption{MarshalRejectNil()},
+			expectError: ErrNilInput,
+		}, {
+			description: "pointer to nil slice rejected",
+			given:       &nilArticles,
+			opts:        []MarshalOption{MarshalRejectNil()},
+			expectError: ErrNilInput,
+		}, {
+			description: "empty slice with nil rejected",
+			given:       []*Article{},
+			opts:        []MarshalOption{MarshalRejectNil()},
+			expect:      emptyManyBody,
+		}, {
+			description: "empty relationship with nil rejected",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			opts:        []MarshalOption{MarshalRejectNil()},
+			expect:      articleABody,
+		}, {
+			description: "nil in slice",
+			given:       []*Article{&articleA, nil},
+			expectError: ErrNilResource,
+		}, {
+			description: "nil interface in slice",
+			given:       []any{nil},
+			expectError: ErrNilResource,
+		}, {
+			description: "nil in relationship",
+			given:       &ArticleRelated{ID: "1", Comments: []*Comment{nil}},
+			expectError: ErrNilResource,
+		}, {
+			description: "nil included",
+			given:       &articleA,
+			opts:        []MarshalOption{MarshalInclude(nilArticle)},
+			expectError: ErrNilResource,
+		}, {
+			description: "nil error object",
+			given:       (*Error)(nil),
+			expectError: ErrNilResource,
+		}, {
+			description: "nil in error objects",
+			given:       []*Error{{Title: "T"}, nil},
+			expectError: ErrNilResource,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given, tc.opts...)
+			if tc.expectError != nil {
+				is.Equal(t, true, errors.Is(err, tc.expectError))
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestMemberNameValidationErrorLocation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		marshal     func() error
+		expect      MemberNameValidationError
+	}{
+		{
+			description: "invalid attribute name",
+			marshal: func() error {
+				_, err := Marshal(&authorWithInvalidAttributeName)
+				return err
+			},
+			expect: MemberNameValidationError{MemberName: "na%me", Field: "AuthorWithInvalidAttributeName.Name", Pointer: "/data/attributes/na%me"},
+		}, {
+			description: "invalid type name in collection",
+			marshal: func() error {
+				_, err := Marshal([]*AuthorWithInvalidTypeName{&authorWithInvalidTypeName})
+				return err
+			},
+			expect: MemberNameValidationError{MemberName: "aut%hor", Field: "AuthorWithInvalidTypeName.ID", Pointer: "/data/0/type"},
+		}, {
+			description: "invalid relationship name",
+			marshal: func() error {
+				_, err := Marshal(&articleWithInvalidRelationshipName)
+				return err
+			},
+			expect: MemberNameValidationError{MemberName: "aut%hor", Field: "ArticleWithInvalidRelationshipName.Author", Pointer: "/data/relationships/aut%hor"},
+		}, {
+			description: "invalid related type name",
+			marshal: func() error {
+				_, err := Marshal(&articleWithInvalidRelationshipTypeName)
+				return err
+			},
+			expect: MemberNameValidationError{MemberName: "aut%hor", Field: "AuthorWithInvalidTypeName.ID", Pointer: "/data/relationships/author/data/type"},
+		}, {
+			description: "invalid included attribute name",
+			marshal: func() error {
+				_, err := Marshal(&articleWithInvalidRelationshipAttributeName, MarshalInclude(&authorWithInvalidAttributeName))
+				return err
+			},
+			expect: MemberNameValidationError{MemberName: "na%me", Field: "AuthorWithInvalidAttributeName.Name", Pointer: "/included/0/attributes/na%me"},
+		}, {
+			description: "unmarshal invalid attribute name",
+			marshal: func() error {
+				var a Article
+				return Unmarshal([]byte(`{"data":[{"type":"articles","id":"1","attributes":{"ti%tle":"A"}}]}`), &a)
+			},
+			expect: MemberNameValidationError{MemberName: "ti%tle", Pointer: "/data/0/attributes/ti%tle"},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var ne *MemberNameValidationError
+			is.MustEqual(t, true, errors.As(tc.marshal(), &ne))
+			is.Equal(t, tc.expect, *ne)
+		})
+	}
+}
+
+func TestMarshalType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		expect      string
+		expectError error
+	}{
+		{
+			description: "default type",
+			given:       &TenantArticle{ID: "1", Title: "A"},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`,
+		}, {
+			description: "runtime type",
+			given:       &TenantArticle{ID: "1", Tenant: "acme", Title: "A"},
+			expect:      `{"data":{"type":"acme-articles","id":"1","attributes":{"title":"A"}}}`,
+		}, {
+			description: "runtime types in collection",
+			given:       []*TenantArticle{{ID: "1", Tenant: "acme"}, {ID: "1", Tenant: "initech"}},
+			expect:      `{"data":[{"type":"acme-articles","id":"1","attributes":{"title":""}},{"type":"initech-articles","id":"1","attributes":{"title":""}}]}`,
+		}, {
+			description: "invalid runtime type",
+			given:       &TenantArticle{ID: "1", Tenant: "ac%me"},
+			expectError: &MemberNameValidationError{MemberName: "ac%me-articles", Field: "TenantArticle.ID"},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
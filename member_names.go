@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 var (
-	defaultNameRegex *regexp.Regexp
-	strictNameRegex  *regexp.Regexp
+	defaultNameRegex   *regexp.Regexp
+	strictNameRegex    *regexp.Regexp
+	namespaceNameRegex *regexp.Regexp
 )
 
 func init() {
@@ -22,7 +24,13 @@ func init() {
 	// - at least one lower case letter
 	// - camel case, and must end with a lower case letter
 	// - may have digits inside the word
-	strictNameRegex = regexp.MustCompile(`^([a-z]|[a-z]+((\d)|([A-Z\d][a-z\d]+))*([A-Z\d][a-z\d]*[a-z]))$`)
+	// - a single lower case word of any length (e.g. "id" or "title") is valid, which the regex
+	//   used before relaxed validation was added wrongly rejected for words of two letters or more
+	strictNameRegex = regexp.MustCompile(`^[a-z]+(([A-Z\d][a-z\d]*)*[a-z])?$`)
+
+	// extension namespaces must only contain ascii letters and digits, as required by
+	// https://jsonapi.org/format/1.1/#extension-rules
+	namespaceNameRegex = regexp.MustCompile(`^[a-zA-Z\d]+$`)
 }
 
 type memberNameValidationMode int
@@ -48,6 +56,49 @@ const (
 	strictValidation
 )
 
+// relaxed returns the validation mode used for member classes exempted from strict validation.
+//
+// Relaxing only affects the naming recommendations, so a relaxed member name must still conform to
+// the basic rules from https://jsonapi.org/format/#document-member-names.
+func (mode memberNameValidationMode) relaxed() memberNameValidationMode {
+	if mode == strictValidation {
+		return defaultValidation
+	}
+	return mode
+}
+
+// MemberClass identifies a class of member names which can be exempted from strict member name
+// validation independently of the rest of the document.
+type MemberClass int
+
+const (
+	// TypeMembers is the class of resource object type names.
+	TypeMembers MemberClass = iota
+
+	// AttributeMembers is the class of resource object attribute names, including the names of
+	// members nested within attribute values.
+	AttributeMembers
+)
+
+// memberClasses is a set of MemberClass values.
+type memberClasses map[MemberClass]bool
+
+func newMemberClasses(classes []MemberClass) memberClasses {
+	mc := make(memberClasses, len(classes))
+	for _, class := range classes {
+		mc[class] = true
+	}
+	return mc
+}
+
+// modeFor returns the validation mode to use for the given member class.
+func (mc memberClasses) modeFor(class MemberClass, mode memberNameValidationMode) memberNameValidationMode {
+	if mc[class] {
+		return mode.relaxed()
+	}
+	return mode
+}
+
 func isValidMemberName(name string, mode memberNameValidationMode) bool {
 	switch mode {
 	case disableValidation:
@@ -59,20 +110,67 @@ func isValidMemberName(name string, mode memberNameValidationMode) bool {
 	}
 }
 
-func validateMapMemberNames(m map[string]any, mode memberNameValidationMode) error {
-	for member, val := range m {
-		if !isValidMemberName(member, mode) {
-			return &MemberNameValidationError{member}
+// extensionNamespaces is the set of extension namespaces registered via MarshalExtensions or
+// UnmarshalExtensions.
+type extensionNamespaces map[string]bool
+
+func newExtensionNamespaces(namespaces []string) extensionNamespaces {
+	ns := make(extensionNamespaces, len(namespaces))
+	for _, namespace := range namespaces {
+		ns[namespace] = true
+	}
+	return ns
+}
+
+// with returns a copy of ns with the given namespace registered as well.
+func (ns extensionNamespaces) with(namespace string) extensionNamespaces {
+	with := make(extensionNamespaces, len(ns)+1)
+	for n := range ns {
+		with[n] = true
+	}
+	with[namespace] = true
+	return with
+}
+
+// isExtensionMemberName returns true if name is a member name of the form namespace:member, e.g.
+// atomic:operations, whose namespace is registered in ns and whose member is valid in the given
+// mode.
+func (ns extensionNamespaces) isExtensionMemberName(name string, mode memberNameValidationMode) bool {
+	namespace, member, ok := strings.Cut(name, ":")
+	if !ok || !ns[namespace] || !namespaceNameRegex.MatchString(namespace) {
+		return false
+	}
+	if mode == disableValidation {
+		// the member of an extension member name must still be named
+		mode = defaultValidation
+	}
+	return isValidMemberName(member, mode)
+}
+
+// isValidMemberName returns true if name is a valid member name in the given mode, or an extension
+// member name of a namespace registered in ns.
+func (ns extensionNamespaces) isValidMemberName(name string, mode memberNameValidationMode) bool {
+	return isValidMemberName(name, mode) || ns.isExtensionMemberName(name, mode)
+}
+
+// validateMapMemberNames validates the member names of a decoded json object found at the given
+// JSON pointer, and those of the objects nested within it.
+func validateMapMemberNames(m map[string]any, mode memberNameValidationMode, ns extensionNamespaces, pointer string) error {
+	for _, member := range sortedMembers(m) {
+		val := m[member]
+		memberPointer := pointer + "/" + escapePointerToken(member)
+		if !ns.isValidMemberName(member, mode) {
+			return &MemberNameValidationError{MemberName: member, Pointer: memberPointer}
 		}
 		switch nested := val.(type) {
 		case map[string]any:
-			if err := validateMapMemberNames(nested, mode); err != nil {
+			if err := validateMapMemberNames(nested, mode, ns, memberPointer); err != nil {
 				return err
 			}
 		case []any:
-			for _, entry := range nested {
+			for i, entry := range nested {
 				if subMap, ok := entry.(map[string]any); ok {
-					if err := validateMapMemberNames(subMap, mode); err != nil {
+					if err := validateMapMemberNames(subMap, mode, ns, fmt.Sprintf("%s/%d", memberPointer, i)); err != nil {
 						return err
 					}
 				}
@@ -84,10 +182,70 @@ func validateMapMemberNames(m map[string]any, mode memberNameValidationMode) err
 	return nil
 }
 
-func validateJSONMemberNames(b []byte, mode memberNameValidationMode) error {
+// validateResourceObjectMemberNames validates the member names of a decoded resource object found at
+// the given JSON pointer, using attrMode for the names within its attributes.
+func validateResourceObjectMemberNames(ro any, mode, attrMode memberNameValidationMode, ns extensionNamespaces, pointer string) error {
+	m, ok := ro.(map[string]any)
+	if !ok {
+		return nil
+	}
+	for _, member := range sortedMembers(m) {
+		memberPointer := pointer + "/" + escapePointerToken(member)
+		if !ns.isValidMemberName(member, mode) {
+			return &MemberNameValidationError{MemberName: member, Pointer: memberPointer}
+		}
+		nested, ok := m[member].(map[string]any)
+		if !ok {
+			continue
+		}
+		nestedMode := mode
+		if member == "attributes" {
+			nestedMode = attrMode
+		}
+		if err := validateMapMemberNames(nested, nestedMode, ns, memberPointer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateJSONMemberNames(b []byte, mode memberNameValidationMode, relaxed memberClasses, ns extensionNamespaces) error {
 	var m map[string]any
 	if err := json.Unmarshal(b, &m); err != nil {
 		return fmt.Errorf("unexpected unmarshal failure: %w", err)
 	}
-	return validateMapMemberNames(m, mode)
+	return validateMemberNames(m, mode, relaxed, ns)
+}
+
+// validateMemberNames validates the member names of the decoded document m.
+func validateMemberNames(m map[string]any, mode memberNameValidationMode, relaxed memberClasses, ns extensionNamespaces) error {
+	attrMode := relaxed.modeFor(AttributeMembers, mode)
+	if attrMode == mode {
+		return validateMapMemberNames(m, mode, ns, "")
+	}
+
+	// attribute names are validated differently, so resource objects in primary and included data
+	// must be walked separately from the rest of the document
+	for _, member := range []string{"data", "included"} {
+		switch ros := m[member].(type) {
+		case map[string]any:
+			if err := validateResourceObjectMemberNames(ros, mode, attrMode, ns, "/"+member); err != nil {
+				return err
+			}
+		case []any:
+			for i, ro := range ros {
+				if err := validateResourceObjectMemberNames(ro, mode, attrMode, ns, fmt.Sprintf("/%s/%d", member, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	rest := make(map[string]any, len(m))
+	for member, val := range m {
+		if member != "data" && member != "included" {
+			rest[member] = val
+		}
+	}
+	return validateMapMemberNames(rest, mode, ns, "")
 }
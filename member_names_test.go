@@ -13,6 +13,10 @@ func TestIsValidMemberName(t *testing.T) {
 	testValidations := map[memberNameValidationMode][]string{
 		strictValidation: {
 			"a",
+			"id",
+			"data",
+			"title",
+			"html5Doc",
 			"lowercase1with2numerals",
 			"camelCase",
 			"camel12Case9WithNumera1s",
@@ -23,6 +27,7 @@ func TestIsValidMemberName(t *testing.T) {
 			"camelCaseWithNumeralSuffix10",
 			"4camelCaseWithSurroundingNumerals5",
 			"camelC",
+			"numeralSuffix1",
 			"PascalCase",
 			"dash-case",
 			"snake_case",
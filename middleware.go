@@ -0,0 +1,79 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// HandlerFunc is an http.Handler returning an error, which is written as an error document by
+// WriteError if nothing has been written to the response yet. Panics are recovered as done by
+// Recover.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP implements the http.Handler interface.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rw := &responseWriter{ResponseWriter: w}
+	defer recoverHandler(rw)
+
+	if err := f(rw, r); err != nil && !rw.wroteHeader {
+		_ = WriteError(rw, err)
+	}
+}
+
+// Recover is a middleware recovering panics of the next handler, which are written as a 500
+// (Internal Server Error) error document if nothing has been written to the response yet, so
+// clients of JSON:API endpoints never receive plain-text errors.
+//
+// As done by net/http, a panic with the value http.ErrAbortHandler is not recovered.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		defer recoverHandler(rw)
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// recoverHandler recovers a panic of a handler writing to rw.
+func recoverHandler(rw *responseWriter) {
+	rvr := recover()
+	if rvr == nil {
+		return
+	}
+	if err, ok := rvr.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+		panic(rvr)
+	}
+	if !rw.wroteHeader {
+		_ = WriteError(rw, fmt.Errorf("handler panic: %v", rvr))
+	}
+}
+
+// responseWriter is an http.ResponseWriter keeping track of whether the header has been written.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+// Flush implements the http.Flusher interface if the underlying http.ResponseWriter does.
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		rw.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,152 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// conflictError is an error implementing StatusError.
+type conflictError struct{}
+
+func (conflictError) Error() string { return "article already exists" }
+func (conflictError) Status() int   { return http.StatusConflict }
+
+func TestHandlerFunc(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description  string
+		given        HandlerFunc
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			description: "no error",
+			given: func(w http.ResponseWriter, r *http.Request) error {
+				return Write(w, http.StatusOK, &articleA)
+			},
+			expectStatus: http.StatusOK,
+			expectBody:   articleABody,
+		}, {
+			description: "*Error",
+			given: func(w http.ResponseWriter, r *http.Request) error {
+				return &Error{Status: Status(http.StatusNotFound), Title: "Not Found"}
+			},
+			expectStatus: http.StatusNotFound,
+			expectBody:   `{"errors":[{"status":"404","title":"Not Found"}]}`,
+		}, {
+			description: "StatusError",
+			given: func(w http.ResponseWriter, r *http.Request) error {
+				return fmt.Errorf("create: %w", conflictError{})
+			},
+			expectStatus: http.StatusConflict,
+			expectBody:   `{"errors":[{"status":"409","title":"Conflict","detail":"create: article already exists"}]}`,
+		}, {
+			description: "unknown error",
+			given: func(w http.ResponseWriter, r *http.Request) error {
+				return errors.New("connection refused")
+			},
+			expectStatus: http.StatusInternalServerError,
+			expectBody:   `{"errors":[{"status":"500","title":"Internal Server Error"}]}`,
+		}, {
+			description: "error after writing",
+			given: func(w http.ResponseWriter, r *http.Request) error {
+				_ = Write(w, http.StatusOK, &articleA)
+				return errors.New("connection reset")
+			},
+			expectStatus: http.StatusOK,
+			expectBody:   articleABody,
+		}, {
+			description: "panic",
+			given: func(w http.ResponseWriter, r *http.Request) error {
+				panic("nil map")
+			},
+			expectStatus: http.StatusInternalServerError,
+			expectBody:   `{"errors":[{"status":"500","title":"Internal Server Error"}]}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			rec := httptest.NewRecorder()
+			tc.given.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/articles/1", nil))
+			is.Equal(t, tc.expectStatus, rec.Code)
+			is.Equal(t, MediaType, rec.Header().Get("Content-Type"))
+			is.EqualJSON(t, tc.expectBody, rec.Body.String())
+		})
+	}
+}
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description  string
+		given        http.HandlerFunc
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			description: "no panic",
+			given: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expectStatus: http.StatusNoContent,
+		}, {
+			description: "panic",
+			given: func(w http.ResponseWriter, r *http.Request) {
+				panic(errors.New("index out of range"))
+			},
+			expectStatus: http.StatusInternalServerError,
+			expectBody:   `{"errors":[{"status":"500","title":"Internal Server Error"}]}`,
+		}, {
+			description: "panic after writing",
+			given: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic("index out of range")
+			},
+			expectStatus: http.StatusAccepted,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			rec := httptest.NewRecorder()
+			Recover(tc.given).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/articles/1", nil))
+			is.Equal(t, tc.expectStatus, rec.Code)
+			if tc.expectBody == "" {
+				is.Equal(t, 0, rec.Body.Len())
+				return
+			}
+			is.EqualJSON(t, tc.expectBody, rec.Body.String())
+		})
+	}
+}
+
+func TestRecoverAbortHandler(t *testing.T) {
+	t.Parallel()
+
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		rvr := recover()
+		is.Equal(t, true, strings.Contains(fmt.Sprint(rvr), http.ErrAbortHandler.Error()))
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/articles/1", nil))
+}
@@ -0,0 +1,185 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NamingConvention derives the member name of an attribute or relationship from the name of its Go
+// struct field, for fields whose json tag gives no name. It can be set with MarshalNamingConvention
+// and UnmarshalNamingConvention, and must return the same name for both.
+type NamingConvention func(fieldName string) string
+
+// CamelCase is the NamingConvention recommended by https://jsonapi.org/recommendations/#naming,
+// e.g. UserID becomes userId.
+func CamelCase(fieldName string) string {
+	words := splitWords(fieldName)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToUpper(r)) + strings.ToLower(word[size:])
+	}
+	return strings.Join(words, "")
+}
+
+// SnakeCase is the NamingConvention joining lower case words with underscores, e.g. UserID
+// becomes user_id.
+func SnakeCase(fieldName string) string {
+	return strings.ToLower(strings.Join(splitWords(fieldName), "_"))
+}
+
+// KebabCase is the NamingConvention joining lower case words with dashes, e.g. UserID becomes
+// user-id.
+func KebabCase(fieldName string) string {
+	return strings.ToLower(strings.Join(splitWords(fieldName), "-"))
+}
+
+// splitWords splits a Go identifier into its words, keeping acronyms and digits together, e.g.
+// HTTPServer2Name becomes HTTP, Server2 and Name.
+func splitWords(name string) []string {
+	runes := []rune(name)
+	words := make([]string, 0)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		switch {
+		case cur == '_':
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+		case unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			// fooBar, foo2Bar
+			words = append(words, string(runes[start:i]))
+			start = i
+		case unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// HTTPServer
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// MarshalNamingConvention derives the member names of attributes and relationships from their
+// field names using the given NamingConvention (e.g. CamelCase), unless given by their json tag.
+func MarshalNamingConvention(c NamingConvention) MarshalOption {
+	return func(m *Marshaler) {
+		m.naming = c
+	}
+}
+
+// UnmarshalNamingConvention derives the member names of attributes and relationships from their
+// field names using the given NamingConvention (e.g. CamelCase), unless given by their json tag.
+func UnmarshalNamingConvention(c NamingConvention) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.naming = c
+	}
+}
+
+// memberName returns the member name of the struct field f as given by parseJSONTag, derived from
+// its field name with the given NamingConvention if its json tag gives no name.
+func memberName(f reflect.StructField, naming NamingConvention) (string, bool, bool) {
+	name, ok, omit := parseJSONTag(f)
+	if ok && naming != nil && !hasJSONName(f) {
+		name = naming(f.Name)
+	}
+	return name, ok, omit
+}
+
+// hasJSONName returns true if the json tag of the struct field f gives its name.
+func hasJSONName(f reflect.StructField) bool {
+	return strings.Split(f.Tag.Get("json"), ",")[0] != ""
+}
+
+// conventionalAttributes returns the field names of the attributes of the struct type t whose
+// member names are derived with the given NamingConvention, by member name.
+func conventionalAttributes(t reflect.Type, naming NamingConvention) map[string]string {
+	fields := make(map[string]string)
+	addConventionalAttributes(derefType(t), naming, fields)
+	return fields
+}
+
+func addConventionalAttributes(t reflect.Type, naming NamingConvention, fields map[string]string) {
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isFlattened(f) {
+			addConventionalAttributes(derefType(f.Type), naming, fields)
+			continue
+		}
+		tag, err := parseJSONAPITag(f)
+		if err != nil || tag == nil || tag.directive != attribute || hasJSONName(f) {
+			continue
+		}
+		if name, ok, _ := memberName(f, naming); ok && name != f.Name {
+			fields[name] = f.Name
+		}
+	}
+}
+
+// renameConventionalAttributes renames the members of the given attributes object named with the
+// Unmarshaler's NamingConvention to the names of their fields in v, so that they are decoded like
+// untagged fields. It returns the renamed attributes along with the member names of the renamed
+// attributes, by field name.
+func (m *Unmarshaler) renameConventionalAttributes(data []byte, v any) ([]byte, map[string]string, error) {
+	fields := conventionalAttributes(reflect.TypeOf(v), m.naming)
+	if len(fields) == 0 {
+		return data, nil, nil
+	}
+
+	var attributes map[string]rawValue
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, nil, &FieldError{Code: CodeInvalidAttribute, Member: "attributes", Pointer: "/attributes", Err: err}
+	}
+
+	renamed := make(map[string]string)
+	for name, field := range fields {
+		value, ok := attributes[name]
+		if !ok {
+			continue
+		}
+		delete(attributes, name)
+		attributes[field] = value
+		renamed[field] = name
+	}
+	if len(renamed) == 0 {
+		return data, nil, nil
+	}
+
+	b, err := json.Marshal(attributes)
+	return b, renamed, err
+}
+
+// renameFieldErrors replaces the field names of renamed attributes in the FieldErrors of err's chain
+// with their member names.
+func renameFieldErrors(err error, renamed map[string]string) error {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		fe, ok := e.(*FieldError)
+		if !ok {
+			continue
+		}
+		name, ok := renamed[fe.Member]
+		if !ok {
+			continue
+		}
+		prefix := "/attributes/" + escapePointerToken(fe.Member)
+		if fe.Pointer == prefix || strings.HasPrefix(fe.Pointer, prefix+"/") {
+			fe.Pointer = "/attributes/" + escapePointerToken(name) + strings.TrimPrefix(fe.Pointer, prefix)
+		}
+		fe.Member = name
+	}
+	return err
+}
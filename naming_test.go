@@ -0,0 +1,100 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestNamingConventions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		given string
+		camel string
+		snake string
+		kebab string
+	}{
+		{given: "Name", camel: "name", snake: "name", kebab: "name"},
+		{given: "FirstName", camel: "firstName", snake: "first_name", kebab: "first-name"},
+		{given: "UserID", camel: "userId", snake: "user_id", kebab: "user-id"},
+		{given: "HTTPServer", camel: "httpServer", snake: "http_server", kebab: "http-server"},
+		{given: "Address2Line", camel: "address2Line", snake: "address2_line", kebab: "address2-line"},
+		{given: "Legacy_Name", camel: "legacyName", snake: "legacy_name", kebab: "legacy-name"},
+		{given: "FooÉclair", camel: "fooÉclair", snake: "foo_éclair", kebab: "foo-éclair"},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.given)
+
+			is.Equal(t, tc.camel, CamelCase(tc.given))
+			is.Equal(t, tc.snake, SnakeCase(tc.given))
+			is.Equal(t, tc.kebab, KebabCase(tc.given))
+		})
+	}
+}
+
+func TestMarshalNamingConvention(t *testing.T) {
+	t.Parallel()
+
+	profile := &Profile{ID: "1", FirstName: "A", LastName: "B", Nickname: "C", BestFriend: &authorA}
+
+	tests := []struct {
+		description string
+		naming      NamingConvention
+		expect      string
+	}{
+		{
+			description: "none",
+			expect:      `{"data":{"type":"profiles","id":"1","attributes":{"FirstName":"A","LastName":"B","nick":"C"},"relationships":{"BestFriend":{"data":{"type":"author","id":"1"}}}}}`,
+		}, {
+			description: "snake case",
+			naming:      SnakeCase,
+			expect:      `{"data":{"type":"profiles","id":"1","attributes":{"first_name":"A","last_name":"B","nick":"C"},"relationships":{"best_friend":{"data":{"type":"author","id":"1"}}}}}`,
+		}, {
+			description: "custom",
+			naming:      strings.ToUpper,
+			expect:      `{"data":{"type":"profiles","id":"1","attributes":{"FIRSTNAME":"A","LASTNAME":"B","nick":"C"},"relationships":{"BESTFRIEND":{"data":{"type":"author","id":"1"}}}}}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(profile, MarshalNamingConvention(tc.naming))
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestUnmarshalNamingConvention(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":{"type":"profiles","id":"1","attributes":{"first_name":"A","last_name":"B","nick":"C"},"relationships":{"best_friend":{"data":{"type":"author","id":"1"}}}},"included":[{"type":"author","id":"1","attributes":{"name":"A"}}]}`
+
+	var profile Profile
+	is.MustNoError(t, Unmarshal([]byte(body), &profile, UnmarshalNamingConvention(SnakeCase)))
+	is.Equal(t, Profile{ID: "1", FirstName: "A", LastName: "B", Nickname: "C", BestFriend: &authorA}, profile)
+
+	b, err := Marshal(&profile, MarshalNamingConvention(KebabCase))
+	is.MustNoError(t, err)
+	var roundTripped Profile
+	is.MustNoError(t, Unmarshal(b, &roundTripped, UnmarshalNamingConvention(KebabCase)))
+	is.Equal(t, Profile{ID: "1", FirstName: "A", LastName: "B", Nickname: "C", BestFriend: &Author{ID: "1"}}, roundTripped)
+
+	err = Unmarshal([]byte(`{"data":{"type":"profiles","id":"1","attributes":{"first_name":1}}}`), &profile, UnmarshalNamingConvention(SnakeCase))
+	var fe *FieldError
+	is.MustEqual(t, true, errors.As(err, &fe))
+	is.Equal(t, "first_name", fe.Member)
+	is.Equal(t, "/data/attributes/first_name", fe.Pointer)
+}
@@ -0,0 +1,142 @@
+package jsonapi
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// splitHeaderList splits a comma separated header value into its elements, ignoring commas within
+// quoted strings.
+func splitHeaderList(value string) []string {
+	var (
+		elements []string
+		quoted   bool
+		start    int
+	)
+	for i, c := range value {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			elements = append(elements, strings.TrimSpace(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(elements, strings.TrimSpace(value[start:]))
+}
+
+// checkMediaTypeParams returns a description of the first parameter of a JSON:API media type
+// instance which is not allowed by https://jsonapi.org/format/1.1/#media-type-parameter-rules, or
+// the empty string. Only the ext and profile parameters are allowed, and every extension listed in
+// ext must be one of the supported extensions.
+func checkMediaTypeParams(params map[string]string, extensions []string, ignore ...string) string {
+	supported := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		supported[ext] = true
+	}
+
+params:
+	for name, value := range params {
+		for _, ignored := range ignore {
+			if name == ignored {
+				continue params
+			}
+		}
+
+		switch name {
+		case "profile":
+			// unrecognized profiles must be ignored
+		case "ext":
+			for _, ext := range strings.Fields(value) {
+				if !supported[ext] {
+					return fmt.Sprintf("unsupported extension %q", ext)
+				}
+			}
+		default:
+			return fmt.Sprintf("unsupported media type parameter %q", name)
+		}
+	}
+
+	return ""
+}
+
+// NegotiateRequest checks that r conforms to the content negotiation rules of
+// https://jsonapi.org/format/1.1/#content-negotiation-servers, given the URIs of the extensions
+// supported by the server.
+//
+// A 415 (Unsupported Media Type) error is returned if the Content-Type header is not the JSON:API
+// media type or has parameters other than ext and profile, or ext lists an unsupported extension.
+// A 406 (Not Acceptable) error is returned if the Accept header lists the JSON:API media type, but
+// every instance of it has parameters other than ext and profile, or lists unsupported extensions.
+// Otherwise, NegotiateRequest returns nil. The returned errors are of type *Error.
+func NegotiateRequest(r *http.Request, extensions ...string) error {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+
+		reason := "the media type must be " + MediaType
+		if err == nil && mediaType == MediaType {
+			reason = checkMediaTypeParams(params, extensions)
+		}
+		if reason != "" {
+			return &Error{
+				Status: Status(http.StatusUnsupportedMediaType),
+				Title:  http.StatusText(http.StatusUnsupportedMediaType),
+				Detail: fmt.Sprintf("Content-Type %q is not supported: %s.", contentType, reason),
+				Source: &ErrorSource{Header: "Content-Type"},
+			}
+		}
+	}
+
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return nil
+	}
+
+	var (
+		instances int
+		reason    string
+	)
+	for _, value := range accept {
+		for _, element := range splitHeaderList(value) {
+			mediaType, params, err := mime.ParseMediaType(element)
+			if err != nil || mediaType != MediaType {
+				continue
+			}
+
+			instances++
+			// the quality value is an accept parameter rather than a media type parameter
+			if reason = checkMediaTypeParams(params, extensions, "q"); reason == "" {
+				return nil
+			}
+		}
+	}
+
+	if instances == 0 {
+		// the client will accept other media types
+		return nil
+	}
+
+	return &Error{
+		Status: Status(http.StatusNotAcceptable),
+		Title:  http.StatusText(http.StatusNotAcceptable),
+		Detail: fmt.Sprintf("No acceptable instance of the %s media type: %s.", MediaType, reason),
+		Source: &ErrorSource{Header: "Accept"},
+	}
+}
+
+// Negotiate performs content negotiation as described by NegotiateRequest. If negotiation fails the
+// error document is written to w and false is returned, otherwise Negotiate returns true and the
+// request should be served.
+func Negotiate(w http.ResponseWriter, r *http.Request, extensions ...string) bool {
+	w.Header().Add("Vary", "Accept")
+
+	if err := NegotiateRequest(r, extensions...); err != nil {
+		e := err.(*Error)
+		_ = Write(w, *e.Status, e)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,113 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestNegotiateRequest(t *testing.T) {
+	t.Parallel()
+
+	atomic := "https://jsonapi.org/ext/atomic"
+
+	tests := []struct {
+		description string
+		contentType string
+		accept      []string
+		extensions  []string
+		expect      *int
+	}{
+		{
+			description: "no headers",
+			expect:      nil,
+		}, {
+			description: "jsonapi",
+			contentType: MediaType,
+			accept:      []string{MediaType},
+			expect:      nil,
+		}, {
+			description: "other content type",
+			contentType: "application/json",
+			expect:      Status(http.StatusUnsupportedMediaType),
+		}, {
+			description: "content type with unsupported parameter",
+			contentType: MediaType + "; charset=utf-8",
+			expect:      Status(http.StatusUnsupportedMediaType),
+		}, {
+			description: "content type with profile",
+			contentType: MediaType + `; profile="https://example.com/profile"`,
+			expect:      nil,
+		}, {
+			description: "content type with unsupported extension",
+			contentType: MediaType + `; ext="` + atomic + `"`,
+			expect:      Status(http.StatusUnsupportedMediaType),
+		}, {
+			description: "content type with supported extension",
+			contentType: MediaType + `; ext="` + atomic + `"`,
+			extensions:  []string{atomic},
+			expect:      nil,
+		}, {
+			description: "accept other media types",
+			accept:      []string{"application/json, */*"},
+			expect:      nil,
+		}, {
+			description: "accept only with unsupported parameter",
+			accept:      []string{MediaType + "; charset=utf-8"},
+			expect:      Status(http.StatusNotAcceptable),
+		}, {
+			description: "accept one instance without parameters",
+			accept:      []string{MediaType + "; charset=utf-8, " + MediaType},
+			expect:      nil,
+		}, {
+			description: "accept instances in multiple headers",
+			accept:      []string{MediaType + "; charset=utf-8", MediaType + ";q=0.5"},
+			expect:      nil,
+		}, {
+			description: "accept with unsupported extension",
+			accept:      []string{MediaType + `; ext="` + atomic + `"`},
+			expect:      Status(http.StatusNotAcceptable),
+		}, {
+			description: "accept with supported extension and profile",
+			accept:      []string{MediaType + `; ext="` + atomic + `"; profile="https://example.com/a https://example.com/b"`},
+			extensions:  []string{atomic},
+			expect:      nil,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			r := httptest.NewRequest(http.MethodPost, "/articles", nil)
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+			for _, v := range tc.accept {
+				r.Header.Add("Accept", v)
+			}
+
+			err := NegotiateRequest(r, tc.extensions...)
+			if tc.expect == nil {
+				is.MustNoError(t, err)
+			} else {
+				e, ok := err.(*Error)
+				is.MustEqual(t, true, ok)
+				is.Equal(t, tc.expect, e.Status)
+			}
+
+			w := httptest.NewRecorder()
+			ok := Negotiate(w, r, tc.extensions...)
+			is.Equal(t, tc.expect == nil, ok)
+			if tc.expect != nil {
+				is.Equal(t, *tc.expect, w.Code)
+				is.Equal(t, MediaType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package jsonapi
+
+import "reflect"
+
+// NullableRelationship is a to-one relationship field distinguishing a relationship which is
+// omitted from one whose resource linkage is null, e.g. to clear the relationship with a PATCH
+// request. T is the type of the related resource, e.g. *Author, or string for relationships holding
+// resource ids only.
+//
+// A NullableRelationship which isn't present is omitted when marshaling, and one which is present
+// is marshaled as the resource linkage of Related, which is null if Related is nil (or empty for
+// resource ids). Present is set when unmarshaling a relationship with resource linkage, null or
+// not, so the state of the relationship survives round trips.
+type NullableRelationship[T any] struct {
+	// Related is the related resource, or nil (or empty) if the resource linkage is null.
+	Related T
+
+	// Present is true if the relationship has resource linkage.
+	Present bool
+}
+
+// NewNullableRelationship returns a present NullableRelationship with the given related resource,
+// which may be nil (or empty) to clear the relationship.
+func NewNullableRelationship[T any](related T) NullableRelationship[T] {
+	return NullableRelationship[T]{Related: related, Present: true}
+}
+
+// isNullableRelationship implements nullableRelationship.
+func (NullableRelationship[T]) isNullableRelationship() {}
+
+// nullableRelationship is implemented by all NullableRelationship types.
+type nullableRelationship interface {
+	isNullableRelationship()
+}
+
+var nullableRelationshipType = reflect.TypeOf((*nullableRelationship)(nil)).Elem()
+
+// isNullableRelationship returns true if t is a NullableRelationship type.
+func isNullableRelationship(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && implements(t, nullableRelationshipType)
+}
+
+// relatedFieldType returns the type of the related resources held by relationship fields of type
+// t, i.e. the type of the Related field of NullableRelationship types, or t itself.
+func relatedFieldType(t reflect.Type) reflect.Type {
+	if isNullableRelationship(t) {
+		return t.Field(0).Type
+	}
+	return t
+}
+
+// relatedField returns the related resources held by the relationship field fv, i.e. the Related
+// field of a NullableRelationship, or fv itself. It returns false for NullableRelationships which
+// aren't present.
+func relatedField(fv reflect.Value) (reflect.Value, bool) {
+	if isNullableRelationship(fv.Type()) {
+		return fv.Field(0), fv.Field(1).Bool()
+	}
+	return fv, true
+}
+
+// setRelationshipPresent marks the relationship field fv as present if it is a
+// NullableRelationship, and returns the field holding its related resources.
+func setRelationshipPresent(fv reflect.Value) reflect.Value {
+	if isNullableRelationship(fv.Type()) {
+		fv.Field(1).SetBool(true)
+		return fv.Field(0)
+	}
+	return fv
+}
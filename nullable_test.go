@@ -0,0 +1,112 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalNullableRelationship(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       *ArticleNullable
+		expect      string
+	}{
+		{
+			description: "omitted",
+			given:       &ArticleNullable{ID: "1", Title: "A"},
+			expect:      articleABody,
+		}, {
+			description: "null",
+			given:       &ArticleNullable{ID: "1", Title: "A", Author: NewNullableRelationship[*Author](nil), EditorID: NewNullableRelationship("")},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":null},"editor":{"data":null}}}}`,
+		}, {
+			description: "related",
+			given:       &ArticleNullable{ID: "1", Title: "A", Author: NewNullableRelationship(&authorA), EditorID: NewNullableRelationship("2")},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"}},"editor":{"data":{"id":"2","type":"author"}}}}}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestUnmarshalNullableRelationship(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expect      *ArticleNullable
+	}{
+		{
+			description: "omitted",
+			given:       articleABody,
+			expect:      &ArticleNullable{ID: "1", Title: "A"},
+		}, {
+			description: "without resource linkage",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"links":{"related":"http://example.com/articles/1/author"}}}}}`,
+			expect:      &ArticleNullable{ID: "1", Title: "A"},
+		}, {
+			description: "null",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":null},"editor":{"data":null}}}}`,
+			expect:      &ArticleNullable{ID: "1", Title: "A", Author: NewNullableRelationship[*Author](nil), EditorID: NewNullableRelationship("")},
+		}, {
+			description: "related",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"}},"editor":{"data":{"id":"2","type":"author"}}}}}`,
+			expect:      &ArticleNullable{ID: "1", Title: "A", Author: NewNullableRelationship(&Author{ID: "1"}), EditorID: NewNullableRelationship("2")},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var actual ArticleNullable
+			err := Unmarshal([]byte(tc.given), &actual)
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &actual)
+
+			// the state of the relationships survives the round trip
+			b, err := Marshal(&actual)
+			is.MustNoError(t, err)
+			var again ArticleNullable
+			is.MustNoError(t, Unmarshal(b, &again))
+			is.Equal(t, tc.expect, &again)
+		})
+	}
+}
+
+func TestNullableRelationshipRef(t *testing.T) {
+	t.Parallel()
+
+	b, err := MarshalRef(&ArticleNullable{ID: "1"}, "author")
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":null}`, string(b))
+
+	var a ArticleNullable
+	is.MustNoError(t, UnmarshalRef([]byte(`{"data":null}`), &a, "author"))
+	is.Equal(t, NewNullableRelationship[*Author](nil), a.Author)
+
+	is.MustNoError(t, UnmarshalRef([]byte(`{"data":{"id":"2","type":"author"}}`), &a, "editor"))
+	is.Equal(t, NewNullableRelationship("2"), a.EditorID)
+
+	s, err := SchemaOf(&a)
+	is.MustNoError(t, err)
+	is.Equal(t, []RelationshipSchema{{Name: "author", Field: "Author", Type: reflect.TypeOf(NullableRelationship[*Author]{}), RelatedType: "author"}, {Name: "editor", Field: "EditorID", Type: reflect.TypeOf(NullableRelationship[string]{}), RelatedType: "author"}}, s.Relationships)
+}
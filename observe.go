@@ -0,0 +1,150 @@
+package jsonapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Operation describes a single marshal or unmarshal operation as reported to Hooks, e.g. to be
+// exported as metrics.
+type Operation struct {
+	// Duration is the time taken by the operation.
+	Duration time.Duration
+
+	// Size is the number of bytes encoded, or decoded. It is zero for documents converted to or from
+	// maps by MarshalToMap and UnmarshalFromMap, which aren't encoded.
+	Size int
+
+	// ResourceType is the type of the primary resource objects, or empty if there are none or
+	// they are of different types.
+	ResourceType string
+
+	// Primary, Included and Errors are the number of primary resource objects, included resource
+	// objects and error objects of the document. They are zero if the operation failed before the
+	// document was constructed, or parsed.
+	Primary  int
+	Included int
+	Errors   int
+
+	// Err is the error returned by the operation, if any.
+	Err error
+}
+
+// Hooks are called with the context given by MarshalContext or UnmarshalContext once an operation
+// is done, whether it failed or not. Hooks must be safe for concurrent use if the options they are
+// given by are shared between goroutines.
+type Hooks struct {
+	// OnMarshal is called by every function marshaling a document: Marshal, MarshalAppend,
+	// MarshalTo, MarshalRef, MarshalRelated, MarshalInfo, MarshalToMap and Write, and thereby by
+	// Server and by Client for request documents.
+	OnMarshal func(ctx context.Context, op Operation)
+
+	// OnUnmarshal is called by every function unmarshaling a document: Unmarshal, UnmarshalPatch,
+	// UnmarshalMany, UnmarshalWithIncluded, UnmarshalRef, UnmarshalRelationshipUpdate,
+	// UnmarshalErrors, UnmarshalFromMap, DecodeEach and Verify, by Read once the body has been read,
+	// and by Client for response documents.
+	OnUnmarshal func(ctx context.Context, op Operation)
+}
+
+// MarshalHooks reports marshal operations to the OnMarshal hook of h.
+func MarshalHooks(h Hooks) MarshalOption {
+	return func(m *Marshaler) {
+		m.onMarshal = h.OnMarshal
+	}
+}
+
+// UnmarshalHooks reports unmarshal operations to the OnUnmarshal hook of h.
+func UnmarshalHooks(h Hooks) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.onUnmarshal = h.OnUnmarshal
+	}
+}
+
+// add adds the primary resource objects, included resource objects and error objects of the
+// document d, if any, to op.
+func (op *Operation) add(d *document) {
+	if d == nil {
+		return
+	}
+
+	op.Included += len(d.Included)
+	op.Errors += len(d.Errors)
+	primary := d.DataMany
+	if !d.hasMany && d.DataOne != nil {
+		primary = []*resourceObject{d.DataOne}
+	}
+	for _, ro := range primary {
+		if op.Primary == 0 {
+			op.ResourceType = ro.Type
+		} else if ro.Type != op.ResourceType {
+			op.ResourceType = ""
+		}
+		op.Primary++
+	}
+}
+
+// observe runs the marshal operation f, which describes itself in the given Operation, and reports
+// it to the OnMarshal hook once done, whether it failed or not. Panics are recovered as errors,
+// except for aborting the response as configured by MarshalStreamFailure.
+func (m *Marshaler) observe(f func(op *Operation) error) (err error) {
+	var op Operation
+	if m.onMarshal != nil {
+		start := time.Now()
+		defer func() {
+			op.Duration, op.Err = time.Since(start), err
+			m.onMarshal(m.context(), op)
+		}()
+	}
+
+	defer func() {
+		// because we make use of reflect we must recover any panics
+		if rvr := recover(); rvr != nil {
+			if rvr == http.ErrAbortHandler {
+				err = http.ErrAbortHandler
+				panic(rvr)
+			}
+			err = recoverError(rvr)
+			return
+		}
+	}()
+
+	return f(&op)
+}
+
+// observe runs the unmarshal operation f, which describes itself in the given Operation, and
+// reports it to the OnUnmarshal hook once done, whether it failed or not. Panics are recovered as
+// errors.
+func (m *Unmarshaler) observe(f func(op *Operation) error) (err error) {
+	var op Operation
+	if m.onUnmarshal != nil {
+		start := time.Now()
+		defer func() {
+			op.Duration, op.Err = time.Since(start), err
+			m.onUnmarshal(m.context(), op)
+		}()
+	}
+
+	defer func() {
+		// because we make use of reflect we must recover any panics
+		if rvr := recover(); rvr != nil {
+			err = recoverError(rvr)
+			return
+		}
+	}()
+
+	return f(&op)
+}
+
+// countingReader is an io.Reader counting the bytes read from it, for Hooks.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
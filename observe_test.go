@@ -0,0 +1,276 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalHooks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      Operation
+		expectError bool
+	}{
+		{
+			description: "single resource",
+			given:       &articleA,
+			expect:      Operation{Size: len(`{"data":{"id":"1","type":"articles","attributes":{"title":"A"}}}`), ResourceType: "articles", Primary: 1},
+		}, {
+			description: "compound document",
+			given:       []*ArticleRelated{&articleRelatedComplete},
+			opts:        []MarshalOption{MarshalInclude(&authorA, &commentA, &commentB)},
+			expect:      Operation{ResourceType: "articles", Primary: 1, Included: 3},
+		}, {
+			description: "mixed types",
+			given:       []any{&articleA, &authorA},
+			expect:      Operation{Primary: 2},
+		}, {
+			description: "errors",
+			given:       []*Error{{Title: "A"}, {Title: "B"}},
+			expect:      Operation{Errors: 2},
+		}, {
+			description: "failure",
+			given:       &Article{},
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var ops []Operation
+			hooks := Hooks{OnMarshal: func(ctx context.Context, op Operation) {
+				is.Equal(t, "value", ctx.Value(hooksTestKey{}))
+				ops = append(ops, op)
+			}}
+			ctx := context.WithValue(context.Background(), hooksTestKey{}, "value")
+			opts := append([]MarshalOption{MarshalContext(ctx), MarshalHooks(hooks)}, tc.opts...)
+
+			b, err := Marshal(tc.given, opts...)
+			if tc.expectError {
+				is.MustError(t, err)
+			} else {
+				is.MustNoError(t, err)
+			}
+			is.MustEqual(t, 1, len(ops))
+
+			op := ops[0]
+			is.Equal(t, err, op.Err)
+			is.Equal(t, len(b), op.Size)
+			op.Duration, op.Err = 0, nil
+			if tc.expect.Size == 0 {
+				op.Size = 0
+			}
+			is.Equal(t, tc.expect, op)
+		})
+	}
+}
+
+type hooksTestKey struct{}
+
+func TestUnmarshalHooks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expect      Operation
+		expectError bool
+	}{
+		{
+			description: "many resources",
+			given:       articlesABBody,
+			expect:      Operation{ResourceType: "articles", Primary: 2},
+		}, {
+			description: "compound document",
+			given:       articleRelatedAuthorTwiceWithIncludeBody,
+			expect:      Operation{ResourceType: "articles", Primary: 2, Included: 1},
+		}, {
+			description: "invalid json",
+			given:       `{"data":`,
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var ops []Operation
+			hooks := Hooks{OnUnmarshal: func(_ context.Context, op Operation) { ops = append(ops, op) }}
+
+			var a []*ArticleRelated
+			err := Unmarshal([]byte(tc.given), &a, UnmarshalHooks(hooks))
+			if tc.expectError {
+				is.MustError(t, err)
+			} else {
+				is.MustNoError(t, err)
+			}
+			is.MustEqual(t, 1, len(ops))
+
+			op := ops[0]
+			is.Equal(t, err, op.Err)
+			op.Duration, op.Err = 0, nil
+			tc.expect.Size = len(tc.given)
+			is.Equal(t, tc.expect, op)
+		})
+	}
+}
+
+func TestWriteHooks(t *testing.T) {
+	t.Parallel()
+
+	var ops []Operation
+	hooks := Hooks{OnMarshal: func(_ context.Context, op Operation) { ops = append(ops, op) }}
+
+	w := httptest.NewRecorder()
+	err := Write(w, http.StatusOK, []*Article{&articleA, &articleB}, MarshalHooks(hooks), MarshalFlushThreshold(1))
+	is.MustNoError(t, err)
+	is.Equal(t, true, w.Flushed)
+	is.MustEqual(t, 1, len(ops))
+
+	op := ops[0]
+	op.Duration = 0
+	is.Equal(t, Operation{Size: w.Body.Len(), ResourceType: "articles", Primary: 2}, op)
+}
+
+func TestEntryPointHooks(t *testing.T) {
+	t.Parallel()
+
+	article := &ArticleRelated{ID: "1", Title: "A", Author: &authorA}
+	authorBody := `{"data":{"id":"1","type":"author"}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MediaType)
+		_, _ = w.Write([]byte(articlesABBody))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		description string
+		do          func(m MarshalOption, u UnmarshalOption) ([]byte, error)
+		expect      Operation
+		expectSize  int
+	}{
+		{
+			description: "MarshalTo",
+			do: func(m MarshalOption, _ UnmarshalOption) ([]byte, error) {
+				var buf bytes.Buffer
+				err := MarshalTo(&buf, []*Article{&articleA, &articleB}, m)
+				return buf.Bytes(), err
+			},
+			expect: Operation{ResourceType: "articles", Primary: 2},
+		}, {
+			description: "MarshalRef",
+			do: func(m MarshalOption, _ UnmarshalOption) ([]byte, error) {
+				return MarshalRef(article, "author", m)
+			},
+			expect: Operation{ResourceType: "author", Primary: 1},
+		}, {
+			description: "MarshalRelated",
+			do: func(m MarshalOption, _ UnmarshalOption) ([]byte, error) {
+				return MarshalRelated(article, "author", m)
+			},
+			expect: Operation{ResourceType: "author", Primary: 1},
+		}, {
+			description: "MarshalInfo",
+			do: func(m MarshalOption, _ UnmarshalOption) ([]byte, error) {
+				return MarshalInfo(map[string]any{"status": "ok"}, nil, m)
+			},
+		}, {
+			description: "MarshalToMap",
+			do: func(m MarshalOption, _ UnmarshalOption) ([]byte, error) {
+				_, err := MarshalToMap(&articleA, m)
+				return nil, err
+			},
+			expect: Operation{ResourceType: "articles", Primary: 1},
+		}, {
+			description: "DecodeEach",
+			do: func(_ MarshalOption, u UnmarshalOption) ([]byte, error) {
+				return nil, DecodeEach(strings.NewReader(articlesABBody), func(*Article) error { return nil }, u)
+			},
+			expect:     Operation{ResourceType: "articles", Primary: 2},
+			expectSize: len(articlesABBody),
+		}, {
+			description: "UnmarshalRef",
+			do: func(_ MarshalOption, u UnmarshalOption) ([]byte, error) {
+				return nil, UnmarshalRef([]byte(authorBody), new(ArticleRelated), "author", u)
+			},
+			expect:     Operation{ResourceType: "author", Primary: 1},
+			expectSize: len(authorBody),
+		}, {
+			description: "UnmarshalWithIncluded",
+			do: func(_ MarshalOption, u UnmarshalOption) ([]byte, error) {
+				_, err := UnmarshalWithIncluded([]byte(articleRelatedAuthorTwiceWithIncludeBody), new([]*ArticleRelated), u)
+				return nil, err
+			},
+			expect:     Operation{ResourceType: "articles", Primary: 2, Included: 1},
+			expectSize: len(articleRelatedAuthorTwiceWithIncludeBody),
+		}, {
+			description: "UnmarshalErrors",
+			do: func(_ MarshalOption, u UnmarshalOption) ([]byte, error) {
+				_, err := UnmarshalErrors([]byte(errorsSimpleStructBody), u)
+				return nil, err
+			},
+			expect:     Operation{Errors: 1},
+			expectSize: len(errorsSimpleStructBody),
+		}, {
+			description: "UnmarshalFromMap",
+			do: func(_ MarshalOption, u UnmarshalOption) ([]byte, error) {
+				return nil, UnmarshalFromMap(map[string]any{"data": nil}, new(Article), u)
+			},
+		}, {
+			description: "Verify",
+			do: func(_ MarshalOption, u UnmarshalOption) ([]byte, error) {
+				return nil, Verify([]byte(articlesABBody), u)
+			},
+			expect:     Operation{ResourceType: "articles", Primary: 2},
+			expectSize: len(articlesABBody),
+		}, {
+			description: "Client",
+			do: func(_ MarshalOption, u UnmarshalOption) ([]byte, error) {
+				_, err := List[Article](context.Background(), NewClient(srv.Client(), ClientUnmarshalOptions(u)), srv.URL)
+				return nil, err
+			},
+			expect:     Operation{ResourceType: "articles", Primary: 2},
+			expectSize: len(articlesABBody),
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var ops []Operation
+			report := func(_ context.Context, op Operation) { ops = append(ops, op) }
+			m, u := MarshalHooks(Hooks{OnMarshal: report}), UnmarshalHooks(Hooks{OnUnmarshal: report})
+
+			b, err := tc.do(m, u)
+			is.MustNoError(t, err)
+			is.MustEqual(t, 1, len(ops))
+
+			// the size of marshal operations is that of the document returned, or written
+			op := ops[0]
+			op.Duration = 0
+			tc.expect.Size = tc.expectSize + len(b)
+			is.Equal(t, tc.expect, op)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// jsonValue is a value of a json document, as visited by walkJSON.
+type jsonValue struct {
+	// pointer is the JSON pointer to the value.
+	pointer string
+
+	// name is the name of the object member holding the value, if any.
+	name string
+
+	// start and end are the byte offsets of the start and end of the value.
+	start, end int64
+}
+
+// walkJSON calls visit with every value of the json document data, in the order the values end.
+// If data is invalid json, the JSON pointer to the innermost value containing the syntax error is
+// returned along with the error.
+func walkJSON(data []byte, visit func(v jsonValue)) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var (
+		failed string
+		walk   func(pointer, name string) error
+	)
+	walk = func(pointer, name string) (err error) {
+		defer func() {
+			if err != nil && failed == "" {
+				failed = pointer
+			}
+		}()
+
+		// separators are only consumed along with the next token
+		start := dec.InputOffset()
+		for start < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[start]) >= 0 {
+			start++
+		}
+
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				name, _ := key.(string)
+				if err := walk(pointer+"/"+escapePointerToken(name), name); err != nil {
+					return err
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(pointer+"/"+strconv.Itoa(i), ""); err != nil {
+					return err
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+		}
+
+		visit(jsonValue{pointer: pointer, name: name, start: start, end: dec.InputOffset()})
+		return nil
+	}
+
+	err := walk("", "")
+	if err != nil {
+		return failed, err
+	}
+	return "", nil
+}
+
+// offsetOf returns the byte offset of the value of the json document data the given JSON pointer
+// refers to, or 0 if there is none.
+func offsetOf(data []byte, pointer string) int64 {
+	var offset int64
+	_, _ = walkJSON(data, func(v jsonValue) {
+		if v.pointer == pointer {
+			offset = v.start
+		}
+	})
+	return offset
+}
+
+// locatable is implemented by errors carrying the byte offset of the offending document member.
+type locatable interface {
+	pointerError
+	locate(f func(pointer string) int64)
+}
+
+// locateErrors sets the byte offsets of the errors in err's chain to the offsets of the members
+// of the json document data their JSON pointers refer to. A bare error decoding data is wrapped in
+// a DocumentError giving the position of the offending value, if it can be found.
+func locateErrors(err error, data []byte) error {
+	if err == nil {
+		return nil
+	}
+
+	var le locatable
+	if !errors.As(err, &le) && isJSONDecodeError(err) {
+		pointer, offset, ok := locateJSONError(err, data)
+		if !ok {
+			return err
+		}
+		return &DocumentError{Pointer: pointer, Offset: offset, Err: err}
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if le, ok := e.(locatable); ok {
+			le.locate(func(pointer string) int64 {
+				if pointer == "" {
+					return 0
+				}
+				return offsetOf(data, pointer)
+			})
+		}
+	}
+	return err
+}
+
+// locateJSONError returns the JSON pointer to and byte offset of the value of the json document
+// data causing the decoding error err, if it can be found.
+func locateJSONError(err error, data []byte) (string, int64, bool) {
+	// the offsets of type errors are relative to the values whose json.Unmarshaler failed, so
+	// look for the first member of the given name and kind instead
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		name := typeErr.Field[strings.LastIndexByte(typeErr.Field, '.')+1:]
+
+		var found *jsonValue
+		_, _ = walkJSON(data, func(v jsonValue) {
+			if v.name == name && jsonKindOf(data[v.start:v.end]) == typeErr.Value && (found == nil || v.start < found.start) {
+				found = &v
+			}
+		})
+		if found != nil {
+			return found.pointer, found.start, true
+		}
+		return "", 0, false
+	}
+
+	offset, pointer, ok := syntaxErrorPosition(err)
+	if !ok {
+		return "", 0, false
+	}
+	if failed, walkErr := walkJSON(data, func(jsonValue) {}); pointer == "" && walkErr != nil {
+		pointer = failed
+	}
+	return pointer, offset, true
+}
@@ -0,0 +1,118 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestUnmarshalErrorPositions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description   string
+		given         string
+		expectPointer string
+		expectOffset  int64
+	}{
+		{
+			description:   "invalid attribute",
+			given:         `{"data":{"type":"articles","id":"1","attributes":{"title":1}}}`,
+			expectPointer: "/data/attributes/title",
+			expectOffset:  58,
+		}, {
+			description:   "invalid type",
+			given:         `{"data":{"type":1,"id":"1"}}`,
+			expectPointer: "/data/type",
+			expectOffset:  16,
+		}, {
+			description:   "invalid included id",
+			given:         `{"data":{"type":"articles","id":"1"},"included":[{"type":"comments","id":1}]}`,
+			expectPointer: "/included/0/id",
+			expectOffset:  73,
+		}, {
+			description:   "invalid linkage",
+			given:         `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":{"type":"author","id":1}}}}}`,
+			expectPointer: "/data/relationships/author/data/id",
+			expectOffset:  92,
+		}, {
+			description:   "missing linkage type",
+			given:         `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":{"id":"1"}}}}}`,
+			expectPointer: "/data/relationships/author",
+			expectOffset:  62,
+		}, {
+			description:   "type conflict",
+			given:         `{"data":{"type":"people","id":"1"}}`,
+			expectPointer: "/data/type",
+			expectOffset:  16,
+		}, {
+			description:   "syntax error",
+			given:         `{"data":{"type":"articles","id":"1","attributes":{"title":"A",}}}`,
+			expectPointer: "/data/attributes",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var a ArticleRelated
+			err := Unmarshal([]byte(tc.given), &a)
+			is.MustError(t, err)
+
+			objects := ErrorObjects(err)
+			is.MustEqual(t, 1, len(objects))
+			is.Equal(t, &ErrorSource{Pointer: tc.expectPointer}, objects[0].Source)
+
+			var (
+				offset int64
+				fe     *FieldError
+				re     *ResourceError
+				de     *DocumentError
+			)
+			switch {
+			case errors.As(err, &fe):
+				offset = fe.Offset
+			case errors.As(err, &re):
+				offset = re.Offset
+			case errors.As(err, &de):
+				offset = de.Offset
+			}
+			if tc.expectOffset == 0 {
+				// syntax errors are reported at slightly different offsets by the json backends
+				is.Equal(t, true, offset > 0)
+				return
+			}
+			is.Equal(t, tc.expectOffset, offset)
+		})
+	}
+}
+
+func TestWalkJSON(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`{"a": [1, {"b/c": "d"}], "e" : null}`)
+
+	var values []jsonValue
+	failed, err := walkJSON(data, func(v jsonValue) {
+		values = append(values, v)
+	})
+	is.MustNoError(t, err)
+	is.Equal(t, "", failed)
+	is.Equal(t, []jsonValue{
+		{pointer: "/a/0", start: 7, end: 8},
+		{pointer: "/a/1/b~1c", name: "b/c", start: 18, end: 21},
+		{pointer: "/a/1", start: 10, end: 22},
+		{pointer: "/a", name: "a", start: 6, end: 23},
+		{pointer: "/e", name: "e", start: 31, end: 35},
+		{pointer: "", start: 0, end: 36},
+	}, values)
+
+	failed, err = walkJSON([]byte(`{"a":[1,}`), func(jsonValue) {})
+	is.Equal(t, true, err != nil)
+	is.Equal(t, "/a/1", failed)
+}
@@ -0,0 +1,98 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Precompile analyzes the struct layouts of the given resource types, along with the types of their
+// relationships, so that their struct tags are parsed at startup rather than by the first Marshal
+// or Unmarshal. The types can be given as values, nil pointers (e.g. (*Article)(nil)) or slices.
+//
+// Precompile returns an error if the struct tags of any of the types are invalid, including types
+// without or with several primary fields and type, attribute, relationship and extension names
+// which aren't valid member names, so that misconfigured resource types can fail fast at startup:
+//
+//	if err := jsonapi.Precompile((*Article)(nil), (*Comment)(nil)); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Calling Precompile is optional, and safe for concurrent use.
+func Precompile(types ...any) error {
+	compiled := make(map[reflect.Type]bool)
+	for _, v := range types {
+		if v == nil {
+			return &TypeError{Actual: "nil", Expected: []string{"struct"}}
+		}
+		if err := precompile(reflect.TypeOf(v), compiled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// precompile analyzes the resource type t and the types of its relationships, skipping those which
+// are already compiled.
+func precompile(t reflect.Type, compiled map[reflect.Type]bool) error {
+	t = derefType(t)
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = derefType(t.Elem())
+	}
+	if compiled[t] {
+		return nil
+	}
+	compiled[t] = true
+
+	if _, err := schemaOf(t); err != nil {
+		return fmt.Errorf("%s: %w", t, err)
+	}
+
+	var primaries int
+	related := make([]reflect.Type, 0)
+	for _, field := range getFlattenedFields(reflect.New(t).Interface()) {
+		// the tags were validated by schemaOf, which parsed and cached them
+		tag, _ := parseJSONAPITag(field.f)
+		if tag == nil {
+			continue
+		}
+		switch tag.directive {
+		case primary:
+			primaries++
+			if !isValidMemberName(tag.resourceType, defaultValidation) {
+				return &MemberNameValidationError{MemberName: tag.resourceType, Field: structFieldName(t, field.f)}
+			}
+		case attribute, relationship:
+			if name, ok, _ := memberName(field.f, nil); ok && !isValidMemberName(name, defaultValidation) {
+				return &MemberNameValidationError{MemberName: name, Field: structFieldName(t, field.f)}
+			}
+			if tag.directive != relationship {
+				break
+			}
+			if _, idsOnly, _ := parseRelTypeTag(field.f); !idsOnly {
+				related = append(related, relatedFieldType(field.f.Type))
+			}
+		case extension:
+			// the namespace is registered when marshaling, so only its name can be checked here
+			name, _, _ := parseJSONTag(field.f)
+			namespace, _, _ := strings.Cut(name, ":")
+			if !(extensionNamespaces{namespace: true}).isExtensionMemberName(name, defaultValidation) {
+				return &MemberNameValidationError{MemberName: name, Field: structFieldName(t, field.f)}
+			}
+		}
+	}
+	switch {
+	case primaries == 0:
+		return fmt.Errorf("%s: %w", t, ErrMissingPrimaryField)
+	case primaries > 1:
+		return fmt.Errorf("%s: %w", t, ErrUnmarshalDuplicatePrimaryField)
+	}
+
+	for _, rt := range related {
+		if err := precompile(rt, compiled); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
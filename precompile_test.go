@@ -0,0 +1,104 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestPrecompile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       []any
+		expectError error
+	}{
+		{
+			description: "no types",
+		}, {
+			description: "values, pointers and slices",
+			given:       []any{Article{}, (*ArticleRelated)(nil), []*Comment{}, (*[]Author)(nil)},
+		}, {
+			description: "relationships to the same type",
+			given:       []any{(*ArticleRelated)(nil), (*ArticleRelated)(nil)},
+		}, {
+			description: "ids only relationship",
+			given:       []any{(*ArticleTagged)(nil)},
+		}, {
+			description: "nil",
+			given:       []any{nil},
+			expectError: &TypeError{Actual: "nil", Expected: []string{"struct"}},
+		}, {
+			description: "not a struct",
+			given:       []any{1},
+			expectError: fmt.Errorf("int: %w", &TypeError{Actual: "int", Expected: []string{"struct"}}),
+		}, {
+			description: "missing primary",
+			given:       []any{(*Metadata)(nil)},
+			expectError: fmt.Errorf("jsonapi.Metadata: %w", ErrMissingPrimaryField),
+		}, {
+			description: "relationship to a type without primary",
+			given:       []any{(*articleUnidentifiedAuthor)(nil)},
+			expectError: fmt.Errorf("jsonapi.articleUnidentifiedAuthor: %w", ErrMissingPrimaryField),
+		}, {
+			description: "duplicate primary",
+			given:       []any{(*ArticleDoubleID)(nil)},
+			expectError: fmt.Errorf("jsonapi.ArticleDoubleID: %w", ErrUnmarshalDuplicatePrimaryField),
+		}, {
+			description: "invalid reltype",
+			given:       []any{(*ArticleInvalidRelType)(nil)},
+			expectError: fmt.Errorf("jsonapi.ArticleInvalidRelType: %w", &TagError{TagName: "reltype", Field: "AuthorID", Reason: "only valid on relationship fields of type string or []string"}),
+		}, {
+			description: "invalid related type",
+			given:       []any{(*ArticleWithInvalidRelated)(nil)},
+			expectError: fmt.Errorf("jsonapi.ArticleInvalidTag: %w", &TagError{TagName: "jsonapi", Field: "Title", Reason: "readonly and writeonly are mutually exclusive"}),
+		}, {
+			description: "invalid type name",
+			given:       []any{(*AuthorWithInvalidTypeName)(nil)},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor", Field: "AuthorWithInvalidTypeName.ID"},
+		}, {
+			description: "invalid extension name",
+			given:       []any{(*ArticleWithInvalidExtensionName)(nil)},
+			expectError: &MemberNameValidationError{MemberName: "ver-sion:id", Field: "ArticleWithInvalidExtensionName.VersionID"},
+		}, {
+			description: "invalid attribute name",
+			given:       []any{(*AuthorWithInvalidAttributeName)(nil)},
+			expectError: &MemberNameValidationError{MemberName: "na%me", Field: "AuthorWithInvalidAttributeName.Name"},
+		}, {
+			description: "invalid relationship name",
+			given:       []any{(*ArticleWithInvalidRelationshipName)(nil)},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor", Field: "ArticleWithInvalidRelationshipName.Author"},
+		}, {
+			description: "invalid related type name",
+			given:       []any{(*ArticleWithInvalidRelationshipTypeName)(nil)},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor", Field: "AuthorWithInvalidTypeName.ID"},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			err := Precompile(tc.given...)
+			is.EqualError(t, tc.expectError, err)
+		})
+	}
+}
+
+func TestPrecompileErrorsIs(t *testing.T) {
+	t.Parallel()
+
+	err := Precompile((*Metadata)(nil))
+	is.Equal(t, true, errors.Is(err, ErrMissingPrimaryField))
+}
+
+// articleUnidentifiedAuthor has a relationship to a type without primary field.
+type articleUnidentifiedAuthor struct {
+	ID     string    `jsonapi:"primary,articles"`
+	Author *Metadata `jsonapi:"relationship" json:"author"`
+}
@@ -0,0 +1,212 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProblemMediaType is the media type of problem details documents as defined by
+// https://www.rfc-editor.org/rfc/rfc7807.
+const ProblemMediaType = "application/problem+json"
+
+// problemDetailsMembers are the members of problem details objects defined by RFC 7807.
+var problemDetailsMembers = map[string]bool{
+	"type":     true,
+	"title":    true,
+	"status":   true,
+	"detail":   true,
+	"instance": true,
+}
+
+// Problem is a problem details object as defined by https://www.rfc-editor.org/rfc/rfc7807, for
+// services exchanging errors with APIs which use application/problem+json instead of JSON:API error
+// objects. Problems can be converted to and from error objects with ErrorObject and Error.Problem.
+//
+// Problem implements the error interface, so it can be returned by handlers, and is converted to an
+// error object by ErrorObjects.
+type Problem struct {
+	// Type is a URI reference identifying the problem type. It is "about:blank" if empty.
+	Type string `json:"type,omitempty"`
+
+	// Title is a short, human-readable summary of the problem type.
+	Title string `json:"title,omitempty"`
+
+	// Status is the HTTP status code of the problem, or zero if unknown.
+	Status int `json:"status,omitempty"`
+
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is a URI reference identifying this occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+
+	// Extensions holds the extension members of the problem by name. Members named like the above
+	// are ignored.
+	Extensions map[string]any `json:"-"`
+}
+
+// Error implements the error interface.
+func (p *Problem) Error() string {
+	return fmt.Sprintf("%s: %s", p.Title, p.Detail)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type alias Problem
+	b, err := json.Marshal((*alias)(p))
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := make(map[string]any, len(p.Extensions))
+	for name, value := range p.Extensions {
+		if !problemDetailsMembers[name] {
+			extensions[name] = value
+		}
+	}
+	return appendMembers(b, extensions)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	type alias Problem
+	if err := json.Unmarshal(data, (*alias)(p)); err != nil {
+		return err
+	}
+
+	var members map[string]any
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	p.Extensions = nil
+	for name, value := range members {
+		if problemDetailsMembers[name] {
+			continue
+		}
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]any)
+		}
+		p.Extensions[name] = value
+	}
+	return nil
+}
+
+// ErrorObject converts p to an error object. The type and instance of the problem become the type
+// and about links of the error object, and its code, id and source are given by the extension
+// members of the same name. Other extension members become members of the error object's meta.
+func (p *Problem) ErrorObject() *Error {
+	e := &Error{Title: p.Title, Detail: p.Detail}
+	if p.Status != 0 {
+		e.Status = Status(p.Status)
+	}
+	links := &ErrorLink{}
+	if p.Type != "" && p.Type != "about:blank" {
+		links.Type = p.Type
+	}
+	if p.Instance != "" {
+		links.About = p.Instance
+	}
+	if links.Type != nil || links.About != nil {
+		e.Links = links
+	}
+
+	meta := make(map[string]any)
+	for name, value := range p.Extensions {
+		switch name {
+		case "code":
+			if code, ok := value.(string); ok {
+				e.Code = code
+				continue
+			}
+		case "id":
+			if id, ok := value.(string); ok {
+				e.ID = id
+				continue
+			}
+		case "source":
+			if source, ok := problemSource(value); ok {
+				e.Source = source
+				continue
+			}
+		}
+		meta[name] = value
+	}
+	if len(meta) > 0 {
+		e.Meta = meta
+	}
+
+	return e
+}
+
+// problemSource returns the error source held by the source extension member of a problem.
+func problemSource(value any) (*ErrorSource, bool) {
+	if source, ok := value.(*ErrorSource); ok {
+		return source, true
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var source ErrorSource
+	if err := json.Unmarshal(b, &source); err != nil {
+		return nil, false
+	}
+	return &source, true
+}
+
+// Problem converts e to a problem details object, reversing Problem.ErrorObject. The href of the
+// type and about links of e become the type and instance of the problem, and its code, id and
+// source become extension members of the same name. The members of its meta, if it is a map or
+// struct, become extension members as well, except those named like the members above.
+func (e *Error) Problem() *Problem {
+	p := &Problem{Title: e.Title, Detail: e.Detail}
+	if e.Status != nil {
+		p.Status = *e.Status
+	}
+	if e.Links != nil {
+		p.Type = LinkHref(e.Links.Type)
+		p.Instance = LinkHref(e.Links.About)
+	}
+
+	extensions := make(map[string]any)
+	if meta, ok := problemMeta(e.Meta); ok {
+		for name, value := range meta {
+			if !problemDetailsMembers[name] && name != "code" && name != "id" && name != "source" {
+				extensions[name] = value
+			}
+		}
+	}
+	if e.Code != "" {
+		extensions["code"] = e.Code
+	}
+	if e.ID != "" {
+		extensions["id"] = e.ID
+	}
+	if e.Source != nil {
+		extensions["source"] = e.Source
+	}
+	if len(extensions) > 0 {
+		p.Extensions = extensions
+	}
+
+	return p
+}
+
+// problemMeta returns the members of the given error object meta, if it is a map or struct.
+func problemMeta(meta any) (map[string]any, bool) {
+	if meta == nil || checkMeta(meta) != nil {
+		return nil, false
+	}
+	if m, ok := meta.(map[string]any); ok {
+		return m, true
+	}
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return nil, false
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, false
+	}
+	return m, true
+}
@@ -0,0 +1,122 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestErrorProblem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       *Error
+		expect      string
+	}{
+		{
+			description: "empty",
+			given:       &Error{},
+			expect:      `{}`,
+		}, {
+			description: "status, title and detail",
+			given:       &Error{Status: Status(http.StatusNotFound), Title: "Not Found", Detail: "article 1"},
+			expect:      `{"title":"Not Found","status":404,"detail":"article 1"}`,
+		}, {
+			description: "links",
+			given:       &errorsWithTypeLink,
+			expect:      `{"type":"https://example.com/errors/types/validation","instance":"https://example.com/errors/C","code":"C"}`,
+		}, {
+			description: "id, source and meta",
+			given:       &Error{ID: "1", Source: &ErrorSource{Pointer: "/data/attributes/title"}, Meta: map[string]any{"balance": 30, "code": "ignored"}},
+			expect:      `{"balance":30,"id":"1","source":{"pointer":"/data/attributes/title"}}`,
+		}, {
+			description: "struct meta",
+			given:       &Error{Meta: struct{ Balance int }{30}},
+			expect:      `{"Balance":30}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := json.Marshal(tc.given.Problem())
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestProblemErrorObject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expect      *Error
+	}{
+		{
+			description: "empty",
+			given:       `{}`,
+			expect:      &Error{},
+		}, {
+			description: "about:blank type",
+			given:       `{"type":"about:blank","title":"Not Found","status":404}`,
+			expect:      &Error{Status: Status(http.StatusNotFound), Title: "Not Found"},
+		}, {
+			description: "type and instance",
+			given:       `{"type":"https://example.com/probs/out-of-credit","instance":"/account/12345/msgs/abc"}`,
+			expect:      &Error{Links: &ErrorLink{Type: "https://example.com/probs/out-of-credit", About: "/account/12345/msgs/abc"}},
+		}, {
+			description: "extension members",
+			given:       `{"detail":"D","code":"C","id":"1","source":{"parameter":"sort"},"balance":30}`,
+			expect:      &Error{Detail: "D", Code: "C", ID: "1", Source: &ErrorSource{Parameter: "sort"}, Meta: map[string]any{"balance": float64(30)}},
+		}, {
+			description: "non-string code",
+			given:       `{"code":1}`,
+			expect:      &Error{Meta: map[string]any{"code": float64(1)}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var p Problem
+			is.MustNoError(t, json.Unmarshal([]byte(tc.given), &p))
+			is.Equal(t, tc.expect, p.ErrorObject())
+		})
+	}
+}
+
+func TestProblemRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	given := `{"type":"https://example.com/probs/out-of-credit","title":"Forbidden","status":403,"detail":"D","instance":"/account/1","balance":30,"code":"C"}`
+
+	var p Problem
+	is.MustNoError(t, json.Unmarshal([]byte(given), &p))
+	b, err := json.Marshal(p.ErrorObject().Problem())
+	is.MustNoError(t, err)
+	is.EqualJSON(t, given, string(b))
+}
+
+func TestProblemErrorObjects(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("charge: %w", &Problem{Title: "Forbidden", Status: http.StatusForbidden, Detail: "out of credit"})
+	is.Equal(t, []*Error{{Status: Status(http.StatusForbidden), Title: "Forbidden", Detail: "out of credit"}}, ErrorObjects(err))
+
+	var p *Problem
+	is.MustEqual(t, true, errors.As(err, &p))
+	is.Equal(t, "Forbidden: out of credit", p.Error())
+}
@@ -0,0 +1,238 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"strings"
+)
+
+// ProfileHandler implements a profile as defined by https://jsonapi.org/format/1.1/#profiles, adding
+// and interpreting the members of resource object meta it defines, e.g. a timestamps profile adding
+// created and updated members.
+//
+// Profiles given by MarshalProfiles are declared by the profile member of the top-level jsonapi
+// object, and by the profile media type parameter of the Content-Type header set by Write.
+type ProfileHandler struct {
+	// URI identifies the profile, and must be an absolute URI.
+	URI string
+
+	// Members are the members of resource objects handled by the profile, ProfileMeta by default.
+	Members ProfileMembers
+
+	// MarshalResource, if set, returns members added to the meta, or attributes, of the resource
+	// object of the primary or included resource v, overriding members of the same name.
+	MarshalResource func(ctx context.Context, v any) (map[string]any, error)
+
+	// UnmarshalResource, if set, is called with the meta, or attributes, of the resource object of
+	// each resource v unmarshaled, or an empty map if it has none, once the fields of v are set.
+	// Numbers are decoded as json.Number, so that they keep their precision.
+	UnmarshalResource func(ctx context.Context, v any, members map[string]any) error
+
+	// CheckResource, if set, is called like UnmarshalResource but before any field of v is set, so
+	// that resources can be rejected without being modified, e.g. stale updates applied to stored
+	// resources by UnmarshalPatch.
+	CheckResource func(ctx context.Context, v any, members map[string]any) error
+}
+
+// ProfileMembers are the members of resource objects handled by a ProfileHandler.
+type ProfileMembers int
+
+const (
+	// ProfileMeta handles the members of the meta of resource objects.
+	ProfileMeta ProfileMembers = iota
+
+	// ProfileAttributes handles the attributes of resource objects.
+	ProfileAttributes
+)
+
+// MarshalProfiles applies the given profiles to documents when marshaling.
+func MarshalProfiles(profiles ...*ProfileHandler) MarshalOption {
+	return func(m *Marshaler) {
+		m.profiles = append(m.profiles, profiles...)
+	}
+}
+
+// UnmarshalProfiles applies the given profiles to documents when unmarshaling.
+func UnmarshalProfiles(profiles ...*ProfileHandler) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.profiles = append(m.profiles, profiles...)
+	}
+}
+
+// marshalProfiles adds the members given by the profiles of m to the meta or attributes of ro, the
+// resource object of the resource v.
+func (m *Marshaler) marshalProfiles(v any, ro *resourceObject) error {
+	members := make(map[string]any)
+	for _, p := range m.profiles {
+		if p.MarshalResource == nil {
+			continue
+		}
+		pm, err := p.MarshalResource(m.context(), v)
+		if err != nil {
+			return &ResourceError{Code: CodeInvalidResource, Type: ro.Type, ID: ro.ID, Err: err}
+		}
+		if p.Members == ProfileAttributes {
+			if ro.Attributes == nil && len(pm) > 0 {
+				ro.Attributes = make(map[string]any, len(pm))
+			}
+			for k, v := range pm {
+				ro.Attributes[k] = v
+			}
+			continue
+		}
+		for k, v := range pm {
+			members[k] = v
+		}
+	}
+
+	meta, err := mergeMeta(ro.Meta, members)
+	if err != nil {
+		return err
+	}
+	ro.Meta = meta
+	return nil
+}
+
+// unmarshalProfiles calls the UnmarshalResource handlers of the profiles of m with the meta or
+// attributes of ro, which has been unmarshaled into v.
+func (m *Unmarshaler) unmarshalProfiles(v any, ro *resourceObject) error {
+	return m.callProfiles(v, ro, func(p *ProfileHandler) func(context.Context, any, map[string]any) error {
+		return p.UnmarshalResource
+	})
+}
+
+// checkProfiles calls the CheckResource handlers of the profiles of m with the meta or attributes
+// of ro, which is about to be unmarshaled into v.
+func (m *Unmarshaler) checkProfiles(v any, ro *resourceObject) error {
+	return m.callProfiles(v, ro, func(p *ProfileHandler) func(context.Context, any, map[string]any) error {
+		return p.CheckResource
+	})
+}
+
+// callProfiles calls the handler of each profile of m given by handler, if any, with the meta or
+// attributes of ro.
+func (m *Unmarshaler) callProfiles(v any, ro *resourceObject, handler func(p *ProfileHandler) func(context.Context, any, map[string]any) error) error {
+	for _, p := range m.profiles {
+		h := handler(p)
+		if h == nil {
+			continue
+		}
+		members, err := ro.profileMembers(p.Members)
+		if err == nil {
+			err = h(m.context(), v, members)
+		}
+		if err != nil {
+			return &ResourceError{Code: CodeInvalidResource, Type: ro.Type, ID: ro.ID, Err: err}
+		}
+	}
+	return nil
+}
+
+// profileMembers returns the given members of the unmarshaled resource object ro.
+func (ro *resourceObject) profileMembers(pm ProfileMembers) (map[string]any, error) {
+	if pm == ProfileAttributes {
+		return decodeMembers(ro.rawAttributes)
+	}
+	if ro.raw != nil {
+		var aux struct {
+			Meta rawValue `json:"meta"`
+		}
+		if err := json.Unmarshal(ro.raw, &aux); err != nil {
+			return nil, err
+		}
+		return decodeMembers(aux.Meta)
+	}
+	if ro.Meta == nil {
+		return make(map[string]any), nil
+	}
+	b, err := json.Marshal(ro.Meta)
+	if err != nil {
+		return nil, err
+	}
+	return decodeMembers(b)
+}
+
+// decodeMembers decodes the members of the json object b, which may be empty or null, decoding
+// numbers as json.Number.
+func decodeMembers(b []byte) (map[string]any, error) {
+	members := make(map[string]any)
+	if len(b) == 0 || string(b) == "null" {
+		return members, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// profileURIs returns the URIs of the profiles of m.
+func (m *Marshaler) profileURIs() []string {
+	uris := make([]string, len(m.profiles))
+	for i, p := range m.profiles {
+		uris[i] = p.URI
+	}
+	return uris
+}
+
+// documentJSONAPIObject returns the top-level jsonapi object given by MarshalJSONAPI or
+// MarshalJSONAPIObject, declaring the extensions applied by m, e.g. by MarshalStringTable, and the
+// profiles given by MarshalProfiles, if any.
+func (m *Marshaler) documentJSONAPIObject() *JSONAPIObject {
+	extensions := m.extensionURIs()
+	if len(m.profiles) == 0 && len(extensions) == 0 {
+		return m.jsonAPI
+	}
+
+	o := JSONAPIObject{Version: "1.1"}
+	if m.jsonAPI != nil {
+		o = *m.jsonAPI
+		o.Ext = append([]string{}, m.jsonAPI.Ext...)
+		o.Profile = append([]string{}, m.jsonAPI.Profile...)
+		if o.Version == "1.0" {
+			// extensions and profiles require JSON:API 1.1, while MarshalJSONAPI defaults to 1.0
+			o.Version = "1.1"
+		}
+	}
+	for _, uri := range extensions {
+		if !containsString(o.Ext, uri) {
+			o.Ext = append(o.Ext, uri)
+		}
+	}
+	for _, uri := range m.profileURIs() {
+		if !containsString(o.Profile, uri) {
+			o.Profile = append(o.Profile, uri)
+		}
+	}
+	return &o
+}
+
+// contentType returns the Content-Type of documents, which is the JSON:API media type along with the
+// ext and profile parameters listing the extensions applied by m and the profiles given by
+// MarshalProfiles, if any.
+func (m *Marshaler) contentType() string {
+	params := make(map[string]string)
+	if extensions := m.extensionURIs(); len(extensions) > 0 {
+		params["ext"] = strings.Join(extensions, " ")
+	}
+	if len(m.profiles) > 0 {
+		params["profile"] = strings.Join(m.profileURIs(), " ")
+	}
+	if len(params) == 0 {
+		return MediaType
+	}
+	return mime.FormatMediaType(MediaType, params)
+}
+
+// containsString returns true if values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package jsonapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// ArticleStamped is an article with timestamps read and written by timestampsProfile.
+type ArticleStamped struct {
+	ID      string    `jsonapi:"primary,articles"`
+	Title   string    `jsonapi:"attribute" json:"title"`
+	Meta    any       `jsonapi:"meta"`
+	Created time.Time `json:"-"`
+}
+
+var timestampsProfile = &ProfileHandler{
+	URI: "https://example.com/profiles/timestamps",
+	MarshalResource: func(_ context.Context, v any) (map[string]any, error) {
+		a, ok := v.(*ArticleStamped)
+		if !ok {
+			return nil, nil
+		}
+		if a.Created.IsZero() {
+			return nil, errors.New("missing creation time")
+		}
+		return map[string]any{"created": a.Created.Format(time.RFC3339)}, nil
+	},
+	UnmarshalResource: func(_ context.Context, v any, meta map[string]any) error {
+		a, ok := v.(*ArticleStamped)
+		if !ok {
+			return nil
+		}
+		created, _ := meta["created"].(string)
+		t, err := time.Parse(time.RFC3339, created)
+		if err != nil {
+			return err
+		}
+		a.Created = t
+		return nil
+	},
+}
+
+func TestMarshalProfiles(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(1989, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+		expectError bool
+	}{
+		{
+			description: "resource meta",
+			given:       &ArticleStamped{ID: "1", Title: "A", Created: created},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"meta":{"created":"1989-06-15T00:00:00Z"}},"jsonapi":{"version":"1.1","profile":["https://example.com/profiles/timestamps"]}}`,
+		}, {
+			description: "merged resource meta",
+			given:       &ArticleStamped{ID: "1", Title: "A", Meta: map[string]any{"views": 10, "created": "now"}, Created: created},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"meta":{"created":"1989-06-15T00:00:00Z","views":10}},"jsonapi":{"version":"1.1","profile":["https://example.com/profiles/timestamps"]}}`,
+		}, {
+			description: "jsonapi object",
+			given:       &articleA,
+			opts:        []MarshalOption{MarshalJSONAPI(map[string]any{"a": 1})},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"jsonapi":{"version":"1.1","profile":["https://example.com/profiles/timestamps"],"meta":{"a":1}}}`,
+		}, {
+			description: "handler error",
+			given:       &ArticleStamped{ID: "1", Title: "A"},
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given, append(tc.opts, MarshalProfiles(timestampsProfile))...)
+			if tc.expectError {
+				is.MustError(t, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestUnmarshalProfiles(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"meta":{"created":"1989-06-15T00:00:00Z"}}}`
+
+	var a ArticleStamped
+	err := Unmarshal([]byte(body), &a, UnmarshalProfiles(timestampsProfile))
+	is.MustNoError(t, err)
+	is.Equal(t, time.Date(1989, 6, 15, 0, 0, 0, 0, time.UTC), a.Created)
+
+	err = Unmarshal([]byte(`{"data":{"id":"1","type":"articles","attributes":{"title":"A"}}}`), &a, UnmarshalProfiles(timestampsProfile))
+	var re *ResourceError
+	is.Equal(t, true, errors.As(err, &re))
+}
+
+func TestWriteProfiles(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	err := Write(rec, http.StatusOK, &articleA, MarshalProfiles(timestampsProfile, &ProfileHandler{URI: "https://example.com/profiles/other"}))
+	is.MustNoError(t, err)
+	is.Equal(t, `application/vnd.api+json; profile="https://example.com/profiles/timestamps https://example.com/profiles/other"`, rec.Header().Get("Content-Type"))
+}
@@ -0,0 +1,328 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// familyQueryRegex matches the names of the query parameter families defined by the specification,
+// e.g. "page[size]", and filter parameters with an operator, e.g. "filter[views][gt]".
+var familyQueryRegex = regexp.MustCompile(`^(fields|page|filter)\[([^\[\]]+)\](?:\[([^\[\]]+)\])?$`)
+
+// SortField is a sort field as defined by https://jsonapi.org/format/#fetching-sorting.
+type SortField struct {
+	// Field is the name of the sort field, e.g. "title" or "author.name".
+	Field string
+
+	// Descending is true if the sort field was prefixed with "-".
+	Descending bool
+}
+
+// String returns the sort field as given in the sort query parameter.
+func (f SortField) String() string {
+	if f.Descending {
+		return "-" + f.Field
+	}
+	return f.Field
+}
+
+// Query holds the query parameters of a request as defined by https://jsonapi.org/format/#fetching.
+type Query struct {
+	// Include holds the dot separated include paths of the include parameter, as defined by
+	// https://jsonapi.org/format/#fetching-includes.
+	Include []string
+
+	// Fields holds the sparse fieldsets of the fields[type] parameters by resource type, as defined
+	// by https://jsonapi.org/format/#fetching-sparse-fieldsets.
+	Fields map[string][]string
+
+	// Sort holds the sort fields of the sort parameter, as defined by
+	// https://jsonapi.org/format/#fetching-sorting.
+	Sort []SortField
+
+	// Page holds the page[name] parameters by name, as defined by
+	// https://jsonapi.org/format/#fetching-pagination.
+	Page map[string]string
+
+	// Filter holds the filter[name] parameters by name, as defined by
+	// https://jsonapi.org/format/#fetching-filtering. Parameters with an operator such as
+	// filter[views][gt] are held by name and operator, e.g. "views[gt]". Use ParseFilter to parse
+	// them into a filter expression.
+	Filter map[string]string
+
+	// Values are the query parameters the Query was parsed from.
+	Values url.Values
+}
+
+// newQueryParameterError creates a 400 (Bad Request) error for an invalid query parameter.
+func newQueryParameterError(parameter, detail string) *Error {
+	return &Error{
+		Status: Status(http.StatusBadRequest),
+		Title:  http.StatusText(http.StatusBadRequest),
+		Detail: detail,
+		Source: &ErrorSource{Parameter: parameter},
+	}
+}
+
+// splitQueryList splits a comma separated query parameter value, returning an error if an element
+// is empty.
+func splitQueryList(parameter, value string) ([]string, error) {
+	elements := strings.Split(value, ",")
+	for _, e := range elements {
+		if e == "" {
+			return nil, newQueryParameterError(parameter, fmt.Sprintf("The %s parameter must not contain empty elements.", parameter))
+		}
+	}
+	return elements, nil
+}
+
+// ParseQuery parses the query parameters defined by the specification.
+//
+// A 400 (Bad Request) *Error is returned if a parameter is malformed, or if a parameter name
+// consists of lowercase letters a-z only but is not defined by the specification, as required by
+// https://jsonapi.org/format/#query-parameters-custom.
+func ParseQuery(values url.Values) (*Query, error) {
+	q := &Query{
+		Include: make([]string, 0),
+		Fields:  make(map[string][]string),
+		Sort:    make([]SortField, 0),
+		Page:    make(map[string]string),
+		Filter:  make(map[string]string),
+		Values:  values,
+	}
+
+	// parameters are parsed in order, so that the same error is returned for the same query
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := values.Get(name)
+
+		switch name {
+		case "include":
+			if value == "" {
+				continue
+			}
+			paths, err := splitQueryList(name, value)
+			if err != nil {
+				return nil, err
+			}
+			q.Include = paths
+			continue
+		case "sort":
+			if value == "" {
+				continue
+			}
+			fields, err := splitQueryList(name, value)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range fields {
+				sf := SortField{Field: strings.TrimPrefix(f, "-"), Descending: strings.HasPrefix(f, "-")}
+				if sf.Field == "" {
+					return nil, newQueryParameterError(name, "The sort parameter must not contain empty sort fields.")
+				}
+				q.Sort = append(q.Sort, sf)
+			}
+			continue
+		}
+
+		matches := familyQueryRegex.FindStringSubmatch(name)
+		if matches != nil && matches[3] != "" && matches[1] != "filter" {
+			matches = nil
+		}
+		if matches == nil {
+			if isImplementationSpecificParameter(name) {
+				continue
+			}
+			return nil, newQueryParameterError(name, fmt.Sprintf("The query parameter %q is not supported.", name))
+		}
+
+		switch matches[1] {
+		case "fields":
+			q.Fields[matches[2]] = make([]string, 0)
+			if value != "" {
+				fields, err := splitQueryList(name, value)
+				if err != nil {
+					return nil, err
+				}
+				q.Fields[matches[2]] = fields
+			}
+		case "page":
+			q.Page[matches[2]] = value
+		case "filter":
+			key := matches[2]
+			if matches[3] != "" {
+				key += "[" + matches[3] + "]"
+			}
+			q.Filter[key] = value
+		}
+	}
+
+	return q, nil
+}
+
+// CheckSort returns an error if a sort field of q can't be sorted by for the resource struct of v,
+// which may be a nil pointer (e.g. (*Article)(nil)), as SortResources would with the given options.
+//
+// A 400 (Bad Request) *Error with the sort parameter as source is returned for unsupported sort
+// fields, ready to be written by WriteError.
+func (q *Query) CheckSort(v any, opts ...MarshalOption) error {
+	if v == nil {
+		return &TypeError{Actual: "nil", Expected: []string{"struct"}}
+	}
+	m := makeMarshaler(opts...)
+	t := derefType(reflect.TypeOf(v))
+	for _, f := range q.Sort {
+		if _, err := resolveSortField(t, f.Field, m.naming); err != nil {
+			if _, ok := err.(*TypeError); ok {
+				return err
+			}
+			return newSortFieldError(f, err)
+		}
+	}
+	return nil
+}
+
+// CheckFields returns an error if a sparse fieldset of q is requested for a resource type other than
+// those of the given resource structs, which may be nil pointers (e.g. (*Article)(nil)), or if it
+// has a member which isn't an attribute or relationship of the resource type.
+//
+// A 400 (Bad Request) *Error with the fields[TYPE] parameter as source is returned for invalid
+// sparse fieldsets, ready to be written by WriteError.
+func (q *Query) CheckFields(types ...any) error {
+	schemas := make(map[string]*ResourceSchema, len(types))
+	for _, v := range types {
+		s, err := SchemaOf(v)
+		if err != nil {
+			return err
+		}
+		schemas[s.Type] = s
+	}
+
+	resourceTypes := make([]string, 0, len(q.Fields))
+	for resourceType := range q.Fields {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	sort.Strings(resourceTypes)
+
+	for _, resourceType := range resourceTypes {
+		parameter := "fields[" + resourceType + "]"
+		s, ok := schemas[resourceType]
+		if !ok {
+			return newQueryParameterError(parameter, fmt.Sprintf("The resource type %q is not supported.", resourceType))
+		}
+		for _, name := range q.Fields[resourceType] {
+			if !s.HasField(name) {
+				return newQueryParameterError(parameter, fmt.Sprintf("The field %q is not a field of %q resources.", name, resourceType))
+			}
+		}
+	}
+	return nil
+}
+
+// CheckInclude returns an error if an include path of q isn't a path of relationships of the
+// resource struct of v, which may be a nil pointer (e.g. (*Article)(nil)), or is longer than
+// maxDepth relationships if maxDepth is positive.
+//
+// Related resource types are given by the struct types of relationship fields. Relationships of
+// resource identifiers only (see the reltype tag) are followed using the given TypeRegistry, which
+// may be nil to allow them at the end of include paths only.
+//
+// A 400 (Bad Request) *Error with the include parameter as source is returned for unsupported
+// include paths, ready to be written by WriteError.
+func (q *Query) CheckInclude(v any, maxDepth int, types *TypeRegistry) error {
+	s, err := SchemaOf(v)
+	if err != nil {
+		return err
+	}
+	for _, path := range q.Include {
+		segments := strings.Split(path, ".")
+		if maxDepth > 0 && len(segments) > maxDepth {
+			return newQueryParameterError("include", fmt.Sprintf("The include path %q exceeds the maximum depth of %d.", path, maxDepth))
+		}
+
+		ss := s
+		for i, segment := range segments {
+			if ss == nil {
+				return newQueryParameterError("include", fmt.Sprintf("The include path %q is not supported: the type of %q resources is unknown.", path, segments[i-1]))
+			}
+			rel, ok := ss.Relationship(segment)
+			if !ok {
+				return newQueryParameterError("include", fmt.Sprintf("The include path %q is not supported: %q is not a relationship of %q resources.", path, segment, ss.Type))
+			}
+			if ss, err = relatedSchema(rel, types); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// relatedSchema returns the ResourceSchema of the related resources of the relationship rel, or nil
+// if they are only known by their resource type and aren't registered in types.
+func relatedSchema(rel RelationshipSchema, types *TypeRegistry) (*ResourceSchema, error) {
+	rt := derefType(relatedFieldType(rel.Type))
+	if rt.Kind() == reflect.Slice {
+		rt = derefType(rt.Elem())
+	}
+	if rt.Kind() != reflect.Struct {
+		if types == nil {
+			return nil, nil
+		}
+		var ok bool
+		if rt, ok = types.types[rel.RelatedType]; !ok {
+			return nil, nil
+		}
+	}
+	return schemaOf(rt)
+}
+
+// PageInt returns the value of the page[name] parameter of q as an integer, or defaultValue if it
+// isn't given. min is the smallest value allowed, e.g. 1 for page[size] or 0 for page[offset]. If
+// max is positive, it is the largest value allowed.
+//
+// A 400 (Bad Request) *Error with the page[name] parameter as source is returned if the value isn't
+// an integer or is out of bounds, ready to be written by WriteError.
+func (q *Query) PageInt(name string, defaultValue, min, max int) (int, error) {
+	value, ok := q.Page[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	parameter := "page[" + name + "]"
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, newQueryParameterError(parameter, fmt.Sprintf("The %s parameter must be an integer.", parameter))
+	}
+	if n < min {
+		return 0, newQueryParameterError(parameter, fmt.Sprintf("The %s parameter must not be less than %d.", parameter, min))
+	}
+	if max > 0 && n > max {
+		return 0, newQueryParameterError(parameter, fmt.Sprintf("The %s parameter must not exceed %d.", parameter, max))
+	}
+	return n, nil
+}
+
+// isImplementationSpecificParameter returns true if name is allowed as implementation-specific
+// query parameter name, i.e. it contains a character other than a-z.
+func isImplementationSpecificParameter(name string) bool {
+	family := name
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		family = name[:i]
+	}
+	for _, c := range family {
+		if c < 'a' || c > 'z' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,295 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description     string
+		given           string
+		expect          *Query
+		expectParameter string
+	}{
+		{
+			description: "empty",
+			given:       "",
+			expect: &Query{
+				Include: []string{},
+				Fields:  map[string][]string{},
+				Sort:    []SortField{},
+				Page:    map[string]string{},
+				Filter:  map[string]string{},
+			},
+		}, {
+			description: "all parameters",
+			given:       "include=author,comments.author&fields[articles]=title,body&fields[author]=&sort=-created,title&page[number]=2&page[size]=10&filter[author]=1&camelCase=1",
+			expect: &Query{
+				Include: []string{"author", "comments.author"},
+				Fields:  map[string][]string{"articles": {"title", "body"}, "author": {}},
+				Sort:    []SortField{{Field: "created", Descending: true}, {Field: "title"}},
+				Page:    map[string]string{"number": "2", "size": "10"},
+				Filter:  map[string]string{"author": "1"},
+			},
+		}, {
+			description: "filter operators",
+			given:       "filter[views][gt]=10&filter[title]=A",
+			expect: &Query{
+				Include: []string{},
+				Fields:  map[string][]string{},
+				Sort:    []SortField{},
+				Page:    map[string]string{},
+				Filter:  map[string]string{"views[gt]": "10", "title": "A"},
+			},
+		}, {
+			description:     "operator outside of filter family",
+			given:           "page[size][gt]=10",
+			expectParameter: "page[size][gt]",
+		}, {
+			description:     "empty include path",
+			given:           "include=author,,comments",
+			expectParameter: "include",
+		}, {
+			description:     "empty sort field",
+			given:           "sort=-",
+			expectParameter: "sort",
+		}, {
+			description:     "unsupported parameter",
+			given:           "foo=bar",
+			expectParameter: "foo",
+		}, {
+			description:     "unsupported parameter family",
+			given:           "foo[bar]=baz",
+			expectParameter: "foo[bar]",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			values, err := url.ParseQuery(tc.given)
+			is.MustNoError(t, err)
+
+			q, err := ParseQuery(values)
+			if tc.expectParameter != "" {
+				e, ok := err.(*Error)
+				is.MustEqual(t, true, ok)
+				is.Equal(t, tc.expectParameter, e.Source.Parameter)
+				return
+			}
+			is.MustNoError(t, err)
+			tc.expect.Values = values
+			is.Equal(t, tc.expect, q)
+		})
+	}
+}
+
+func TestQueryChecks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description     string
+		given           string
+		check           func(q *Query) error
+		expectParameter string
+		expectDetail    string
+	}{
+		{
+			description: "sort fields",
+			given:       "sort=-title,author.name",
+			check:       func(q *Query) error { return q.CheckSort((*ArticleSortable)(nil)) },
+		}, {
+			description:     "unknown sort field",
+			given:           "sort=title,body",
+			check:           func(q *Query) error { return q.CheckSort((*ArticleSortable)(nil)) },
+			expectParameter: "sort",
+			expectDetail:    `The sort field "body" is not supported: "body" is not an attribute.`,
+		}, {
+			description: "sparse fieldsets",
+			given:       "fields[articles]=title,author&fields[author]=name",
+			check:       func(q *Query) error { return q.CheckFields((*ArticleRelated)(nil), (*Author)(nil)) },
+		}, {
+			description:     "unknown sparse fieldset type",
+			given:           "fields[people]=name",
+			check:           func(q *Query) error { return q.CheckFields((*ArticleRelated)(nil)) },
+			expectParameter: "fields[people]",
+			expectDetail:    `The resource type "people" is not supported.`,
+		}, {
+			description:     "unknown sparse fieldset member",
+			given:           "fields[articles]=title,body",
+			check:           func(q *Query) error { return q.CheckFields((*ArticleRelated)(nil)) },
+			expectParameter: "fields[articles]",
+			expectDetail:    `The field "body" is not a field of "articles" resources.`,
+		}, {
+			description: "page size",
+			given:       "page[size]=10",
+			check:       func(q *Query) error { _, err := q.PageInt("size", 20, 1, 100); return err },
+		}, {
+			description:     "invalid page size",
+			given:           "page[size]=ten",
+			check:           func(q *Query) error { _, err := q.PageInt("size", 20, 1, 100); return err },
+			expectParameter: "page[size]",
+			expectDetail:    "The page[size] parameter must be an integer.",
+		}, {
+			description:     "negative page size",
+			given:           "page[size]=-1",
+			check:           func(q *Query) error { _, err := q.PageInt("size", 20, 1, 100); return err },
+			expectParameter: "page[size]",
+			expectDetail:    "The page[size] parameter must not be less than 1.",
+		}, {
+			description: "zero page offset",
+			given:       "page[offset]=0",
+			check:       func(q *Query) error { _, err := q.PageInt("offset", 0, 0, 0); return err },
+		}, {
+			description:     "page size too large",
+			given:           "page[size]=1000",
+			check:           func(q *Query) error { _, err := q.PageInt("size", 20, 1, 100); return err },
+			expectParameter: "page[size]",
+			expectDetail:    "The page[size] parameter must not exceed 100.",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			values, err := url.ParseQuery(tc.given)
+			is.MustNoError(t, err)
+			q, err := ParseQuery(values)
+			is.MustNoError(t, err)
+
+			err = tc.check(q)
+			if tc.expectParameter == "" {
+				is.MustNoError(t, err)
+				return
+			}
+			is.Equal(t, []*Error{newQueryParameterError(tc.expectParameter, tc.expectDetail)}, ErrorObjects(err))
+		})
+	}
+}
+
+func TestQueryPageInt(t *testing.T) {
+	t.Parallel()
+
+	q, err := ParseQuery(url.Values{"page[size]": {"10"}, "page[offset]": {"0"}})
+	is.MustNoError(t, err)
+
+	size, err := q.PageInt("size", 20, 1, 100)
+	is.MustNoError(t, err)
+	is.Equal(t, 10, size)
+
+	number, err := q.PageInt("number", 1, 1, 0)
+	is.MustNoError(t, err)
+	is.Equal(t, 1, number)
+
+	offset, err := q.PageInt("offset", 10, 0, 0)
+	is.MustNoError(t, err)
+	is.Equal(t, 0, offset)
+}
+
+func TestQueryCheckInclude(t *testing.T) {
+	t.Parallel()
+
+	registry, err := NewTypeRegistry((*Comment)(nil), (*Author)(nil))
+	is.MustNoError(t, err)
+
+	tests := []struct {
+		description  string
+		given        string
+		v            any
+		maxDepth     int
+		types        *TypeRegistry
+		expectDetail string
+	}{
+		{
+			description: "include paths",
+			given:       "include=author,comments.author",
+			v:           (*ArticleRelated)(nil),
+		}, {
+			description:  "unknown relationship",
+			given:        "include=author,editor",
+			v:            (*ArticleRelated)(nil),
+			expectDetail: `The include path "editor" is not supported: "editor" is not a relationship of "articles" resources.`,
+		}, {
+			description:  "unknown nested relationship",
+			given:        "include=comments.article",
+			v:            (*ArticleRelated)(nil),
+			expectDetail: `The include path "comments.article" is not supported: "article" is not a relationship of "comments" resources.`,
+		}, {
+			description:  "attribute",
+			given:        "include=title",
+			v:            (*ArticleRelated)(nil),
+			expectDetail: `The include path "title" is not supported: "title" is not a relationship of "articles" resources.`,
+		}, {
+			description:  "maximum depth",
+			given:        "include=comments.author",
+			v:            (*ArticleRelated)(nil),
+			maxDepth:     1,
+			expectDetail: `The include path "comments.author" exceeds the maximum depth of 1.`,
+		}, {
+			description: "identifiers only",
+			given:       "include=comments",
+			v:           (*ArticleRelatedIDs)(nil),
+		}, {
+			description:  "identifiers only without registry",
+			given:        "include=comments.author",
+			v:            (*ArticleRelatedIDs)(nil),
+			expectDetail: `The include path "comments.author" is not supported: the type of "comments" resources is unknown.`,
+		}, {
+			description: "identifiers only with registry",
+			given:       "include=comments.author",
+			v:           (*ArticleRelatedIDs)(nil),
+			types:       registry,
+		}, {
+			description: "flattened relationships",
+			given:       "include=author,comments.author,editor",
+			v:           (*articleFlattenedRelationships)(nil),
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			values, err := url.ParseQuery(tc.given)
+			is.MustNoError(t, err)
+			q, err := ParseQuery(values)
+			is.MustNoError(t, err)
+
+			err = q.CheckInclude(tc.v, tc.maxDepth, tc.types)
+			if tc.expectDetail == "" {
+				is.MustNoError(t, err)
+				return
+			}
+			is.Equal(t, []*Error{newQueryParameterError("include", tc.expectDetail)}, ErrorObjects(err))
+		})
+	}
+}
+
+// articleRelationships holds the relationships of articleFlattenedRelationships.
+type articleRelationships struct {
+	Author   *Author    `jsonapi:"relationship" json:"author"`
+	Comments []*Comment `jsonapi:"relationship" json:"comments"`
+}
+
+// articleFlattenedRelationships has relationships promoted from an embedded and a flattened struct.
+type articleFlattenedRelationships struct {
+	ID string `jsonapi:"primary,articles"`
+	*articleRelationships
+	Related struct {
+		Editor *Author `jsonapi:"relationship" json:"editor"`
+	} `jsonapi:"attribute,flatten"`
+}
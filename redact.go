@@ -0,0 +1,65 @@
+package jsonapi
+
+import "context"
+
+// AttributeRedactor decides how the attribute named attribute of resources of the given type is
+// marshaled, e.g. to hide the email of users from callers other than admins. It returns the value
+// to marshal in place of value, such as a mask like "***", or false to leave the attribute out.
+type AttributeRedactor func(ctx context.Context, resourceType, attribute string, value any) (any, bool)
+
+// MarshalAttributeRedactor consults r for every attribute of the resource objects of primary data
+// and included resources, which allows servers to hide or mask attributes per request without
+// building separate resources per role. r is called with the context given by MarshalContext.
+//
+// Attributes left out by sparse fieldsets (see MarshalFields) are not passed to r.
+func MarshalAttributeRedactor(r AttributeRedactor) MarshalOption {
+	return func(m *Marshaler) {
+		m.attributeRedactor = r
+	}
+}
+
+// HideAttributes returns an AttributeRedactor which leaves out the given attributes of resources
+// of the given type unless allow returns true, e.g. for callers with an admin role.
+func HideAttributes(resourceType string, allow func(ctx context.Context) bool, attributes ...string) AttributeRedactor {
+	hidden := make(map[string]bool, len(attributes))
+	for _, name := range attributes {
+		hidden[name] = true
+	}
+	return func(ctx context.Context, typ, attribute string, value any) (any, bool) {
+		if typ != resourceType || !hidden[attribute] {
+			return value, true
+		}
+		return value, allow != nil && allow(ctx)
+	}
+}
+
+// redactDocumentAttributes applies the Marshaler's AttributeRedactor to the attributes of the
+// document's resource objects.
+func redactDocumentAttributes(d *document, m *Marshaler) {
+	if m.attributeRedactor == nil {
+		return
+	}
+
+	ctx := m.context()
+	redactAttributes(ctx, d.DataOne, m)
+	for _, ro := range d.DataMany {
+		redactAttributes(ctx, ro, m)
+	}
+	for _, ro := range d.Included {
+		redactAttributes(ctx, ro, m)
+	}
+}
+
+// redactAttributes applies the Marshaler's AttributeRedactor to the attributes of ro.
+func redactAttributes(ctx context.Context, ro *resourceObject, m *Marshaler) {
+	if ro == nil || m.attributeRedactor == nil {
+		return
+	}
+	for name, value := range ro.Attributes {
+		if value, ok := m.attributeRedactor(ctx, ro.Type, name, value); ok {
+			ro.Attributes[name] = value
+		} else {
+			delete(ro.Attributes, name)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalAttributeRedactor(t *testing.T) {
+	t.Parallel()
+
+	type adminKey struct{}
+	isAdmin := func(ctx context.Context) bool {
+		admin, _ := ctx.Value(adminKey{}).(bool)
+		return admin
+	}
+	mask := func(ctx context.Context, resourceType, attribute string, value any) (any, bool) {
+		if attribute == "name" {
+			return "***", true
+		}
+		return value, true
+	}
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+	}{
+		{
+			description: "hidden in primary data",
+			given:       &authorA,
+			opts:        []MarshalOption{MarshalAttributeRedactor(HideAttributes("author", isAdmin, "name"))},
+			expect:      `{"data":{"type":"author","id":"1"}}`,
+		}, {
+			description: "allowed",
+			given:       &authorA,
+			opts:        []MarshalOption{MarshalAttributeRedactor(HideAttributes("author", isAdmin, "name")), MarshalContext(context.WithValue(context.Background(), adminKey{}, true))},
+			expect:      `{"data":{"type":"author","id":"1","attributes":{"name":"A"}}}`,
+		}, {
+			description: "other resource types",
+			given:       &articleA,
+			opts:        []MarshalOption{MarshalAttributeRedactor(HideAttributes("author", isAdmin, "name"))},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`,
+		}, {
+			description: "hidden in included resources",
+			given:       &ArticleRelated{ID: "1", Title: "A", Author: &authorA},
+			opts:        []MarshalOption{MarshalInclude(&authorA), MarshalAttributeRedactor(HideAttributes("author", isAdmin, "name"))},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"A"},"relationships":{"author":{"data":{"type":"author","id":"1"},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}}}},"included":[{"type":"author","id":"1"}]}`,
+		}, {
+			description: "masked in collections",
+			given:       []*Author{&authorA, {ID: "2", Name: "B"}},
+			opts:        []MarshalOption{MarshalAttributeRedactor(mask)},
+			expect:      `{"data":[{"type":"author","id":"1","attributes":{"name":"***"}},{"type":"author","id":"2","attributes":{"name":"***"}}]}`,
+		}, {
+			description: "null data",
+			given:       nil,
+			opts:        []MarshalOption{MarshalAttributeRedactor(mask)},
+			expect:      `{"data":null}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// newUnknownRelationshipError creates a FieldError for a relationship which doesn't exist.
+func newUnknownRelationshipError(relation string) *FieldError {
+	return &FieldError{
+		Code:    CodeInvalidRelationship,
+		Member:  relation,
+		Pointer: "/relationships/" + escapePointerToken(relation),
+		Err:     ErrUnknownRelationship,
+	}
+}
+
+// MarshalRef returns the json:api encoding of the relationship named relation of the resource v,
+// as served by relationship endpoints (e.g. /articles/1/relationships/comments) and defined by
+// https://jsonapi.org/format/#fetching-relationships.
+//
+// The primary data of the document is the resource linkage of the relationship, consisting of
+// resource identifier objects only: null or a single resource identifier object for to-one
+// relationships, and an empty or non-empty array of them for to-many relationships. If v implements
+// LinkableRelation, the links of the relationship are added as top-level links, unless given via
+// MarshalLinks.
+func MarshalRef(v any, relation string, opts ...MarshalOption) (b []byte, err error) {
+	m := makeMarshaler(opts...)
+	// the resource linkage of relationship endpoints is always held by data
+	m.dataMember = ""
+
+	err = m.observe(func(op *Operation) (err error) {
+		fv, ft, ok := findRelationshipField(v, relation, m.naming)
+		if !ok {
+			return newUnknownRelationshipError(relation)
+		}
+		// relationship endpoints always serve resource linkage, so omitted relationships are null
+		fv, _ = relatedField(fv)
+
+		if m.link == nil {
+			if m.link, err = m.relationLink(v, relation); err != nil {
+				return err
+			}
+		}
+
+		var d *document
+		relatedType, idsOnly, err := parseRelTypeTag(ft)
+		if err != nil {
+			return err
+		}
+		if idsOnly {
+			if d, err = makeLinkageDocument(fv, relatedType, m); err != nil {
+				return err
+			}
+			err = addOptionalDocumentFields(d, m)
+		} else {
+			d, err = makeDocument(fv.Interface(), m, true)
+		}
+		if err != nil {
+			return err
+		}
+		op.add(d)
+		if err = d.addIdentifierMeta(v, relation); err != nil {
+			return err
+		}
+		if err = d.addExtensions(v, relation, m); err != nil {
+			return err
+		}
+
+		b, err = m.appendDocument(nil, d)
+		op.Size = len(b)
+		return err
+	})
+
+	return
+}
+
+// MarshalRelated returns the json:api encoding of the related resources of the relationship named
+// relation of the resource v, as served by related resource endpoints (e.g. /articles/1/comments)
+// and defined by https://jsonapi.org/format/#fetching-resources.
+//
+// The primary data of the document are the full resource objects of the relationship: null or a
+// single resource object for to-one relationships, and an empty or non-empty array of them for
+// to-many relationships. If v implements LinkableRelation, the related link of the relationship is
+// added as the top-level self link, unless links are given via MarshalLinks. Includes given via
+// MarshalIncludeResolver are resolved relative to the related resources.
+func MarshalRelated(v any, relation string, opts ...MarshalOption) (b []byte, err error) {
+	m := makeMarshaler(opts...)
+
+	err = m.observe(func(op *Operation) error {
+		fv, _, ok := findRelationshipField(v, relation, m.naming)
+		if !ok {
+			return newUnknownRelationshipError(relation)
+		}
+
+		if m.link == nil {
+			link, err := m.relationLink(v, relation)
+			if err != nil {
+				return err
+			}
+			if link != nil && link.Related != nil {
+				// the related resource endpoint is the self link of the document
+				m.link = &Link{Self: link.Related}
+			}
+		}
+
+		d, err := makeDocument(fv.Interface(), m, false)
+		if err != nil {
+			return err
+		}
+		op.add(d)
+
+		b, err = m.appendDocument(nil, d)
+		op.Size = len(b)
+		return err
+	})
+
+	return
+}
+
+// UnmarshalRef parses a json:api document whose primary data is resource linkage, as sent to
+// relationship endpoints (e.g. PATCH /articles/1/relationships/comments) and defined by
+// https://jsonapi.org/format/#crud-updating-relationships, and stores the result in the
+// relationship named relation of the resource pointed to by v.
+//
+// The primary data must consist of resource identifier objects only. A null to-one relationship
+// sets the relationship field to its zero value, and an empty to-many relationship sets it to an
+// empty slice. The other fields of v are left untouched.
+func UnmarshalRef(data []byte, v any, relation string, opts ...UnmarshalOption) error {
+	m := makeUnmarshaler(opts...)
+
+	return m.observe(func(op *Operation) (err error) {
+		op.Size = len(data)
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Pointer || rv.IsNil() || derefType(rv.Type()).Kind() != reflect.Struct {
+			err = &TypeError{Actual: rv.Kind().String(), Expected: []string{"non-nil pointer to struct"}, err: ErrUnmarshalInvalidTarget}
+			return
+		}
+
+		fv, ft, ok := findRelationshipField(v, relation, m.naming)
+		if !ok {
+			err = newUnknownRelationshipError(relation)
+			return
+		}
+		// NullableRelationships are only marked as present once their resource linkage is unmarshaled
+		rf := fv
+		fv, _ = relatedField(fv)
+		defer func() {
+			if err == nil {
+				setRelationshipPresent(rf)
+			}
+		}()
+
+		if err = m.checkDocument(data, true); err != nil {
+			return
+		}
+
+		var d document
+		if err = unmarshalJSON(data, &d); err != nil {
+			return
+		}
+		op.add(&d)
+
+		if err = validateJSONMemberNames(data, m.memberNameValidationMode, m.relaxedMemberClasses, m.extensions); err != nil {
+			return
+		}
+
+		if err = d.verifyRef(data, derefType(fv.Type()).Kind() == reflect.Slice); err != nil {
+			return
+		}
+
+		relatedType, idsOnly, err := parseRelTypeTag(ft)
+		if err != nil {
+			return
+		}
+		if idsOnly {
+			if err = d.unmarshalLinkage(fv, relatedType, m); err != nil {
+				return
+			}
+			if err = d.unmarshalOptionalFields(m); err != nil {
+				return
+			}
+			if err = d.unmarshalIdentifierMeta(v, relation); err != nil {
+				return
+			}
+			err = d.unmarshalExtensions(v, relation, m)
+			return
+		}
+
+		rel := reflect.New(derefType(fv.Type())).Interface()
+		if err = d.unmarshal(rel, m); err != nil {
+			return
+		}
+
+		if d.DataOne == nil && !d.hasMany {
+			fv.Set(reflect.Zero(fv.Type()))
+			return
+		}
+		setFieldValue(fv, rel)
+
+		if err = d.unmarshalIdentifierMeta(v, relation); err != nil {
+			return
+		}
+		err = d.unmarshalExtensions(v, relation, m)
+
+		return
+	})
+}
+
+// verifyRef returns an error if the primary data of the given document, parsed from data, is not
+// resource linkage of a to-many relationship if many is true, or a to-one relationship otherwise.
+func (d *document) verifyRef(data []byte, many bool) error {
+	var members map[string]any
+	if err := unmarshalJSON(data, &members); err != nil {
+		return err
+	}
+	if _, ok := members["data"]; !ok {
+		return &DocumentError{Code: CodeMissingData, Err: ErrMissingDataField}
+	}
+
+	if d.hasMany != many {
+		actual, expected := "object", []string{"array"}
+		if d.hasMany {
+			actual, expected = "array", []string{"object", "null"}
+		} else if d.DataOne == nil {
+			actual = "null"
+		}
+		return &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: &TypeError{Actual: actual, Expected: expected}}
+	}
+
+	isIdentifier := func(ro *resourceObject) bool {
+		return ro == nil || (!ro.hasAttributes() && len(ro.Relationships) == 0 && ro.Links == nil)
+	}
+	if !isIdentifier(d.DataOne) {
+		return &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrResourceIdentifierOnly}
+	}
+	for i, ro := range d.DataMany {
+		if !isIdentifier(ro) {
+			return &DocumentError{Code: CodeInvalidData, Pointer: fmt.Sprintf("/data/%d", i), Err: ErrResourceIdentifierOnly}
+		}
+	}
+
+	return nil
+}
+
+// RelationshipOperation is the operation of a RelationshipUpdate.
+type RelationshipOperation int
+
+const (
+	// RelationshipReplace replaces all members of the relationship with the given identifiers, or
+	// clears it if there are none.
+	RelationshipReplace RelationshipOperation = iota
+
+	// RelationshipAdd adds the given identifiers to a to-many relationship, unless already present.
+	RelationshipAdd
+
+	// RelationshipRemove removes the given identifiers from a to-many relationship, if present.
+	RelationshipRemove
+)
+
+// String returns the name of the operation.
+func (o RelationshipOperation) String() string {
+	switch o {
+	case RelationshipReplace:
+		return "replace"
+	case RelationshipAdd:
+		return "add"
+	case RelationshipRemove:
+		return "remove"
+	}
+	return fmt.Sprintf("RelationshipOperation(%d)", int(o))
+}
+
+// RelationshipUpdate is an update of a relationship requested via its relationship endpoint (e.g.
+// /articles/1/relationships/tags), as defined by https://jsonapi.org/format/#crud-updating-relationships.
+type RelationshipUpdate struct {
+	// Op is the operation to perform.
+	Op RelationshipOperation
+
+	// ToMany is true if the update targets a to-many relationship.
+	ToMany bool
+
+	// Identifiers are the identifiers of the resources to replace, add or remove. It is empty when
+	// clearing a relationship.
+	Identifiers []ResourceIdentifier
+}
+
+// UnmarshalRelationshipUpdate parses the json:api encoded body of a request to a relationship
+// endpoint sent with the given method, as defined by https://jsonapi.org/format/#crud-updating-relationships:
+//
+//   - PATCH replaces a to-one relationship with null or a resource identifier object, or a to-many
+//     relationship with an array of resource identifier objects.
+//   - POST adds the array of resource identifier objects to a to-many relationship.
+//   - DELETE removes the array of resource identifier objects from a to-many relationship.
+//
+// The primary data must consist of resource identifier objects only. Other methods result in
+// ErrRelationshipUpdateMethod.
+func UnmarshalRelationshipUpdate(data []byte, method string, opts ...UnmarshalOption) (u RelationshipUpdate, err error) {
+	m := makeUnmarshaler(opts...)
+
+	err = m.observe(func(op *Operation) (err error) {
+		op.Size = len(data)
+		switch method {
+		case http.MethodPatch:
+			u.Op = RelationshipReplace
+		case http.MethodPost:
+			u.Op = RelationshipAdd
+		case http.MethodDelete:
+			u.Op = RelationshipRemove
+		default:
+			err = ErrRelationshipUpdateMethod
+			return
+		}
+
+		if err = m.checkDocument(data, true); err != nil {
+			return
+		}
+
+		var d document
+		if err = unmarshalJSON(data, &d); err != nil {
+			return
+		}
+		op.add(&d)
+
+		if err = validateJSONMemberNames(data, m.memberNameValidationMode, m.relaxedMemberClasses, m.extensions); err != nil {
+			return
+		}
+
+		// only to-many relationships can be added to or removed from
+		if err = d.verifyRef(data, d.hasMany || u.Op != RelationshipReplace); err != nil {
+			return
+		}
+		u.ToMany = d.hasMany
+
+		identifiers := d.DataMany
+		if d.DataOne != nil {
+			identifiers = []*resourceObject{d.DataOne}
+		}
+		u.Identifiers = make([]ResourceIdentifier, 0, len(identifiers))
+		for i, ro := range identifiers {
+			pointer := "/data"
+			if u.ToMany {
+				pointer = fmt.Sprintf("/data/%d", i)
+			}
+			if ro.Type == "" {
+				err = &FieldError{Code: CodeInvalidType, Member: "type", Pointer: pointer + "/type", Err: ErrMissingTypeField}
+				return
+			}
+			if ro.ID == "" {
+				err = &FieldError{Code: CodeInvalidID, Member: "id", Pointer: pointer + "/id", Err: ErrEmptyPrimaryField}
+				return
+			}
+			u.Identifiers = append(u.Identifiers, ResourceIdentifier{Type: ro.Type, ID: ro.ID})
+		}
+
+		return
+	})
+
+	return
+}
@@ -0,0 +1,340 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalRef(t *testing.T) {
+	t.Parallel()
+
+	authorLinks := `"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}`
+	commentsLinks := `"links":{"self":"http://example.com/articles/1/relationships/comments","related":"http://example.com/articles/1/comments"}`
+
+	tests := []struct {
+		description string
+		given       any
+		relation    string
+		opts        []MarshalOption
+		expect      string
+		expectError error
+	}{
+		{
+			description: "to-one",
+			given:       &ArticleRelated{ID: "1", Title: "A", Author: &authorA},
+			relation:    "author",
+			expect:      `{"data":{"id":"1","type":"author"},` + authorLinks + `}`,
+		}, {
+			description: "empty to-one",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			relation:    "author",
+			expect:      `{"data":null,` + authorLinks + `}`,
+		}, {
+			description: "to-many",
+			given:       &ArticleRelated{ID: "1", Title: "A", Comments: []*Comment{&commentAWithAuthor, &commentB}},
+			relation:    "comments",
+			expect:      `{"data":[{"id":"1","type":"comments"},{"id":"2","type":"comments"}],` + commentsLinks + `}`,
+		}, {
+			description: "empty to-many",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			relation:    "comments",
+			expect:      `{"data":[],` + commentsLinks + `}`,
+		}, {
+			description: "links and meta options",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			relation:    "comments",
+			opts:        []MarshalOption{MarshalLinks(&Link{Self: "http://example.com/self"}), MarshalMeta(map[string]any{"count": 0})},
+			expect:      `{"data":[],"meta":{"count":0},"links":{"self":"http://example.com/self"}}`,
+		}, {
+			description: "unknown relationship",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			relation:    "title",
+			expectError: newUnknownRelationshipError("title"),
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := MarshalRef(tc.given, tc.relation, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestMarshalRelated(t *testing.T) {
+	t.Parallel()
+
+	authorSelfLink := `"links":{"self":"http://example.com/articles/1/author"}`
+	commentsSelfLink := `"links":{"self":"http://example.com/articles/1/comments"}`
+
+	tests := []struct {
+		description string
+		given       any
+		relation    string
+		opts        []MarshalOption
+		expect      string
+		expectError error
+	}{
+		{
+			description: "to-one",
+			given:       &ArticleRelated{ID: "1", Title: "A", Author: &authorA},
+			relation:    "author",
+			expect:      `{"data":{"id":"1","type":"author","attributes":{"name":"A"}},` + authorSelfLink + `}`,
+		}, {
+			description: "empty to-one",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			relation:    "author",
+			expect:      `{"data":null,` + authorSelfLink + `}`,
+		}, {
+			description: "to-many with nested relationships",
+			given:       &ArticleRelated{ID: "1", Title: "A", Comments: []*Comment{&commentAWithAuthor}},
+			relation:    "comments",
+			expect:      `{"data":[{"id":"1","type":"comments","attributes":{"body":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/comments/1/relationships/author","related":"http://example.com/comments/1/author"}}}}],` + commentsSelfLink + `}`,
+		}, {
+			description: "empty to-many",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			relation:    "comments",
+			expect:      `{"data":[],` + commentsSelfLink + `}`,
+		}, {
+			description: "include resolved relative to related resources",
+			given:       &ArticleRelated{ID: "1", Title: "A", Comments: []*Comment{&commentA}},
+			relation:    "comments",
+			opts:        []MarshalOption{MarshalIncludeResolver(IncludeResolverFunc(articleResolver), "author")},
+			expect:      `{"data":[{"id":"1","type":"comments","attributes":{"body":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/comments/1/relationships/author","related":"http://example.com/comments/1/author"}}}}],"included":[{"id":"1","type":"author","attributes":{"name":"A"}}],` + commentsSelfLink + `}`,
+		}, {
+			description: "links option",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			relation:    "comments",
+			opts:        []MarshalOption{MarshalLinks(&Link{Self: "http://example.com/self", Next: "http://example.com/next"})},
+			expect:      `{"data":[],"links":{"self":"http://example.com/self","next":"http://example.com/next"}}`,
+		}, {
+			description: "unknown relationship",
+			given:       &ArticleRelated{ID: "1", Title: "A"},
+			relation:    "title",
+			expectError: newUnknownRelationshipError("title"),
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := MarshalRelated(tc.given, tc.relation, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestUnmarshalRef(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		relation    string
+		expect      *ArticleRelated
+		expectIs    error
+	}{
+		{
+			description: "to-one",
+			given:       `{"data":{"id":"2","type":"author"}}`,
+			relation:    "author",
+			expect:      &ArticleRelated{ID: "1", Title: "A", Author: &Author{ID: "2"}},
+		}, {
+			description: "empty to-one",
+			given:       `{"data":null}`,
+			relation:    "author",
+			expect:      &ArticleRelated{ID: "1", Title: "A"},
+		}, {
+			description: "to-many",
+			given:       `{"data":[{"id":"1","type":"comments"},{"id":"2","type":"comments"}]}`,
+			relation:    "comments",
+			expect:      &ArticleRelated{ID: "1", Title: "A", Author: &authorA, Comments: []*Comment{{ID: "1"}, {ID: "2"}}},
+		}, {
+			description: "empty to-many",
+			given:       `{"data":[]}`,
+			relation:    "comments",
+			expect:      &ArticleRelated{ID: "1", Title: "A", Author: &authorA, Comments: []*Comment{}},
+		}, {
+			description: "missing data",
+			given:       `{"meta":{"count":0}}`,
+			relation:    "comments",
+			expectIs:    ErrMissingDataField,
+		}, {
+			description: "null to-many",
+			given:       `{"data":null}`,
+			relation:    "comments",
+			expectIs:    &TypeError{},
+		}, {
+			description: "resource object",
+			given:       `{"data":{"id":"2","type":"author","attributes":{"name":"B"}}}`,
+			relation:    "author",
+			expectIs:    ErrResourceIdentifierOnly,
+		}, {
+			description: "wrong type",
+			given:       `{"data":{"id":"2","type":"comments"}}`,
+			relation:    "author",
+			expectIs:    &TypeError{},
+		}, {
+			description: "unknown relationship",
+			given:       `{"data":null}`,
+			relation:    "editor",
+			expectIs:    ErrUnknownRelationship,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual := &ArticleRelated{ID: "1", Title: "A", Author: &authorA, Comments: []*Comment{&commentA}}
+			err := UnmarshalRef([]byte(tc.given), actual, tc.relation)
+			switch expect := tc.expectIs.(type) {
+			case nil:
+				is.MustNoError(t, err)
+				is.Equal(t, tc.expect.ID, actual.ID)
+				is.Equal(t, tc.expect.Title, actual.Title)
+				is.Equal(t, tc.expect.Author, actual.Author)
+				if tc.relation == "comments" {
+					is.Equal(t, tc.expect.Comments, actual.Comments)
+				}
+			case *TypeError:
+				is.Equal(t, true, errors.As(err, &expect))
+			default:
+				is.Equal(t, true, errors.Is(err, tc.expectIs))
+			}
+		})
+	}
+}
+
+func TestRefRelationshipIDs(t *testing.T) {
+	t.Parallel()
+
+	article := &ArticleRelatedIDs{ID: "1", Title: "A", AuthorID: "1", CommentIDs: []string{"1", "2"}}
+
+	b, err := MarshalRef(article, "comments")
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":[{"id":"1","type":"comments"},{"id":"2","type":"comments"}]}`, string(b))
+
+	err = UnmarshalRef([]byte(`{"data":[{"id":"3","type":"comments"}]}`), article, "comments")
+	is.MustNoError(t, err)
+	is.Equal(t, []string{"3"}, article.CommentIDs)
+
+	err = UnmarshalRef([]byte(`{"data":null}`), article, "author")
+	is.MustNoError(t, err)
+	is.Equal(t, "", article.AuthorID)
+}
+
+func TestUnmarshalRelationshipUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		method      string
+		expect      RelationshipUpdate
+		expectIs    error
+	}{
+		{
+			description: "replace to-one",
+			given:       `{"data":{"id":"2","type":"author"}}`,
+			method:      http.MethodPatch,
+			expect:      RelationshipUpdate{Op: RelationshipReplace, Identifiers: []ResourceIdentifier{{Type: "author", ID: "2"}}},
+		}, {
+			description: "clear to-one",
+			given:       `{"data":null}`,
+			method:      http.MethodPatch,
+			expect:      RelationshipUpdate{Op: RelationshipReplace, Identifiers: []ResourceIdentifier{}},
+		}, {
+			description: "replace to-many",
+			given:       `{"data":[{"id":"1","type":"comments"},{"id":"2","type":"comments"}]}`,
+			method:      http.MethodPatch,
+			expect: RelationshipUpdate{
+				Op:          RelationshipReplace,
+				ToMany:      true,
+				Identifiers: []ResourceIdentifier{{Type: "comments", ID: "1"}, {Type: "comments", ID: "2"}},
+			},
+		}, {
+			description: "clear to-many",
+			given:       `{"data":[]}`,
+			method:      http.MethodPatch,
+			expect:      RelationshipUpdate{Op: RelationshipReplace, ToMany: true, Identifiers: []ResourceIdentifier{}},
+		}, {
+			description: "add to-many",
+			given:       `{"data":[{"id":"1","type":"comments"}]}`,
+			method:      http.MethodPost,
+			expect:      RelationshipUpdate{Op: RelationshipAdd, ToMany: true, Identifiers: []ResourceIdentifier{{Type: "comments", ID: "1"}}},
+		}, {
+			description: "remove to-many",
+			given:       `{"data":[{"id":"1","type":"comments"}]}`,
+			method:      http.MethodDelete,
+			expect:      RelationshipUpdate{Op: RelationshipRemove, ToMany: true, Identifiers: []ResourceIdentifier{{Type: "comments", ID: "1"}}},
+		}, {
+			description: "add to-one",
+			given:       `{"data":{"id":"1","type":"comments"}}`,
+			method:      http.MethodPost,
+			expectIs:    &TypeError{},
+		}, {
+			description: "missing data",
+			given:       `{"meta":{"count":0}}`,
+			method:      http.MethodPatch,
+			expectIs:    ErrMissingDataField,
+		}, {
+			description: "resource object",
+			given:       `{"data":[{"id":"2","type":"comments","attributes":{"body":"B"}}]}`,
+			method:      http.MethodPost,
+			expectIs:    ErrResourceIdentifierOnly,
+		}, {
+			description: "missing id",
+			given:       `{"data":[{"type":"comments"}]}`,
+			method:      http.MethodDelete,
+			expectIs:    ErrEmptyPrimaryField,
+		}, {
+			description: "unsupported method",
+			given:       `{"data":[]}`,
+			method:      http.MethodPut,
+			expectIs:    ErrRelationshipUpdateMethod,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := UnmarshalRelationshipUpdate([]byte(tc.given), tc.method)
+			switch expect := tc.expectIs.(type) {
+			case nil:
+				is.MustNoError(t, err)
+				is.Equal(t, tc.expect, actual)
+			case *TypeError:
+				is.Equal(t, true, errors.As(err, &expect))
+			default:
+				is.Equal(t, true, errors.Is(err, tc.expectIs))
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package jsonapi
 import (
 	"fmt"
 	"reflect"
-	"runtime/debug"
 )
 
 func derefValue(v reflect.Value) reflect.Value {
@@ -30,9 +29,9 @@ func recoverError(rvr any) error {
 	var err error
 	switch e := rvr.(type) {
 	case error:
-		err = fmt.Errorf("unknown error: %w %s", e, debug.Stack())
+		err = fmt.Errorf("unknown error: %w %s", e, stack())
 	default:
-		err = fmt.Errorf("%v %s", e, debug.Stack())
+		err = fmt.Errorf("%v %s", e, stack())
 	}
 	return err
 }
@@ -0,0 +1,19 @@
+//go:build !tinygo
+
+package jsonapi
+
+import (
+	"reflect"
+	"runtime/debug"
+)
+
+// implements returns true if the type t implements the interface type iface.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface)
+}
+
+// stack returns the stack trace of the calling goroutine, to be included in errors recovered from
+// panics.
+func stack() []byte {
+	return debug.Stack()
+}
@@ -0,0 +1,46 @@
+//go:build tinygo
+
+package jsonapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+)
+
+// The tinygo build tag is set by TinyGo, which supports a subset of the reflect package only.
+// The functions below replace the features TinyGo doesn't implement.
+
+// implements returns true if the type t implements the interface type iface. TinyGo doesn't
+// implement reflect.Type.Implements, so the zero value of t is type asserted instead, which is
+// only possible for the interface types used by this package.
+func implements(t, iface reflect.Type) bool {
+	if t.Kind() == reflect.Interface {
+		return false
+	}
+
+	v := reflect.Zero(t).Interface()
+	switch iface {
+	case jsonMarshalerType:
+		_, ok := v.(json.Marshaler)
+		return ok
+	case textMarshalerType:
+		_, ok := v.(encoding.TextMarshaler)
+		return ok
+	case jsonUnmarshalerType:
+		_, ok := v.(json.Unmarshaler)
+		return ok
+	case textUnmarshalerType:
+		_, ok := v.(encoding.TextUnmarshaler)
+		return ok
+	case nullableRelationshipType:
+		_, ok := v.(nullableRelationship)
+		return ok
+	}
+	return false
+}
+
+// stack returns nil, as TinyGo doesn't provide stack traces.
+func stack() []byte {
+	return nil
+}
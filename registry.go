@@ -0,0 +1,72 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TypeRegistry maps resource types to the Go struct types their resource objects are unmarshaled
+// into, to unmarshal collections of different resource types (e.g. search results) into a []any,
+// or a single resource object of any of them into an any.
+//
+// A TypeRegistry is immutable once created, so it can be shared between concurrent calls.
+type TypeRegistry struct {
+	types map[string]reflect.Type
+}
+
+// NewTypeRegistry returns a TypeRegistry of the given resource struct types, which can be given as
+// values or nil pointers (e.g. (*Article)(nil)). It returns an error if the struct tags of a type
+// are invalid, or if two types have the same resource type.
+func NewTypeRegistry(types ...any) (*TypeRegistry, error) {
+	r := &TypeRegistry{types: make(map[string]reflect.Type, len(types))}
+	for _, v := range types {
+		if v == nil {
+			return nil, &TypeError{Actual: "nil", Expected: []string{"struct"}}
+		}
+		t := derefType(reflect.TypeOf(v))
+		s, err := schemaOf(t)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t, err)
+		}
+		if other, ok := r.types[s.Type]; ok && other != t {
+			return nil, fmt.Errorf("%s: %w", s.Type, ErrDuplicateResourceType)
+		}
+		r.types[s.Type] = t
+	}
+	return r, nil
+}
+
+// UnmarshalTypeRegistry unmarshals resource objects into values of the Go types registered in r
+// for their resource type when the destination is an interface value, such as an any or the
+// elements of a []any. Such values are pointers to new structs (e.g. *Article), or a *Resource for
+// resource types which aren't registered.
+func UnmarshalTypeRegistry(r *TypeRegistry) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.types = r
+	}
+}
+
+// newResourceValue returns a pointer to a new value to unmarshal resource objects of the given
+// type into, when the destination is an interface value.
+func (m *Unmarshaler) newResourceValue(resourceType string) any {
+	if m.types != nil {
+		if t, ok := m.types.types[resourceType]; ok {
+			return reflect.New(t).Interface()
+		}
+	}
+	return new(Resource)
+}
+
+// unmarshalInterface unmarshals ro into a new value set to the interface value iv, as given by
+// newResourceValue.
+func (ro *resourceObject) unmarshalInterface(iv reflect.Value, m *Unmarshaler) error {
+	v := m.newResourceValue(ro.Type)
+	if !reflect.TypeOf(v).AssignableTo(iv.Type()) {
+		return &TypeError{Actual: reflect.TypeOf(v).String(), Expected: []string{iv.Type().String()}}
+	}
+	if err := ro.unmarshal(v, m); err != nil {
+		return err
+	}
+	iv.Set(reflect.ValueOf(v))
+	return nil
+}
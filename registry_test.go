@@ -0,0 +1,108 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestNewTypeRegistry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       []any
+		expectError error
+	}{
+		{
+			description: "values and pointers",
+			given:       []any{Article{}, (*Author)(nil), (*Comment)(nil)},
+		}, {
+			description: "same type twice",
+			given:       []any{Article{}, (*Article)(nil)},
+		}, {
+			description: "nil",
+			given:       []any{nil},
+			expectError: &TypeError{Actual: "nil", Expected: []string{"struct"}},
+		}, {
+			description: "missing primary",
+			given:       []any{(*Metadata)(nil)},
+			expectError: fmt.Errorf("jsonapi.Metadata: %w", ErrMissingPrimaryField),
+		}, {
+			description: "duplicate resource type",
+			given:       []any{(*Article)(nil), (*ArticleRelated)(nil)},
+			expectError: fmt.Errorf("articles: %w", ErrDuplicateResourceType),
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			_, err := NewTypeRegistry(tc.given...)
+			is.EqualError(t, tc.expectError, err)
+		})
+	}
+}
+
+func TestUnmarshalTypeRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry, err := NewTypeRegistry((*Article)(nil), (*Author)(nil))
+	is.MustNoError(t, err)
+
+	given := []any{&articleA, &authorA, NewResource("comments", "1").SetAttr("body", "A")}
+	b, err := Marshal(given)
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":[{"type":"articles","id":"1","attributes":{"title":"A"}},{"type":"author","id":"1","attributes":{"name":"A"}},{"type":"comments","id":"1","attributes":{"body":"A"}}]}`, string(b))
+
+	t.Run("collection", func(t *testing.T) {
+		t.Parallel()
+
+		var got []any
+		is.MustNoError(t, Unmarshal(b, &got, UnmarshalTypeRegistry(registry)))
+		is.MustEqual(t, 3, len(got))
+		is.Equal(t, &articleA, got[0])
+		is.Equal(t, &authorA, got[1])
+		is.Equal(t, ResourceIdentifier{Type: "comments", ID: "1"}, got[2].(*Resource).Identifier())
+	})
+
+	t.Run("single", func(t *testing.T) {
+		t.Parallel()
+
+		var got any
+		is.MustNoError(t, Unmarshal([]byte(articleABody), &got, UnmarshalTypeRegistry(registry)))
+		is.Equal(t, &articleA, got)
+	})
+
+	t.Run("without registry", func(t *testing.T) {
+		t.Parallel()
+
+		var got []any
+		is.MustNoError(t, Unmarshal(b, &got))
+		is.MustEqual(t, 3, len(got))
+		is.Equal(t, ResourceIdentifier{Type: "articles", ID: "1"}, got[0].(*Resource).Identifier())
+	})
+
+	t.Run("invalid resource", func(t *testing.T) {
+		t.Parallel()
+
+		var got []any
+		err := Unmarshal([]byte(`{"data":[{"type":"articles","id":"1","attributes":{"title":1}}]}`), &got, UnmarshalTypeRegistry(registry))
+		var fe *FieldError
+		is.MustEqual(t, true, errors.As(err, &fe))
+		is.Equal(t, "/data/0/attributes/title", fe.Pointer)
+	})
+
+	t.Run("not assignable", func(t *testing.T) {
+		t.Parallel()
+
+		var got []fmt.Stringer
+		err := Unmarshal(b, &got, UnmarshalTypeRegistry(registry))
+		is.EqualError(t, &TypeError{Actual: "*jsonapi.Article", Expected: []string{"fmt.Stringer"}}, err)
+	})
+}
@@ -0,0 +1,66 @@
+package jsonapi
+
+import "context"
+
+// RelationshipLinker returns links to add to the links of the relationship named relation of the
+// resource v, e.g. the pagination links of a paginated to-many relationship, or nil.
+type RelationshipLinker func(ctx context.Context, v any, relation string) *Link
+
+// MarshalRelationshipLinks adds the links returned by l to the links of every relationship, taking
+// precedence over those given by LinkableRelation. This allows servers to paginate to-many
+// relationships, as permitted by https://jsonapi.org/format/#document-resource-object-relationships,
+// based on the request being served rather than on the resources alone.
+//
+// As relationship links must have a self or related link, relationships with pagination links
+// should implement LinkableRelation, or l should return those links as well. The links returned by
+// l are also the top-level links of the documents of MarshalRef.
+func MarshalRelationshipLinks(l RelationshipLinker) MarshalOption {
+	return func(m *Marshaler) {
+		m.relationshipLinker = l
+	}
+}
+
+// relationLink returns the links of the relationship named relation of v, as given by
+// LinkableRelation and the Marshaler's RelationshipLinker, or nil if there are none.
+func (m *Marshaler) relationLink(v any, relation string) (*Link, error) {
+	var link *Link
+	if lv, ok := v.(LinkableRelation); ok {
+		link = lv.LinkRelation(relation)
+	}
+	if m.relationshipLinker != nil {
+		link = mergeLinks(link, m.relationshipLinker(m.context(), v, relation))
+	}
+	if link == nil {
+		return nil, nil
+	}
+	if err := link.check(); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// mergeLinks returns the links of l along with those of other, which take precedence. Neither l
+// nor other are modified.
+func mergeLinks(l, other *Link) *Link {
+	switch {
+	case other == nil:
+		return l
+	case l == nil:
+		return other
+	}
+
+	merged := *l
+	merged.Extra = make(map[string]any, len(l.Extra)+len(other.Extra))
+	for name, link := range l.Extra {
+		merged.Extra[name] = link
+	}
+	for _, nl := range other.namedLinks() {
+		if *nl.link != nil {
+			merged.Set(nl.name, *nl.link)
+		}
+	}
+	for name, link := range other.Extra {
+		merged.Extra[name] = link
+	}
+	return &merged
+}
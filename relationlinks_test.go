@@ -0,0 +1,82 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// paginateComments returns the pagination links of the comments relationship of articles.
+func paginateComments(ctx context.Context, v any, relation string) *Link {
+	a, ok := v.(*ArticleRelated)
+	if !ok || relation != "comments" {
+		return nil
+	}
+	return &Link{
+		First: fmt.Sprintf("http://example.com/articles/%s/relationships/comments?page[number]=1", a.ID),
+		Next:  fmt.Sprintf("http://example.com/articles/%s/relationships/comments?page[number]=2", a.ID),
+	}
+}
+
+func TestMarshalRelationshipLinks(t *testing.T) {
+	t.Parallel()
+
+	paginatedLinks := `"links":{"self":"http://example.com/articles/1/relationships/comments","related":"http://example.com/articles/1/comments","first":"http://example.com/articles/1/relationships/comments?page[number]=1","next":"http://example.com/articles/1/relationships/comments?page[number]=2"}`
+
+	tests := []struct {
+		description string
+		do          func() ([]byte, error)
+		expect      string
+		expectError error
+	}{
+		{
+			description: "pagination links",
+			do: func() ([]byte, error) {
+				return Marshal(&articleRelatedComments, MarshalRelationshipLinks(paginateComments))
+			},
+			expect: `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"comments":{"data":[{"id":"1","type":"comments"}],` + paginatedLinks + `}}}}`,
+		}, {
+			description: "relationship endpoint",
+			do: func() ([]byte, error) {
+				return MarshalRef(&articleRelatedComments, "comments", MarshalRelationshipLinks(paginateComments))
+			},
+			expect: `{"data":[{"id":"1","type":"comments"}],` + paginatedLinks + `}`,
+		}, {
+			description: "links override those of LinkableRelation",
+			do: func() ([]byte, error) {
+				linker := func(ctx context.Context, v any, relation string) *Link {
+					return &Link{Related: "http://example.com/" + relation}
+				}
+				return Marshal(&articleRelatedComments, MarshalRelationshipLinks(linker))
+			},
+			expect: `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"comments":{"data":[{"id":"1","type":"comments"}],"links":{"self":"http://example.com/articles/1/relationships/comments","related":"http://example.com/comments"}}}}}`,
+		}, {
+			description: "pagination links without self or related link",
+			do: func() ([]byte, error) {
+				linker := func(ctx context.Context, v any, relation string) *Link {
+					return &Link{Next: "http://example.com/next"}
+				}
+				return Marshal(&ArticleRelatedNoOmitEmpty{ID: "1", Title: "A"}, MarshalRelationshipLinks(linker))
+			},
+			expectError: ErrMissingLinkFields,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := tc.do()
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
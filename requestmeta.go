@@ -0,0 +1,81 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+// metaContextKey is the context key of the top-level meta members added by ContextWithMeta.
+type metaContextKey struct{}
+
+// ContextWithMeta returns a copy of ctx carrying the top-level meta member name with the given
+// value, e.g. for middleware to attach a request id, timing or deprecation notice to every document
+// marshaled with the context given by MarshalContext, without changing the handler.
+func ContextWithMeta(ctx context.Context, name string, value any) context.Context {
+	parent, _ := ctx.Value(metaContextKey{}).(map[string]any)
+	members := make(map[string]any, len(parent)+1)
+	for k, v := range parent {
+		members[k] = v
+	}
+	members[name] = value
+	return context.WithValue(ctx, metaContextKey{}, members)
+}
+
+// MarshalMetaFunc adds the members of the map returned by f to the top-level meta of the document,
+// where f is called with the context given by MarshalContext. Request-scoped metadata can so be
+// added to documents by the options shared across handlers. Members returned by f take precedence
+// over those of the same name given by ContextWithMeta and MarshalMeta, and several functions can
+// be given.
+func MarshalMetaFunc(f func(ctx context.Context) map[string]any) MarshalOption {
+	return func(m *Marshaler) {
+		m.metaFuncs = append(m.metaFuncs, f)
+	}
+}
+
+// documentMeta returns the top-level meta of the document: the meta given by MarshalMeta, with the
+// members given by ContextWithMeta and MarshalMetaFunc added.
+func (m *Marshaler) documentMeta() (any, error) {
+	ctx := m.context()
+
+	members, _ := ctx.Value(metaContextKey{}).(map[string]any)
+	if len(m.metaFuncs) > 0 {
+		merged := make(map[string]any, len(members))
+		for k, v := range members {
+			merged[k] = v
+		}
+		for _, f := range m.metaFuncs {
+			for k, v := range f(ctx) {
+				merged[k] = v
+			}
+		}
+		members = merged
+	}
+	return mergeMeta(m.meta, members)
+}
+
+// mergeMeta returns the meta object given by the map or struct meta with the given members added,
+// overriding members of the same name.
+func mergeMeta(meta any, members map[string]any) (any, error) {
+	if len(members) == 0 {
+		return meta, nil
+	}
+
+	merged := make(map[string]any, len(members))
+	if meta != nil {
+		// the given meta may be any map or struct, so it is merged as a json object
+		b, err := marshalJSON(meta)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		if err := dec.Decode(&merged); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range members {
+		merged[k] = v
+	}
+	return merged, nil
+}
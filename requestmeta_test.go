@@ -0,0 +1,64 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalRequestMeta(t *testing.T) {
+	t.Parallel()
+
+	ctx := ContextWithMeta(context.Background(), "requestId", "abc")
+	ctx = ContextWithMeta(ctx, "deprecation", "use /v2/articles")
+	elapsed := func(ctx context.Context) map[string]any {
+		return map[string]any{"elapsed": 12}
+	}
+	article := &Article{ID: "1", Title: "A"}
+
+	tests := []struct {
+		description string
+		opts        []MarshalOption
+		expect      string
+	}{
+		{
+			description: "context",
+			opts:        []MarshalOption{MarshalContext(ctx)},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"meta":{"deprecation":"use /v2/articles","requestId":"abc"}}`,
+		}, {
+			description: "function",
+			opts:        []MarshalOption{MarshalMetaFunc(elapsed)},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"meta":{"elapsed":12}}`,
+		}, {
+			description: "merged with struct meta",
+			opts:        []MarshalOption{MarshalMeta(&struct{ Count int64 }{Count: 9007199254740993}), MarshalContext(ctx), MarshalMetaFunc(elapsed)},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"meta":{"Count":9007199254740993,"deprecation":"use /v2/articles","elapsed":12,"requestId":"abc"}}`,
+		}, {
+			description: "precedence",
+			opts: []MarshalOption{
+				MarshalMeta(map[string]any{"requestId": "meta"}),
+				MarshalContext(ContextWithMeta(context.Background(), "requestId", "context")),
+				MarshalMetaFunc(func(ctx context.Context) map[string]any { return map[string]any{"requestId": "func"} }),
+			},
+			expect: `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"meta":{"requestId":"func"}}`,
+		}, {
+			description: "meta only",
+			opts:        []MarshalOption{MarshalMeta(map[string]any{"count": 1})},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"meta":{"count":1}}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(article, tc.opts...)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
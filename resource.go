@@ -0,0 +1,300 @@
+package jsonapi
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Resource is a resource object whose type, attributes and relationships are only known at
+// runtime, for gateways, admin tools and tests dealing with arbitrary resource types. It can be
+// marshaled and unmarshaled like a struct with jsonapi tags, in collections and as included
+// resources as well:
+//
+//	r := jsonapi.NewResource("articles", "1").
+//		SetAttr("title", "Hello World").
+//		SetToOne("author", &jsonapi.ResourceIdentifier{Type: "people", ID: "9"})
+//	b, err := jsonapi.Marshal(r)
+//
+// Relationships are made of resource linkage only, so relationships without data are unmarshaled
+// as null to-one relationships. The zero value is an empty resource ready to use.
+type Resource struct {
+	typ           string
+	id            string
+	attributes    map[string]any
+	relationships map[string]*resourceLinkage
+	meta          map[string]any
+	links         *Link
+}
+
+// ResourceMarshaler can be implemented by resources to take full control of their resource object
+// when the representation given by their struct tags isn't enough, analogously to json.Marshaler.
+// The attributes, relationships, meta and links of the returned Resource make up the resource
+// object, and the struct tags of the resource are ignored. Relationships are made of resource
+// linkage only, so included resources must be given via MarshalInclude.
+type ResourceMarshaler interface {
+	MarshalResource() (*Resource, error)
+}
+
+// ResourceUnmarshaler can be implemented by resources to take full control of unmarshaling their
+// resource object, analogously to json.Unmarshaler. UnmarshalResource is called with the resource
+// object as a Resource in place of unmarshaling it into the fields given by the struct tags of the
+// resource.
+type ResourceUnmarshaler interface {
+	UnmarshalResource(r *Resource) error
+}
+
+// resourceLinkage is the resource linkage of a relationship of a Resource.
+type resourceLinkage struct {
+	toMany      bool
+	identifiers []ResourceIdentifier
+}
+
+// linkageOf returns the resource linkage of the relationship document d.
+func linkageOf(d *document) *resourceLinkage {
+	linkage := &resourceLinkage{toMany: d.hasMany}
+	identifiers := d.DataMany
+	if !d.hasMany && d.DataOne != nil {
+		identifiers = []*resourceObject{d.DataOne}
+	}
+	for _, ri := range identifiers {
+		linkage.identifiers = append(linkage.identifiers, ResourceIdentifier{Type: ri.Type, ID: ri.ID})
+	}
+	return linkage
+}
+
+// NewResource returns a new Resource of the given type and id.
+func NewResource(typ, id string) *Resource {
+	return &Resource{typ: typ, id: id}
+}
+
+// Type returns the type of the resource.
+func (r *Resource) Type() string {
+	return r.typ
+}
+
+// ID returns the id of the resource.
+func (r *Resource) ID() string {
+	return r.id
+}
+
+// Identifier returns the identifier of the resource, e.g. to relate another resource to it.
+func (r *Resource) Identifier() ResourceIdentifier {
+	return ResourceIdentifier{Type: r.typ, ID: r.id}
+}
+
+// SetAttr sets the attribute with the given name to value, which is encoded like encoding/json
+// does, and returns r.
+func (r *Resource) SetAttr(name string, value any) *Resource {
+	if r.attributes == nil {
+		r.attributes = make(map[string]any)
+	}
+	r.attributes[name] = value
+	return r
+}
+
+// Attr returns the value of the attribute with the given name, and whether it is set. Unmarshaled
+// attributes are decoded like encoding/json does into an interface value.
+func (r *Resource) Attr(name string) (any, bool) {
+	value, ok := r.attributes[name]
+	return value, ok
+}
+
+// Attributes returns a copy of the attributes of the resource, by name.
+func (r *Resource) Attributes() map[string]any {
+	attributes := make(map[string]any, len(r.attributes))
+	for name, value := range r.attributes {
+		attributes[name] = value
+	}
+	return attributes
+}
+
+// SetToOne sets the to-one relationship with the given name to the resource identified by ref, or
+// to null if ref is nil, and returns r.
+func (r *Resource) SetToOne(name string, ref *ResourceIdentifier) *Resource {
+	linkage := &resourceLinkage{}
+	if ref != nil {
+		linkage.identifiers = []ResourceIdentifier{*ref}
+	}
+	return r.setRelationship(name, linkage)
+}
+
+// SetToMany sets the to-many relationship with the given name to the resources identified by refs,
+// which may be empty, and returns r.
+func (r *Resource) SetToMany(name string, refs ...ResourceIdentifier) *Resource {
+	identifiers := make([]ResourceIdentifier, len(refs))
+	copy(identifiers, refs)
+	return r.setRelationship(name, &resourceLinkage{toMany: true, identifiers: identifiers})
+}
+
+func (r *Resource) setRelationship(name string, linkage *resourceLinkage) *Resource {
+	if r.relationships == nil {
+		r.relationships = make(map[string]*resourceLinkage)
+	}
+	r.relationships[name] = linkage
+	return r
+}
+
+// ToOne returns the identifier of the related resource of the to-one relationship with the given
+// name, or nil if it is null. ok is false if the resource has no such to-one relationship.
+func (r *Resource) ToOne(name string) (ref *ResourceIdentifier, ok bool) {
+	linkage, ok := r.relationships[name]
+	if !ok || linkage.toMany {
+		return nil, false
+	}
+	if len(linkage.identifiers) == 0 {
+		return nil, true
+	}
+	ri := linkage.identifiers[0]
+	return &ri, true
+}
+
+// ToMany returns the identifiers of the related resources of the to-many relationship with the
+// given name. ok is false if the resource has no such to-many relationship.
+func (r *Resource) ToMany(name string) (refs []ResourceIdentifier, ok bool) {
+	linkage, ok := r.relationships[name]
+	if !ok || !linkage.toMany {
+		return nil, false
+	}
+	refs = make([]ResourceIdentifier, len(linkage.identifiers))
+	copy(refs, linkage.identifiers)
+	return refs, true
+}
+
+// Relationships returns the names of the relationships of the resource, in sorted order.
+func (r *Resource) Relationships() []string {
+	names := make([]string, 0, len(r.relationships))
+	for name := range r.relationships {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetMeta sets the meta of the resource object and returns r.
+func (r *Resource) SetMeta(meta map[string]any) *Resource {
+	r.meta = meta
+	return r
+}
+
+// Meta returns the meta of the resource object, if any.
+func (r *Resource) Meta() map[string]any {
+	return r.meta
+}
+
+// SetLinks sets the links of the resource object and returns r.
+func (r *Resource) SetLinks(links *Link) *Resource {
+	r.links = links
+	return r
+}
+
+// Links returns the links of the resource object, if any.
+func (r *Resource) Links() *Link {
+	return r.links
+}
+
+// resourceObject makes the resource object of r, or its resource identifier object only if
+// isRelationship is true.
+func (r *Resource) resourceObject(m *Marshaler, isRelationship bool) (*resourceObject, error) {
+	if r.typ == "" {
+		return nil, ErrMissingTypeField
+	}
+	if !isValidMemberName(r.typ, m.relaxedMemberClasses.modeFor(TypeMembers, m.memberNameValidationMode)) {
+		// type names count as member names
+		return nil, &MemberNameValidationError{MemberName: r.typ, Pointer: "/type"}
+	}
+	if r.id == "" && !m.clientMode {
+		return nil, ErrEmptyPrimaryField
+	}
+
+	ro := &resourceObject{
+		Type:          r.typ,
+		ID:            r.id,
+		Attributes:    make(map[string]any, 0),
+		Relationships: make(map[string]*document, 0),
+	}
+	if isRelationship {
+		return ro, nil
+	}
+
+	for name, value := range r.attributes {
+		if !isValidMemberName(name, m.relaxedMemberClasses.modeFor(AttributeMembers, m.memberNameValidationMode)) {
+			return nil, &MemberNameValidationError{MemberName: name, Pointer: "/attributes/" + escapePointerToken(name)}
+		}
+		ro.Attributes[name] = value
+	}
+
+	for name, linkage := range r.relationships {
+		pointer := "/relationships/" + escapePointerToken(name)
+		if !isValidMemberName(name, m.memberNameValidationMode) {
+			return nil, &MemberNameValidationError{MemberName: name, Pointer: pointer}
+		}
+		d := newDocument()
+		d.hasMany = linkage.toMany
+		for i, ri := range linkage.identifiers {
+			if ri.Type == "" {
+				p := pointer + "/data"
+				if linkage.toMany {
+					p += fmt.Sprintf("/%d", i)
+				}
+				return nil, &FieldError{Code: CodeInvalidRelationship, Member: name, Pointer: p + "/type", Err: ErrMissingTypeField}
+			}
+			if ri.ID == "" && !m.clientMode {
+				return nil, ErrEmptyPrimaryField
+			}
+			related := &resourceObject{Type: ri.Type, ID: ri.ID}
+			if linkage.toMany {
+				d.DataMany = append(d.DataMany, related)
+			} else {
+				d.DataOne = related
+			}
+		}
+		ro.Relationships[name] = d
+	}
+
+	if r.meta != nil {
+		ro.Meta = r.meta
+	}
+	if r.links != nil {
+		if err := r.links.check(); err != nil {
+			return nil, err
+		}
+		ro.Links = r.links
+	}
+
+	return ro, nil
+}
+
+// unmarshalResource sets r to the resource object ro.
+func (ro *resourceObject) unmarshalResource(r *Resource, m *Unmarshaler) error {
+	if !isValidMemberName(ro.Type, m.relaxedMemberClasses.modeFor(TypeMembers, m.memberNameValidationMode)) {
+		// type names count as member names
+		return &FieldError{
+			Code:    CodeInvalidType,
+			Member:  "type",
+			Pointer: "/type",
+			Err:     &MemberNameValidationError{MemberName: ro.Type, Pointer: "/type"},
+		}
+	}
+
+	*r = Resource{typ: ro.Type, id: ro.ID, links: ro.Links}
+
+	if ro.hasAttributes() {
+		if err := m.decodeJSON(ro.rawAttributes, &r.attributes); err != nil {
+			return &FieldError{Code: CodeInvalidAttribute, Member: "attributes", Pointer: "/attributes", Err: err}
+		}
+	}
+
+	for name, rel := range ro.Relationships {
+		r.setRelationship(name, linkageOf(rel))
+	}
+
+	if ro.Meta != nil {
+		meta, ok := ro.Meta.(map[string]any)
+		if !ok {
+			return &FieldError{Code: CodeInvalidMeta, Member: "meta", Pointer: "/meta", Err: &TypeError{Actual: fmt.Sprintf("%T", ro.Meta), Expected: []string{"object"}}}
+		}
+		r.meta = meta
+	}
+
+	return nil
+}
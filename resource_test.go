@@ -0,0 +1,241 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestResourceMarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+		expectError error
+	}{
+		{
+			description: "attributes and relationships",
+			given: NewResource("articles", "1").
+				SetAttr("title", "A").
+				SetToOne("author", &ResourceIdentifier{Type: "author", ID: "1"}).
+				SetToMany("comments", ResourceIdentifier{Type: "comments", ID: "1"}),
+			expect: `{"data":{"type":"articles","id":"1","attributes":{"title":"A"},"relationships":{"author":{"data":{"type":"author","id":"1"}},"comments":{"data":[{"type":"comments","id":"1"}]}}}}`,
+		}, {
+			description: "empty relationships",
+			given:       NewResource("articles", "1").SetToOne("author", nil).SetToMany("comments"),
+			expect:      `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":null},"comments":{"data":[]}}}}`,
+		}, {
+			description: "meta and links",
+			given:       NewResource("articles", "1").SetMeta(map[string]any{"count": 1}).SetLinks(&Link{Self: "http://example.com/articles/1"}),
+			expect:      `{"data":{"type":"articles","id":"1","meta":{"count":1},"links":{"self":"http://example.com/articles/1"}}}`,
+		}, {
+			description: "collection of values",
+			given:       []Resource{*NewResource("articles", "1"), *NewResource("comments", "2")},
+			expect:      `{"data":[{"type":"articles","id":"1"},{"type":"comments","id":"2"}]}`,
+		}, {
+			description: "included",
+			given:       NewResource("articles", "1").SetToOne("author", &ResourceIdentifier{Type: "author", ID: "1"}),
+			opts:        []MarshalOption{MarshalInclude(NewResource("author", "1").SetAttr("name", "A"))},
+			expect:      `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":{"type":"author","id":"1"}}}},"included":[{"type":"author","id":"1","attributes":{"name":"A"}}]}`,
+		}, {
+			description: "struct relationship",
+			given:       &ArticleWithResourceAuthor{ID: "1", Author: NewResource("author", "1").SetAttr("name", "A")},
+			expect:      `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":{"type":"author","id":"1"}}}}}`,
+		}, {
+			description: "zero value",
+			given:       Resource{},
+			expect:      `{"data":null}`,
+		}, {
+			description: "missing type",
+			given:       NewResource("", "1"),
+			expectError: ErrMissingTypeField,
+		}, {
+			description: "empty id",
+			given:       NewResource("articles", ""),
+			expectError: ErrEmptyPrimaryField,
+		}, {
+			description: "empty id in client mode",
+			given:       NewResource("articles", "").SetAttr("title", "A"),
+			opts:        []MarshalOption{MarshalClientMode()},
+			expect:      `{"data":{"type":"articles","attributes":{"title":"A"}}}`,
+		}, {
+			description: "invalid attribute name",
+			given:       NewResource("articles", "1").SetAttr("ti%tle", "A"),
+			expectError: &MemberNameValidationError{MemberName: "ti%tle"},
+		}, {
+			description: "missing related type",
+			given:       NewResource("articles", "1").SetToMany("comments", ResourceIdentifier{ID: "1"}),
+			expectError: &FieldError{Code: CodeInvalidRelationship, Member: "comments", Pointer: "/data/relationships/comments/data/0/type", Err: ErrMissingTypeField},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestResourceUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	var r Resource
+	err := Unmarshal([]byte(`{"data":{"type":"articles","id":"1","attributes":{"title":"A","count":2},"relationships":{"author":{"data":{"type":"author","id":"1"}},"reviewer":{"data":null},"comments":{"data":[{"type":"comments","id":"1"}]}},"meta":{"a":"b"},"links":{"self":"http://example.com/articles/1"}}}`), &r)
+	is.MustNoError(t, err)
+
+	is.Equal(t, ResourceIdentifier{Type: "articles", ID: "1"}, r.Identifier())
+	is.Equal(t, map[string]any{"title": "A", "count": float64(2)}, r.Attributes())
+	title, ok := r.Attr("title")
+	is.Equal(t, true, ok)
+	is.Equal(t, any("A"), title)
+
+	is.Equal(t, []string{"author", "comments", "reviewer"}, r.Relationships())
+	author, ok := r.ToOne("author")
+	is.Equal(t, true, ok)
+	is.Equal(t, &ResourceIdentifier{Type: "author", ID: "1"}, author)
+	reviewer, ok := r.ToOne("reviewer")
+	is.Equal(t, true, ok)
+	is.Equal(t, (*ResourceIdentifier)(nil), reviewer)
+	comments, ok := r.ToMany("comments")
+	is.Equal(t, true, ok)
+	is.Equal(t, []ResourceIdentifier{{Type: "comments", ID: "1"}}, comments)
+	_, ok = r.ToMany("author")
+	is.Equal(t, false, ok)
+
+	is.Equal(t, map[string]any{"a": "b"}, r.Meta())
+	is.Equal(t, &Link{Self: "http://example.com/articles/1"}, r.Links())
+}
+
+func TestResourceUnmarshalCollection(t *testing.T) {
+	t.Parallel()
+
+	var rs []*Resource
+	err := Unmarshal([]byte(`{"data":[{"type":"articles","id":"1","attributes":{"count":2}},{"type":"comments","id":"2"}]}`), &rs, UnmarshalUseNumber())
+	is.MustNoError(t, err)
+	is.MustEqual(t, 2, len(rs))
+
+	count, _ := rs[0].Attr("count")
+	is.Equal(t, any(json.Number("2")), count)
+	is.Equal(t, ResourceIdentifier{Type: "comments", ID: "2"}, rs[1].Identifier())
+	is.Equal(t, map[string]any{}, rs[1].Attributes())
+}
+
+func TestResourceRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	b, err := Marshal(&articleA)
+	is.MustNoError(t, err)
+
+	var r Resource
+	is.MustNoError(t, Unmarshal(b, &r))
+
+	got, err := Marshal(&r)
+	is.MustNoError(t, err)
+	is.EqualJSON(t, string(b), string(got))
+}
+
+func TestResourceMarshaler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		expect      string
+		expectError error
+	}{
+		{
+			description: "resource",
+			given:       &ArticleCustom{ID: "1", Words: []string{"Hello", "World"}, AuthorID: "1"},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"Hello World"},"relationships":{"author":{"data":{"type":"author","id":"1"}}}}}`,
+		}, {
+			description: "collection",
+			given:       []*ArticleCustom{{ID: "1", Words: []string{"A"}}, {ID: "2", Words: []string{"B"}}},
+			expect:      `{"data":[{"type":"articles","id":"1","attributes":{"title":"A"}},{"type":"articles","id":"2","attributes":{"title":"B"}}]}`,
+		}, {
+			description: "relationship",
+			given:       &ArticleWithCustomAuthor{ID: "1", Author: &ArticleCustom{ID: "2", Words: []string{"A"}}},
+			expect:      `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":{"type":"articles","id":"2"}}}}}`,
+		}, {
+			description: "error",
+			given:       &ArticleCustom{ID: "1"},
+			expectError: errArticleCustomTitle,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given)
+			if tc.expectError != nil {
+				is.Equal(t, true, errors.Is(err, tc.expectError))
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestResourceUnmarshaler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("resource", func(t *testing.T) {
+		t.Parallel()
+
+		var a ArticleCustom
+		err := Unmarshal([]byte(`{"data":{"type":"articles","id":"1","attributes":{"title":"Hello World"},"relationships":{"author":{"data":{"type":"author","id":"1"}}}}}`), &a)
+		is.MustNoError(t, err)
+		is.Equal(t, ArticleCustom{ID: "1", Words: []string{"Hello", "World"}, AuthorID: "1"}, a)
+	})
+
+	t.Run("collection", func(t *testing.T) {
+		t.Parallel()
+
+		var as []*ArticleCustom
+		err := Unmarshal([]byte(`{"data":[{"type":"articles","id":"1","attributes":{"title":"A"}},{"type":"articles","id":"2","attributes":{"title":"B"}}]}`), &as)
+		is.MustNoError(t, err)
+		is.Equal(t, []*ArticleCustom{{ID: "1", Words: []string{"A"}}, {ID: "2", Words: []string{"B"}}}, as)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		var a ArticleCustom
+		err := Unmarshal([]byte(`{"data":{"type":"articles","id":"1"}}`), &a)
+		is.Equal(t, true, errors.Is(err, errArticleCustomTitle))
+		var re *ResourceError
+		is.MustEqual(t, true, errors.As(err, &re))
+		is.Equal(t, "/data", re.Pointer)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+
+		given := &ArticleCustom{ID: "1", Words: []string{"Hello", "World"}, AuthorID: "1"}
+		b, err := Marshal(given)
+		is.MustNoError(t, err)
+
+		var got ArticleCustom
+		is.MustNoError(t, Unmarshal(b, &got))
+		is.Equal(t, given, &got)
+	})
+}
@@ -0,0 +1,62 @@
+package jsonapi
+
+import "reflect"
+
+// ResourceBase can be embedded in resource structs to hold their id, links and meta, and
+// optionally override their resource type, without declaring the fields and implementing Linkable
+// and LinkUnmarshaler. The resource type is given by the jsonapi tag of the embedded field, as it
+// would be by the primary field:
+//
+//	type Article struct {
+//		jsonapi.ResourceBase `jsonapi:"primary,articles"`
+//		Title                string `jsonapi:"attribute" json:"title"`
+//	}
+//
+//	a := Article{ResourceBase: jsonapi.ResourceBase{ID: "1", Links: &jsonapi.Link{Self: "/articles/1"}}}
+//
+// Structs embedding ResourceBase may still implement Linkable or LinkUnmarshaler themselves, which
+// takes precedence over the methods of ResourceBase.
+type ResourceBase struct {
+	// ID is the id of the resource.
+	ID string `jsonapi:"primary"`
+
+	// Type overrides the resource type given by the tag of the embedded field when marshaling, if
+	// not empty. Resource objects are unmarshaled if their type is the one given by the tag only.
+	Type string `json:"-"`
+
+	// Links are the links of the resource object.
+	Links *Link `json:"-"`
+
+	// Meta is the meta of the resource object.
+	Meta map[string]any `jsonapi:"meta"`
+}
+
+var resourceBaseType = reflect.TypeOf(ResourceBase{})
+
+// Link implements the Linkable interface.
+func (b ResourceBase) Link() *Link {
+	return b.Links
+}
+
+// UnmarshalLink implements the LinkUnmarshaler interface.
+func (b *ResourceBase) UnmarshalLink(link *Link) error {
+	b.Links = link
+	return nil
+}
+
+// resourceTypeOverride returns the resource type overriding the one given by the struct tags, if
+// any.
+func (b ResourceBase) resourceTypeOverride() string {
+	return b.Type
+}
+
+// resourceTypeOverrider is implemented by resource structs embedding ResourceBase.
+type resourceTypeOverrider interface {
+	resourceTypeOverride() string
+}
+
+// resourceBasePrimaryTag returns the struct tag of the primary field of the embedded ResourceBase
+// field f, i.e. the jsonapi tag of f.
+func resourceBasePrimaryTag(f reflect.StructField) reflect.StructTag {
+	return reflect.StructTag(`jsonapi:"` + f.Tag.Get("jsonapi") + `"`)
+}
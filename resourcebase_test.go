@@ -0,0 +1,82 @@
+package jsonapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// ArticleBase is an article embedding ResourceBase.
+type ArticleBase struct {
+	ResourceBase `jsonapi:"primary,articles"`
+	Title        string  `jsonapi:"attribute" json:"title"`
+	Author       *Author `jsonapi:"relationship" json:"author,omitempty"`
+}
+
+func TestResourceBase(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       *ArticleBase
+		expect      string
+	}{
+		{
+			description: "id",
+			given:       &ArticleBase{ResourceBase: ResourceBase{ID: "1"}, Title: "A"},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}}}`,
+		}, {
+			description: "links and meta",
+			given: &ArticleBase{
+				ResourceBase: ResourceBase{ID: "1", Links: &Link{Self: "http://example.com/articles/1"}, Meta: map[string]any{"views": 1.0}},
+				Title:        "A",
+				Author:       &Author{ID: "1", Name: "B"},
+			},
+			expect: `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"}}},"meta":{"views":1},"links":{"self":"http://example.com/articles/1"}}}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+
+			var a ArticleBase
+			is.MustNoError(t, Unmarshal(b, &a))
+			if tc.given.Author != nil {
+				tc.given.Author = &Author{ID: tc.given.Author.ID}
+			}
+			is.Equal(t, tc.given, &a)
+		})
+	}
+}
+
+func TestResourceBaseType(t *testing.T) {
+	t.Parallel()
+
+	b, err := Marshal(&ArticleBase{ResourceBase: ResourceBase{ID: "1", Type: "drafts"}})
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":{"id":"1","type":"drafts","attributes":{"title":""}}}`, string(b))
+
+	// resource objects are unmarshaled if their type is the one given by the tag only
+	var a ArticleBase
+	err = Unmarshal(b, &a)
+	is.MustError(t, err)
+
+	s, err := SchemaOf((*ArticleBase)(nil))
+	is.MustNoError(t, err)
+	is.Equal(t, "articles", s.Type)
+	is.Equal(t, "ID", s.IDField)
+
+	// the resource type is given by the tag of the embedded field
+	_, err = Marshal(&struct {
+		ResourceBase
+	}{ResourceBase: ResourceBase{ID: "1"}})
+	is.EqualError(t, ErrMissingPrimaryField, err)
+}
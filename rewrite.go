@@ -0,0 +1,138 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RewriteIdentifiers returns the given json:api document with the type and id of every resource
+// object and resource identifier replaced with the result of f, e.g. for API gateways remapping the
+// identifiers of an upstream service into their own namespace.
+//
+// Identifiers are rewritten in primary data, relationships and included resources alike, so resource
+// linkage is preserved as long as f is deterministic. f is called with an empty id for resource
+// objects without one (e.g. those created by clients), whose type is rewritten only. Members other
+// than type and id, including extension members, are left as they are.
+//
+// RewriteIdentifiers returns a ResourceError wrapping ErrIdentifierConflict if f rewrites distinct
+// resources of the document to the same identifier.
+func RewriteIdentifiers(data []byte, f func(typ, id string) (string, string)) ([]byte, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+
+	r := &identifierRewriter{f: f, rewritten: make(map[string]string)}
+	for _, member := range []string{"data", "included"} {
+		raw, ok := members[member]
+		if !ok {
+			continue
+		}
+		b, err := r.rewriteResources(raw, "/"+member, true)
+		if err != nil {
+			return nil, err
+		}
+		members[member] = b
+	}
+
+	return json.Marshal(members)
+}
+
+// identifierRewriter rewrites the identifiers of a single document.
+type identifierRewriter struct {
+	f func(typ, id string) (string, string)
+
+	// rewritten maps the rewritten identifiers of resource objects to their original ones
+	rewritten map[string]string
+}
+
+// rewriteResources rewrites primary data, included resources or resource linkage: null, a single
+// resource object or an array of them. Only full resource objects are checked for conflicts, as
+// resource linkage refers to them.
+func (r *identifierRewriter) rewriteResources(raw json.RawMessage, pointer string, full bool) (json.RawMessage, error) {
+	switch kind := jsonKindOf(raw); kind {
+	case "null":
+		return raw, nil
+	case "object":
+		return r.rewriteResource(raw, pointer, full)
+	case "array":
+		var ros []json.RawMessage
+		if err := json.Unmarshal(raw, &ros); err != nil {
+			return nil, err
+		}
+		for i, ro := range ros {
+			b, err := r.rewriteResource(ro, fmt.Sprintf("%s/%d", pointer, i), full)
+			if err != nil {
+				return nil, err
+			}
+			ros[i] = b
+		}
+		return json.Marshal(ros)
+	default:
+		return nil, &DocumentError{
+			Code:    CodeInvalidData,
+			Pointer: pointer,
+			Err:     &TypeError{Actual: kind, Expected: []string{"object", "array", "null"}},
+		}
+	}
+}
+
+// rewriteResource rewrites a single resource object or resource identifier, along with the resource
+// linkage of its relationships.
+func (r *identifierRewriter) rewriteResource(raw json.RawMessage, pointer string, full bool) (json.RawMessage, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return nil, err
+	}
+
+	var typ, id string
+	if err := json.Unmarshal(members["type"], &typ); err != nil || typ == "" {
+		return nil, &ResourceError{Code: CodeInvalidType, Pointer: pointer, Err: ErrMissingTypeField}
+	}
+	rawID, hasID := members["id"]
+	if hasID {
+		if err := json.Unmarshal(rawID, &id); err != nil {
+			return nil, &ResourceError{Code: CodeInvalidID, Type: typ, Pointer: pointer, Err: ErrUnmarshalInvalidPrimaryField}
+		}
+	}
+
+	newType, newID := r.f(typ, id)
+	if full && hasID {
+		key := newType + "/" + newID
+		if original, ok := r.rewritten[key]; ok && original != typ+"/"+id {
+			return nil, &ResourceError{Code: CodeInvalidID, Type: newType, ID: newID, Pointer: pointer, Err: ErrIdentifierConflict}
+		}
+		r.rewritten[key] = typ + "/" + id
+	}
+
+	var err error
+	if members["type"], err = json.Marshal(newType); err != nil {
+		return nil, err
+	}
+	if hasID {
+		if members["id"], err = json.Marshal(newID); err != nil {
+			return nil, err
+		}
+	}
+
+	if rawRels, ok := members["relationships"]; ok && full {
+		var rels map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(rawRels, &rels); err != nil {
+			return nil, err
+		}
+		for name, rel := range rels {
+			linkage, ok := rel["data"]
+			if !ok {
+				continue
+			}
+			if rel["data"], err = r.rewriteResources(linkage, pointer+"/relationships/"+escapePointerToken(name)+"/data", false); err != nil {
+				return nil, err
+			}
+		}
+		if members["relationships"], err = json.Marshal(rels); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(members)
+}
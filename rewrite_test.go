@@ -0,0 +1,117 @@
+package jsonapi
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// upstreamIdentifiers prefixes types with "upstream-" and ids with "u".
+func upstreamIdentifiers(typ, id string) (string, string) {
+	if id == "" {
+		return "upstream-" + typ, ""
+	}
+	return "upstream-" + typ, "u" + id
+}
+
+func TestRewriteIdentifiers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		f           func(typ, id string) (string, string)
+		expect      string
+		expectError error
+	}{
+		{
+			description: "single resource",
+			given:       articleABody,
+			f:           upstreamIdentifiers,
+			expect:      `{"data":{"type":"upstream-articles","id":"u1","attributes":{"title":"A"}}}`,
+		}, {
+			description: "many resources",
+			given:       articlesABBody,
+			f:           upstreamIdentifiers,
+			expect:      `{"data":[{"type":"upstream-articles","id":"u1","attributes":{"title":"A"}},{"type":"upstream-articles","id":"u2","attributes":{"title":"B"}}]}`,
+		}, {
+			description: "null data",
+			given:       `{"data":null,"meta":{"count":0}}`,
+			f:           upstreamIdentifiers,
+			expect:      `{"data":null,"meta":{"count":0}}`,
+		}, {
+			description: "relationships and included",
+			given:       articleRelatedCommentsWithIncludeBody,
+			f:           upstreamIdentifiers,
+			expect:      `{"data":{"id":"u1","type":"upstream-articles","attributes":{"title":"A"},"relationships":{"comments":{"data":[{"id":"u1","type":"upstream-comments"}],"links":{"self":"http://example.com/articles/1/relationships/comments","related":"http://example.com/articles/1/comments"}}}},"included":[{"id":"u1","type":"upstream-comments","attributes":{"body":"A"},"relationships":{"author":{"data":{"id":"u1","type":"upstream-author"},"links":{"self":"http://example.com/comments/1/relationships/author","related":"http://example.com/comments/1/author"}}}}]}`,
+		}, {
+			description: "resource without id",
+			given:       `{"data":{"type":"articles","lid":"a","attributes":{"title":"A"}}}`,
+			f:           upstreamIdentifiers,
+			expect:      `{"data":{"type":"upstream-articles","lid":"a","attributes":{"title":"A"}}}`,
+		}, {
+			description: "identifier meta and extension members",
+			given:       `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":{"type":"author","id":"1","meta":{"primary":true}}}}},"version:id":"1"}`,
+			f:           upstreamIdentifiers,
+			expect:      `{"data":{"type":"upstream-articles","id":"u1","relationships":{"author":{"data":{"type":"upstream-author","id":"u1","meta":{"primary":true}}}}},"version:id":"1"}`,
+		}, {
+			description: "errors",
+			given:       errorsSimpleStructBody,
+			f:           upstreamIdentifiers,
+			expect:      errorsSimpleStructBody,
+		}, {
+			description: "conflicting identifiers",
+			given:       articlesABBody,
+			f:           func(typ, id string) (string, string) { return typ, "1" },
+			expectError: &ResourceError{Code: CodeInvalidID, Type: "articles", ID: "1", Pointer: "/data/1", Err: ErrIdentifierConflict},
+		}, {
+			description: "missing type",
+			given:       `{"data":{"id":"1"}}`,
+			f:           upstreamIdentifiers,
+			expectError: &ResourceError{Code: CodeInvalidType, Pointer: "/data", Err: ErrMissingTypeField},
+		}, {
+			description: "invalid data",
+			given:       `{"data":"1"}`,
+			f:           upstreamIdentifiers,
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: &TypeError{Actual: "string", Expected: []string{"object", "array", "null"}}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := RewriteIdentifiers([]byte(tc.given), tc.f)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestRewriteIdentifiersLinkage(t *testing.T) {
+	t.Parallel()
+
+	b, err := Marshal(&articleRelatedComplete, MarshalInclude(&authorAWithMeta, &commentA, &commentB))
+	is.MustNoError(t, err)
+
+	b, err = RewriteIdentifiers(b, func(typ, id string) (string, string) {
+		return typ, strings.Repeat(id, 2)
+	})
+	is.MustNoError(t, err)
+
+	var article ArticleRelated
+	is.MustNoError(t, Unmarshal(b, &article))
+	is.Equal(t, "11", article.ID)
+	is.Equal(t, "11", article.Author.ID)
+	is.Equal(t, "A", article.Author.Name)
+	is.Equal(t, "22", article.Comments[1].ID)
+	is.Equal(t, "B", article.Comments[1].Body)
+}
@@ -0,0 +1,273 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// checkDocument returns an error if the json encoded document data exceeds the limits given by
+// UnmarshalLimits, or has duplicate members if rejected as configured by
+// UnmarshalRejectDuplicateMembers. The document is tokenized rather than decoded, so that hostile
+// documents are rejected as soon as a limit is exceeded. If linkage is true, the primary data of the
+// document is resource linkage, as sent to relationship endpoints.
+func (m *Unmarshaler) checkDocument(data []byte, linkage bool) error {
+	if m.limits.MaxBytes > 0 && int64(len(data)) > m.limits.MaxBytes {
+		return &LimitError{Limit: "MaxBytes", Max: m.limits.MaxBytes}
+	}
+	return m.newDocumentScanner(linkage).scan(data, nil, 0)
+}
+
+// documentScanner tokenizes documents, or parts of them, to enforce DecodeLimits and reject
+// duplicate members before they are decoded.
+type documentScanner struct {
+	limits           DecodeLimits
+	rejectDuplicates bool
+
+	// dataMember is the name of the member holding primary data
+	dataMember string
+
+	// linkage is true if the primary data is resource linkage
+	linkage bool
+}
+
+// newDocumentScanner creates a documentScanner checking documents as configured for m.
+func (m *Unmarshaler) newDocumentScanner(linkage bool) *documentScanner {
+	s := &documentScanner{limits: m.limits, rejectDuplicates: m.rejectDuplicateMembers, dataMember: "data", linkage: linkage}
+	if m.dataMember != "" && !linkage {
+		s.dataMember = m.dataMember
+	}
+	return s
+}
+
+// enabled returns true if s has anything to check while tokenizing.
+func (s *documentScanner) enabled() bool {
+	l := s.limits
+	return s.rejectDuplicates || l.MaxDepth > 0 || l.MaxIncluded > 0 || l.MaxRelationships > 0
+}
+
+// pathSegment is a member name or array index of the path to a json value within a document.
+type pathSegment struct {
+	name  string
+	index int
+}
+
+// isMember returns true if the segment is the member of the given name.
+func (p pathSegment) isMember(name string) bool {
+	return p.index < 0 && p.name == name
+}
+
+// jsonPointer returns the JSON pointer to the value at the given path.
+func jsonPointer(path []pathSegment) string {
+	var b []byte
+	for _, seg := range path {
+		b = append(b, '/')
+		if seg.index >= 0 {
+			b = strconv.AppendInt(b, int64(seg.index), 10)
+			continue
+		}
+		b = append(b, escapePointerToken(seg.name)...)
+	}
+	return string(b)
+}
+
+// scan tokenizes the json value data found at the given path of a document, nested at the given
+// depth. Malformed json is left to be reported by the decoder.
+func (s *documentScanner) scan(data []byte, path []pathSegment, depth int) error {
+	if !s.enabled() {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var walk func(path []pathSegment, depth int) error
+	walk = func(path []pathSegment, depth int) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		delim, ok := tok.(json.Delim)
+		if !ok {
+			return nil
+		}
+		depth++
+		if err := s.checkDepth(depth); err != nil {
+			return err
+		}
+
+		switch delim {
+		case '{':
+			var names *memberNames
+			if s.rejectDuplicates {
+				names = newMemberNames(s.isStructObject(path))
+			}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				name, _ := key.(string)
+				p := append(path[:len(path):len(path)], pathSegment{name: name, index: -1})
+				if names != nil {
+					if err := names.add(name, jsonPointer(p)); err != nil {
+						return err
+					}
+				}
+				if err := walk(p, depth); err != nil {
+					return err
+				}
+			}
+		case '[':
+			limit, max := s.arrayLimit(path)
+			for i := 0; dec.More(); i++ {
+				if max > 0 && i == max {
+					return &LimitError{Limit: limit, Max: int64(max), Pointer: jsonPointer(path)}
+				}
+				if err := walk(append(path[:len(path):len(path)], pathSegment{index: i}), depth); err != nil {
+					return err
+				}
+			}
+		}
+		_, err = dec.Token()
+		return err
+	}
+
+	err := walk(path, depth)
+	if _, ok := err.(*LimitError); ok {
+		return err
+	}
+	if _, ok := err.(*DocumentError); ok {
+		return err
+	}
+	return nil
+}
+
+// scanValue walks the generic Go value v found at the given path of a document given as such, e.g.
+// to UnmarshalFromMap, nested at the given depth, enforcing the same limits as scan. Maps can't hold
+// duplicate members.
+func (s *documentScanner) scanValue(v any, path []pathSegment, depth int) error {
+	switch v := v.(type) {
+	case map[string]any:
+		if err := s.checkDepth(depth + 1); err != nil {
+			return err
+		}
+		for name, value := range v {
+			if err := s.scanValue(value, append(path[:len(path):len(path)], pathSegment{name: name, index: -1}), depth+1); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if err := s.checkDepth(depth + 1); err != nil {
+			return err
+		}
+		if limit, max := s.arrayLimit(path); max > 0 && len(v) > max {
+			return &LimitError{Limit: limit, Max: int64(max), Pointer: jsonPointer(path)}
+		}
+		for i, value := range v {
+			if err := s.scanValue(value, append(path[:len(path):len(path)], pathSegment{index: i}), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkDepth returns a LimitError if an object or array nested at the given depth is nested deeper
+// than allowed.
+func (s *documentScanner) checkDepth(depth int) error {
+	if s.limits.MaxDepth > 0 && depth > s.limits.MaxDepth {
+		return &LimitError{Limit: "MaxDepth", Max: int64(s.limits.MaxDepth)}
+	}
+	return nil
+}
+
+// arrayLimit returns the name and value of the limit on the number of elements of the array at the
+// given path, if any.
+func (s *documentScanner) arrayLimit(path []pathSegment) (string, int) {
+	if len(path) == 1 && path[0].isMember("included") {
+		return "MaxIncluded", s.limits.MaxIncluded
+	}
+	if s.isLinkage(path) {
+		return "MaxRelationships", s.limits.MaxRelationships
+	}
+	return "", 0
+}
+
+// isLinkage returns true if the value at the given path is the resource linkage of a relationship,
+// or the primary data of a document whose primary data is resource linkage.
+func (s *documentScanner) isLinkage(path []pathSegment) bool {
+	if s.linkage && len(path) == 1 && path[0].isMember(s.dataMember) {
+		return true
+	}
+	n := len(path)
+	return n >= 3 && path[n-1].isMember("data") && path[n-3].isMember("relationships") && s.isResourceObject(path[:n-3])
+}
+
+// isResourceObject returns true if the value at the given path is a primary or included resource
+// object.
+func (s *documentScanner) isResourceObject(path []pathSegment) bool {
+	if s.linkage || len(path) == 0 {
+		return false
+	}
+	if path[0].isMember("included") {
+		return len(path) == 2 && path[1].index >= 0
+	}
+	if !path[0].isMember(s.dataMember) {
+		return false
+	}
+	switch len(path) {
+	case 1:
+		return true
+	case 2:
+		return path[1].index >= 0
+	case 3:
+		// the primary data of atomic:results is held by result objects
+		return s.dataMember == AtomicResultsMember && path[1].index >= 0 && path[2].isMember("data")
+	}
+	return false
+}
+
+// isStructObject returns true if the members of the object at the given path are matched to struct
+// fields when decoding, i.e. case-insensitively by encoding/json.
+func (s *documentScanner) isStructObject(path []pathSegment) bool {
+	n := len(path)
+	switch {
+	case n == 0:
+		// the top-level object
+		return true
+	case s.isResourceObject(path) || s.isLinkage(path):
+		return true
+	case n == 2 && path[0].isMember("errors") && path[1].index >= 0:
+		return true
+	case path[n-1].index >= 0 && s.isLinkage(path[:n-1]):
+		// resource identifier objects of to-many relationships
+		return true
+	case path[n-1].isMember("attributes") || path[n-1].isMember("relationships"):
+		return s.isResourceObject(path[:n-1])
+	case n >= 2 && path[n-2].isMember("relationships"):
+		// relationship objects
+		return s.isResourceObject(path[:n-2])
+	}
+	return false
+}
+
+// limitedReader reads from r, failing with a LimitError once more than max bytes have been read.
+// Bytes past the limit are withheld, as the json decoder may complete a value from bytes read along
+// with an error without reporting it.
+type limitedReader struct {
+	r      io.Reader
+	n, max int64
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if left := lr.max - lr.n + 1; int64(len(p)) > left {
+		p = p[:left]
+	}
+	n, err := lr.r.Read(p)
+	if lr.n += int64(n); lr.n > lr.max {
+		return n - int(lr.n-lr.max), &LimitError{Limit: "MaxBytes", Max: lr.max}
+	}
+	return n, err
+}
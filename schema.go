@@ -0,0 +1,410 @@
+package jsonapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// AttributeSchema describes an attribute of a resource as defined by https://jsonapi.org/format/#document-resource-object-attributes.
+type AttributeSchema struct {
+	// Name is the member name of the attribute.
+	Name string
+
+	// Field is the name of the struct field holding the attribute.
+	Field string
+
+	// Type is the Go type of the attribute.
+	Type reflect.Type
+
+	// OmitEmpty is true if the attribute is omitted when empty.
+	OmitEmpty bool
+
+	// ReadOnly is true if the attribute is only sent by servers, as given by the readonly option.
+	ReadOnly bool
+
+	// WriteOnly is true if the attribute is only sent by clients, as given by the writeonly option.
+	WriteOnly bool
+
+	// TimeFormat is the format of time attributes given by a time format option, if any.
+	TimeFormat TimeFormat
+
+	// String is true if the int64 or uint64 attribute is encoded as a string, as given by the
+	// string option.
+	String bool
+}
+
+// RelationshipSchema describes a relationship of a resource as defined by https://jsonapi.org/format/#document-resource-object-relationships.
+type RelationshipSchema struct {
+	// Name is the member name of the relationship.
+	Name string
+
+	// Field is the name of the struct field holding the relationship.
+	Field string
+
+	// Type is the Go type of the struct field holding the relationship.
+	Type reflect.Type
+
+	// RelatedType is the resource type of the related resources.
+	RelatedType string
+
+	// ToMany is true if the relationship is a to-many relationship.
+	ToMany bool
+}
+
+// ResourceSchema describes the JSON:API representation of a resource struct, as given by its struct
+// tags.
+type ResourceSchema struct {
+	// Type is the resource type.
+	Type string
+
+	// IDField is the name of the primary struct field.
+	IDField string
+
+	// Attributes are the resource's attributes, in struct field order.
+	Attributes []AttributeSchema
+
+	// Relationships are the resource's relationships, in struct field order.
+	Relationships []RelationshipSchema
+}
+
+// Attribute returns the attribute with the given member name.
+func (s *ResourceSchema) Attribute(name string) (AttributeSchema, bool) {
+	for _, attr := range s.Attributes {
+		if attr.Name == name {
+			return attr, true
+		}
+	}
+	return AttributeSchema{}, false
+}
+
+// Relationship returns the relationship with the given member name.
+func (s *ResourceSchema) Relationship(name string) (RelationshipSchema, bool) {
+	for _, rel := range s.Relationships {
+		if rel.Name == name {
+			return rel, true
+		}
+	}
+	return RelationshipSchema{}, false
+}
+
+// AttributeNames returns the member names of the resource's attributes, in struct field order.
+func (s *ResourceSchema) AttributeNames() []string {
+	names := make([]string, 0, len(s.Attributes))
+	for _, attr := range s.Attributes {
+		names = append(names, attr.Name)
+	}
+	return names
+}
+
+// RelationshipNames returns the member names of the resource's relationships, in struct field
+// order.
+func (s *ResourceSchema) RelationshipNames() []string {
+	names := make([]string, 0, len(s.Relationships))
+	for _, rel := range s.Relationships {
+		names = append(names, rel.Name)
+	}
+	return names
+}
+
+// HasField returns true if the resource has an attribute or relationship with the given member
+// name, i.e. name is a valid member of a sparse fieldset (fields[TYPE]) of the resource type.
+func (s *ResourceSchema) HasField(name string) bool {
+	_, isAttr := s.Attribute(name)
+	_, isRel := s.Relationship(name)
+	return isAttr || isRel
+}
+
+// SchemaOf returns the ResourceSchema of the resource struct type of v, which may be a nil pointer
+// (e.g. (*Article)(nil)). It describes the resource type, id field, attributes and relationships
+// given by the struct tags, e.g. to validate sparse fieldsets and sort fields requested by clients
+// or to document the resource type.
+func SchemaOf(v any) (*ResourceSchema, error) {
+	if v == nil {
+		return nil, &TypeError{Actual: "nil", Expected: []string{"struct"}}
+	}
+	return schemaOf(reflect.TypeOf(v))
+}
+
+// schemaOf returns the ResourceSchema of the struct type t.
+func schemaOf(t reflect.Type) (*ResourceSchema, error) {
+	t = derefType(t)
+	if t.Kind() != reflect.Struct {
+		return nil, &TypeError{Actual: t.String(), Expected: []string{"struct"}}
+	}
+
+	s := new(ResourceSchema)
+	for _, field := range getFlattenedFields(reflect.New(t).Interface()) {
+		tag, err := parseJSONAPITag(field.f)
+		if err != nil {
+			return nil, err
+		}
+		if tag == nil {
+			continue
+		}
+
+		switch tag.directive {
+		case primary:
+			s.Type = tag.resourceType
+			s.IDField = field.f.Name
+		case attribute:
+			name, ok, omit := parseJSONTag(field.f)
+			if !ok {
+				continue
+			}
+			s.Attributes = append(s.Attributes, AttributeSchema{
+				Name:       name,
+				Field:      field.f.Name,
+				Type:       field.f.Type,
+				OmitEmpty:  omit,
+				ReadOnly:   tag.readOnly,
+				WriteOnly:  tag.writeOnly,
+				TimeFormat: tag.timeFormat,
+				String:     tag.int64String,
+			})
+		case relationship:
+			name, ok, _ := parseJSONTag(field.f)
+			if !ok {
+				continue
+			}
+			rt := derefType(relatedFieldType(field.f.Type))
+			toMany := rt.Kind() == reflect.Slice
+			if toMany {
+				rt = rt.Elem()
+			}
+			relatedType, idsOnly, err := parseRelTypeTag(field.f)
+			if err != nil {
+				return nil, err
+			}
+			if !idsOnly {
+				if relatedType, err = resourceTypeOf(rt); err != nil {
+					return nil, err
+				}
+			}
+			s.Relationships = append(s.Relationships, RelationshipSchema{
+				Name:        name,
+				Field:       field.f.Name,
+				Type:        field.f.Type,
+				RelatedType: relatedType,
+				ToMany:      toMany,
+			})
+		}
+	}
+
+	if s.IDField == "" {
+		return nil, ErrMissingPrimaryField
+	}
+
+	return s, nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// jsonKind returns the kind of json value the Go type t is encoded as, or "any" if it can't be
+// determined.
+func jsonKind(t reflect.Type) string {
+	if implements(t, jsonMarshalerType) || implements(reflect.PointerTo(t), jsonMarshalerType) {
+		if implements(t, textMarshalerType) || implements(reflect.PointerTo(t), textMarshalerType) {
+			// e.g. time.Time
+			return "string"
+		}
+		return "any"
+	}
+	if implements(t, textMarshalerType) || implements(reflect.PointerTo(t), textMarshalerType) {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonKind(t.Elem())
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return "any"
+}
+
+// SchemaChange is a difference between two versions of a ResourceSchema.
+type SchemaChange struct {
+	// Member is the name of the changed attribute or relationship, or empty if the resource itself
+	// changed.
+	Member string
+
+	// Breaking is true if clients of the old schema may not be compatible with the new schema.
+	Breaking bool
+
+	// Detail describes the change.
+	Detail string
+}
+
+// String implements the fmt.Stringer interface.
+func (c SchemaChange) String() string {
+	kind := "compatible"
+	if c.Breaking {
+		kind = "breaking"
+	}
+	if c.Member == "" {
+		return fmt.Sprintf("%s: %s", kind, c.Detail)
+	}
+	return fmt.Sprintf("%s: %q %s", kind, c.Member, c.Detail)
+}
+
+// SchemaChanges is a list of SchemaChange.
+type SchemaChanges []SchemaChange
+
+// Breaking returns only the breaking changes.
+func (cs SchemaChanges) Breaking() SchemaChanges {
+	breaking := make(SchemaChanges, 0)
+	for _, c := range cs {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+	return breaking
+}
+
+// CompareSchemas compares the ResourceSchema of two versions of a resource struct, given as values
+// of the old and new struct types, and returns the changes between them. This can be used to gate
+// API compatibility, e.g. in tests comparing the structs of a published API version to the current
+// ones.
+//
+// Breaking changes are a changed resource type, removed or renamed attributes and relationships,
+// attributes whose json representation changed (e.g. from string to number), and relationships
+// whose related type or cardinality changed. Added attributes and relationships are compatible.
+func CompareSchemas(oldVersion, newVersion any) (SchemaChanges, error) {
+	if oldVersion == nil || newVersion == nil {
+		return nil, &TypeError{Actual: "nil", Expected: []string{"struct"}}
+	}
+
+	os, err := schemaOf(reflect.TypeOf(oldVersion))
+	if err != nil {
+		return nil, err
+	}
+	ns, err := schemaOf(reflect.TypeOf(newVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	return compareSchemas(os, ns), nil
+}
+
+func compareSchemas(os, ns *ResourceSchema) SchemaChanges {
+	changes := make(SchemaChanges, 0)
+
+	if os.Type != ns.Type {
+		changes = append(changes, SchemaChange{
+			Breaking: true,
+			Detail:   fmt.Sprintf("resource type changed from %q to %q", os.Type, ns.Type),
+		})
+	}
+
+	for _, oa := range os.Attributes {
+		na, ok := ns.Attribute(oa.Name)
+		if !ok {
+			changes = append(changes, SchemaChange{Member: oa.Name, Breaking: true, Detail: "attribute removed"})
+			continue
+		}
+		oldKind, newKind := jsonKind(oa.Type), jsonKind(na.Type)
+		if oldKind != newKind && oldKind != "any" && newKind != "any" {
+			changes = append(changes, SchemaChange{
+				Member:   oa.Name,
+				Breaking: true,
+				Detail:   fmt.Sprintf("attribute type changed from %s to %s", oldKind, newKind),
+			})
+		} else if oa.TimeFormat != na.TimeFormat {
+			changes = append(changes, SchemaChange{
+				Member:   oa.Name,
+				Breaking: true,
+				Detail:   fmt.Sprintf("attribute time format changed from %q to %q", oa.TimeFormat, na.TimeFormat),
+			})
+		} else if oa.String != na.String {
+			changes = append(changes, SchemaChange{
+				Member:   oa.Name,
+				Breaking: true,
+				Detail:   fmt.Sprintf("attribute string encoding changed from %t to %t", oa.String, na.String),
+			})
+		}
+	}
+	for _, na := range ns.Attributes {
+		if _, ok := os.Attribute(na.Name); !ok {
+			changes = append(changes, SchemaChange{Member: na.Name, Detail: "attribute added"})
+		}
+	}
+
+	// relationships that were removed and added with the same related type are considered renamed
+	removed := make([]RelationshipSchema, 0)
+	added := make([]RelationshipSchema, 0)
+	for _, or := range os.Relationships {
+		nr, ok := ns.Relationship(or.Name)
+		if !ok {
+			removed = append(removed, or)
+			continue
+		}
+		if or.RelatedType != nr.RelatedType {
+			changes = append(changes, SchemaChange{
+				Member:   or.Name,
+				Breaking: true,
+				Detail:   fmt.Sprintf("relationship type changed from %q to %q", or.RelatedType, nr.RelatedType),
+			})
+		}
+		if or.ToMany != nr.ToMany {
+			changes = append(changes, SchemaChange{
+				Member:   or.Name,
+				Breaking: true,
+				Detail:   fmt.Sprintf("relationship changed from %s to %s", cardinality(or.ToMany), cardinality(nr.ToMany)),
+			})
+		}
+	}
+	for _, nr := range ns.Relationships {
+		if _, ok := os.Relationship(nr.Name); !ok {
+			added = append(added, nr)
+		}
+	}
+
+	for _, or := range removed {
+		detail := "relationship removed"
+		for i, nr := range added {
+			if nr.RelatedType == or.RelatedType && nr.ToMany == or.ToMany {
+				detail = fmt.Sprintf("relationship renamed to %q", nr.Name)
+				added = append(added[:i], added[i+1:]...)
+				break
+			}
+		}
+		changes = append(changes, SchemaChange{Member: or.Name, Breaking: true, Detail: detail})
+	}
+	for _, nr := range added {
+		changes = append(changes, SchemaChange{Member: nr.Name, Detail: "relationship added"})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Breaking && !changes[j].Breaking
+	})
+
+	return changes
+}
+
+func cardinality(toMany bool) string {
+	if toMany {
+		return "to-many"
+	}
+	return "to-one"
+}
@@ -0,0 +1,477 @@
+// Package schema generates OpenAPI 3.1 schema components describing the JSON:API representation of
+// resource structs, as defined by https://spec.openapis.org/oas/v3.1.0#components-object.
+//
+// Resource structs are registered with a Generator, which emits a JSON Schema for each registered
+// resource object, its single-resource and collection documents, and the shared JSON:API members
+// (resource identifiers, links, meta, and error documents):
+//
+//	g := schema.NewGenerator()
+//	if err := g.Register((*Article)(nil), (*Author)(nil)); err != nil {
+//		return err
+//	}
+//	b, err := json.Marshal(g.Components())
+//
+// The generated components can be referenced from handwritten OpenAPI documents, e.g. with
+// {"$ref": "#/components/schemas/ArticleDocument"}.
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/jsonapi"
+)
+
+// Names of the shared components.
+const (
+	// ResourceIdentifier is the name of the resource identifier object component.
+	ResourceIdentifier = "ResourceIdentifier"
+
+	// Links is the name of the links object component.
+	Links = "Links"
+
+	// Link is the name of the link component, a string or link object.
+	Link = "Link"
+
+	// Meta is the name of the meta object component.
+	Meta = "Meta"
+
+	// JSONAPI is the name of the jsonapi object component.
+	JSONAPI = "JSONAPI"
+
+	// Error is the name of the error object component.
+	Error = "Error"
+
+	// ErrorDocument is the name of the error document component.
+	ErrorDocument = "ErrorDocument"
+)
+
+// refPrefix is the prefix of references to components.
+const refPrefix = "#/components/schemas/"
+
+// Schema is a JSON Schema as used by OpenAPI 3.1, limited to the keywords used by this package.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+}
+
+// Ref returns a Schema referencing the component with the given name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: refPrefix + name}
+}
+
+// Components holds the generated schemas, as the schemas member of an OpenAPI components object.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// resource is a registered resource struct.
+type resource struct {
+	name   string
+	schema *jsonapi.ResourceSchema
+}
+
+// Generator generates the schema components of registered resource structs.
+type Generator struct {
+	resources []*resource
+	byType    map[string]*resource
+}
+
+// NewGenerator creates a new Generator without registered resources.
+func NewGenerator() *Generator {
+	return &Generator{byType: make(map[string]*resource)}
+}
+
+// Register registers the resource struct types of the given values, which may be nil pointers
+// (e.g. (*Article)(nil)). The components of a resource are named after its struct type, e.g.
+// Article, ArticleAttributes, ArticleRelationships, ArticleDocument and ArticleCollectionDocument.
+//
+// An error is returned if a value is not a valid resource struct, or if its name or resource type
+// is already registered.
+func (g *Generator) Register(vs ...any) error {
+	for _, v := range vs {
+		rs, err := jsonapi.SchemaOf(v)
+		if err != nil {
+			return err
+		}
+
+		t := reflect.TypeOf(v)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		r := &resource{name: t.Name(), schema: rs}
+		if _, ok := sharedSchemas()[r.name]; ok {
+			return fmt.Errorf("schema: resource name %q is reserved", r.name)
+		}
+		for _, other := range g.resources {
+			if other.name == r.name {
+				return fmt.Errorf("schema: resource name %q is already registered", r.name)
+			}
+		}
+		if _, ok := g.byType[rs.Type]; ok {
+			return fmt.Errorf("schema: resource type %q is already registered", rs.Type)
+		}
+
+		g.resources = append(g.resources, r)
+		g.byType[rs.Type] = r
+	}
+	return nil
+}
+
+// Components returns the schemas of the registered resources and the shared JSON:API members.
+func (g *Generator) Components() *Components {
+	schemas := sharedSchemas()
+
+	included := make([]*Schema, 0, len(g.resources))
+	for _, r := range g.resources {
+		included = append(included, Ref(r.name))
+	}
+
+	for _, r := range g.resources {
+		schemas[r.name+"Attributes"] = g.attributesSchema(r)
+		schemas[r.name+"Relationships"] = g.relationshipsSchema(r)
+		schemas[r.name+"Identifier"] = identifierSchema(r.schema.Type)
+		schemas[r.name] = &Schema{
+			Description: fmt.Sprintf("A resource object of type %q.", r.schema.Type),
+			Type:        "object",
+			Required:    []string{"type", "id"},
+			Properties: map[string]*Schema{
+				"type":          {Type: "string", Const: r.schema.Type},
+				"id":            {Type: "string"},
+				"attributes":    Ref(r.name + "Attributes"),
+				"relationships": Ref(r.name + "Relationships"),
+				"links":         Ref(Links),
+				"meta":          Ref(Meta),
+			},
+		}
+		schemas[r.name+"Document"] = documentSchema(
+			fmt.Sprintf("A document whose primary data is a single resource object of type %q.", r.schema.Type),
+			&Schema{AnyOf: []*Schema{Ref(r.name), {Type: "null"}}},
+			included,
+		)
+		schemas[r.name+"CollectionDocument"] = documentSchema(
+			fmt.Sprintf("A document whose primary data is a collection of resource objects of type %q.", r.schema.Type),
+			&Schema{Type: "array", Items: Ref(r.name)},
+			included,
+		)
+	}
+
+	return &Components{Schemas: schemas}
+}
+
+// documentSchema returns the schema of a document with the given primary data.
+func documentSchema(description string, data *Schema, included []*Schema) *Schema {
+	s := &Schema{
+		Description: description,
+		Type:        "object",
+		Required:    []string{"data"},
+		Properties: map[string]*Schema{
+			"data":    data,
+			"meta":    Ref(Meta),
+			"links":   Ref(Links),
+			"jsonapi": Ref(JSONAPI),
+		},
+	}
+	if len(included) > 0 {
+		s.Properties["included"] = &Schema{Type: "array", Items: &Schema{OneOf: included}}
+	}
+	return s
+}
+
+// identifierSchema returns the schema of a resource identifier object of the given resource type.
+func identifierSchema(resourceType string) *Schema {
+	return &Schema{
+		Type:     "object",
+		Required: []string{"type", "id"},
+		Properties: map[string]*Schema{
+			"type": {Type: "string", Const: resourceType},
+			"id":   {Type: "string"},
+			"meta": Ref(Meta),
+		},
+	}
+}
+
+// attributesSchema returns the schema of the attributes object of the given resource.
+func (g *Generator) attributesSchema(r *resource) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, attr := range r.schema.Attributes {
+		p := typeSchema(attr.Type, make(map[reflect.Type]bool))
+		if attr.TimeFormat != "" {
+			p = timeSchema(attr.Type, attr.TimeFormat)
+		}
+		if attr.String {
+			p = int64StringSchema(attr.Type)
+		}
+		p.ReadOnly = attr.ReadOnly
+		p.WriteOnly = attr.WriteOnly
+		s.Properties[attr.Name] = p
+		if !attr.OmitEmpty {
+			s.Required = append(s.Required, attr.Name)
+		}
+	}
+	return s
+}
+
+// timeSchema returns the schema of time attributes of type t encoded in the given format.
+func timeSchema(t reflect.Type, format jsonapi.TimeFormat) *Schema {
+	s := &Schema{Type: "string", Format: "date-time"}
+	switch format {
+	case jsonapi.TimeUnix, jsonapi.TimeUnixMilli:
+		s = &Schema{Type: "integer"}
+	case jsonapi.TimeDate:
+		s.Format = "date"
+	}
+	if t.Kind() == reflect.Pointer {
+		s.Type = []string{s.Type.(string), "null"}
+	}
+	return s
+}
+
+// int64StringSchema returns the schema of int64 or uint64 attributes of type t encoded as strings.
+func int64StringSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "string", Format: "int64"}
+	if t.Kind() == reflect.Pointer {
+		s.Type = []string{"string", "null"}
+	}
+	return s
+}
+
+// relationshipsSchema returns the schema of the relationships object of the given resource.
+func (g *Generator) relationshipsSchema(r *resource) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, rel := range r.schema.Relationships {
+		identifier := identifierSchema(rel.RelatedType)
+		if related, ok := g.byType[rel.RelatedType]; ok {
+			identifier = Ref(related.name + "Identifier")
+		}
+
+		data := &Schema{AnyOf: []*Schema{identifier, {Type: "null"}}}
+		if rel.ToMany {
+			data = &Schema{Type: "array", Items: identifier}
+		}
+
+		s.Properties[rel.Name] = &Schema{
+			Description: fmt.Sprintf("A %s relationship to resources of type %q.", cardinality(rel.ToMany), rel.RelatedType),
+			Type:        "object",
+			Properties: map[string]*Schema{
+				"data":  data,
+				"links": Ref(Links),
+				"meta":  Ref(Meta),
+			},
+		}
+	}
+	return s
+}
+
+func cardinality(toMany bool) string {
+	if toMany {
+		return "to-many"
+	}
+	return "to-one"
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// implements returns true if values of type t or *t implement the interface type iface.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// typeSchema returns the schema of the json encoding of values of type t, as done by
+// encoding/json. Types already being described in seen are described by the empty schema, so
+// recursive types are supported.
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() != reflect.Pointer && implements(t, jsonMarshalerType):
+		// the encoding is determined by the type itself
+		return &Schema{}
+	case t.Kind() != reflect.Pointer && implements(t, textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := typeSchema(t.Elem(), seen)
+		if reflect.DeepEqual(s, &Schema{}) {
+			// the empty schema already allows null
+			return s
+		}
+		if typ, ok := s.Type.(string); ok && s.Ref == "" {
+			s.Type = []string{typ, "null"}
+			return s
+		}
+		return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &Schema{Type: []string{"array", "null"}, Items: typeSchema(t.Elem(), seen)}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: typeSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: []string{"object", "null"}, AdditionalProperties: typeSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return &Schema{}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return structSchema(t, seen)
+	}
+
+	// interfaces may hold any json value
+	return &Schema{}
+}
+
+// structSchema returns the schema of the json encoding of the struct type t.
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// fields of embedded structs are promoted
+				embedded := structSchema(ft, seen)
+				for n, p := range embedded.Properties {
+					if _, ok := s.Properties[n]; !ok {
+						s.Properties[n] = p
+					}
+				}
+				s.Required = append(s.Required, embedded.Required...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = typeSchema(f.Type, seen)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	sort.Strings(s.Required)
+	return s
+}
+
+// sharedSchemas returns the schemas of the JSON:API members shared by all resources.
+func sharedSchemas() map[string]*Schema {
+	link := &Schema{OneOf: []*Schema{
+		{Type: "string", Format: "uri-reference"},
+		{
+			Type:     "object",
+			Required: []string{"href"},
+			Properties: map[string]*Schema{
+				"href": {Type: "string", Format: "uri-reference"},
+				"meta": Ref(Meta),
+			},
+		},
+	}}
+
+	linkProperties := make(map[string]*Schema)
+	for _, name := range []string{"self", "related"} {
+		linkProperties[name] = Ref(Link)
+	}
+	for _, name := range []string{"first", "last", "next", "previous"} {
+		linkProperties[name] = &Schema{Type: "string", Format: "uri-reference"}
+	}
+
+	return map[string]*Schema{
+		ResourceIdentifier: {
+			Type:     "object",
+			Required: []string{"type", "id"},
+			Properties: map[string]*Schema{
+				"type": {Type: "string"},
+				"id":   {Type: "string"},
+				"meta": Ref(Meta),
+			},
+		},
+		Link:    link,
+		Links:   {Type: "object", Properties: linkProperties},
+		Meta:    {Type: "object", AdditionalProperties: &Schema{}},
+		JSONAPI: {Type: "object", Properties: map[string]*Schema{"version": {Type: "string"}, "meta": Ref(Meta)}},
+		Error: {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":     {Type: "string"},
+				"links":  {Type: "object", Properties: map[string]*Schema{"about": Ref(Link)}},
+				"status": {Type: "string"},
+				"code":   {Type: "string"},
+				"title":  {Type: "string"},
+				"detail": {Type: "string"},
+				"source": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"pointer":   {Type: "string", Format: "json-pointer"},
+						"parameter": {Type: "string"},
+						"header":    {Type: "string"},
+					},
+				},
+				"meta": Ref(Meta),
+			},
+		},
+		ErrorDocument: {
+			Type:     "object",
+			Required: []string{"errors"},
+			Properties: map[string]*Schema{
+				"errors":  {Type: "array", Items: Ref(Error)},
+				"meta":    Ref(Meta),
+				"links":   Ref(Links),
+				"jsonapi": Ref(JSONAPI),
+			},
+		},
+	}
+}
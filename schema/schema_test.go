@@ -0,0 +1,267 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DataDog/jsonapi"
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+type author struct {
+	ID   string `jsonapi:"primary,authors"`
+	Name string `jsonapi:"attribute" json:"name"`
+}
+
+type comment struct {
+	ID     string  `jsonapi:"primary,comments"`
+	Body   string  `jsonapi:"attribute" json:"body"`
+	Author *author `jsonapi:"relationship" json:"author,omitempty"`
+}
+
+type article struct {
+	ID        string     `jsonapi:"primary,articles"`
+	Title     string     `jsonapi:"attribute" json:"title"`
+	Published *time.Time `jsonapi:"attribute" json:"published,omitempty"`
+	Author    *author    `jsonapi:"relationship" json:"author,omitempty"`
+	Comments  []*comment `jsonapi:"relationship" json:"comments,omitempty"`
+}
+
+type node struct {
+	Name     string  `json:"name"`
+	Children []*node `json:"children,omitempty"`
+}
+
+type embedded struct {
+	Base  string `json:"base"`
+	Level int    `json:"level,omitempty"`
+}
+
+type withEmbedded struct {
+	embedded
+	Own    string `json:"own"`
+	hidden string //nolint:unused
+	Skip   string `json:"-"`
+}
+
+func TestGenerator(t *testing.T) {
+	t.Parallel()
+
+	g := NewGenerator()
+	is.MustNoError(t, g.Register((*article)(nil), (*author)(nil)))
+
+	schemas := g.Components().Schemas
+
+	for _, name := range []string{
+		"article", "articleAttributes", "articleRelationships", "articleIdentifier", "articleDocument", "articleCollectionDocument",
+		"author", "authorAttributes", "authorRelationships", "authorIdentifier", "authorDocument", "authorCollectionDocument",
+		ResourceIdentifier, Links, Link, Meta, JSONAPI, Error, ErrorDocument,
+	} {
+		_, ok := schemas[name]
+		is.Equal(t, true, ok)
+	}
+	is.Equal(t, 19, len(schemas))
+
+	b, err := json.Marshal(schemas["article"])
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{
+		"description": "A resource object of type \"articles\".",
+		"type": "object",
+		"properties": {
+			"type": {"type": "string", "const": "articles"},
+			"id": {"type": "string"},
+			"attributes": {"$ref": "#/components/schemas/articleAttributes"},
+			"relationships": {"$ref": "#/components/schemas/articleRelationships"},
+			"links": {"$ref": "#/components/schemas/Links"},
+			"meta": {"$ref": "#/components/schemas/Meta"}
+		},
+		"required": ["type", "id"]
+	}`, string(b))
+
+	b, err = json.Marshal(schemas["articleAttributes"])
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{
+		"type": "object",
+		"properties": {
+			"title": {"type": "string"},
+			"published": {"type": ["string", "null"], "format": "date-time"}
+		},
+		"required": ["title"]
+	}`, string(b))
+
+	// relationships to registered resources reference their identifier, others are inlined
+	b, err = json.Marshal(schemas["articleRelationships"])
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{
+		"type": "object",
+		"properties": {
+			"author": {
+				"description": "A to-one relationship to resources of type \"authors\".",
+				"type": "object",
+				"properties": {
+					"data": {"anyOf": [{"$ref": "#/components/schemas/authorIdentifier"}, {"type": "null"}]},
+					"links": {"$ref": "#/components/schemas/Links"},
+					"meta": {"$ref": "#/components/schemas/Meta"}
+				}
+			},
+			"comments": {
+				"description": "A to-many relationship to resources of type \"comments\".",
+				"type": "object",
+				"properties": {
+					"data": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {
+								"type": {"type": "string", "const": "comments"},
+								"id": {"type": "string"},
+								"meta": {"$ref": "#/components/schemas/Meta"}
+							},
+							"required": ["type", "id"]
+						}
+					},
+					"links": {"$ref": "#/components/schemas/Links"},
+					"meta": {"$ref": "#/components/schemas/Meta"}
+				}
+			}
+		}
+	}`, string(b))
+
+	b, err = json.Marshal(schemas["articleCollectionDocument"])
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{
+		"description": "A document whose primary data is a collection of resource objects of type \"articles\".",
+		"type": "object",
+		"properties": {
+			"data": {"type": "array", "items": {"$ref": "#/components/schemas/article"}},
+			"included": {"type": "array", "items": {"oneOf": [{"$ref": "#/components/schemas/article"}, {"$ref": "#/components/schemas/author"}]}},
+			"meta": {"$ref": "#/components/schemas/Meta"},
+			"links": {"$ref": "#/components/schemas/Links"},
+			"jsonapi": {"$ref": "#/components/schemas/JSONAPI"}
+		},
+		"required": ["data"]
+	}`, string(b))
+}
+
+func TestGeneratorRegisterErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       []any
+		expectError string
+	}{
+		{
+			description: "not a resource",
+			given:       []any{(*node)(nil)},
+			expectError: "primary/id field must labeled with `jsonapi:\"primary,{type}\"`",
+		}, {
+			description: "duplicate resource type",
+			given:       []any{(*author)(nil), author{}},
+			expectError: `schema: resource name "author" is already registered`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			err := NewGenerator().Register(tc.given...)
+			is.Equal(t, tc.expectError, fmt.Sprint(err))
+		})
+	}
+}
+
+func TestTypeSchema(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		expect      string
+	}{
+		{
+			description: "scalars",
+			given: struct {
+				B bool    `json:"b"`
+				I int     `json:"i"`
+				L int64   `json:"l"`
+				F float64 `json:"f"`
+			}{},
+			expect: `{"type":"object","properties":{"b":{"type":"boolean"},"i":{"type":"integer","format":"int32"},"l":{"type":"integer","format":"int64"},"f":{"type":"number","format":"double"}},"required":["b","f","i","l"]}`,
+		}, {
+			description: "bytes, maps and interfaces",
+			given: struct {
+				B []byte         `json:"b"`
+				M map[string]int `json:"m"`
+				A any            `json:"a"`
+			}{},
+			expect: `{"type":"object","properties":{"b":{"type":"string","contentEncoding":"base64"},"m":{"type":["object","null"],"additionalProperties":{"type":"integer","format":"int32"}},"a":{}},"required":["a","b","m"]}`,
+		}, {
+			description: "recursive types",
+			given:       node{},
+			expect:      `{"type":"object","properties":{"name":{"type":"string"},"children":{"type":["array","null"],"items":{}}},"required":["name"]}`,
+		}, {
+			description: "embedded structs",
+			given:       withEmbedded{},
+			expect:      `{"type":"object","properties":{"base":{"type":"string"},"level":{"type":"integer","format":"int32"},"own":{"type":"string"}},"required":["base","own"]}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := json.Marshal(typeSchema(reflect.TypeOf(tc.given), make(map[reflect.Type]bool)))
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestTimeSchema(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		format      jsonapi.TimeFormat
+		expect      string
+	}{
+		{
+			description: "unix",
+			given:       time.Time{},
+			format:      jsonapi.TimeUnix,
+			expect:      `{"type":"integer"}`,
+		}, {
+			description: "nullable date",
+			given:       (*time.Time)(nil),
+			format:      jsonapi.TimeDate,
+			expect:      `{"type":["string","null"],"format":"date"}`,
+		}, {
+			description: "rfc3339",
+			given:       time.Time{},
+			format:      jsonapi.TimeRFC3339,
+			expect:      `{"type":"string","format":"date-time"}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := json.Marshal(timeSchema(reflect.TypeOf(tc.given), tc.format))
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestSchemaOf(t *testing.T) {
+	t.Parallel()
+
+	s, err := SchemaOf((*ArticleRelated)(nil))
+	is.MustNoError(t, err)
+	is.Equal(t, "articles", s.Type)
+	is.Equal(t, "ID", s.IDField)
+	is.Equal(t, []string{"title"}, s.AttributeNames())
+	is.Equal(t, []string{"author", "comments"}, s.RelationshipNames())
+	is.Equal(t, RelationshipSchema{Name: "comments", Field: "Comments", Type: reflect.TypeOf([]*Comment(nil)), RelatedType: "comments", ToMany: true}, s.Relationships[1])
+
+	for _, name := range []string{"title", "author", "comments"} {
+		is.Equal(t, true, s.HasField(name))
+	}
+	for _, name := range []string{"id", "type", "Title", "ignored"} {
+		is.Equal(t, false, s.HasField(name))
+	}
+
+	s, err = SchemaOf(Article{})
+	is.MustNoError(t, err)
+	is.Equal(t, []string{}, s.RelationshipNames())
+
+	_, err = SchemaOf("articles")
+	is.EqualError(t, &TypeError{Actual: "string", Expected: []string{"struct"}}, err)
+	_, err = SchemaOf(nil)
+	is.EqualError(t, &TypeError{Actual: "nil", Expected: []string{"struct"}}, err)
+}
+
+func TestCompareSchemas(t *testing.T) {
+	t.Parallel()
+
+	type ArticleV1 struct {
+		ID        string     `jsonapi:"primary,articles"`
+		Title     string     `jsonapi:"attribute" json:"title"`
+		Views     int        `jsonapi:"attribute" json:"views"`
+		Published time.Time  `jsonapi:"attribute" json:"published"`
+		Author    *Author    `jsonapi:"relationship" json:"author"`
+		Comments  []*Comment `jsonapi:"relationship" json:"comments"`
+	}
+	type ArticleV2 struct {
+		ID        string     `jsonapi:"primary,articles"`
+		Views     string     `jsonapi:"attribute" json:"views"`
+		Published string     `jsonapi:"attribute" json:"published"`
+		Body      string     `jsonapi:"attribute" json:"body"`
+		Writer    *Author    `jsonapi:"relationship" json:"writer"`
+		Comments  *Comment   `jsonapi:"relationship" json:"comments"`
+		Related   []*Article `jsonapi:"relationship" json:"related"`
+	}
+
+	tests := []struct {
+		description string
+		oldVersion  any
+		newVersion  any
+		expect      SchemaChanges
+		expectError error
+	}{
+		{
+			description: "unchanged",
+			oldVersion:  ArticleV1{},
+			newVersion:  &ArticleV1{},
+			expect:      SchemaChanges{},
+		}, {
+			description: "changed",
+			oldVersion:  ArticleV1{},
+			newVersion:  ArticleV2{},
+			expect: SchemaChanges{
+				{Member: "title", Breaking: true, Detail: "attribute removed"},
+				{Member: "views", Breaking: true, Detail: "attribute type changed from number to string"},
+				{Member: "comments", Breaking: true, Detail: "relationship changed from to-many to to-one"},
+				{Member: "author", Breaking: true, Detail: `relationship renamed to "writer"`},
+				{Member: "body", Detail: "attribute added"},
+				{Member: "related", Detail: "relationship added"},
+			},
+		}, {
+			description: "time format changed",
+			oldVersion:  ArticleV1{},
+			newVersion: struct {
+				ID        string     `jsonapi:"primary,articles"`
+				Title     string     `jsonapi:"attribute" json:"title"`
+				Views     int        `jsonapi:"attribute" json:"views"`
+				Published time.Time  `jsonapi:"attribute,unix" json:"published"`
+				Author    *Author    `jsonapi:"relationship" json:"author"`
+				Comments  []*Comment `jsonapi:"relationship" json:"comments"`
+			}{},
+			expect: SchemaChanges{
+				{Member: "published", Breaking: true, Detail: `attribute time format changed from "" to "unix"`},
+			},
+		}, {
+			description: "string encoding changed",
+			oldVersion:  ArticleV1{},
+			newVersion: struct {
+				ID        string     `jsonapi:"primary,articles"`
+				Title     string     `jsonapi:"attribute" json:"title"`
+				Views     int64      `jsonapi:"attribute,string" json:"views"`
+				Published time.Time  `jsonapi:"attribute" json:"published"`
+				Author    *Author    `jsonapi:"relationship" json:"author"`
+				Comments  []*Comment `jsonapi:"relationship" json:"comments"`
+			}{},
+			expect: SchemaChanges{
+				{Member: "views", Breaking: true, Detail: "attribute string encoding changed from false to true"},
+			},
+		}, {
+			description: "resource type changed",
+			oldVersion:  Article{},
+			newVersion:  Comment{},
+			expect: SchemaChanges{
+				{Breaking: true, Detail: `resource type changed from "articles" to "comments"`},
+				{Member: "title", Breaking: true, Detail: "attribute removed"},
+				{Member: "body", Detail: "attribute added"},
+				{Member: "archived", Detail: "attribute added"},
+				{Member: "author", Detail: "relationship added"},
+			},
+		}, {
+			description: "embedded struct pointer flattened",
+			oldVersion:  ArticleEmbeddedPointer{},
+			newVersion:  ArticleFlattened{},
+			expect:      SchemaChanges{},
+		}, {
+			description: "not a struct",
+			oldVersion:  Article{},
+			newVersion:  "articles",
+			expectError: &TypeError{Actual: "string", Expected: []string{"struct"}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := CompareSchemas(tc.oldVersion, tc.newVersion)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestSchemaChangesBreaking(t *testing.T) {
+	t.Parallel()
+
+	changes := SchemaChanges{
+		{Member: "title", Breaking: true, Detail: "attribute removed"},
+		{Member: "body", Detail: "attribute added"},
+	}
+	is.Equal(t, SchemaChanges{changes[0]}, changes.Breaking())
+	is.Equal(t, `breaking: "title" attribute removed`, changes[0].String())
+}
@@ -0,0 +1,333 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ResourceHandler implements the operations on the resources of type T served by a Server, as
+// defined by https://jsonapi.org/format/#fetching and https://jsonapi.org/format/#crud.
+//
+// Errors returned by a ResourceHandler are written as error documents by WriteError, so they
+// should be of type *Error or implement StatusError. Get returning a nil resource is written as a
+// 404 (Not Found) error.
+type ResourceHandler[T any] interface {
+	// List returns the collection of resources.
+	List(ctx context.Context, q *Query) ([]*T, error)
+
+	// Get returns the resource with the given id.
+	Get(ctx context.Context, id string, q *Query) (*T, error)
+
+	// Create creates the given resource and returns it, or nil if it was created as given.
+	Create(ctx context.Context, v *T) (*T, error)
+
+	// Update updates the resource with the given id and returns it, or nil if it was updated as
+	// given. Only the attributes and relationships present in the request are set in v.
+	Update(ctx context.Context, id string, v *T) (*T, error)
+
+	// Delete deletes the resource with the given id.
+	Delete(ctx context.Context, id string) error
+}
+
+// RelatedHandler can optionally be implemented by a ResourceHandler to serve the related resource
+// and relationship endpoints of a Server, e.g. /articles/1/author and
+// /articles/1/relationships/author.
+type RelatedHandler interface {
+	// GetRelated returns the related resources of the relationship named relation of the resource
+	// with the given id: a resource object or slice of resource objects. For empty to-one
+	// relationships, GetRelated must return nil.
+	GetRelated(ctx context.Context, id, relation string, q *Query) (any, error)
+}
+
+// RelationshipHandler can optionally be implemented by a ResourceHandler to serve updates of
+// relationships via their relationship endpoints, e.g. PATCH /articles/1/relationships/author.
+type RelationshipHandler interface {
+	// UpdateRelationship applies the given update to the relationship named relation of the
+	// resource with the given id.
+	UpdateRelationship(ctx context.Context, id, relation string, u RelationshipUpdate) error
+}
+
+// Server serves the resources of a ResourceHandler, performing content negotiation, parsing query
+// parameters, marshaling and unmarshaling documents, and writing errors as error documents.
+//
+// A Server serves the following routes relative to the root of the collection, so it is usually
+// mounted with http.StripPrefix (e.g. http.StripPrefix("/articles", server)):
+//
+//	GET    /                              ResourceHandler.List
+//	POST   /                              ResourceHandler.Create
+//	GET    /{id}                          ResourceHandler.Get
+//	PATCH  /{id}                          ResourceHandler.Update
+//	DELETE /{id}                          ResourceHandler.Delete
+//	GET    /{id}/{relation}               RelatedHandler.GetRelated
+//	GET    /{id}/relationships/{relation} RelatedHandler.GetRelated, as resource linkage
+//	PATCH  /{id}/relationships/{relation} RelationshipHandler.UpdateRelationship
+//	POST   /{id}/relationships/{relation} RelationshipHandler.UpdateRelationship
+//	DELETE /{id}/relationships/{relation} RelationshipHandler.UpdateRelationship
+//
+// Successful relationship updates are answered with 204 (No Content).
+//
+// If the ResourceHandler implements IncludeResolver, it is used to resolve the include query
+// parameter. Otherwise, requests with the include query parameter are rejected.
+type Server[T any] struct {
+	handler ResourceHandler[T]
+	serverOptions
+}
+
+// ServerOption allows for configuration of a Server.
+type ServerOption func(s *serverOptions)
+
+// serverOptions holds the configuration of a Server, independent of its resource type.
+type serverOptions struct {
+	extensions       []string
+	marshalOptions   []MarshalOption
+	unmarshalOptions []UnmarshalOption
+}
+
+// ServerExtensions sets the URIs of the extensions supported by the Server, as defined by
+// https://jsonapi.org/format/1.1/#extensions.
+func ServerExtensions(extensions ...string) ServerOption {
+	return func(s *serverOptions) {
+		s.extensions = append(s.extensions, extensions...)
+	}
+}
+
+// ServerMarshalOptions sets additional options used to marshal response documents.
+func ServerMarshalOptions(opts ...MarshalOption) ServerOption {
+	return func(s *serverOptions) {
+		s.marshalOptions = append(s.marshalOptions, opts...)
+	}
+}
+
+// ServerUnmarshalOptions sets the options used to unmarshal request documents.
+func ServerUnmarshalOptions(opts ...UnmarshalOption) ServerOption {
+	return func(s *serverOptions) {
+		s.unmarshalOptions = append(s.unmarshalOptions, opts...)
+	}
+}
+
+// NewServer creates a new Server serving the resources of the given ResourceHandler.
+func NewServer[T any](h ResourceHandler[T], opts ...ServerOption) *Server[T] {
+	s := &Server[T]{handler: h}
+	for _, opt := range opts {
+		opt(&s.serverOptions)
+	}
+	return s
+}
+
+// newMethodNotAllowedError creates a 405 (Method Not Allowed) error, setting the Allow header.
+func newMethodNotAllowedError(w http.ResponseWriter, allowed ...string) *Error {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	return &Error{
+		Status: Status(http.StatusMethodNotAllowed),
+		Title:  http.StatusText(http.StatusMethodNotAllowed),
+	}
+}
+
+// newNotFoundError creates a 404 (Not Found) error.
+func newNotFoundError() *Error {
+	return &Error{
+		Status: Status(http.StatusNotFound),
+		Title:  http.StatusText(http.StatusNotFound),
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	HandlerFunc(s.serve).ServeHTTP(w, r)
+}
+
+func (s *Server[T]) serve(w http.ResponseWriter, r *http.Request) error {
+	if !Negotiate(w, r, s.extensions...) {
+		return nil
+	}
+
+	q, err := ParseQuery(r.URL.Query())
+	if err != nil {
+		return err
+	}
+	if _, ok := s.handler.(IncludeResolver); !ok && len(q.Include) > 0 {
+		// reject unsupported includes before any changes are made, as required by
+		// https://jsonapi.org/format/#fetching-includes
+		return newQueryParameterError("include", "The include parameter is not supported.")
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	segments := make([]string, 0)
+	if path != "" {
+		segments = strings.Split(path, "/")
+	}
+
+	switch len(segments) {
+	case 0:
+		switch r.Method {
+		case http.MethodGet:
+			vs, err := s.handler.List(r.Context(), q)
+			if err != nil {
+				return err
+			}
+			return s.write(w, r, q, http.StatusOK, vs)
+		case http.MethodPost:
+			return s.create(w, r, q)
+		}
+		return newMethodNotAllowedError(w, http.MethodGet, http.MethodPost)
+	case 1:
+		id := segments[0]
+		switch r.Method {
+		case http.MethodGet:
+			v, err := s.handler.Get(r.Context(), id, q)
+			if err != nil {
+				return err
+			}
+			if v == nil {
+				return newNotFoundError()
+			}
+			return s.write(w, r, q, http.StatusOK, v)
+		case http.MethodPatch:
+			return s.update(w, r, q, id)
+		case http.MethodDelete:
+			if err := s.handler.Delete(r.Context(), id); err != nil {
+				return err
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+		return newMethodNotAllowedError(w, http.MethodGet, http.MethodPatch, http.MethodDelete)
+	case 2:
+		return s.related(w, r, q, segments[0], segments[1], false)
+	case 3:
+		if segments[1] != "relationships" {
+			break
+		}
+		switch r.Method {
+		case http.MethodPatch, http.MethodPost, http.MethodDelete:
+			return s.updateRelationship(w, r, segments[0], segments[2])
+		}
+		return s.related(w, r, q, segments[0], segments[2], true)
+	}
+
+	return newNotFoundError()
+}
+
+// write writes v as the primary data of a response document.
+func (s *Server[T]) write(w http.ResponseWriter, r *http.Request, q *Query, status int, v any) error {
+	opts := []MarshalOption{MarshalContext(r.Context()), MarshalFields(r.URL.Query())}
+	if resolver, ok := s.handler.(IncludeResolver); ok && len(q.Include) > 0 {
+		opts = append(opts, MarshalIncludeResolver(resolver, q.Include...))
+	}
+
+	return Write(w, status, v, append(opts, s.marshalOptions...)...)
+}
+
+func (s *Server[T]) create(w http.ResponseWriter, r *http.Request, q *Query) error {
+	v := new(T)
+	if err := Read(r, v, s.unmarshalOptions...); err != nil {
+		return err
+	}
+
+	created, err := s.handler.Create(r.Context(), v)
+	if err != nil {
+		return err
+	}
+	if created == nil {
+		created = v
+	}
+	return s.write(w, r, q, http.StatusCreated, created)
+}
+
+func (s *Server[T]) update(w http.ResponseWriter, r *http.Request, q *Query, id string) error {
+	v := new(T)
+	if err := Read(r, v, s.unmarshalOptions...); err != nil {
+		return err
+	}
+
+	// the id of the resource object must match the id in the url, as required by
+	// https://jsonapi.org/format/#crud-updating-responses-409
+	ro, err := makeResourceObject(v, reflect.TypeOf(v), makeMarshaler(MarshalClientMode()), true)
+	if err != nil {
+		return err
+	}
+	if ro.ID != id {
+		return &Error{
+			Status: Status(http.StatusConflict),
+			Title:  http.StatusText(http.StatusConflict),
+			Detail: fmt.Sprintf("The resource object's id %q does not match the id %q of the endpoint.", ro.ID, id),
+			Source: &ErrorSource{Pointer: "/data/id"},
+		}
+	}
+
+	updated, err := s.handler.Update(r.Context(), id, v)
+	if err != nil {
+		return err
+	}
+	if updated == nil {
+		updated = v
+	}
+	return s.write(w, r, q, http.StatusOK, updated)
+}
+
+// related serves the related resources of the given relationship, as resource linkage if linkage is
+// true.
+func (s *Server[T]) related(w http.ResponseWriter, r *http.Request, q *Query, id, relation string, linkage bool) error {
+	rh, ok := s.handler.(RelatedHandler)
+	if !ok {
+		return newNotFoundError()
+	}
+	if r.Method != http.MethodGet {
+		if _, ok := s.handler.(RelationshipHandler); ok && linkage {
+			return newMethodNotAllowedError(w, http.MethodGet, http.MethodPatch, http.MethodPost, http.MethodDelete)
+		}
+		return newMethodNotAllowedError(w, http.MethodGet)
+	}
+
+	related, err := rh.GetRelated(r.Context(), id, relation, q)
+	if err != nil {
+		return err
+	}
+	if !linkage {
+		return s.write(w, r, q, http.StatusOK, related)
+	}
+
+	// resource linkage is marshaled as done by MarshalRef
+	m := makeMarshaler(append([]MarshalOption{MarshalContext(r.Context())}, s.marshalOptions...)...)
+	m.dataMember = ""
+	return m.observe(func(op *Operation) error {
+		d, err := makeDocument(related, m, true)
+		if err != nil {
+			return err
+		}
+		op.add(d)
+		return writeDocument(w, http.StatusOK, m, func(dw *documentWriter) error {
+			defer func() { op.Size = dw.n }()
+			return dw.write(d)
+		})
+	})
+}
+
+// updateRelationship applies the update of the given relationship requested by r.
+func (s *Server[T]) updateRelationship(w http.ResponseWriter, r *http.Request, id, relation string) error {
+	rh, ok := s.handler.(RelationshipHandler)
+	if !ok {
+		if _, ok := s.handler.(RelatedHandler); ok {
+			return newMethodNotAllowedError(w, http.MethodGet)
+		}
+		return newNotFoundError()
+	}
+
+	data, err := readBody(r, makeUnmarshaler(s.unmarshalOptions...))
+	if err != nil {
+		return err
+	}
+	u, err := UnmarshalRelationshipUpdate(data, r.Method, s.unmarshalOptions...)
+	if err != nil {
+		return err
+	}
+
+	if err := rh.UpdateRelationship(r.Context(), id, relation, u); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,341 @@
+package jsonapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// articleStore is an in-memory ResourceHandler of articles.
+type articleStore struct {
+	mu       sync.Mutex
+	articles map[string]*ArticleRelated
+	nextID   int
+}
+
+func newArticleStore() *articleStore {
+	return &articleStore{
+		articles: map[string]*ArticleRelated{
+			"1": {ID: "1", Title: "A", Author: &authorA, Comments: []*Comment{&commentA}},
+		},
+		nextID: 2,
+	}
+}
+
+func (s *articleStore) List(ctx context.Context, q *Query) ([]*ArticleRelated, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	articles := make([]*ArticleRelated, 0, len(s.articles))
+	for i := 1; i < s.nextID; i++ {
+		if a, ok := s.articles[fmt.Sprint(i)]; ok {
+			articles = append(articles, a)
+		}
+	}
+	return articles, nil
+}
+
+func (s *articleStore) Get(ctx context.Context, id string, q *Query) (*ArticleRelated, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.articles[id], nil
+}
+
+func (s *articleStore) Create(ctx context.Context, v *ArticleRelated) (*ArticleRelated, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v.ID = fmt.Sprint(s.nextID)
+	s.nextID++
+	s.articles[v.ID] = v
+	return v, nil
+}
+
+func (s *articleStore) Update(ctx context.Context, id string, v *ArticleRelated) (*ArticleRelated, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.articles[id]
+	if !ok {
+		return nil, &Error{Status: Status(http.StatusNotFound)}
+	}
+	a.Title = v.Title
+	return a, nil
+}
+
+func (s *articleStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.articles, id)
+	return nil
+}
+
+func (s *articleStore) GetRelated(ctx context.Context, id, relation string, q *Query) (any, error) {
+	a, err := s.Get(ctx, id, q)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, &Error{Status: Status(http.StatusNotFound)}
+	}
+
+	switch relation {
+	case "author":
+		if a.Author == nil {
+			return nil, nil
+		}
+		return a.Author, nil
+	case "comments":
+		return a.Comments, nil
+	}
+	return nil, &Error{Status: Status(http.StatusNotFound)}
+}
+
+// includingArticleStore is an articleStore resolving includes.
+type includingArticleStore struct {
+	*articleStore
+}
+
+func (s *includingArticleStore) Resolve(ctx context.Context, parent any, relation string) ([]any, error) {
+	a := parent.(*ArticleRelated)
+	if relation == "author" && a.Author != nil {
+		return []any{a.Author}, nil
+	}
+	return nil, nil
+}
+
+// relatingArticleStore is an articleStore updating the comments relationship.
+type relatingArticleStore struct {
+	*articleStore
+}
+
+func (s *relatingArticleStore) UpdateRelationship(ctx context.Context, id, relation string, u RelationshipUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.articles[id]
+	if !ok || relation != "comments" {
+		return &Error{Status: Status(http.StatusNotFound)}
+	}
+	switch u.Op {
+	case RelationshipReplace:
+		a.Comments = nil
+		fallthrough
+	case RelationshipAdd:
+		for _, ri := range u.Identifiers {
+			a.Comments = append(a.Comments, &Comment{ID: ri.ID})
+		}
+	case RelationshipRemove:
+		return &Error{Status: Status(http.StatusForbidden)}
+	}
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description  string
+		handler      ResourceHandler[ArticleRelated]
+		method       string
+		target       string
+		body         string
+		contentType  string
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			description:  "list",
+			method:       http.MethodGet,
+			target:       "/",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"data":[{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}},"comments":{"data":[{"id":"1","type":"comments"}],"links":{"self":"http://example.com/articles/1/relationships/comments","related":"http://example.com/articles/1/comments"}}}}]}`,
+		}, {
+			description:  "get",
+			method:       http.MethodGet,
+			target:       "/1?fields[articles]=title",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}}}`,
+		}, {
+			description:  "get with include",
+			handler:      &includingArticleStore{newArticleStore()},
+			method:       http.MethodGet,
+			target:       "/1?include=author&fields[articles]=title,author",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}}}},"included":[{"id":"1","type":"author","attributes":{"name":"A"}}]}`,
+		}, {
+			description:  "get with unsupported include",
+			method:       http.MethodGet,
+			target:       "/1?include=author",
+			expectStatus: http.StatusBadRequest,
+		}, {
+			description:  "get not found",
+			method:       http.MethodGet,
+			target:       "/2",
+			expectStatus: http.StatusNotFound,
+		}, {
+			description:  "create",
+			method:       http.MethodPost,
+			target:       "/",
+			body:         `{"data":{"type":"articles","attributes":{"title":"B"}}}`,
+			contentType:  MediaType,
+			expectStatus: http.StatusCreated,
+			expectBody:   `{"data":{"id":"2","type":"articles","attributes":{"title":"B"}}}`,
+		}, {
+			description:  "create with invalid content type",
+			method:       http.MethodPost,
+			target:       "/",
+			body:         `{"data":{"type":"articles","attributes":{"title":"B"}}}`,
+			contentType:  "application/json",
+			expectStatus: http.StatusUnsupportedMediaType,
+		}, {
+			description:  "update",
+			method:       http.MethodPatch,
+			target:       "/1?fields[articles]=title",
+			body:         `{"data":{"id":"1","type":"articles","attributes":{"title":"B"}}}`,
+			contentType:  MediaType,
+			expectStatus: http.StatusOK,
+			expectBody:   `{"data":{"id":"1","type":"articles","attributes":{"title":"B"}}}`,
+		}, {
+			description:  "update with mismatching id",
+			method:       http.MethodPatch,
+			target:       "/1",
+			body:         `{"data":{"id":"2","type":"articles","attributes":{"title":"B"}}}`,
+			contentType:  MediaType,
+			expectStatus: http.StatusConflict,
+		}, {
+			description:  "delete",
+			method:       http.MethodDelete,
+			target:       "/1",
+			expectStatus: http.StatusNoContent,
+		}, {
+			description:  "method not allowed",
+			method:       http.MethodPut,
+			target:       "/1",
+			expectStatus: http.StatusMethodNotAllowed,
+		}, {
+			description:  "related to-one",
+			method:       http.MethodGet,
+			target:       "/1/author",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"data":{"id":"1","type":"author","attributes":{"name":"A"}}}`,
+		}, {
+			description:  "related to-many",
+			method:       http.MethodGet,
+			target:       "/1/comments",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"data":[{"id":"1","type":"comments","attributes":{"body":"A"}}]}`,
+		}, {
+			description:  "relationship to-one",
+			method:       http.MethodGet,
+			target:       "/1/relationships/author",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"data":{"id":"1","type":"author"}}`,
+		}, {
+			description:  "relationship to-many",
+			method:       http.MethodGet,
+			target:       "/1/relationships/comments",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"data":[{"id":"1","type":"comments"}]}`,
+		}, {
+			description:  "unknown relationship",
+			method:       http.MethodGet,
+			target:       "/1/relationships/tags",
+			expectStatus: http.StatusNotFound,
+		}, {
+			description:  "relationship update",
+			handler:      &relatingArticleStore{newArticleStore()},
+			method:       http.MethodPost,
+			target:       "/1/relationships/comments",
+			body:         `{"data":[{"type":"comments","id":"2"}]}`,
+			contentType:  MediaType,
+			expectStatus: http.StatusNoContent,
+		}, {
+			description:  "relationship update rejected by handler",
+			handler:      &relatingArticleStore{newArticleStore()},
+			method:       http.MethodDelete,
+			target:       "/1/relationships/comments",
+			body:         `{"data":[{"type":"comments","id":"1"}]}`,
+			contentType:  MediaType,
+			expectStatus: http.StatusForbidden,
+		}, {
+			description:  "invalid relationship update",
+			handler:      &relatingArticleStore{newArticleStore()},
+			method:       http.MethodPost,
+			target:       "/1/relationships/comments",
+			body:         `{"data":{"type":"comments","id":"2"}}`,
+			contentType:  MediaType,
+			expectStatus: http.StatusBadRequest,
+		}, {
+			description:  "relationship update unsupported",
+			method:       http.MethodPatch,
+			target:       "/1/relationships/comments",
+			body:         `{"data":[]}`,
+			contentType:  MediaType,
+			expectStatus: http.StatusMethodNotAllowed,
+		}, {
+			description:  "unknown route",
+			method:       http.MethodGet,
+			target:       "/1/relationships/author/1",
+			expectStatus: http.StatusNotFound,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			if tc.handler == nil {
+				tc.handler = newArticleStore()
+			}
+			s := NewServer(tc.handler)
+
+			r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, r)
+			is.Equal(t, tc.expectStatus, rec.Code)
+			if tc.expectBody != "" {
+				is.EqualJSON(t, tc.expectBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServerRelationshipMatchesMarshalRef(t *testing.T) {
+	t.Parallel()
+
+	store := newArticleStore()
+	s := NewServer[ArticleRelated](store)
+
+	for _, relation := range []string{"author", "comments"} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/1/relationships/"+relation, nil))
+		is.MustEqual(t, http.StatusOK, rec.Code)
+
+		// relationship endpoints serve the resource linkage encoded by MarshalRef, without the
+		// links of the relationship, which the endpoint doesn't know
+		b, err := MarshalRef(store.articles["1"], relation)
+		is.MustNoError(t, err)
+		var expect, actual struct {
+			Data any `json:"data"`
+		}
+		is.MustNoError(t, json.Unmarshal(b, &expect))
+		is.MustNoError(t, json.Unmarshal(rec.Body.Bytes(), &actual))
+		is.Equal(t, expect.Data, actual.Data)
+	}
+}
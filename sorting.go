@@ -0,0 +1,231 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortResources sorts resources, a slice of resource structs or pointers to them, by the given sort
+// fields as parsed by ParseQuery, e.g. to serve small in-memory collections or in tests. A sort
+// field is the member name of an attribute, or a dot separated path of to-one relationships
+// followed by an attribute of the related resource (e.g. "author.name"), resolved via struct tags
+// and the NamingConvention given by MarshalNamingConvention, if any, as done by Marshal.
+//
+// Attributes must be numbers, strings, booleans or time.Time values, or pointers to them. Nil
+// values, including those of nil related resources, sort before all other values. The sort is
+// stable, so resources with equal sort fields keep their order.
+//
+// A 400 (Bad Request) *Error with the sort parameter as source is returned if a sort field doesn't
+// exist or can't be sorted by, and resources are left untouched.
+func SortResources[T any](resources []T, fields []SortField, opts ...MarshalOption) error {
+	m := makeMarshaler(opts...)
+	t := derefType(reflect.TypeOf(resources).Elem())
+	paths := make([][]sortStep, len(fields))
+	for i, f := range fields {
+		path, err := resolveSortField(t, f.Field, m.naming)
+		if err != nil {
+			return newSortFieldError(f, err)
+		}
+		paths[i] = path
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		vi := reflect.ValueOf(resources[i])
+		vj := reflect.ValueOf(resources[j])
+		for k, f := range fields {
+			c := compareSortValues(sortFieldValue(vi, paths[k]), sortFieldValue(vj, paths[k]))
+			if c == 0 {
+				continue
+			}
+			if f.Descending {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+	return nil
+}
+
+// newSortFieldError creates a 400 (Bad Request) error for the unsupported sort field f.
+func newSortFieldError(f SortField, err error) *Error {
+	return newQueryParameterError("sort", fmt.Sprintf("The sort field %q is not supported: %v.", f.Field, err))
+}
+
+// sortStep is a segment of a sort field resolved to the struct field holding it.
+type sortStep struct {
+	// index is the index sequence of the struct field, through flattened structs
+	index []int
+
+	// field is the struct field
+	field reflect.StructField
+
+	// relationship is true if the struct field is a relationship rather than an attribute
+	relationship bool
+}
+
+// taggedField returns the index sequence of the field of the struct type t, through flattened
+// structs, with the given directive and member name as derived with the given NamingConvention.
+func taggedField(t reflect.Type, d directive, name string, naming NamingConvention) ([]int, reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isFlattened(f) && isStructType(f.Type) {
+			if index, sf, ok := taggedField(derefType(f.Type), d, name, naming); ok {
+				return append([]int{i}, index...), sf, true
+			}
+			continue
+		}
+		tag, err := parseJSONAPITag(f)
+		if err != nil || tag == nil || tag.directive != d {
+			continue
+		}
+		if n, ok, _ := memberName(f, naming); ok && n == name {
+			return []int{i}, f, true
+		}
+	}
+	return nil, reflect.StructField{}, false
+}
+
+// resolveSortField resolves the sort field with the given name to the struct fields holding it,
+// starting from the resource struct type t. It returns an error if t can't be sorted by it.
+func resolveSortField(t reflect.Type, name string, naming NamingConvention) ([]sortStep, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, &TypeError{Actual: t.String(), Expected: []string{"struct"}}
+	}
+
+	segments := strings.Split(name, ".")
+	path := make([]sortStep, len(segments))
+	for i, segment := range segments {
+		if i < len(segments)-1 {
+			index, f, ok := taggedField(t, relationship, segment, naming)
+			if !ok {
+				return nil, fmt.Errorf("%q is not a relationship", segment)
+			}
+			if t = derefType(relatedFieldType(f.Type)); t.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("%q is not a to-one relationship", segment)
+			}
+			path[i] = sortStep{index: index, field: f, relationship: true}
+			continue
+		}
+
+		index, f, ok := taggedField(t, attribute, segment, naming)
+		if !ok {
+			return nil, fmt.Errorf("%q is not an attribute", segment)
+		}
+		if !isSortableType(f.Type) {
+			return nil, fmt.Errorf("attributes of type %s can't be sorted by", f.Type)
+		}
+		path[i] = sortStep{index: index, field: f}
+	}
+	return path, nil
+}
+
+// isSortableType returns true if values of type t can be compared by compareSortValues.
+func isSortableType(t reflect.Type) bool {
+	t = derefType(t)
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return true
+	}
+	return t == timeType
+}
+
+// sortFieldValue returns the value of the sort field resolved to path of the resource rv, or an
+// invalid value if it is nil.
+func sortFieldValue(rv reflect.Value, path []sortStep) reflect.Value {
+	for _, step := range path {
+		for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+			if rv.IsNil() {
+				return reflect.Value{}
+			}
+			rv = rv.Elem()
+		}
+		rv = fieldByIndex(rv, step)
+		if step.relationship {
+			rv, _ = relatedField(rv)
+		}
+	}
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+// fieldByIndex returns the struct field of rv resolved to step. Fields of nil flattened struct
+// pointers are zero, as when marshaling.
+func fieldByIndex(rv reflect.Value, step sortStep) reflect.Value {
+	for i, x := range step.index {
+		if i > 0 {
+			for rv.Kind() == reflect.Pointer {
+				if rv.IsNil() {
+					return reflect.Zero(step.field.Type)
+				}
+				rv = rv.Elem()
+			}
+		}
+		rv = rv.Field(x)
+	}
+	return rv
+}
+
+// compareSortValues returns -1, 0 or 1 if a is less than, equal to or greater than b, which are
+// values of a type for which isSortableType is true, or invalid if nil.
+func compareSortValues(a, b reflect.Value) int {
+	switch {
+	case !a.IsValid() && !b.IsValid():
+		return 0
+	case !a.IsValid():
+		return -1
+	case !b.IsValid():
+		return 1
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compareOrdered(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return compareOrdered(a.Float(), b.Float())
+	case reflect.String:
+		return compareOrdered(a.String(), b.String())
+	case reflect.Bool:
+		return compareOrdered(boolRank(a.Bool()), boolRank(b.Bool()))
+	}
+
+	ta, tb := a.Interface().(time.Time), b.Interface().(time.Time)
+	switch {
+	case ta.Before(tb):
+		return -1
+	case ta.After(tb):
+		return 1
+	}
+	return 0
+}
+
+// compareOrdered returns -1, 0 or 1 if a is less than, equal to or greater than b.
+func compareOrdered[T int64 | uint64 | float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// boolRank ranks false before true.
+func boolRank(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,142 @@
+package jsonapi
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestSortResources(t *testing.T) {
+	t.Parallel()
+
+	rating := func(r float64) *float64 { return &r }
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	articles := func() []*ArticleSortable {
+		return []*ArticleSortable{
+			{ID: "1", Title: "B", Views: 2, Rating: rating(1.5), Published: day(3), Author: &Author{ID: "1", Name: "Y"}},
+			{ID: "2", Title: "A", Views: 2, Draft: true, Published: day(1), Author: &Author{ID: "2", Name: "X"}},
+			{ID: "3", Title: "C", Views: 1, Rating: rating(0.5), Published: day(2)},
+		}
+	}
+
+	tests := []struct {
+		description string
+		fields      []SortField
+		expect      []string
+		expectError string
+	}{
+		{
+			description: "no sort fields",
+			expect:      []string{"1", "2", "3"},
+		}, {
+			description: "string",
+			fields:      []SortField{{Field: "title"}},
+			expect:      []string{"2", "1", "3"},
+		}, {
+			description: "descending string",
+			fields:      []SortField{{Field: "title", Descending: true}},
+			expect:      []string{"3", "1", "2"},
+		}, {
+			description: "several fields",
+			fields:      []SortField{{Field: "views", Descending: true}, {Field: "title"}},
+			expect:      []string{"2", "1", "3"},
+		}, {
+			description: "stable",
+			fields:      []SortField{{Field: "views", Descending: true}},
+			expect:      []string{"1", "2", "3"},
+		}, {
+			description: "nil pointers first",
+			fields:      []SortField{{Field: "rating"}},
+			expect:      []string{"2", "3", "1"},
+		}, {
+			description: "bool",
+			fields:      []SortField{{Field: "draft", Descending: true}},
+			expect:      []string{"2", "1", "3"},
+		}, {
+			description: "time",
+			fields:      []SortField{{Field: "published"}},
+			expect:      []string{"2", "3", "1"},
+		}, {
+			description: "related attribute",
+			fields:      []SortField{{Field: "author.name"}},
+			expect:      []string{"3", "2", "1"},
+		}, {
+			description: "unknown attribute",
+			fields:      []SortField{{Field: "body"}},
+			expectError: `The sort field "body" is not supported: "body" is not an attribute.`,
+		}, {
+			description: "unsortable attribute",
+			fields:      []SortField{{Field: "tags"}},
+			expectError: `The sort field "tags" is not supported: attributes of type []string can't be sorted by.`,
+		}, {
+			description: "to-many relationship",
+			fields:      []SortField{{Field: "comments.body"}},
+			expectError: `The sort field "comments.body" is not supported: "comments" is not a to-one relationship.`,
+		}, {
+			description: "unknown relationship",
+			fields:      []SortField{{Field: "title.body"}},
+			expectError: `The sort field "title.body" is not supported: "title" is not a relationship.`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			resources := articles()
+			err := SortResources(resources, tc.fields)
+			if tc.expectError != "" {
+				is.MustError(t, err)
+				is.Equal(t, []*Error{newQueryParameterError("sort", tc.expectError)}, ErrorObjects(err))
+				is.Equal(t, articles(), resources)
+				return
+			}
+			is.MustNoError(t, err)
+
+			ids := make([]string, len(resources))
+			for i, a := range resources {
+				ids[i] = a.ID
+			}
+			is.Equal(t, tc.expect, ids)
+		})
+	}
+
+	// slices of structs are sorted as well
+	values := []ArticleSortable{{ID: "1", Title: "B"}, {ID: "2", Title: "A"}}
+	is.MustNoError(t, SortResources(values, []SortField{{Field: "title"}}))
+	is.Equal(t, "2", values[0].ID)
+}
+
+func TestSortResourcesNamingConvention(t *testing.T) {
+	t.Parallel()
+
+	type timestamps struct {
+		CreatedAt time.Time `jsonapi:"attribute"`
+	}
+	type article struct {
+		ID         string `jsonapi:"primary,articles"`
+		Title      string `jsonapi:"attribute"`
+		timestamps `jsonapi:"attribute,flatten"`
+	}
+
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+	articles := []article{
+		{ID: "1", Title: "B", timestamps: timestamps{CreatedAt: day(2)}},
+		{ID: "2", Title: "A", timestamps: timestamps{CreatedAt: day(3)}},
+		{ID: "3", Title: "C", timestamps: timestamps{CreatedAt: day(1)}},
+	}
+
+	is.MustNoError(t, SortResources(articles, []SortField{{Field: "createdAt"}}, MarshalNamingConvention(CamelCase)))
+	is.Equal(t, []string{"3", "1", "2"}, []string{articles[0].ID, articles[1].ID, articles[2].ID})
+
+	is.MustNoError(t, SortResources(articles, []SortField{{Field: "title"}}, MarshalNamingConvention(CamelCase)))
+	is.Equal(t, []string{"2", "1", "3"}, []string{articles[0].ID, articles[1].ID, articles[2].ID})
+
+	err := SortResources(articles, []SortField{{Field: "createdAt"}})
+	is.Equal(t, []*Error{newQueryParameterError("sort", `The sort field "createdAt" is not supported: "createdAt" is not an attribute.`)}, ErrorObjects(err))
+}
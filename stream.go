@@ -0,0 +1,340 @@
+package jsonapi
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// StreamFailure is what happens to a document being written incrementally (e.g. by MarshalTo or
+// Write) when marshaling or writing it fails after part of the document has been written. It is
+// given by MarshalStreamFailure. Primary data is made in chunks of MarshalFlushThreshold resource
+// objects, whose includes are resolved by the MarshalIncludeResolver before the chunk is written,
+// so an IncludeResolver failing for a later chunk, or a resource object failing to marshal, fails
+// midway.
+type StreamFailure int
+
+const (
+	// StreamFailureTruncate leaves the incomplete document as written, which isn't valid json.
+	StreamFailureTruncate StreamFailure = iota
+
+	// StreamFailureAbort aborts the response written by Write by panicking with
+	// http.ErrAbortHandler once the error is known, so that net/http closes the connection (or
+	// resets the stream) and clients see a failed transfer rather than a truncated body, without
+	// hijacking the connection. MarshalTo leaves the document as written.
+	StreamFailureAbort
+
+	// StreamFailureTrailer completes the document after the primary data written so far, leaving
+	// out the members following it (e.g. included), so that the body is valid json, and reports the
+	// failure out of band: if the document is written to an http.ResponseWriter, the error objects
+	// of the error, as converted by ErrorObjects, are sent in the StreamErrorTrailer HTTP trailer.
+	// Clients must check the trailer to tell complete documents from failed ones. The document
+	// never holds both data and errors, which json:api forbids.
+	StreamFailureTrailer
+)
+
+// StreamErrorTrailer is the HTTP trailer holding the json encoded error objects of documents
+// failing midway with StreamFailureTrailer.
+const StreamErrorTrailer = "Jsonapi-Stream-Error"
+
+// MarshalStreamFailure sets what happens to documents written incrementally when marshaling fails
+// midway. The error is returned in any case.
+func MarshalStreamFailure(f StreamFailure) MarshalOption {
+	return func(m *Marshaler) {
+		m.streamFailure = f
+	}
+}
+
+// MarshalTo writes the json:api encoding of v to w, as encoded by Marshal.
+//
+// Primary data containing many resource objects is made and written incrementally rather than
+// encoded into a single buffer first: resource objects are made in chunks of MarshalFlushThreshold
+// resource objects (all of them if no threshold is given), and each resource object is written as
+// soon as the includes of its chunk are resolved. w is flushed after every chunk if it implements
+// http.Flusher. Included resources are written after primary data, so they may be ordered
+// differently than by Marshal, and links-only relationships of primary data only link resources
+// included by the time they are written. Documents using MarshalStringTable or
+// MarshalIncludeLimit, which change primary data once the whole document is made, and documents
+// formatted as given by MarshalEscapeHTML or MarshalIndent are made as a whole first.
+//
+// If marshaling fails before anything has been written, nothing is written to w. Otherwise, the
+// failure is handled as configured by MarshalStreamFailure.
+func MarshalTo(w io.Writer, v any, opts ...MarshalOption) error {
+	m := makeMarshaler(opts...)
+
+	return m.observe(func(op *Operation) error {
+		dw := &documentWriter{w: w, m: m}
+		defer func() { op.Size = dw.n }()
+		d, err := dw.stream(v)
+		op.add(d)
+		return err
+	})
+}
+
+// documentWriter writes a document to an io.Writer incrementally, one primary resource object at a
+// time, so that large collections can reach the client before the whole document is encoded.
+type documentWriter struct {
+	w io.Writer
+	m *Marshaler
+
+	// written is the number of primary resource objects written so far
+	written int
+
+	// started is true once part of the document has been written incrementally
+	started bool
+
+	// n is the number of bytes written so far
+	n int
+}
+
+// Write writes b to the underlying writer, counting the bytes written for Hooks.
+func (dw *documentWriter) Write(b []byte) (int, error) {
+	n, err := dw.w.Write(b)
+	dw.n += n
+	return n, err
+}
+
+// flush flushes the underlying writer if it implements http.Flusher and the flush threshold given
+// by MarshalFlushThreshold has been reached.
+func (dw *documentWriter) flush() {
+	if dw.m.flushThreshold <= 0 || dw.written%dw.m.flushThreshold != 0 {
+		return
+	}
+	if f, ok := dw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// encodeResourceObject encodes a single primary resource object into buf, wrapped as the primary
+// data of a document so that its member names are validated as such.
+func (dw *documentWriter) encodeResourceObject(buf *bytes.Buffer, ro *resourceObject) error {
+	buf.WriteString(`{"data":`)
+	if err := encodeJSON(buf, ro); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return validateJSONMemberNames(buf.Bytes(), dw.m.memberNameValidationMode, dw.m.relaxedMemberClasses, dw.m.extensions)
+}
+
+// writeResourceObject writes a single primary resource object, validating its member names.
+// Primary resource objects held by AtomicResultsMember are wrapped in result objects. The document
+// is begun up to the opening bracket of its primary data before its first resource object if it
+// hasn't been yet, as done by stream.
+func (dw *documentWriter) writeResourceObject(ro *resourceObject) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := dw.encodeResourceObject(buf, ro); err != nil {
+		return err
+	}
+
+	wrapped := buf.Bytes()
+	b := wrapped[len(`{"data":`) : len(wrapped)-1]
+
+	if !dw.started {
+		// the document is begun once its first resource object is known to be valid
+		key, err := dataMemberKey(dw.m.dataMember)
+		if err != nil {
+			return err
+		}
+		if _, err := dw.Write(append(append([]byte("{"), key...), '[')); err != nil {
+			return err
+		}
+		dw.started = true
+	}
+	if dw.written > 0 {
+		if _, err := io.WriteString(dw, ","); err != nil {
+			return err
+		}
+	}
+	if dw.m.dataMember == AtomicResultsMember {
+		b = wrapped
+	}
+	if _, err := dw.Write(b); err != nil {
+		return err
+	}
+
+	dw.written++
+	dw.flush()
+
+	return nil
+}
+
+// stream makes the document of v and writes it, writing many primary resource objects as soon as
+// they are made, in chunks of MarshalFlushThreshold resource objects, unless the document is
+// formatted as given by MarshalEscapeHTML or MarshalIndent or can't be streamed. Failures after
+// part of the document has been written are handled as configured by MarshalStreamFailure.
+func (dw *documentWriter) stream(v any) (*document, error) {
+	var emit func(ros []*resourceObject) error
+	if !dw.m.formatted() {
+		emit = dw.writeChunk
+	}
+	d, err := buildDocument(v, dw.m, false, emit)
+	if err != nil {
+		if dw.started {
+			return nil, dw.fail(err, "]")
+		}
+		return nil, err
+	}
+	if !dw.started {
+		return d, dw.write(d)
+	}
+
+	if _, err := io.WriteString(dw, "]"); err != nil {
+		return d, err
+	}
+	rest, err := dw.marshalRest(d)
+	if err != nil {
+		return d, dw.fail(err, "")
+	}
+	return d, dw.writeRest(rest)
+}
+
+// writeChunk writes the given primary resource objects of a document streamed by stream, beginning
+// the document with the first of them.
+func (dw *documentWriter) writeChunk(ros []*resourceObject) error {
+	for _, ro := range ros {
+		if err := dw.writeResourceObject(ro); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write writes the given document. Only documents with many primary resource objects or primary
+// data held by an extension member are written incrementally, all others are marshaled as a whole,
+// as are documents formatted as given by MarshalEscapeHTML or MarshalIndent.
+func (dw *documentWriter) write(d *document) error {
+	if !dw.m.formatted() {
+		return dw.writeCompact(d)
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := (&documentWriter{w: buf, m: dw.m}).writeCompact(d); err != nil {
+		return err
+	}
+	b, err := dw.m.format(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = dw.Write(b)
+	return err
+}
+
+// writeCompact writes the given document without formatting it.
+func (dw *documentWriter) writeCompact(d *document) error {
+	if len(d.Errors) > 0 || d.noData || !d.hasMany && dw.m.dataMember == "" {
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if err := encodeJSON(buf, d); err != nil {
+			return err
+		}
+		if err := validateJSONMemberNames(buf.Bytes(), dw.m.memberNameValidationMode, dw.m.relaxedMemberClasses, dw.m.extensions); err != nil {
+			return err
+		}
+		_, err := dw.Write(buf.Bytes())
+		return err
+	}
+
+	// marshal every member but data up front, so that failures happen before anything is written
+	rest, err := dw.marshalRest(d)
+	if err != nil {
+		return err
+	}
+
+	key, err := dataMemberKey(dw.m.dataMember)
+	if err != nil {
+		return err
+	}
+	if _, err := dw.Write(append([]byte("{"), key...)); err != nil {
+		return err
+	}
+	dw.started = true
+	if err := dw.writeData(d); err != nil {
+		return err
+	}
+	return dw.writeRest(rest)
+}
+
+// marshalRest returns the json object of the members of the given document but its primary data.
+func (dw *documentWriter) marshalRest(d *document) ([]byte, error) {
+	type alias document
+	rest, err := marshalJSON(&struct{ *alias }{alias: (*alias)(d)})
+	if err != nil {
+		return nil, err
+	}
+	if rest, err = appendMembers(rest, d.extensions); err != nil {
+		return nil, err
+	}
+	if err := validateJSONMemberNames(rest, dw.m.memberNameValidationMode, dw.m.relaxedMemberClasses, dw.m.extensions); err != nil {
+		return nil, err
+	}
+	return rest, nil
+}
+
+// writeRest writes the members of the given json object after the primary data written so far,
+// completing the document.
+func (dw *documentWriter) writeRest(rest []byte) error {
+	// rest is a json object, so replace its opening brace to append its members after data
+	rest = bytes.TrimPrefix(rest, []byte("{"))
+	if !bytes.Equal(rest, []byte("}")) {
+		if _, err := io.WriteString(dw, ","); err != nil {
+			return err
+		}
+	}
+	_, err := dw.Write(rest)
+	return err
+}
+
+// writeData writes the primary data of the given document. Primary data held by AtomicResultsMember
+// is always an array of result objects.
+func (dw *documentWriter) writeData(d *document) error {
+	ros := d.DataMany
+	if !d.hasMany {
+		if dw.m.dataMember != AtomicResultsMember {
+			if d.DataOne == nil {
+				_, err := io.WriteString(dw, "null")
+				return err
+			}
+			if err := dw.writeResourceObject(d.DataOne); err != nil {
+				return dw.fail(err, "null")
+			}
+			return nil
+		}
+		ros = nil
+		if d.DataOne != nil {
+			ros = []*resourceObject{d.DataOne}
+		}
+	}
+
+	if _, err := io.WriteString(dw, "["); err != nil {
+		return err
+	}
+	for _, ro := range ros {
+		if err := dw.writeResourceObject(ro); err != nil {
+			return dw.fail(err, "]")
+		}
+	}
+	_, err := io.WriteString(dw, "]")
+	return err
+}
+
+// fail completes the document if configured with StreamFailureTrailer, after writing closing to
+// complete the primary data written so far, and sends the error objects of err in the
+// StreamErrorTrailer HTTP trailer if writing to an http.ResponseWriter. err is returned as is.
+func (dw *documentWriter) fail(err error, closing string) error {
+	if dw.m.streamFailure != StreamFailureTrailer {
+		return err
+	}
+	if rw, ok := dw.w.(http.ResponseWriter); ok {
+		if b, merr := marshalJSON(ErrorObjects(err)); merr == nil {
+			rw.Header().Set(http.TrailerPrefix+StreamErrorTrailer, string(b))
+		}
+	}
+	if _, werr := io.WriteString(dw, closing+"}"); werr == nil {
+		if f, ok := dw.w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	return err
+}
@@ -0,0 +1,236 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// failingWriter fails every write after the first n bytes.
+type failingWriter struct {
+	n   int
+	err error
+}
+
+func (fw *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > fw.n {
+		n := fw.n
+		fw.n = 0
+		return n, fw.err
+	}
+	fw.n -= len(p)
+	return len(p), nil
+}
+
+func TestMarshalTo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expectError error
+	}{
+		{
+			description: "nil",
+			given:       nil,
+		}, {
+			description: "*Article",
+			given:       &articleA,
+		}, {
+			description: "[]*Article (empty)",
+			given:       []*Article{},
+		}, {
+			description: "[]*Article",
+			given:       articlesABPtr,
+		}, {
+			description: "[]*ArticleRelated with include and meta",
+			given:       []*ArticleRelated{&articleRelatedComplete},
+			opts:        []MarshalOption{MarshalInclude(&authorAWithMeta, &commentA, &commentB), MarshalMeta(map[string]any{"foo": "bar"})},
+		}, {
+			description: "[]*Error",
+			given:       errorsComplexSliceManyPtr,
+		}, {
+			description: "[]*Article with missing ID",
+			given:       []*Article{&articleA, &articleANoID},
+			expectError: ErrEmptyPrimaryField,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var buf bytes.Buffer
+			err := MarshalTo(&buf, tc.given, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				is.Equal(t, 0, buf.Len())
+				return
+			}
+			is.MustNoError(t, err)
+
+			expect, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, string(expect), buf.String())
+		})
+	}
+}
+
+func TestMarshalToWriterError(t *testing.T) {
+	t.Parallel()
+
+	articles := make([]*Article, 10)
+	for i := range articles {
+		articles[i] = &Article{ID: fmt.Sprintf("%d", i), Title: "A"}
+	}
+
+	errWrite := errors.New("write failed")
+	for _, n := range []int{0, 10, 100} {
+		err := MarshalTo(&failingWriter{n: n, err: errWrite}, articles)
+		is.EqualError(t, errWrite, err)
+	}
+}
+
+// failingValue fails to marshal if true.
+type failingValue bool
+
+func (f failingValue) MarshalJSON() ([]byte, error) {
+	if f {
+		return nil, errors.New("marshal failed")
+	}
+	return []byte("false"), nil
+}
+
+// ArticleFailing fails to marshal if Fail is true.
+type ArticleFailing struct {
+	ID   string       `jsonapi:"primary,articles"`
+	Fail failingValue `jsonapi:"attribute" json:"fail"`
+}
+
+func TestMarshalStreamFailure(t *testing.T) {
+	t.Parallel()
+
+	articles := []*ArticleFailing{{ID: "1"}, {ID: "2", Fail: true}}
+
+	tests := []struct {
+		description   string
+		given         any
+		opts          []MarshalOption
+		expect        string
+		expectTrailer bool
+	}{
+		{
+			description: "truncated",
+			given:       articles,
+			expect:      `{"data":[{"id":"1","type":"articles","attributes":{"fail":false}}`,
+		}, {
+			description:   "trailer",
+			given:         articles,
+			opts:          []MarshalOption{MarshalStreamFailure(StreamFailureTrailer), MarshalMeta(map[string]any{"count": 2})},
+			expect:        `{"data":[{"id":"1","type":"articles","attributes":{"fail":false}}]}`,
+			expectTrailer: true,
+		}, {
+			description:   "trailer with a single resource object",
+			given:         &ArticleFailing{ID: "1", Fail: true},
+			opts:          []MarshalOption{MarshalStreamFailure(StreamFailureTrailer), MarshalDataMember("version:data"), MarshalExtensions("version")},
+			expect:        `{"version:data":null}`,
+			expectTrailer: true,
+		}, {
+			description: "include resolver failing before anything is written",
+			given:       []*ArticleRelated{{ID: "1"}, {ID: "2"}},
+			opts: []MarshalOption{
+				MarshalStreamFailure(StreamFailureTrailer),
+				MarshalIncludeResolver(IncludeResolverFunc(func(context.Context, any, string) ([]any, error) {
+					return nil, errors.New("resolve failed")
+				}), "author"),
+			},
+		}, {
+			description: "include resolver failing midway",
+			given:       []*ArticleRelated{{ID: "1"}, {ID: "2"}},
+			opts: []MarshalOption{
+				MarshalStreamFailure(StreamFailureTrailer),
+				MarshalFlushThreshold(1),
+				MarshalIncludeResolver(IncludeResolverFunc(func(_ context.Context, parent any, _ string) ([]any, error) {
+					if parent.(*ArticleRelated).ID == "2" {
+						return nil, errors.New("resolve failed")
+					}
+					return []any{&Author{ID: "1", Name: "A"}}, nil
+				}), "author"),
+			},
+			expect:        `{"data":[{"id":"1","type":"articles","attributes":{"title":""},"relationships":{"author":{"data":{"id":"1","type":"author"},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}}}}]}`,
+			expectTrailer: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			rec := httptest.NewRecorder()
+			err := MarshalTo(rec, tc.given, tc.opts...)
+			is.MustError(t, err)
+			is.Equal(t, tc.expect, rec.Body.String())
+
+			trailer := rec.Result().Trailer.Get(StreamErrorTrailer)
+			if !tc.expectTrailer {
+				is.Equal(t, "", trailer)
+				return
+			}
+			var errs []*Error
+			is.MustNoError(t, json.Unmarshal([]byte(trailer), &errs))
+			is.Equal(t, 1, len(errs))
+		})
+	}
+}
+
+func TestMarshalToIncludeResolverChunks(t *testing.T) {
+	t.Parallel()
+
+	articles := []*ArticleRelated{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	resolver := &batchResolverCounter{}
+
+	var buf bytes.Buffer
+	err := MarshalTo(&buf, articles, MarshalFlushThreshold(2), MarshalIncludeResolver(resolver, "author"))
+	is.MustNoError(t, err)
+	is.Equal(t, [][]string{{"1", "2"}, {"3"}}, resolver.batches)
+
+	expect, err := Marshal(articles, MarshalIncludeResolver(&batchResolverCounter{}, "author"))
+	is.MustNoError(t, err)
+	is.EqualJSON(t, string(expect), buf.String())
+}
+
+// batchResolverCounter resolves the author of articles in batches, recording the ids of the parents
+// of each batch.
+type batchResolverCounter struct {
+	batches [][]string
+}
+
+func (r *batchResolverCounter) Resolve(ctx context.Context, parent any, relation string) ([]any, error) {
+	related, err := r.ResolveBatch(ctx, []any{parent}, relation)
+	if err != nil {
+		return nil, err
+	}
+	return related[0], nil
+}
+
+func (r *batchResolverCounter) ResolveBatch(_ context.Context, parents []any, _ string) ([][]any, error) {
+	ids := make([]string, len(parents))
+	related := make([][]any, len(parents))
+	for i, parent := range parents {
+		ids[i] = parent.(*ArticleRelated).ID
+		related[i] = []any{&Author{ID: "1", Name: "A"}}
+	}
+	r.batches = append(r.batches, ids)
+	return related, nil
+}
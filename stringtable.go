@@ -0,0 +1,131 @@
+package jsonapi
+
+import (
+	"reflect"
+)
+
+// StringTableNamespace is the namespace of the members of the string table extension emitted by
+// MarshalStringTable, as defined by https://jsonapi.org/format/1.1/#extensions.
+const StringTableNamespace = "strtab"
+
+// StringTableExtensionURI is the URI of the string table extension emitted by MarshalStringTable.
+const StringTableExtensionURI = "https://pkg.go.dev/github.com/DataDog/jsonapi#MarshalStringTable"
+
+const (
+	// stringTableMember is the top-level member of the string table extension holding the table.
+	stringTableMember = StringTableNamespace + ":table"
+
+	// stringRefsMember is the member of resource objects holding the references to the table.
+	stringRefsMember = StringTableNamespace + ":refs"
+)
+
+// MarshalStringTable is an experimental option deduplicating attribute string values repeated at
+// least minCount times across the resource objects of a document, which cuts the size of large
+// collections with enum-like attributes, e.g. for analytics endpoints.
+//
+// The document applies the string table extension, identified by StringTableExtensionURI, which is
+// declared by the ext member of the top-level jsonapi object and by the ext media type parameter of
+// the Content-Type header set by Write. The repeated values are emitted once in the top-level
+// strtab:table member. Attributes with such a value are removed from the resource object's
+// attributes, and referenced by index in the resource object's strtab:refs member instead:
+//
+//	{
+//	  "data": [
+//	    {"type": "events", "id": "1", "strtab:refs": {"status": 0}},
+//	    {"type": "events", "id": "2", "strtab:refs": {"status": 0}}
+//	  ],
+//	  "jsonapi": {"version": "1.1", "ext": ["https://pkg.go.dev/github.com/DataDog/jsonapi#MarshalStringTable"]},
+//	  "strtab:table": ["published"]
+//	}
+//
+// Clients must expand the references before decoding the document, so this option should only be
+// used with clients which negotiated the extension. Unmarshal rejects documents with string table
+// members, unless the namespace is registered via UnmarshalExtensions, in which case they are
+// ignored. A minCount below 2 is treated as 2.
+func MarshalStringTable(minCount int) MarshalOption {
+	return func(m *Marshaler) {
+		if minCount < 2 {
+			minCount = 2
+		}
+		m.stringTableMinCount = minCount
+	}
+}
+
+// extensionURIs returns the URIs of the extensions applied to documents by m.
+func (m *Marshaler) extensionURIs() []string {
+	if m.stringTableMinCount > 0 {
+		return []string{StringTableExtensionURI}
+	}
+	return nil
+}
+
+// tableString returns the value of the given attribute as a string if it can be deduplicated by a
+// string table, i.e. if it is a string which isn't encoded by a custom method.
+func tableString(v any) (string, bool) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() != reflect.String {
+		return "", false
+	}
+	if implements(rv.Type(), jsonMarshalerType) || implements(rv.Type(), textMarshalerType) {
+		return "", false
+	}
+	return rv.String(), true
+}
+
+// applyStringTable replaces the attribute string values of the resource objects of d repeated at
+// least minCount times by references to a string table, as described by MarshalStringTable.
+func applyStringTable(d *document, minCount int) {
+	ros := make([]*resourceObject, 0, len(d.DataMany)+len(d.Included)+1)
+	if d.DataOne != nil {
+		ros = append(ros, d.DataOne)
+	}
+	ros = append(ros, d.DataMany...)
+	ros = append(ros, d.Included...)
+
+	counts := make(map[string]int)
+	for _, ro := range ros {
+		for _, v := range ro.Attributes {
+			if s, ok := tableString(v); ok {
+				counts[s]++
+			}
+		}
+	}
+
+	// the table is ordered by first occurrence, so that it is deterministic
+	table := make([]string, 0)
+	index := make(map[string]int)
+	for _, ro := range ros {
+		refs := make(map[string]any)
+		for _, name := range sortedMembers(ro.Attributes) {
+			s, ok := tableString(ro.Attributes[name])
+			if !ok || counts[s] < minCount {
+				continue
+			}
+			i, ok := index[s]
+			if !ok {
+				i = len(table)
+				index[s] = i
+				table = append(table, s)
+			}
+			refs[name] = i
+			delete(ro.Attributes, name)
+		}
+		if len(refs) == 0 {
+			continue
+		}
+
+		if ro.extensions == nil {
+			ro.extensions = make(map[string]any, 1)
+		}
+		ro.extensions[stringRefsMember] = refs
+	}
+
+	if len(table) == 0 {
+		return
+	}
+
+	if d.extensions == nil {
+		d.extensions = make(map[string]any, 1)
+	}
+	d.extensions[stringTableMember] = table
+}
@@ -0,0 +1,87 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalStringTable(t *testing.T) {
+	t.Parallel()
+
+	articles := []*Article{{ID: "1", Title: "A"}, {ID: "2", Title: "A"}, {ID: "3", Title: "B"}}
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+	}{
+		{
+			description: "repeated values",
+			given:       articles,
+			opts:        []MarshalOption{MarshalStringTable(2)},
+			expect:      `{"data":[{"type":"articles","id":"1","strtab:refs":{"title":0}},{"type":"articles","id":"2","strtab:refs":{"title":0}},{"type":"articles","id":"3","attributes":{"title":"B"}}],"jsonapi":{"version":"1.1","ext":["https://pkg.go.dev/github.com/DataDog/jsonapi#MarshalStringTable"]},"strtab:table":["A"]}`,
+		}, {
+			description: "values below minCount",
+			given:       articles,
+			opts:        []MarshalOption{MarshalStringTable(3)},
+			expect:      `{"data":[{"type":"articles","id":"1","attributes":{"title":"A"}},{"type":"articles","id":"2","attributes":{"title":"A"}},{"type":"articles","id":"3","attributes":{"title":"B"}}],"jsonapi":{"version":"1.1","ext":["https://pkg.go.dev/github.com/DataDog/jsonapi#MarshalStringTable"]}}`,
+		}, {
+			description: "existing jsonapi object",
+			given:       articles[2:],
+			opts:        []MarshalOption{MarshalStringTable(2), MarshalJSONAPI(map[string]any{"a": 1}), MarshalExtensions("version")},
+			expect:      `{"data":[{"type":"articles","id":"3","attributes":{"title":"B"}}],"jsonapi":{"version":"1.1","ext":["https://pkg.go.dev/github.com/DataDog/jsonapi#MarshalStringTable"],"meta":{"a":1}}}`,
+		}, {
+			description: "minCount below 2",
+			given:       articles,
+			opts:        []MarshalOption{MarshalStringTable(0)},
+			expect:      `{"data":[{"type":"articles","id":"1","strtab:refs":{"title":0}},{"type":"articles","id":"2","strtab:refs":{"title":0}},{"type":"articles","id":"3","attributes":{"title":"B"}}],"jsonapi":{"version":"1.1","ext":["https://pkg.go.dev/github.com/DataDog/jsonapi#MarshalStringTable"]},"strtab:table":["A"]}`,
+		}, {
+			description: "existing meta",
+			given:       []*ArticleWithMeta{{ID: "1", Title: "A", Meta: &ArticleMetrics{Views: 10, Reads: 4}}, {ID: "2", Title: "A"}},
+			opts:        []MarshalOption{MarshalStringTable(2), MarshalMeta(map[string]any{"count": 2})},
+			expect:      `{"data":[{"type":"articles","id":"1","meta":{"views":10,"reads":4},"strtab:refs":{"title":0}},{"type":"articles","id":"2","strtab:refs":{"title":0}}],"meta":{"count":2},"jsonapi":{"version":"1.1","ext":["https://pkg.go.dev/github.com/DataDog/jsonapi#MarshalStringTable"]},"strtab:table":["A"]}`,
+		}, {
+			description: "included resources",
+			given:       &ArticleRelated{ID: "1", Title: "A", Author: &Author{ID: "1", Name: "A"}},
+			opts:        []MarshalOption{MarshalStringTable(2), MarshalInclude(&Author{ID: "1", Name: "A"})},
+			expect:      `{"data":{"type":"articles","id":"1","strtab:refs":{"title":0},"relationships":{"author":{"data":{"type":"author","id":"1"},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}}}},"included":[{"type":"author","id":"1","strtab:refs":{"name":0}}],"jsonapi":{"version":"1.1","ext":["https://pkg.go.dev/github.com/DataDog/jsonapi#MarshalStringTable"]},"strtab:table":["A"]}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestStringTableNegotiation(t *testing.T) {
+	t.Parallel()
+
+	articles := []*Article{{ID: "1", Title: "A"}, {ID: "2", Title: "A"}}
+
+	rec := httptest.NewRecorder()
+	is.MustNoError(t, Write(rec, http.StatusOK, articles, MarshalStringTable(2)))
+	is.Equal(t, `application/vnd.api+json; ext="https://pkg.go.dev/github.com/DataDog/jsonapi#MarshalStringTable"`, rec.Header().Get("Content-Type"))
+
+	// documents applying the extension are rejected unless it is negotiated
+	var actual []*Article
+	err := Unmarshal(rec.Body.Bytes(), &actual)
+	var mnErr *MemberNameValidationError
+	is.Equal(t, true, errors.As(err, &mnErr))
+
+	is.MustNoError(t, Unmarshal(rec.Body.Bytes(), &actual, UnmarshalExtensions(StringTableNamespace)))
+	is.Equal(t, []*Article{{ID: "1"}, {ID: "2"}}, actual)
+}
@@ -3,6 +3,7 @@ package jsonapi
 import (
 	"reflect"
 	"strings"
+	"sync"
 )
 
 type directive int
@@ -12,6 +13,8 @@ const (
 	attribute
 	meta
 	relationship
+	extras
+	extension
 	invalid
 )
 
@@ -25,6 +28,10 @@ func parseDirective(v string) (directive, bool) {
 		return meta, true
 	case "relationship", "rel":
 		return relationship, true
+	case "extras":
+		return extras, true
+	case "extension", "ext":
+		return extension, true
 	}
 	return invalid, false
 }
@@ -33,6 +40,16 @@ type tag struct {
 	directive    directive
 	resourceType string // only valid for primary
 	omitEmpty    bool
+	readOnly     bool           // only valid for attribute
+	writeOnly    bool           // only valid for attribute
+	timeFormat   TimeFormat     // only valid for time attributes
+	timeUTC      bool           // only valid for time attributes
+	byteEncoding byteEncoding   // only valid for byte slice and array attributes
+	int64String  bool           // only valid for int64 and uint64 attributes
+	flatten      bool           // only valid for struct attributes
+	relatedType  string         // only valid for relationships holding resource ids
+	zero         ZeroAttributes // only valid for attribute
+	null         bool           // only valid for pointer attributes
 }
 
 func parseJSONTag(f reflect.StructField) (string, bool, bool) {
@@ -53,7 +70,36 @@ func parseJSONTag(f reflect.StructField) (string, bool, bool) {
 	return ts[0], f.IsExported(), omit
 }
 
+// tagKey identifies a struct field by everything parseJSONAPITag depends on.
+type tagKey struct {
+	name string
+	typ  reflect.Type
+	tag  reflect.StructTag
+}
+
+// parsedTag is the result of parsing the jsonapi tag of a struct field.
+type parsedTag struct {
+	tag *tag
+	err error
+}
+
+// tagCache maps tagKey to the *parsedTag of every struct field parsed so far, so that struct tags
+// are only parsed once per field. The cached tags must not be modified.
+var tagCache sync.Map
+
+// parseJSONAPITag returns the parsed jsonapi tag of the given struct field, or nil if it has none.
 func parseJSONAPITag(f reflect.StructField) (*tag, error) {
+	key := tagKey{name: f.Name, typ: f.Type, tag: f.Tag}
+	if pt, ok := tagCache.Load(key); ok {
+		return pt.(*parsedTag).tag, pt.(*parsedTag).err
+	}
+
+	t, err := parseJSONAPITagUncached(f)
+	tagCache.Store(key, &parsedTag{tag: t, err: err})
+	return t, err
+}
+
+func parseJSONAPITagUncached(f reflect.StructField) (*tag, error) {
 	t := f.Tag.Get("jsonapi")
 	ts := strings.Split(t, ",")
 
@@ -72,6 +118,10 @@ func parseJSONAPITag(f reflect.StructField) (*tag, error) {
 			return nil, nil
 		}
 	default:
+		// attributes may have several options, e.g. readonly and a time format
+		if d, _ := parseDirective(ts[0]); d == attribute {
+			break
+		}
 		return nil, &TagError{
 			TagName: "jsonapi",
 			Field:   f.Name,
@@ -84,6 +134,14 @@ func parseJSONAPITag(f reflect.StructField) (*tag, error) {
 		return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "invalid directive"}
 	}
 
+	if d == extras && (f.Type.Kind() != reflect.Map || f.Type.Key().Kind() != reflect.String) {
+		return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "extras field must be a map with string keys"}
+	}
+
+	if name, _, _ := parseJSONTag(f); d == extension && !strings.Contains(name, ":") {
+		return nil, &TagError{TagName: "json", Field: f.Name, Reason: "extension fields must be named namespace:member"}
+	}
+
 	tag := &tag{directive: d, omitEmpty: omitEmpty}
 	if d == primary {
 		if len(ts) < 2 {
@@ -94,7 +152,148 @@ func parseJSONAPITag(f reflect.StructField) (*tag, error) {
 			}
 		}
 		tag.resourceType = ts[1]
+		return tag, nil
+	}
+
+	for _, option := range ts[1:] {
+		switch option {
+		case "readonly":
+			tag.readOnly = true
+		case "writeonly":
+			tag.writeOnly = true
+		case utcOption:
+			tag.timeUTC = true
+		case stringOption:
+			tag.int64String = true
+		case flattenOption:
+			tag.flatten = true
+		case omitZeroOption, keepZeroOption:
+			z := ZeroAttributesOmit
+			if option == keepZeroOption {
+				z = ZeroAttributesKeep
+			}
+			if tag.zero != ZeroAttributesDefault && tag.zero != z {
+				return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "omitzero and keepzero are mutually exclusive"}
+			}
+			tag.zero = z
+		case nullOption:
+			tag.null = true
+		default:
+			if strings.HasPrefix(option, relatedTypeOptionPrefix) {
+				tag.relatedType = strings.TrimPrefix(option, relatedTypeOptionPrefix)
+			}
+			if e, ok := parseByteEncoding(option); ok {
+				if tag.byteEncoding != "" {
+					return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "base64 encodings are mutually exclusive"}
+				}
+				tag.byteEncoding = e
+			}
+			if format, ok := parseTimeFormat(option); ok {
+				if tag.timeFormat != "" {
+					return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "time formats are mutually exclusive"}
+				}
+				tag.timeFormat = format
+			}
+		}
+	}
+	switch {
+	case (tag.readOnly || tag.writeOnly) && d != attribute:
+		return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "readonly and writeonly are only valid in attribute directives"}
+	case tag.zero != ZeroAttributesDefault && d != attribute:
+		return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "omitzero and keepzero are only valid in attribute directives"}
+	case tag.null && (d != attribute || f.Type.Kind() != reflect.Pointer):
+		return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "null is only valid in attribute directives of pointer fields"}
+	case tag.readOnly && tag.writeOnly:
+		return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "readonly and writeonly are mutually exclusive"}
+	case (tag.timeFormat != "" || tag.timeUTC) && (d != attribute || !isTimeType(f.Type)):
+		return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "time formats are only valid in attribute directives of time.Time fields"}
+	case tag.byteEncoding != "" && (d != attribute || !isByteType(f.Type)):
+		return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "base64 encodings are only valid in attribute directives of byte slice or array fields"}
+	case tag.int64String && (d != attribute || !isInt64Type(f.Type)):
+		return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "string is only valid in attribute directives of int64 or uint64 fields"}
+	case tag.flatten && (d != attribute || !isStructType(f.Type) || isTimeType(f.Type)):
+		return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "flatten is only valid in attribute directives of struct fields"}
+	case tag.relatedType != "" && d != relationship:
+		return nil, &TagError{TagName: "jsonapi", Field: f.Name, Reason: "type is only valid in relationship directives"}
 	}
 
 	return tag, nil
 }
+
+// resourceTypeOf returns the resource type given by the primary field of the struct type t.
+func resourceTypeOf(t reflect.Type) (string, error) {
+	t = derefType(t)
+	if t.Kind() != reflect.Struct {
+		return "", &TypeError{Actual: t.String(), Expected: []string{"struct"}}
+	}
+
+	for _, field := range getFlattenedFields(reflect.New(t).Interface()) {
+		tag, err := parseJSONAPITag(field.f)
+		if err != nil {
+			return "", err
+		}
+		if tag != nil && tag.directive == primary {
+			return tag.resourceType, nil
+		}
+	}
+
+	return "", ErrMissingPrimaryField
+}
+
+// readOnlyAttributes returns the member names of the read-only attributes of the struct type t,
+// including those of embedded structs.
+func readOnlyAttributes(t reflect.Type) []string {
+	t = derefType(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isFlattened(f) {
+			names = append(names, readOnlyAttributes(f.Type)...)
+			continue
+		}
+		tag, err := parseJSONAPITag(f)
+		if err != nil || tag == nil || !tag.readOnly {
+			continue
+		}
+		if name, ok, _ := parseJSONTag(f); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// relatedTypeOptionPrefix prefixes the option of the relationship directive giving the related
+// resource type of a relationship field holding resource ids, e.g. `jsonapi:"rel,type=comments"`.
+const relatedTypeOptionPrefix = "type="
+
+// parseRelTypeTag returns the related resource type given by the reltype tag, or the type option of
+// the relationship directive, of a relationship field holding resource ids only, which must be of
+// type string (to-one) or []string (to-many).
+func parseRelTypeTag(f reflect.StructField) (string, bool, error) {
+	tagName, relatedType := "reltype", f.Tag.Get("reltype")
+	if relatedType == "" {
+		tag, err := parseJSONAPITag(f)
+		if err != nil || tag == nil || tag.relatedType == "" {
+			return "", false, err
+		}
+		tagName, relatedType = "jsonapi", tag.relatedType
+	}
+
+	t := relatedFieldType(f.Type)
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String {
+		return "", false, &TagError{
+			TagName: tagName,
+			Field:   f.Name,
+			Reason:  "only valid on relationship fields of type string or []string",
+		}
+	}
+
+	return relatedType, true, nil
+}
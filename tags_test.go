@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/DataDog/jsonapi/internal/is"
 )
@@ -108,6 +109,215 @@ func TestParseJSONAPITag(t *testing.T) {
 				Foo string `jsonapi:"primary,foo,omitempty"`
 			}{},
 			expect: &tag{directive: primary, resourceType: "foo", omitEmpty: true},
+		}, {
+			description: "valid jsonapi, attribute, readonly",
+			given: struct {
+				Foo string `jsonapi:"attribute,readonly"`
+			}{},
+			expect: &tag{directive: attribute, readOnly: true},
+		}, {
+			description: "valid jsonapi, attribute, writeonly, omitempty",
+			given: struct {
+				Foo string `jsonapi:"attribute,writeonly,omitempty"`
+			}{},
+			expect: &tag{directive: attribute, writeOnly: true, omitEmpty: true},
+		}, {
+			description: "invalid jsonapi tag (readonly relationship)",
+			given: struct {
+				Foo string `jsonapi:"relationship,readonly"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "readonly and writeonly are only valid in attribute directives",
+			},
+		}, {
+			description: "invalid jsonapi tag (readonly and writeonly)",
+			given: struct {
+				Foo string `jsonapi:"attribute,readonly,writeonly"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "readonly and writeonly are mutually exclusive",
+			},
+		}, {
+			description: "valid jsonapi, attribute, readonly, time format, utc",
+			given: struct {
+				Foo time.Time `jsonapi:"attribute,readonly,unix,utc"`
+			}{},
+			expect: &tag{directive: attribute, readOnly: true, timeFormat: TimeUnix, timeUTC: true},
+		}, {
+			description: "invalid jsonapi tag (time format of non-time field)",
+			given: struct {
+				Foo string `jsonapi:"attribute,date"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "time formats are only valid in attribute directives of time.Time fields",
+			},
+		}, {
+			description: "invalid jsonapi tag (several time formats)",
+			given: struct {
+				Foo *time.Time `jsonapi:"attribute,unix,date"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "time formats are mutually exclusive",
+			},
+		}, {
+			description: "valid jsonapi, attribute, keepzero",
+			given: struct {
+				Foo string `jsonapi:"attribute,keepzero"`
+			}{},
+			expect: &tag{directive: attribute, zero: ZeroAttributesKeep},
+		}, {
+			description: "valid jsonapi, attribute, omitzero, null",
+			given: struct {
+				Foo *string `jsonapi:"attribute,omitzero,null"`
+			}{},
+			expect: &tag{directive: attribute, zero: ZeroAttributesOmit, null: true},
+		}, {
+			description: "invalid jsonapi tag (null non-pointer field)",
+			given: struct {
+				Foo string `jsonapi:"attribute,null"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "null is only valid in attribute directives of pointer fields",
+			},
+		}, {
+			description: "invalid jsonapi tag (omitzero and keepzero)",
+			given: struct {
+				Foo string `jsonapi:"attribute,omitzero,keepzero"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "omitzero and keepzero are mutually exclusive",
+			},
+		}, {
+			description: "invalid jsonapi tag (omitzero relationship)",
+			given: struct {
+				Foo string `jsonapi:"relationship,omitzero"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "omitzero and keepzero are only valid in attribute directives",
+			},
+		}, {
+			description: "valid jsonapi, attribute, base64url",
+			given: struct {
+				Foo [8]byte `jsonapi:"attribute,base64url"`
+			}{},
+			expect: &tag{directive: attribute, byteEncoding: base64URL},
+		}, {
+			description: "valid jsonapi, attribute, string",
+			given: struct {
+				Foo *uint64 `jsonapi:"attribute,readonly,string"`
+			}{},
+			expect: &tag{directive: attribute, readOnly: true, int64String: true},
+		}, {
+			description: "invalid jsonapi tag (string of non-int64 field)",
+			given: struct {
+				Foo int32 `jsonapi:"attribute,string"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "string is only valid in attribute directives of int64 or uint64 fields",
+			},
+		}, {
+			description: "valid jsonapi, attribute, flatten",
+			given: struct {
+				Foo *Metadata `jsonapi:"attr,,flatten"`
+			}{},
+			expect: &tag{directive: attribute, flatten: true},
+		}, {
+			description: "invalid jsonapi tag (flatten of non-struct field)",
+			given: struct {
+				Foo map[string]string `jsonapi:"attribute,,flatten"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "flatten is only valid in attribute directives of struct fields",
+			},
+		}, {
+			description: "valid jsonapi, relationship, type",
+			given: struct {
+				Foo []string `jsonapi:"rel,type=comments"`
+			}{},
+			expect: &tag{directive: relationship, relatedType: "comments"},
+		}, {
+			description: "invalid jsonapi tag (type of attribute)",
+			given: struct {
+				Foo string `jsonapi:"attribute,type=comments"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "type is only valid in relationship directives",
+			},
+		}, {
+			description: "invalid jsonapi tag (base64 encoding of non-byte field)",
+			given: struct {
+				Foo []int `jsonapi:"attribute,base64"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "base64 encodings are only valid in attribute directives of byte slice or array fields",
+			},
+		}, {
+			description: "valid jsonapi, extras",
+			given: struct {
+				Foo map[string]any `jsonapi:"extras"`
+			}{},
+			expect: &tag{directive: extras},
+		}, {
+			description: "invalid jsonapi tag (extras not a map)",
+			given: struct {
+				Foo string `jsonapi:"extras"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "jsonapi",
+				Field:   "Foo",
+				Reason:  "extras field must be a map with string keys",
+			},
+		}, {
+			description: "valid jsonapi, extension",
+			given: struct {
+				Foo string `jsonapi:"ext" json:"version:id"`
+			}{},
+			expect: &tag{directive: extension},
+		}, {
+			description: "invalid jsonapi tag (extension without namespace)",
+			given: struct {
+				Foo string `jsonapi:"extension" json:"id"`
+			}{},
+			expect: nil,
+			expectError: &TagError{
+				TagName: "json",
+				Field:   "Foo",
+				Reason:  "extension fields must be named namespace:member",
+			},
 		}, {
 			description: "no struct tags",
 			given:       struct{ Foo string }{},
@@ -0,0 +1,157 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// TimeFormat is the format of time.Time (and *time.Time) attributes. It is given by an option of the
+// attribute directive named like the format, e.g. `jsonapi:"attribute,unix"`, or for all time
+// attributes without one by MarshalTimeFormat and UnmarshalTimeFormat.
+//
+// Time attributes without a format are encoded by encoding/json, i.e. as RFC 3339 strings with
+// fractional seconds if any.
+type TimeFormat string
+
+const (
+	// TimeRFC3339 encodes times as RFC 3339 strings without fractional seconds, e.g.
+	// "2006-01-02T15:04:05Z".
+	TimeRFC3339 TimeFormat = "rfc3339"
+
+	// TimeRFC3339Nano encodes times as RFC 3339 strings with fractional seconds if any, e.g.
+	// "2006-01-02T15:04:05.999999999Z".
+	TimeRFC3339Nano TimeFormat = "rfc3339nano"
+
+	// TimeUnix encodes times as the number of seconds elapsed since January 1, 1970 UTC.
+	TimeUnix TimeFormat = "unix"
+
+	// TimeUnixMilli encodes times as the number of milliseconds elapsed since January 1, 1970 UTC.
+	TimeUnixMilli TimeFormat = "unixmilli"
+
+	// TimeDate encodes times as RFC 3339 full-date strings, e.g. "2006-01-02".
+	TimeDate TimeFormat = "date"
+)
+
+// utcOption is the option of the attribute directive normalizing times to UTC.
+const utcOption = "utc"
+
+// dateLayout is the layout of TimeDate, i.e. time.DateOnly.
+const dateLayout = "2006-01-02"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// parseTimeFormat returns the TimeFormat named by the option of an attribute directive.
+func parseTimeFormat(option string) (TimeFormat, bool) {
+	switch f := TimeFormat(option); f {
+	case TimeRFC3339, TimeRFC3339Nano, TimeUnix, TimeUnixMilli, TimeDate:
+		return f, true
+	}
+	return "", false
+}
+
+// MarshalTimeFormat encodes time attributes without a format option in the given format.
+func MarshalTimeFormat(f TimeFormat) MarshalOption {
+	return func(m *Marshaler) {
+		m.timeFormat = f
+	}
+}
+
+// MarshalTimeUTC converts all time attributes to UTC before encoding them, as done for attributes
+// with the utc option, e.g. `jsonapi:"attribute,rfc3339,utc"`.
+func MarshalTimeUTC() MarshalOption {
+	return func(m *Marshaler) {
+		m.timeUTC = true
+	}
+}
+
+// UnmarshalTimeFormat decodes time attributes without a format option from the given format.
+func UnmarshalTimeFormat(f TimeFormat) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.timeFormat = f
+	}
+}
+
+// UnmarshalTimeUTC converts all time attributes to UTC after decoding them, as done for attributes
+// with the utc option.
+func UnmarshalTimeUTC() UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.timeUTC = true
+	}
+}
+
+// isTimeType returns true if t is time.Time or *time.Time.
+func isTimeType(t reflect.Type) bool {
+	return t == timeType || t.Kind() == reflect.Pointer && t.Elem() == timeType
+}
+
+// attributeTimeFormat returns the format of the time attribute with the given tag, and whether it
+// is normalized to UTC, given the defaults f and utc.
+func attributeTimeFormat(tag *tag, f TimeFormat, utc bool) (TimeFormat, bool) {
+	if tag.timeFormat != "" {
+		f = tag.timeFormat
+	}
+	return f, utc || tag.timeUTC
+}
+
+// formatTime returns the value of the time attribute fv, a time.Time or *time.Time, encoded in the
+// given format.
+func formatTime(fv reflect.Value, f TimeFormat, utc bool) (any, error) {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil, nil
+		}
+		fv = fv.Elem()
+	}
+	t := fv.Interface().(time.Time)
+	if utc {
+		t = t.UTC()
+	}
+
+	switch f {
+	case "":
+		return t, nil
+	case TimeRFC3339Nano:
+		return t.Format(time.RFC3339Nano), nil
+	case TimeRFC3339:
+		return t.Format(time.RFC3339), nil
+	case TimeUnix:
+		return t.Unix(), nil
+	case TimeUnixMilli:
+		return t.UnixMilli(), nil
+	case TimeDate:
+		return t.Format(dateLayout), nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrInvalidTimeFormat, f)
+}
+
+// parseTime decodes the json encoded time value data in the given format. Times given as Unix time
+// or dates are in UTC.
+func parseTime(data []byte, f TimeFormat, utc bool) (t time.Time, err error) {
+	switch f {
+	case "", TimeRFC3339, TimeRFC3339Nano:
+		err = json.Unmarshal(data, &t)
+	case TimeUnix, TimeUnixMilli:
+		var n int64
+		if err = json.Unmarshal(data, &n); err != nil {
+			break
+		}
+		if f == TimeUnix {
+			t = time.Unix(n, 0).UTC()
+		} else {
+			t = time.UnixMilli(n).UTC()
+		}
+	case TimeDate:
+		var s string
+		if err = json.Unmarshal(data, &s); err == nil {
+			t, err = time.Parse(dateLayout, s)
+		}
+	default:
+		err = fmt.Errorf("%w %q", ErrInvalidTimeFormat, f)
+	}
+	if utc {
+		t = t.UTC()
+	}
+	return t, err
+}
@@ -0,0 +1,153 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// ArticleTimes has time attributes in various formats.
+type ArticleTimes struct {
+	ID        string     `jsonapi:"primary,articles"`
+	Created   time.Time  `jsonapi:"attribute" json:"created"`
+	Updated   time.Time  `jsonapi:"attribute,rfc3339,utc" json:"updated"`
+	Published *time.Time `jsonapi:"attribute,unix" json:"published"`
+	Expires   time.Time  `jsonapi:"attribute,unixmilli" json:"expires"`
+	Day       time.Time  `jsonapi:"attribute,date" json:"day"`
+}
+
+func TestMarshalTimeFormat(t *testing.T) {
+	t.Parallel()
+
+	zone := time.FixedZone("CEST", 2*60*60)
+	ts := time.Date(2023, 6, 1, 14, 30, 15, 500000000, zone)
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+		expectError error
+	}{
+		{
+			description: "formats",
+			given:       &ArticleTimes{ID: "1", Created: ts, Updated: ts, Published: &ts, Expires: ts, Day: ts},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"created":"2023-06-01T14:30:15.5+02:00","updated":"2023-06-01T12:30:15Z","published":1685622615,"expires":1685622615500,"day":"2023-06-01"}}}`,
+		}, {
+			description: "null",
+			given:       &ArticleTimes{ID: "1", Created: ts, Updated: ts, Expires: ts, Day: ts},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"created":"2023-06-01T14:30:15.5+02:00","updated":"2023-06-01T12:30:15Z","published":null,"expires":1685622615500,"day":"2023-06-01"}}}`,
+		}, {
+			description: "times nested in attributes",
+			given:       &ArticleComplete{ID: "1", Title: "A", Info: &ArticleInfo{PublishDate: ts}},
+			opts:        []MarshalOption{MarshalTimeFormat(TimeUnix), MarshalTimeUTC()},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"A","info":{"publishDate":"2023-06-01T14:30:15.5+02:00","tags":null,"isPublic":false,"metrics":null}}}}`,
+		}, {
+			description: "global format of attributes without format option",
+			given:       &ArticleTimes{ID: "1", Created: ts, Updated: ts, Expires: ts, Day: ts},
+			opts:        []MarshalOption{MarshalTimeFormat(TimeRFC3339Nano), MarshalTimeUTC()},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"created":"2023-06-01T12:30:15.5Z","updated":"2023-06-01T12:30:15Z","published":null,"expires":1685622615500,"day":"2023-06-01"}}}`,
+		}, {
+			description: "invalid global format",
+			given:       &ArticleTimes{ID: "1"},
+			opts:        []MarshalOption{MarshalTimeFormat("iso")},
+			expectError: ErrInvalidTimeFormat,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given, tc.opts...)
+			if tc.expectError != nil {
+				is.Equal(t, true, errors.Is(err, tc.expectError))
+				return
+			}
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestUnmarshalTimeFormat(t *testing.T) {
+	t.Parallel()
+
+	utc := time.Date(2023, 6, 1, 12, 30, 15, 0, time.UTC)
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		description string
+		given       string
+		opts        []UnmarshalOption
+		expect      *ArticleTimes
+		expectError error
+	}{
+		{
+			description: "formats",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"created":"2023-06-01T12:30:15Z","updated":"2023-06-01T14:30:15+02:00","published":1685622615,"expires":1685622615000,"day":"2023-06-01"}}}`,
+			expect:      &ArticleTimes{ID: "1", Created: utc, Updated: utc, Published: &utc, Expires: utc, Day: day},
+		}, {
+			description: "null",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"published":null}}}`,
+			expect:      &ArticleTimes{ID: "1"},
+		}, {
+			description: "global format of attributes without format option",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"created":1685622615}}}`,
+			opts:        []UnmarshalOption{UnmarshalTimeFormat(TimeUnix)},
+			expect:      &ArticleTimes{ID: "1", Created: utc},
+		}, {
+			description: "utc",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"created":"2023-06-01T14:30:15+02:00"}}}`,
+			opts:        []UnmarshalOption{UnmarshalTimeUTC()},
+			expect:      &ArticleTimes{ID: "1", Created: utc},
+		}, {
+			description: "invalid unix time",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"published":"2023-06-01T12:30:15Z"}}}`,
+			expectError: &FieldError{Code: CodeInvalidAttribute, Member: "published", Pointer: "/data/attributes/published"},
+		}, {
+			description: "invalid date",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"day":"2023-06-01T12:30:15Z"}}}`,
+			expectError: &FieldError{Code: CodeInvalidAttribute, Member: "day", Pointer: "/data/attributes/day"},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var actual ArticleTimes
+			err := Unmarshal([]byte(tc.given), &actual, tc.opts...)
+			if tc.expectError != nil {
+				var fe *FieldError
+				is.MustEqual(t, true, errors.As(err, &fe))
+				is.Equal(t, tc.expectError.(*FieldError).Member, fe.Member)
+				is.Equal(t, tc.expectError.(*FieldError).Pointer, fe.Pointer)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &actual)
+		})
+	}
+}
+
+func TestTimeFormatRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2023, 6, 1, 12, 30, 15, 0, time.UTC)
+	given := &ArticleTimes{ID: "1", Created: ts, Updated: ts, Published: &ts, Expires: ts, Day: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)}
+
+	b, err := Marshal(given)
+	is.MustNoError(t, err)
+
+	var actual ArticleTimes
+	is.MustNoError(t, Unmarshal(b, &actual))
+	is.Equal(t, given, &actual)
+}
@@ -0,0 +1,105 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// TimestampsProfileURI is the URI of the timestamps profile implemented by TimestampsProfile.
+const TimestampsProfileURI = "https://pkg.go.dev/github.com/DataDog/jsonapi#TimestampsProfile"
+
+// timestampFields maps the names of the struct fields surfaced by TimestampsProfile to the names of
+// their members.
+var timestampFields = []struct{ field, member string }{
+	{"CreatedAt", "createdAt"},
+	{"UpdatedAt", "updatedAt"},
+}
+
+// TimestampsProfile returns a handler of the timestamps profile, which surfaces the CreatedAt and
+// UpdatedAt fields of resources as the createdAt and updatedAt members of the meta, or attributes,
+// of their resource objects, so that audit timestamps are handled uniformly across resources.
+//
+// The profile is identified by TimestampsProfileURI and defines that:
+//   - createdAt is the time the resource was created, and updatedAt the time it was last updated
+//   - both are strings in RFC 3339 format, and are omitted if unknown
+//
+// Fields named CreatedAt or UpdatedAt of type time.Time or *time.Time are surfaced, including those
+// of embedded structs, unless they have a jsonapi tag. Zero and nil times are omitted. When
+// unmarshaling, the fields are set from the members present, and other fields are left untouched.
+func TimestampsProfile(members ProfileMembers) *ProfileHandler {
+	return &ProfileHandler{
+		URI:               TimestampsProfileURI,
+		Members:           members,
+		MarshalResource:   marshalTimestamps,
+		UnmarshalResource: unmarshalTimestamps,
+	}
+}
+
+// profileField returns the exported field of the resource v with the given name and without jsonapi
+// tag, if v is a struct or pointer to one having such a field, including those of embedded structs.
+func profileField(v any, name string) (reflect.Value, bool) {
+	rv := derefValue(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	for _, field := range flattenFields(rv) {
+		if field.f.Name != name || !field.f.IsExported() {
+			continue
+		}
+		if _, ok := field.f.Tag.Lookup("jsonapi"); ok {
+			continue
+		}
+		return field.v, true
+	}
+	return reflect.Value{}, false
+}
+
+// timestampField returns the CreatedAt or UpdatedAt field of the resource v with the given name.
+func timestampField(v any, name string) (reflect.Value, bool) {
+	fv, ok := profileField(v, name)
+	return fv, ok && isTimeType(fv.Type())
+}
+
+func marshalTimestamps(_ context.Context, v any) (map[string]any, error) {
+	var members map[string]any
+	for _, ts := range timestampFields {
+		fv, ok := timestampField(v, ts.field)
+		if !ok || fv.Kind() == reflect.Pointer && fv.IsNil() {
+			continue
+		}
+		t := derefValue(fv).Interface().(time.Time)
+		if t.IsZero() {
+			continue
+		}
+		if members == nil {
+			members = make(map[string]any, len(timestampFields))
+		}
+		members[ts.member] = t.Format(time.RFC3339Nano)
+	}
+	return members, nil
+}
+
+func unmarshalTimestamps(_ context.Context, v any, members map[string]any) error {
+	for _, ts := range timestampFields {
+		value, present := members[ts.member]
+		if !present || value == nil {
+			continue
+		}
+		fv, ok := timestampField(v, ts.field)
+		if !ok || !fv.CanSet() {
+			continue
+		}
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string, got %T", ts.member, value)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ts.member, err)
+		}
+		setFieldValue(fv, &t)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// Audit holds audit timestamps, embedded by ArticleAudited.
+type Audit struct {
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+// ArticleAudited is an article with timestamps surfaced by TimestampsProfile.
+type ArticleAudited struct {
+	ID    string `jsonapi:"primary,articles"`
+	Title string `jsonapi:"attribute" json:"title"`
+	Audit
+}
+
+// ArticleAuditedTagged is an article with a CreatedAt attribute, which TimestampsProfile leaves
+// alone.
+type ArticleAuditedTagged struct {
+	ID        string    `jsonapi:"primary,articles"`
+	CreatedAt time.Time `jsonapi:"attribute" json:"created"`
+}
+
+func TestTimestampsProfile(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(1989, 6, 15, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(1989, 6, 16, 12, 30, 0, 500, time.UTC)
+	jsonapiObject := `"jsonapi":{"version":"1.1","profile":["https://pkg.go.dev/github.com/DataDog/jsonapi#TimestampsProfile"]}`
+
+	tests := []struct {
+		description string
+		given       any
+		members     ProfileMembers
+		expect      string
+	}{
+		{
+			description: "meta",
+			given:       &ArticleAudited{ID: "1", Title: "A", Audit: Audit{CreatedAt: created, UpdatedAt: &updated}},
+			members:     ProfileMeta,
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"meta":{"createdAt":"1989-06-15T00:00:00Z","updatedAt":"1989-06-16T12:30:00.0000005Z"}},` + jsonapiObject + `}`,
+		}, {
+			description: "attributes",
+			given:       &ArticleAudited{ID: "1", Title: "A", Audit: Audit{CreatedAt: created, UpdatedAt: &updated}},
+			members:     ProfileAttributes,
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A","createdAt":"1989-06-15T00:00:00Z","updatedAt":"1989-06-16T12:30:00.0000005Z"}},` + jsonapiObject + `}`,
+		}, {
+			description: "zero times",
+			given:       &ArticleAudited{ID: "1", Title: "A"},
+			members:     ProfileMeta,
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},` + jsonapiObject + `}`,
+		}, {
+			description: "tagged field",
+			given:       &ArticleAuditedTagged{ID: "1", CreatedAt: created},
+			members:     ProfileMeta,
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"created":"1989-06-15T00:00:00Z"}},` + jsonapiObject + `}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			profile := TimestampsProfile(tc.members)
+			b, err := Marshal(tc.given, MarshalProfiles(profile))
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+
+			v := reflect.New(reflect.TypeOf(tc.given).Elem()).Interface()
+			err = Unmarshal(b, v, UnmarshalProfiles(profile))
+			is.MustNoError(t, err)
+			is.Equal(t, tc.given, v)
+		})
+	}
+}
+
+func TestTimestampsProfileInvalid(t *testing.T) {
+	t.Parallel()
+
+	var a ArticleAudited
+	err := Unmarshal([]byte(`{"data":{"id":"1","type":"articles","meta":{"createdAt":"yesterday"}}}`), &a, UnmarshalProfiles(TimestampsProfile(ProfileMeta)))
+	is.MustError(t, err)
+
+	err = Unmarshal([]byte(`{"data":{"id":"1","type":"articles","meta":{"createdAt":1}}}`), &a, UnmarshalProfiles(TimestampsProfile(ProfileMeta)))
+	is.EqualError(t, &ResourceError{Code: CodeInvalidResource, Type: "articles", ID: "1", Err: fmt.Errorf("createdAt must be a string, got json.Number")}, err)
+}
@@ -0,0 +1,131 @@
+package jsonapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// TraceContext is the trace context of a request as defined by https://www.w3.org/TR/trace-context/,
+// i.e. the values of its traceparent and tracestate headers. It can be propagated in the top-level
+// meta of documents, so that distributed traces can be correlated across JSON:API hops that don't
+// propagate headers, such as message queues or responses stored for later processing.
+type TraceContext struct {
+	// TraceParent identifies the request in a tracing system, e.g.
+	// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+	TraceParent string
+
+	// TraceState holds vendor-specific trace identification data, if any.
+	TraceState string
+}
+
+// TraceMetaKeys are the names of the top-level meta members holding a trace context.
+type TraceMetaKeys struct {
+	TraceParent string
+	TraceState  string
+}
+
+// DefaultTraceMetaKeys names the top-level meta members holding a trace context after the headers
+// defined by https://www.w3.org/TR/trace-context/.
+var DefaultTraceMetaKeys = TraceMetaKeys{TraceParent: "traceparent", TraceState: "tracestate"}
+
+// IsValid returns true if tc has a traceparent of version 00 as defined by
+// https://www.w3.org/TR/trace-context/#traceparent-header, or of a later version starting the same.
+func (tc TraceContext) IsValid() bool {
+	parts := strings.Split(tc.TraceParent, "-")
+	if len(parts) < 4 || parts[0] == "ff" || parts[0] == "00" && len(parts) != 4 {
+		return false
+	}
+	for i, n := range []int{2, 32, 16, 2} {
+		if len(parts[i]) != n || !isLowerHex(parts[i]) {
+			return false
+		}
+	}
+	return strings.Trim(parts[1], "0") != "" && strings.Trim(parts[2], "0") != ""
+}
+
+// isLowerHex returns true if s consists of lowercase hexadecimal digits only.
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// TraceContextFromHeader returns the trace context given by the traceparent and tracestate headers
+// of h, or the zero value if there is no valid traceparent header.
+func TraceContextFromHeader(h http.Header) TraceContext {
+	tc := TraceContext{
+		TraceParent: strings.TrimSpace(h.Get("traceparent")),
+		TraceState:  strings.TrimSpace(strings.Join(h.Values("tracestate"), ",")),
+	}
+	if !tc.IsValid() {
+		return TraceContext{}
+	}
+	return tc
+}
+
+// traceContextKey is the context key of the TraceContext given by ContextWithTraceContext.
+type traceContextKey struct{}
+
+// ContextWithTraceContext returns a copy of ctx carrying the trace context tc.
+func ContextWithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFromContext returns the trace context carried by ctx, if any.
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// PropagateTraceContext is a middleware storing the trace context given by the headers of requests
+// in their context, if valid, to be added to response documents by MarshalTraceContext.
+func PropagateTraceContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tc := TraceContextFromHeader(r.Header); tc.IsValid() {
+			r = r.WithContext(ContextWithTraceContext(r.Context(), tc))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// MarshalTraceContext adds the trace context carried by the context given by MarshalContext, if
+// any, to the top-level meta of the document as members named by keys, e.g. DefaultTraceMetaKeys.
+// The tracestate member is omitted if empty.
+func MarshalTraceContext(keys TraceMetaKeys) MarshalOption {
+	return MarshalMetaFunc(func(ctx context.Context) map[string]any {
+		tc, ok := TraceContextFromContext(ctx)
+		if !ok || !tc.IsValid() {
+			return nil
+		}
+		meta := map[string]any{keys.TraceParent: tc.TraceParent}
+		if tc.TraceState != "" {
+			meta[keys.TraceState] = tc.TraceState
+		}
+		return meta
+	})
+}
+
+// ExtractTraceContext returns the trace context held by the top-level meta members of the json:api
+// encoded data named by keys, e.g. DefaultTraceMetaKeys, such as added by MarshalTraceContext. The
+// zero value is returned if the document has no valid trace context.
+func ExtractTraceContext(data []byte, keys TraceMetaKeys) (TraceContext, error) {
+	raw, err := topLevelMeta(data)
+	if err != nil {
+		return TraceContext{}, err
+	}
+	meta, err := DecodeMeta[map[string]any](&DocumentInfo{Meta: raw})
+	if err != nil {
+		return TraceContext{}, err
+	}
+	parent, _ := meta[keys.TraceParent].(string)
+	state, _ := meta[keys.TraceState].(string)
+	tc := TraceContext{TraceParent: parent, TraceState: state}
+	if !tc.IsValid() {
+		return TraceContext{}, nil
+	}
+	return tc, nil
+}
@@ -0,0 +1,109 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestTraceContextIsValid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		given  string
+		expect bool
+	}{
+		{given: testTraceParent, expect: true},
+		{given: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", expect: true},
+		{given: "", expect: false},
+		{given: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", expect: false},
+		{given: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expect: false},
+		{given: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", expect: false},
+		{given: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", expect: false},
+		{given: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", expect: false},
+		{given: "00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01", expect: false},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.given)
+
+			is.Equal(t, tc.expect, TraceContext{TraceParent: tc.given}.IsValid())
+		})
+	}
+}
+
+func TestPropagateTraceContext(t *testing.T) {
+	t.Parallel()
+
+	handler := PropagateTraceContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = Write(w, http.StatusOK, &Article{ID: "1", Title: "A"}, MarshalContext(r.Context()), MarshalTraceContext(DefaultTraceMetaKeys))
+	}))
+
+	tests := []struct {
+		description string
+		header      http.Header
+		expect      string
+		expectTrace TraceContext
+	}{
+		{
+			description: "trace context",
+			header:      http.Header{"Traceparent": {testTraceParent}, "Tracestate": {"congo=t61rcWkgMzE", "rojo=00f067aa0ba902b7"}},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"meta":{"traceparent":"` + testTraceParent + `","tracestate":"congo=t61rcWkgMzE,rojo=00f067aa0ba902b7"}}`,
+			expectTrace: TraceContext{TraceParent: testTraceParent, TraceState: "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7"},
+		}, {
+			description: "no trace state",
+			header:      http.Header{"Traceparent": {testTraceParent}},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"meta":{"traceparent":"` + testTraceParent + `"}}`,
+			expectTrace: TraceContext{TraceParent: testTraceParent},
+		}, {
+			description: "invalid trace context",
+			header:      http.Header{"Traceparent": {"invalid"}, "Tracestate": {"congo=t61rcWkgMzE"}},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}}}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			r := httptest.NewRequest(http.MethodGet, "/articles/1", nil)
+			r.Header = tc.header
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			is.EqualJSON(t, tc.expect, w.Body.String())
+
+			trace, err := ExtractTraceContext(w.Body.Bytes(), DefaultTraceMetaKeys)
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expectTrace, trace)
+		})
+	}
+}
+
+func TestMarshalTraceContextKeys(t *testing.T) {
+	t.Parallel()
+
+	keys := TraceMetaKeys{TraceParent: "trace", TraceState: "state"}
+	ctx := ContextWithTraceContext(context.Background(), TraceContext{TraceParent: testTraceParent})
+
+	b, err := Marshal(&Article{ID: "1"}, MarshalContext(ctx), MarshalTraceContext(keys))
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":{"id":"1","type":"articles","attributes":{"title":""}},"meta":{"trace":"`+testTraceParent+`"}}`, string(b))
+
+	trace, err := ExtractTraceContext(b, keys)
+	is.MustNoError(t, err)
+	is.Equal(t, TraceContext{TraceParent: testTraceParent}, trace)
+
+	_, err = ExtractTraceContext([]byte(`{"meta":[]}`), keys)
+	is.MustError(t, err)
+}
@@ -0,0 +1,148 @@
+package jsonapi
+
+import (
+	"sort"
+)
+
+// IncludeTruncationMeta is the name of the top-level meta member describing the truncation of the
+// included resources of a compound document by MarshalIncludeLimit.
+const IncludeTruncationMeta = "includeTruncation"
+
+// IncludeTruncation describes the truncation of the included resources of a compound document, as
+// recorded in the IncludeTruncationMeta member of its top-level meta.
+type IncludeTruncation struct {
+	// Limit is the maximum number of included resources.
+	Limit int `json:"limit"`
+
+	// Total is the number of included resources before truncation.
+	Total int `json:"total"`
+
+	// Paths are the relationship paths (e.g. "comments.author") whose related resources were
+	// partially or entirely left out, in lexical order.
+	Paths []string `json:"paths"`
+}
+
+// MarshalIncludeLimit bounds the number of included resources of compound documents to n, keeping
+// the resources given via MarshalInclude or resolved first. Resources left out of a truncated
+// document are still referenced by resource linkage, and the relationships linking to them get a
+// related link clients can follow to fetch them: the one given by LinkableRelation, or else the self
+// link of the resource object suffixed with the relationship name (e.g. /articles/1/comments). The
+// truncation is recorded in the top-level meta as an IncludeTruncation, under the
+// IncludeTruncationMeta member.
+//
+// Included resources only reachable via left out resources are left out as well, so truncated
+// documents remain fully linked. A limit of 0 or less disables truncation, which is the default.
+func MarshalIncludeLimit(n int) MarshalOption {
+	return func(m *Marshaler) {
+		m.includeLimit = n
+	}
+}
+
+// truncateIncluded truncates the included resources of the given document to the given limit,
+// returning a description of the truncation or nil if the document was not truncated.
+func truncateIncluded(d *document, limit int) *IncludeTruncation {
+	if limit <= 0 || len(d.Included) <= limit {
+		return nil
+	}
+
+	all := make(map[string]bool, len(d.Included))
+	for _, ro := range d.Included {
+		all[ro.identifier()] = true
+	}
+	total := len(d.Included)
+
+	d.Included = d.Included[:limit:limit]
+	pruneIncludes(d, func(string, string) bool { return true })
+
+	kept := make(map[string]*resourceObject, len(d.Included))
+	for _, ro := range d.Included {
+		kept[ro.identifier()] = ro
+	}
+
+	type node struct {
+		ro   *resourceObject
+		path string
+	}
+	queue := make([]node, 0, len(d.DataMany)+1)
+	visited := make(map[string]bool)
+	for _, ro := range d.DataMany {
+		queue = append(queue, node{ro: ro})
+		visited[ro.identifier()] = true
+	}
+	if d.DataOne != nil {
+		queue = append(queue, node{ro: d.DataOne})
+		visited[d.DataOne.identifier()] = true
+	}
+
+	truncated := make(map[string]bool)
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		names := make([]string, 0, len(n.ro.Relationships))
+		for name := range n.ro.Relationships {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			rel := n.ro.Relationships[name]
+			path := name
+			if n.path != "" {
+				path = n.path + "." + name
+			}
+
+			linkage := rel.DataMany
+			if rel.DataOne != nil {
+				linkage = []*resourceObject{rel.DataOne}
+			}
+			left := false
+			for _, ri := range linkage {
+				id := ri.identifier()
+				if iro, ok := kept[id]; ok {
+					if !visited[id] {
+						visited[id] = true
+						queue = append(queue, node{ro: iro, path: path})
+					}
+				} else if all[id] {
+					left = true
+				}
+			}
+			if left {
+				truncated[path] = true
+				addRelatedLink(n.ro, name, rel)
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(truncated))
+	for path := range truncated {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return &IncludeTruncation{Limit: limit, Total: total, Paths: paths}
+}
+
+// addRelatedLink sets the related link of the relationship named relation of ro to the self link
+// of ro suffixed with the relationship name, unless it has one or ro has no self link.
+func addRelatedLink(ro *resourceObject, relation string, rel *document) {
+	if rel.Links != nil && rel.Links.Related != nil {
+		return
+	}
+	if ro.Links == nil {
+		return
+	}
+
+	self := LinkHref(ro.Links.Self)
+	if self == "" {
+		return
+	}
+
+	link := Link{}
+	if rel.Links != nil {
+		link = *rel.Links
+	}
+	link.Related = self + "/" + relation
+	rel.Links = &link
+}
@@ -0,0 +1,67 @@
+package jsonapi
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestMarshalIncludeLimit(t *testing.T) {
+	t.Parallel()
+
+	authorB := &Author{ID: "2", Name: "B"}
+	commentC := &Comment{ID: "3", Body: "C", Author: authorB}
+	article := &ArticleLinkedWithComments{ID: "1", Comments: []*Comment{&commentA, commentC}}
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+	}{
+		{
+			description: "under the limit",
+			given:       &articleRelatedComplete,
+			opts:        []MarshalOption{MarshalInclude(&authorAWithMeta), MarshalIncludeLimit(1)},
+			expect:      `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"},"meta":{"count":10},"links":{"self":"http://example.com/articles/1/relationships/author","related":"http://example.com/articles/1/author"}},"comments":{"data":[{"id":"1","type":"comments"},{"id":"2","type":"comments"}],"links":{"self":"http://example.com/articles/1/relationships/comments","related":"http://example.com/articles/1/comments"}}}},"included":[{"id":"1","type":"author","attributes":{"name":"A"},"meta":{"count":10}}]}`,
+		}, {
+			description: "related link derived from the self link",
+			given:       article,
+			opts:        []MarshalOption{MarshalInclude(&commentA, commentC), MarshalIncludeLimit(1), MarshalMeta(map[string]any{"count": 2})},
+			expect:      `{"data":{"id":"1","type":"articles","relationships":{"comments":{"data":[{"id":"1","type":"comments"},{"id":"3","type":"comments"}],"links":{"related":"https://example.com/articles/1/comments"}}},"links":{"self":"https://example.com/articles/1"}},"included":[{"id":"1","type":"comments","attributes":{"body":"A"}}],"meta":{"count":2,"includeTruncation":{"limit":1,"total":2,"paths":["comments"]}}}`,
+		}, {
+			description: "nested relationship",
+			given:       article,
+			opts:        []MarshalOption{MarshalInclude(&commentA, commentC, authorB), MarshalIncludeLimit(2)},
+			expect:      `{"data":{"id":"1","type":"articles","relationships":{"comments":{"data":[{"id":"1","type":"comments"},{"id":"3","type":"comments"}]}},"links":{"self":"https://example.com/articles/1"}},"included":[{"id":"1","type":"comments","attributes":{"body":"A"}},{"id":"3","type":"comments","attributes":{"body":"C"},"relationships":{"author":{"data":{"id":"2","type":"author"},"links":{"self":"http://example.com/comments/3/relationships/author","related":"http://example.com/comments/3/author"}}}}],"meta":{"includeTruncation":{"limit":2,"total":3,"paths":["comments.author"]}}}`,
+		}, {
+			description: "resources only reachable via left out resources",
+			given:       article,
+			opts:        []MarshalOption{MarshalInclude(authorB, commentC), MarshalIncludeLimit(1)},
+			expect:      `{"data":{"id":"1","type":"articles","relationships":{"comments":{"data":[{"id":"1","type":"comments"},{"id":"3","type":"comments"}],"links":{"related":"https://example.com/articles/1/comments"}}},"links":{"self":"https://example.com/articles/1"}},"meta":{"includeTruncation":{"limit":1,"total":2,"paths":["comments"]}}}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(actual))
+		})
+	}
+}
+
+func TestMarshalIncludeLimitKeepsMetaPrecision(t *testing.T) {
+	t.Parallel()
+
+	article := &ArticleLinkedWithComments{ID: "1", Comments: []*Comment{&commentA, &commentB}}
+	actual, err := Marshal(article, MarshalInclude(&commentA, &commentB), MarshalIncludeLimit(1), MarshalMeta(map[string]any{"count": uint64(1<<60 + 1)}))
+	is.MustNoError(t, err)
+	is.Equal(t, true, strings.Contains(string(actual), `"meta":{"count":1152921504606846977,"includeTruncation":`))
+}
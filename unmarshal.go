@@ -1,9 +1,14 @@
 package jsonapi
 
 import (
+	"bytes"
+	"context"
 	"encoding"
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 // Unmarshaler is configured internally via UnmarshalOption's passed to Unmarshal.
@@ -11,7 +16,43 @@ import (
 type Unmarshaler struct {
 	unmarshalMeta            bool
 	meta                     any
+	jsonAPI                  *JSONAPIObject
+	documentInfo             *DocumentInfo
 	memberNameValidationMode memberNameValidationMode
+	relaxedMemberClasses     memberClasses
+	linkageOnly              bool
+	ctx                      context.Context
+	maxBodySize              int64
+	zeroCopyStrings          bool
+	clientMode               bool
+	ignoreReadOnly           bool
+	metaSchema               MetaSchema
+	dataMember               string
+	useNumber                bool
+	naming                   NamingConvention
+	types                    *TypeRegistry
+	typeAliases              map[string][]string
+	partialLinkage           bool
+	partialLinkageHandler    func(err *PartialLinkageError)
+	extensions               extensionNamespaces
+	extensionMembers         any
+	patch                    bool
+	bulk                     bool
+	timeFormat               TimeFormat
+	timeUTC                  bool
+	int64Strings             bool
+	limits                   DecodeLimits
+	rejectDuplicateMembers   bool
+	onUnmarshal              func(ctx context.Context, op Operation)
+	profiles                 []*ProfileHandler
+
+	// checked is true if documents have already been checked by checkDocument while being read, as
+	// done by DecodeEach
+	checked bool
+
+	// visiting holds the resource objects currently being unmarshaled, to detect cycles between
+	// included resources
+	visiting map[string]bool
 }
 
 // UnmarshalOption allows for configuration of Unmarshaling.
@@ -25,6 +66,15 @@ func UnmarshalMeta(meta any) UnmarshalOption {
 	}
 }
 
+// UnmarshalJSONAPIObject stores the top-level jsonapi object of the document in o when
+// unmarshaling, e.g. to check the extensions and profiles applied to it. o is left untouched if the
+// document has none.
+func UnmarshalJSONAPIObject(o *JSONAPIObject) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.jsonAPI = o
+	}
+}
+
 // UnmarshalStrictNameValidation enables member name validation that is more strict than default.
 //
 // In addition to the basic naming rules from https://jsonapi.org/format/#document-member-names,
@@ -46,65 +96,420 @@ func UnmarshalDisableNameValidation() UnmarshalOption {
 	}
 }
 
+// UnmarshalRelaxNameValidation exempts the given classes of member names from strict member name
+// validation, while keeping it for every other member of the document. This is useful for
+// compatibility with APIs using e.g. PascalCase type names.
+//
+// Relaxed member names must still conform to https://jsonapi.org/format/#document-member-names.
+func UnmarshalRelaxNameValidation(classes ...MemberClass) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.relaxedMemberClasses = newMemberClasses(classes)
+	}
+}
+
+// UnmarshalLinkageOnly populates relationship fields with resource linkage (type and id) only.
+//
+// By default, relationship fields of compound documents are populated with the full included
+// resources as defined by https://jsonapi.org/format/#document-compound-documents, including their
+// attributes, meta, and (transitively) their relationships. Included resources referring back to a
+// resource which is already being unmarshaled are populated with resource linkage only, so cyclic
+// relationships between included resources are safe to unmarshal.
+func UnmarshalLinkageOnly() UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.linkageOnly = true
+	}
+}
+
+// UnmarshalMaxBodySize limits the size of request bodies read by Read, and of response bodies read
+// by a Client, to n bytes, overriding DefaultMaxBodySize. If n is negative, their size is not
+// limited.
+func UnmarshalMaxBodySize(n int64) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.maxBodySize = n
+	}
+}
+
+// UnmarshalZeroCopyStrings makes string attributes share the memory of their json values, as
+// copied from the data being unmarshaled, instead of decoding them into new strings. The data itself
+// is never aliased, as encoding/json doesn't allow retaining it, so it may be modified or reused
+// once unmarshaled.
+//
+// Retaining any of the strings keeps the copy of its json value in memory. Only attributes of type
+// string whose json value contains no escape sequences share its memory; all other attributes are
+// decoded as usual.
+func UnmarshalZeroCopyStrings() UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.zeroCopyStrings = true
+	}
+}
+
+// UnmarshalAllowPartialLinkage disables the verification of full linkage of compound documents, as
+// defined by https://jsonapi.org/format/#document-compound-documents, for interoperability with
+// servers including orphan resources. Included resources without a chain of relationships from
+// primary data are ignored. If handler is not nil, it is called with the error describing them,
+// e.g. to log a warning.
+func UnmarshalAllowPartialLinkage(handler func(err *PartialLinkageError)) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.partialLinkage = true
+		m.partialLinkageHandler = handler
+	}
+}
+
+// UnmarshalClientMode enables client mode, for unmarshaling documents written by servers, in
+// which read-only attributes are accepted.
+func UnmarshalClientMode() UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.clientMode = true
+	}
+}
+
+// UnmarshalIgnoreReadOnly ignores read-only attributes set by documents, which are otherwise
+// rejected with ErrReadOnlyAttribute unless in client mode.
+func UnmarshalIgnoreReadOnly() UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.ignoreReadOnly = true
+	}
+}
+
+// UnmarshalUseNumber decodes numbers in attributes as json.Number instead of float64 when the
+// destination is an interface value (e.g. any or map[string]any fields), as done by
+// json.Decoder.UseNumber, so large integers and decimal values keep their precision.
+func UnmarshalUseNumber() UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.useNumber = true
+	}
+}
+
+// UnmarshalContext sets the context passed to callbacks invoked while unmarshaling, such as an
+// AfterUnmarshaler.
+func UnmarshalContext(ctx context.Context) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		m.ctx = ctx
+	}
+}
+
+// context returns the context given by UnmarshalContext, or context.Background.
+func (m *Unmarshaler) context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// decodeJSON parses the json encoded data into the value pointed to by v, decoding numbers as
+// json.Number if enabled by UnmarshalUseNumber.
+func (m *Unmarshaler) decodeJSON(data []byte, v any) error {
+	if !m.useNumber {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// UnmarshalTypeAliases accepts resource objects of the given alias types where resourceType is
+// expected, e.g. while clients migrate to a renamed resource type. Otherwise, resource objects whose
+// type doesn't match the type of the struct they are unmarshaled into are rejected with an error
+// wrapping ErrTypeConflict.
+func UnmarshalTypeAliases(resourceType string, aliases ...string) UnmarshalOption {
+	return func(m *Unmarshaler) {
+		if m.typeAliases == nil {
+			m.typeAliases = make(map[string][]string)
+		}
+		m.typeAliases[resourceType] = append(m.typeAliases[resourceType], aliases...)
+	}
+}
+
 // relationshipUnmarshaler creates a new marshaler from a parent one for the sake of unmarshaling
 // relationship documents, by copying over relevant fields.
 func (m *Unmarshaler) relationshipUnmarshaler() *Unmarshaler {
 	rm := new(Unmarshaler)
 
 	rm.memberNameValidationMode = m.memberNameValidationMode
+	rm.relaxedMemberClasses = m.relaxedMemberClasses
+	rm.visiting = m.visiting
+	rm.zeroCopyStrings = m.zeroCopyStrings
+	rm.clientMode = m.clientMode
+	rm.ignoreReadOnly = m.ignoreReadOnly
+	rm.useNumber = m.useNumber
+	rm.naming = m.naming
+	rm.types = m.types
+	rm.typeAliases = m.typeAliases
+	rm.extensions = m.extensions
+	rm.ctx = m.ctx
+	rm.timeFormat = m.timeFormat
+	rm.timeUTC = m.timeUTC
+	rm.int64Strings = m.int64Strings
 	return rm
 }
 
+// makeUnmarshaler creates a new Unmarshaler configured with the given options.
+func makeUnmarshaler(opts ...UnmarshalOption) *Unmarshaler {
+	m := &Unmarshaler{maxBodySize: DefaultMaxBodySize, visiting: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
 // Unmarshal parses the json:api encoded data and stores the result in the value pointed to by v.
 // If v is nil or not a pointer, Unmarshal returns an error.
-func Unmarshal(data []byte, v any, opts ...UnmarshalOption) (err error) {
-	defer func() {
-		// because we make use of reflect we must recover any panics
-		if rvr := recover(); rvr != nil {
-			err = recoverError(rvr)
+//
+// The error objects of error documents are stored in v if it points to an ErrorList or []*Error,
+// and are otherwise returned as an ErrorList.
+func Unmarshal(data []byte, v any, opts ...UnmarshalOption) error {
+	m := makeUnmarshaler(opts...)
+
+	return m.observe(func(op *Operation) error {
+		op.Size = len(data)
+		d, err := m.unmarshal(data, v)
+		op.add(d)
+		return err
+	})
+}
+
+// UnmarshalPatch parses the json:api encoded data, which must have a single resource object as
+// primary data, into the existing struct pointed to by v, e.g. to apply the body of a PATCH request
+// to a resource loaded from storage. Only the fields of members present in the resource object are
+// overwritten, and all other fields of v are left untouched:
+//   - attributes replace the values of their fields, rather than being merged into them
+//   - unknown attributes are added to the extras field
+//   - relationships with null or empty resource linkage clear their fields
+//   - meta and extension members replace the values of their fields
+//
+// UnmarshalPatch accepts the same options as Unmarshal.
+func UnmarshalPatch(data []byte, v any, opts ...UnmarshalOption) error {
+	m := makeUnmarshaler(opts...)
+	m.patch = true
+
+	return m.observe(func(op *Operation) error {
+		op.Size = len(data)
+		d, err := m.unmarshal(data, v)
+		op.add(d)
+		return err
+	})
+}
+
+// Verify checks that data is a valid json:api document, without unmarshaling it into a Go value.
+// That is, it must have valid member names, must not contain both data and errors, resource
+// objects must have a type, and compound documents must be fully linked. The given options
+// configure member name validation as done by Unmarshal.
+func Verify(data []byte, opts ...UnmarshalOption) error {
+	m := makeUnmarshaler(opts...)
+
+	return m.observe(func(op *Operation) (err error) {
+		op.Size = len(data)
+		if err = m.checkDocument(data, false); err != nil {
 			return
 		}
-	}()
 
-	m := new(Unmarshaler)
-	for _, opt := range opts {
-		opt(m)
+		var members map[string]json.RawMessage
+		if err = json.Unmarshal(data, &members); err != nil {
+			return
+		}
+		if err = checkDataAndErrors(members); err != nil {
+			return
+		}
+
+		var d document
+		if err = unmarshalJSON(data, &d); err != nil {
+			return
+		}
+		op.add(&d)
+		if err = validateJSONMemberNames(data, m.memberNameValidationMode, m.relaxedMemberClasses, m.extensions); err != nil {
+			return
+		}
+
+		primary := d.DataMany
+		if !d.hasMany && d.DataOne != nil {
+			primary = []*resourceObject{d.DataOne}
+		}
+		for i, ro := range primary {
+			pointer := "/data"
+			if d.hasMany {
+				pointer = fmt.Sprintf("/data/%d", i)
+			}
+			if err = ro.verify(pointer); err != nil {
+				return
+			}
+		}
+		for i, ro := range d.Included {
+			if err = ro.verify(fmt.Sprintf("/included/%d", i)); err != nil {
+				return
+			}
+		}
+
+		return allowPartialLinkage(d.verifyFullLinkage(false), m.partialLinkage, m.partialLinkageHandler)
+	})
+}
+
+// checkDataAndErrors returns an error if the top-level members of a document contain both data and
+// errors.
+func checkDataAndErrors(members map[string]json.RawMessage) error {
+	_, hasData := members["data"]
+	_, hasErrors := members["errors"]
+	if hasData && hasErrors {
+		return &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrDataAndErrorsFields}
+	}
+	return nil
+}
+
+// UnmarshalErrors parses the error objects of the json:api encoded error document data, e.g. the
+// body of a response with a 4xx or 5xx status, without a value to unmarshal primary data into. It
+// returns an error if data is not an error document, i.e. has no errors member, or has both data
+// and errors members. The given options are applied as done by Unmarshal, e.g. UnmarshalMeta
+// decodes the top-level meta of the document.
+func UnmarshalErrors(data []byte, opts ...UnmarshalOption) (errs []*Error, err error) {
+	m := makeUnmarshaler(opts...)
+
+	err = m.observe(func(op *Operation) (err error) {
+		op.Size = len(data)
+		if err = m.checkDocument(data, false); err != nil {
+			return
+		}
+
+		var members map[string]json.RawMessage
+		if err = json.Unmarshal(data, &members); err != nil {
+			return
+		}
+		if err = checkDataAndErrors(members); err != nil {
+			return
+		}
+		if _, ok := members["errors"]; !ok {
+			err = &DocumentError{Code: CodeMissingData, Err: ErrMissingErrorsField}
+			return
+		}
+
+		var d document
+		if err = unmarshalJSON(data, &d); err != nil {
+			return
+		}
+		op.add(&d)
+		if err = validateJSONMemberNames(data, m.memberNameValidationMode, m.relaxedMemberClasses, m.extensions); err != nil {
+			return
+		}
+		if err = d.unmarshalErrors(&errs, m); err != nil {
+			return
+		}
+		if errs == nil {
+			errs = make([]*Error, 0)
+		}
+
+		return
+	})
+
+	return
+}
+
+// verify checks that the resource object at the given JSON pointer and its resource linkage have a
+// type.
+func (ro *resourceObject) verify(pointer string) error {
+	if ro.Type == "" {
+		return &FieldError{Code: CodeInvalidType, Member: "type", Pointer: pointer + "/type", Err: ErrMissingTypeField}
+	}
+	for name, rel := range ro.Relationships {
+		linkage := rel.DataMany
+		if !rel.hasMany && rel.DataOne != nil {
+			linkage = []*resourceObject{rel.DataOne}
+		}
+		for i, ri := range linkage {
+			if ri.Type != "" {
+				continue
+			}
+			p := pointer + "/relationships/" + escapePointerToken(name) + "/data"
+			if rel.hasMany {
+				p += fmt.Sprintf("/%d", i)
+			}
+			return &FieldError{Code: CodeInvalidRelationship, Member: name, Pointer: p + "/type", Err: ErrMissingTypeField}
+		}
 	}
+	return nil
+}
 
+// unmarshal parses the json:api encoded data into v, returning the parsed document. Errors give
+// the byte offsets of the offending members of data.
+func (m *Unmarshaler) unmarshal(data []byte, v any) (*document, error) {
+	d, err := m.unmarshalDocument(data, v)
+	return d, locateErrors(err, data)
+}
+
+// unmarshalDocument parses the json:api encoded data into v, returning the parsed document.
+func (m *Unmarshaler) unmarshalDocument(data []byte, v any) (*document, error) {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Pointer || rv.IsNil() {
-		err = &TypeError{Actual: rv.Kind().String(), Expected: []string{"non-nil pointer"}}
-		return
+		return nil, &TypeError{Actual: rv.Kind().String(), Expected: []string{"non-nil pointer"}, err: ErrUnmarshalInvalidTarget}
+	}
+
+	if !m.checked {
+		if err := m.checkDocument(data, false); err != nil {
+			return nil, err
+		}
+	}
+
+	pointer := func(p string) string { return p }
+	if m.dataMember != "" {
+		var err error
+		if data, pointer, err = moveDataMember(data, m.dataMember); err != nil {
+			return nil, err
+		}
 	}
 
 	var d document
-	if err = json.Unmarshal(data, &d); err != nil {
-		return
+	if err := unmarshalJSON(data, &d); err != nil {
+		return nil, mapPointers(err, pointer)
 	}
 
-	if err = validateJSONMemberNames(data, m.memberNameValidationMode); err != nil {
-		return
+	if err := validateJSONMemberNames(data, m.memberNameValidationMode, m.relaxedMemberClasses, m.extensions); err != nil {
+		return nil, mapPointers(err, pointer)
 	}
 
-	err = d.unmarshal(v, m)
+	if len(d.Errors) > 0 {
+		return &d, mapPointers(d.unmarshalErrors(v, m), pointer)
+	}
 
-	return
+	return &d, mapPointers(d.unmarshal(v, m), pointer)
+}
+
+// unmarshalErrors unmarshals the error objects of an error document into v if it points to an
+// ErrorList or []*Error, or returns them as an ErrorList otherwise.
+func (d *document) unmarshalErrors(v any, m *Unmarshaler) error {
+	if err := d.unmarshalOptionalFields(m); err != nil {
+		return err
+	}
+	switch ev := v.(type) {
+	case *ErrorList:
+		*ev = d.Errors
+	case *[]*Error:
+		*ev = d.Errors
+	default:
+		return ErrorList(d.Errors)
+	}
+	return nil
 }
 
 func (d *document) unmarshal(v any, m *Unmarshaler) (err error) {
+	if m.patch && (d.hasMany || d.DataOne == nil) {
+		return &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrInvalidPatchData}
+	}
+	if m.bulk && !d.hasMany {
+		return &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrInvalidBulkData}
+	}
+
 	// verify full-linkage in-case this is a compound document
-	if err = d.verifyFullLinkage(true); err != nil {
+	if err = allowPartialLinkage(d.verifyFullLinkage(!m.linkageOnly), m.partialLinkage, m.partialLinkageHandler); err != nil {
 		return
 	}
 
 	if d.hasMany {
 		err = unmarshalResourceObjects(d.DataMany, v, m)
 		if err != nil {
+			err = prefixPointer(err, "/data")
 			return
 		}
 	} else if d.DataOne != nil {
 		err = d.DataOne.unmarshal(v, m)
 		if err != nil {
+			err = prefixPointer(err, "/data")
 			return
 		}
 	}
@@ -120,6 +525,34 @@ func (d *document) unmarshalOptionalFields(m *Unmarshaler) error {
 		// this is possible during recursive document unmarshaling
 		return nil
 	}
+	if m.metaSchema != nil || m.documentInfo != nil {
+		// the meta is validated as received rather than as re-encoded from its decoded value
+		var meta json.RawMessage
+		var err error
+		if d.raw != nil {
+			meta, err = topLevelMeta(d.raw)
+		} else if d.Meta != nil {
+			// documents made from maps by UnmarshalFromMap have no encoding
+			meta, err = marshalJSON(d.Meta)
+		}
+		if err != nil {
+			return err
+		}
+		if err := validateMeta(m.metaSchema, meta); err != nil {
+			return err
+		}
+		if m.documentInfo != nil {
+			*m.documentInfo = DocumentInfo{Links: d.Links, JSONAPI: d.JSONAPI, Meta: meta}
+		}
+	}
+	if m.jsonAPI != nil && d.JSONAPI != nil {
+		*m.jsonAPI = *d.JSONAPI
+	}
+	if m.extensionMembers != nil {
+		if err := m.unmarshalExtensionMembers(d.raw, m.extensionMembers); err != nil {
+			return &DocumentError{Code: CodeInvalidExtension, Err: err}
+		}
+	}
 	if m.unmarshalMeta {
 		b, err := json.Marshal(d.Meta)
 		if err != nil {
@@ -128,7 +561,7 @@ func (d *document) unmarshalOptionalFields(m *Unmarshaler) error {
 		if err := json.Unmarshal(b, m.meta); err != nil {
 			return err
 		}
-		if err := validateJSONMemberNames(b, m.memberNameValidationMode); err != nil {
+		if err := validateJSONMemberNames(b, m.memberNameValidationMode, nil, m.extensions); err != nil {
 			return err
 		}
 	}
@@ -148,11 +581,32 @@ func unmarshalResourceObjects(ros []*resourceObject, v any, m *Unmarshaler) erro
 		outValue = reflect.MakeSlice(outType, 0, 0)
 	}
 
-	for _, ro := range ros {
+	// with UnmarshalMany, the errors of all invalid resource objects are collected
+	var bulkErr BulkError
+
+	for i, ro := range ros {
+		// resource objects of different types are unmarshaled into values of the registered types
+		if outType.Elem().Kind() == reflect.Interface {
+			outValue = reflect.Append(outValue, reflect.Zero(outType.Elem()))
+			if err := ro.unmarshalInterface(outValue.Index(outValue.Len()-1), m); err != nil {
+				if !m.bulk {
+					return prefixPointer(err, fmt.Sprintf("/%d", i))
+				}
+				outValue.Index(outValue.Len() - 1).Set(reflect.Zero(outType.Elem()))
+				bulkErr = append(bulkErr, bulkElementError(err, ro, i))
+			}
+			continue
+		}
+
 		// unmarshal the resource object into an empty value of the slices element type
 		outElem := reflect.New(derefType(outType.Elem())).Interface()
 		if err := ro.unmarshal(outElem, m); err != nil {
-			return err
+			if !m.bulk {
+				return prefixPointer(err, fmt.Sprintf("/%d", i))
+			}
+			outValue = reflect.Append(outValue, reflect.Zero(outType.Elem()))
+			bulkErr = append(bulkErr, bulkElementError(err, ro, i))
+			continue
 		}
 
 		// reflect.New creates a pointer, so if our slices underlying type
@@ -169,32 +623,123 @@ func unmarshalResourceObjects(ros []*resourceObject, v any, m *Unmarshaler) erro
 	// set the value of the passed in object to our result
 	reflect.ValueOf(v).Elem().Set(outValue)
 
+	if len(bulkErr) > 0 {
+		return bulkErr
+	}
 	return nil
 }
 
 func (ro *resourceObject) unmarshal(v any, m *Unmarshaler) error {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.Elem().Kind() == reflect.Interface {
+		return ro.unmarshalInterface(rv.Elem(), m)
+	}
+	if r, ok := v.(*Resource); ok {
+		if err := ro.unmarshalResource(r, m); err != nil {
+			return &ResourceError{Code: CodeInvalidResource, Type: ro.Type, ID: ro.ID, Err: err}
+		}
+		return nil
+	}
+
 	// first, it must be a struct since we'll be parsing the jsonapi struct tags
 	vt := reflect.TypeOf(v)
 	if derefType(vt).Kind() != reflect.Struct {
 		return &TypeError{Actual: vt.String(), Expected: []string{"struct"}}
 	}
 
-	if err := ro.unmarshalFields(v, m); err != nil {
+	if err := m.checkProfiles(v, ro); err != nil {
 		return err
 	}
 
-	return ro.unmarshalAttributes(v)
+	// resources may take full control of unmarshaling their resource object instead
+	if ru, ok := v.(ResourceUnmarshaler); ok {
+		var r Resource
+		if err := ro.unmarshalResource(&r, m); err != nil {
+			return &ResourceError{Code: CodeInvalidResource, Type: ro.Type, ID: ro.ID, Err: err}
+		}
+		if err := ru.UnmarshalResource(&r); err != nil {
+			return &ResourceError{Code: CodeInvalidResource, Type: ro.Type, ID: ro.ID, Err: err}
+		}
+		return ro.afterUnmarshal(v, m)
+	}
+
+	// if this resource object is already being unmarshaled, the included resources are cyclic,
+	// so only unmarshal its resource linkage
+	key := ro.identifier()
+	if m.visiting[key] {
+		ro = &resourceObject{Type: ro.Type, ID: ro.ID}
+	} else {
+		m.visiting[key] = true
+		defer delete(m.visiting, key)
+	}
+
+	if err := ro.unmarshalFields(v, m); err != nil {
+		return &ResourceError{Code: CodeInvalidResource, Type: ro.Type, ID: ro.ID, Err: err}
+	}
+
+	if err := ro.unmarshalAttributes(v, m); err != nil {
+		return &ResourceError{Code: CodeInvalidResource, Type: ro.Type, ID: ro.ID, Err: err}
+	}
+
+	if err := ro.unmarshalLinks(v); err != nil {
+		return &ResourceError{Code: CodeInvalidResource, Type: ro.Type, ID: ro.ID, Err: err}
+	}
+
+	return ro.afterUnmarshal(v, m)
+}
+
+// unmarshalLinks passes the links of the resource object and of its relationships to v, if it
+// implements LinkUnmarshaler and RelationLinkUnmarshaler respectively.
+func (ro *resourceObject) unmarshalLinks(v any) error {
+	if lu, ok := v.(LinkUnmarshaler); ok && ro.Links != nil {
+		if err := lu.UnmarshalLink(ro.Links); err != nil {
+			return &FieldError{Code: CodeInvalidResource, Member: "links", Pointer: "/links", Err: err}
+		}
+	}
+
+	lu, ok := v.(RelationLinkUnmarshaler)
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0, len(ro.Relationships))
+	for name, rel := range ro.Relationships {
+		if rel != nil && rel.Links != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := lu.UnmarshalLinkRelation(name, ro.Relationships[name].Links); err != nil {
+			pointer := "/relationships/" + escapePointerToken(name)
+			return &FieldError{Code: CodeInvalidRelationship, Member: name, Pointer: pointer + "/links", Err: err}
+		}
+	}
+	return nil
+}
+
+// afterUnmarshal calls the handlers of profiles and the AfterUnmarshalJSONAPI hook of v, the
+// unmarshaled resource object ro.
+func (ro *resourceObject) afterUnmarshal(v any, m *Unmarshaler) error {
+	if err := m.unmarshalProfiles(v, ro); err != nil {
+		return err
+	}
+	if err := m.afterUnmarshal(v); err != nil {
+		return &ResourceError{Code: CodeInvalidResource, Type: ro.Type, ID: ro.ID, Err: err}
+	}
+	return nil
 }
 
 // unmarshalFields unmarshals a resource object into all non-attribute struct fields
 func (ro *resourceObject) unmarshalFields(v any, m *Unmarshaler) error {
 	setPrimary := false
-	rv := derefValue(reflect.ValueOf(v))
-	rt := reflect.TypeOf(rv.Interface())
-
-	for i := 0; i < rv.NumField(); i++ {
-		fv := rv.Field(i)
-		ft := rt.Field(i)
+	// the extension members of the resource object, decoded for the first extension field
+	var extensions map[string]rawValue
+	for _, field := range flattenFields(derefValue(reflect.ValueOf(v))) {
+		fv := field.v
+		ft := field.f
+		if !fv.CanSet() {
+			// fields of nil embedded struct pointers are only flattened as zero values
+			continue
+		}
 
 		jsonapiTag, err := parseJSONAPITag(ft)
 		if err != nil {
@@ -209,12 +754,21 @@ func (ro *resourceObject) unmarshalFields(v any, m *Unmarshaler) error {
 			if setPrimary {
 				return ErrUnmarshalDuplicatePrimaryField
 			}
-			if ro.Type != jsonapiTag.resourceType {
-				return &TypeError{Actual: ro.Type, Expected: []string{jsonapiTag.resourceType}}
+			if vu, ok := v.(UnmarshalType); ok {
+				if err := vu.UnmarshalResourceType(ro.Type); err != nil {
+					return &FieldError{Code: CodeInvalidType, Member: "type", Pointer: "/type", Err: err}
+				}
+			} else if err := m.checkResourceType(ro, jsonapiTag.resourceType); err != nil {
+				return err
 			}
-			if !isValidMemberName(ro.Type, m.memberNameValidationMode) {
+			if !isValidMemberName(ro.Type, m.relaxedMemberClasses.modeFor(TypeMembers, m.memberNameValidationMode)) {
 				// type names count as member names
-				return &MemberNameValidationError{ro.Type}
+				return &FieldError{
+					Code:    CodeInvalidType,
+					Member:  "type",
+					Pointer: "/type",
+					Err:     &MemberNameValidationError{MemberName: ro.Type, Pointer: "/type"},
+				}
 			}
 
 			// if omitempty is allowed, skip if this is an empty id
@@ -229,7 +783,7 @@ func (ro *resourceObject) unmarshalFields(v any, m *Unmarshaler) error {
 			//     4. Fail
 			if vu, ok := v.(UnmarshalIdentifier); ok {
 				if err := vu.UnmarshalID(ro.ID); err != nil {
-					return err
+					return newIDFieldError(err)
 				}
 				setPrimary = true
 				continue
@@ -246,7 +800,7 @@ func (ro *resourceObject) unmarshalFields(v any, m *Unmarshaler) error {
 
 			if fviu, ok := fvi.(encoding.TextUnmarshaler); ok {
 				if err := fviu.UnmarshalText([]byte(ro.ID)); err != nil {
-					return err
+					return newIDFieldError(err)
 				}
 				setPrimary = true
 				continue
@@ -258,24 +812,53 @@ func (ro *resourceObject) unmarshalFields(v any, m *Unmarshaler) error {
 				continue
 			}
 
-			return ErrUnmarshalInvalidPrimaryField
+			return newIDFieldError(ErrUnmarshalInvalidPrimaryField)
 		case relationship:
-			name, exported, _ := parseJSONTag(ft)
+			name, exported, _ := memberName(ft, m.naming)
 			if !exported {
 				continue
 			}
 			relDocument, ok := ro.Relationships[name]
+			if ok && !relDocument.noData {
+				fv = setRelationshipPresent(fv)
+			} else {
+				fv, _ = relatedField(fv)
+			}
 			if !ok || relDocument.isEmpty() {
-				// relDocument has no relationship data, so there's nothing to do
+				// relDocument has no relationship data, so there's nothing to do unless a patch
+				// explicitly clears the relationship
+				if ok && m.patch && !relDocument.noData {
+					fv.Set(reflect.Zero(fv.Type()))
+				}
 				continue
 			}
 
-			rm := m.relationshipUnmarshaler()
-			rel := reflect.New(derefType(ft.Type)).Interface()
-			if err := relDocument.unmarshal(rel, rm); err != nil {
+			relatedType, idsOnly, err := parseRelTypeTag(ft)
+			if err != nil {
 				return err
 			}
-			setFieldValue(fv, rel)
+			if idsOnly {
+				err = relDocument.unmarshalLinkage(fv, relatedType, m)
+			} else {
+				rel := reflect.New(derefType(fv.Type())).Interface()
+				if err = relDocument.unmarshal(rel, m.relationshipUnmarshaler()); err == nil {
+					setFieldValue(fv, rel)
+				}
+			}
+			if err == nil {
+				err = relDocument.unmarshalIdentifierMeta(v, name)
+			}
+			if err == nil {
+				err = relDocument.unmarshalExtensions(v, name, m)
+			}
+			if err != nil {
+				return &FieldError{
+					Code:    CodeInvalidRelationship,
+					Member:  name,
+					Pointer: "/relationships/" + escapePointerToken(name),
+					Err:     prefixPointer(err, "/relationships/"+escapePointerToken(name)),
+				}
+			}
 		case meta:
 			if ro.Meta == nil {
 				continue
@@ -287,9 +870,26 @@ func (ro *resourceObject) unmarshalFields(v any, m *Unmarshaler) error {
 
 			meta := reflect.New(derefType(ft.Type)).Interface()
 			if err = json.Unmarshal(b, meta); err != nil {
-				return err
+				return &FieldError{Code: CodeInvalidMeta, Member: "meta", Pointer: "/meta", Err: err}
 			}
 			setFieldValue(fv, meta)
+		case extension:
+			name, exported, _ := parseJSONTag(ft)
+			if !exported || ro.raw == nil {
+				continue
+			}
+			if extensions == nil {
+				if extensions, err = m.extensions.members(ro.raw); err != nil {
+					return err
+				}
+			}
+			value, ok := extensions[name]
+			if !ok {
+				continue
+			}
+			if err := m.decodeJSON(value, fv.Addr().Interface()); err != nil {
+				return &FieldError{Code: CodeInvalidExtension, Member: name, Pointer: "/" + escapePointerToken(name), Err: err}
+			}
 		default:
 			continue
 		}
@@ -298,10 +898,241 @@ func (ro *resourceObject) unmarshalFields(v any, m *Unmarshaler) error {
 	return nil
 }
 
-func (ro *resourceObject) unmarshalAttributes(v any) error {
-	b, err := json.Marshal(ro.Attributes)
+// unmarshalLinkage unmarshals the resource linkage of a relationship document into a relationship
+// field holding the ids of related resources of the given type only, i.e. a string for to-one
+// relationships and a []string for to-many relationships.
+func (d *document) unmarshalLinkage(fv reflect.Value, relatedType string, m *Unmarshaler) error {
+	if d.hasMany != (fv.Kind() == reflect.Slice) {
+		return &DocumentError{
+			Code:    CodeInvalidData,
+			Pointer: "/data",
+			Err:     &TypeError{Actual: fv.Type().String(), Expected: []string{"string", "[]string"}},
+		}
+	}
+
+	checkType := func(ro *resourceObject, pointer string) error {
+		return prefixPointer(m.checkResourceType(ro, relatedType), pointer)
+	}
+
+	if !d.hasMany {
+		if d.DataOne == nil {
+			fv.SetString("")
+			return nil
+		}
+		if err := checkType(d.DataOne, "/data"); err != nil {
+			return err
+		}
+		fv.SetString(d.DataOne.ID)
+		return nil
+	}
+
+	ids := reflect.MakeSlice(fv.Type(), len(d.DataMany), len(d.DataMany))
+	for i, ro := range d.DataMany {
+		if err := checkType(ro, fmt.Sprintf("/data/%d", i)); err != nil {
+			return err
+		}
+		ids.Index(i).SetString(ro.ID)
+	}
+	fv.Set(ids)
+	return nil
+}
+
+// checkResourceType returns an error wrapping ErrTypeConflict if the type of ro is neither the
+// expected resource type nor one of its aliases given via UnmarshalTypeAliases.
+func (m *Unmarshaler) checkResourceType(ro *resourceObject, expected string) error {
+	if ro.Type == expected {
+		return nil
+	}
+	aliases := m.typeAliases[expected]
+	for _, alias := range aliases {
+		if ro.Type == alias {
+			return nil
+		}
+	}
+	return &FieldError{
+		Code:    CodeInvalidType,
+		Member:  "type",
+		Pointer: "/type",
+		Err:     &TypeError{Actual: ro.Type, Expected: append([]string{expected}, aliases...), err: ErrTypeConflict},
+	}
+}
+
+func (ro *resourceObject) unmarshalAttributes(v any, m *Unmarshaler) error {
+	b := []byte(ro.rawAttributes)
+	if b == nil {
+		var err error
+		if b, err = json.Marshal(ro.Attributes); err != nil {
+			return err
+		}
+	}
+	if m.naming != nil {
+		var (
+			renamed map[string]string
+			err     error
+		)
+		if b, renamed, err = m.renameConventionalAttributes(b, v); err != nil {
+			return err
+		}
+		if len(renamed) > 0 {
+			return renameFieldErrors(m.decodeAttributes(b, v), renamed)
+		}
+	}
+	return m.decodeAttributes(b, v)
+}
+
+// decodeAttributes decodes the given attributes object into v.
+func (m *Unmarshaler) decodeAttributes(b []byte, v any) error {
+	if !m.clientMode {
+		var err error
+		if b, err = m.checkReadOnlyAttributes(b, v); err != nil || b == nil {
+			return err
+		}
+	}
+	if fv, ok := extrasField(reflect.ValueOf(v)); ok {
+		// attributes named with a NamingConvention have already been renamed to their field names
+		var err error
+		if b, err = m.unmarshalExtras(b, fv, attributeNames(reflect.TypeOf(v), nil)); err != nil || b == nil {
+			return err
+		}
+	}
+	if parsers := m.attributeParsers(reflect.TypeOf(v), nil); len(parsers) > 0 {
+		var err error
+		if b, err = parseAttributes(b, parsers); err != nil {
+			return err
+		}
+	}
+	if m.zeroCopyStrings {
+		var err error
+		if b, err = aliasStringAttributes(b, v); err != nil || b == nil {
+			return err
+		}
+	}
+	if m.patch {
+		if err := m.resetAttributes(b, v); err != nil {
+			return err
+		}
+	}
+	err := m.decodeJSON(b, v)
+	if err == nil {
+		err = m.decodeFlattened(b, reflect.ValueOf(v))
+	}
 	if err != nil {
-		return err
+		fe := &FieldError{Code: CodeInvalidAttribute, Member: "attributes", Pointer: "/attributes", Err: err}
+		if te, ok := err.(*json.UnmarshalTypeError); ok && te.Field != "" {
+			fe.Member = te.Field
+			fe.Pointer += "/" + escapePointerToken(strings.ReplaceAll(te.Field, ".", "/"))
+		}
+		return fe
 	}
-	return json.Unmarshal(b, v)
+	return nil
+}
+
+// resetAttributes zeroes the attribute fields of v named by the members of the given attributes
+// object, so that patches replace their values rather than being decoded into them. Members are
+// matched to fields as when decoding them, i.e. by their names given by the Unmarshaler's
+// NamingConvention, or their field names once renamed by renameConventionalAttributes, and
+// case-insensitively.
+func (m *Unmarshaler) resetAttributes(data []byte, v any) error {
+	var attributes map[string]rawValue
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return &FieldError{Code: CodeInvalidAttribute, Member: "attributes", Pointer: "/attributes", Err: err}
+	}
+	members := make([]string, 0, len(attributes))
+	for member := range attributes {
+		members = append(members, member)
+	}
+	m.resetAttributeFields(reflect.ValueOf(v), members)
+	return nil
+}
+
+func (m *Unmarshaler) resetAttributeFields(rv reflect.Value, members []string) {
+	rv = derefValue(rv)
+	if rv.Kind() != reflect.Struct {
+		return
+	}
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		fv := rv.Field(i)
+		ft := rt.Field(i)
+		if isFlattened(ft) {
+			// nil embedded struct pointers are allocated when decoding, so have nothing to reset
+			if fv.Kind() != reflect.Pointer || !fv.IsNil() {
+				m.resetAttributeFields(fv, members)
+			}
+			continue
+		}
+		tag, err := parseJSONAPITag(ft)
+		if err != nil || tag == nil || tag.directive != attribute || !fv.CanSet() {
+			continue
+		}
+		name, ok, _ := memberName(ft, m.naming)
+		if !ok {
+			continue
+		}
+		for _, member := range members {
+			if strings.EqualFold(member, name) || !hasJSONName(ft) && strings.EqualFold(member, ft.Name) {
+				fv.Set(reflect.Zero(ft.Type))
+				break
+			}
+		}
+	}
+}
+
+// checkReadOnlyAttributes returns an error if the given attributes object sets a read-only
+// attribute of v, or removes them if they are ignored. It returns the remaining attributes, or nil
+// if there are none.
+func (m *Unmarshaler) checkReadOnlyAttributes(data []byte, v any) ([]byte, error) {
+	names := readOnlyAttributes(reflect.TypeOf(v))
+	if len(names) == 0 {
+		return data, nil
+	}
+
+	var attributes map[string]rawValue
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, &FieldError{Code: CodeInvalidAttribute, Member: "attributes", Pointer: "/attributes", Err: err}
+	}
+
+	// encoding/json matches member names to fields case-insensitively, so members whose names only
+	// differ in case from read-only attributes would set them as well
+	members := make([]string, 0, len(attributes))
+	for member := range attributes {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+
+	set := false
+	for _, name := range names {
+		for _, member := range members {
+			if !strings.EqualFold(member, name) {
+				continue
+			}
+			if !m.ignoreReadOnly {
+				return nil, &FieldError{
+					Code:    CodeInvalidAttribute,
+					Member:  member,
+					Pointer: "/attributes/" + escapePointerToken(member),
+					Err:     ErrReadOnlyAttribute,
+				}
+			}
+			delete(attributes, member)
+			set = true
+		}
+	}
+	if !set {
+		return data, nil
+	}
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(attributes)
+}
+
+// newIDFieldError creates a FieldError for an invalid resource object id.
+func newIDFieldError(err error) *FieldError {
+	return &FieldError{Code: CodeInvalidID, Member: "id", Pointer: "/id", Err: err}
+}
+
+// escapePointerToken escapes a reference token of a JSON pointer as defined by RFC 6901.
+func escapePointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
 }
@@ -2,7 +2,9 @@ package jsonapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"testing"
 
@@ -229,6 +231,26 @@ func TestUnmarshal(t *testing.T) {
 			},
 			expect:      &articleEmbeddedPointer,
 			expectError: nil,
+		}, {
+			description: "ArticleFlattened",
+			given:       articleEmbeddedBody,
+			do: func(body []byte) (any, error) {
+				var a ArticleFlattened
+				err := Unmarshal(body, &a)
+				return &a, err
+			},
+			expect:      &articleFlattened,
+			expectError: nil,
+		}, {
+			description: "ArticleFlattenedPointer",
+			given:       articleEmbeddedBody,
+			do: func(body []byte) (any, error) {
+				var a ArticleFlattenedPointer
+				err := Unmarshal(body, &a)
+				return &a, err
+			},
+			expect:      &articleFlattenedPointer,
+			expectError: nil,
 		}, {
 			description: "nil",
 			given:       "",
@@ -287,7 +309,7 @@ func TestUnmarshal(t *testing.T) {
 				return &a, err
 			},
 			expect:      new(Article),
-			expectError: &PartialLinkageError{[]string{"{Type: author, ID: 1}"}},
+			expectError: &PartialLinkageError{Resources: []ResourceIdentifier{{Type: "author", ID: "1"}}},
 		}, {
 			description: "*ArticleRelated empty relationships (invalid)",
 			given:       articleRelatedInvalidEmptyRelationshipBody,
@@ -566,7 +588,7 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 				err := Unmarshal(body, &a, opts...)
 				return err
 			},
-			expectError: &MemberNameValidationError{"aut%hor"},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor"},
 		}, {
 			description: "Author with invalid attribute member name",
 			given:       authorWithInvalidAttributeNameBody,
@@ -575,7 +597,7 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 				err := Unmarshal(body, &a, opts...)
 				return err
 			},
-			expectError: &MemberNameValidationError{"na%me"},
+			expectError: &MemberNameValidationError{MemberName: "na%me"},
 		}, {
 			description: "Article with invalid resource meta member name",
 			given:       articleWithInvalidResourceMetaMemberNameBody,
@@ -584,7 +606,7 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 				err := Unmarshal(body, &a, opts...)
 				return err
 			},
-			expectError: &MemberNameValidationError{"foo%"},
+			expectError: &MemberNameValidationError{MemberName: "foo%"},
 		}, {
 			description: "Article with invalid top-level meta member name",
 			given:       articleWithInvalidToplevelMetaMemberNameBody,
@@ -597,7 +619,7 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 				err := Unmarshal(body, &a, opts...)
 				return err
 			},
-			expectError: &MemberNameValidationError{"foo%"},
+			expectError: &MemberNameValidationError{MemberName: "foo%"},
 		}, {
 			description: "Article with invalid link meta member name",
 			given:       articleWithInvalidLinkMetaMemberNameBody,
@@ -606,7 +628,7 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 				err := Unmarshal(body, &a, opts...)
 				return err
 			},
-			expectError: &MemberNameValidationError{"foo%"},
+			expectError: &MemberNameValidationError{MemberName: "foo%"},
 		}, {
 			description: "Article with invalid jsonapi meta member name",
 			given:       articleWithInvalidJSONAPIMetaMemberNameBody,
@@ -615,7 +637,7 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 				err := Unmarshal(body, &a, opts...)
 				return err
 			},
-			expectError: &MemberNameValidationError{"foo%"},
+			expectError: &MemberNameValidationError{MemberName: "foo%"},
 		}, {
 			description: "Article with invalid relationship name",
 			given:       articleWithInvalidRelationshipNameBody,
@@ -624,7 +646,7 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 				err := Unmarshal(body, &a, opts...)
 				return err
 			},
-			expectError: &MemberNameValidationError{"aut%hor"},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor"},
 		}, {
 			description: "Article with invalid relationship type name body",
 			given:       articleWithInvalidRelationshipTypeNameBody,
@@ -633,7 +655,7 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 				err := Unmarshal(body, &a, opts...)
 				return err
 			},
-			expectError: &MemberNameValidationError{"aut%hor"},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor"},
 		}, {
 			description: "Article with invalid relationship attribute member names not included",
 			given:       articleWithInvalidRelationshipAttributeNameNotIncludedBody,
@@ -651,7 +673,7 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 				err := Unmarshal(body, &a, opts...)
 				return err
 			},
-			expectError: &MemberNameValidationError{"na%me"},
+			expectError: &MemberNameValidationError{MemberName: "na%me"},
 		}, {
 			description: "[]*Article with one invalid resource meta member name",
 			given:       articlesWithOneInvalidResourceMetaMemberName,
@@ -660,7 +682,7 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 				err := Unmarshal(body, &a, opts...)
 				return err
 			},
-			expectError: &MemberNameValidationError{"foo%"},
+			expectError: &MemberNameValidationError{MemberName: "foo%"},
 		}, {
 			description: "Website with invalid nested relationship type member name",
 			given:       websiteWithInvalidNestedRelationshipTypeNameBody,
@@ -669,7 +691,7 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 				err := Unmarshal(body, &a, opts...)
 				return err
 			},
-			expectError: &MemberNameValidationError{"aut%hor"},
+			expectError: &MemberNameValidationError{MemberName: "aut%hor"},
 		},
 	}
 
@@ -687,3 +709,882 @@ func TestUnmarshalMemberNameValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestUnmarshalRelaxNameValidation(t *testing.T) {
+	t.Parallel()
+
+	legacyWorkspaceBody := `{"data":{"id":"1","type":"WorkspaceResource","attributes":{"DisplayName":"A","Nested":{"InnerName":"B"}}}}`
+	legacyWorkspaceWithMetaBody := `{"data":{"id":"1","type":"WorkspaceResource","attributes":{"DisplayName":"A"},"meta":{"RequestID":"1"}}}`
+
+	tests := []struct {
+		description string
+		given       string
+		opts        []UnmarshalOption
+		expect      *LegacyWorkspace
+		expectError error
+	}{
+		{
+			description: "strict",
+			given:       legacyWorkspaceBody,
+			opts:        []UnmarshalOption{UnmarshalStrictNameValidation()},
+			expectError: &MemberNameValidationError{MemberName: "DisplayName"},
+		}, {
+			description: "strict, relaxed attributes",
+			given:       legacyWorkspaceBody,
+			opts:        []UnmarshalOption{UnmarshalStrictNameValidation(), UnmarshalRelaxNameValidation(AttributeMembers)},
+			expectError: &MemberNameValidationError{MemberName: "WorkspaceResource"},
+		}, {
+			description: "strict, relaxed types and attributes",
+			given:       legacyWorkspaceBody,
+			opts:        []UnmarshalOption{UnmarshalStrictNameValidation(), UnmarshalRelaxNameValidation(TypeMembers, AttributeMembers)},
+			expect:      &LegacyWorkspace{ID: "1", Name: "A"},
+		}, {
+			description: "strict, relaxed types and attributes, strict meta",
+			given:       legacyWorkspaceWithMetaBody,
+			opts:        []UnmarshalOption{UnmarshalStrictNameValidation(), UnmarshalRelaxNameValidation(TypeMembers, AttributeMembers)},
+			expectError: &MemberNameValidationError{MemberName: "RequestID"},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var actual LegacyWorkspace
+			err := Unmarshal([]byte(tc.given), &actual, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &actual)
+		})
+	}
+}
+
+func TestUnmarshalIncluded(t *testing.T) {
+	t.Parallel()
+
+	peopleCyclicBody := `{"data":{"id":"1","type":"people","attributes":{"name":"A"},"relationships":{"friends":{"data":[{"id":"2","type":"people"}]}}},"included":[{"id":"2","type":"people","attributes":{"name":"B"},"relationships":{"friends":{"data":[{"id":"3","type":"people"}]}}},{"id":"3","type":"people","attributes":{"name":"C"},"relationships":{"friends":{"data":[{"id":"2","type":"people"}]}}}]}`
+
+	tests := []struct {
+		description string
+		given       string
+		do          func(body []byte) (any, error)
+		expect      any
+	}{
+		{
+			description: "nested included",
+			given:       articleRelatedCommentsNestedWithIncludeBody,
+			do: func(body []byte) (any, error) {
+				var a ArticleRelated
+				err := Unmarshal(body, &a)
+				return &a, err
+			},
+			expect: &articleRelatedCommentsNested,
+		}, {
+			description: "nested included, linkage only",
+			given:       articleRelatedCommentsNestedWithIncludeBody,
+			do: func(body []byte) (any, error) {
+				var a ArticleRelated
+				err := Unmarshal(body, &a, UnmarshalLinkageOnly())
+				return &a, err
+			},
+			expect: &ArticleRelated{ID: "1", Title: "A", Comments: []*Comment{{ID: "1"}}},
+		}, {
+			description: "cyclic included",
+			given:       peopleCyclicBody,
+			do: func(body []byte) (any, error) {
+				var p Person
+				err := Unmarshal(body, &p)
+				return &p, err
+			},
+			expect: &Person{ID: "1", Name: "A", Friends: []*Person{
+				{ID: "2", Name: "B", Friends: []*Person{
+					{ID: "3", Name: "C", Friends: []*Person{{ID: "2"}}},
+				}},
+			}},
+		}, {
+			description: "orphan included, partial linkage allowed",
+			given:       articleWithIncludeOnlyBody,
+			do: func(body []byte) (any, error) {
+				var a Article
+				err := Unmarshal(body, &a, UnmarshalAllowPartialLinkage(nil))
+				return &a, err
+			},
+			expect: &articleA,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual, err := tc.do([]byte(tc.given))
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestUnmarshalRelationshipIDs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expect      *ArticleRelatedIDs
+		expectIs    error
+	}{
+		{
+			description: "to-one and to-many",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"}},"comments":{"data":[{"id":"1","type":"comments"},{"id":"2","type":"comments"}]}}}}`,
+			expect:      &ArticleRelatedIDs{ID: "1", Title: "A", AuthorID: "1", CommentIDs: []string{"1", "2"}},
+		}, {
+			description: "compound document",
+			given:       articleRelatedCommentsNestedWithIncludeBody,
+			expect:      &ArticleRelatedIDs{ID: "1", Title: "A", CommentIDs: []string{"1"}},
+		}, {
+			description: "wrong type",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"comments":{"data":[{"id":"1","type":"author"}]}}}}`,
+			expectIs:    &TypeError{},
+		}, {
+			description: "wrong cardinality",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":[{"id":"1","type":"author"}]}}}}`,
+			expectIs:    &TypeError{},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var actual ArticleRelatedIDs
+			err := Unmarshal([]byte(tc.given), &actual)
+			if tc.expectIs != nil {
+				var te *TypeError
+				is.Equal(t, true, errors.As(err, &te))
+				objects := ErrorObjects(err)
+				is.MustEqual(t, 1, len(objects))
+				is.Equal(t, CodeInvalidRelationship, objects[0].Code)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &actual)
+		})
+	}
+}
+
+func TestUnmarshalPatch(t *testing.T) {
+	t.Parallel()
+
+	type article struct {
+		ID       string          `jsonapi:"primary,articles"`
+		Title    string          `jsonapi:"attribute" json:"title"`
+		SubTitle string          `jsonapi:"attribute" json:"subtitle"`
+		Info     *ArticleInfo    `jsonapi:"attribute" json:"info"`
+		Author   *Author         `jsonapi:"relationship" json:"author"`
+		Comments []*Comment      `jsonapi:"relationship" json:"comments"`
+		Meta     *ArticleMetrics `jsonapi:"meta"`
+		Extra    map[string]int  `jsonapi:"extras"`
+	}
+	existing := func() *article {
+		return &article{
+			ID:       "1",
+			Title:    "A",
+			SubTitle: "B",
+			Info:     &ArticleInfo{Tags: []string{"a"}, IsPublic: true},
+			Author:   &Author{ID: "1"},
+			Comments: []*Comment{{ID: "1"}},
+			Meta:     &ArticleMetrics{Views: 1},
+			Extra:    map[string]int{"stars": 1},
+		}
+	}
+
+	tests := []struct {
+		description string
+		given       string
+		expect      func(a *article)
+		expectError error
+	}{
+		{
+			description: "attribute",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"C"}}}`,
+			expect:      func(a *article) { a.Title = "C" },
+		}, {
+			description: "attribute replaced rather than merged",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"info":{"tags":["b"]}}}}`,
+			expect:      func(a *article) { a.Info = &ArticleInfo{Tags: []string{"b"}} },
+		}, {
+			description: "null attribute",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"info":null}}}`,
+			expect:      func(a *article) { a.Info = nil },
+		}, {
+			description: "unknown attribute added to extras",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"likes":2}}}`,
+			expect:      func(a *article) { a.Extra["likes"] = 2 },
+		}, {
+			description: "relationship",
+			given:       `{"data":{"id":"1","type":"articles","relationships":{"author":{"data":{"id":"2","type":"author"}}}}}`,
+			expect:      func(a *article) { a.Author = &Author{ID: "2"} },
+		}, {
+			description: "null and empty relationships",
+			given:       `{"data":{"id":"1","type":"articles","relationships":{"author":{"data":null},"comments":{"data":[]}}}}`,
+			expect: func(a *article) {
+				a.Author = nil
+				a.Comments = nil
+			},
+		}, {
+			description: "relationship without data",
+			given:       `{"data":{"id":"1","type":"articles","relationships":{"author":{"links":{"related":"http://example.com/articles/1/author"}}}}}`,
+			expect:      func(*article) {},
+		}, {
+			description: "meta",
+			given:       `{"data":{"id":"1","type":"articles","meta":{"reads":2}}}`,
+			expect:      func(a *article) { a.Meta = &ArticleMetrics{Reads: 2} },
+		}, {
+			description: "many resource objects",
+			given:       `{"data":[{"id":"1","type":"articles"}]}`,
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrInvalidPatchData},
+		}, {
+			description: "null data",
+			given:       `{"data":null}`,
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrInvalidPatchData},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual := existing()
+			err := UnmarshalPatch([]byte(tc.given), actual)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				is.Equal(t, true, errors.Is(err, ErrInvalidPatchData))
+				is.Equal(t, existing(), actual)
+				return
+			}
+			is.MustNoError(t, err)
+
+			expect := existing()
+			tc.expect(expect)
+			is.Equal(t, expect, actual)
+		})
+	}
+}
+
+func TestUnmarshalPatchMemberNames(t *testing.T) {
+	t.Parallel()
+
+	type article struct {
+		ID     string       `jsonapi:"primary,articles"`
+		Info   *ArticleInfo `jsonapi:"attribute" json:"info"`
+		Labels []string     `jsonapi:"attribute" json:",omitempty"`
+	}
+
+	tests := []struct {
+		description string
+		given       string
+		opts        []UnmarshalOption
+		expect      *article
+	}{
+		{
+			description: "attribute differing in case",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"Info":{"tags":["b"]}}}}`,
+			expect:      &article{ID: "1", Info: &ArticleInfo{Tags: []string{"b"}}, Labels: []string{"a"}},
+		}, {
+			description: "untagged attribute",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"labels":["b"]}}}`,
+			expect:      &article{ID: "1", Info: &ArticleInfo{Tags: []string{"a"}, IsPublic: true}, Labels: []string{"b"}},
+		}, {
+			description: "attribute named with naming convention",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"labels":["b"]}}}`,
+			opts:        []UnmarshalOption{UnmarshalNamingConvention(SnakeCase)},
+			expect:      &article{ID: "1", Info: &ArticleInfo{Tags: []string{"a"}, IsPublic: true}, Labels: []string{"b"}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			actual := &article{ID: "1", Info: &ArticleInfo{Tags: []string{"a"}, IsPublic: true}, Labels: []string{"a"}}
+			is.MustNoError(t, UnmarshalPatch([]byte(tc.given), actual, tc.opts...))
+			is.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		opts        []UnmarshalOption
+		expectError error
+	}{
+		{
+			description: "null data",
+			given:       nullDataBody,
+		}, {
+			description: "resource object",
+			given:       articleABody,
+		}, {
+			description: "resource object without id",
+			given:       articleANoIDBody,
+		}, {
+			description: "compound document",
+			given:       articleRelatedCompleteWithIncludeBody,
+		}, {
+			description: "errors",
+			given:       errorsComplexSliceManyBody,
+		}, {
+			description: "meta only",
+			given:       `{"meta":{"count":1}}`,
+		}, {
+			description: "invalid json",
+			given:       `{"data":`,
+			expectError: errors.New("unexpected end of JSON input"),
+		}, {
+			description: "empty document",
+			given:       `{}`,
+			expectError: ErrMissingDataField,
+		}, {
+			description: "data and errors",
+			given:       `{"data":null,"errors":[{"title":"T"}]}`,
+			expectError: ErrDataAndErrorsFields,
+		}, {
+			description: "missing type",
+			given:       `{"data":[{"id":"1","type":"articles"},{"id":"2"}]}`,
+			expectError: ErrMissingTypeField,
+		}, {
+			description: "missing relationship type",
+			given:       `{"data":{"id":"1","type":"articles","relationships":{"author":{"data":{"id":"1"}}}}}`,
+			expectError: ErrMissingTypeField,
+		}, {
+			description: "invalid member name",
+			given:       authorWithInvalidAttributeNameBody,
+			expectError: &MemberNameValidationError{MemberName: "na%me"},
+		}, {
+			description: "invalid member name, validation disabled",
+			given:       authorWithInvalidAttributeNameBody,
+			opts:        []UnmarshalOption{UnmarshalDisableNameValidation()},
+		}, {
+			description: "partial linkage",
+			given:       articleWithIncludeOnlyBody,
+			expectError: &PartialLinkageError{Resources: []ResourceIdentifier{{Type: "author", ID: "1"}}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			err := Verify([]byte(tc.given), tc.opts...)
+			if tc.expectError == nil {
+				is.MustNoError(t, err)
+				return
+			}
+			is.EqualError(t, tc.expectError, err)
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	t.Parallel()
+
+	notFound := http.StatusNotFound
+
+	tests := []struct {
+		description string
+		given       string
+		expect      []*Error
+		expectError error
+	}{
+		{
+			description: "error document",
+			given:       `{"errors":[{"status":"404","title":"Not Found"},{"code":"gone"}],"meta":{"requestId":"1"}}`,
+			expect:      []*Error{{Status: &notFound, Title: "Not Found"}, {Code: "gone"}},
+		}, {
+			description: "no error objects",
+			given:       `{"errors":[]}`,
+			expect:      []*Error{},
+		}, {
+			description: "data and errors",
+			given:       `{"data":null,"errors":[{"title":"T"}]}`,
+			expectError: &DocumentError{Code: CodeInvalidData, Pointer: "/data", Err: ErrDataAndErrorsFields},
+		}, {
+			description: "not an error document",
+			given:       articleABody,
+			expectError: &DocumentError{Code: CodeMissingData, Err: ErrMissingErrorsField},
+		}, {
+			description: "invalid json",
+			given:       `{"errors":`,
+			expectError: errors.New("unexpected end of JSON input"),
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			errs, err := UnmarshalErrors([]byte(tc.given))
+			is.EqualError(t, tc.expectError, err)
+			is.Equal(t, tc.expect, errs)
+		})
+	}
+
+	var meta map[string]any
+	_, err := UnmarshalErrors([]byte(`{"errors":[{"title":"T"}],"meta":{"requestId":"1"}}`), UnmarshalMeta(&meta))
+	is.MustNoError(t, err)
+	is.Equal(t, map[string]any{"requestId": "1"}, meta)
+}
+
+func TestUnmarshalAllowPartialLinkage(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"relationships":{"author":{"data":{"id":"1","type":"author"}}}},"included":[{"id":"1","type":"author","attributes":{"name":"A"}},{"id":"2","type":"author","attributes":{"name":"B"}}]}`
+
+	var unlinked *PartialLinkageError
+	var a ArticleRelated
+	err := Unmarshal([]byte(body), &a, UnmarshalAllowPartialLinkage(func(err *PartialLinkageError) {
+		unlinked = err
+	}))
+	is.MustNoError(t, err)
+	is.Equal(t, &ArticleRelated{ID: "1", Title: "A", Author: &authorA}, &a)
+	is.Equal(t, &PartialLinkageError{Resources: []ResourceIdentifier{{Type: "author", ID: "2"}}}, unlinked)
+
+	err = Verify([]byte(articleWithIncludeOnlyBody))
+	var ple *PartialLinkageError
+	is.MustEqual(t, true, errors.As(err, &ple))
+	is.Equal(t, []ResourceIdentifier{{Type: "author", ID: "1"}}, ple.Resources)
+
+	is.MustNoError(t, Verify([]byte(articleWithIncludeOnlyBody), UnmarshalAllowPartialLinkage(nil)))
+}
+
+func TestUnmarshalIdentifierMeta(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expect      *ArticleTagged
+		expectError string
+	}{
+		{
+			description: "resource linkage",
+			given:       `{"data":{"type":"articles","id":"1","relationships":{"tags":{"data":[{"type":"tags","id":"1","meta":{"assignedAt":"1989-06-15"}},{"type":"tags","id":"2"}]},"featuredTags":{"data":[{"type":"tags","id":"2","meta":{"assignedAt":"1989-06-16"}}]}}}}`,
+			expect: &ArticleTagged{
+				ID:         "1",
+				Tags:       []*Tag{{ID: "1"}, {ID: "2"}},
+				TagIDs:     []string{"2"},
+				AssignedAt: map[string]string{"1": "1989-06-15", "2": "1989-06-16"},
+			},
+		}, {
+			description: "included resources",
+			given:       `{"data":{"type":"articles","id":"1","relationships":{"tags":{"data":[{"type":"tags","id":"1","meta":{"assignedAt":"1989-06-15"}}]}}},"included":[{"type":"tags","id":"1","attributes":{"name":"A"},"meta":{"count":1}}]}`,
+			expect: &ArticleTagged{
+				ID:         "1",
+				Tags:       []*Tag{{ID: "1", Name: "A"}},
+				AssignedAt: map[string]string{"1": "1989-06-15"},
+			},
+		}, {
+			description: "invalid meta",
+			given:       `{"data":{"type":"articles","id":"1","relationships":{"tags":{"data":[{"type":"tags","id":"1","meta":{"assignedAt":1}}]}}}}`,
+			expectError: "/data/relationships/tags",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var actual ArticleTagged
+			err := Unmarshal([]byte(tc.given), &actual)
+			if tc.expectError != "" {
+				objects := ErrorObjects(err)
+				is.MustEqual(t, 1, len(objects))
+				is.Equal(t, CodeInvalidRelationship, objects[0].Code)
+				is.Equal(t, tc.expectError, objects[0].Source.Pointer)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &actual)
+		})
+	}
+
+	var article ArticleTagged
+	err := UnmarshalRef([]byte(`{"data":[{"type":"tags","id":"1","meta":{"assignedAt":"1989-06-15"}}]}`), &article, "tags")
+	is.MustNoError(t, err)
+	is.Equal(t, map[string]string{"1": "1989-06-15"}, article.AssignedAt)
+}
+
+func TestUnmarshalLinks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description   string
+		given         string
+		expect        *ArticleLinksUnmarshaled
+		expectCode    string
+		expectPointer string
+	}{
+		{
+			description: "no links",
+			given:       `{"data":{"type":"articles","id":"1"}}`,
+			expect:      &ArticleLinksUnmarshaled{ID: "1"},
+		}, {
+			description: "resource and relationship links",
+			given:       `{"data":{"type":"articles","id":"1","links":{"self":"https://example.com/articles/1"},"relationships":{"comments":{"data":[{"type":"comments","id":"1"}],"links":{"related":"https://example.com/articles/1/comments"}},"author":{"links":{"related":{"href":"https://example.com/articles/1/author"}}},"tags":{"data":[]}}}}`,
+			expect: &ArticleLinksUnmarshaled{
+				ID:       "1",
+				Comments: []*Comment{{ID: "1"}},
+				Self:     "https://example.com/articles/1",
+				RelatedLinks: map[string]string{
+					"author":   "https://example.com/articles/1/author",
+					"comments": "https://example.com/articles/1/comments",
+				},
+			},
+		}, {
+			description:   "invalid resource links",
+			given:         `{"data":{"type":"articles","id":"1","links":{"related":"https://example.com/articles/1/comments"}}}`,
+			expectCode:    CodeInvalidResource,
+			expectPointer: "/data/links",
+		}, {
+			description:   "invalid relationship links",
+			given:         `{"data":{"type":"articles","id":"1","relationships":{"comments":{"links":{"self":"https://example.com/articles/1/relationships/comments"}}}}}`,
+			expectCode:    CodeInvalidRelationship,
+			expectPointer: "/data/relationships/comments/links",
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var actual ArticleLinksUnmarshaled
+			err := Unmarshal([]byte(tc.given), &actual)
+			if tc.expectCode != "" {
+				objects := ErrorObjects(err)
+				is.MustEqual(t, 1, len(objects))
+				is.Equal(t, tc.expectCode, objects[0].Code)
+				is.Equal(t, tc.expectPointer, objects[0].Source.Pointer)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &actual)
+		})
+	}
+
+	// the links of every resource object of a collection are unmarshaled
+	var articles []*ArticleLinksUnmarshaled
+	err := Unmarshal([]byte(`{"data":[{"type":"articles","id":"1","links":{"self":"https://example.com/articles/1"}},{"type":"articles","id":"2","links":{"self":"https://example.com/articles/2"}}]}`), &articles)
+	is.MustNoError(t, err)
+	is.MustEqual(t, 2, len(articles))
+	is.Equal(t, "https://example.com/articles/2", articles[1].Self)
+}
+
+func TestUnmarshalReadOnlyWriteOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		opts        []UnmarshalOption
+		expect      *Account
+		expectError error
+	}{
+		{
+			description: "write-only attribute",
+			given:       `{"data":{"type":"accounts","id":"1","attributes":{"name":"A","password":"secret"}}}`,
+			expect:      &Account{ID: "1", Name: "A", Password: "secret"},
+		}, {
+			description: "read-only attribute",
+			given:       `{"data":{"type":"accounts","id":"1","attributes":{"name":"A","createdAt":"1989-06-15"}}}`,
+			expectError: &FieldError{Code: CodeInvalidAttribute, Member: "createdAt", Pointer: "/data/attributes/createdAt", Err: ErrReadOnlyAttribute},
+		}, {
+			description: "read-only attribute differing in case",
+			given:       `{"data":{"type":"accounts","id":"1","attributes":{"name":"A","CREATEDAT":"1989-06-15"}}}`,
+			expectError: &FieldError{Code: CodeInvalidAttribute, Member: "CREATEDAT", Pointer: "/data/attributes/CREATEDAT", Err: ErrReadOnlyAttribute},
+		}, {
+			description: "read-only attribute differing in case ignored",
+			given:       `{"data":{"type":"accounts","id":"1","attributes":{"name":"A","CreatedAt":"1989-06-15"}}}`,
+			opts:        []UnmarshalOption{UnmarshalIgnoreReadOnly()},
+			expect:      &Account{ID: "1", Name: "A"},
+		}, {
+			description: "read-only attribute ignored",
+			given:       `{"data":{"type":"accounts","id":"1","attributes":{"name":"A","createdAt":"1989-06-15"}}}`,
+			opts:        []UnmarshalOption{UnmarshalIgnoreReadOnly()},
+			expect:      &Account{ID: "1", Name: "A"},
+		}, {
+			description: "only read-only attribute ignored",
+			given:       `{"data":{"type":"accounts","id":"1","attributes":{"createdAt":"1989-06-15"}}}`,
+			opts:        []UnmarshalOption{UnmarshalIgnoreReadOnly()},
+			expect:      &Account{ID: "1"},
+		}, {
+			description: "read-only attribute in client mode",
+			given:       `{"data":{"type":"accounts","id":"1","attributes":{"name":"A","createdAt":"1989-06-15"}}}`,
+			opts:        []UnmarshalOption{UnmarshalClientMode()},
+			expect:      &Account{ID: "1", Name: "A", CreatedAt: "1989-06-15"},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var a Account
+			err := Unmarshal([]byte(tc.given), &a, tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, &a)
+		})
+	}
+}
+
+func TestUnmarshalUseNumber(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":{"type":"orders","id":"1","attributes":{"total":19.99,"details":{"customerId":9007199254740993}}}}`
+
+	var order Order
+	is.MustNoError(t, Unmarshal([]byte(body), &order, UnmarshalUseNumber()))
+	is.Equal(t, &Order{
+		ID:      "1",
+		Total:   json.Number("19.99"),
+		Details: map[string]any{"customerId": json.Number("9007199254740993")},
+	}, &order)
+
+	order = Order{}
+	is.MustNoError(t, Unmarshal([]byte(body), &order))
+	is.Equal(t, float64(9007199254740992), order.Details["customerId"])
+}
+
+func TestUnmarshalInvalidTarget(t *testing.T) {
+	t.Parallel()
+
+	var nilArticle *Article
+	for _, v := range []any{nil, nilArticle, Article{}, []*Article{}} {
+		err := Unmarshal([]byte(articleABody), v)
+		is.Equal(t, true, errors.Is(err, ErrUnmarshalInvalidTarget))
+	}
+
+	err := UnmarshalRef([]byte(`{"data":{"type":"author","id":"1"}}`), nilArticle, "author")
+	is.Equal(t, true, errors.Is(err, ErrUnmarshalInvalidTarget))
+}
+
+func TestUnmarshalType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expect      TenantArticle
+		expectError error
+	}{
+		{
+			description: "default type",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"title":"A"}}}`,
+			expect:      TenantArticle{ID: "1", Title: "A"},
+		}, {
+			description: "runtime type",
+			given:       `{"data":{"type":"acme-articles","id":"1","attributes":{"title":"A"}}}`,
+			expect:      TenantArticle{ID: "1", Tenant: "acme", Title: "A"},
+		}, {
+			description: "rejected type",
+			given:       `{"data":{"type":"comments","id":"1"}}`,
+			expectError: &TypeError{Actual: "comments", Expected: []string{"articles", "<tenant>-articles"}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var a TenantArticle
+			err := Unmarshal([]byte(tc.given), &a)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				var fe *FieldError
+				is.MustEqual(t, true, errors.As(err, &fe))
+				is.Equal(t, "/data/type", fe.Pointer)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, a)
+		})
+	}
+}
+
+func TestUnmarshalTypeAliases(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		do          func(data []byte, opts ...UnmarshalOption) (any, error)
+		opts        []UnmarshalOption
+		expect      any
+		expectError error
+	}{
+		{
+			description: "conflicting type",
+			given:       `{"data":{"type":"people","id":"1","attributes":{"title":"A"}}}`,
+			do: func(data []byte, opts ...UnmarshalOption) (any, error) {
+				var a Article
+				err := Unmarshal(data, &a, opts...)
+				return a, err
+			},
+			expectError: &TypeError{Actual: "people", Expected: []string{"articles"}},
+		}, {
+			description: "alias",
+			given:       `{"data":{"type":"posts","id":"1","attributes":{"title":"A"}}}`,
+			do: func(data []byte, opts ...UnmarshalOption) (any, error) {
+				var a Article
+				err := Unmarshal(data, &a, opts...)
+				return a, err
+			},
+			opts:   []UnmarshalOption{UnmarshalTypeAliases("articles", "posts", "entries")},
+			expect: articleA,
+		}, {
+			description: "conflicting type with aliases",
+			given:       `{"data":{"type":"people","id":"1","attributes":{"title":"A"}}}`,
+			do: func(data []byte, opts ...UnmarshalOption) (any, error) {
+				var a Article
+				err := Unmarshal(data, &a, opts...)
+				return a, err
+			},
+			opts:        []UnmarshalOption{UnmarshalTypeAliases("articles", "posts"), UnmarshalTypeAliases("articles", "entries")},
+			expectError: &TypeError{Actual: "people", Expected: []string{"articles", "posts", "entries"}},
+		}, {
+			description: "alias of related type",
+			given:       `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":{"type":"people","id":"1"}}}}}`,
+			do: func(data []byte, opts ...UnmarshalOption) (any, error) {
+				var a ArticleRelated
+				err := Unmarshal(data, &a, opts...)
+				return a, err
+			},
+			opts:   []UnmarshalOption{UnmarshalTypeAliases("author", "people"), UnmarshalLinkageOnly()},
+			expect: ArticleRelated{ID: "1", Author: &Author{ID: "1"}},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			got, err := tc.do([]byte(tc.given), tc.opts...)
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				is.Equal(t, true, errors.Is(err, ErrTypeConflict))
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, got)
+		})
+	}
+}
+
+func TestUnmarshalLinkObjects(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":null,"links":{"self":{"href":"https://example.com/articles/1","rel":"self","describedby":{"href":"https://example.com/schemas/articles","hreflang":["en","fr"]},"title":"Article","type":"application/vnd.api+json","hreflang":"en"},"next":"https://example.com/articles?page=3","last":{"href":"https://example.com/articles?page=9"}}}`
+
+	var d document
+	is.MustNoError(t, unmarshalJSON([]byte(body), &d))
+	is.Equal(t, &Link{
+		Self: &LinkObject{
+			Href:        "https://example.com/articles/1",
+			Rel:         "self",
+			DescribedBy: &LinkObject{Href: "https://example.com/schemas/articles", HrefLang: HrefLang{"en", "fr"}},
+			Title:       "Article",
+			Type:        "application/vnd.api+json",
+			HrefLang:    HrefLang{"en"},
+		},
+		Next: "https://example.com/articles?page=3",
+		Last: &LinkObject{Href: "https://example.com/articles?page=9"},
+	}, d.Links)
+	is.Equal(t, "https://example.com/articles?page=9", LinkHref(d.Links.Last))
+
+	var l Link
+	err := json.Unmarshal([]byte(`{"self":1}`), &l)
+	is.MustError(t, err)
+}
+
+func TestLinkExtra(t *testing.T) {
+	t.Parallel()
+
+	l := (&Link{}).
+		Set("self", "https://example.com/articles/1").
+		Set("x-vendor", "https://example.com/v").
+		Set("canonical", &LinkObject{Href: "https://example.com/a/1"})
+	is.Equal(t, any("https://example.com/articles/1"), l.Self)
+	is.Equal(t, map[string]any{"x-vendor": "https://example.com/v", "canonical": &LinkObject{Href: "https://example.com/a/1"}}, l.Extra)
+
+	// marshaling is deterministic, with extra links sorted by name after the others
+	for i := 0; i < 10; i++ {
+		b, err := json.Marshal(l)
+		is.MustNoError(t, err)
+		is.Equal(t, `{"self":"https://example.com/articles/1","canonical":{"href":"https://example.com/a/1"},"x-vendor":"https://example.com/v"}`, string(b))
+	}
+
+	var got Link
+	is.MustNoError(t, json.Unmarshal([]byte(`{"self":"https://example.com/articles/1","canonical":{"href":"https://example.com/a/1"},"x-vendor":"https://example.com/v","next":null}`), &got))
+	is.Equal(t, l, &got)
+	is.Equal(t, any("https://example.com/v"), got.Get("x-vendor"))
+	is.Equal(t, nil, got.Get("next"))
+
+	got.Set("x-vendor", nil)
+	is.Equal(t, map[string]any{"canonical": &LinkObject{Href: "https://example.com/a/1"}}, got.Extra)
+}
+
+func TestUnmarshalJSONAPIObject(t *testing.T) {
+	t.Parallel()
+
+	var (
+		a Article
+		o JSONAPIObject
+	)
+	body := `{"data":{"id":"1","type":"articles","attributes":{"title":"A"}},"jsonapi":{"version":"1.1","ext":["https://jsonapi.org/ext/atomic"],"profile":["https://example.com/profiles/timestamps"],"meta":{"foo":"bar"}}}`
+	is.MustNoError(t, Unmarshal([]byte(body), &a, UnmarshalJSONAPIObject(&o)))
+	is.Equal(t, articleA, a)
+	is.Equal(t, JSONAPIObject{
+		Version: "1.1",
+		Ext:     []string{"https://jsonapi.org/ext/atomic"},
+		Profile: []string{"https://example.com/profiles/timestamps"},
+		Meta:    map[string]any{"foo": "bar"},
+	}, o)
+
+	o = JSONAPIObject{Version: "1.0"}
+	is.MustNoError(t, Unmarshal([]byte(articleABody), &a, UnmarshalJSONAPIObject(&o)))
+	is.Equal(t, JSONAPIObject{Version: "1.0"}, o)
+}
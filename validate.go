@@ -0,0 +1,677 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Violation is a violation of the structural rules of JSON:API found by Validate.
+type Violation struct {
+	// Pointer is a JSON pointer (RFC 6901) to the offending member of the document, or empty if
+	// the violation concerns the document as a whole.
+	Pointer string
+
+	// Message describes the violation.
+	Message string
+}
+
+// String implements the fmt.Stringer interface.
+func (v Violation) String() string {
+	if v.Pointer == "" {
+		return v.Message
+	}
+	return v.Pointer + ": " + v.Message
+}
+
+// validator holds the configuration and state of Validate.
+type validator struct {
+	version                  string
+	clientMode               bool
+	memberNameValidationMode memberNameValidationMode
+	relaxedMemberClasses     memberClasses
+	violations               []Violation
+}
+
+// ValidateOption allows for configuration of Validate.
+type ValidateOption func(v *validator)
+
+// ValidateVersion sets the version of the specification ("1.0" or "1.1") documents are validated
+// against. By default, the version given by the document's jsonapi object is used, or 1.0 if there
+// is none.
+func ValidateVersion(version string) ValidateOption {
+	return func(v *validator) {
+		v.version = version
+	}
+}
+
+// ValidateClientMode validates documents sent by clients, whose primary data may lack an id when
+// it represents a new resource to be created, as described by
+// https://jsonapi.org/format/#crud-creating.
+func ValidateClientMode() ValidateOption {
+	return func(v *validator) {
+		v.clientMode = true
+	}
+}
+
+// ValidateStrictNameValidation enables member name validation that is more strict than default,
+// following the guidelines from https://jsonapi.org/recommendations/#naming.
+func ValidateStrictNameValidation() ValidateOption {
+	return func(v *validator) {
+		v.memberNameValidationMode = strictValidation
+	}
+}
+
+// ValidateRelaxNameValidation exempts the given classes of member names from strict member name
+// validation, while keeping it for every other member of the document.
+func ValidateRelaxNameValidation(classes ...MemberClass) ValidateOption {
+	return func(v *validator) {
+		v.relaxedMemberClasses = newMemberClasses(classes)
+	}
+}
+
+// Validate checks that data is a document conforming to the structural rules of JSON:API 1.0 or
+// 1.1, and returns every violation found. A document is valid if no violations are returned.
+// Violations are ordered deterministically rather than by their position in the document: those of
+// an object come before those of its members, which are visited in the order of their names, and
+// violations of the full linkage of compound documents come last.
+//
+// Unlike Verify, Validate doesn't stop at the first violation, and checks the complete structure
+// of the document: the allowed members and their types, member names, resource objects and
+// resource identifier objects, links, error objects, the uniqueness of resources, and the full
+// linkage of compound documents. This is useful in tests and gateways.
+func Validate(data []byte, opts ...ValidateOption) []Violation {
+	v := &validator{violations: make([]Violation, 0)}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	if !json.Valid(data) {
+		v.add("", "document is not valid json")
+		return v.violations
+	}
+
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		v.add("", "document is not valid json")
+		return v.violations
+	}
+
+	v.document(doc)
+	return v.violations
+}
+
+// add adds a violation of the member at the given pointer.
+func (v *validator) add(pointer, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// sortedMembers returns the member names of the given object in sorted order, so that violations
+// are reported deterministically.
+func sortedMembers(obj map[string]any) []string {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pointerTo returns the JSON pointer to the given member of the value at pointer.
+func pointerTo(pointer, member string) string {
+	return pointer + "/" + escapePointerToken(member)
+}
+
+// object returns the given value as object, adding a violation if it isn't one.
+func (v *validator) object(pointer string, val any, what string) (map[string]any, bool) {
+	obj, ok := val.(map[string]any)
+	if !ok {
+		v.add(pointer, "%s must be an object", what)
+	}
+	return obj, ok
+}
+
+// str checks that the given member of obj is a string if present, returning it.
+func (v *validator) str(pointer string, obj map[string]any, member string) (string, bool) {
+	val, ok := obj[member]
+	if !ok {
+		return "", false
+	}
+	s, ok := val.(string)
+	if !ok {
+		v.add(pointerTo(pointer, member), "%s must be a string", member)
+	}
+	return s, ok
+}
+
+// isExtensionMember returns true if name is the name of a member defined by an extension, or an
+// @-member, which are allowed anywhere by JSON:API 1.1.
+func (v *validator) isExtensionMember(name string) bool {
+	if v.version != "1.1" {
+		return false
+	}
+	return strings.HasPrefix(name, "@") || strings.Contains(name, ":")
+}
+
+// members adds violations for the members of obj which are not among the allowed ones.
+func (v *validator) members(pointer string, obj map[string]any, what string, allowed ...string) {
+	for _, name := range sortedMembers(obj) {
+		if v.isExtensionMember(name) {
+			continue
+		}
+		found := false
+		for _, a := range allowed {
+			if name == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.add(pointerTo(pointer, name), "%s must not contain the member %q", what, name)
+		}
+	}
+}
+
+// names adds violations for every invalid member name within the given value, recursively.
+func (v *validator) names(pointer string, val any, mode memberNameValidationMode) {
+	switch val := val.(type) {
+	case map[string]any:
+		for _, name := range sortedMembers(val) {
+			p := pointerTo(pointer, name)
+			if !v.isExtensionMember(name) && !isValidMemberName(name, mode) {
+				v.add(p, "invalid member name %q", name)
+			}
+			v.names(p, val[name], mode)
+		}
+	case []any:
+		for i, elem := range val {
+			v.names(fmt.Sprintf("%s/%d", pointer, i), elem, mode)
+		}
+	}
+}
+
+func (v *validator) document(doc any) {
+	top, ok := v.object("", doc, "a document")
+	if !ok {
+		return
+	}
+
+	if v.version == "" {
+		v.version = "1.0"
+		if ja, ok := top["jsonapi"].(map[string]any); ok && ja["version"] == "1.1" {
+			v.version = "1.1"
+		}
+	}
+
+	_, hasData := top["data"]
+	_, hasErrors := top["errors"]
+	_, hasMeta := top["meta"]
+	_, hasIncluded := top["included"]
+	if !hasData && !hasErrors && !hasMeta {
+		v.add("", "a document must contain at least one of the members data, errors and meta")
+	}
+	if hasData && hasErrors {
+		v.add("", "the members data and errors must not coexist in the same document")
+	}
+	if hasIncluded && !hasData {
+		v.add("/included", "a document without data must not contain included")
+	}
+
+	v.members("", top, "a document", "data", "errors", "meta", "jsonapi", "links", "included")
+
+	for _, name := range sortedMembers(top) {
+		val := top[name]
+		p := pointerTo("", name)
+		switch name {
+		case "data":
+			switch data := val.(type) {
+			case nil:
+			case []any:
+				for i, ro := range data {
+					v.resourceObject(fmt.Sprintf("%s/%d", p, i), ro, v.clientMode)
+				}
+			default:
+				v.resourceObject(p, data, v.clientMode)
+			}
+		case "included":
+			included, ok := val.([]any)
+			if !ok {
+				v.add(p, "included must be an array")
+				continue
+			}
+			for i, ro := range included {
+				v.resourceObject(fmt.Sprintf("%s/%d", p, i), ro, false)
+			}
+		case "errors":
+			errs, ok := val.([]any)
+			if !ok {
+				v.add(p, "errors must be an array")
+				continue
+			}
+			for i, e := range errs {
+				v.errorObject(fmt.Sprintf("%s/%d", p, i), e)
+			}
+		case "meta":
+			v.meta(p, val)
+		case "links":
+			v.links(p, val)
+		case "jsonapi":
+			v.jsonAPI(p, val)
+		}
+	}
+
+	v.linkage(top)
+}
+
+// resourceObject validates the resource object at the given pointer. If newResource is true, the
+// resource object may lack an id.
+func (v *validator) resourceObject(pointer string, val any, newResource bool) {
+	ro, ok := v.object(pointer, val, "a resource object")
+	if !ok {
+		return
+	}
+
+	allowed := []string{"id", "type", "attributes", "relationships", "links", "meta"}
+	if v.version == "1.1" {
+		allowed = append(allowed, "lid")
+	}
+	v.members(pointer, ro, "a resource object", allowed...)
+
+	v.resourceType(pointer, ro)
+	v.resourceID(pointer, ro, newResource)
+
+	attributes := make(map[string]any)
+	if val, ok := ro["attributes"]; ok {
+		p := pointerTo(pointer, "attributes")
+		if attributes, ok = v.object(p, val, "attributes"); ok {
+			mode := v.relaxedMemberClasses.modeFor(AttributeMembers, v.memberNameValidationMode)
+			v.names(p, attributes, mode)
+			for _, name := range sortedMembers(attributes) {
+				switch name {
+				case "id", "type":
+					v.add(pointerTo(p, name), "attributes must not contain a member named %q", name)
+				case "relationships", "links":
+					v.add(pointerTo(p, name), "attributes must not contain the member %q", name)
+				}
+			}
+		}
+	}
+
+	if val, ok := ro["relationships"]; ok {
+		p := pointerTo(pointer, "relationships")
+		if relationships, ok := v.object(p, val, "relationships"); ok {
+			for _, name := range sortedMembers(relationships) {
+				rp := pointerTo(p, name)
+				if !v.isExtensionMember(name) && !isValidMemberName(name, v.memberNameValidationMode) {
+					v.add(rp, "invalid member name %q", name)
+				}
+				switch _, ok := attributes[name]; {
+				case ok:
+					v.add(rp, "a resource object must not contain an attribute and a relationship named %q", name)
+				case name == "id" || name == "type":
+					v.add(rp, "relationships must not contain a member named %q", name)
+				}
+				v.relationship(rp, relationships[name])
+			}
+		}
+	}
+
+	if val, ok := ro["links"]; ok {
+		v.links(pointerTo(pointer, "links"), val)
+	}
+	if val, ok := ro["meta"]; ok {
+		v.meta(pointerTo(pointer, "meta"), val)
+	}
+}
+
+// resourceType validates the type member of the resource object or resource identifier object at
+// the given pointer.
+func (v *validator) resourceType(pointer string, obj map[string]any) {
+	if _, ok := obj["type"]; !ok {
+		v.add(pointer, "a resource must contain the member type")
+		return
+	}
+	t, ok := v.str(pointer, obj, "type")
+	if !ok {
+		return
+	}
+	if t == "" {
+		v.add(pointerTo(pointer, "type"), "type must not be empty")
+		return
+	}
+	if !isValidMemberName(t, v.relaxedMemberClasses.modeFor(TypeMembers, v.memberNameValidationMode)) {
+		v.add(pointerTo(pointer, "type"), "invalid member name %q", t)
+	}
+}
+
+// resourceID validates the id and lid members of the resource object or resource identifier object
+// at the given pointer. If newResource is true, the resource may lack an id.
+func (v *validator) resourceID(pointer string, obj map[string]any, newResource bool) {
+	v.str(pointer, obj, "id")
+	_, hasLID := obj["lid"]
+	if hasLID && v.version == "1.1" {
+		v.str(pointer, obj, "lid")
+	}
+
+	if _, hasID := obj["id"]; !hasID && !newResource && !(hasLID && v.version == "1.1") {
+		v.add(pointer, "a resource must contain the member id")
+	}
+}
+
+// resourceIdentifier validates the resource identifier object at the given pointer.
+func (v *validator) resourceIdentifier(pointer string, val any) {
+	ri, ok := v.object(pointer, val, "a resource identifier object")
+	if !ok {
+		return
+	}
+
+	allowed := []string{"id", "type", "meta"}
+	if v.version == "1.1" {
+		allowed = append(allowed, "lid")
+	}
+	v.members(pointer, ri, "a resource identifier object", allowed...)
+
+	v.resourceType(pointer, ri)
+	v.resourceID(pointer, ri, false)
+	if val, ok := ri["meta"]; ok {
+		v.meta(pointerTo(pointer, "meta"), val)
+	}
+}
+
+// relationship validates the relationship object at the given pointer.
+func (v *validator) relationship(pointer string, val any) {
+	rel, ok := v.object(pointer, val, "a relationship object")
+	if !ok {
+		return
+	}
+
+	v.members(pointer, rel, "a relationship object", "data", "links", "meta")
+
+	_, hasData := rel["data"]
+	_, hasLinks := rel["links"]
+	_, hasMeta := rel["meta"]
+	if !hasData && !hasLinks && !hasMeta {
+		v.add(pointer, "a relationship object must contain at least one of the members data, links and meta")
+	}
+
+	if hasData {
+		p := pointerTo(pointer, "data")
+		switch data := rel["data"].(type) {
+		case nil:
+		case []any:
+			for i, ri := range data {
+				v.resourceIdentifier(fmt.Sprintf("%s/%d", p, i), ri)
+			}
+		default:
+			v.resourceIdentifier(p, data)
+		}
+	}
+	if hasLinks {
+		v.links(pointerTo(pointer, "links"), rel["links"])
+	}
+	if hasMeta {
+		v.meta(pointerTo(pointer, "meta"), rel["meta"])
+	}
+}
+
+// links validates the links object at the given pointer.
+func (v *validator) links(pointer string, val any) {
+	links, ok := v.object(pointer, val, "links")
+	if !ok {
+		return
+	}
+	for _, name := range sortedMembers(links) {
+		p := pointerTo(pointer, name)
+		if !v.isExtensionMember(name) && !isValidMemberName(name, v.memberNameValidationMode) {
+			v.add(p, "invalid member name %q", name)
+		}
+		v.link(p, links[name])
+	}
+}
+
+// link validates the link at the given pointer, which must be null, a string or a link object.
+func (v *validator) link(pointer string, val any) {
+	switch val := val.(type) {
+	case nil, string:
+		return
+	case map[string]any:
+		allowed := []string{"href", "meta"}
+		if v.version == "1.1" {
+			allowed = append(allowed, "rel", "describedby", "title", "type", "hreflang")
+		}
+		v.members(pointer, val, "a link object", allowed...)
+
+		if _, ok := val["href"]; !ok {
+			v.add(pointer, "a link object must contain the member href")
+		}
+		v.str(pointer, val, "href")
+		if v.version == "1.1" {
+			v.str(pointer, val, "rel")
+			v.str(pointer, val, "title")
+			v.str(pointer, val, "type")
+			if describedBy, ok := val["describedby"]; ok {
+				v.link(pointerTo(pointer, "describedby"), describedBy)
+			}
+			if hreflang, ok := val["hreflang"]; ok {
+				v.stringOrStrings(pointerTo(pointer, "hreflang"), hreflang, "hreflang")
+			}
+		}
+		if meta, ok := val["meta"]; ok {
+			v.meta(pointerTo(pointer, "meta"), meta)
+		}
+	default:
+		v.add(pointer, "a link must be null, a string or a link object")
+	}
+}
+
+// stringOrStrings validates that the value at the given pointer is a string or an array of strings.
+func (v *validator) stringOrStrings(pointer string, val any, what string) {
+	switch val := val.(type) {
+	case string:
+		return
+	case []any:
+		for _, s := range val {
+			if _, ok := s.(string); !ok {
+				v.add(pointer, "%s must be a string or an array of strings", what)
+				return
+			}
+		}
+	default:
+		v.add(pointer, "%s must be a string or an array of strings", what)
+	}
+}
+
+// meta validates the meta object at the given pointer.
+func (v *validator) meta(pointer string, val any) {
+	if meta, ok := v.object(pointer, val, "meta"); ok {
+		v.names(pointer, meta, v.memberNameValidationMode)
+	}
+}
+
+// jsonAPI validates the jsonapi object at the given pointer.
+func (v *validator) jsonAPI(pointer string, val any) {
+	ja, ok := v.object(pointer, val, "the jsonapi object")
+	if !ok {
+		return
+	}
+
+	allowed := []string{"version", "meta"}
+	if v.version == "1.1" {
+		allowed = append(allowed, "ext", "profile")
+	}
+	v.members(pointer, ja, "the jsonapi object", allowed...)
+
+	v.str(pointer, ja, "version")
+	if v.version == "1.1" {
+		for _, name := range []string{"ext", "profile"} {
+			val, ok := ja[name]
+			if !ok {
+				continue
+			}
+			uris, ok := val.([]any)
+			if !ok {
+				v.add(pointerTo(pointer, name), "%s must be an array of strings", name)
+				continue
+			}
+			for i, uri := range uris {
+				if _, ok := uri.(string); !ok {
+					v.add(fmt.Sprintf("%s/%d", pointerTo(pointer, name), i), "%s must be an array of strings", name)
+				}
+			}
+		}
+	}
+	if meta, ok := ja["meta"]; ok {
+		v.meta(pointerTo(pointer, "meta"), meta)
+	}
+}
+
+// errorObject validates the error object at the given pointer.
+func (v *validator) errorObject(pointer string, val any) {
+	e, ok := v.object(pointer, val, "an error object")
+	if !ok {
+		return
+	}
+
+	v.members(pointer, e, "an error object", "id", "links", "status", "code", "title", "detail", "source", "meta")
+
+	for _, name := range []string{"id", "status", "code", "title", "detail"} {
+		v.str(pointer, e, name)
+	}
+
+	if val, ok := e["links"]; ok {
+		p := pointerTo(pointer, "links")
+		if links, ok := v.object(p, val, "links"); ok {
+			allowed := []string{"about"}
+			if v.version == "1.1" {
+				allowed = append(allowed, "type")
+			}
+			v.members(p, links, "error links", allowed...)
+			for _, name := range sortedMembers(links) {
+				v.link(pointerTo(p, name), links[name])
+			}
+		}
+	}
+
+	if val, ok := e["source"]; ok {
+		p := pointerTo(pointer, "source")
+		if source, ok := v.object(p, val, "source"); ok {
+			allowed := []string{"pointer", "parameter"}
+			if v.version == "1.1" {
+				allowed = append(allowed, "header")
+			}
+			v.members(p, source, "source", allowed...)
+			if sp, ok := v.str(p, source, "pointer"); ok && sp != "" && !strings.HasPrefix(sp, "/") {
+				v.add(pointerTo(p, "pointer"), "pointer must be a JSON pointer")
+			}
+			v.str(p, source, "parameter")
+			v.str(p, source, "header")
+		}
+	}
+
+	if val, ok := e["meta"]; ok {
+		v.meta(pointerTo(pointer, "meta"), val)
+	}
+}
+
+// linkage validates that the resources of the given document are unique, and that every included
+// resource is linked to primary data, as defined by
+// https://jsonapi.org/format/#document-compound-documents.
+func (v *validator) linkage(top map[string]any) {
+	// the resource objects of the document by identifier, skipping malformed ones
+	identify := func(val any) (map[string]any, string, bool) {
+		ro, ok := val.(map[string]any)
+		if !ok {
+			return nil, "", false
+		}
+		t, ok := ro["type"].(string)
+		if !ok {
+			return nil, "", false
+		}
+		if id, ok := ro["id"].(string); ok {
+			return ro, resourceIdentifier(t, id), true
+		}
+		if lid, ok := ro["lid"].(string); ok && v.version == "1.1" {
+			return ro, resourceIdentifier(t, "lid:"+lid), true
+		}
+		return nil, "", false
+	}
+
+	seen := make(map[string]bool)
+	queue := make([]map[string]any, 0)
+	unique := func(pointer string, val any) (map[string]any, string, bool) {
+		ro, id, ok := identify(val)
+		if !ok {
+			return nil, "", false
+		}
+		if seen[id] {
+			v.add(pointer, "a compound document must not contain more than one resource object for each type and id")
+			return nil, "", false
+		}
+		seen[id] = true
+		return ro, id, true
+	}
+
+	switch data := top["data"].(type) {
+	case []any:
+		for i, val := range data {
+			if ro, _, ok := unique(fmt.Sprintf("/data/%d", i), val); ok {
+				queue = append(queue, ro)
+			}
+		}
+	case map[string]any:
+		if ro, _, ok := unique("/data", data); ok {
+			queue = append(queue, ro)
+		}
+	}
+
+	included, _ := top["included"].([]any)
+	byID := make(map[string]map[string]any, len(included))
+	pointers := make(map[string]string, len(included))
+	order := make([]string, 0, len(included))
+	for i, val := range included {
+		p := fmt.Sprintf("/included/%d", i)
+		if ro, id, ok := unique(p, val); ok {
+			byID[id] = ro
+			pointers[id] = p
+			order = append(order, id)
+		}
+	}
+
+	linked := make(map[string]bool)
+	for len(queue) > 0 {
+		ro := queue[0]
+		queue = queue[1:]
+
+		relationships, _ := ro["relationships"].(map[string]any)
+		for _, name := range sortedMembers(relationships) {
+			rel, _ := relationships[name].(map[string]any)
+			var linkage []any
+			switch data := rel["data"].(type) {
+			case []any:
+				linkage = data
+			case map[string]any:
+				linkage = []any{data}
+			}
+			for _, ri := range linkage {
+				_, id, ok := identify(ri)
+				if !ok || linked[id] {
+					continue
+				}
+				if iro, ok := byID[id]; ok {
+					linked[id] = true
+					queue = append(queue, iro)
+				}
+			}
+		}
+	}
+
+	for _, id := range order {
+		if !linked[id] {
+			v.add(pointers[id], "an included resource must be linked to primary data by a chain of relationships")
+		}
+	}
+}
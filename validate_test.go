@@ -0,0 +1,229 @@
+package jsonapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		opts        []ValidateOption
+		expect      []Violation
+	}{
+		{
+			description: "null data",
+			given:       nullDataBody,
+			expect:      []Violation{},
+		}, {
+			description: "resource object",
+			given:       articleABody,
+			expect:      []Violation{},
+		}, {
+			description: "resource objects with links and meta",
+			given:       articleRelatedCompleteBody,
+			expect:      []Violation{},
+		}, {
+			description: "compound document",
+			given:       articleRelatedCommentsNestedWithIncludeBody,
+			expect:      []Violation{},
+		}, {
+			description: "error document",
+			given:       errorsWithLinkObjectBody,
+			expect:      []Violation{},
+		}, {
+			description: "invalid json",
+			given:       `{"data":`,
+			expect:      []Violation{{Message: "document is not valid json"}},
+		}, {
+			description: "not an object",
+			given:       `[]`,
+			expect:      []Violation{{Message: "a document must be an object"}},
+		}, {
+			description: "no top-level members",
+			given:       `{}`,
+			expect:      []Violation{{Message: "a document must contain at least one of the members data, errors and meta"}},
+		}, {
+			description: "data and errors",
+			given:       `{"data":null,"errors":[{"title":"T"}]}`,
+			expect:      []Violation{{Message: "the members data and errors must not coexist in the same document"}},
+		}, {
+			description: "included without data, unknown member",
+			given:       `{"meta":{},"included":[],"foo":1}`,
+			expect: []Violation{
+				{Pointer: "/included", Message: "a document without data must not contain included"},
+				{Pointer: "/foo", Message: `a document must not contain the member "foo"`},
+			},
+		}, {
+			description: "resource object without id, invalid type",
+			given:       `{"data":{"type":1,"attributes":{"title":"A"}}}`,
+			expect: []Violation{
+				{Pointer: "/data/type", Message: "type must be a string"},
+				{Pointer: "/data", Message: "a resource must contain the member id"},
+			},
+		}, {
+			description: "resource object without id in client mode",
+			given:       articleANoIDBody,
+			opts:        []ValidateOption{ValidateClientMode()},
+			expect:      []Violation{},
+		}, {
+			description: "resource object without id, type",
+			given:       `{"data":[{"attributes":{}},{"type":"","id":1}]}`,
+			expect: []Violation{
+				{Pointer: "/data/0", Message: "a resource must contain the member type"},
+				{Pointer: "/data/0", Message: "a resource must contain the member id"},
+				{Pointer: "/data/1/type", Message: "type must not be empty"},
+				{Pointer: "/data/1/id", Message: "id must be a string"},
+			},
+		}, {
+			description: "lid is only valid in 1.1",
+			given:       `{"data":{"type":"articles","lid":"a"}}`,
+			expect: []Violation{
+				{Pointer: "/data/lid", Message: `a resource object must not contain the member "lid"`},
+				{Pointer: "/data", Message: "a resource must contain the member id"},
+			},
+		}, {
+			description: "lid in 1.1",
+			given:       `{"data":{"type":"articles","lid":"a"},"jsonapi":{"version":"1.1"}}`,
+			expect:      []Violation{},
+		}, {
+			description: "invalid fields",
+			given:       `{"data":{"type":"articles","id":"1","attributes":{"id":"1","title":"A","links":{}},"relationships":{"title":{"data":null},"author":{}}}}`,
+			expect: []Violation{
+				{Pointer: "/data/attributes/id", Message: `attributes must not contain a member named "id"`},
+				{Pointer: "/data/attributes/links", Message: `attributes must not contain the member "links"`},
+				{Pointer: "/data/relationships/author", Message: "a relationship object must contain at least one of the members data, links and meta"},
+				{Pointer: "/data/relationships/title", Message: `a resource object must not contain an attribute and a relationship named "title"`},
+			},
+		}, {
+			description: "invalid resource linkage",
+			given:       `{"data":{"type":"articles","id":"1","relationships":{"author":{"data":{"type":"author","id":"1","attributes":{}}},"comments":{"data":["1"]}}}}`,
+			expect: []Violation{
+				{Pointer: "/data/relationships/author/data/attributes", Message: `a resource identifier object must not contain the member "attributes"`},
+				{Pointer: "/data/relationships/comments/data/0", Message: "a resource identifier object must be an object"},
+			},
+		}, {
+			description: "invalid member names",
+			given:       `{"data":{"type":"aut%hor","id":"1","attributes":{"na%me":{"fi%rst":"A"}},"meta":{"foo%":1}}}`,
+			expect: []Violation{
+				{Pointer: "/data/type", Message: `invalid member name "aut%hor"`},
+				{Pointer: "/data/attributes/na%me", Message: `invalid member name "na%me"`},
+				{Pointer: "/data/attributes/na%me/fi%rst", Message: `invalid member name "fi%rst"`},
+				{Pointer: "/data/meta/foo%", Message: `invalid member name "foo%"`},
+			},
+		}, {
+			description: "strict member names",
+			given:       `{"data":{"type":"blog-posts","id":"1","attributes":{"first_name":"A"}}}`,
+			opts:        []ValidateOption{ValidateStrictNameValidation()},
+			expect: []Violation{
+				{Pointer: "/data/type", Message: `invalid member name "blog-posts"`},
+				{Pointer: "/data/attributes/first_name", Message: `invalid member name "first_name"`},
+			},
+		}, {
+			description: "strict member names with relaxed types",
+			given:       `{"data":{"type":"blog-posts","id":"1","attributes":{"first_name":"A"}}}`,
+			opts:        []ValidateOption{ValidateStrictNameValidation(), ValidateRelaxNameValidation(TypeMembers)},
+			expect: []Violation{
+				{Pointer: "/data/attributes/first_name", Message: `invalid member name "first_name"`},
+			},
+		}, {
+			description: "invalid links",
+			given:       `{"data":null,"links":{"self":1,"related":{"meta":{}},"first":{"href":"a","title":"b"}}}`,
+			expect: []Violation{
+				{Pointer: "/links/first/title", Message: `a link object must not contain the member "title"`},
+				{Pointer: "/links/related", Message: "a link object must contain the member href"},
+				{Pointer: "/links/self", Message: "a link must be null, a string or a link object"},
+			},
+		}, {
+			description: "link objects in 1.1",
+			given:       `{"data":null,"links":{"self":{"href":"a","title":"b","hreflang":["en","fr"]}},"jsonapi":{"version":"1.1","ext":["https://example.com/ext"]}}`,
+			expect:      []Violation{},
+		}, {
+			description: "invalid error objects",
+			given:       `{"errors":[{"status":500,"source":{"pointer":"data"},"links":{"self":"a"}},"error"]}`,
+			expect: []Violation{
+				{Pointer: "/errors/0/status", Message: "status must be a string"},
+				{Pointer: "/errors/0/links/self", Message: `error links must not contain the member "self"`},
+				{Pointer: "/errors/0/source/pointer", Message: "pointer must be a JSON pointer"},
+				{Pointer: "/errors/1", Message: "an error object must be an object"},
+			},
+		}, {
+			description: "invalid jsonapi object and meta",
+			given:       `{"meta":[],"jsonapi":{"version":1,"ext":[]}}`,
+			expect: []Violation{
+				{Pointer: "/jsonapi/ext", Message: `the jsonapi object must not contain the member "ext"`},
+				{Pointer: "/jsonapi/version", Message: "version must be a string"},
+				{Pointer: "/meta", Message: "meta must be an object"},
+			},
+		}, {
+			description: "duplicate resources",
+			given:       `{"data":[{"type":"articles","id":"1"},{"type":"articles","id":"1"}]}`,
+			expect: []Violation{
+				{Pointer: "/data/1", Message: "a compound document must not contain more than one resource object for each type and id"},
+			},
+		}, {
+			description: "included resources without full linkage",
+			given:       articleWithIncludeOnlyBody,
+			expect: []Violation{
+				{Pointer: "/included/0", Message: "an included resource must be linked to primary data by a chain of relationships"},
+			},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			violations := Validate([]byte(tc.given), tc.opts...)
+			is.Equal(t, tc.expect, violations)
+		})
+	}
+}
+
+func TestValidateMarshaled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+	}{
+		{
+			description: "resource object",
+			given:       &articleA,
+		}, {
+			description: "compound document",
+			given:       &articleRelatedComments,
+			opts:        []MarshalOption{MarshalInclude(&commentA)},
+		}, {
+			description: "error document",
+			given:       []*Error{&errorsSimpleStruct, &errorsWithLinkObject},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.Equal(t, []Violation{}, Validate(b))
+		})
+	}
+}
+
+func TestViolationString(t *testing.T) {
+	t.Parallel()
+
+	is.Equal(t, "document is not valid json", Violation{Message: "document is not valid json"}.String())
+	is.Equal(t, "/data/id: id must be a string", Violation{Pointer: "/data/id", Message: "id must be a string"}.String())
+}
@@ -0,0 +1,139 @@
+package jsonapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// VersionProfileURI is the URI of the version profile implemented by VersionProfile.
+const VersionProfileURI = "https://pkg.go.dev/github.com/DataDog/jsonapi#VersionProfile"
+
+// CodeVersionConflict indicates that a resource has been modified since the version given by the
+// request, as reported by NewVersionConflict.
+const CodeVersionConflict = "version_conflict"
+
+// versionMember is the name of the member of resource object meta holding its version.
+const versionMember = "version"
+
+// VersionProfile returns a handler of the version profile, which supports optimistic locking by
+// surfacing the version, or revision, of resources held by their field with the given name as the
+// version member of the meta of their resource objects.
+//
+// The profile is identified by VersionProfileURI and defines that the version member is a number or
+// string identifying the state of the resource, which changes whenever the resource is modified.
+// Clients send the version they last retrieved along with updates, which are rejected with a 409
+// (Conflict) error if the resource has been modified since.
+//
+// The field must be an exported field of an integer or string type without jsonapi tag, and may be
+// a field of an embedded struct. Zero versions are omitted. When unmarshaling, a version is stored in
+// the field if it is zero. Otherwise, as when applying a PATCH document to the stored resource with
+// UnmarshalPatch, an error created by NewVersionConflict is returned if the versions differ. The
+// versions are compared before any field of the resource is set, so that the resource is left
+// untouched on conflicts. Resource objects without version leave the field untouched.
+func VersionProfile(field string) *ProfileHandler {
+	return &ProfileHandler{
+		URI: VersionProfileURI,
+		MarshalResource: func(_ context.Context, v any) (map[string]any, error) {
+			fv, ok := versionField(v, field)
+			if !ok || fv.IsZero() {
+				return nil, nil
+			}
+			return map[string]any{versionMember: fv.Interface()}, nil
+		},
+		CheckResource: func(_ context.Context, v any, meta map[string]any) error {
+			fv, version, ok, err := resourceVersion(v, field, meta)
+			if !ok || err != nil {
+				return err
+			}
+			if !fv.IsZero() && fv.Interface() != version.Interface() {
+				return NewVersionConflict(fv.Interface())
+			}
+			return nil
+		},
+		UnmarshalResource: func(_ context.Context, v any, meta map[string]any) error {
+			fv, version, ok, err := resourceVersion(v, field, meta)
+			if ok && err == nil && fv.IsZero() {
+				fv.Set(version)
+			}
+			return err
+		},
+	}
+}
+
+// resourceVersion returns the version field of the resource v with the given name, along with the
+// version given by the version member of meta parsed into its type, or false if either is missing.
+func resourceVersion(v any, field string, meta map[string]any) (reflect.Value, reflect.Value, bool, error) {
+	value, present := meta[versionMember]
+	fv, ok := versionField(v, field)
+	if !present || value == nil || !ok || !fv.CanSet() {
+		return reflect.Value{}, reflect.Value{}, false, nil
+	}
+
+	version := reflect.New(fv.Type()).Elem()
+	if err := setVersion(version, value); err != nil {
+		return reflect.Value{}, reflect.Value{}, false, err
+	}
+	return fv, version, true, nil
+}
+
+// versionField returns the version field of the resource v with the given name.
+func versionField(v any, name string) (reflect.Value, bool) {
+	fv, ok := profileField(v, name)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.String:
+		return fv, true
+	}
+	return reflect.Value{}, false
+}
+
+// setVersion sets the version field fv to the version given by the unmarshaled meta member value.
+// Numbers are parsed from their json.Number into the kind of fv, so that large versions keep their
+// precision.
+func setVersion(fv reflect.Value, value any) error {
+	switch v := value.(type) {
+	case string:
+		if fv.Kind() == reflect.String {
+			fv.SetString(v)
+			return nil
+		}
+	case json.Number:
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(v.String(), 10, 64)
+			if err == nil && !fv.OverflowInt(n) {
+				fv.SetInt(n)
+				return nil
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(v.String(), 10, 64)
+			if err == nil && !fv.OverflowUint(n) {
+				fv.SetUint(n)
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s %v is not a valid %s", versionMember, value, fv.Type())
+}
+
+// NewVersionConflict creates a 409 (Conflict) error object with code CodeVersionConflict, reporting
+// that a resource has been modified since the version given by a request. The current version of
+// the resource is given by the currentVersion member of its meta.
+func NewVersionConflict(currentVersion any) *Error {
+	return &Error{
+		Status: Status(http.StatusConflict),
+		Code:   CodeVersionConflict,
+		Title:  http.StatusText(http.StatusConflict),
+		Detail: "The resource has been modified since the given version.",
+		Source: &ErrorSource{Pointer: "/data/meta/" + versionMember},
+		Meta:   map[string]any{"currentVersion": currentVersion},
+	}
+}
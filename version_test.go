@@ -0,0 +1,101 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// ArticleRevised is an article with a version surfaced by VersionProfile.
+type ArticleRevised struct {
+	ID       string `jsonapi:"primary,articles"`
+	Title    string `jsonapi:"attribute" json:"title"`
+	Revision uint64
+}
+
+func TestVersionProfile(t *testing.T) {
+	t.Parallel()
+
+	profile := VersionProfile("Revision")
+
+	b, err := Marshal(&ArticleRevised{ID: "1", Title: "A", Revision: 3}, MarshalProfiles(profile))
+	is.MustNoError(t, err)
+	is.EqualJSON(t, `{"data":{"id":"1","type":"articles","attributes":{"title":"A"},"meta":{"version":3}},"jsonapi":{"version":"1.1","profile":["https://pkg.go.dev/github.com/DataDog/jsonapi#VersionProfile"]}}`, string(b))
+
+	tests := []struct {
+		description string
+		stored      ArticleRevised
+		given       string
+		expect      ArticleRevised
+		expectError error
+	}{
+		{
+			description: "new resource",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"B"},"meta":{"version":3}}}`,
+			expect:      ArticleRevised{ID: "1", Title: "B", Revision: 3},
+		}, {
+			description: "matching version",
+			stored:      ArticleRevised{ID: "1", Title: "A", Revision: 3},
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"B"},"meta":{"version":3}}}`,
+			expect:      ArticleRevised{ID: "1", Title: "B", Revision: 3},
+		}, {
+			description: "no version",
+			stored:      ArticleRevised{ID: "1", Title: "A", Revision: 3},
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"B"}}}`,
+			expect:      ArticleRevised{ID: "1", Title: "B", Revision: 3},
+		}, {
+			description: "conflicting version",
+			stored:      ArticleRevised{ID: "1", Title: "A", Revision: 4},
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"B"},"meta":{"version":3}}}`,
+			expectError: NewVersionConflict(uint64(4)),
+		}, {
+			description: "conflicting version beyond float64 precision",
+			stored:      ArticleRevised{ID: "1", Title: "A", Revision: 1 << 60},
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"B"},"meta":{"version":1152921504606846977}}}`,
+			expectError: NewVersionConflict(uint64(1 << 60)),
+		}, {
+			description: "version beyond float64 precision",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"B"},"meta":{"version":1152921504606846977}}}`,
+			expect:      ArticleRevised{ID: "1", Title: "B", Revision: 1<<60 + 1},
+		}, {
+			description: "invalid version",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"B"},"meta":{"version":-1}}}`,
+			expectError: &ResourceError{Code: CodeInvalidResource, Type: "articles", ID: "1", Err: fmt.Errorf("version -1 is not a valid uint64")},
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			a := tc.stored
+			err := UnmarshalPatch([]byte(tc.given), &a, UnmarshalProfiles(profile))
+			if tc.expectError != nil {
+				is.EqualError(t, tc.expectError, err)
+				// rejected patches leave the stored resource untouched
+				is.Equal(t, tc.stored, a)
+				return
+			}
+			is.MustNoError(t, err)
+			is.Equal(t, tc.expect, a)
+		})
+	}
+}
+
+func TestNewVersionConflict(t *testing.T) {
+	t.Parallel()
+
+	a := ArticleRevised{ID: "1", Revision: 4}
+	err := UnmarshalPatch([]byte(`{"data":{"id":"1","type":"articles","meta":{"version":3}}}`), &a, UnmarshalProfiles(VersionProfile("Revision")))
+	is.Equal(t, true, HasCode(err, CodeVersionConflict))
+
+	objects := ErrorObjects(err)
+	is.MustEqual(t, 1, len(objects))
+	is.Equal(t, http.StatusConflict, *objects[0].Status)
+	is.Equal(t, "/data/meta/version", objects[0].Source.Pointer)
+	is.Equal(t, map[string]any{"currentVersion": uint64(4)}, objects[0].Meta)
+}
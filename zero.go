@@ -0,0 +1,62 @@
+package jsonapi
+
+import "reflect"
+
+// ZeroAttributes controls whether attributes with a zero value are marshaled or omitted. It is
+// given for all attributes by MarshalZeroAttributes, and for single attributes by the omitzero and
+// keepzero options of the attribute directive, e.g. `jsonapi:"attribute,keepzero"`, which take
+// precedence.
+type ZeroAttributes int
+
+const (
+	// ZeroAttributesDefault omits zero attributes if their json tag has the omitempty option.
+	ZeroAttributesDefault ZeroAttributes = iota
+
+	// ZeroAttributesKeep marshals zero attributes, even if their json tag has the omitempty
+	// option, for clients expecting all attributes to be present.
+	ZeroAttributesKeep
+
+	// ZeroAttributesOmit omits zero attributes, even if their json tag doesn't have the omitempty
+	// option, for minimal payloads.
+	ZeroAttributesOmit
+)
+
+const (
+	// omitZeroOption is the option of the attribute directive omitting the attribute if zero.
+	omitZeroOption = "omitzero"
+
+	// keepZeroOption is the option of the attribute directive marshaling the attribute if zero.
+	keepZeroOption = "keepzero"
+
+	// nullOption is the option of the attribute directive marshaling the pointer attribute as null
+	// if nil, even if zero attributes are omitted otherwise.
+	nullOption = "null"
+)
+
+// MarshalZeroAttributes sets whether attributes with a zero value are marshaled or omitted, for
+// attributes without an omitzero or keepzero option.
+func MarshalZeroAttributes(z ZeroAttributes) MarshalOption {
+	return func(m *Marshaler) {
+		m.zeroAttributes = z
+	}
+}
+
+// omitAttribute returns true if the attribute field f with the given tag is omitted, where
+// omitEmpty is true if its json tag has the omitempty option. Nil pointers with the null option are
+// never omitted, to be marshaled as null.
+func (m *Marshaler) omitAttribute(f reflect.Value, tag *tag, omitEmpty bool) bool {
+	if !f.IsZero() || tag.null && f.Kind() == reflect.Pointer || m.keepZero {
+		return false
+	}
+	z := tag.zero
+	if z == ZeroAttributesDefault {
+		z = m.zeroAttributes
+	}
+	switch z {
+	case ZeroAttributesKeep:
+		return false
+	case ZeroAttributesOmit:
+		return true
+	}
+	return omitEmpty
+}
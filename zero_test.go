@@ -0,0 +1,70 @@
+package jsonapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+// ArticleZero has attributes with and without zero value options.
+type ArticleZero struct {
+	ID      string  `jsonapi:"primary,articles"`
+	Title   string  `jsonapi:"attribute" json:"title,omitempty"`
+	Body    string  `jsonapi:"attribute" json:"body"`
+	Views   int     `jsonapi:"attribute,keepzero" json:"views,omitempty"`
+	Rating  *int    `jsonapi:"attribute,omitzero" json:"rating"`
+	Summary *string `jsonapi:"attribute" json:"summary"`
+	Editor  *string `jsonapi:"attribute,null" json:"editor,omitempty"`
+}
+
+func TestMarshalZeroAttributes(t *testing.T) {
+	t.Parallel()
+
+	editor, empty := "C", ""
+
+	tests := []struct {
+		description string
+		given       any
+		opts        []MarshalOption
+		expect      string
+	}{
+		{
+			description: "default",
+			given:       &ArticleZero{ID: "1"},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"body":"","views":0,"summary":null,"editor":null}}}`,
+		}, {
+			description: "keep zero attributes",
+			given:       &ArticleZero{ID: "1"},
+			opts:        []MarshalOption{MarshalZeroAttributes(ZeroAttributesKeep)},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"","body":"","views":0,"summary":null,"editor":null}}}`,
+		}, {
+			description: "omit zero attributes",
+			given:       &ArticleZero{ID: "1"},
+			opts:        []MarshalOption{MarshalZeroAttributes(ZeroAttributesOmit)},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"views":0,"editor":null}}}`,
+		}, {
+			description: "non-zero attributes",
+			given:       &ArticleZero{ID: "1", Title: "A", Body: "B", Views: 1, Editor: &editor},
+			opts:        []MarshalOption{MarshalZeroAttributes(ZeroAttributesOmit)},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"title":"A","body":"B","views":1,"editor":"C"}}}`,
+		}, {
+			description: "null and empty pointer attributes",
+			given:       &ArticleZero{ID: "1", Editor: &empty},
+			opts:        []MarshalOption{MarshalZeroAttributes(ZeroAttributesOmit)},
+			expect:      `{"data":{"type":"articles","id":"1","attributes":{"views":0,"editor":""}}}`,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			b, err := Marshal(tc.given, tc.opts...)
+			is.MustNoError(t, err)
+			is.EqualJSON(t, tc.expect, string(b))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// aliasStringAttributes sets the string attribute fields of v to strings aliasing the copies of
+// their json values decoded from the given attributes object, as enabled by UnmarshalZeroCopyStrings. It returns the json encoding of the
+// remaining attributes, which must be decoded as usual, or nil if there are none.
+func aliasStringAttributes(data []byte, v any) ([]byte, error) {
+	var attributes map[string]rawValue
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, err
+	}
+
+	// values with custom decoding must be decoded as usual
+	if rv := derefValue(reflect.ValueOf(v)); !hasCustomDecoding(rv.Type()) {
+		aliasStringFields(rv, attributes)
+	}
+
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(attributes)
+}
+
+// aliasStringFields sets the string attribute fields of the struct value rv, including those of
+// embedded structs, to the aliased attributes, removing them from attributes. Fields of embedded
+// struct pointers are left to be decoded as usual, as the pointers may be nil.
+func aliasStringFields(rv reflect.Value, attributes map[string]rawValue) {
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		fv := rv.Field(i)
+		ft := rt.Field(i)
+
+		if isFlattened(ft) && fv.Kind() == reflect.Struct {
+			aliasStringFields(fv, attributes)
+			continue
+		}
+		if fv.Kind() != reflect.String || !fv.CanSet() || hasCustomDecoding(ft.Type) {
+			continue
+		}
+		tag, err := parseJSONAPITag(ft)
+		if err != nil || tag == nil || tag.directive != attribute {
+			continue
+		}
+		name, ok, _ := parseJSONTag(ft)
+		if !ok || name == "" || name == "-" || strings.Contains(ft.Tag.Get("json"), ",string") {
+			continue
+		}
+		value, ok := attributes[name]
+		if !ok || len(value) < 2 || value[0] != '"' {
+			continue
+		}
+
+		// strings with escape sequences or invalid UTF-8 must be decoded
+		s := value[1 : len(value)-1]
+		if bytes.IndexByte(s, '\\') >= 0 || !utf8.Valid(s) {
+			continue
+		}
+
+		fv.SetString(unsafeString(s))
+		delete(attributes, name)
+	}
+}
+
+// hasCustomDecoding returns true if values of type t are decoded by their own UnmarshalJSON or
+// UnmarshalText method.
+func hasCustomDecoding(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return implements(pt, jsonUnmarshalerType) || implements(pt, textUnmarshalerType)
+}
+
+// unsafeString returns a string sharing its memory with b.
+func unsafeString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
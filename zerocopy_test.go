@@ -0,0 +1,94 @@
+package jsonapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/jsonapi/internal/is"
+)
+
+type zeroCopyBase struct {
+	Category string `jsonapi:"attribute" json:"category"`
+}
+
+type zeroCopyArticle struct {
+	zeroCopyBase
+	ID      string   `jsonapi:"primary,articles"`
+	Title   string   `jsonapi:"attribute" json:"title"`
+	Summary *string  `jsonapi:"attribute" json:"summary,omitempty"`
+	Tags    []string `jsonapi:"attribute" json:"tags,omitempty"`
+	Views   int      `jsonapi:"attribute" json:"views,omitempty"`
+}
+
+func TestUnmarshalZeroCopyStrings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		description string
+		given       string
+		expectError bool
+	}{
+		{
+			description: "string attributes",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"A","category":"news"}}}`,
+		}, {
+			description: "escaped and non-string attributes",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":"A \"quoted\" title","summary":"S","tags":["a","b"],"views":3}}}`,
+		}, {
+			description: "case-insensitive attribute names",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"Title":"A"}}}`,
+		}, {
+			description: "null attributes",
+			given:       `{"data":{"id":"1","type":"articles","attributes":null}}`,
+		}, {
+			description: "invalid attribute type",
+			given:       `{"data":{"id":"1","type":"articles","attributes":{"title":1}}}`,
+			expectError: true,
+		}, {
+			description: "invalid attributes",
+			given:       `{"data":{"id":"1","type":"articles","attributes":"A"}}`,
+			expectError: true,
+		},
+	}
+
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.description)
+
+			var expect, actual zeroCopyArticle
+			expectErr := Unmarshal([]byte(tc.given), &expect)
+			err := Unmarshal([]byte(tc.given), &actual, UnmarshalZeroCopyStrings())
+			if tc.expectError {
+				is.Equal(t, true, expectErr != nil)
+				is.EqualError(t, expectErr, err)
+				return
+			}
+			is.MustNoError(t, expectErr)
+			is.MustNoError(t, err)
+			is.Equal(t, expect, actual)
+		})
+	}
+}
+
+func TestUnmarshalZeroCopyStringsAliasing(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`{"data":[{"id":"1","type":"articles","attributes":{"title":"A","summary":"S"}}]}`)
+
+	var articles []*zeroCopyArticle
+	err := Unmarshal(data, &articles, UnmarshalZeroCopyStrings())
+	is.MustNoError(t, err)
+	is.MustEqual(t, 1, len(articles))
+	is.Equal(t, "A", articles[0].Title)
+
+	// the data isn't aliased, so it can be reused once unmarshaled
+	for i := range data {
+		if data[i] == 'A' || data[i] == 'S' {
+			data[i] = 'B'
+		}
+	}
+	is.Equal(t, "A", articles[0].Title)
+	is.Equal(t, "S", *articles[0].Summary)
+}